@@ -1,5 +1,5 @@
 import { describe, it, expect, vi, beforeEach } from 'vitest'
-import { setToken, clearToken, isLoggedIn, get, post, put, del } from './client'
+import { setToken, clearToken, isLoggedIn, get, post, put, del, authFetch } from './client'
 import { mockFetch, createMockResponse } from '@/test/setup'
 
 // ═══════════════════════════════════════════════════════════
@@ -206,20 +206,99 @@ describe('Error Handling', () => {
     })
 })
 
+// ═══════════════════════════════════════════════════════════
+// 令牌刷新测试
+// ═══════════════════════════════════════════════════════════
 
+function createUnauthorizedResponse() {
+  return Promise.resolve({
+    json: async () => ({ success: false, error: 'invalid or expired token' }),
+    text: async () => JSON.stringify({ success: false, error: 'invalid or expired token' }),
+    ok: false,
+    status: 401,
+    statusText: 'Unauthorized',
+    headers: {
+      get: () => 'application/json',
+    },
+  })
+}
 
+describe('Token Refresh', () => {
+  let stored: Record<string, string>
 
+  beforeEach(() => {
+    mockFetch.mockReset()
+    stored = { token: 'old-token', refreshToken: 'old-refresh' }
+    vi.mocked(localStorage.getItem).mockImplementation((key: string) => stored[key] ?? null)
+    vi.mocked(localStorage.setItem).mockImplementation((key: string, value: string) => {
+      stored[key] = value
+    })
+    vi.mocked(localStorage.removeItem).mockImplementation((key: string) => {
+      delete stored[key]
+    })
+  })
 
+  it('should keep the refresh token returned by login', () => {
+    setToken('access', 'refresh')
+    expect(stored).toEqual({ token: 'access', refreshToken: 'refresh' })
 
+    clearToken()
+    expect(stored).toEqual({})
+  })
 
+  it('should refresh and retry once on 401', async () => {
+    mockFetch
+      .mockReturnValueOnce(createUnauthorizedResponse())
+      .mockReturnValueOnce(createMockResponse({ token: 'new-token', refreshToken: 'new-refresh' }))
+      .mockReturnValueOnce(createMockResponse({ id: 1 }))
 
+    const response = await get('/user')
 
+    expect(response.success).toBe(true)
+    expect(mockFetch).toHaveBeenCalledTimes(3)
+    expect(mockFetch.mock.calls[1][0]).toBe('/api/auth/refresh')
+    expect(JSON.parse(mockFetch.mock.calls[1][1].body)).toEqual({ refreshToken: 'old-refresh' })
+    expect(mockFetch.mock.calls[2][1].headers.get('Authorization')).toBe('Bearer new-token')
+    expect(stored).toEqual({ token: 'new-token', refreshToken: 'new-refresh' })
+  })
 
+  it('should share one refresh between concurrent 401 responses', async () => {
+    mockFetch.mockImplementation((url: string, options: RequestInit) => {
+      if (url === '/api/auth/refresh') {
+        return createMockResponse({ token: 'new-token', refreshToken: 'new-refresh' })
+      }
+      const authorization = new Headers(options.headers).get('Authorization')
+      return authorization === 'Bearer new-token' ? createMockResponse({ ok: true }) : createUnauthorizedResponse()
+    })
 
+    const responses = await Promise.all([get('/a'), get('/b'), get('/c')])
 
+    expect(responses.every((r) => r.success)).toBe(true)
+    expect(mockFetch.mock.calls.filter(([url]) => url === '/api/auth/refresh')).toHaveLength(1)
+  })
 
+  it('should clear tokens when the refresh token is rejected', async () => {
+    mockFetch
+      .mockReturnValueOnce(createUnauthorizedResponse())
+      .mockReturnValueOnce(createUnauthorizedResponse())
 
+    const response = await get('/user')
 
+    expect(response.success).toBe(false)
+    expect(mockFetch).toHaveBeenCalledTimes(2)
+    expect(stored).toEqual({})
+  })
 
+  it('should not refresh without a refresh token or for login failures', async () => {
+    mockFetch.mockReturnValue(createUnauthorizedResponse())
 
+    await post('/auth/login', { username: 'test', password: 'wrong' })
+    expect(mockFetch).toHaveBeenCalledTimes(1)
 
+    // 会话机制上线前登录的用户没有刷新令牌
+    delete stored.refreshToken
+    const response = await authFetch('/api/user')
+    expect(response.status).toBe(401)
+    expect(mockFetch).toHaveBeenCalledTimes(2)
+  })
+})
@@ -7,14 +7,23 @@ export function getToken(): string | null {
   return localStorage.getItem('token')
 }
 
-// 设置token
-export function setToken(token: string): void {
+// 获取存储的刷新令牌
+export function getRefreshToken(): string | null {
+  return localStorage.getItem('refreshToken')
+}
+
+// 设置token（登录/刷新时同时保存轮换后的刷新令牌）
+export function setToken(token: string, refreshToken?: string): void {
   localStorage.setItem('token', token)
+  if (refreshToken) {
+    localStorage.setItem('refreshToken', refreshToken)
+  }
 }
 
 // 清除token
 export function clearToken(): void {
   localStorage.removeItem('token')
+  localStorage.removeItem('refreshToken')
 }
 
 // 检查是否已登录
@@ -22,22 +31,85 @@ export function isLoggedIn(): boolean {
   return !!getToken()
 }
 
+// 登录/注册/刷新本身返回401时不触发刷新
+const NO_REFRESH_ENDPOINTS = /\/auth\/(login|register|refresh)$/
+
+// 进行中的刷新（并发请求同时遇到401时共用一次刷新，避免旧刷新令牌被重复使用导致会话注销）
+let refreshing: Promise<boolean> | null = null
+
+// 用刷新令牌换取新的访问令牌，刷新令牌失效时清除本地登录状态
+export function refreshAccessToken(): Promise<boolean> {
+  if (!refreshing) {
+    refreshing = doRefresh().finally(() => {
+      refreshing = null
+    })
+  }
+  return refreshing
+}
+
+async function doRefresh(): Promise<boolean> {
+  const refreshToken = getRefreshToken()
+  if (!refreshToken) {
+    return false
+  }
+
+  let response: Response
+  try {
+    response = await fetch(`${API_BASE}/auth/refresh`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refreshToken }),
+    })
+  } catch (error) {
+    // 网络错误时保留令牌，下次请求再试
+    console.error('Token refresh failed:', error)
+    return false
+  }
+
+  try {
+    const data = response.ok ? await response.json() : null
+    if (data?.success && data.data?.token) {
+      setToken(data.data.token, data.data.refreshToken)
+      return true
+    }
+  } catch {
+    // 无法解析的响应按刷新失败处理
+  }
+  clearToken()
+  return false
+}
+
+// 带认证的 fetch：附加访问令牌，返回401时用刷新令牌换取新令牌并重试一次
+export async function authFetch(url: string, options: RequestInit = {}): Promise<Response> {
+  const send = () => {
+    const headers = new Headers(options.headers)
+    const token = getToken()
+    if (token) {
+      headers.set('Authorization', `Bearer ${token}`)
+    }
+    return fetch(url, { ...options, headers })
+  }
+
+  const response = await send()
+  if (response.status !== 401 || NO_REFRESH_ENDPOINTS.test(url) || !getRefreshToken()) {
+    return response
+  }
+  if (!(await refreshAccessToken())) {
+    return response
+  }
+  return send()
+}
+
 // 通用请求函数
 async function request<T>(
   endpoint: string,
   options: RequestInit = {}
 ): Promise<APIResponse<T>> {
-  const token = getToken()
-  
   const headers = new Headers(options.headers)
   headers.set('Content-Type', 'application/json')
-  
-  if (token) {
-    headers.set('Authorization', `Bearer ${token}`)
-  }
 
   try {
-    const response = await fetch(`${API_BASE}${endpoint}`, {
+    const response = await authFetch(`${API_BASE}${endpoint}`, {
       ...options,
       headers,
     })
@@ -29,7 +29,7 @@ export const useAuthStore = defineStore('auth', () => {
       const response = await post<AuthResponse>('/auth/register', data)
       
       if (response.success && response.data) {
-        setToken(response.data.token)
+        setToken(response.data.token, response.data.refreshToken)
         user.value = response.data.user
         return true
       } else {
@@ -54,7 +54,7 @@ export const useAuthStore = defineStore('auth', () => {
       const response = await post<AuthResponse>('/auth/login', data)
       
       if (response.success && response.data) {
-        setToken(response.data.token)
+        setToken(response.data.token, response.data.refreshToken)
         user.value = response.data.user
         return true
       } else {
@@ -70,8 +70,11 @@ export const useAuthStore = defineStore('auth', () => {
     }
   }
 
-  // 登出
+  // 登出（同时注销服务端会话，使刷新令牌失效）
   function logout() {
+    if (isLoggedIn()) {
+      void post('/auth/logout')
+    }
     clearToken()
     user.value = null
   }
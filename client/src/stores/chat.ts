@@ -141,31 +141,43 @@ export const useChatStore = defineStore('chat', () => {
     }
   }
 
-  // 设置在线状态（不再需要characterId，使用玩家信息）
-  async function setOnline(zoneId?: string) {
+  // 实时推送连接（建立连接即上线，断开即离线，在线状态由服务端维护）
+  let eventSource: EventSource | null = null
+
+  async function connectStream() {
+    if (eventSource) {
+      return
+    }
     try {
-      await post('/chat/online', { zoneId })
+      // 浏览器的 EventSource 不能设置请求头，先用访问令牌换取一次性票据
+      const response = await post<{ ticket: string }>('/stream/ticket', {})
+      if (!response.success || !response.data || eventSource) {
+        return
+      }
+      const source = new EventSource(`/api/stream/events?ticket=${encodeURIComponent(response.data.ticket)}`)
+      source.addEventListener('chat', (e) => {
+        addMessage(JSON.parse((e as MessageEvent).data).data as ChatMessage)
+      })
+      source.addEventListener('presence', () => {
+        fetchOnlineUsers()
+      })
+      // 票据只能使用一次，浏览器自动重连会失败，断开后由调用方重新连接
+      source.onerror = () => {
+        disconnectStream()
+      }
+      eventSource = source
     } catch (e) {
       // 忽略错误
     }
   }
 
-  // 设置离线状态
-  async function setOffline() {
-    try {
-      await post('/chat/offline', {})
-    } catch (e) {
-      // 忽略错误
-    }
+  function disconnectStream() {
+    eventSource?.close()
+    eventSource = null
   }
 
-  // 心跳
-  async function heartbeat() {
-    try {
-      await post('/chat/heartbeat', {})
-    } catch (e) {
-      // 忽略错误
-    }
+  function isStreamConnected() {
+    return eventSource !== null
   }
 
   // 切换频道
@@ -175,6 +187,10 @@ export const useChatStore = defineStore('chat', () => {
 
   // 添加消息 (用于实时推送)
   function addMessage(msg: ChatMessage) {
+    // 自己发送的消息已在发送成功时加入
+    if (messages.value.some((m) => m.id === msg.id)) {
+      return
+    }
     messages.value.push(msg)
     
     // 如果是私聊，记录发送者
@@ -292,9 +308,9 @@ export const useChatStore = defineStore('chat', () => {
     fetchOnlineUsers,
     blockPlayer,
     unblockPlayer,
-    setOnline,
-    setOffline,
-    heartbeat,
+    connectStream,
+    disconnectStream,
+    isStreamConnected,
     setChannel,
     addMessage,
     handleInput,
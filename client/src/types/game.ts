@@ -19,6 +19,9 @@ export interface User {
 
 export interface AuthResponse {
   token: string
+  refreshToken?: string      // 刷新令牌（每次刷新后轮换）
+  expiresIn?: number         // 访问令牌有效期(秒)
+  refreshExpiresAt?: string
   user: User
 }
 
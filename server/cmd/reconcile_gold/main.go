@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"text-wow/internal/database"
+	"text-wow/internal/game"
+)
+
+// 金币对账工具：比较每个用户的金币余额与金币流水合计
+// 用法: go run ./cmd/reconcile_gold [-fix]
+//
+//	-fix  为差异补记 reconcile 流水（不修改余额）
+func main() {
+	fix := flag.Bool("fix", false, "record reconcile ledger entries for discrepancies")
+	flag.Parse()
+
+	// 获取当前工作目录，然后找到 server 目录
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	// 如果从 cmd/reconcile_gold 运行，需要回到 server 目录
+	serverDir := wd
+	if filepath.Base(wd) == "reconcile_gold" {
+		serverDir = filepath.Join(wd, "..", "..")
+	} else if filepath.Base(filepath.Dir(wd)) == "cmd" {
+		serverDir = filepath.Join(wd, "..")
+	}
+	if err := os.Chdir(serverDir); err != nil {
+		log.Fatalf("Failed to change directory: %v", err)
+	}
+
+	if err := database.Init(); err != nil {
+		log.Fatalf("Failed to init database: %v", err)
+	}
+	defer database.Close()
+
+	discrepancies, err := game.NewEconomyManager().Reconcile(*fix)
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	fmt.Println("金币对账结果：")
+	fmt.Println("═══════════════════════════════════════════════════════════")
+	if len(discrepancies) == 0 {
+		fmt.Println("✅ 所有用户的金币余额与流水一致")
+		return
+	}
+
+	fmt.Printf("%-8s %-20s %-12s %-12s %-12s\n", "用户ID", "用户名", "余额", "流水合计", "差额")
+	fmt.Println("───────────────────────────────────────────────────────────")
+	for _, d := range discrepancies {
+		fmt.Printf("%-8d %-20s %-12d %-12d %+d\n", d.UserID, d.Username, d.Gold, d.LedgerBalance, d.Difference)
+	}
+	fmt.Println("───────────────────────────────────────────────────────────")
+	if *fix {
+		fmt.Printf("⚠️  发现 %d 个差异，已补记 reconcile 流水\n", len(discrepancies))
+	} else {
+		fmt.Printf("⚠️  发现 %d 个差异，使用 -fix 补记修正流水\n", len(discrepancies))
+		database.Close()
+		os.Exit(1)
+	}
+}
@@ -28,11 +28,30 @@ CREATE TABLE IF NOT EXISTS users (
     play_time INTEGER DEFAULT 0,              -- 游戏时长(秒)
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     last_login_at DATETIME,
-    status INTEGER DEFAULT 1
+    status INTEGER DEFAULT 1,
+    role VARCHAR(16) DEFAULT 'player'         -- player/moderator/gamemaster/admin
 );
 
 CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 
+-- 登录会话表 (每次登录一个会话，刷新令牌只保存SHA-256哈希，每次刷新轮换)
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id VARCHAR(32) PRIMARY KEY,               -- 会话ID (写入访问令牌的 sid)
+    user_id INTEGER NOT NULL,
+    refresh_token_hash VARCHAR(64) NOT NULL UNIQUE, -- 当前刷新令牌哈希
+    previous_token_hash VARCHAR(64),          -- 上一个刷新令牌哈希 (被重复使用说明令牌泄露)
+    user_agent VARCHAR(256),
+    ip_address VARCHAR(64),
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME NOT NULL,           -- 最近一次刷新时间
+    expires_at DATETIME NOT NULL,             -- 刷新令牌过期时间
+    revoked_at DATETIME,                      -- 注销时间 (NULL=有效)
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_user ON auth_sessions(user_id, revoked_at);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_previous ON auth_sessions(previous_token_hash);
+
 -- 角色表 (每个用户最多5个角色组成小队)
 CREATE TABLE IF NOT EXISTS characters (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -215,7 +234,7 @@ CREATE INDEX IF NOT EXISTS idx_effects_type ON effects(type);
 -- 配置版本表 - 用于配置版本管理和热更新
 CREATE TABLE IF NOT EXISTS config_versions (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    config_type VARCHAR(32) NOT NULL,  -- monster/skill/item/economy/zone
+    config_type VARCHAR(32) NOT NULL,  -- monster/skill/item/economy/zone/stamina
     version INTEGER NOT NULL,
     config_data TEXT NOT NULL,         -- JSON格式的配置数据
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -253,7 +272,9 @@ CREATE TABLE IF NOT EXISTS items (
     mp_bonus INTEGER DEFAULT 0,
     crit_rate REAL DEFAULT 0,
     effect_type VARCHAR(32),
-    effect_value INTEGER
+    effect_value INTEGER,
+    effect_stat VARCHAR(32),              -- buff类效果影响的属性
+    effect_duration INTEGER               -- buff类效果持续回合数
 );
 
 CREATE INDEX IF NOT EXISTS idx_items_type ON items(type);
@@ -328,6 +349,7 @@ CREATE TABLE IF NOT EXISTS zones (
     -- 探索度解锁系统
     unlock_zone_id VARCHAR(32),              -- 需要探索的前置地图ID（NULL表示初始地图）
     required_exploration INTEGER DEFAULT 0,   -- 解锁所需探索度（0表示无需探索度解锁）
+    stamina_cost INTEGER,                     -- 每场战斗消耗体力（NULL表示使用服务器默认值）
     FOREIGN KEY (parent_zone_id) REFERENCES zones(id),
     FOREIGN KEY (unlock_zone_id) REFERENCES zones(id)
 );
@@ -355,6 +377,7 @@ CREATE TABLE IF NOT EXISTS monsters (
     description TEXT,
     level INTEGER NOT NULL,
     type VARCHAR(16) DEFAULT 'normal',
+    family VARCHAR(32),                       -- 怪物族群 (wolf/boar/kobold...，用于图鉴加成)
     hp INTEGER NOT NULL,
     mp INTEGER DEFAULT 0,
     physical_attack INTEGER NOT NULL,
@@ -919,12 +942,13 @@ CREATE TABLE IF NOT EXISTS codex (
     target_id VARCHAR(32) NOT NULL,
     name VARCHAR(64) NOT NULL,
     description TEXT,
-    unlock_condition TEXT,
-    bonus_type VARCHAR(32),
-    bonus_value REAL
+    unlock_condition TEXT,                 -- 加成生效所需的解锁次数 (如 '10'，为空则首次解锁即生效)
+    bonus_type VARCHAR(32),                -- damage_vs_monster/damage_vs_family/damage_all
+    bonus_value REAL                       -- 百分比加成 (5 = 5%)
 );
 
 CREATE INDEX IF NOT EXISTS idx_codex_category ON codex(category);
+CREATE INDEX IF NOT EXISTS idx_codex_target ON codex(category, target_id);
 
 -- 玩家图鉴表
 CREATE TABLE IF NOT EXISTS user_codex (
@@ -1018,10 +1042,10 @@ CREATE INDEX IF NOT EXISTS idx_user_honor_wins ON user_honor(pvp_wins DESC);
 CREATE INDEX IF NOT EXISTS idx_user_honor_faction ON user_honor(faction);
 
 -- 全服公告表
--- 记录并推送PVP战斗结果到全服
+-- 记录并通过聊天系统频道推送全服事件（区域占领、连胜、首杀、传说掉落、管理员公告）
 CREATE TABLE IF NOT EXISTS server_announcements (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    type VARCHAR(32) NOT NULL,                -- pvp_victory/zone_captured/kill_streak/zone_contested/faction_dominant
+    type VARCHAR(32) NOT NULL,                -- zone_captured/kill_streak/first_kill/legendary_drop/admin
     content TEXT NOT NULL,                    -- 公告内容
     zone_id VARCHAR(32),                      -- 相关区域
     winner_user_id INTEGER,                   -- 胜利者ID
@@ -1039,6 +1063,15 @@ CREATE TABLE IF NOT EXISTS server_announcements (
 CREATE INDEX IF NOT EXISTS idx_announcements_time ON server_announcements(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_announcements_type ON server_announcements(type);
 
+-- 全服首杀记录 (每个首领只公告一次)
+CREATE TABLE IF NOT EXISTS server_first_kills (
+    monster_id VARCHAR(32) PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    killed_at DATETIME NOT NULL,
+    FOREIGN KEY (monster_id) REFERENCES monsters(id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
 -- 荣誉商店配置表
 -- 使用荣誉值兑换独特奖励
 CREATE TABLE IF NOT EXISTS honor_shop (
@@ -1058,6 +1091,24 @@ CREATE TABLE IF NOT EXISTS honor_shop (
 
 CREATE INDEX IF NOT EXISTS idx_honor_shop_rank ON honor_shop(rank_required);
 
+-- 荣誉商店购买记录表
+-- 用于每周限购统计和购买历史查询
+CREATE TABLE IF NOT EXISTS honor_shop_purchases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    shop_item_id VARCHAR(32) NOT NULL,        -- 商品ID
+    character_id INTEGER,                     -- 接收消耗品的角色
+    honor_cost INTEGER NOT NULL,              -- 实际花费荣誉
+    equipment_instance_id INTEGER,            -- 生成的装备实例
+    purchased_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (shop_item_id) REFERENCES honor_shop(id),
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE SET NULL,
+    FOREIGN KEY (equipment_instance_id) REFERENCES equipment_instance(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_honor_purchases_user ON honor_shop_purchases(user_id, purchased_at DESC);
+
 -- ═══════════════════════════════════════════════════════════
 -- 体力系统
 -- ═══════════════════════════════════════════════════════════
@@ -1073,6 +1124,335 @@ CREATE TABLE IF NOT EXISTS user_stamina (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
+-- ═══════════════════════════════════════════════════════════
+-- 拍卖行
+-- ═══════════════════════════════════════════════════════════
+
+-- 拍卖行上架表
+-- 上架期间装备由拍卖行托管（status='active'），不能穿戴、强化或重复上架
+CREATE TABLE IF NOT EXISTS auction_listings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    seller_id INTEGER NOT NULL,
+    equipment_id INTEGER NOT NULL,         -- 托管的装备实例
+    item_id VARCHAR(32) NOT NULL,          -- 基础物品ID
+    price INTEGER NOT NULL,                -- 一口价（0=竞拍无一口价）
+    starting_bid INTEGER,                  -- 起拍价（NULL=一口价上架，不接受竞价）
+    min_increment INTEGER DEFAULT 0,       -- 最低加价
+    current_bid INTEGER,                   -- 当前最高出价
+    current_bidder_id INTEGER,             -- 当前最高出价者（出价金币由拍卖行托管）
+    bid_count INTEGER DEFAULT 0,
+    listing_fee INTEGER DEFAULT 0,         -- 已支付的上架费（不退还）
+    transaction_fee INTEGER DEFAULT 0,     -- 成交手续费
+    status VARCHAR(16) DEFAULT 'active',   -- active/sold/expired/cancelled
+    buyer_id INTEGER,
+    sold_price INTEGER,                    -- 成交价（一口价或最高出价）
+    listed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    closed_at DATETIME,                    -- 成交/过期/取消时间
+    FOREIGN KEY (seller_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (equipment_id) REFERENCES equipment_instance(id) ON DELETE CASCADE,
+    FOREIGN KEY (buyer_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_auction_active_equipment ON auction_listings(equipment_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_auction_status_expires ON auction_listings(status, expires_at);
+CREATE INDEX IF NOT EXISTS idx_auction_seller ON auction_listings(seller_id, listed_at DESC);
+
+-- 拍卖出价记录表
+-- 被超过的出价立即退还金币
+CREATE TABLE IF NOT EXISTS auction_bids (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    listing_id INTEGER NOT NULL,
+    bidder_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL,
+    status VARCHAR(16) DEFAULT 'active',   -- active/outbid/won/refunded
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (listing_id) REFERENCES auction_listings(id) ON DELETE CASCADE,
+    FOREIGN KEY (bidder_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_auction_bids_listing ON auction_bids(listing_id, amount DESC);
+CREATE INDEX IF NOT EXISTS idx_auction_bids_bidder ON auction_bids(bidder_id, created_at DESC);
+
+-- ═══════════════════════════════════════════════════════════
+-- 玩家直接交易
+-- ═══════════════════════════════════════════════════════════
+
+-- 交易窗口表
+-- 双方放入装备与金币，双方都确认后原子完成；任何变更都会重置双方确认
+CREATE TABLE IF NOT EXISTS trade_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    initiator_id INTEGER NOT NULL,          -- 发起方
+    partner_id INTEGER NOT NULL,            -- 交易对象
+    status VARCHAR(16) DEFAULT 'open',      -- open/completed/cancelled/expired
+    initiator_gold INTEGER DEFAULT 0,       -- 发起方放入的金币
+    partner_gold INTEGER DEFAULT 0,         -- 交易对象放入的金币
+    initiator_confirmed INTEGER DEFAULT 0,
+    partner_confirmed INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,           -- 无操作超时时间（每次变更顺延）
+    closed_at DATETIME,
+    FOREIGN KEY (initiator_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (partner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_trade_initiator ON trade_sessions(initiator_id, status);
+CREATE INDEX IF NOT EXISTS idx_trade_partner ON trade_sessions(partner_id, status);
+CREATE INDEX IF NOT EXISTS idx_trade_status_expires ON trade_sessions(status, expires_at);
+
+-- 交易窗口物品表
+CREATE TABLE IF NOT EXISTS trade_session_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    trade_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,               -- 放入物品的一方
+    equipment_id INTEGER NOT NULL,
+    added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (trade_id) REFERENCES trade_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (equipment_id) REFERENCES equipment_instance(id) ON DELETE CASCADE,
+    UNIQUE(trade_id, equipment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trade_items_trade ON trade_session_items(trade_id);
+
+-- ═══════════════════════════════════════════════════════════
+-- 邮箱系统
+-- ═══════════════════════════════════════════════════════════
+
+-- 邮件表
+-- 附件（装备）在领取前由邮箱托管；到期未领取的玩家邮件退回发件人
+CREATE TABLE IF NOT EXISTS mails (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    recipient_id INTEGER NOT NULL,
+    sender_id INTEGER,                      -- NULL=系统邮件
+    sender_name VARCHAR(32) NOT NULL,
+    mail_type VARCHAR(16) DEFAULT 'player', -- player/system/auction/returned/cod_payment
+    subject VARCHAR(64) NOT NULL,
+    body TEXT DEFAULT '',
+    gold INTEGER DEFAULT 0,                 -- 附带金币
+    cod_amount INTEGER DEFAULT 0,           -- 货到付款金额（领取时由收件人支付给发件人）
+    status VARCHAR(16) DEFAULT 'active',    -- active/returned/expired/deleted
+    is_read INTEGER DEFAULT 0,
+    returned_from_id INTEGER,               -- 退信对应的原邮件
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    read_at DATETIME,
+    claimed_at DATETIME,                    -- 附件领取时间
+    expires_at DATETIME NOT NULL,
+    closed_at DATETIME,
+    FOREIGN KEY (recipient_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_mails_recipient ON mails(recipient_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_mails_status_expires ON mails(status, expires_at);
+
+-- 邮件附件表
+CREATE TABLE IF NOT EXISTS mail_attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    mail_id INTEGER NOT NULL,
+    equipment_id INTEGER NOT NULL,
+    FOREIGN KEY (mail_id) REFERENCES mails(id) ON DELETE CASCADE,
+    FOREIGN KEY (equipment_id) REFERENCES equipment_instance(id) ON DELETE CASCADE,
+    UNIQUE(mail_id, equipment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_attachments_equipment ON mail_attachments(equipment_id);
+
+-- ═══════════════════════════════════════════════════════════
+-- 金币流水
+-- ═══════════════════════════════════════════════════════════
+
+-- 金币流水表（只追加，不允许修改或删除）
+-- 每次金币变动都与变动本身在同一事务中写入，余额 = 该用户所有 delta 之和
+CREATE TABLE IF NOT EXISTS gold_ledger (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    delta INTEGER NOT NULL,                 -- 变动金额（正数增加，负数扣除）
+    balance_after INTEGER NOT NULL,         -- 变动后余额
+    reason VARCHAR(32) NOT NULL,            -- 原因代码（auction_purchase/mail_claim/trade_receive...）
+    ref_type VARCHAR(32),                   -- 关联对象类型（auction_listing/mail/trade/battle...）
+    ref_id VARCHAR(64),                     -- 关联对象ID
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)   -- 不级联删除，保留审计记录
+);
+
+CREATE INDEX IF NOT EXISTS idx_gold_ledger_user ON gold_ledger(user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_gold_ledger_reason ON gold_ledger(reason, created_at);
+
+CREATE TRIGGER IF NOT EXISTS gold_ledger_no_update
+BEFORE UPDATE ON gold_ledger
+BEGIN
+    SELECT RAISE(ABORT, 'gold_ledger is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS gold_ledger_no_delete
+BEFORE DELETE ON gold_ledger
+BEGIN
+    SELECT RAISE(ABORT, 'gold_ledger is append-only');
+END;
+
+-- 期初余额：流水上线前已有金币的用户补记一条 opening_balance，使余额 = 流水合计
+-- 只处理尚无任何流水的用户，重复执行不会重复补记
+INSERT INTO gold_ledger (user_id, delta, balance_after, reason)
+SELECT u.id, u.gold, u.gold, 'opening_balance'
+FROM users u
+WHERE u.gold != 0
+  AND NOT EXISTS (SELECT 1 FROM gold_ledger l WHERE l.user_id = u.id);
+
+-- ═══════════════════════════════════════════════════════════
+-- NPC商人
+-- ═══════════════════════════════════════════════════════════
+
+-- 商人配置表（绑定区域，只能与当前区域的商人交易）
+CREATE TABLE IF NOT EXISTS vendors (
+    id VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    title VARCHAR(64),                        -- 称号（如"杂货商"）
+    zone_id VARCHAR(32) NOT NULL,
+    description TEXT,
+    restock_interval_minutes INTEGER DEFAULT 60, -- 限量商品补货间隔
+    last_restock_at DATETIME,
+    is_active INTEGER DEFAULT 1,
+    FOREIGN KEY (zone_id) REFERENCES zones(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vendors_zone ON vendors(zone_id);
+
+-- 商人出售列表
+CREATE TABLE IF NOT EXISTS vendor_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    vendor_id VARCHAR(32) NOT NULL,
+    item_id VARCHAR(32) NOT NULL,
+    price INTEGER,                            -- 售价 (NULL=使用物品的buy_price)
+    max_stock INTEGER,                        -- 补货上限 (NULL=无限)
+    current_stock INTEGER,                    -- 当前库存
+    sort_order INTEGER DEFAULT 0,
+    FOREIGN KEY (vendor_id) REFERENCES vendors(id) ON DELETE CASCADE,
+    FOREIGN KEY (item_id) REFERENCES items(id),
+    UNIQUE(vendor_id, item_id)
+);
+
+-- 回购记录（卖给商人的物品在一段时间内可原价买回）
+-- 卖出的装备在回购期内保持托管，过期后销毁
+CREATE TABLE IF NOT EXISTS vendor_buyback (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    character_id INTEGER,                     -- 物品原所在角色（装备为NULL）
+    item_id VARCHAR(32) NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 1,
+    equipment_id INTEGER,                     -- 卖出的装备实例
+    price INTEGER NOT NULL,                   -- 卖出所得（即回购价）
+    status VARCHAR(16) NOT NULL DEFAULT 'available', -- available/bought_back/expired
+    sold_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    closed_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE SET NULL,
+    FOREIGN KEY (item_id) REFERENCES items(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vendor_buyback_user ON vendor_buyback(user_id, status, sold_at DESC);
+CREATE INDEX IF NOT EXISTS idx_vendor_buyback_equipment ON vendor_buyback(equipment_id);
+CREATE INDEX IF NOT EXISTS idx_vendor_buyback_expiry ON vendor_buyback(status, expires_at);
+
+-- ═══════════════════════════════════════════════════════════
+-- 专业技能与制造
+-- ═══════════════════════════════════════════════════════════
+
+-- 专业配置表
+CREATE TABLE IF NOT EXISTS professions (
+    id VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(32) NOT NULL,
+    type VARCHAR(16) NOT NULL DEFAULT 'crafting', -- crafting/gathering
+    description TEXT,
+    max_skill INTEGER NOT NULL DEFAULT 150
+);
+
+-- 配方配置表
+-- 技能点 < skill_up_yellow 时必定提升，之后线性降低，达到 skill_up_gray 后不再提升
+CREATE TABLE IF NOT EXISTS recipes (
+    id VARCHAR(32) PRIMARY KEY,
+    profession_id VARCHAR(32) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    description TEXT,
+    required_skill INTEGER NOT NULL DEFAULT 1,
+    skill_up_yellow INTEGER NOT NULL,
+    skill_up_gray INTEGER NOT NULL,
+    result_item_id VARCHAR(32) NOT NULL,
+    result_quantity INTEGER NOT NULL DEFAULT 1,
+    result_quality VARCHAR(16) DEFAULT 'common', -- 装备成品的基础品质
+    proc_chance REAL DEFAULT 0,               -- 触发概率：装备品质提升一档/消耗品额外产出一个
+    craft_seconds INTEGER NOT NULL DEFAULT 5, -- 每件制造耗时
+    FOREIGN KEY (profession_id) REFERENCES professions(id),
+    FOREIGN KEY (result_item_id) REFERENCES items(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recipes_profession ON recipes(profession_id, required_skill);
+
+-- 配方材料表
+CREATE TABLE IF NOT EXISTS recipe_materials (
+    recipe_id VARCHAR(32) NOT NULL,
+    item_id VARCHAR(32) NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (recipe_id, item_id),
+    FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE,
+    FOREIGN KEY (item_id) REFERENCES items(id)
+);
+
+-- 角色专业进度
+CREATE TABLE IF NOT EXISTS character_professions (
+    character_id INTEGER NOT NULL,
+    profession_id VARCHAR(32) NOT NULL,
+    skill_level INTEGER NOT NULL DEFAULT 1,
+    learned_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (character_id, profession_id),
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
+    FOREIGN KEY (profession_id) REFERENCES professions(id)
+);
+
+-- 制造队列（加入队列时扣除全部材料，取消时返还未完成部分）
+CREATE TABLE IF NOT EXISTS crafting_queue (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    character_id INTEGER NOT NULL,
+    recipe_id VARCHAR(32) NOT NULL,
+    quantity INTEGER NOT NULL,
+    crafted INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'queued', -- queued/active/completed/cancelled
+    next_craft_at DATETIME,                   -- 当前这一件的完成时间（仅active）
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    finished_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
+    FOREIGN KEY (recipe_id) REFERENCES recipes(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_crafting_queue_character ON crafting_queue(character_id, status, id);
+CREATE INDEX IF NOT EXISTS idx_crafting_queue_due ON crafting_queue(status, next_craft_at);
+
+-- 区域采集表（战斗胜利后与休息结束时，按角色的采集专业掷骰）
+CREATE TABLE IF NOT EXISTS zone_gathering_nodes (
+    id VARCHAR(32) PRIMARY KEY,
+    zone_id VARCHAR(32) NOT NULL,
+    profession_id VARCHAR(32) NOT NULL,
+    node_type VARCHAR(16) NOT NULL,           -- herb/ore/leather（leather只能在击杀后剥取）
+    name VARCHAR(64) NOT NULL,
+    item_id VARCHAR(32) NOT NULL,
+    required_skill INTEGER NOT NULL DEFAULT 1,
+    skill_up_yellow INTEGER NOT NULL,
+    skill_up_gray INTEGER NOT NULL,
+    chance REAL NOT NULL DEFAULT 0.2,         -- 未探索时的基础发现概率，完全探索后翻倍
+    min_quantity INTEGER NOT NULL DEFAULT 1,
+    max_quantity INTEGER NOT NULL DEFAULT 1,
+    required_exploration INTEGER DEFAULT 0,   -- 该区域探索度达到后才会出现
+    FOREIGN KEY (zone_id) REFERENCES zones(id) ON DELETE CASCADE,
+    FOREIGN KEY (profession_id) REFERENCES professions(id),
+    FOREIGN KEY (item_id) REFERENCES items(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gathering_nodes_zone ON zone_gathering_nodes(zone_id, profession_id);
+
 -- ═══════════════════════════════════════════════════════════
 -- 作战策略系统
 -- ═══════════════════════════════════════════════════════════
@@ -1173,7 +1553,7 @@ CREATE INDEX IF NOT EXISTS idx_threat_log_enemy ON battle_threat_log(battle_id,
 -- 聊天消息表
 CREATE TABLE IF NOT EXISTS chat_messages (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    channel VARCHAR(16) NOT NULL,          -- world/zone/trade/lfg/whisper/system/battlefield
+    channel VARCHAR(16) NOT NULL,          -- world/zone/trade/lfg/whisper/guild/system/battlefield
     faction VARCHAR(16),                   -- alliance/horde (公共频道用)
     zone_id VARCHAR(32),                   -- 区域ID (zone频道用)
     sender_id INTEGER NOT NULL,
@@ -1181,6 +1561,8 @@ CREATE TABLE IF NOT EXISTS chat_messages (
     sender_class VARCHAR(32),
     receiver_id INTEGER,                   -- 私聊目标用户ID
     content TEXT NOT NULL,
+    message_type VARCHAR(16) DEFAULT 'say', -- say/emote/roll
+    guild_id INTEGER,                      -- 公会ID (guild频道用)
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (sender_id) REFERENCES users(id),
     FOREIGN KEY (receiver_id) REFERENCES users(id)
@@ -1204,6 +1586,79 @@ CREATE TABLE IF NOT EXISTS chat_blocks (
 
 CREATE INDEX IF NOT EXISTS idx_blocks_user ON chat_blocks(user_id);
 
+-- 可加入的聊天频道成员（/join lfg）
+CREATE TABLE IF NOT EXISTS chat_channel_members (
+    user_id INTEGER NOT NULL,
+    channel VARCHAR(16) NOT NULL,
+    joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, channel),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_members ON chat_channel_members(channel);
+
+-- 聊天消息中的物品/技能/成就链接（发送时的快照）
+CREATE TABLE IF NOT EXISTS chat_message_links (
+    message_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,             -- 在消息中的顺序
+    token VARCHAR(64) NOT NULL,            -- 原文中的链接标记，如 [item:123]
+    link_type VARCHAR(16) NOT NULL,        -- item/skill/achievement
+    target_id VARCHAR(32) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    quality VARCHAR(16),
+    snapshot TEXT NOT NULL,                -- JSON 快照
+    PRIMARY KEY (message_id, position),
+    FOREIGN KEY (message_id) REFERENCES chat_messages(id) ON DELETE CASCADE
+);
+
+-- 聊天禁言/封禁记录
+CREATE TABLE IF NOT EXISTS chat_sanctions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    sanction_type VARCHAR(16) NOT NULL,    -- mute(禁止公共频道发言)/ban(禁止一切聊天)
+    channel VARCHAR(16),                   -- 仅禁言指定频道，NULL表示全部公共频道
+    reason TEXT,
+    issued_by INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME,                   -- NULL表示永久
+    revoked_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (issued_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_sanctions_user ON chat_sanctions(user_id, expires_at);
+
+-- 聊天举报
+CREATE TABLE IF NOT EXISTS chat_reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id INTEGER NOT NULL,
+    reporter_id INTEGER NOT NULL,
+    reported_user_id INTEGER NOT NULL,
+    content TEXT NOT NULL,                 -- 举报时的消息内容
+    reason VARCHAR(200),
+    status VARCHAR(16) DEFAULT 'pending',  -- pending/resolved/dismissed
+    handled_by INTEGER,
+    handled_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(message_id, reporter_id),
+    FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (reported_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_reports_status ON chat_reports(status, created_at);
+
+-- 聊天令牌桶限流状态（按用户和频道，重启后保留）
+CREATE TABLE IF NOT EXISTS chat_rate_limits (
+    user_id INTEGER NOT NULL,
+    channel VARCHAR(16) NOT NULL,
+    tokens REAL NOT NULL,                  -- 剩余消息额度
+    updated_at DATETIME NOT NULL,          -- 上次计算额度的时间
+    last_content TEXT,                     -- 上一条消息（归一化后，用于重复检测）
+    last_sent_at DATETIME,
+    PRIMARY KEY (user_id, channel),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
 -- 在线状态表
 CREATE TABLE IF NOT EXISTS user_online_status (
     user_id INTEGER PRIMARY KEY,
@@ -1220,3 +1675,190 @@ CREATE TABLE IF NOT EXISTS user_online_status (
 CREATE INDEX IF NOT EXISTS idx_online_faction ON user_online_status(faction, is_online);
 CREATE INDEX IF NOT EXISTS idx_online_zone ON user_online_status(zone_id, is_online);
 
+-- ═══════════════════════════════════════════════════════════
+-- 公会系统
+-- ═══════════════════════════════════════════════════════════
+
+-- 公会表
+CREATE TABLE IF NOT EXISTS guilds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(24) UNIQUE NOT NULL,
+    faction VARCHAR(16) NOT NULL,          -- 只能邀请同阵营玩家
+    leader_id INTEGER NOT NULL,
+    motd VARCHAR(200),                     -- 每日公告
+    motd_updated_at DATETIME,
+    bank_gold INTEGER DEFAULT 0,           -- 公会银行金币
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (leader_id) REFERENCES users(id)
+);
+
+-- 公会会阶 (rank_index 0 为会长，数字越大权限越低)
+CREATE TABLE IF NOT EXISTS guild_ranks (
+    guild_id INTEGER NOT NULL,
+    rank_index INTEGER NOT NULL,
+    name VARCHAR(16) NOT NULL,
+    permissions TEXT NOT NULL DEFAULT '[]', -- 权限列表 (JSON数组)
+    gold_withdraw_limit INTEGER DEFAULT 0,  -- 每24小时可提取金币，-1表示不限
+    item_withdraw_limit INTEGER DEFAULT 0,  -- 每24小时可提取装备件数，-1表示不限
+    PRIMARY KEY (guild_id, rank_index),
+    FOREIGN KEY (guild_id) REFERENCES guilds(id) ON DELETE CASCADE
+);
+
+-- 公会成员 (每个玩家最多加入一个公会)
+CREATE TABLE IF NOT EXISTS guild_members (
+    user_id INTEGER PRIMARY KEY,
+    guild_id INTEGER NOT NULL,
+    rank_index INTEGER NOT NULL,
+    joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (guild_id) REFERENCES guilds(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_guild_members_guild ON guild_members(guild_id, rank_index);
+
+-- 公会邀请
+CREATE TABLE IF NOT EXISTS guild_invites (
+    guild_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    invited_by INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    PRIMARY KEY (guild_id, user_id),
+    FOREIGN KEY (guild_id) REFERENCES guilds(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_guild_invites_user ON guild_invites(user_id, expires_at);
+
+-- 公会银行装备 (存放期间装备处于托管状态)
+CREATE TABLE IF NOT EXISTS guild_bank_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    guild_id INTEGER NOT NULL,
+    equipment_id INTEGER NOT NULL UNIQUE,
+    deposited_by INTEGER NOT NULL,
+    deposited_at DATETIME NOT NULL,
+    FOREIGN KEY (guild_id) REFERENCES guilds(id) ON DELETE CASCADE,
+    FOREIGN KEY (equipment_id) REFERENCES equipment_instance(id) ON DELETE CASCADE,
+    FOREIGN KEY (deposited_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_guild_bank_items_guild ON guild_bank_items(guild_id);
+
+-- 公会活动日志 (同时用于统计每日提取额度)
+CREATE TABLE IF NOT EXISTS guild_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    guild_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,              -- 操作者
+    action VARCHAR(32) NOT NULL,           -- create/invite/join/leave/kick/rank_change/...
+    target_name VARCHAR(32),               -- 目标玩家/会阶/装备名称
+    amount INTEGER DEFAULT 0,              -- 金币数量或装备件数
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (guild_id) REFERENCES guilds(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_guild_logs_guild ON guild_logs(guild_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_guild_logs_withdraw ON guild_logs(guild_id, user_id, action, created_at);
+
+-- ═══════════════════════════════════════════════════════════
+-- 排行榜系统
+-- ═══════════════════════════════════════════════════════════
+
+-- 排行榜赛季 (ended_at 为 NULL 的是当前赛季)
+CREATE TABLE IF NOT EXISTS leaderboard_seasons (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(32) NOT NULL,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME,
+    last_snapshot_at DATETIME              -- 最近一次快照时间
+);
+
+-- 排行榜快照 (定时整体重建，rank 为全服排名，筛选排名按 rank 顺序计算)
+CREATE TABLE IF NOT EXISTS leaderboard_entries (
+    season_id INTEGER NOT NULL,
+    board VARCHAR(32) NOT NULL,            -- level/kills/abyss_floor/honor/gold_earned/highest_hit/fastest_boss_kill
+    rank INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    character_id INTEGER NOT NULL DEFAULT 0, -- 角色榜为角色ID，玩家榜为0
+    name VARCHAR(32) NOT NULL,             -- 角色名或玩家名
+    faction VARCHAR(16) NOT NULL,
+    class_id VARCHAR(32) NOT NULL,         -- 玩家榜取队伍首位角色的职业
+    level INTEGER DEFAULT 0,
+    score INTEGER NOT NULL,
+    PRIMARY KEY (season_id, board, rank),
+    FOREIGN KEY (season_id) REFERENCES leaderboard_seasons(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_user ON leaderboard_entries(season_id, board, user_id, rank);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_faction ON leaderboard_entries(season_id, board, faction, rank);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_class ON leaderboard_entries(season_id, board, class_id, rank);
+
+-- 赛季基线 (累计类榜单的赛季成绩 = 当前累计值 - 赛季开始时的累计值)
+CREATE TABLE IF NOT EXISTS leaderboard_baselines (
+    season_id INTEGER NOT NULL,
+    board VARCHAR(32) NOT NULL,
+    user_id INTEGER NOT NULL,
+    score INTEGER NOT NULL,
+    PRIMARY KEY (season_id, board, user_id),
+    FOREIGN KEY (season_id) REFERENCES leaderboard_seasons(id) ON DELETE CASCADE
+);
+
+-- ═══════════════════════════════════════════════════════════
+-- GM管理
+-- ═══════════════════════════════════════════════════════════
+
+-- 账号封禁 (生效期间禁止登录，封禁时注销所有登录会话)
+CREATE TABLE IF NOT EXISTS account_bans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    reason TEXT,
+    issued_by INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME,                   -- NULL表示永久
+    revoked_at DATETIME,                   -- 提前解封时间
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (issued_by) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_bans_user ON account_bans(user_id, revoked_at);
+
+-- GM操作审计日志 (只追加)
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id INTEGER NOT NULL,
+    action VARCHAR(32) NOT NULL,           -- grant_gold/grant_item/teleport/reset_session/stop_battle/kill_battle/ban/unban/set_role/reload_config/start_season/announce/bootstrap_admin
+    target_user_id INTEGER,
+    details TEXT,                          -- 操作参数摘要
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (actor_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_target ON admin_audit_log(target_user_id, id);
+
+-- ═══════════════════════════════════════════════════════════
+-- 登录防护
+-- ═══════════════════════════════════════════════════════════
+
+-- 登录尝试审计日志 (同时作为按IP/用户名滑动窗口计数的数据源)
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(64) NOT NULL,         -- 归一化(小写)后的用户名，不要求账号存在
+    user_id INTEGER,                       -- 用户名对应的账号 (不存在时为NULL)
+    ip_address VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(256),
+    result VARCHAR(16) NOT NULL,           -- success/failure/locked/denied/pending
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_username ON login_attempts(username, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts(ip_address, created_at);
+
+-- 登录锁定状态 (连续锁定时长按指数增长)
+CREATE TABLE IF NOT EXISTS login_lockouts (
+    scope VARCHAR(16) NOT NULL,            -- ip/username
+    lock_key VARCHAR(64) NOT NULL,
+    level INTEGER NOT NULL,                -- 连续锁定次数，锁定时长 = 基础时长 × 2^(level-1)
+    locked_until DATETIME NOT NULL,
+    PRIMARY KEY (scope, lock_key)
+);
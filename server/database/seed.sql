@@ -543,6 +543,9 @@ INSERT OR REPLACE INTO zones (id, name, description, min_level, max_level, facti
 ('winterspring', '冬泉谷', '永恒的雪域，蓝龙军团的领地。', 52, 60, NULL, 1.5, 1.5, 'burning_steppes', 600),
 ('silithus', '希利苏斯', '沙漠中的虫巢，其拉虫人的威胁。', 52, 60, NULL, 1.5, 1.5, 'burning_steppes', 600);
 
+-- 争夺区域每场战斗消耗更多体力（仅在服务器启用体力系统时生效，其余区域使用默认消耗）
+UPDATE zones SET stamina_cost = 2 WHERE faction IS NULL;
+
 -- ═══════════════════════════════════════════════════════════
 -- 怪物数据
 -- ═══════════════════════════════════════════════════════════
@@ -1071,6 +1074,16 @@ INSERT OR REPLACE INTO items (id, name, description, type, subtype, quality, sta
 ('minor_mana_potion', '初级法力药水', '恢复8点法力值。', 'consumable', 'potion', 'common', 1, 20, 1, 3, 'heal_mp', 8),
 ('mana_potion', '法力药水', '恢复16点法力值。', 'consumable', 'potion', 'uncommon', 1, 20, 2, 8, 'heal_mp', 16);
 
+-- 消耗品 - 药剂 (战斗中施加增益，持续若干回合)
+INSERT OR REPLACE INTO items (id, name, description, type, subtype, quality, stackable, max_stack, sell_price, buy_price, effect_type, effect_value, effect_stat, effect_duration) VALUES
+('elixir_of_might', '力量药剂', '10回合内攻击力提高10%。', 'consumable', 'elixir', 'uncommon', 1, 10, 3, 12, 'buff', 10, 'attack', 10),
+('elixir_of_fortitude', '坚韧药剂', '10回合内受到的伤害降低10%。', 'consumable', 'elixir', 'uncommon', 1, 10, 3, 12, 'buff', -10, 'damage_taken', 10);
+
+-- 消耗品 - 食物 (休息时使用，加快恢复速度)
+INSERT OR REPLACE INTO items (id, name, description, type, subtype, quality, stackable, max_stack, sell_price, buy_price, effect_type, effect_value) VALUES
+('tough_jerky', '硬肉干', '休息时食用，恢复速度提高50%。', 'consumable', 'food', 'common', 1, 20, 1, 2, 'rest_speed', 50),
+('spiced_bread', '香料面包', '休息时食用，恢复速度提高100%。', 'consumable', 'food', 'uncommon', 1, 20, 2, 6, 'rest_speed', 100);
+
 -- 装备 - 武器 (攻击加成1~10)
 INSERT OR REPLACE INTO items (id, name, description, type, subtype, quality, level_required, slot, sell_price, attack, strength) VALUES
 ('worn_sword', '破旧的剑', '一把破旧的铁剑。', 'equipment', 'weapon', 'common', 1, 'main_hand', 2, 1, 0),
@@ -1090,7 +1103,25 @@ INSERT OR REPLACE INTO items (id, name, description, type, subtype, quality, sta
 ('wolf_pelt', '狼皮', '可以出售给商人。', 'material', 'leather', 'common', 1, 99, 2),
 ('linen_cloth', '亚麻布', '基础的布料。', 'material', 'cloth', 'common', 1, 99, 1),
 ('copper_ore', '铜矿石', '基础的矿石。', 'material', 'ore', 'common', 1, 99, 2),
-('kobold_candle', '狗头人蜡烛', '你不许拿走蜡烛！', 'material', 'junk', 'common', 1, 99, 1);
+('kobold_candle', '狗头人蜡烛', '你不许拿走蜡烛！', 'material', 'junk', 'common', 1, 99, 1),
+('copper_bar', '铜锭', '熔炼铜矿石得到的金属锭。', 'material', 'bar', 'common', 1, 99, 3),
+('peacebloom', '宁神花', '常见的草药，炼金术的基础材料。', 'material', 'herb', 'common', 1, 99, 1),
+('silverleaf', '银叶草', '常见的草药，炼金术的基础材料。', 'material', 'herb', 'common', 1, 99, 1),
+('strange_dust', '奇异之尘', '分解装备得到的魔法粉尘。', 'material', 'dust', 'common', 1, 99, 2),
+('briarthorn', '石南草', '生长在荒野中的带刺草药。', 'material', 'herb', 'common', 1, 99, 3),
+('tin_ore', '锡矿石', '较为少见的矿石。', 'material', 'ore', 'common', 1, 99, 4),
+('light_leather', '轻皮', '从野兽身上剥下的柔软皮革。', 'material', 'leather', 'common', 1, 99, 3);
+
+-- 材料 - 商人出售的制造辅料
+INSERT OR REPLACE INTO items (id, name, description, type, subtype, quality, stackable, max_stack, sell_price, buy_price) VALUES
+('empty_vial', '空瓶', '用于装盛药水的玻璃瓶。', 'material', 'reagent', 'common', 1, 99, 0, 1);
+
+-- 材料 - 附魔产出的强化材料 (subtype 对应装备强化的材料类型)
+INSERT OR REPLACE INTO items (id, name, description, type, subtype, quality, stackable, max_stack, sell_price) VALUES
+('minor_catalyst', '次级催化剂', '强化装备时提升已有词缀数值。', 'material', 'catalyst', 'uncommon', 1, 20, 5),
+('lesser_magic_essence', '次级魔法精华', '强化装备时保证出现指定词缀。', 'material', 'essence', 'uncommon', 1, 20, 8),
+('reforging_powder', '重铸粉末', '强化装备时重铸或添加词缀。', 'material', 'base', 'uncommon', 1, 20, 6),
+('rune_of_warding', '守护符文', '强化装备时锁定一条词缀。', 'material', 'protection', 'rare', 1, 20, 12);
 
 -- ═══════════════════════════════════════════════════════════
 -- 怪物掉落表
@@ -1121,6 +1152,196 @@ INSERT OR REPLACE INTO monster_drops (monster_id, item_id, drop_rate, min_quanti
 ('hogger', 'defias_leather_vest', 0.15, 1, 1),
 ('hogger', 'healing_potion', 0.5, 1, 2);
 
+-- ═══════════════════════════════════════════════════════════
+-- 怪物族群 (图鉴族群加成使用)
+-- ═══════════════════════════════════════════════════════════
+
+UPDATE monsters SET family = 'wolf' WHERE id LIKE '%wolf%' OR id IN ('prowler', 'darkhound', 'vile_fang');
+UPDATE monsters SET family = 'boar' WHERE id LIKE '%boar%';
+UPDATE monsters SET family = 'kobold' WHERE id LIKE 'kobold%';
+UPDATE monsters SET family = 'murloc' WHERE id LIKE 'murloc%';
+UPDATE monsters SET family = 'defias' WHERE id LIKE 'defias%';
+UPDATE monsters SET family = 'undead' WHERE id LIKE 'skelet%' OR id IN ('rotting_dead', 'ghoul', 'cursed_undead', 'necrotic_shade', 'abomination', 'stitches');
+UPDATE monsters SET family = 'quillboar' WHERE id LIKE 'bristleback%' OR id LIKE 'razormane%';
+UPDATE monsters SET family = 'worgen' WHERE id LIKE '%worgen%';
+
+-- ═══════════════════════════════════════════════════════════
+-- 图鉴数据
+-- ═══════════════════════════════════════════════════════════
+-- unlock_condition: 加成生效所需的解锁次数 (击杀/获得次数)
+-- bonus_type: damage_vs_monster(对该怪物) / damage_vs_family(对同族群怪物) / damage_all(对所有怪物)
+-- bonus_value: 百分比加成 (5 = 5%)
+
+INSERT OR REPLACE INTO codex (id, category, target_id, name, description, unlock_condition, bonus_type, bonus_value) VALUES
+-- 怪物图鉴
+('codex_wolf', 'monster', 'wolf', '森林狼', '艾尔文森林中成群出没的掠食者。', '10', 'damage_vs_family', 2),
+('codex_young_boar', 'monster', 'young_boar', '小野猪', '脾气暴躁的小野猪。', '10', 'damage_vs_family', 2),
+('codex_kobold_worker', 'monster', 'kobold_worker', '狗头人矿工', '在矿洞中挖掘的狗头人。', '10', 'damage_vs_family', 2),
+('codex_murloc', 'monster', 'murloc', '鱼人', '栖息在水边的两栖生物。', '10', 'damage_vs_family', 2),
+('codex_defias_thug', 'monster', 'defias_thug', '迪菲亚暴徒', '迪菲亚兄弟会的底层成员。', '10', 'damage_vs_family', 2),
+('codex_skeleton_warrior', 'monster', 'skeleton_warrior', '骷髅战士', '被亡灵魔法唤醒的士兵。', '10', 'damage_vs_family', 3),
+('codex_bristleback_quillboar', 'monster', 'bristleback_quillboar', '刺背野猪人', '贫瘠之地的野猪人部族。', '10', 'damage_vs_family', 2),
+('codex_worgen', 'monster', 'worgen', '狼人', '暮色森林中游荡的狼人。', '10', 'damage_vs_family', 3),
+-- 首领图鉴
+('codex_hogger', 'boss', 'hogger', '霍格', '豺狼人首领，艾尔文森林的噩梦。', '3', 'damage_vs_monster', 5),
+('codex_stitches', 'boss', 'stitches', '缝合怪', '暮色森林的可怖造物。', '3', 'damage_vs_monster', 5),
+-- 物品图鉴
+('codex_wolf_pelt', 'item', 'wolf_pelt', '狼皮', '猎人们最常见的战利品。', '20', 'damage_all', 1),
+('codex_kobold_candle', 'item', 'kobold_candle', '狗头人蜡烛', '狗头人视之如命。', '20', 'damage_all', 1),
+('codex_outlaw_sabre', 'item', 'outlaw_sabre', '逃犯军刀', '从霍格身上缴获的武器。', NULL, NULL, NULL);
+
+-- ═══════════════════════════════════════════════════════════
+-- 荣誉商店
+-- ═══════════════════════════════════════════════════════════
+-- rank_required: 需求军衔 (0-14，每周结算)
+-- weekly_limit / stock: NULL 表示不限
+
+-- PVP装备
+INSERT OR REPLACE INTO items (id, name, description, type, subtype, quality, level_required, slot, sell_price, attack, strength) VALUES
+('pvp_knight_lieutenant_sword', '骑士中尉的长剑', '联盟军官的制式佩剑。', 'equipment', 'weapon', 'rare', 20, 'main_hand', 50, 9, 4),
+('pvp_blood_guard_axe', '血卫士的战斧', '部落军官的制式战斧。', 'equipment', 'weapon', 'rare', 20, 'main_hand', 50, 9, 4);
+
+INSERT OR REPLACE INTO items (id, name, description, type, subtype, quality, level_required, slot, sell_price, defense, stamina) VALUES
+('pvp_soldier_tabard_armor', '士兵的战甲', '前线士兵的标准护甲。', 'equipment', 'armor', 'uncommon', 15, 'chest', 20, 5, 3);
+
+INSERT OR REPLACE INTO honor_shop (id, name, description, item_type, item_id, honor_cost, rank_required, faction, weekly_limit, stock, is_active) VALUES
+('honor_healing_potion', '强效治疗药水', '战场补给，每周限购。', 'consumable', 'greater_healing_potion', 15, 0, NULL, 10, NULL, 1),
+('honor_mana_potion', '法力药水', '战场补给，每周限购。', 'consumable', 'mana_potion', 10, 0, NULL, 10, NULL, 1),
+('honor_soldier_armor', '士兵的战甲', '达到下士/步兵军衔后可兑换。', 'equipment', 'pvp_soldier_tabard_armor', 120, 2, NULL, NULL, NULL, 1),
+('honor_alliance_sword', '骑士中尉的长剑', '联盟骑士中尉的荣耀。', 'equipment', 'pvp_knight_lieutenant_sword', 400, 7, 'alliance', 1, NULL, 1),
+('honor_horde_axe', '血卫士的战斧', '部落血卫士的荣耀。', 'equipment', 'pvp_blood_guard_axe', 400, 7, 'horde', 1, NULL, 1);
+
+-- ═══════════════════════════════════════════════════════════
+-- NPC商人 (限量商品按补货间隔恢复库存)
+-- ═══════════════════════════════════════════════════════════
+
+INSERT OR REPLACE INTO vendors (id, name, title, zone_id, description, restock_interval_minutes, is_active) VALUES
+('vendor_elwynn_goldshire', '布里尔·史密斯', '杂货商', 'elwynn', '闪金镇旅店旁的杂货摊。', 60, 1),
+('vendor_elwynn_smith', '阿尔古斯·铁锤', '武器商', 'elwynn', '闪金镇铁匠铺的老铁匠。', 120, 1),
+('vendor_westfall_sentinel', '萨丁农场补给官', '补给商', 'westfall', '哨兵岭的军需补给。', 60, 1),
+('vendor_durotar_razor', '乌尔克', '杂货商', 'durotar', '剃刀岭的兽人商贩。', 60, 1),
+('vendor_durotar_smith', '卡格尔', '武器商', 'durotar', '剃刀岭的兽人铁匠。', 120, 1);
+
+INSERT OR REPLACE INTO vendor_items (vendor_id, item_id, price, max_stock, current_stock, sort_order) VALUES
+('vendor_elwynn_goldshire', 'minor_healing_potion', NULL, NULL, NULL, 1),
+('vendor_elwynn_goldshire', 'minor_mana_potion', NULL, NULL, NULL, 2),
+('vendor_elwynn_goldshire', 'healing_potion', NULL, 10, 10, 3),
+('vendor_elwynn_goldshire', 'linen_cloth', 4, NULL, NULL, 4),
+('vendor_elwynn_goldshire', 'tough_jerky', NULL, NULL, NULL, 5),
+('vendor_elwynn_goldshire', 'empty_vial', NULL, NULL, NULL, 6),
+('vendor_elwynn_smith', 'worn_sword', 8, NULL, NULL, 1),
+('vendor_elwynn_smith', 'worn_leather_vest', 6, NULL, NULL, 2),
+('vendor_elwynn_smith', 'militia_sword', 25, 3, 3, 3),
+('vendor_elwynn_smith', 'militia_chain_vest', 20, 3, 3, 4),
+('vendor_westfall_sentinel', 'healing_potion', NULL, NULL, NULL, 1),
+('vendor_westfall_sentinel', 'mana_potion', NULL, NULL, NULL, 2),
+('vendor_westfall_sentinel', 'greater_healing_potion', NULL, 5, 5, 3),
+('vendor_westfall_sentinel', 'spiced_bread', NULL, NULL, NULL, 4),
+('vendor_westfall_sentinel', 'elixir_of_might', NULL, 5, 5, 5),
+('vendor_westfall_sentinel', 'elixir_of_fortitude', NULL, 5, 5, 6),
+('vendor_durotar_razor', 'minor_healing_potion', NULL, NULL, NULL, 1),
+('vendor_durotar_razor', 'minor_mana_potion', NULL, NULL, NULL, 2),
+('vendor_durotar_razor', 'healing_potion', NULL, 10, 10, 3),
+('vendor_durotar_razor', 'tough_jerky', NULL, NULL, NULL, 4),
+('vendor_durotar_razor', 'empty_vial', NULL, NULL, NULL, 5),
+('vendor_durotar_smith', 'worn_sword', 8, NULL, NULL, 1),
+('vendor_durotar_smith', 'worn_leather_vest', 6, NULL, NULL, 2),
+('vendor_durotar_smith', 'militia_sword', 25, 3, 3, 3);
+
+-- ═══════════════════════════════════════════════════════════
+-- 专业技能与配方
+-- ═══════════════════════════════════════════════════════════
+
+INSERT OR REPLACE INTO professions (id, name, type, description, max_skill) VALUES
+('blacksmithing', '锻造', 'crafting', '将金属锭打造成武器和护甲。', 150),
+('alchemy', '炼金术', 'crafting', '用草药调配药水和药剂。', 150),
+('enchanting', '附魔', 'crafting', '将魔法粉尘制成装备强化材料。', 150),
+('herbalism', '草药学', 'gathering', '在野外采集草药。', 150),
+('mining', '采矿', 'gathering', '在野外开采矿石。', 150),
+('skinning', '剥皮', 'gathering', '从击败的野兽身上剥取皮革。', 150);
+
+-- 配方：技能点低于黄色阈值时必定提升，达到灰色阈值后不再提升
+INSERT OR REPLACE INTO recipes (id, profession_id, name, description, required_skill, skill_up_yellow, skill_up_gray, result_item_id, result_quantity, result_quality, proc_chance, craft_seconds) VALUES
+-- 锻造
+('bs_copper_bar', 'blacksmithing', '熔炼铜锭', '将铜矿石熔炼成铜锭。', 1, 25, 40, 'copper_bar', 1, 'common', 0, 3),
+('bs_worn_sword', 'blacksmithing', '破旧的剑', '打造一把简单的铜剑。', 1, 20, 35, 'worn_sword', 1, 'common', 0.10, 5),
+('bs_militia_sword', 'blacksmithing', '民兵之剑', '打造民兵的制式武器。', 25, 45, 65, 'militia_sword', 1, 'common', 0.10, 8),
+('bs_militia_chain_vest', 'blacksmithing', '民兵锁甲', '打造民兵的制式护甲。', 40, 60, 80, 'militia_chain_vest', 1, 'common', 0.10, 8),
+('bs_outlaw_sabre', 'blacksmithing', '逃犯军刀', '打造一把附有魔法的军刀。', 75, 95, 115, 'outlaw_sabre', 1, 'uncommon', 0.05, 12),
+-- 炼金术
+('alc_minor_healing_potion', 'alchemy', '初级治疗药水', '调配初级治疗药水。', 1, 30, 55, 'minor_healing_potion', 1, 'common', 0.20, 3),
+('alc_minor_mana_potion', 'alchemy', '初级法力药水', '调配初级法力药水。', 5, 35, 60, 'minor_mana_potion', 1, 'common', 0.20, 3),
+('alc_healing_potion', 'alchemy', '治疗药水', '调配治疗药水。', 40, 65, 85, 'healing_potion', 1, 'common', 0.15, 5),
+('alc_elixir_of_might', 'alchemy', '力量药剂', '调配提升攻击力的药剂。', 55, 80, 100, 'elixir_of_might', 1, 'common', 0.15, 6),
+('alc_elixir_of_fortitude', 'alchemy', '坚韧药剂', '调配降低所受伤害的药剂。', 60, 85, 105, 'elixir_of_fortitude', 1, 'common', 0.15, 6),
+-- 附魔
+('ench_minor_catalyst', 'enchanting', '次级催化剂', '将奇异之尘凝聚为催化剂。', 1, 30, 50, 'minor_catalyst', 1, 'common', 0.10, 4),
+('ench_lesser_magic_essence', 'enchanting', '次级魔法精华', '提炼魔法精华。', 30, 55, 75, 'lesser_magic_essence', 1, 'common', 0.10, 6),
+('ench_reforging_powder', 'enchanting', '重铸粉末', '研磨重铸粉末。', 45, 70, 90, 'reforging_powder', 1, 'common', 0.10, 6),
+('ench_rune_of_warding', 'enchanting', '守护符文', '刻制守护符文。', 60, 85, 105, 'rune_of_warding', 1, 'common', 0.05, 10);
+
+INSERT OR REPLACE INTO recipe_materials (recipe_id, item_id, quantity) VALUES
+('bs_copper_bar', 'copper_ore', 2),
+('bs_worn_sword', 'copper_bar', 3),
+('bs_militia_sword', 'copper_bar', 6),
+('bs_militia_sword', 'linen_cloth', 2),
+('bs_militia_chain_vest', 'copper_bar', 8),
+('bs_militia_chain_vest', 'wolf_pelt', 2),
+('bs_outlaw_sabre', 'copper_bar', 12),
+('bs_outlaw_sabre', 'strange_dust', 2),
+('alc_minor_healing_potion', 'peacebloom', 1),
+('alc_minor_healing_potion', 'empty_vial', 1),
+('alc_minor_mana_potion', 'silverleaf', 1),
+('alc_minor_mana_potion', 'empty_vial', 1),
+('alc_healing_potion', 'peacebloom', 2),
+('alc_healing_potion', 'silverleaf', 1),
+('alc_healing_potion', 'empty_vial', 1),
+('alc_elixir_of_might', 'silverleaf', 3),
+('alc_elixir_of_might', 'empty_vial', 1),
+('alc_elixir_of_fortitude', 'peacebloom', 3),
+('alc_elixir_of_fortitude', 'empty_vial', 1),
+('ench_minor_catalyst', 'strange_dust', 2),
+('ench_lesser_magic_essence', 'strange_dust', 4),
+('ench_lesser_magic_essence', 'copper_bar', 1),
+('ench_reforging_powder', 'strange_dust', 3),
+('ench_reforging_powder', 'linen_cloth', 2),
+('ench_rune_of_warding', 'strange_dust', 6),
+('ench_rune_of_warding', 'copper_bar', 2);
+
+-- 区域采集表：探索度越高发现概率越大，部分节点需要一定探索度才会出现
+INSERT OR REPLACE INTO zone_gathering_nodes (id, zone_id, profession_id, node_type, name, item_id, required_skill, skill_up_yellow, skill_up_gray, chance, min_quantity, max_quantity, required_exploration) VALUES
+-- 联盟初始地图
+('elwynn_peacebloom', 'elwynn', 'herbalism', 'herb', '宁神花丛', 'peacebloom', 1, 25, 50, 0.25, 1, 3, 0),
+('elwynn_silverleaf', 'elwynn', 'herbalism', 'herb', '银叶草丛', 'silverleaf', 1, 25, 50, 0.15, 1, 2, 20),
+('elwynn_copper', 'elwynn', 'mining', 'ore', '铜矿脉', 'copper_ore', 1, 25, 50, 0.2, 1, 3, 0),
+('elwynn_wolf_pelt', 'elwynn', 'skinning', 'leather', '野兽毛皮', 'wolf_pelt', 1, 25, 50, 0.3, 1, 2, 0),
+('dun_morogh_peacebloom', 'dun_morogh', 'herbalism', 'herb', '宁神花丛', 'peacebloom', 1, 25, 50, 0.2, 1, 2, 0),
+('dun_morogh_copper', 'dun_morogh', 'mining', 'ore', '铜矿脉', 'copper_ore', 1, 25, 50, 0.3, 1, 3, 0),
+('dun_morogh_wolf_pelt', 'dun_morogh', 'skinning', 'leather', '野兽毛皮', 'wolf_pelt', 1, 25, 50, 0.3, 1, 2, 0),
+('teldrassil_peacebloom', 'teldrassil', 'herbalism', 'herb', '宁神花丛', 'peacebloom', 1, 25, 50, 0.3, 1, 3, 0),
+('teldrassil_silverleaf', 'teldrassil', 'herbalism', 'herb', '银叶草丛', 'silverleaf', 1, 25, 50, 0.2, 1, 2, 20),
+('teldrassil_wolf_pelt', 'teldrassil', 'skinning', 'leather', '野兽毛皮', 'wolf_pelt', 1, 25, 50, 0.3, 1, 2, 0),
+-- 部落初始地图
+('durotar_peacebloom', 'durotar', 'herbalism', 'herb', '宁神花丛', 'peacebloom', 1, 25, 50, 0.2, 1, 2, 0),
+('durotar_copper', 'durotar', 'mining', 'ore', '铜矿脉', 'copper_ore', 1, 25, 50, 0.3, 1, 3, 0),
+('durotar_wolf_pelt', 'durotar', 'skinning', 'leather', '野兽毛皮', 'wolf_pelt', 1, 25, 50, 0.3, 1, 2, 0),
+('mulgore_peacebloom', 'mulgore', 'herbalism', 'herb', '宁神花丛', 'peacebloom', 1, 25, 50, 0.3, 1, 3, 0),
+('mulgore_silverleaf', 'mulgore', 'herbalism', 'herb', '银叶草丛', 'silverleaf', 1, 25, 50, 0.2, 1, 2, 20),
+('mulgore_wolf_pelt', 'mulgore', 'skinning', 'leather', '野兽毛皮', 'wolf_pelt', 1, 25, 50, 0.3, 1, 2, 0),
+('tirisfal_silverleaf', 'tirisfal', 'herbalism', 'herb', '银叶草丛', 'silverleaf', 1, 25, 50, 0.25, 1, 2, 0),
+('tirisfal_copper', 'tirisfal', 'mining', 'ore', '铜矿脉', 'copper_ore', 1, 25, 50, 0.2, 1, 3, 0),
+-- 10级以上地图
+('westfall_briarthorn', 'westfall', 'herbalism', 'herb', '石南草丛', 'briarthorn', 50, 75, 100, 0.2, 1, 2, 0),
+('westfall_tin', 'westfall', 'mining', 'ore', '锡矿脉', 'tin_ore', 50, 75, 100, 0.15, 1, 2, 30),
+('westfall_light_leather', 'westfall', 'skinning', 'leather', '轻皮', 'light_leather', 50, 75, 100, 0.3, 1, 2, 0),
+('loch_modan_tin', 'loch_modan', 'mining', 'ore', '锡矿脉', 'tin_ore', 50, 75, 100, 0.25, 1, 3, 0),
+('loch_modan_light_leather', 'loch_modan', 'skinning', 'leather', '轻皮', 'light_leather', 50, 75, 100, 0.3, 1, 2, 0),
+('barrens_briarthorn', 'barrens', 'herbalism', 'herb', '石南草丛', 'briarthorn', 50, 75, 100, 0.2, 1, 2, 0),
+('barrens_tin', 'barrens', 'mining', 'ore', '锡矿脉', 'tin_ore', 50, 75, 100, 0.15, 1, 2, 30),
+('barrens_light_leather', 'barrens', 'skinning', 'leather', '轻皮', 'light_leather', 50, 75, 100, 0.35, 1, 2, 0),
+('silverpine_briarthorn', 'silverpine', 'herbalism', 'herb', '石南草丛', 'briarthorn', 50, 75, 100, 0.25, 1, 2, 0),
+('silverpine_tin', 'silverpine', 'mining', 'ore', '锡矿脉', 'tin_ore', 50, 75, 100, 0.2, 1, 2, 30);
+
 -- ═══════════════════════════════════════════════════════════
 -- 游戏公式配置 (玩家可查询)
 -- ═══════════════════════════════════════════════════════════
@@ -0,0 +1,445 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler GM管理API处理器（路由组需挂载 AuthMiddleware 与 RequireRole）
+type AdminHandler struct {
+	adminMgr *game.AdminManager
+}
+
+// NewAdminHandler 创建GM管理处理器
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{
+		adminMgr: game.GetAdminManager(),
+	}
+}
+
+// AdminGrantGoldRequest 发放/扣除金币请求
+type AdminGrantGoldRequest struct {
+	Amount int    `json:"amount" binding:"required"` // 负数为扣除
+	Note   string `json:"note"`
+}
+
+// AdminGrantItemRequest 发放物品请求
+type AdminGrantItemRequest struct {
+	CharacterID int    `json:"characterId" binding:"required"`
+	ItemID      string `json:"itemId" binding:"required"`
+	Quantity    int    `json:"quantity"` // 默认1
+}
+
+// AdminTeleportRequest 传送请求
+type AdminTeleportRequest struct {
+	ZoneID string `json:"zoneId" binding:"required"`
+}
+
+// AdminStopBattleRequest 停止战斗请求
+type AdminStopBattleRequest struct {
+	Kill bool `json:"kill"` // 同时强制结束当前遭遇
+}
+
+// AdminBanRequest 封禁账号请求
+type AdminBanRequest struct {
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"durationMinutes"` // 0表示永久
+}
+
+// AdminStartSeasonRequest 开启排行榜新赛季请求
+type AdminStartSeasonRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// AdminPostAnnouncementRequest 发布全服公告请求
+type AdminPostAnnouncementRequest struct {
+	Content         string `json:"content" binding:"required"`
+	Importance      int    `json:"importance"`      // 1-5，默认5
+	DurationMinutes int    `json:"durationMinutes"` // 有效时长，0表示永不过期
+}
+
+// AdminSetRoleRequest 设置角色请求
+type AdminSetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// AdminReloadConfigRequest 重载配置请求
+type AdminReloadConfigRequest struct {
+	Types []string `json:"types"` // 为空时重载全部
+}
+
+// FindUser 按用户名查找玩家并返回其状态
+func (h *AdminHandler) FindUser(c *gin.Context) {
+	actorID := c.GetInt("userID")
+
+	targetID, err := h.adminMgr.FindUser(actorID, c.Query("name"))
+	if err != nil {
+		h.respondAdminError(c, err, "failed to find user")
+		return
+	}
+	h.respondUserState(c, actorID, targetID)
+}
+
+// GetUser 查看玩家状态
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	targetID, ok := adminTargetID(c)
+	if !ok {
+		return
+	}
+	h.respondUserState(c, c.GetInt("userID"), targetID)
+}
+
+// GrantGold 发放或扣除金币
+func (h *AdminHandler) GrantGold(c *gin.Context) {
+	targetID, ok := adminTargetID(c)
+	if !ok {
+		return
+	}
+	var req AdminGrantGoldRequest
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+
+	balance, err := h.adminMgr.GrantGold(c.GetInt("userID"), targetID, req.Amount, req.Note)
+	if err != nil {
+		h.respondAdminError(c, err, "failed to grant gold")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    gin.H{"balance": balance},
+		Message: "gold adjusted",
+	})
+}
+
+// GrantItem 发放物品到玩家角色背包
+func (h *AdminHandler) GrantItem(c *gin.Context) {
+	targetID, ok := adminTargetID(c)
+	if !ok {
+		return
+	}
+	var req AdminGrantItemRequest
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	if err := h.adminMgr.GrantItem(c.GetInt("userID"), targetID, req.CharacterID, req.ItemID, req.Quantity); err != nil {
+		h.respondAdminError(c, err, "failed to grant item")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "item granted",
+	})
+}
+
+// Teleport 传送玩家到指定区域
+func (h *AdminHandler) Teleport(c *gin.Context) {
+	targetID, ok := adminTargetID(c)
+	if !ok {
+		return
+	}
+	var req AdminTeleportRequest
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+
+	zone, err := h.adminMgr.Teleport(c.GetInt("userID"), targetID, req.ZoneID)
+	if err != nil {
+		h.respondAdminError(c, err, "failed to teleport user")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    zone,
+		Message: "user teleported",
+	})
+}
+
+// ResetSession 重置玩家的战斗会话
+func (h *AdminHandler) ResetSession(c *gin.Context) {
+	targetID, ok := adminTargetID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminMgr.ResetSession(c.GetInt("userID"), targetID); err != nil {
+		h.respondAdminError(c, err, "failed to reset session")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "session reset",
+	})
+}
+
+// StopBattle 停止（或强制结束）玩家的战斗
+func (h *AdminHandler) StopBattle(c *gin.Context) {
+	targetID, ok := adminTargetID(c)
+	if !ok {
+		return
+	}
+	var req AdminStopBattleRequest
+	if c.Request.ContentLength > 0 && !bindAdminRequest(c, &req) {
+		return
+	}
+
+	if err := h.adminMgr.StopBattle(c.GetInt("userID"), targetID, req.Kill); err != nil {
+		h.respondAdminError(c, err, "failed to stop battle")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "battle stopped",
+	})
+}
+
+// Ban 封禁账号
+func (h *AdminHandler) Ban(c *gin.Context) {
+	targetID, ok := adminTargetID(c)
+	if !ok {
+		return
+	}
+	var req AdminBanRequest
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+
+	ban, err := h.adminMgr.Ban(c.GetInt("userID"), targetID, req.Reason,
+		time.Duration(req.DurationMinutes)*time.Minute)
+	if err != nil {
+		h.respondAdminError(c, err, "failed to ban user")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    ban,
+		Message: "user banned",
+	})
+}
+
+// Unban 解除账号封禁
+func (h *AdminHandler) Unban(c *gin.Context) {
+	targetID, ok := adminTargetID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminMgr.Unban(c.GetInt("userID"), targetID); err != nil {
+		h.respondAdminError(c, err, "failed to unban user")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "user unbanned",
+	})
+}
+
+// SetRole 设置玩家角色（管理员）
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	targetID, ok := adminTargetID(c)
+	if !ok {
+		return
+	}
+	var req AdminSetRoleRequest
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+
+	if err := h.adminMgr.SetRole(c.GetInt("userID"), targetID, req.Role); err != nil {
+		h.respondAdminError(c, err, "failed to set role")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "role updated",
+	})
+}
+
+// ReloadConfig 热重载服务器配置（管理员）
+func (h *AdminHandler) ReloadConfig(c *gin.Context) {
+	var req AdminReloadConfigRequest
+	if c.Request.ContentLength > 0 && !bindAdminRequest(c, &req) {
+		return
+	}
+
+	reloaded, err := h.adminMgr.ReloadConfig(c.GetInt("userID"), req.Types)
+	if err != nil {
+		h.respondAdminError(c, err, "failed to reload config")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    gin.H{"reloaded": reloaded},
+		Message: "config reloaded",
+	})
+}
+
+// StartLeaderboardSeason 结束当前排行榜赛季并开启新赛季（管理员）
+func (h *AdminHandler) StartLeaderboardSeason(c *gin.Context) {
+	var req AdminStartSeasonRequest
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+
+	season, err := h.adminMgr.StartLeaderboardSeason(c.GetInt("userID"), req.Name, time.Now())
+	if err != nil {
+		h.respondAdminError(c, err, "failed to start season")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    season,
+		Message: "new season started",
+	})
+}
+
+// PostAnnouncement 发布全服公告（管理员）
+func (h *AdminHandler) PostAnnouncement(c *gin.Context) {
+	var req AdminPostAnnouncementRequest
+	if !bindAdminRequest(c, &req) {
+		return
+	}
+	if req.Importance == 0 {
+		req.Importance = 5
+	}
+
+	announcement, err := h.adminMgr.PostAnnouncement(c.GetInt("userID"), req.Content, req.Importance,
+		time.Duration(req.DurationMinutes)*time.Minute)
+	if err != nil {
+		h.respondAdminError(c, err, "failed to post announcement")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    announcement,
+		Message: "announcement posted",
+	})
+}
+
+// GetAuditLog 获取GM操作审计日志（管理员，支持 user/before/limit）
+func (h *AdminHandler) GetAuditLog(c *gin.Context) {
+	targetID, _ := strconv.Atoi(c.Query("user"))
+	beforeID, _ := strconv.Atoi(c.Query("before"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	entries, err := h.adminMgr.GetAuditLog(c.GetInt("userID"), targetID, beforeID, limit)
+	if err != nil {
+		h.respondAdminError(c, err, "failed to get audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entries,
+	})
+}
+
+// GetLoginAttempts 获取登录尝试审计记录（管理员，支持 username/ip/before/limit）
+func (h *AdminHandler) GetLoginAttempts(c *gin.Context) {
+	beforeID, _ := strconv.Atoi(c.Query("before"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	attempts, err := h.adminMgr.GetLoginAttempts(c.GetInt("userID"), c.Query("username"), c.Query("ip"), beforeID, limit)
+	if err != nil {
+		h.respondAdminError(c, err, "failed to get login attempts")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    attempts,
+	})
+}
+
+func (h *AdminHandler) respondUserState(c *gin.Context, actorID, targetID int) {
+	state, err := h.adminMgr.GetUserState(actorID, targetID)
+	if err != nil {
+		h.respondAdminError(c, err, "failed to get user state")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    state,
+	})
+}
+
+// adminTargetID 解析路径中的目标玩家ID
+func adminTargetID(c *gin.Context) (int, bool) {
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || targetID <= 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid user id",
+		})
+		return 0, false
+	}
+	return targetID, true
+}
+
+func bindAdminRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// respondAdminError 将GM管理错误映射为HTTP响应
+func (h *AdminHandler) respondAdminError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, game.ErrAdminForbidden),
+		errors.Is(err, game.ErrAdminTargetRank):
+		status, message = http.StatusForbidden, err.Error()
+	case errors.Is(err, game.ErrAdminUserNotFound),
+		errors.Is(err, game.ErrAdminCharacterNotFound),
+		errors.Is(err, game.ErrAdminItemNotFound),
+		errors.Is(err, game.ErrAdminZoneNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, game.ErrAdminInvalidAmount),
+		errors.Is(err, game.ErrAdminInvalidQuantity),
+		errors.Is(err, game.ErrAdminInvalidRole),
+		errors.Is(err, game.ErrAdminInvalidBan),
+		errors.Is(err, game.ErrAdminUnknownConfig),
+		errors.Is(err, game.ErrInvalidSeasonName),
+		errors.Is(err, game.ErrInvalidAnnouncement),
+		errors.Is(err, game.ErrInvalidAnnouncementLevel),
+		errors.Is(err, game.ErrInvalidAnnouncementExpiry),
+		errors.Is(err, repository.ErrInsufficientGold):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.Is(err, game.ErrAdminNotBanned):
+		status, message = http.StatusConflict, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnnouncementHandler 全服公告API处理器
+type AnnouncementHandler struct {
+	announcementMgr *game.AnnouncementManager
+}
+
+// NewAnnouncementHandler 创建全服公告处理器
+func NewAnnouncementHandler() *AnnouncementHandler {
+	return &AnnouncementHandler{
+		announcementMgr: game.GetAnnouncementManager(),
+	}
+}
+
+// GetAnnouncements 获取未过期的全服公告（支持 type/before/limit）
+func (h *AnnouncementHandler) GetAnnouncements(c *gin.Context) {
+	beforeID, _ := strconv.Atoi(c.Query("before"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	announcements, err := h.announcementMgr.GetFeed(c.Query("type"), beforeID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get announcements",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    announcements,
+	})
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuctionHandler 拍卖行API处理器
+type AuctionHandler struct {
+	tradingMgr *game.TradingManager
+}
+
+// NewAuctionHandler 创建拍卖行处理器
+func NewAuctionHandler() *AuctionHandler {
+	return &AuctionHandler{
+		tradingMgr: game.GetTradingManager(),
+	}
+}
+
+// CreateListingRequest 上架请求（设置起拍价时为竞价拍卖，price 为可选的一口价）
+type CreateListingRequest struct {
+	EquipmentID  int `json:"equipmentId" binding:"required"`
+	Price        int `json:"price"`
+	StartingBid  int `json:"startingBid"`
+	MinIncrement int `json:"minIncrement"`
+	DurationDays int `json:"durationDays"` // 上架天数（默认2天，最长7天）
+}
+
+// PlaceBidRequest 出价请求
+type PlaceBidRequest struct {
+	Amount int `json:"amount" binding:"required"`
+}
+
+// SearchListings 搜索拍卖行
+func (h *AuctionHandler) SearchListings(c *gin.Context) {
+	filter := repository.AuctionSearchFilter{
+		Slot:    c.Query("slot"),
+		Quality: c.Query("quality"),
+		Affix:   c.Query("affix"),
+	}
+	filter.MinLevel, _ = strconv.Atoi(c.Query("minLevel"))
+	filter.MaxLevel, _ = strconv.Atoi(c.Query("maxLevel"))
+	filter.MaxPrice, _ = strconv.Atoi(c.Query("maxPrice"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 50
+	}
+	filter.Limit = limit
+
+	listings, err := h.tradingMgr.GetActiveListings(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to search auction listings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    listings,
+	})
+}
+
+// GetMyListings 获取我的上架记录
+func (h *AuctionHandler) GetMyListings(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	listings, err := h.tradingMgr.GetUserListings(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get listings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    listings,
+	})
+}
+
+// CreateListing 上架装备
+func (h *AuctionHandler) CreateListing(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req CreateListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	var listing *models.AuctionListing
+	var err error
+	if req.StartingBid > 0 {
+		listing, err = h.tradingMgr.ListAuction(userID, req.EquipmentID, game.AuctionOptions{
+			StartingBid:  req.StartingBid,
+			MinIncrement: req.MinIncrement,
+			BuyoutPrice:  req.Price,
+			DurationDays: req.DurationDays,
+		})
+	} else {
+		listing, err = h.tradingMgr.ListItem(userID, req.EquipmentID, req.Price, req.DurationDays)
+	}
+	if err != nil {
+		h.respondAuctionError(c, err, "failed to create listing")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    listing,
+		Message: "item listed",
+	})
+}
+
+// BuyListing 一口价购买
+func (h *AuctionHandler) BuyListing(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	listingID, err := strconv.Atoi(c.Param("listingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid listing ID",
+		})
+		return
+	}
+
+	listing, err := h.tradingMgr.BuyItem(userID, listingID)
+	if err != nil {
+		h.respondAuctionError(c, err, "failed to buy listing")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    listing,
+		Message: "purchase successful",
+	})
+}
+
+// PlaceBid 竞价出价
+func (h *AuctionHandler) PlaceBid(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	listingID, err := strconv.Atoi(c.Param("listingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid listing ID",
+		})
+		return
+	}
+
+	var req PlaceBidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	listing, err := h.tradingMgr.PlaceBid(userID, listingID, req.Amount)
+	if err != nil {
+		h.respondAuctionError(c, err, "failed to place bid")
+		return
+	}
+
+	message := "bid placed"
+	if listing.Status == "sold" {
+		message = "buyout price reached, purchase successful"
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    listing,
+		Message: message,
+	})
+}
+
+// GetListingBids 获取上架的出价记录
+func (h *AuctionHandler) GetListingBids(c *gin.Context) {
+	listingID, err := strconv.Atoi(c.Param("listingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid listing ID",
+		})
+		return
+	}
+
+	bids, err := h.tradingMgr.GetListingBids(listingID)
+	if err != nil {
+		h.respondAuctionError(c, err, "failed to get bids")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    bids,
+	})
+}
+
+// GetMyBids 获取我的出价记录
+func (h *AuctionHandler) GetMyBids(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	bids, err := h.tradingMgr.GetUserBids(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get bids",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    bids,
+	})
+}
+
+// CancelListing 取消上架
+func (h *AuctionHandler) CancelListing(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	listingID, err := strconv.Atoi(c.Param("listingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid listing ID",
+		})
+		return
+	}
+
+	listing, err := h.tradingMgr.CancelListing(userID, listingID)
+	if err != nil {
+		h.respondAuctionError(c, err, "failed to cancel listing")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    listing,
+		Message: "listing cancelled",
+	})
+}
+
+// respondAuctionError 将拍卖行错误映射为HTTP响应
+func (h *AuctionHandler) respondAuctionError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, repository.ErrAuctionListingNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrAuctionNotSeller),
+		errors.Is(err, repository.ErrEquipmentNotOwned):
+		status, message = http.StatusForbidden, err.Error()
+	case errors.Is(err, repository.ErrAuctionListingNotActive),
+		errors.Is(err, repository.ErrAuctionListingExpired),
+		errors.Is(err, repository.ErrAuctionOwnListing),
+		errors.Is(err, repository.ErrAuctionHasBids),
+		errors.Is(err, repository.ErrAuctionNoBidding),
+		errors.Is(err, repository.ErrAuctionNoBuyout),
+		errors.Is(err, repository.ErrAuctionBidTooLow),
+		errors.Is(err, repository.ErrAuctionAlreadyHighest),
+		errors.Is(err, repository.ErrEquipmentEquipped),
+		errors.Is(err, repository.ErrEquipmentLocked),
+		errors.Is(err, repository.ErrEquipmentInEscrow),
+		errors.Is(err, repository.ErrInsufficientGold),
+		errors.Is(err, game.ErrInvalidAuctionPrice),
+		errors.Is(err, game.ErrInvalidAuctionDuration),
+		errors.Is(err, game.ErrInvalidBuyoutPrice):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
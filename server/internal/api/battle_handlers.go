@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -36,6 +37,13 @@ func (h *BattleHandler) StartBattle(c *gin.Context) {
 	userID := c.GetInt("userID")
 
 	isRunning, err := h.battleMgr.StartBattle(userID)
+	if errors.Is(err, game.ErrNotEnoughStamina) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "not enough stamina",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -78,6 +86,13 @@ func (h *BattleHandler) ToggleBattle(c *gin.Context) {
 	userID := c.GetInt("userID")
 
 	isRunning, err := h.battleMgr.ToggleBattle(userID)
+	if errors.Is(err, game.ErrNotEnoughStamina) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "not enough stamina",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -283,6 +298,25 @@ func (h *BattleHandler) GetBattleLogs(c *gin.Context) {
 	})
 }
 
+// GetStamina 获取体力状态（未启用体力系统时 enabled=false）
+func (h *BattleHandler) GetStamina(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	status, err := h.battleMgr.GetStaminaStatus(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get stamina",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    status,
+	})
+}
+
 // ═══════════════════════════════════════════════════════════
 // 区域 API
 // ═══════════════════════════════════════════════════════════
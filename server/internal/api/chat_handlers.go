@@ -1,12 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
-	"regexp"
+	"sort"
 	"strings"
-	"time"
 	"unicode/utf8"
 
+	"text-wow/internal/game"
 	"text-wow/internal/repository"
 
 	"github.com/gin-gonic/gin"
@@ -14,36 +15,29 @@ import (
 
 // ChatHandler 聊天处理器
 type ChatHandler struct {
-	chatRepo *repository.ChatRepository
-	charRepo *repository.CharacterRepository
-	userRepo *repository.UserRepository
-
-	// 简单的刷屏检测 (生产环境应使用Redis)
-	lastMessages map[int]time.Time
+	chatRepo   *repository.ChatRepository
+	charRepo   *repository.CharacterRepository
+	userRepo   *repository.UserRepository
+	eventHub   *game.EventHub
+	commandMgr *game.ChatCommandManager
+	linkMgr    *game.ChatLinkManager
+	moderation *game.ChatModerationManager
+	guildMgr   *game.GuildManager
+	announcer  *game.AnnouncementManager
 }
 
 // NewChatHandler 创建聊天处理器
 func NewChatHandler() *ChatHandler {
 	return &ChatHandler{
-		chatRepo:     repository.NewChatRepository(),
-		charRepo:     repository.NewCharacterRepository(),
-		userRepo:     repository.NewUserRepository(),
-		lastMessages: make(map[int]time.Time),
-	}
-}
-
-// 敏感词列表 (简化版，生产环境应使用更完整的词库)
-var sensitiveWords = []string{
-	// 这里添加敏感词...
-}
-
-// 敏感词正则
-var sensitiveRegex *regexp.Regexp
-
-func init() {
-	if len(sensitiveWords) > 0 {
-		pattern := strings.Join(sensitiveWords, "|")
-		sensitiveRegex = regexp.MustCompile("(?i)" + pattern)
+		chatRepo:   repository.NewChatRepository(),
+		charRepo:   repository.NewCharacterRepository(),
+		userRepo:   repository.NewUserRepository(),
+		eventHub:   game.GetEventHub(),
+		commandMgr: game.GetChatCommandManager(),
+		linkMgr:    game.GetChatLinkManager(),
+		moderation: game.GetChatModerationManager(),
+		guildMgr:   game.GetGuildManager(),
+		announcer:  game.GetAnnouncementManager(),
 	}
 }
 
@@ -75,7 +69,7 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	// 验证频道
 	validChannels := map[string]bool{
 		"world": true, "zone": true, "trade": true,
-		"lfg": true, "whisper": true,
+		"lfg": true, "whisper": true, "guild": true,
 	}
 	if !validChannels[req.Channel] {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -85,17 +79,6 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	// 刷屏检测 (3秒内不能发送相同消息)
-	if lastTime, ok := h.lastMessages[userID]; ok {
-		if time.Since(lastTime) < 3*time.Second {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"success": false,
-				"error":   "please wait before sending another message",
-			})
-			return
-		}
-	}
-
 	// 获取玩家信息
 	user, err := h.userRepo.GetByID(userID)
 	if err != nil {
@@ -117,8 +100,39 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	}
 	faction := chars[0].Faction // 使用第一个角色的阵营
 
+	// 斜杠命令：不产生消息的命令直接返回结果，其余命令改写为普通消息继续发送
+	var command *game.ChatCommandResult
+	messageType := ""
+	if game.IsChatCommand(req.Content) {
+		var ok bool
+		command, ok = h.executeCommand(c, &game.ChatCommandContext{
+			UserID:     userID,
+			PlayerName: user.Username,
+			Faction:    faction,
+			Channel:    req.Channel,
+			ZoneID:     req.ZoneID,
+		}, req.Content)
+		if !ok {
+			return
+		}
+		if command.Draft == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": true,
+				"data":    command,
+			})
+			return
+		}
+		req.Channel = command.Draft.Channel
+		req.Receiver = command.Draft.Receiver
+		req.Content = command.Draft.Content
+		messageType = command.Draft.MessageType
+		if req.Channel == "zone" && req.ZoneID == "" {
+			req.ZoneID = user.CurrentZoneID
+		}
+	}
+
 	// 内容过滤
-	content := filterContent(req.Content)
+	content := h.moderation.FilterContent(req.Content)
 	if utf8.RuneCountInString(content) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -136,6 +150,7 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		SenderName:  user.Username, // 使用玩家用户名
 		SenderClass: "",            // 玩家没有职业，角色才有职业
 		Content:     content,
+		MessageType: messageType,
 	}
 
 	// 私聊处理
@@ -191,6 +206,62 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		msg.ReceiverID = receiver.UserID
 	}
 
+	// 公会频道只有公会成员可以发言
+	if req.Channel == "guild" {
+		membership, err := h.guildMgr.GetMembership(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "failed to get guild membership",
+			})
+			return
+		}
+		if membership == nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   game.ErrNotInGuild.Error(),
+			})
+			return
+		}
+		msg.GuildID = membership.GuildID
+	}
+
+	// 禁言/封禁、重复消息与频道限流检查（先于链接解析，被拒绝的消息不查询链接）
+	if err := h.moderation.Admit(userID, msg.Channel, content); err != nil {
+		status := http.StatusInternalServerError
+		message := "failed to check message"
+		switch {
+		case errors.Is(err, game.ErrChatMuted), errors.Is(err, game.ErrChatBanned):
+			status, message = http.StatusForbidden, err.Error()
+		case errors.Is(err, game.ErrChatRateLimited), errors.Is(err, game.ErrChatDuplicateMessage):
+			status, message = http.StatusTooManyRequests, err.Error()
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
+
+	// 解析物品/技能/成就链接并保存快照
+	links, err := h.linkMgr.Resolve(userID, content)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "failed to resolve links"
+		switch {
+		case errors.Is(err, game.ErrChatLinkNotFound):
+			status, message = http.StatusNotFound, err.Error()
+		case errors.Is(err, game.ErrChatTooManyLinks):
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
+	msg.Links = links
+
 	// 保存消息
 	savedMsg, err := h.chatRepo.SendMessage(msg)
 	if err != nil {
@@ -201,8 +272,20 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	// 更新刷屏检测
-	h.lastMessages[userID] = time.Now()
+	// 在可加入的频道发言时自动加入
+	if game.IsJoinableChannel(savedMsg.Channel) {
+		h.chatRepo.JoinChannel(userID, savedMsg.Channel)
+	}
+	h.publishMessage(savedMsg)
+
+	if command != nil {
+		command.Message = savedMsg
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    command,
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -253,8 +336,28 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 				messages, _ = h.chatRepo.GetWhisperMessages(userID, other.UserID, 50, 0)
 			}
 		}
+	case "guild":
+		membership, mErr := h.guildMgr.GetMembership(userID)
+		if mErr == nil && membership == nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   game.ErrNotInGuild.Error(),
+			})
+			return
+		}
+		err = mErr
+		if err == nil {
+			messages, err = h.chatRepo.GetGuildMessages(membership.GuildID, 50, 0)
+		}
+	case "system":
+		messages, err = h.announcer.GetSystemMessages(50)
 	case "recent":
 		messages, err = h.chatRepo.GetRecentMessages(faction, 100)
+		if err == nil {
+			var announcements []repository.ChatMessage
+			announcements, err = h.announcer.GetSystemMessages(20)
+			messages = mergeRecentMessages(messages, announcements, 100)
+		}
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -411,104 +514,92 @@ func (h *ChatHandler) UnblockUser(c *gin.Context) {
 	})
 }
 
-// SetOnline 设置在线状态（使用玩家信息）
-func (h *ChatHandler) SetOnline(c *gin.Context) {
-	userID := c.GetInt("userID")
-
-	var req struct {
-		ZoneID string `json:"zoneId"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "invalid request",
-		})
-		return
-	}
-
-	// 获取玩家信息
-	user, err := h.userRepo.GetByID(userID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "user not found",
-		})
-		return
-	}
+// ═══════════════════════════════════════════════════════════
+// 辅助函数
+// ═══════════════════════════════════════════════════════════
 
-	// 获取第一个角色以获取阵营信息
-	chars, err := h.charRepo.GetByUserID(userID)
-	if err != nil || len(chars) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "no character",
-		})
+// publishMessage 将消息实时推送给频道内的在线玩家（屏蔽了发送者的玩家除外）
+func (h *ChatHandler) publishMessage(msg *repository.ChatMessage) {
+	if msg.Channel == "whisper" {
+		h.eventHub.Publish(msg.ReceiverID, game.EventChat, msg)
+		h.eventHub.Publish(msg.SenderID, game.EventChat, msg)
 		return
 	}
-	faction := chars[0].Faction
 
-	zoneID := req.ZoneID
-	if zoneID == "" {
-		zoneID = user.CurrentZoneID
-	}
-	// 如果 zoneID 仍然为空，使用默认值
-	if zoneID == "" {
-		zoneID = "elwynn"
+	blockedBy, _ := h.chatRepo.GetBlockedBy(msg.SenderID)
+	blocked := make(map[int]bool, len(blockedBy))
+	for _, id := range blockedBy {
+		blocked[id] = true
 	}
 
-	// 使用玩家用户名而不是角色名
-	if err := h.chatRepo.SetOnlineStatus(userID, 0, user.Username, faction, zoneID, true); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "failed to set online status: " + err.Error(),
-		})
-		return
+	// 可加入的频道和公会频道只推送给成员
+	var members map[int]bool
+	if game.IsJoinableChannel(msg.Channel) || msg.Channel == "guild" {
+		var ids []int
+		if msg.Channel == "guild" {
+			ids, _ = h.guildMgr.GetMemberIDs(msg.GuildID)
+		} else {
+			ids, _ = h.chatRepo.GetChannelMembers(msg.Channel)
+		}
+		members = make(map[int]bool, len(ids))
+		for _, id := range ids {
+			members[id] = true
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
+	h.eventHub.Broadcast(game.EventChat, msg, func(sub *game.Subscriber) bool {
+		if sub.Faction != msg.Faction || blocked[sub.UserID] {
+			return false
+		}
+		if members != nil && !members[sub.UserID] {
+			return false
+		}
+		return msg.Channel != "zone" || sub.Zone() == msg.ZoneID
 	})
 }
 
-// SetOffline 设置离线状态
-func (h *ChatHandler) SetOffline(c *gin.Context) {
-	userID := c.GetInt("userID")
-
-	if err := h.chatRepo.SetOnlineStatus(userID, 0, "", "", "", false); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "failed to set offline status",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
+// mergeRecentMessages 按时间倒序合并聊天消息与系统公告，最多保留 limit 条
+func mergeRecentMessages(messages, announcements []repository.ChatMessage, limit int) []repository.ChatMessage {
+	merged := append(messages, announcements...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
 	})
-}
-
-// Heartbeat 心跳 (保持在线状态)
-func (h *ChatHandler) Heartbeat(c *gin.Context) {
-	userID := c.GetInt("userID")
-	h.chatRepo.UpdateLastActive(userID)
-	c.JSON(http.StatusOK, gin.H{"success": true})
-}
-
-// ═══════════════════════════════════════════════════════════
-// 辅助函数
-// ═══════════════════════════════════════════════════════════
-
-// filterContent 过滤消息内容
-func filterContent(content string) string {
-	// 去除首尾空白
-	content = strings.TrimSpace(content)
-
-	// 敏感词替换
-	if sensitiveRegex != nil {
-		content = sensitiveRegex.ReplaceAllString(content, "***")
+	if len(merged) > limit {
+		merged = merged[:limit]
 	}
+	return merged
+}
 
-	return content
+// executeCommand 解析并执行斜杠命令（失败时已写入响应）
+func (h *ChatHandler) executeCommand(c *gin.Context, ctx *game.ChatCommandContext, input string) (*game.ChatCommandResult, bool) {
+	cmd, err := game.ParseChatCommand(input)
+	var result *game.ChatCommandResult
+	if err == nil {
+		result, err = h.commandMgr.Execute(ctx, cmd)
+	}
+	if err == nil {
+		return result, true
+	}
+
+	status := http.StatusInternalServerError
+	message := "failed to execute command"
+	switch {
+	case errors.Is(err, game.ErrChatPlayerNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, game.ErrChatEnemyFaction):
+		status, message = http.StatusForbidden, err.Error()
+	case errors.Is(err, game.ErrUnknownChatCommand),
+		errors.Is(err, game.ErrInvalidChatCommand),
+		errors.Is(err, game.ErrChatTargetSelf),
+		errors.Is(err, game.ErrChatNoReplyTarget),
+		errors.Is(err, game.ErrChatUnknownChannel):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   message,
+	})
+	return nil, false
 }
 
 // GarbleMessage 将消息转换为"敌方语言" (跨阵营时使用)
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"text-wow/internal/game"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChatModerationHandler 聊天管理处理器（举报、禁言/封禁）
+type ChatModerationHandler struct {
+	moderation *game.ChatModerationManager
+}
+
+// NewChatModerationHandler 创建聊天管理处理器
+func NewChatModerationHandler() *ChatModerationHandler {
+	return &ChatModerationHandler{
+		moderation: game.GetChatModerationManager(),
+	}
+}
+
+// ReportMessageRequest 举报消息请求
+type ReportMessageRequest struct {
+	MessageID int    `json:"messageId" binding:"required"`
+	Reason    string `json:"reason" binding:"max=200"`
+}
+
+// ResolveReportRequest 处理举报请求
+type ResolveReportRequest struct {
+	Status string `json:"status" binding:"required,oneof=resolved dismissed"`
+}
+
+// SanctionRequest 禁言/封禁请求
+type SanctionRequest struct {
+	PlayerName      string `json:"playerName" binding:"required"`
+	Type            string `json:"type" binding:"required,oneof=mute ban"`
+	Channel         string `json:"channel,omitempty"`
+	DurationMinutes int    `json:"durationMinutes"` // 0 表示永久
+	Reason          string `json:"reason" binding:"max=200"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 玩家 API
+// ═══════════════════════════════════════════════════════════
+
+// ReportMessage 举报消息
+func (h *ChatModerationHandler) ReportMessage(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req ReportMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	report, err := h.moderation.Report(userID, req.MessageID, req.Reason)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// GetMySanctions 获取自己生效中的禁言/封禁
+func (h *ChatModerationHandler) GetMySanctions(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	sanctions, err := h.moderation.GetSanctions(userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sanctions,
+	})
+}
+
+// ═══════════════════════════════════════════════════════════
+// 管理员 API
+// ═══════════════════════════════════════════════════════════
+
+// GetReports 获取举报列表（?status=pending/resolved/dismissed，默认 pending）
+func (h *ChatModerationHandler) GetReports(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	status := c.DefaultQuery("status", "pending")
+	if status == "all" {
+		status = ""
+	}
+	reports, err := h.moderation.ListReports(userID, status)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    reports,
+	})
+}
+
+// ResolveReport 处理举报
+func (h *ChatModerationHandler) ResolveReport(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	reportID, ok := parseModerationID(c, "reportId")
+	if !ok {
+		return
+	}
+	var req ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.moderation.ResolveReport(userID, reportID, req.Status); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "report " + req.Status,
+	})
+}
+
+// GetSanctions 获取生效中的禁言/封禁（?player= 筛选玩家）
+func (h *ChatModerationHandler) GetSanctions(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	sanctions, err := h.moderation.ListSanctions(userID, c.Query("player"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sanctions,
+	})
+}
+
+// CreateSanction 禁言或封禁玩家
+func (h *ChatModerationHandler) CreateSanction(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req SanctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	sanction, err := h.moderation.Sanction(userID, &game.ChatSanctionInput{
+		PlayerName: req.PlayerName,
+		Type:       req.Type,
+		Channel:    req.Channel,
+		Duration:   time.Duration(req.DurationMinutes) * time.Minute,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sanction,
+	})
+}
+
+// RevokeSanction 解除禁言/封禁
+func (h *ChatModerationHandler) RevokeSanction(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	sanctionID, ok := parseModerationID(c, "sanctionId")
+	if !ok {
+		return
+	}
+
+	if err := h.moderation.RevokeSanction(userID, sanctionID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "sanction revoked",
+	})
+}
+
+// parseModerationID 解析路径中的 ID 参数（失败时已写入响应）
+func parseModerationID(c *gin.Context, param string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid " + param,
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondError 将聊天管理错误映射为 HTTP 状态码
+func (h *ChatModerationHandler) respondError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	message := "failed to process moderation request"
+	switch {
+	case errors.Is(err, game.ErrChatNotModerator), errors.Is(err, game.ErrChatCannotSanction):
+		status, message = http.StatusForbidden, err.Error()
+	case errors.Is(err, game.ErrChatMessageNotFound),
+		errors.Is(err, game.ErrChatPlayerNotFound),
+		errors.Is(err, repository.ErrChatReportNotPending),
+		errors.Is(err, repository.ErrChatSanctionNotActive):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrChatAlreadyReported):
+		status, message = http.StatusConflict, err.Error()
+	case errors.Is(err, game.ErrChatReportOwnMessage),
+		errors.Is(err, game.ErrChatTargetSelf),
+		errors.Is(err, game.ErrInvalidChatSanction):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   message,
+	})
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CodexHandler 图鉴API处理器
+type CodexHandler struct {
+	codexMgr *game.CodexManager
+}
+
+// NewCodexHandler 创建图鉴处理器
+func NewCodexHandler() *CodexHandler {
+	return &CodexHandler{
+		codexMgr: game.GetBattleManager().GetCodexManager(),
+	}
+}
+
+// validCodexCategories 图鉴分类
+var validCodexCategories = map[string]bool{
+	"monster": true,
+	"boss":    true,
+	"item":    true,
+	"zone":    true,
+}
+
+// GetCodex 获取图鉴列表（含解锁进度与背景故事）
+func (h *CodexHandler) GetCodex(c *gin.Context) {
+	userID := c.GetInt("userID")
+	category := c.Query("category")
+	if category != "" && !validCodexCategories[category] {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid codex category",
+		})
+		return
+	}
+
+	entries, err := h.codexMgr.GetCodex(userID, category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get codex",
+		})
+		return
+	}
+
+	unlocked := 0
+	for _, entry := range entries {
+		if entry.Unlocked {
+			unlocked++
+		}
+	}
+
+	bonuses, err := h.codexMgr.GetBonuses(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get codex bonuses",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"entries":  entries,
+			"total":    len(entries),
+			"unlocked": unlocked,
+			"bonuses": gin.H{
+				"damageAll":       bonuses.DamageAll,
+				"damageByMonster": bonuses.DamageByMonster,
+				"damageByFamily":  bonuses.DamageByFamily,
+			},
+		},
+	})
+}
+
+// GetCodexEntry 获取单个图鉴条目
+func (h *CodexHandler) GetCodexEntry(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	entry, err := h.codexMgr.GetCodexEntry(userID, c.Param("codexId"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "codex entry not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get codex entry",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entry,
+	})
+}
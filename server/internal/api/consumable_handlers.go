@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsumableHandler 消耗品API处理器
+type ConsumableHandler struct {
+	battleMgr *game.BattleManager
+	charRepo  *repository.CharacterRepository
+}
+
+// NewConsumableHandler 创建消耗品处理器
+func NewConsumableHandler() *ConsumableHandler {
+	return &ConsumableHandler{
+		battleMgr: game.GetBattleManager(),
+		charRepo:  repository.NewCharacterRepository(),
+	}
+}
+
+// UseItemRequest 使用消耗品请求
+type UseItemRequest struct {
+	ItemID string `json:"itemId" binding:"required"`
+}
+
+// GetConsumables 获取角色背包中的消耗品
+func (h *ConsumableHandler) GetConsumables(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID, err := strconv.Atoi(c.Param("characterId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid character ID",
+		})
+		return
+	}
+
+	char, err := h.charRepo.GetByID(characterID)
+	if err != nil || char == nil || char.UserID != userID {
+		h.respondConsumableError(c, game.ErrConsumableNoCharacter, "")
+		return
+	}
+
+	items, err := h.battleMgr.GetConsumableManager().GetConsumables(characterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get consumables",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    items,
+	})
+}
+
+// UseItem 使用消耗品
+func (h *ConsumableHandler) UseItem(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID, err := strconv.Atoi(c.Param("characterId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid character ID",
+		})
+		return
+	}
+
+	var req UseItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.battleMgr.UseItem(userID, characterID, req.ItemID)
+	if err != nil {
+		h.respondConsumableError(c, err, "failed to use item")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+		Message: "item used",
+	})
+}
+
+// respondConsumableError 将消耗品错误映射为HTTP响应
+func (h *ConsumableHandler) respondConsumableError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, game.ErrConsumableNoCharacter),
+		errors.Is(err, repository.ErrItemNotInInventory):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrItemNotConsumable),
+		errors.Is(err, game.ErrConsumableNoEffect),
+		errors.Is(err, game.ErrFoodRequiresRest),
+		errors.Is(err, game.ErrCannotUseWhileDead),
+		errors.Is(err, game.ErrItemNotUsableInBattle):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
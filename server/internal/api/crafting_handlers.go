@@ -0,0 +1,299 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CraftingHandler 专业与制造API处理器
+type CraftingHandler struct {
+	craftingMgr  *game.CraftingManager
+	gatheringMgr *game.GatheringManager
+}
+
+// NewCraftingHandler 创建制造处理器
+func NewCraftingHandler() *CraftingHandler {
+	return &CraftingHandler{
+		craftingMgr:  game.GetCraftingManager(),
+		gatheringMgr: game.GetGatheringManager(),
+	}
+}
+
+// QueueCraftRequest 加入制造队列请求
+type QueueCraftRequest struct {
+	RecipeID string `json:"recipeId" binding:"required"`
+	Quantity int    `json:"quantity"` // 默认1
+}
+
+// GetProfessions 获取所有专业
+func (h *CraftingHandler) GetProfessions(c *gin.Context) {
+	professions, err := h.craftingMgr.GetProfessions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get professions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    professions,
+	})
+}
+
+// GetRecipes 获取专业配方（带 characterId 时标注难度）
+func (h *CraftingHandler) GetRecipes(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID := 0
+	if raw := c.Query("characterId"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "invalid character ID",
+			})
+			return
+		}
+		characterID = id
+	}
+
+	recipes, err := h.craftingMgr.GetRecipes(userID, characterID, c.Param("professionId"))
+	if err != nil {
+		h.respondCraftingError(c, err, "failed to get recipes")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    recipes,
+	})
+}
+
+// GetZoneGathering 获取区域采集表（带 characterId 时计算实际发现概率）
+func (h *CraftingHandler) GetZoneGathering(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID := 0
+	if raw := c.Query("characterId"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "invalid character ID",
+			})
+			return
+		}
+		characterID = id
+	}
+
+	nodes, err := h.gatheringMgr.GetZoneNodes(userID, characterID, c.Param("zoneId"))
+	if err != nil {
+		h.respondCraftingError(c, err, "failed to get gathering nodes")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    nodes,
+	})
+}
+
+// GetCharacterProfessions 获取角色专业进度
+func (h *CraftingHandler) GetCharacterProfessions(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID, ok := h.characterParam(c)
+	if !ok {
+		return
+	}
+
+	professions, err := h.craftingMgr.GetCharacterProfessions(userID, characterID)
+	if err != nil {
+		h.respondCraftingError(c, err, "failed to get professions")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    professions,
+	})
+}
+
+// LearnProfession 学习专业
+func (h *CraftingHandler) LearnProfession(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID, ok := h.characterParam(c)
+	if !ok {
+		return
+	}
+
+	profession, err := h.craftingMgr.LearnProfession(userID, characterID, c.Param("professionId"))
+	if err != nil {
+		h.respondCraftingError(c, err, "failed to learn profession")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    profession,
+		Message: "profession learned",
+	})
+}
+
+// AbandonProfession 放弃专业
+func (h *CraftingHandler) AbandonProfession(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID, ok := h.characterParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.craftingMgr.AbandonProfession(userID, characterID, c.Param("professionId")); err != nil {
+		h.respondCraftingError(c, err, "failed to abandon profession")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "profession abandoned",
+	})
+}
+
+// GetQueue 获取制造队列（同时返回本次结算完成的制造）
+func (h *CraftingHandler) GetQueue(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID, ok := h.characterParam(c)
+	if !ok {
+		return
+	}
+
+	entries, results, err := h.craftingMgr.GetQueue(userID, characterID)
+	if err != nil {
+		h.respondCraftingError(c, err, "failed to get crafting queue")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"queue":     entries,
+			"completed": results,
+		},
+	})
+}
+
+// QueueCraft 加入制造队列
+func (h *CraftingHandler) QueueCraft(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID, ok := h.characterParam(c)
+	if !ok {
+		return
+	}
+
+	var req QueueCraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	entry, err := h.craftingMgr.QueueCraft(userID, characterID, req.RecipeID, req.Quantity)
+	if err != nil {
+		h.respondCraftingError(c, err, "failed to queue craft")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entry,
+		Message: "craft queued",
+	})
+}
+
+// CancelCraft 取消制造并返还未使用的材料
+func (h *CraftingHandler) CancelCraft(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	characterID, ok := h.characterParam(c)
+	if !ok {
+		return
+	}
+	queueID, err := strconv.Atoi(c.Param("queueId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid queue ID",
+		})
+		return
+	}
+
+	entry, err := h.craftingMgr.CancelCraft(userID, characterID, queueID)
+	if err != nil {
+		h.respondCraftingError(c, err, "failed to cancel craft")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entry,
+		Message: "craft cancelled",
+	})
+}
+
+// characterParam 解析路径中的角色ID（失败时已写入响应）
+func (h *CraftingHandler) characterParam(c *gin.Context) (int, bool) {
+	characterID, err := strconv.Atoi(c.Param("characterId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid character ID",
+		})
+		return 0, false
+	}
+	return characterID, true
+}
+
+// respondCraftingError 将制造错误映射为HTTP响应
+func (h *CraftingHandler) respondCraftingError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, game.ErrCraftingNoCharacter),
+		errors.Is(err, repository.ErrProfessionNotFound),
+		errors.Is(err, repository.ErrRecipeNotFound),
+		errors.Is(err, repository.ErrCraftNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrProfessionNotLearned),
+		errors.Is(err, repository.ErrProfessionAlreadyLearned),
+		errors.Is(err, repository.ErrProfessionLimit),
+		errors.Is(err, repository.ErrProfessionInUse),
+		errors.Is(err, repository.ErrMissingMaterials),
+		errors.Is(err, repository.ErrCraftFinished),
+		errors.Is(err, game.ErrInvalidCraftQuantity),
+		errors.Is(err, game.ErrCraftingQueueFull),
+		errors.Is(err, game.ErrRecipeSkillTooLow):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
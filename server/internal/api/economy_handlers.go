@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EconomyHandler 经济系统API处理器
+type EconomyHandler struct {
+	economyMgr *game.EconomyManager
+}
+
+// NewEconomyHandler 创建经济系统处理器
+func NewEconomyHandler() *EconomyHandler {
+	return &EconomyHandler{
+		economyMgr: game.NewEconomyManager(),
+	}
+}
+
+// GetLedger 获取我的金币流水（支持按原因过滤，before 为上一页最后一条的ID）
+func (h *EconomyHandler) GetLedger(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		limit = 50
+	}
+	beforeID, _ := strconv.Atoi(c.Query("before"))
+
+	entries, err := h.economyMgr.GetLedger(userID, c.Query("reason"), beforeID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get gold ledger",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entries,
+	})
+}
@@ -0,0 +1,522 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GuildHandler 公会API处理器
+type GuildHandler struct {
+	guildMgr *game.GuildManager
+}
+
+// NewGuildHandler 创建公会处理器
+func NewGuildHandler() *GuildHandler {
+	return &GuildHandler{
+		guildMgr: game.GetGuildManager(),
+	}
+}
+
+// CreateGuildRequest 创建公会请求
+type CreateGuildRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// GuildPlayerRequest 以玩家名为目标的公会请求（邀请、转让会长）
+type GuildPlayerRequest struct {
+	PlayerName string `json:"playerName" binding:"required"`
+}
+
+// SetGuildRankRequest 调整成员会阶请求
+type SetGuildRankRequest struct {
+	RankIndex int `json:"rankIndex"`
+}
+
+// UpdateGuildRankRequest 修改会阶请求
+type UpdateGuildRankRequest struct {
+	Name              string   `json:"name" binding:"required"`
+	Permissions       []string `json:"permissions"`
+	GoldWithdrawLimit int      `json:"goldWithdrawLimit"` // -1表示不限
+	ItemWithdrawLimit int      `json:"itemWithdrawLimit"` // -1表示不限
+}
+
+// SetGuildMOTDRequest 修改每日公告请求
+type SetGuildMOTDRequest struct {
+	MOTD string `json:"motd"`
+}
+
+// GuildGoldRequest 公会银行金币请求
+type GuildGoldRequest struct {
+	Amount int `json:"amount" binding:"required"`
+}
+
+// GuildItemRequest 公会银行存入装备请求
+type GuildItemRequest struct {
+	EquipmentID int `json:"equipmentId" binding:"required"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 公会
+// ═══════════════════════════════════════════════════════════
+
+// GetGuild 获取自己所在公会的详情
+func (h *GuildHandler) GetGuild(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	info, err := h.guildMgr.GetGuild(userID)
+	if err != nil {
+		h.respondGuildError(c, err, "failed to get guild")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    info,
+	})
+}
+
+// CreateGuild 创建公会
+func (h *GuildHandler) CreateGuild(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req CreateGuildRequest
+	if !bindGuildRequest(c, &req) {
+		return
+	}
+
+	guild, err := h.guildMgr.CreateGuild(userID, req.Name)
+	if err != nil {
+		h.respondGuildError(c, err, "failed to create guild")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    guild,
+		Message: "guild created",
+	})
+}
+
+// DisbandGuild 解散公会
+func (h *GuildHandler) DisbandGuild(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	if err := h.guildMgr.DisbandGuild(userID); err != nil {
+		h.respondGuildError(c, err, "failed to disband guild")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "guild disbanded",
+	})
+}
+
+// LeaveGuild 退出公会
+func (h *GuildHandler) LeaveGuild(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	if err := h.guildMgr.Leave(userID); err != nil {
+		h.respondGuildError(c, err, "failed to leave guild")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "left guild",
+	})
+}
+
+// ═══════════════════════════════════════════════════════════
+// 邀请
+// ═══════════════════════════════════════════════════════════
+
+// GetInvites 获取收到的公会邀请
+func (h *GuildHandler) GetInvites(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	invites, err := h.guildMgr.GetInvites(userID)
+	if err != nil {
+		h.respondGuildError(c, err, "failed to get guild invites")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    invites,
+	})
+}
+
+// Invite 邀请玩家加入公会
+func (h *GuildHandler) Invite(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req GuildPlayerRequest
+	if !bindGuildRequest(c, &req) {
+		return
+	}
+
+	invite, err := h.guildMgr.Invite(userID, req.PlayerName)
+	if err != nil {
+		h.respondGuildError(c, err, "failed to invite player")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    invite,
+		Message: "invite sent",
+	})
+}
+
+// AcceptInvite 接受公会邀请
+func (h *GuildHandler) AcceptInvite(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	guildID, ok := parseGuildParam(c, "guildId")
+	if !ok {
+		return
+	}
+
+	guild, err := h.guildMgr.AcceptInvite(userID, guildID)
+	if err != nil {
+		h.respondGuildError(c, err, "failed to accept invite")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    guild,
+		Message: "joined guild",
+	})
+}
+
+// DeclineInvite 拒绝公会邀请
+func (h *GuildHandler) DeclineInvite(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	guildID, ok := parseGuildParam(c, "guildId")
+	if !ok {
+		return
+	}
+
+	if err := h.guildMgr.DeclineInvite(userID, guildID); err != nil {
+		h.respondGuildError(c, err, "failed to decline invite")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "invite declined",
+	})
+}
+
+// ═══════════════════════════════════════════════════════════
+// 成员、会阶与公告
+// ═══════════════════════════════════════════════════════════
+
+// KickMember 踢出成员
+func (h *GuildHandler) KickMember(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	if err := h.guildMgr.Kick(userID, c.Param("playerName")); err != nil {
+		h.respondGuildError(c, err, "failed to kick member")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "member removed",
+	})
+}
+
+// SetMemberRank 调整成员会阶
+func (h *GuildHandler) SetMemberRank(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req SetGuildRankRequest
+	if !bindGuildRequest(c, &req) {
+		return
+	}
+
+	if err := h.guildMgr.SetMemberRank(userID, c.Param("playerName"), req.RankIndex); err != nil {
+		h.respondGuildError(c, err, "failed to change member rank")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "member rank changed",
+	})
+}
+
+// TransferLeadership 转让会长
+func (h *GuildHandler) TransferLeadership(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req GuildPlayerRequest
+	if !bindGuildRequest(c, &req) {
+		return
+	}
+
+	if err := h.guildMgr.TransferLeadership(userID, req.PlayerName); err != nil {
+		h.respondGuildError(c, err, "failed to transfer leadership")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "leadership transferred",
+	})
+}
+
+// UpdateRank 修改会阶名称、权限和提取额度
+func (h *GuildHandler) UpdateRank(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	rankIndex, ok := parseGuildParam(c, "rankIndex")
+	if !ok {
+		return
+	}
+	var req UpdateGuildRankRequest
+	if !bindGuildRequest(c, &req) {
+		return
+	}
+
+	rank := &models.GuildRank{
+		Index:             rankIndex,
+		Name:              req.Name,
+		Permissions:       req.Permissions,
+		GoldWithdrawLimit: req.GoldWithdrawLimit,
+		ItemWithdrawLimit: req.ItemWithdrawLimit,
+	}
+	if err := h.guildMgr.UpdateRank(userID, rank); err != nil {
+		h.respondGuildError(c, err, "failed to update rank")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    rank,
+		Message: "rank updated",
+	})
+}
+
+// SetMOTD 修改每日公告
+func (h *GuildHandler) SetMOTD(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req SetGuildMOTDRequest
+	if !bindGuildRequest(c, &req) {
+		return
+	}
+
+	if err := h.guildMgr.SetMOTD(userID, req.MOTD); err != nil {
+		h.respondGuildError(c, err, "failed to update message of the day")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "message of the day updated",
+	})
+}
+
+// GetLog 获取公会活动日志（?before= 翻页，?limit= 条数）
+func (h *GuildHandler) GetLog(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	beforeID, _ := strconv.Atoi(c.Query("before"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	entries, err := h.guildMgr.GetLog(userID, beforeID, limit)
+	if err != nil {
+		h.respondGuildError(c, err, "failed to get guild log")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entries,
+	})
+}
+
+// ═══════════════════════════════════════════════════════════
+// 公会银行
+// ═══════════════════════════════════════════════════════════
+
+// GetBank 获取公会银行
+func (h *GuildHandler) GetBank(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	bank, err := h.guildMgr.GetBank(userID)
+	if err != nil {
+		h.respondGuildError(c, err, "failed to get guild bank")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    bank,
+	})
+}
+
+// DepositGold 存入金币
+func (h *GuildHandler) DepositGold(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req GuildGoldRequest
+	if !bindGuildRequest(c, &req) {
+		return
+	}
+
+	if err := h.guildMgr.DepositGold(userID, req.Amount); err != nil {
+		h.respondGuildError(c, err, "failed to deposit gold")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "gold deposited",
+	})
+}
+
+// WithdrawGold 提取金币
+func (h *GuildHandler) WithdrawGold(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req GuildGoldRequest
+	if !bindGuildRequest(c, &req) {
+		return
+	}
+
+	if err := h.guildMgr.WithdrawGold(userID, req.Amount); err != nil {
+		h.respondGuildError(c, err, "failed to withdraw gold")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "gold withdrawn",
+	})
+}
+
+// DepositItem 存入装备
+func (h *GuildHandler) DepositItem(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req GuildItemRequest
+	if !bindGuildRequest(c, &req) {
+		return
+	}
+
+	if err := h.guildMgr.DepositItem(userID, req.EquipmentID); err != nil {
+		h.respondGuildError(c, err, "failed to deposit item")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "item deposited",
+	})
+}
+
+// WithdrawItem 提取装备
+func (h *GuildHandler) WithdrawItem(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	equipmentID, ok := parseGuildParam(c, "equipmentId")
+	if !ok {
+		return
+	}
+
+	if err := h.guildMgr.WithdrawItem(userID, equipmentID); err != nil {
+		h.respondGuildError(c, err, "failed to withdraw item")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "item withdrawn",
+	})
+}
+
+// ═══════════════════════════════════════════════════════════
+// 辅助函数
+// ═══════════════════════════════════════════════════════════
+
+// bindGuildRequest 解析请求体（失败时已写入响应）
+func bindGuildRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// parseGuildParam 解析路径中的整数参数（失败时已写入响应）
+func parseGuildParam(c *gin.Context, param string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid " + param,
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondGuildError 将公会错误映射为HTTP响应
+func (h *GuildHandler) respondGuildError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, game.ErrNotInGuild),
+		errors.Is(err, game.ErrGuildPlayerNotFound),
+		errors.Is(err, game.ErrGuildInviteNotFound),
+		errors.Is(err, game.ErrGuildTargetNotMember),
+		errors.Is(err, repository.ErrGuildNotFound),
+		errors.Is(err, repository.ErrGuildBankItemNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, game.ErrGuildPermissionDenied),
+		errors.Is(err, game.ErrGuildRankTooHigh),
+		errors.Is(err, game.ErrGuildEnemyFaction),
+		errors.Is(err, repository.ErrEquipmentNotOwned):
+		status, message = http.StatusForbidden, err.Error()
+	case errors.Is(err, game.ErrAlreadyInGuild),
+		errors.Is(err, game.ErrGuildNameTaken),
+		errors.Is(err, game.ErrGuildTargetInGuild):
+		status, message = http.StatusConflict, err.Error()
+	case errors.Is(err, game.ErrGuildWithdrawLimit):
+		status, message = http.StatusTooManyRequests, err.Error()
+	case errors.Is(err, game.ErrInvalidGuildName),
+		errors.Is(err, game.ErrGuildNoCharacter),
+		errors.Is(err, game.ErrGuildTargetSelf),
+		errors.Is(err, game.ErrGuildFull),
+		errors.Is(err, game.ErrGuildInvalidRank),
+		errors.Is(err, game.ErrGuildLeaderCannotLeave),
+		errors.Is(err, game.ErrGuildMOTDTooLong),
+		errors.Is(err, game.ErrInvalidGuildGold),
+		errors.Is(err, repository.ErrGuildBankInsufficientGold),
+		errors.Is(err, repository.ErrInsufficientGold),
+		errors.Is(err, repository.ErrEquipmentEquipped),
+		errors.Is(err, repository.ErrEquipmentLocked),
+		errors.Is(err, repository.ErrEquipmentInEscrow):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
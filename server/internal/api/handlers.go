@@ -26,6 +26,9 @@ type Handler struct {
 	skillRepo       *repository.SkillRepository
 	skillService    *service.SkillService
 	battleStatsRepo *repository.BattleStatsRepository
+	sessionRepo     *repository.SessionRepository
+	adminRepo       *repository.AdminRepository
+	streamTickets   *auth.StreamTicketStore
 }
 
 // NewHandler 创建处理器
@@ -39,6 +42,9 @@ func NewHandler() *Handler {
 		skillRepo:       skillRepo,
 		skillService:    skillService,
 		battleStatsRepo: repository.NewBattleStatsRepository(),
+		sessionRepo:     repository.NewSessionRepository(),
+		adminRepo:       repository.NewAdminRepository(),
+		streamTickets:   auth.NewStreamTicketStore(),
 	}
 }
 
@@ -82,9 +88,89 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 会话已注销（登出/下线全部设备）或已过期时令牌立即失效
+		active, err := h.sessionValid(claims.SessionID, claims.UserID, time.Now())
+		if err != nil || !active {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Error:   "session revoked or expired",
+			})
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存入context
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("sessionID", claims.SessionID)
+		c.Next()
+	}
+}
+
+// sessionValid 令牌绑定的会话是否仍有效
+// 会话机制上线前签发的令牌没有 sid，在令牌自身有效期内继续放行（账号封禁仍立即生效），过期后需重新登录
+func (h *Handler) sessionValid(sessionID string, userID int, now time.Time) (bool, error) {
+	if sessionID != "" {
+		return h.sessionRepo.IsActive(sessionID, userID, now)
+	}
+	ban, err := h.adminRepo.GetActiveBan(userID, now)
+	if err != nil {
+		return false, err
+	}
+	return ban == nil, nil
+}
+
+// RequireRole 角色权限中间件（需在 AuthMiddleware 之后），角色每次从数据库读取以便立即生效
+func (h *Handler) RequireRole(required string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, err := h.userRepo.GetRole(c.GetInt("userID"))
+		if err != nil || !models.RoleAtLeast(role, required) {
+			c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Error:   "insufficient permissions",
+			})
+			c.Abort()
+			return
+		}
+		c.Set("role", role)
+		c.Next()
+	}
+}
+
+// StreamAuthMiddleware 实时推送连接的认证中间件
+// 浏览器的 WebSocket/EventSource 无法设置请求头，通过 ticket 查询参数传递一次性票据（POST /api/stream/ticket 签发），
+// 不接受查询参数中的访问令牌；能设置请求头的客户端仍可使用 Authorization
+func (h *Handler) StreamAuthMiddleware() gin.HandlerFunc {
+	authenticate := h.AuthMiddleware()
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if c.GetHeader("Authorization") != "" || ticket == "" {
+			authenticate(c)
+			return
+		}
+
+		now := time.Now()
+		claims, err := h.streamTickets.Redeem(ticket, now)
+		if err == nil {
+			// 票据签发后会话可能已注销
+			var active bool
+			active, err = h.sessionValid(claims.SessionID, claims.UserID, now)
+			if err == nil && !active {
+				err = auth.ErrInvalidStreamTicket
+			}
+		}
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Error:   auth.ErrInvalidStreamTicket.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Set("sessionID", claims.SessionID)
 		c.Next()
 	}
 }
@@ -143,8 +229,8 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	// 生成token
-	token, err := auth.GenerateToken(user.ID, user.Username)
+	// 创建登录会话并签发令牌
+	resp, err := h.issueSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -156,10 +242,7 @@ func (h *Handler) Register(c *gin.Context) {
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "registration successful",
-		Data: models.AuthResponse{
-			Token: token,
-			User:  *user,
-		},
+		Data:    resp,
 	})
 }
 
@@ -178,6 +261,8 @@ func (h *Handler) Login(c *gin.Context) {
 	userID, passwordHash, err := h.userRepo.GetPasswordHash(req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
+			// 仍执行一次密码比较，避免通过响应时间判断用户名是否存在
+			auth.SimulatePasswordCheck(req.Password)
 			c.JSON(http.StatusUnauthorized, models.APIResponse{
 				Success: false,
 				Error:   "invalid username or password",
@@ -200,6 +285,27 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	// 账号封禁期间禁止登录
+	ban, err := h.adminRepo.GetActiveBan(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "database error",
+		})
+		return
+	}
+	if ban != nil {
+		message := "account banned"
+		if ban.ExpiresAt != nil {
+			message += " until " + ban.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
 	// 更新最后登录时间
 	h.userRepo.UpdateLastLogin(userID)
 
@@ -213,8 +319,8 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	// 生成token
-	token, err := auth.GenerateToken(user.ID, user.Username)
+	// 创建登录会话并签发令牌
+	resp, err := h.issueSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -226,10 +332,7 @@ func (h *Handler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "login successful",
-		Data: models.AuthResponse{
-			Token: token,
-			User:  *user,
-		},
+		Data:    resp,
 	})
 }
 
@@ -1509,3 +1612,50 @@ func (h *Handler) GetBattleDPSAnalysis(c *gin.Context) {
 		Data:    analysis,
 	})
 }
+
+// GetBattleThreatAnalysis 获取单场战斗仇恨分析（仇恨时间线与OT汇总）
+func (h *Handler) GetBattleThreatAnalysis(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	battleIDStr := c.Param("battleId")
+	battleID, err := strconv.Atoi(battleIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid battle id",
+		})
+		return
+	}
+
+	// 验证战斗记录的所有权
+	battle, err := h.battleStatsRepo.GetBattleRecordByID(battleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "battle not found",
+		})
+		return
+	}
+
+	// 验证战斗是否属于当前用户
+	if battle.UserID != userID.(int) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Error:   "forbidden",
+		})
+		return
+	}
+
+	analysis, err := h.battleStatsRepo.GetBattleThreatAnalysis(battleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get battle threat analysis: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    analysis,
+	})
+}
@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"text-wow/internal/auth"
 	"text-wow/internal/database"
+	"text-wow/internal/game"
 	"text-wow/internal/models"
+	"text-wow/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
@@ -47,7 +52,8 @@ func setupRoutes(router *gin.Engine, handler *Handler) {
 	{
 		// 公开接口
 		api.POST("/auth/register", handler.Register)
-		api.POST("/auth/login", handler.Login)
+		api.POST("/auth/login", LoginRateLimit(game.NewLoginLimiter(game.DefaultLoginLimiterConfig()), "username"), handler.Login)
+		api.POST("/auth/refresh", handler.RefreshToken)
 		api.GET("/races", handler.GetRaces)
 		api.GET("/classes", handler.GetClasses)
 
@@ -58,6 +64,16 @@ func setupRoutes(router *gin.Engine, handler *Handler) {
 			protected.GET("/user", handler.GetCurrentUser)
 			protected.GET("/characters", handler.GetCharacters)
 			protected.POST("/characters", handler.CreateCharacter)
+			protected.POST("/auth/logout", handler.Logout)
+			protected.POST("/auth/logout-all", handler.LogoutAll)
+			protected.POST("/stream/ticket", handler.IssueStreamTicket)
+		}
+
+		// 实时推送认证（以获取当前用户代替长连接）
+		stream := api.Group("/stream")
+		stream.Use(handler.StreamAuthMiddleware())
+		{
+			stream.GET("/user", handler.GetCurrentUser)
 		}
 	}
 }
@@ -520,3 +536,312 @@ func TestHandler_TokenContainsCorrectClaims(t *testing.T) {
 	}
 }
 
+// ═══════════════════════════════════════════════════════════
+// 刷新令牌与会话注销测试
+// ═══════════════════════════════════════════════════════════
+
+func parseAuthResponse(t *testing.T, w *httptest.ResponseRecorder) models.AuthResponse {
+	var response struct {
+		Data models.AuthResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse auth response: %v", err)
+	}
+	return response.Data
+}
+
+func TestHandler_RefreshToken_Rotation(t *testing.T) {
+	_, router, cleanup := setupHandlerTest(t)
+	defer cleanup()
+
+	w := makeRequest(router, "POST", "/api/auth/register", models.UserRegister{
+		Username: "refreshuser",
+		Password: "password123",
+	})
+	first := parseAuthResponse(t, w)
+	if first.RefreshToken == "" || first.ExpiresIn != int(auth.AccessTokenTTL.Seconds()) {
+		t.Fatalf("Expected refresh token and access token lifetime, got %+v", first)
+	}
+
+	// 刷新后得到新的访问令牌和新的刷新令牌
+	w = makeRequest(router, "POST", "/api/auth/refresh", RefreshTokenRequest{RefreshToken: first.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	second := parseAuthResponse(t, w)
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("Refresh token should rotate")
+	}
+	if w := makeAuthRequest(router, "GET", "/api/user", second.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("Refreshed access token should work, got %d", w.Code)
+	}
+
+	// 旧刷新令牌被重复使用时注销整个会话
+	w = makeRequest(router, "POST", "/api/auth/refresh", RefreshTokenRequest{RefreshToken: first.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for reused refresh token, got %d", w.Code)
+	}
+	if w := makeAuthRequest(router, "GET", "/api/user", second.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Access token of a revoked session should be rejected, got %d", w.Code)
+	}
+	w = makeRequest(router, "POST", "/api/auth/refresh", RefreshTokenRequest{RefreshToken: second.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for revoked session, got %d", w.Code)
+	}
+}
+
+func TestHandler_Logout(t *testing.T) {
+	_, router, cleanup := setupHandlerTest(t)
+	defer cleanup()
+
+	credentials := models.UserCredentials{Username: "logoutuser", Password: "password123"}
+	makeRequest(router, "POST", "/api/auth/register", models.UserRegister{
+		Username: credentials.Username,
+		Password: credentials.Password,
+	})
+	phone := parseAuthResponse(t, makeRequest(router, "POST", "/api/auth/login", credentials))
+	laptop := parseAuthResponse(t, makeRequest(router, "POST", "/api/auth/login", credentials))
+	tablet := parseAuthResponse(t, makeRequest(router, "POST", "/api/auth/login", credentials))
+
+	// 注销当前会话只影响该设备
+	if w := makeAuthRequest(router, "POST", "/api/auth/logout", phone.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := makeAuthRequest(router, "GET", "/api/user", phone.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Logged out token should be rejected, got %d", w.Code)
+	}
+	if w := makeRequest(router, "POST", "/api/auth/refresh", RefreshTokenRequest{RefreshToken: phone.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Logged out refresh token should be rejected, got %d", w.Code)
+	}
+	if w := makeAuthRequest(router, "GET", "/api/user", laptop.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("Other sessions should stay valid, got %d", w.Code)
+	}
+
+	// 注销所有设备
+	w := makeAuthRequest(router, "POST", "/api/auth/logout-all", laptop.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	for _, session := range []models.AuthResponse{laptop, tablet} {
+		if w := makeAuthRequest(router, "GET", "/api/user", session.Token, nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("All sessions should be revoked, got %d", w.Code)
+		}
+	}
+}
+
+func TestHandler_LegacyTokenWithoutSession(t *testing.T) {
+	_, router, cleanup := setupHandlerTest(t)
+	defer cleanup()
+
+	registered := parseAuthResponse(t, makeRequest(router, "POST", "/api/auth/register", models.UserRegister{
+		Username: "legacyuser",
+		Password: "password123",
+	}))
+
+	// 会话机制上线前签发的令牌没有 sid，在有效期内继续可用
+	legacy, err := auth.GenerateToken(registered.User.ID, registered.User.Username, "")
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if w := makeAuthRequest(router, "GET", "/api/user", legacy, nil); w.Code != http.StatusOK {
+		t.Fatalf("Legacy token should be accepted, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := makeAuthRequest(router, "POST", "/api/auth/logout", legacy, nil); w.Code != http.StatusOK {
+		t.Errorf("Logout with a legacy token should succeed, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// 账号封禁对没有会话的令牌同样立即生效
+	_, err = repository.NewAdminRepository().CreateBan(&models.AccountBan{
+		UserID:    registered.User.ID,
+		Reason:    "test",
+		IssuedBy:  registered.User.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("CreateBan failed: %v", err)
+	}
+	if w := makeAuthRequest(router, "GET", "/api/user", legacy, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Legacy token of a banned account should be rejected, got %d", w.Code)
+	}
+}
+
+func TestHandler_StreamTicket(t *testing.T) {
+	_, router, cleanup := setupHandlerTest(t)
+	defer cleanup()
+
+	credentials := models.UserCredentials{Username: "streamuser", Password: "password123"}
+	makeRequest(router, "POST", "/api/auth/register", models.UserRegister{
+		Username: credentials.Username,
+		Password: credentials.Password,
+	})
+	session := parseAuthResponse(t, makeRequest(router, "POST", "/api/auth/login", credentials))
+
+	issueTicket := func() string {
+		w := makeAuthRequest(router, "POST", "/api/stream/ticket", session.Token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data models.StreamTicketResponse `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data.Ticket == "" {
+			t.Fatalf("Failed to parse ticket: %v. Body: %s", err, w.Body.String())
+		}
+		return resp.Data.Ticket
+	}
+
+	// 访问令牌不能放在查询参数中
+	if w := makeRequest(router, "GET", "/api/stream/user?token="+session.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Token query parameter should be rejected, got %d", w.Code)
+	}
+
+	// 票据只能使用一次
+	ticket := issueTicket()
+	if w := makeRequest(router, "GET", "/api/stream/user?ticket="+ticket, nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := makeRequest(router, "GET", "/api/stream/user?ticket="+ticket, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Reused ticket should be rejected, got %d", w.Code)
+	}
+
+	// 会话注销后未使用的票据同时失效
+	ticket = issueTicket()
+	makeAuthRequest(router, "POST", "/api/auth/logout", session.Token, nil)
+	if w := makeRequest(router, "GET", "/api/stream/user?ticket="+ticket, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Ticket of a revoked session should be rejected, got %d", w.Code)
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	cases := map[string]string{
+		"/api/stream/ws":                     "/api/stream/ws",
+		"/api/stream/ws?ticket=abc":          "/api/stream/ws?ticket=REDACTED",
+		"/api/stream/events?token=a.b.c&x=1": "/api/stream/events?token=REDACTED&x=1",
+		"/api/leaderboards?season=3":         "/api/leaderboards?season=3",
+	}
+	for path, expected := range cases {
+		if got := redactQuery(path); got != expected {
+			t.Errorf("redactQuery(%q) = %q, want %q", path, got, expected)
+		}
+	}
+}
+
+// ═══════════════════════════════════════════════════════════
+// 角色权限与账号封禁测试
+// ═══════════════════════════════════════════════════════════
+
+func TestHandler_RequireRoleAndBannedLogin(t *testing.T) {
+	handler, router, cleanup := setupHandlerTest(t)
+	defer cleanup()
+	router.GET("/api/gm-only", handler.AuthMiddleware(), handler.RequireRole(models.RoleGameMaster), func(c *gin.Context) {
+		c.JSON(http.StatusOK, models.APIResponse{Success: true})
+	})
+
+	credentials := models.UserCredentials{Username: "gmuser", Password: "password123"}
+	session := parseAuthResponse(t, makeRequest(router, "POST", "/api/auth/register", models.UserRegister{
+		Username: credentials.Username,
+		Password: credentials.Password,
+	}))
+
+	if w := makeAuthRequest(router, "GET", "/api/gm-only", session.Token, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for player, got %d", w.Code)
+	}
+	// 角色变更立即生效，无需重新登录
+	handler.userRepo.SetRole(session.User.ID, models.RoleAdmin)
+	if w := makeAuthRequest(router, "GET", "/api/gm-only", session.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for admin, got %d", w.Code)
+	}
+
+	// 封禁期间无法登录，解封后恢复
+	expiresAt := time.Now().Add(time.Hour)
+	ban, err := handler.adminRepo.CreateBan(&models.AccountBan{
+		UserID: session.User.ID, IssuedBy: session.User.ID, CreatedAt: time.Now(), ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create ban: %v", err)
+	}
+	w := makeRequest(router, "POST", "/api/auth/login", credentials)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for banned account, got %d", w.Code)
+	}
+	if response := parseResponse(w); !strings.HasPrefix(response.Error, "account banned until") {
+		t.Errorf("Expected ban message, got '%s'", response.Error)
+	}
+	handler.adminRepo.RevokeBans(ban.UserID, time.Now())
+	if w := makeRequest(router, "POST", "/api/auth/login", credentials); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 after unban, got %d", w.Code)
+	}
+}
+
+func TestHandler_LoginRateLimit(t *testing.T) {
+	_, router, cleanup := setupHandlerTest(t)
+	defer cleanup()
+
+	credentials := models.UserCredentials{Username: "lockme", Password: "password123"}
+	makeRequest(router, "POST", "/api/auth/register", credentials)
+
+	// 用户名不存在与密码错误的响应完全一致
+	unknown := makeRequest(router, "POST", "/api/auth/login", models.UserCredentials{Username: "ghost", Password: "password123"})
+	wrong := makeRequest(router, "POST", "/api/auth/login", models.UserCredentials{Username: "lockme", Password: "wrongpass"})
+	if unknown.Code != http.StatusUnauthorized || wrong.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d and %d", unknown.Code, wrong.Code)
+	}
+	if parseResponse(unknown).Error != parseResponse(wrong).Error {
+		t.Errorf("Expected identical errors, got '%s' and '%s'", parseResponse(unknown).Error, parseResponse(wrong).Error)
+	}
+
+	// 窗口内失败次数达到上限后用户名被锁定，正确密码也被拒绝
+	for i := 0; i < game.DefaultLoginLimiterConfig().MaxUsernameFailures-1; i++ {
+		makeRequest(router, "POST", "/api/auth/login", models.UserCredentials{Username: "LockMe", Password: "wrongpass"})
+	}
+	w := makeRequest(router, "POST", "/api/auth/login", credentials)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected Retry-After 60, got '%s'", w.Header().Get("Retry-After"))
+	}
+
+	// 其他用户名不受影响（同一IP未达到上限）
+	if w := makeRequest(router, "POST", "/api/auth/login", models.UserCredentials{Username: "ghost", Password: "password123"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for other username, got %d", w.Code)
+	}
+}
+
+func TestHandler_LoginRateLimitConcurrentBurst(t *testing.T) {
+	_, router, cleanup := setupHandlerTest(t)
+	defer cleanup()
+	// 内存数据库每个连接相互独立，并发请求需共用同一连接
+	database.DB.SetMaxOpenConns(1)
+
+	makeRequest(router, "POST", "/api/auth/register", models.UserCredentials{Username: "burst", Password: "password123"})
+
+	// 并发的错误密码请求在密码校验期间同时到达，超出上限的请求不会进入密码校验
+	const requests = 30
+	codes := make(chan int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- makeRequest(router, "POST", "/api/auth/login", models.UserCredentials{Username: "burst", Password: "wrongpass"}).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	maxFailures := game.DefaultLoginLimiterConfig().MaxUsernameFailures
+	if counts[http.StatusUnauthorized] != maxFailures || counts[http.StatusTooManyRequests] != requests-maxFailures {
+		t.Fatalf("Expected %d x 401 and %d x 429, got %v", maxFailures, requests-maxFailures, counts)
+	}
+
+	// 上限内的失败结算后用户名被锁定，正确密码也被拒绝
+	w := makeRequest(router, "POST", "/api/auth/login", models.UserCredentials{Username: "burst", Password: "password123"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", w.Code)
+	}
+}
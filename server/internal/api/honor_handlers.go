@@ -0,0 +1,163 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HonorHandler 荣誉军衔与荣誉商店API处理器
+type HonorHandler struct {
+	honorMgr *game.HonorManager
+}
+
+// NewHonorHandler 创建荣誉处理器
+func NewHonorHandler() *HonorHandler {
+	return &HonorHandler{
+		honorMgr: game.GetBattleManager().GetHonorManager(),
+	}
+}
+
+// GetStandings 获取军衔排名
+func (h *HonorHandler) GetStandings(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	faction := c.Query("faction")
+	if faction != "" && faction != "alliance" && faction != "horde" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid faction",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	standings, position, err := h.honorMgr.GetStandings(userID, faction, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get honor standings",
+		})
+		return
+	}
+
+	honor, err := h.honorMgr.GetHonor(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get honor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"standings": standings,
+			"position":  position,
+			"honor":     honor,
+		},
+	})
+}
+
+// GetShop 获取荣誉商店商品列表
+func (h *HonorHandler) GetShop(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	items, err := h.honorMgr.GetShop(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get honor shop",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    items,
+	})
+}
+
+// HonorPurchaseRequest 荣誉商店购买请求
+type HonorPurchaseRequest struct {
+	CharacterID int `json:"characterId"` // 接收消耗品的角色（可选）
+}
+
+// Purchase 购买荣誉商店商品
+func (h *HonorHandler) Purchase(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req HonorPurchaseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "invalid request: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	purchase, err := h.honorMgr.Purchase(userID, c.Param("shopItemId"), req.CharacterID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "failed to purchase item"
+		switch {
+		case errors.Is(err, repository.ErrHonorShopItemNotFound):
+			status, message = http.StatusNotFound, err.Error()
+		case errors.Is(err, repository.ErrHonorFactionMismatch),
+			errors.Is(err, repository.ErrHonorRankTooLow),
+			errors.Is(err, repository.ErrInsufficientHonor),
+			errors.Is(err, repository.ErrHonorWeeklyLimit),
+			errors.Is(err, repository.ErrHonorOutOfStock),
+			errors.Is(err, repository.ErrHonorNoCharacter):
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		c.JSON(status, models.APIResponse{
+			Success: false,
+			Error:   message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    purchase,
+		Message: "purchase successful",
+	})
+}
+
+// GetPurchases 获取荣誉商店购买历史
+func (h *HonorHandler) GetPurchases(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	purchases, err := h.honorMgr.GetPurchases(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get purchase history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    purchases,
+	})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LeaderboardHandler 排行榜API处理器
+type LeaderboardHandler struct {
+	leaderboardMgr *game.LeaderboardManager
+}
+
+// NewLeaderboardHandler 创建排行榜处理器
+func NewLeaderboardHandler() *LeaderboardHandler {
+	return &LeaderboardHandler{
+		leaderboardMgr: game.GetLeaderboardManager(),
+	}
+}
+
+// GetBoards 获取全部排行榜定义与赛季列表
+func (h *LeaderboardHandler) GetBoards(c *gin.Context) {
+	seasons, err := h.leaderboardMgr.GetSeasons()
+	if err != nil {
+		h.respondLeaderboardError(c, err, "failed to get seasons")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"boards":  h.leaderboardMgr.Boards(),
+			"seasons": seasons,
+		},
+	})
+}
+
+// GetLeaderboard 获取排行榜（支持 season/faction/class 筛选与分页）
+func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	board, err := h.leaderboardMgr.GetLeaderboard(leaderboardQuery(c), limit, offset)
+	if err != nil {
+		h.respondLeaderboardError(c, err, "failed to get leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    board,
+	})
+}
+
+// GetMyPosition 获取自己的排名及前后相邻玩家
+func (h *LeaderboardHandler) GetMyPosition(c *gin.Context) {
+	userID := c.GetInt("userID")
+	neighbours, _ := strconv.Atoi(c.Query("neighbours"))
+
+	position, err := h.leaderboardMgr.GetPosition(userID, leaderboardQuery(c), neighbours)
+	if err != nil {
+		h.respondLeaderboardError(c, err, "failed to get leaderboard position")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    position,
+	})
+}
+
+// leaderboardQuery 从路径和查询参数构造排行榜查询条件
+func leaderboardQuery(c *gin.Context) *game.LeaderboardQuery {
+	seasonID, _ := strconv.Atoi(c.Query("season"))
+	return &game.LeaderboardQuery{
+		Board:    c.Param("board"),
+		SeasonID: seasonID,
+		Faction:  c.Query("faction"),
+		ClassID:  c.Query("class"),
+	}
+}
+
+// respondLeaderboardError 将排行榜错误映射为HTTP响应
+func (h *LeaderboardHandler) respondLeaderboardError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, game.ErrLeaderboardNotFound),
+		errors.Is(err, repository.ErrLeaderboardSeasonNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, game.ErrInvalidLeaderboardFilter):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
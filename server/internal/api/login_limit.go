@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loginBodyMaxBytes 登录请求体读取上限
+const loginBodyMaxBytes = 16 << 10
+
+// LoginRateLimit 登录防护中间件：处理前预占一次尝试，锁定或额度已被并发请求占满时直接返回429，处理完成后按响应状态结算
+// usernameField 为请求JSON中用户名字段名；200 视为成功，401 视为凭据错误，403 视为被拒绝，其余状态不计入
+func LoginRateLimit(limiter *game.LoginLimiter, usernameField string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, loginBodyMaxBytes+1))
+		if err != nil || len(body) > loginBodyMaxBytes {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "invalid request body",
+			})
+			c.Abort()
+			return
+		}
+		// 还原请求体供后续处理器绑定
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var fields map[string]interface{}
+		_ = json.Unmarshal(body, &fields)
+		username, _ := fields[usernameField].(string)
+		ip := c.ClientIP()
+		userAgent := c.Request.UserAgent()
+		if len(userAgent) > sessionUserAgentMaxLength {
+			userAgent = userAgent[:sessionUserAgentMaxLength]
+		}
+
+		attemptID, retryAfter, err := limiter.Begin(ip, username, userAgent, time.Now())
+		if errors.Is(err, game.ErrLoginLocked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, models.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   "database error",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		var result string
+		switch c.Writer.Status() {
+		case http.StatusOK:
+			result = models.LoginResultSuccess
+		case http.StatusUnauthorized:
+			result = models.LoginResultFailure
+		case http.StatusForbidden:
+			result = models.LoginResultDenied
+		}
+		// 响应已发出，结算失败只记录日志（未结算的预占超时后不再计入）
+		if err := limiter.Finish(attemptID, ip, username, result, time.Now()); err != nil {
+			fmt.Printf("[ERROR] Failed to record login attempt: %v\n", err)
+		}
+	}
+}
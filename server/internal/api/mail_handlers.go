@@ -0,0 +1,200 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MailHandler 邮箱API处理器
+type MailHandler struct {
+	mailMgr *game.MailManager
+}
+
+// NewMailHandler 创建邮箱处理器
+func NewMailHandler() *MailHandler {
+	return &MailHandler{
+		mailMgr: game.GetMailManager(),
+	}
+}
+
+// SendMailRequest 发送邮件请求
+type SendMailRequest struct {
+	Recipient    string `json:"recipient" binding:"required"`
+	Subject      string `json:"subject" binding:"required"`
+	Body         string `json:"body"`
+	Gold         int    `json:"gold"`
+	EquipmentIDs []int  `json:"equipmentIds"`
+	CODAmount    int    `json:"codAmount"` // 货到付款金额
+}
+
+// GetMailbox 获取收件箱
+func (h *MailHandler) GetMailbox(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	mails, err := h.mailMgr.GetMailbox(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get mailbox",
+		})
+		return
+	}
+	unread, err := h.mailMgr.CountUnread(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get mailbox",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"mails":  mails,
+			"unread": unread,
+		},
+	})
+}
+
+// SendMail 发送邮件
+func (h *MailHandler) SendMail(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req SendMailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	mail, err := h.mailMgr.SendMail(userID, req.Recipient, req.Subject, req.Body, req.Gold, req.EquipmentIDs, req.CODAmount)
+	if err != nil {
+		h.respondMailError(c, err, "failed to send mail")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    mail,
+		Message: "mail sent",
+	})
+}
+
+// ReadMail 阅读邮件
+func (h *MailHandler) ReadMail(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	mailID, ok := h.parseMailID(c)
+	if !ok {
+		return
+	}
+
+	mail, err := h.mailMgr.ReadMail(userID, mailID)
+	if err != nil {
+		h.respondMailError(c, err, "failed to read mail")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    mail,
+	})
+}
+
+// ClaimMail 领取邮件附件
+func (h *MailHandler) ClaimMail(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	mailID, ok := h.parseMailID(c)
+	if !ok {
+		return
+	}
+
+	mail, err := h.mailMgr.ClaimMail(userID, mailID)
+	if err != nil {
+		h.respondMailError(c, err, "failed to claim mail")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    mail,
+		Message: "attachments claimed",
+	})
+}
+
+// DeleteMail 删除邮件
+func (h *MailHandler) DeleteMail(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	mailID, ok := h.parseMailID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.mailMgr.DeleteMail(userID, mailID); err != nil {
+		h.respondMailError(c, err, "failed to delete mail")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "mail deleted",
+	})
+}
+
+// parseMailID 解析路径中的邮件ID
+func (h *MailHandler) parseMailID(c *gin.Context) (int, bool) {
+	mailID, err := strconv.Atoi(c.Param("mailId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid mail ID",
+		})
+		return 0, false
+	}
+	return mailID, true
+}
+
+// respondMailError 将邮箱错误映射为HTTP响应
+func (h *MailHandler) respondMailError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, repository.ErrMailNotFound),
+		errors.Is(err, game.ErrMailRecipientNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, game.ErrMailNotRecipient),
+		errors.Is(err, repository.ErrEquipmentNotOwned):
+		status, message = http.StatusForbidden, err.Error()
+	case errors.Is(err, repository.ErrMailNotActive),
+		errors.Is(err, repository.ErrMailExpired),
+		errors.Is(err, repository.ErrMailAlreadyClaimed),
+		errors.Is(err, repository.ErrMailHasAttachments),
+		errors.Is(err, repository.ErrEquipmentEquipped),
+		errors.Is(err, repository.ErrEquipmentLocked),
+		errors.Is(err, repository.ErrEquipmentInEscrow),
+		errors.Is(err, repository.ErrInsufficientGold),
+		errors.Is(err, game.ErrMailToSelf),
+		errors.Is(err, game.ErrInvalidMailSubject),
+		errors.Is(err, game.ErrMailBodyTooLong),
+		errors.Is(err, game.ErrTooManyAttachments),
+		errors.Is(err, game.ErrInvalidMailGold),
+		errors.Is(err, game.ErrCODWithoutAttachment):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
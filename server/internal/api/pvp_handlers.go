@@ -0,0 +1,119 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PvPHandler 阵营PVP API处理器
+type PvPHandler struct {
+	pvpMgr *game.PvPManager
+}
+
+// NewPvPHandler 创建PVP处理器
+func NewPvPHandler() *PvPHandler {
+	return &PvPHandler{
+		pvpMgr: game.GetBattleManager().GetPvPManager(),
+	}
+}
+
+// GetZoneControls 获取争夺区域的阵营控制状态
+func (h *PvPHandler) GetZoneControls(c *gin.Context) {
+	controls, err := h.pvpMgr.GetZoneControls()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get zone control",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    controls,
+	})
+}
+
+// GetEncounters 获取玩家的PVP遭遇战记录
+func (h *PvPHandler) GetEncounters(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	encounters, err := h.pvpMgr.GetEncounters(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get pvp encounters",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    encounters,
+	})
+}
+
+// GetEncounter 获取遭遇战详情（含战斗日志，仅参战双方可见）
+func (h *PvPHandler) GetEncounter(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	encounterID, err := strconv.Atoi(c.Param("encounterId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid encounter id",
+		})
+		return
+	}
+
+	encounter, err := h.pvpMgr.GetEncounter(encounterID)
+	if err == sql.ErrNoRows || (err == nil && encounter.AttackerUserID != userID && encounter.DefenderUserID != userID) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "encounter not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get encounter",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    encounter,
+	})
+}
+
+// GetHonor 获取玩家荣誉战绩
+func (h *PvPHandler) GetHonor(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	honor, err := h.pvpMgr.GetHonor(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get honor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    honor,
+	})
+}
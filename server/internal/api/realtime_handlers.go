@@ -0,0 +1,178 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	realtimePingInterval   = 30 * time.Second // 保活间隔（同时刷新在线状态的最后活跃时间）
+	realtimeWriteTimeout   = 10 * time.Second // 单个事件的写超时，超时视为连接已断开
+	realtimeMaxClientFrame = 4096             // 客户端上行消息仅用于保活，限制大小
+)
+
+// RealtimeHandler 实时推送处理器（WebSocket，SSE 作为降级方案）
+type RealtimeHandler struct {
+	hub      *game.EventHub
+	userRepo *repository.UserRepository
+	charRepo *repository.CharacterRepository
+}
+
+// NewRealtimeHandler 创建实时推送处理器
+func NewRealtimeHandler() *RealtimeHandler {
+	return &RealtimeHandler{
+		hub:      game.GetEventHub(),
+		userRepo: repository.NewUserRepository(),
+		charRepo: repository.NewCharacterRepository(),
+	}
+}
+
+// presenceInfo 连接注册所需的玩家信息
+type presenceInfo struct {
+	userID  int
+	name    string
+	faction string
+	zoneID  string
+}
+
+// WebSocket 建立 WebSocket 推送连接
+func (h *RealtimeHandler) WebSocket(c *gin.Context) {
+	info, ok := h.lookupPresence(c)
+	if !ok {
+		return
+	}
+
+	// 连接已通过票据或访问令牌认证（不依赖 Cookie），无需校验 Origin
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = realtimeMaxClientFrame
+			h.serveWebSocket(ws, info)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// Events 建立 SSE 推送连接（不支持 WebSocket 时使用）
+func (h *RealtimeHandler) Events(c *gin.Context) {
+	info, ok := h.lookupPresence(c)
+	if !ok {
+		return
+	}
+
+	sub := h.hub.Subscribe(info.userID, info.name, info.faction, info.zoneID)
+	defer h.hub.Unsubscribe(sub)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(realtimePingInterval)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-sub.Events():
+			c.SSEvent(event.Type, event)
+			return true
+		case <-ticker.C:
+			h.hub.KeepAlive(sub)
+			c.SSEvent(game.EventPing, pingEvent())
+			return true
+		case <-sub.Done():
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// serveWebSocket 推送事件直到客户端断开或被服务端断开
+func (h *RealtimeHandler) serveWebSocket(ws *websocket.Conn, info *presenceInfo) {
+	defer ws.Close()
+
+	sub := h.hub.Subscribe(info.userID, info.name, info.faction, info.zoneID)
+	defer h.hub.Unsubscribe(sub)
+
+	// 读循环：内容忽略，读取失败即客户端已断开（控制帧由库自动应答）
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var msg string
+		for {
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(realtimePingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-sub.Events():
+			if !sendWebSocketEvent(ws, event) {
+				return
+			}
+		case <-ticker.C:
+			h.hub.KeepAlive(sub)
+			if !sendWebSocketEvent(ws, pingEvent()) {
+				return
+			}
+		case <-sub.Done():
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
+// lookupPresence 查询当前用户的玩家名、阵营（第一个角色）与所在区域（失败时已写入响应）
+func (h *RealtimeHandler) lookupPresence(c *gin.Context) (*presenceInfo, bool) {
+	userID := c.GetInt("userID")
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil || user == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "user not found",
+		})
+		return nil, false
+	}
+
+	info := &presenceInfo{
+		userID: userID,
+		name:   user.Username,
+		zoneID: user.CurrentZoneID,
+	}
+	if chars, err := h.charRepo.GetByUserID(userID); err == nil && len(chars) > 0 {
+		info.faction = chars[0].Faction
+	}
+	if info.zoneID == "" {
+		info.zoneID = "elwynn"
+	}
+	return info, true
+}
+
+// sendWebSocketEvent 以 JSON 文本帧发送事件，失败返回 false
+func sendWebSocketEvent(ws *websocket.Conn, event *models.RealtimeEvent) bool {
+	if err := ws.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout)); err != nil {
+		return false
+	}
+	return websocket.JSON.Send(ws, event) == nil
+}
+
+// pingEvent 保活事件
+func pingEvent() *models.RealtimeEvent {
+	return &models.RealtimeEvent{
+		Type:      game.EventPing,
+		Timestamp: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SalvageHandler 装备分解API处理器
+type SalvageHandler struct {
+	salvageMgr *game.SalvageManager
+}
+
+// NewSalvageHandler 创建分解处理器
+func NewSalvageHandler() *SalvageHandler {
+	return &SalvageHandler{
+		salvageMgr: game.GetSalvageManager(),
+	}
+}
+
+// SalvageRequest 分解请求（equipmentIds 与 filter 二选一）
+type SalvageRequest struct {
+	CharacterID  int                   `json:"characterId"` // 接收材料的角色（预览时不需要）
+	EquipmentIDs []int                 `json:"equipmentIds"`
+	Filter       *models.SalvageFilter `json:"filter"`
+}
+
+// PreviewSalvage 预览分解产出
+func (h *SalvageHandler) PreviewSalvage(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req SalvageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	report, err := h.salvageMgr.Preview(userID, req.EquipmentIDs, req.Filter)
+	if err != nil {
+		h.respondSalvageError(c, err, "failed to preview salvage")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// Salvage 分解装备
+func (h *SalvageHandler) Salvage(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req SalvageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	report, err := h.salvageMgr.Salvage(userID, req.CharacterID, req.EquipmentIDs, req.Filter)
+	if err != nil {
+		h.respondSalvageError(c, err, "failed to salvage equipment")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+		Message: "equipment salvaged",
+	})
+}
+
+// respondSalvageError 将分解错误映射为HTTP响应
+func (h *SalvageHandler) respondSalvageError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, game.ErrSalvageNoCharacter):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrEquipmentNotOwned):
+		status, message = http.StatusForbidden, err.Error()
+	case errors.Is(err, game.ErrSalvageNoSelection),
+		errors.Is(err, game.ErrSalvageTooMany),
+		errors.Is(err, game.ErrNothingToSalvage),
+		errors.Is(err, repository.ErrEquipmentEquipped),
+		errors.Is(err, repository.ErrEquipmentLocked),
+		errors.Is(err, repository.ErrEquipmentInEscrow):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
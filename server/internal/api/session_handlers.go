@@ -0,0 +1,192 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"text-wow/internal/auth"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sessionUserAgentMaxLength = 256
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshToken 用刷新令牌换取新的访问令牌（刷新令牌同时轮换）
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to generate token",
+		})
+		return
+	}
+
+	now := time.Now()
+	session, err := h.sessionRepo.Rotate(auth.HashRefreshToken(req.RefreshToken), auth.HashRefreshToken(refreshToken),
+		now, now.Add(auth.RefreshTokenTTL))
+	if err != nil {
+		h.respondSessionError(c, err, "failed to refresh token")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(session.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get user info",
+		})
+		return
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Username, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "token refreshed",
+		Data:    authResponse(token, refreshToken, session, user),
+	})
+}
+
+// Logout 注销当前会话
+func (h *Handler) Logout(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	// 会话机制上线前签发的令牌没有会话可注销，由客户端丢弃令牌即可
+	if sessionID := c.GetString("sessionID"); sessionID != "" {
+		if err := h.sessionRepo.Revoke(sessionID, userID, time.Now()); err != nil {
+			h.respondSessionError(c, err, "failed to logout")
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "logged out",
+	})
+}
+
+// LogoutAll 注销该账号在所有设备上的会话
+func (h *Handler) LogoutAll(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	revoked, err := h.sessionRepo.RevokeAll(userID, time.Now())
+	if err != nil {
+		h.respondSessionError(c, err, "failed to logout")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    gin.H{"revoked": revoked},
+		Message: "logged out from all devices",
+	})
+}
+
+// IssueStreamTicket 签发实时推送连接的一次性票据（用于 /api/stream/ws 与 /api/stream/events 的 ticket 参数）
+func (h *Handler) IssueStreamTicket(c *gin.Context) {
+	ticket, expiresAt, err := h.streamTickets.Issue(c.GetInt("userID"), c.GetString("username"), c.GetString("sessionID"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to issue stream ticket",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: &models.StreamTicketResponse{
+			Ticket:    ticket,
+			ExpiresAt: expiresAt,
+		},
+	})
+}
+
+// issueSession 为登录/注册成功的用户创建会话并签发访问令牌与刷新令牌
+func (h *Handler) issueSession(c *gin.Context, user *models.User) (*models.AuthResponse, error) {
+	sessionID, err := auth.NewSessionID()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > sessionUserAgentMaxLength {
+		userAgent = userAgent[:sessionUserAgentMaxLength]
+	}
+
+	now := time.Now()
+	session := &models.AuthSession{
+		ID:         sessionID,
+		UserID:     user.ID,
+		UserAgent:  userAgent,
+		IPAddress:  c.ClientIP(),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(auth.RefreshTokenTTL),
+	}
+	if err := h.sessionRepo.Create(session, auth.HashRefreshToken(refreshToken)); err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Username, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return authResponse(token, refreshToken, session, user), nil
+}
+
+func authResponse(token, refreshToken string, session *models.AuthSession, user *models.User) *models.AuthResponse {
+	return &models.AuthResponse{
+		Token:            token,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int(auth.AccessTokenTTL / time.Second),
+		RefreshExpiresAt: session.ExpiresAt,
+		User:             *user,
+	}
+}
+
+// respondSessionError 将会话错误映射为HTTP响应（刷新失败统一返回401，客户端需重新登录）
+func (h *Handler) respondSessionError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, repository.ErrSessionNotFound),
+		errors.Is(err, repository.ErrSessionRevoked),
+		errors.Is(err, repository.ErrSessionExpired),
+		errors.Is(err, repository.ErrRefreshTokenReused):
+		status, message = http.StatusUnauthorized, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
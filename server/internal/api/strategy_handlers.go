@@ -439,6 +439,7 @@ func (h *StrategyHandlers) GetConditionTypes(c *gin.Context) {
 		{"type": "self_resource", "name": "自身资源值", "category": "self", "operators": []string{"<", ">", "<=", ">=", "="}, "valueType": "number"},
 		{"type": "self_has_buff", "name": "自身有Buff", "category": "self", "operators": []string{"=", "!="}, "valueType": "buff_id"},
 		{"type": "self_missing_buff", "name": "自身无Buff", "category": "self", "operators": []string{"="}, "valueType": "buff_id"},
+		{"type": "item_count", "name": "物品数量", "category": "self", "operators": []string{"<", ">", "<=", ">=", "="}, "valueType": "item_id"},
 
 		// 敌人状态
 		{"type": "alive_enemy_count", "name": "存活敌人数量", "category": "enemy", "operators": []string{"<", ">", "<=", ">=", "="}, "valueType": "number"},
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TradeHandler 玩家直接交易API处理器
+type TradeHandler struct {
+	tradingMgr *game.TradingManager
+}
+
+// NewTradeHandler 创建交易处理器
+func NewTradeHandler() *TradeHandler {
+	return &TradeHandler{
+		tradingMgr: game.GetTradingManager(),
+	}
+}
+
+// OpenTradeRequest 发起交易请求
+type OpenTradeRequest struct {
+	PartnerName string `json:"partnerName" binding:"required"`
+}
+
+// TradeItemRequest 放入装备请求
+type TradeItemRequest struct {
+	EquipmentID int `json:"equipmentId" binding:"required"`
+}
+
+// TradeGoldRequest 设置交易金币请求
+type TradeGoldRequest struct {
+	Amount int `json:"amount"`
+}
+
+// GetMyTrades 获取我进行中的交易窗口
+func (h *TradeHandler) GetMyTrades(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	trades, err := h.tradingMgr.GetUserTrades(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get trades",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trades,
+	})
+}
+
+// OpenTrade 发起交易
+func (h *TradeHandler) OpenTrade(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req OpenTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	trade, err := h.tradingMgr.OpenTradeByName(userID, req.PartnerName)
+	if err != nil {
+		h.respondTradeError(c, err, "failed to open trade")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trade,
+		Message: "trade opened",
+	})
+}
+
+// GetTrade 获取交易窗口
+func (h *TradeHandler) GetTrade(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	tradeID, ok := h.parseTradeID(c)
+	if !ok {
+		return
+	}
+
+	trade, err := h.tradingMgr.GetTrade(userID, tradeID)
+	if err != nil {
+		h.respondTradeError(c, err, "failed to get trade")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trade,
+	})
+}
+
+// AddItem 放入装备
+func (h *TradeHandler) AddItem(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	tradeID, ok := h.parseTradeID(c)
+	if !ok {
+		return
+	}
+
+	var req TradeItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	trade, err := h.tradingMgr.AddTradeItem(userID, tradeID, req.EquipmentID)
+	if err != nil {
+		h.respondTradeError(c, err, "failed to add item")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trade,
+		Message: "item added",
+	})
+}
+
+// RemoveItem 取回装备
+func (h *TradeHandler) RemoveItem(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	tradeID, ok := h.parseTradeID(c)
+	if !ok {
+		return
+	}
+	equipmentID, err := strconv.Atoi(c.Param("equipmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid equipment ID",
+		})
+		return
+	}
+
+	trade, err := h.tradingMgr.RemoveTradeItem(userID, tradeID, equipmentID)
+	if err != nil {
+		h.respondTradeError(c, err, "failed to remove item")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trade,
+		Message: "item removed",
+	})
+}
+
+// SetGold 设置交易金币
+func (h *TradeHandler) SetGold(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	tradeID, ok := h.parseTradeID(c)
+	if !ok {
+		return
+	}
+
+	var req TradeGoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	trade, err := h.tradingMgr.SetTradeGold(userID, tradeID, req.Amount)
+	if err != nil {
+		h.respondTradeError(c, err, "failed to set gold")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trade,
+		Message: "gold updated",
+	})
+}
+
+// Confirm 确认交易
+func (h *TradeHandler) Confirm(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	tradeID, ok := h.parseTradeID(c)
+	if !ok {
+		return
+	}
+
+	trade, err := h.tradingMgr.ConfirmTrade(userID, tradeID)
+	if err != nil {
+		h.respondTradeError(c, err, "failed to confirm trade")
+		return
+	}
+
+	message := "trade confirmed, waiting for the other player"
+	if trade.Status == "completed" {
+		message = "trade completed"
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trade,
+		Message: message,
+	})
+}
+
+// Cancel 取消交易
+func (h *TradeHandler) Cancel(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	tradeID, ok := h.parseTradeID(c)
+	if !ok {
+		return
+	}
+
+	trade, err := h.tradingMgr.CancelTrade(userID, tradeID)
+	if err != nil {
+		h.respondTradeError(c, err, "failed to cancel trade")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trade,
+		Message: "trade cancelled",
+	})
+}
+
+// parseTradeID 解析路径中的交易ID
+func (h *TradeHandler) parseTradeID(c *gin.Context) (int, bool) {
+	tradeID, err := strconv.Atoi(c.Param("tradeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid trade ID",
+		})
+		return 0, false
+	}
+	return tradeID, true
+}
+
+// respondTradeError 将交易错误映射为HTTP响应
+func (h *TradeHandler) respondTradeError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, repository.ErrTradeNotFound),
+		errors.Is(err, game.ErrTradePartnerNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrTradeNotParticipant),
+		errors.Is(err, repository.ErrEquipmentNotOwned):
+		status, message = http.StatusForbidden, err.Error()
+	case errors.Is(err, repository.ErrTradeNotOpen),
+		errors.Is(err, repository.ErrTradeExpired),
+		errors.Is(err, repository.ErrTradeAlreadyOpen),
+		errors.Is(err, repository.ErrTradeItemNotFound),
+		errors.Is(err, repository.ErrTradeItemDuplicate),
+		errors.Is(err, repository.ErrEquipmentEquipped),
+		errors.Is(err, repository.ErrEquipmentLocked),
+		errors.Is(err, repository.ErrEquipmentInEscrow),
+		errors.Is(err, repository.ErrInsufficientGold),
+		errors.Is(err, game.ErrTradeWithSelf),
+		errors.Is(err, game.ErrInvalidTradeGold):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"text-wow/internal/game"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VendorHandler NPC商人API处理器
+type VendorHandler struct {
+	vendorMgr *game.VendorManager
+}
+
+// NewVendorHandler 创建商人处理器
+func NewVendorHandler() *VendorHandler {
+	return &VendorHandler{
+		vendorMgr: game.GetVendorManager(),
+	}
+}
+
+// VendorBuyRequest 购买商品请求
+type VendorBuyRequest struct {
+	ItemID      string `json:"itemId" binding:"required"`
+	Quantity    int    `json:"quantity"`    // 默认1
+	CharacterID int    `json:"characterId"` // 接收物品的角色（可选）
+}
+
+// VendorSellRequest 出售物品请求（inventoryId 与 equipmentId 二选一）
+type VendorSellRequest struct {
+	InventoryID int `json:"inventoryId"`
+	Quantity    int `json:"quantity"` // 0=整组出售
+	EquipmentID int `json:"equipmentId"`
+}
+
+// VendorBuybackRequest 回购请求
+type VendorBuybackRequest struct {
+	CharacterID int `json:"characterId"` // 原角色不存在时接收物品的角色（可选）
+}
+
+// GetZoneVendors 获取当前区域的商人
+func (h *VendorHandler) GetZoneVendors(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	zoneID, vendors, err := h.vendorMgr.GetZoneVendors(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get vendors",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: gin.H{
+			"zoneId":  zoneID,
+			"vendors": vendors,
+		},
+	})
+}
+
+// GetVendor 获取商人详情
+func (h *VendorHandler) GetVendor(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	vendor, err := h.vendorMgr.GetVendor(userID, c.Param("vendorId"))
+	if err != nil {
+		h.respondVendorError(c, err, "failed to get vendor")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    vendor,
+	})
+}
+
+// BuyItem 购买商品
+func (h *VendorHandler) BuyItem(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req VendorBuyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	purchase, err := h.vendorMgr.BuyItem(userID, c.Param("vendorId"), req.ItemID, req.Quantity, req.CharacterID)
+	if err != nil {
+		h.respondVendorError(c, err, "failed to buy item")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    purchase,
+		Message: "purchase successful",
+	})
+}
+
+// SellItem 出售背包物品或装备
+func (h *VendorHandler) SellItem(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req VendorSellRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+	if (req.InventoryID == 0) == (req.EquipmentID == 0) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "exactly one of inventoryId or equipmentId is required",
+		})
+		return
+	}
+
+	vendorID := c.Param("vendorId")
+	var buyback *models.VendorBuyback
+	var err error
+	if req.EquipmentID != 0 {
+		buyback, err = h.vendorMgr.SellEquipment(userID, vendorID, req.EquipmentID)
+	} else {
+		buyback, err = h.vendorMgr.SellInventoryItem(userID, vendorID, req.InventoryID, req.Quantity)
+	}
+	if err != nil {
+		h.respondVendorError(c, err, "failed to sell item")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    buyback,
+		Message: "item sold",
+	})
+}
+
+// GetBuyback 获取回购栏
+func (h *VendorHandler) GetBuyback(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	list, err := h.vendorMgr.GetBuyback(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "failed to get buyback list",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    list,
+	})
+}
+
+// Buyback 回购物品
+func (h *VendorHandler) Buyback(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	buybackID, err := strconv.Atoi(c.Param("buybackId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "invalid buyback ID",
+		})
+		return
+	}
+
+	var req VendorBuybackRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "invalid request: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	buyback, err := h.vendorMgr.Buyback(userID, c.Param("vendorId"), buybackID, req.CharacterID)
+	if err != nil {
+		h.respondVendorError(c, err, "failed to buy back item")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    buyback,
+		Message: "item bought back",
+	})
+}
+
+// respondVendorError 将商人错误映射为HTTP响应
+func (h *VendorHandler) respondVendorError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch {
+	case errors.Is(err, repository.ErrVendorNotFound),
+		errors.Is(err, repository.ErrVendorItemNotFound),
+		errors.Is(err, repository.ErrInventoryItemNotFound),
+		errors.Is(err, repository.ErrBuybackNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrEquipmentNotOwned),
+		errors.Is(err, game.ErrVendorNotInZone):
+		status, message = http.StatusForbidden, err.Error()
+	case errors.Is(err, repository.ErrVendorOutOfStock),
+		errors.Is(err, repository.ErrInsufficientQuantity),
+		errors.Is(err, repository.ErrItemNotSellable),
+		errors.Is(err, repository.ErrBuybackNotAvailable),
+		errors.Is(err, repository.ErrVendorNoCharacter),
+		errors.Is(err, repository.ErrEquipmentEquipped),
+		errors.Is(err, repository.ErrEquipmentLocked),
+		errors.Is(err, repository.ErrEquipmentInEscrow),
+		errors.Is(err, repository.ErrInsufficientGold),
+		errors.Is(err, game.ErrInvalidVendorQuantity):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
@@ -1,18 +1,27 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var (
-	// JWTSecret 用于签名JWT的密钥（生产环境应该从环境变量读取）
-	JWTSecret = []byte("text-wow-secret-key-change-in-production")
+const (
+	// AccessTokenTTL 访问令牌有效期（短期，过期后用刷新令牌换取）
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL 刷新令牌有效期（每次刷新后顺延）
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
 
+var (
 	ErrInvalidCredentials = errors.New("invalid username or password")
 	ErrUserExists         = errors.New("username already exists")
 	ErrInvalidToken       = errors.New("invalid token")
@@ -21,8 +30,9 @@ var (
 
 // Claims JWT声明
 type Claims struct {
-	UserID   int    `json:"userId"`
-	Username string `json:"username"`
+	UserID    int    `json:"userId"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid,omitempty"` // 登录会话ID，用于注销校验
 	jwt.RegisteredClaims
 }
 
@@ -38,20 +48,38 @@ func CheckPassword(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateToken 生成JWT令牌
-func GenerateToken(userID int, username string) (string, error) {
+// dummyPasswordHash 用户不存在时用于比较的哈希（首次使用时生成）
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// SimulatePasswordCheck 执行一次结果必然失败的密码比较，使不存在的用户名与密码错误的响应耗时一致
+func SimulatePasswordCheck(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("text-wow-dummy-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
+
+// GenerateToken 生成绑定登录会话的短期访问令牌（使用当前签名密钥，头部带 kid）
+func GenerateToken(userID int, username, sessionID string) (string, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)), // 7天有效期
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    "text-wow",
 		},
 	}
 
+	keyID, secret := CurrentKeyRing().signingKey()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(JWTSecret)
+	token.Header["kid"] = keyID
+	return token.SignedString(secret)
 }
 
 // ValidateToken 验证JWT令牌
@@ -60,7 +88,12 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return JWTSecret, nil
+		keyID, _ := token.Header["kid"].(string)
+		secret, ok := CurrentKeyRing().verificationKey(keyID)
+		if !ok {
+			return nil, ErrUnknownSigningKey
+		}
+		return secret, nil
 	})
 
 	if err != nil {
@@ -74,55 +107,26 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	return nil, ErrInvalidToken
 }
 
+// NewSessionID 生成随机登录会话ID
+func NewSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
 
+// NewRefreshToken 生成随机刷新令牌（原文只交给客户端，服务端保存哈希）
+func NewRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
 
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
+// HashRefreshToken 计算刷新令牌的存储哈希
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
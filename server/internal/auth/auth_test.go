@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -98,7 +99,7 @@ func TestGenerateToken(t *testing.T) {
 	userID := 1
 	username := "testuser"
 
-	token, err := GenerateToken(userID, username)
+	token, err := GenerateToken(userID, username, "session")
 	if err != nil {
 		t.Fatalf("GenerateToken failed: %v", err)
 	}
@@ -123,7 +124,7 @@ func TestValidateToken_ValidToken(t *testing.T) {
 	userID := 42
 	username := "testplayer"
 
-	token, err := GenerateToken(userID, username)
+	token, err := GenerateToken(userID, username, "session")
 	if err != nil {
 		t.Fatalf("GenerateToken failed: %v", err)
 	}
@@ -163,7 +164,7 @@ func TestValidateToken_EmptyToken(t *testing.T) {
 }
 
 func TestValidateToken_TamperedToken(t *testing.T) {
-	token, _ := GenerateToken(1, "user")
+	token, _ := GenerateToken(1, "user", "session")
 
 	// 篡改token的最后一个字符
 	tamperedToken := token[:len(token)-1] + "X"
@@ -175,7 +176,7 @@ func TestValidateToken_TamperedToken(t *testing.T) {
 }
 
 func TestValidateToken_Issuer(t *testing.T) {
-	token, _ := GenerateToken(1, "user")
+	token, _ := GenerateToken(1, "user", "session")
 	claims, err := ValidateToken(token)
 
 	if err != nil {
@@ -188,20 +189,20 @@ func TestValidateToken_Issuer(t *testing.T) {
 }
 
 func TestValidateToken_ExpirationTime(t *testing.T) {
-	token, _ := GenerateToken(1, "user")
+	token, _ := GenerateToken(1, "user", "session")
 	claims, err := ValidateToken(token)
 
 	if err != nil {
 		t.Fatalf("ValidateToken failed: %v", err)
 	}
 
-	// 验证过期时间大约是7天后
-	expectedExpiry := time.Now().Add(7 * 24 * time.Hour)
+	// 验证过期时间为短期访问令牌有效期
+	expectedExpiry := time.Now().Add(AccessTokenTTL)
 	actualExpiry := claims.ExpiresAt.Time
 
 	// 允许1分钟的误差
 	if actualExpiry.Sub(expectedExpiry) > time.Minute || expectedExpiry.Sub(actualExpiry) > time.Minute {
-		t.Errorf("Token expiry time is not approximately 7 days from now")
+		t.Errorf("Token expiry time is not approximately %v from now", AccessTokenTTL)
 	}
 }
 
@@ -258,7 +259,7 @@ func TestGenerateToken_SpecialUsername(t *testing.T) {
 	userID := 1
 	username := "user@special.name"
 
-	token, err := GenerateToken(userID, username)
+	token, err := GenerateToken(userID, username, "session")
 	if err != nil {
 		t.Fatalf("GenerateToken failed: %v", err)
 	}
@@ -273,6 +274,106 @@ func TestGenerateToken_SpecialUsername(t *testing.T) {
 	}
 }
 
+// ═══════════════════════════════════════════════════════════
+// 密钥环与刷新令牌测试
+// ═══════════════════════════════════════════════════════════
+
+func TestKeyRing_Rotation(t *testing.T) {
+	original := CurrentKeyRing()
+	defer SetKeyRing(original)
+
+	ring, err := parseKeyRing("k1:" + strings.Repeat("a", 32) + ",k0:" + strings.Repeat("b", 32))
+	if err != nil {
+		t.Fatalf("parseKeyRing failed: %v", err)
+	}
+	if ring.ActiveKeyID() != "k1" {
+		t.Fatalf("Expected first key to be active, got %s", ring.ActiveKeyID())
+	}
+	SetKeyRing(ring)
+
+	oldToken, _ := GenerateToken(1, "user", "session")
+	if err := ring.Rotate("k2", []byte(strings.Repeat("c", 32))); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	newToken, _ := GenerateToken(1, "user", "session")
+
+	// 轮换后旧密钥签发的令牌仍然有效
+	for _, token := range []string{oldToken, newToken} {
+		claims, err := ValidateToken(token)
+		if err != nil {
+			t.Fatalf("ValidateToken failed after rotation: %v", err)
+		}
+		if claims.SessionID != "session" {
+			t.Errorf("Expected sid 'session', got '%s'", claims.SessionID)
+		}
+	}
+
+	// 移除旧密钥后其签发的令牌失效，当前密钥不可移除
+	if err := ring.Remove("k2"); err != ErrActiveKeyRemoval {
+		t.Errorf("Expected ErrActiveKeyRemoval, got %v", err)
+	}
+	if err := ring.Remove("k1"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := ValidateToken(oldToken); err == nil {
+		t.Error("Token signed with a removed key should be rejected")
+	}
+	if _, err := ValidateToken(newToken); err != nil {
+		t.Errorf("Token signed with the active key should stay valid: %v", err)
+	}
+}
+
+func TestKeyRing_InvalidKeys(t *testing.T) {
+	if _, err := parseKeyRing("k1:short"); err != ErrInvalidSigningKey {
+		t.Errorf("Expected ErrInvalidSigningKey for short secret, got %v", err)
+	}
+	if _, err := parseKeyRing("missing-separator"); err != ErrInvalidSigningKey {
+		t.Errorf("Expected ErrInvalidSigningKey without separator, got %v", err)
+	}
+	if _, err := NewKeyRing("k9", map[string][]byte{"k1": []byte(strings.Repeat("a", 32))}); err == nil {
+		t.Error("NewKeyRing should reject an unknown active key")
+	}
+}
+
+func TestDevelopmentKeyAllowed(t *testing.T) {
+	cases := []struct {
+		allow, ginMode string
+		expected       bool
+	}{
+		{"", "", false},
+		{"", "release", false},
+		{"0", "", false},
+		{"yes", "", false},
+		{"1", "", true},
+		{"true", "release", true},
+		{"", "debug", true},
+	}
+	for _, c := range cases {
+		t.Setenv(EnvAllowDevSigningKey, c.allow)
+		t.Setenv("GIN_MODE", c.ginMode)
+		if got := DevelopmentKeyAllowed(); got != c.expected {
+			t.Errorf("ALLOW_DEV_JWT_KEY=%q GIN_MODE=%q: expected %v, got %v", c.allow, c.ginMode, c.expected, got)
+		}
+	}
+}
+
+func TestRefreshToken_Hash(t *testing.T) {
+	first, err := NewRefreshToken()
+	if err != nil {
+		t.Fatalf("NewRefreshToken failed: %v", err)
+	}
+	second, _ := NewRefreshToken()
+	if first == second {
+		t.Error("Refresh tokens should be random")
+	}
+	if HashRefreshToken(first) != HashRefreshToken(first) {
+		t.Error("HashRefreshToken should be deterministic")
+	}
+	if HashRefreshToken(first) == first || len(HashRefreshToken(first)) != 64 {
+		t.Error("HashRefreshToken should return a hex SHA-256 digest")
+	}
+}
+
 // ═══════════════════════════════════════════════════════════
 // 基准测试
 // ═══════════════════════════════════════════════════════════
@@ -295,15 +396,60 @@ func BenchmarkCheckPassword(b *testing.B) {
 
 func BenchmarkGenerateToken(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		GenerateToken(1, "benchuser")
+		GenerateToken(1, "benchuser", "session")
 	}
 }
 
 func BenchmarkValidateToken(b *testing.B) {
-	token, _ := GenerateToken(1, "benchuser")
+	token, _ := GenerateToken(1, "benchuser", "session")
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ValidateToken(token)
 	}
 }
 
+
+// ═══════════════════════════════════════════════════════════
+// 实时推送票据测试
+// ═══════════════════════════════════════════════════════════
+
+func TestStreamTicket_SingleUse(t *testing.T) {
+	store := NewStreamTicketStore()
+	now := time.Now()
+
+	ticket, expiresAt, err := store.Issue(7, "player", "sid-1", now)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(StreamTicketTTL)) {
+		t.Errorf("unexpected expiry: %v", expiresAt)
+	}
+
+	claims, err := store.Redeem(ticket, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("Redeem failed: %v", err)
+	}
+	if claims.UserID != 7 || claims.Username != "player" || claims.SessionID != "sid-1" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	if _, err := store.Redeem(ticket, now.Add(time.Second)); err != ErrInvalidStreamTicket {
+		t.Errorf("ticket should be single-use, got %v", err)
+	}
+}
+
+func TestStreamTicket_Expired(t *testing.T) {
+	store := NewStreamTicketStore()
+	now := time.Now()
+
+	ticket, _, err := store.Issue(7, "player", "sid-1", now)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if _, err := store.Redeem(ticket, now.Add(StreamTicketTTL)); err != ErrInvalidStreamTicket {
+		t.Errorf("expired ticket should be rejected, got %v", err)
+	}
+	if _, err := store.Redeem("unknown", now); err != ErrInvalidStreamTicket {
+		t.Errorf("unknown ticket should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 签名密钥错误
+var (
+	ErrNoSigningKeys      = errors.New("no signing keys configured")
+	ErrInvalidSigningKey  = errors.New("signing key needs an id and a secret of at least 32 bytes")
+	ErrUnknownSigningKey  = errors.New("unknown signing key")
+	ErrActiveKeyRemoval   = errors.New("cannot remove the active signing key")
+	ErrInvalidKeyRingFile = errors.New("invalid signing key file")
+)
+
+const (
+	// EnvSigningKeys 签名密钥列表 "kid:secret,kid:secret"，第一个为当前签名密钥
+	EnvSigningKeys = "JWT_SIGNING_KEYS"
+	// EnvSigningKeyFile 签名密钥配置文件路径（JSON: {"activeKeyId": "...", "keys": {"kid": "secret"}}）
+	EnvSigningKeyFile = "JWT_SIGNING_KEY_FILE"
+	// EnvAllowDevSigningKey 设为 1/true 时允许在未配置签名密钥时使用开发密钥（仅限本地开发）
+	EnvAllowDevSigningKey = "ALLOW_DEV_JWT_KEY"
+
+	minSigningKeyLength = 32
+	devSigningKeyID     = "dev"
+	devSigningKey       = "text-wow-secret-key-change-in-production"
+)
+
+// KeyRing JWT签名密钥环：用当前密钥签发，环内所有密钥都可验证，便于平滑轮换
+type KeyRing struct {
+	mu       sync.RWMutex
+	activeID string
+	keys     map[string][]byte
+}
+
+// keyRingFile 密钥配置文件格式
+type keyRingFile struct {
+	ActiveKeyID string            `json:"activeKeyId"`
+	Keys        map[string]string `json:"keys"`
+}
+
+// NewKeyRing 创建密钥环，activeID 必须在 keys 中
+func NewKeyRing(activeID string, keys map[string][]byte) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoSigningKeys
+	}
+	ring := &KeyRing{keys: make(map[string][]byte, len(keys))}
+	for id, secret := range keys {
+		if err := validateSigningKey(id, secret); err != nil {
+			return nil, err
+		}
+		ring.keys[id] = append([]byte(nil), secret...)
+	}
+	if _, ok := ring.keys[activeID]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSigningKey, activeID)
+	}
+	ring.activeID = activeID
+	return ring, nil
+}
+
+// DevelopmentKeyRing 仅用于本地开发和测试的固定密钥
+func DevelopmentKeyRing() *KeyRing {
+	return &KeyRing{
+		activeID: devSigningKeyID,
+		keys:     map[string][]byte{devSigningKeyID: []byte(devSigningKey)},
+	}
+}
+
+// DevelopmentKeyAllowed 未配置签名密钥时是否允许回退到开发密钥（显式设置 ALLOW_DEV_JWT_KEY 或 GIN_MODE=debug）
+func DevelopmentKeyAllowed() bool {
+	if allowed, err := strconv.ParseBool(os.Getenv(EnvAllowDevSigningKey)); err == nil && allowed {
+		return true
+	}
+	return os.Getenv("GIN_MODE") == "debug"
+}
+
+// LoadKeyRing 从环境变量或密钥配置文件加载密钥环，均未配置时返回 ErrNoSigningKeys
+func LoadKeyRing() (*KeyRing, error) {
+	if path := os.Getenv(EnvSigningKeyFile); path != "" {
+		return loadKeyRingFile(path)
+	}
+	if value := os.Getenv(EnvSigningKeys); value != "" {
+		return parseKeyRing(value)
+	}
+	return nil, ErrNoSigningKeys
+}
+
+// parseKeyRing 解析 "kid:secret,kid:secret" 格式的密钥列表
+func parseKeyRing(value string) (*KeyRing, error) {
+	keys := make(map[string][]byte)
+	activeID := ""
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, ErrInvalidSigningKey
+		}
+		id = strings.TrimSpace(id)
+		if activeID == "" {
+			activeID = id
+		}
+		keys[id] = []byte(secret)
+	}
+	return NewKeyRing(activeID, keys)
+}
+
+// loadKeyRingFile 读取JSON格式的密钥配置文件
+func loadKeyRingFile(path string) (*KeyRing, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key file: %w", err)
+	}
+	var file keyRingFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyRingFile, err)
+	}
+	keys := make(map[string][]byte, len(file.Keys))
+	for id, secret := range file.Keys {
+		keys[id] = []byte(secret)
+	}
+	return NewKeyRing(file.ActiveKeyID, keys)
+}
+
+func validateSigningKey(id string, secret []byte) error {
+	if strings.TrimSpace(id) == "" || len(secret) < minSigningKeyLength {
+		return ErrInvalidSigningKey
+	}
+	return nil
+}
+
+// ActiveKeyID 当前签名密钥ID
+func (r *KeyRing) ActiveKeyID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.activeID
+}
+
+// KeyIDs 环内全部密钥ID
+func (r *KeyRing) KeyIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Rotate 加入新密钥并设为当前签名密钥，旧密钥保留用于验证未过期的令牌
+func (r *KeyRing) Rotate(id string, secret []byte) error {
+	if err := validateSigningKey(id, secret); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[id] = append([]byte(nil), secret...)
+	r.activeID = id
+	return nil
+}
+
+// Remove 移除不再用于验证的旧密钥
+func (r *KeyRing) Remove(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == r.activeID {
+		return ErrActiveKeyRemoval
+	}
+	if _, ok := r.keys[id]; !ok {
+		return ErrUnknownSigningKey
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+// signingKey 返回当前签名密钥
+func (r *KeyRing) signingKey() (string, []byte) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.activeID, r.keys[r.activeID]
+}
+
+// verificationKey 按ID查找验证密钥
+func (r *KeyRing) verificationKey(id string) ([]byte, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	secret, ok := r.keys[id]
+	return secret, ok
+}
+
+// 全局密钥环（未配置时使用开发密钥）
+var (
+	keyRing   = DevelopmentKeyRing()
+	keyRingMu sync.RWMutex
+)
+
+// SetKeyRing 替换全局密钥环
+func SetKeyRing(ring *KeyRing) {
+	keyRingMu.Lock()
+	defer keyRingMu.Unlock()
+	keyRing = ring
+}
+
+// CurrentKeyRing 获取全局密钥环
+func CurrentKeyRing() *KeyRing {
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+	return keyRing
+}
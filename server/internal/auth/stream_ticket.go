@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// StreamTicketTTL 实时推送连接票据有效期（只用于建立连接，签发后应立即使用）
+const StreamTicketTTL = 30 * time.Second
+
+// ErrInvalidStreamTicket 票据不存在、已使用或已过期
+var ErrInvalidStreamTicket = errors.New("invalid or expired stream ticket")
+
+// StreamTicket 实时推送连接票据绑定的登录身份
+type StreamTicket struct {
+	UserID    int
+	Username  string
+	SessionID string
+	ExpiresAt time.Time
+}
+
+// StreamTicketStore 一次性连接票据存储（内存）
+// 浏览器的 WebSocket/EventSource 无法设置请求头，用票据代替访问令牌放在查询参数中，避免令牌出现在访问日志里
+type StreamTicketStore struct {
+	mu      sync.Mutex
+	tickets map[string]*StreamTicket
+}
+
+// NewStreamTicketStore 创建票据存储
+func NewStreamTicketStore() *StreamTicketStore {
+	return &StreamTicketStore{tickets: make(map[string]*StreamTicket)}
+}
+
+// Issue 为已认证的会话签发一次性票据
+func (s *StreamTicketStore) Issue(userID int, username, sessionID string, now time.Time) (string, time.Time, error) {
+	ticket, err := NewRefreshToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := now.Add(StreamTicketTTL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 顺带清理过期未使用的票据
+	for key, t := range s.tickets {
+		if !now.Before(t.ExpiresAt) {
+			delete(s.tickets, key)
+		}
+	}
+	s.tickets[HashRefreshToken(ticket)] = &StreamTicket{
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
+		ExpiresAt: expiresAt,
+	}
+	return ticket, expiresAt, nil
+}
+
+// Redeem 兑换票据，无论成功与否票据都会失效
+func (s *StreamTicketStore) Redeem(ticket string, now time.Time) (*StreamTicket, error) {
+	key := HashRefreshToken(ticket)
+
+	s.mu.Lock()
+	t, ok := s.tickets[key]
+	delete(s.tickets, key)
+	s.mu.Unlock()
+
+	if !ok || !now.Before(t.ExpiresAt) {
+		return nil, ErrInvalidStreamTicket
+	}
+	return t, nil
+}
@@ -46,7 +46,7 @@ func NewConfigManager() *ConfigManager {
 }
 
 // LoadConfig 加载配置
-// configType: monster/skill/item/economy/zone
+// configType: monster/skill/item/economy/zone/stamina/chat_moderation
 func (cm *ConfigManager) LoadConfig(configType string) error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
@@ -62,6 +62,10 @@ func (cm *ConfigManager) LoadConfig(configType string) error {
 		return cm.loadEconomyConfigs()
 	case "zone":
 		return cm.loadZoneConfigs()
+	case "stamina":
+		return cm.loadStaminaConfigs()
+	case "chat_moderation":
+		return cm.loadChatModerationConfigs()
 	default:
 		return fmt.Errorf("unknown config type: %s", configType)
 	}
@@ -278,6 +282,130 @@ func (cm *ConfigManager) loadEconomyConfigs() error {
 	return nil
 }
 
+// StaminaConfig 体力系统配置（按服务器开关）
+type StaminaConfig struct {
+	Enabled              bool `json:"enabled"`                 // 是否启用体力系统
+	MaxStamina           int  `json:"max_stamina"`             // 新玩家体力上限
+	RegenIntervalSeconds int  `json:"regen_interval_seconds"`  // 每恢复1点体力所需秒数
+	BattleCost           int  `json:"battle_cost"`             // 每场战斗默认消耗（区域未配置时使用）
+	OverflowExpPerPoint  int  `json:"overflow_exp_per_point"`  // 体力满时每点溢出转化的经验
+	OverflowGoldPerPoint int  `json:"overflow_gold_per_point"` // 体力满时每点溢出转化的金币
+}
+
+// DefaultStaminaConfig 默认体力配置（默认关闭）
+func DefaultStaminaConfig() StaminaConfig {
+	return StaminaConfig{
+		Enabled:              false,
+		MaxStamina:           100,
+		RegenIntervalSeconds: 360,
+		BattleCost:           1,
+		OverflowExpPerPoint:  5,
+		OverflowGoldPerPoint: 2,
+	}
+}
+
+// loadStaminaConfigs 加载体力配置（默认值 + config_versions 中最新版本的覆盖项）
+func (cm *ConfigManager) loadStaminaConfigs() error {
+	stamina := DefaultStaminaConfig()
+
+	var configData string
+	err := database.DB.QueryRow(`
+		SELECT config_data FROM config_versions
+		WHERE config_type = 'stamina'
+		ORDER BY version DESC LIMIT 1
+	`).Scan(&configData)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to load stamina configs: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal([]byte(configData), &stamina); err != nil {
+			return fmt.Errorf("invalid stamina config data: %w", err)
+		}
+	}
+
+	version := cm.getConfigVersion("stamina")
+	if version == 0 {
+		version = 1
+	}
+	cm.configs["stamina"] = &ConfigCache{
+		Data:      stamina,
+		Version:   version,
+		UpdatedAt: time.Now(),
+	}
+
+	log.Printf("✅ Loaded stamina configs (enabled=%v)", stamina.Enabled)
+	return nil
+}
+
+// ChatRateLimit 聊天令牌桶限流参数
+type ChatRateLimit struct {
+	Capacity      int     `json:"capacity"`       // 桶容量（允许连续发送的消息数）
+	RefillSeconds float64 `json:"refill_seconds"` // 每恢复1条消息额度所需秒数
+}
+
+// ChatModerationConfig 聊天管理配置（敏感词、各频道限流、重复消息检测）
+type ChatModerationConfig struct {
+	BannedWords            []string                 `json:"banned_words"`             // 敏感词（不区分大小写，替换为***）
+	DefaultRateLimit       ChatRateLimit            `json:"default_rate_limit"`       // 未单独配置的频道使用
+	ChannelRateLimits      map[string]ChatRateLimit `json:"channel_rate_limits"`      // 按频道覆盖
+	DuplicateWindowSeconds int                      `json:"duplicate_window_seconds"` // 该时间内同一频道不能重复发送相同内容
+}
+
+// DefaultChatModerationConfig 默认聊天管理配置
+func DefaultChatModerationConfig() ChatModerationConfig {
+	return ChatModerationConfig{
+		BannedWords:      []string{},
+		DefaultRateLimit: ChatRateLimit{Capacity: 5, RefillSeconds: 3},
+		ChannelRateLimits: map[string]ChatRateLimit{
+			"world":   {Capacity: 3, RefillSeconds: 10},
+			"trade":   {Capacity: 3, RefillSeconds: 10},
+			"whisper": {Capacity: 10, RefillSeconds: 1},
+		},
+		DuplicateWindowSeconds: 30,
+	}
+}
+
+// RateLimitFor 获取频道的限流参数
+func (c ChatModerationConfig) RateLimitFor(channel string) ChatRateLimit {
+	if limit, ok := c.ChannelRateLimits[channel]; ok {
+		return limit
+	}
+	return c.DefaultRateLimit
+}
+
+// loadChatModerationConfigs 加载聊天管理配置（默认值 + config_versions 中最新版本的覆盖项）
+func (cm *ConfigManager) loadChatModerationConfigs() error {
+	moderation := DefaultChatModerationConfig()
+
+	var configData string
+	err := database.DB.QueryRow(`
+		SELECT config_data FROM config_versions
+		WHERE config_type = 'chat_moderation'
+		ORDER BY version DESC LIMIT 1
+	`).Scan(&configData)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to load chat moderation configs: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal([]byte(configData), &moderation); err != nil {
+			return fmt.Errorf("invalid chat moderation config data: %w", err)
+		}
+	}
+
+	version := cm.getConfigVersion("chat_moderation")
+	if version == 0 {
+		version = 1
+	}
+	cm.configs["chat_moderation"] = &ConfigCache{
+		Data:      moderation,
+		Version:   version,
+		UpdatedAt: time.Now(),
+	}
+
+	log.Printf("✅ Loaded chat moderation configs (%d banned words)", len(moderation.BannedWords))
+	return nil
+}
+
 // loadZoneConfigs 加载区域配置
 func (cm *ConfigManager) loadZoneConfigs() error {
 	rows, err := database.DB.Query(`
@@ -468,6 +596,42 @@ func (cm *ConfigManager) GetEconomyConfig() (map[string]interface{}, error) {
 	return economy, nil
 }
 
+// GetStaminaConfig 获取体力配置
+func (cm *ConfigManager) GetStaminaConfig() (StaminaConfig, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	cache, exists := cm.configs["stamina"]
+	if !exists {
+		return StaminaConfig{}, fmt.Errorf("stamina configs not loaded")
+	}
+
+	stamina, ok := cache.Data.(StaminaConfig)
+	if !ok {
+		return StaminaConfig{}, fmt.Errorf("invalid stamina config cache")
+	}
+
+	return stamina, nil
+}
+
+// GetChatModerationConfig 获取聊天管理配置
+func (cm *ConfigManager) GetChatModerationConfig() (ChatModerationConfig, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	cache, exists := cm.configs["chat_moderation"]
+	if !exists {
+		return ChatModerationConfig{}, fmt.Errorf("chat moderation configs not loaded")
+	}
+
+	moderation, ok := cache.Data.(ChatModerationConfig)
+	if !ok {
+		return ChatModerationConfig{}, fmt.Errorf("invalid chat moderation config cache")
+	}
+
+	return moderation, nil
+}
+
 // GetAllConfigs 获取所有已加载的配置类型
 func (cm *ConfigManager) GetAllConfigs() []string {
 	cm.mu.RLock()
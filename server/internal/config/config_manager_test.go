@@ -150,3 +150,51 @@ func TestConfigChangeNotification(t *testing.T) {
 
 
 
+
+func TestLoadStaminaConfig(t *testing.T) {
+	cm, cleanup := setupConfigTest(t)
+	defer cleanup()
+
+	// 未配置时使用默认值（体力系统关闭）
+	assert.NoError(t, cm.LoadConfig("stamina"))
+	stamina, err := cm.GetStaminaConfig()
+	assert.NoError(t, err)
+	assert.False(t, stamina.Enabled)
+	assert.Equal(t, 100, stamina.MaxStamina)
+
+	// 最新版本的配置覆盖默认值
+	err = cm.SaveConfigVersion("stamina", 1, map[string]interface{}{"enabled": true, "battle_cost": 2}, "enable stamina")
+	assert.NoError(t, err)
+	assert.NoError(t, cm.ReloadConfig("stamina"))
+
+	stamina, err = cm.GetStaminaConfig()
+	assert.NoError(t, err)
+	assert.True(t, stamina.Enabled)
+	assert.Equal(t, 2, stamina.BattleCost)
+	assert.Equal(t, 100, stamina.MaxStamina, "未覆盖的字段保持默认值")
+}
+
+func TestLoadChatModerationConfig(t *testing.T) {
+	cm, cleanup := setupConfigTest(t)
+	defer cleanup()
+
+	assert.NoError(t, cm.LoadConfig("chat_moderation"))
+	moderation, err := cm.GetChatModerationConfig()
+	assert.NoError(t, err)
+	assert.Empty(t, moderation.BannedWords)
+	assert.Equal(t, 3, moderation.RateLimitFor("world").Capacity)
+	assert.Equal(t, moderation.DefaultRateLimit, moderation.RateLimitFor("zone"))
+
+	err = cm.SaveConfigVersion("chat_moderation", 1, map[string]interface{}{
+		"banned_words":        []string{"gold seller"},
+		"channel_rate_limits": map[string]interface{}{"zone": map[string]interface{}{"capacity": 2, "refill_seconds": 5}},
+	}, "add banned words")
+	assert.NoError(t, err)
+	assert.NoError(t, cm.ReloadConfig("chat_moderation"))
+
+	moderation, err = cm.GetChatModerationConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gold seller"}, moderation.BannedWords)
+	assert.Equal(t, 2, moderation.RateLimitFor("zone").Capacity)
+	assert.Equal(t, 3, moderation.RateLimitFor("world").Capacity, "未覆盖的频道保持默认值")
+}
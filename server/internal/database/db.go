@@ -274,9 +274,60 @@ func runMigrations() error {
 	if err := migrateConfigVersions(); err != nil {
 		return fmt.Errorf("failed to migrate config_versions: %w", err)
 	}
+	// 迁移4: 添加family列到monsters表
+	if err := migrateMonsterFamily(); err != nil {
+		return fmt.Errorf("failed to migrate monster family: %w", err)
+	}
+	// 迁移5: 添加stamina_cost列到zones表
+	if err := migrateZoneStaminaCost(); err != nil {
+		return fmt.Errorf("failed to migrate zone stamina_cost: %w", err)
+	}
+	// 迁移6: 添加竞价相关列到auction_listings表
+	if err := migrateAuctionBidding(); err != nil {
+		return fmt.Errorf("failed to migrate auction bidding: %w", err)
+	}
+	// 迁移7: 添加消耗品效果相关列到items表
+	if err := migrateItemConsumableEffects(); err != nil {
+		return fmt.Errorf("failed to migrate item consumable effects: %w", err)
+	}
+	// 迁移8: 添加message_type列到chat_messages表
+	if err := migrateChatMessageType(); err != nil {
+		return fmt.Errorf("failed to migrate chat message type: %w", err)
+	}
+	// 迁移9: 添加role列到users表
+	if err := migrateUserRole(); err != nil {
+		return fmt.Errorf("failed to migrate user role: %w", err)
+	}
+	// 迁移10: 添加guild_id列到chat_messages表
+	if err := migrateChatGuildID(); err != nil {
+		return fmt.Errorf("failed to migrate chat guild_id: %w", err)
+	}
 	return nil
 }
 
+// hasColumn 检查表中是否存在指定列
+func hasColumn(table, column string) (bool, error) {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid int
+		var name, colType string
+		var notNull, pk int
+		var dfltValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // migrateDodgeRate 添加dodge_rate列到characters表
 func migrateDodgeRate() error {
 	// 检查列是否已存在
@@ -512,3 +563,189 @@ func migrateConfigVersions() error {
 	debugLog("config_versions table created successfully")
 	return nil
 }
+
+// migrateMonsterFamily 添加family列到monsters表（图鉴族群加成使用）
+func migrateMonsterFamily() error {
+	exists, err := hasColumn("monsters", "family")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	debugLog("Adding family column to monsters table...")
+	if _, err := DB.Exec("ALTER TABLE monsters ADD COLUMN family VARCHAR(32)"); err != nil {
+		return fmt.Errorf("failed to add family column: %w", err)
+	}
+	debugLog("family column added successfully")
+	return nil
+}
+
+// migrateZoneStaminaCost 添加stamina_cost列到zones表
+func migrateZoneStaminaCost() error {
+	exists, err := hasColumn("zones", "stamina_cost")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	debugLog("Adding stamina_cost column to zones table...")
+	if _, err := DB.Exec("ALTER TABLE zones ADD COLUMN stamina_cost INTEGER"); err != nil {
+		return fmt.Errorf("failed to add stamina_cost column: %w", err)
+	}
+	debugLog("stamina_cost column added successfully")
+	return nil
+}
+
+// migrateAuctionBidding 添加竞价相关列到auction_listings表
+func migrateAuctionBidding() error {
+	// 检查表是否存在
+	var tableName string
+	err := DB.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='auction_listings'").Scan(&tableName)
+	if err == sql.ErrNoRows {
+		// 表不存在，将由 schema.sql 创建
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	columns := []struct {
+		name       string
+		definition string
+	}{
+		{"starting_bid", "INTEGER"},
+		{"min_increment", "INTEGER DEFAULT 0"},
+		{"current_bid", "INTEGER"},
+		{"current_bidder_id", "INTEGER"},
+		{"bid_count", "INTEGER DEFAULT 0"},
+		{"sold_price", "INTEGER"},
+	}
+
+	for _, column := range columns {
+		exists, err := hasColumn("auction_listings", column.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		debugLog("Adding %s column to auction_listings table...", column.name)
+		if _, err := DB.Exec(fmt.Sprintf("ALTER TABLE auction_listings ADD COLUMN %s %s", column.name, column.definition)); err != nil {
+			return fmt.Errorf("failed to add %s column: %w", column.name, err)
+		}
+	}
+	return nil
+}
+
+// migrateItemConsumableEffects 添加消耗品效果相关列到items表
+func migrateItemConsumableEffects() error {
+	// 检查表是否存在
+	var tableName string
+	err := DB.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='items'").Scan(&tableName)
+	if err == sql.ErrNoRows {
+		// 表不存在，将由 schema.sql 创建
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	columns := []struct {
+		name       string
+		definition string
+	}{
+		{"effect_stat", "VARCHAR(32)"},
+		{"effect_duration", "INTEGER"},
+	}
+
+	for _, column := range columns {
+		exists, err := hasColumn("items", column.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		debugLog("Adding %s column to items table...", column.name)
+		if _, err := DB.Exec(fmt.Sprintf("ALTER TABLE items ADD COLUMN %s %s", column.name, column.definition)); err != nil {
+			return fmt.Errorf("failed to add %s column: %w", column.name, err)
+		}
+	}
+	return nil
+}
+
+// migrateChatMessageType 添加message_type列到chat_messages表（表情与掷骰消息）
+func migrateChatMessageType() error {
+	var tableName string
+	err := DB.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='chat_messages'").Scan(&tableName)
+	if err == sql.ErrNoRows {
+		// 表不存在，将由 schema.sql 创建
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	exists, err := hasColumn("chat_messages", "message_type")
+	if err != nil || exists {
+		return err
+	}
+
+	debugLog("Adding message_type column to chat_messages table...")
+	_, err = DB.Exec("ALTER TABLE chat_messages ADD COLUMN message_type VARCHAR(16) DEFAULT 'say'")
+	return err
+}
+
+// migrateUserRole 添加role列到users表（玩家/GM权限）
+func migrateUserRole() error {
+	var tableName string
+	err := DB.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='users'").Scan(&tableName)
+	if err == sql.ErrNoRows {
+		// 表不存在，将由 schema.sql 创建
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	exists, err := hasColumn("users", "role")
+	if err != nil || exists {
+		return err
+	}
+
+	debugLog("Adding role column to users table...")
+	_, err = DB.Exec("ALTER TABLE users ADD COLUMN role VARCHAR(16) DEFAULT 'player'")
+	return err
+}
+
+// migrateChatGuildID 添加guild_id列到chat_messages表（公会频道）
+func migrateChatGuildID() error {
+	var tableName string
+	err := DB.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='chat_messages'").Scan(&tableName)
+	if err == sql.ErrNoRows {
+		// 表不存在，将由 schema.sql 创建
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	exists, err := hasColumn("chat_messages", "guild_id")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		debugLog("Adding guild_id column to chat_messages table...")
+		if _, err := DB.Exec("ALTER TABLE chat_messages ADD COLUMN guild_id INTEGER"); err != nil {
+			return err
+		}
+	}
+	_, err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_chat_guild ON chat_messages(guild_id, created_at DESC)")
+	return err
+}
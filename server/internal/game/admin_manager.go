@@ -0,0 +1,566 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"text-wow/internal/auth"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// GM管理错误
+var (
+	ErrAdminForbidden         = errors.New("insufficient permissions")
+	ErrAdminUserNotFound      = errors.New("user not found")
+	ErrAdminTargetRank        = errors.New("cannot act on a user with an equal or higher role")
+	ErrAdminInvalidAmount     = errors.New("gold amount must be non-zero and within 1,000,000,000")
+	ErrAdminItemNotFound      = errors.New("item not found")
+	ErrAdminInvalidQuantity   = errors.New("quantity must be between 1 and 1000")
+	ErrAdminCharacterNotFound = errors.New("character not found for this user")
+	ErrAdminZoneNotFound      = errors.New("zone not found")
+	ErrAdminInvalidRole       = errors.New("unknown role")
+	ErrAdminInvalidBan        = errors.New("ban duration must be between 0 and 365 days")
+	ErrAdminNotBanned         = errors.New("user is not banned")
+	ErrAdminUnknownConfig     = errors.New("unknown config type")
+)
+
+// GM操作类型（审计日志）
+const (
+	AdminActionGrantGold    = "grant_gold"
+	AdminActionGrantItem    = "grant_item"
+	AdminActionTeleport     = "teleport"
+	AdminActionResetSession = "reset_session"
+	AdminActionStopBattle   = "stop_battle"
+	AdminActionKillBattle   = "kill_battle"
+	AdminActionBan          = "ban"
+	AdminActionUnban        = "unban"
+	AdminActionSetRole      = "set_role"
+	AdminActionReloadConfig = "reload_config"
+	AdminActionStartSeason  = "start_season"
+	AdminActionAnnounce     = "announce"
+	AdminActionBootstrap    = "bootstrap_admin"
+)
+
+// EnvAdminUsernames 启动时提升为管理员的用户名列表（逗号分隔），用于创建第一个管理员
+const EnvAdminUsernames = "ADMIN_USERNAMES"
+
+// 可热重载的配置
+const (
+	AdminConfigZones          = "zones"
+	AdminConfigMonsters       = "monsters"
+	AdminConfigStamina        = "stamina"
+	AdminConfigChatModeration = "chat_moderation"
+	AdminConfigSigningKeys    = "signing_keys"
+)
+
+const (
+	adminMaxGoldAmount   = 1000000000
+	adminMaxItemQuantity = 1000
+	adminMaxBanDuration  = 365 * 24 * time.Hour
+	adminAuditMaxLimit   = 200
+	adminAuditLimit      = 50
+)
+
+// adminConfigTypes 重载全部配置时的顺序
+var adminConfigTypes = []string{
+	AdminConfigZones, AdminConfigMonsters, AdminConfigStamina, AdminConfigChatModeration, AdminConfigSigningKeys,
+}
+
+// AdminManager GM管理器 - 按角色权限执行GM操作并写入审计日志
+type AdminManager struct {
+	userRepo      *repository.UserRepository
+	charRepo      *repository.CharacterRepository
+	gameRepo      *repository.GameRepository
+	inventoryRepo *repository.InventoryRepository
+	economyMgr    *EconomyManager
+	sessionRepo   *repository.SessionRepository
+	adminRepo     *repository.AdminRepository
+	battleMgr     *BattleManager
+	moderationMgr *ChatModerationManager
+	eventHub      *EventHub
+	loginLimiter  *LoginLimiter
+	leaderboard   *LeaderboardManager
+	announcer     *AnnouncementManager
+}
+
+// NewAdminManager 创建GM管理器
+func NewAdminManager() *AdminManager {
+	return &AdminManager{
+		userRepo:      repository.NewUserRepository(),
+		charRepo:      repository.NewCharacterRepository(),
+		gameRepo:      repository.NewGameRepository(),
+		inventoryRepo: repository.NewInventoryRepository(),
+		economyMgr:    NewEconomyManager(),
+		sessionRepo:   repository.NewSessionRepository(),
+		adminRepo:     repository.NewAdminRepository(),
+		battleMgr:     GetBattleManager(),
+		moderationMgr: GetChatModerationManager(),
+		eventHub:      GetEventHub(),
+		loginLimiter:  GetLoginLimiter(),
+		leaderboard:   GetLeaderboardManager(),
+		announcer:     GetAnnouncementManager(),
+	}
+}
+
+// 全局GM管理器实例
+var adminManager *AdminManager
+var adminOnce sync.Once
+
+// GetAdminManager 获取GM管理器单例
+func GetAdminManager() *AdminManager {
+	adminOnce.Do(func() {
+		adminManager = NewAdminManager()
+	})
+	return adminManager
+}
+
+// ═══════════════════════════════════════════════════════════
+// 查看与处罚（版主及以上）
+// ═══════════════════════════════════════════════════════════
+
+// GetUserState 查看玩家的账号、角色、战斗与封禁状态
+func (m *AdminManager) GetUserState(actorID, userID int) (*models.AdminUserState, error) {
+	if _, err := m.requireRole(actorID, models.RoleModerator); err != nil {
+		return nil, err
+	}
+	user, err := m.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := m.userRepo.GetRole(userID)
+	if err != nil {
+		return nil, err
+	}
+	characters, err := m.charRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sessions, err := m.sessionRepo.CountActive(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	ban, err := m.adminRepo.GetActiveBan(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AdminUserState{
+		User:           user,
+		Role:           role,
+		Characters:     characters,
+		Battle:         m.battleMgr.GetBattleStatus(userID),
+		Online:         m.eventHub.IsOnline(userID),
+		ActiveSessions: sessions,
+		Ban:            ban,
+	}, nil
+}
+
+// FindUser 按用户名查找玩家ID
+func (m *AdminManager) FindUser(actorID int, username string) (int, error) {
+	if _, err := m.requireRole(actorID, models.RoleModerator); err != nil {
+		return 0, err
+	}
+	user, err := m.userRepo.GetByUsername(strings.TrimSpace(username))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAdminUserNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// Ban 封禁账号（duration 为0表示永久）：注销所有登录会话、停止战斗并断开实时连接
+func (m *AdminManager) Ban(actorID, userID int, reason string, duration time.Duration) (*models.AccountBan, error) {
+	if err := m.requireOutranks(actorID, userID, models.RoleModerator); err != nil {
+		return nil, err
+	}
+	if duration < 0 || duration > adminMaxBanDuration {
+		return nil, ErrAdminInvalidBan
+	}
+
+	now := time.Now()
+	ban := &models.AccountBan{
+		UserID:    userID,
+		Reason:    strings.TrimSpace(reason),
+		IssuedBy:  actorID,
+		CreatedAt: now,
+	}
+	if duration > 0 {
+		expiresAt := now.Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+	ban, err := m.adminRepo.CreateBan(ban)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.sessionRepo.RevokeAll(userID, now); err != nil {
+		return nil, err
+	}
+	m.battleMgr.StopBattle(userID)
+	m.eventHub.Disconnect(userID)
+
+	m.audit(actorID, AdminActionBan, &userID, fmt.Sprintf("duration=%s reason=%s", duration, ban.Reason))
+	return ban, nil
+}
+
+// Unban 解除账号封禁
+func (m *AdminManager) Unban(actorID, userID int) error {
+	if err := m.requireOutranks(actorID, userID, models.RoleModerator); err != nil {
+		return err
+	}
+	revoked, err := m.adminRepo.RevokeBans(userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrAdminNotBanned
+	}
+	m.audit(actorID, AdminActionUnban, &userID, "")
+	return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// 游戏干预（GM及以上）
+// ═══════════════════════════════════════════════════════════
+
+// GrantGold 发放（正数）或扣除（负数）金币，经济系统统一记账，返回调整后的余额
+func (m *AdminManager) GrantGold(actorID, userID, amount int, note string) (int, error) {
+	if _, err := m.requireRole(actorID, models.RoleGameMaster); err != nil {
+		return 0, err
+	}
+	if amount == 0 || amount > adminMaxGoldAmount || amount < -adminMaxGoldAmount {
+		return 0, ErrAdminInvalidAmount
+	}
+	if _, err := m.getUser(userID); err != nil {
+		return 0, err
+	}
+
+	ref := repository.NewGoldRef("admin", actorID)
+	var err error
+	if amount > 0 {
+		err = m.economyMgr.AddGold(userID, amount, repository.GoldReasonAdminAdjust, ref)
+	} else {
+		err = m.economyMgr.SpendGold(userID, -amount, repository.GoldReasonAdminAdjust, ref)
+	}
+	if err != nil {
+		return 0, err
+	}
+	balance, err := m.economyMgr.GetGold(userID)
+	if err != nil {
+		return 0, err
+	}
+	m.audit(actorID, AdminActionGrantGold, &userID, fmt.Sprintf("amount=%d balance=%d note=%s", amount, balance, strings.TrimSpace(note)))
+	return balance, nil
+}
+
+// GrantItem 向玩家角色背包发放物品
+func (m *AdminManager) GrantItem(actorID, userID, characterID int, itemID string, quantity int) error {
+	if _, err := m.requireRole(actorID, models.RoleGameMaster); err != nil {
+		return err
+	}
+	if quantity < 1 || quantity > adminMaxItemQuantity {
+		return ErrAdminInvalidQuantity
+	}
+	char, err := m.charRepo.GetByID(characterID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && char.UserID != userID) {
+		return ErrAdminCharacterNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := m.gameRepo.GetItemByID(itemID); errors.Is(err, sql.ErrNoRows) {
+		return ErrAdminItemNotFound
+	} else if err != nil {
+		return err
+	}
+	if err := m.inventoryRepo.AddItem(characterID, itemID, quantity); err != nil {
+		return err
+	}
+	m.audit(actorID, AdminActionGrantItem, &userID, fmt.Sprintf("character=%d item=%s quantity=%d", characterID, itemID, quantity))
+	return nil
+}
+
+// Teleport 将玩家传送到指定区域（无视进入条件）
+func (m *AdminManager) Teleport(actorID, userID int, zoneID string) (*models.Zone, error) {
+	if _, err := m.requireRole(actorID, models.RoleGameMaster); err != nil {
+		return nil, err
+	}
+	if _, err := m.getUser(userID); err != nil {
+		return nil, err
+	}
+	zone, err := m.battleMgr.TeleportZone(userID, zoneID)
+	if err != nil {
+		return nil, ErrAdminZoneNotFound
+	}
+	m.audit(actorID, AdminActionTeleport, &userID, "zone="+zone.ID)
+	return zone, nil
+}
+
+// ResetSession 重置玩家的战斗会话（卡死时使用）
+func (m *AdminManager) ResetSession(actorID, userID int) error {
+	if _, err := m.requireRole(actorID, models.RoleGameMaster); err != nil {
+		return err
+	}
+	if _, err := m.getUser(userID); err != nil {
+		return err
+	}
+	existed := m.battleMgr.ResetSession(userID)
+	m.audit(actorID, AdminActionResetSession, &userID, fmt.Sprintf("existed=%t", existed))
+	return nil
+}
+
+// StopBattle 停止玩家的自动战斗；kill 为 true 时同时强制结束当前遭遇（不结算奖励）
+func (m *AdminManager) StopBattle(actorID, userID int, kill bool) error {
+	if _, err := m.requireRole(actorID, models.RoleGameMaster); err != nil {
+		return err
+	}
+	if _, err := m.getUser(userID); err != nil {
+		return err
+	}
+	if kill {
+		inCombat := m.battleMgr.AbortBattle(userID)
+		m.audit(actorID, AdminActionKillBattle, &userID, fmt.Sprintf("in_combat=%t", inCombat))
+		return nil
+	}
+	if err := m.battleMgr.StopBattle(userID); err != nil {
+		return err
+	}
+	m.audit(actorID, AdminActionStopBattle, &userID, "")
+	return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// 服务器管理（管理员）
+// ═══════════════════════════════════════════════════════════
+
+// SetRole 设置玩家角色（与其他操作一样只能作用于角色低于自己的账号，管理员之间不能互相修改角色）
+func (m *AdminManager) SetRole(actorID, userID int, role string) error {
+	if err := m.requireOutranks(actorID, userID, models.RoleAdmin); err != nil {
+		return err
+	}
+	if !models.IsValidRole(role) {
+		return ErrAdminInvalidRole
+	}
+	if err := m.userRepo.SetRole(userID, role); err != nil {
+		return err
+	}
+	m.audit(actorID, AdminActionSetRole, &userID, "role="+role)
+	return nil
+}
+
+// BootstrapAdmins 将指定用户名的账号提升为管理员（服务器启动时按 ADMIN_USERNAMES 执行，不做操作者权限检查）
+// 返回本次提升的用户名和尚未注册的用户名；已是管理员的账号不重复记录
+func (m *AdminManager) BootstrapAdmins(usernames []string) (promoted, missing []string, err error) {
+	for _, username := range usernames {
+		user, err := m.userRepo.GetByUsername(username)
+		if errors.Is(err, sql.ErrNoRows) {
+			missing = append(missing, username)
+			continue
+		}
+		if err != nil {
+			return promoted, missing, err
+		}
+		role, err := m.userRepo.GetRole(user.ID)
+		if err != nil {
+			return promoted, missing, err
+		}
+		if role == models.RoleAdmin {
+			continue
+		}
+		if err := m.userRepo.SetRole(user.ID, models.RoleAdmin); err != nil {
+			return promoted, missing, err
+		}
+		m.audit(user.ID, AdminActionBootstrap, &user.ID, fmt.Sprintf("role=%s previous=%s source=%s", models.RoleAdmin, role, EnvAdminUsernames))
+		promoted = append(promoted, username)
+	}
+	return promoted, missing, nil
+}
+
+// ParseAdminUsernames 解析逗号分隔的用户名列表（忽略空白与空项）
+func ParseAdminUsernames(value string) []string {
+	usernames := make([]string, 0)
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" && !containsString(usernames, name) {
+			usernames = append(usernames, name)
+		}
+	}
+	return usernames
+}
+
+// ReloadConfig 热重载配置（types 为空时重载全部），返回已重载的配置
+func (m *AdminManager) ReloadConfig(actorID int, types []string) ([]string, error) {
+	if _, err := m.requireRole(actorID, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		types = adminConfigTypes
+	}
+	for _, configType := range types {
+		if !containsString(adminConfigTypes, configType) {
+			return nil, fmt.Errorf("%w: %s", ErrAdminUnknownConfig, configType)
+		}
+	}
+
+	reloaded := make([]string, 0, len(types))
+	for _, configType := range types {
+		if err := m.reloadConfig(configType); err != nil {
+			return reloaded, fmt.Errorf("failed to reload %s: %w", configType, err)
+		}
+		reloaded = append(reloaded, configType)
+	}
+	m.audit(actorID, AdminActionReloadConfig, nil, strings.Join(reloaded, ","))
+	return reloaded, nil
+}
+
+func (m *AdminManager) reloadConfig(configType string) error {
+	switch configType {
+	case AdminConfigZones:
+		return m.battleMgr.zoneManager.ReloadAllZones()
+	case AdminConfigMonsters:
+		return m.battleMgr.monsterManager.ReloadAllMonsterConfigs()
+	case AdminConfigStamina:
+		return m.battleMgr.staminaManager.ReloadConfig()
+	case AdminConfigChatModeration:
+		return m.moderationMgr.ReloadConfig()
+	case AdminConfigSigningKeys:
+		// 未配置签名密钥时保持当前密钥环
+		ring, err := auth.LoadKeyRing()
+		if errors.Is(err, auth.ErrNoSigningKeys) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		auth.SetKeyRing(ring)
+	}
+	return nil
+}
+
+// StartLeaderboardSeason 结束当前排行榜赛季并开启新赛季
+func (m *AdminManager) StartLeaderboardSeason(actorID int, name string, now time.Time) (*models.LeaderboardSeason, error) {
+	if _, err := m.requireRole(actorID, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	season, err := m.leaderboard.StartSeason(name, now)
+	if err != nil {
+		return nil, err
+	}
+	m.audit(actorID, AdminActionStartSeason, nil, fmt.Sprintf("season=%d name=%s", season.ID, season.Name))
+	return season, nil
+}
+
+// PostAnnouncement 发布全服公告（duration 为 0 时永不过期）
+func (m *AdminManager) PostAnnouncement(actorID int, content string, importance int, duration time.Duration) (*models.ServerAnnouncement, error) {
+	if _, err := m.requireRole(actorID, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	announcement, err := m.announcer.PostAdmin(content, importance, duration)
+	if err != nil {
+		return nil, err
+	}
+	m.audit(actorID, AdminActionAnnounce, nil, fmt.Sprintf("announcement=%d", announcement.ID))
+	return announcement, nil
+}
+
+// GetAuditLog 获取GM操作审计日志（targetUserID 为0表示全部）
+func (m *AdminManager) GetAuditLog(actorID, targetUserID, beforeID, limit int) ([]*models.AdminAuditEntry, error) {
+	if _, err := m.requireRole(actorID, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = adminAuditLimit
+	}
+	if limit > adminAuditMaxLimit {
+		limit = adminAuditMaxLimit
+	}
+	return m.adminRepo.GetAuditLog(targetUserID, beforeID, limit)
+}
+
+// GetLoginAttempts 获取登录尝试审计记录（username/ip 为空表示不限）
+func (m *AdminManager) GetLoginAttempts(actorID int, username, ip string, beforeID, limit int) ([]*models.LoginAttempt, error) {
+	if _, err := m.requireRole(actorID, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = adminAuditLimit
+	}
+	if limit > adminAuditMaxLimit {
+		limit = adminAuditMaxLimit
+	}
+	return m.loginLimiter.GetAttempts(username, ip, beforeID, limit)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 权限检查
+// ═══════════════════════════════════════════════════════════
+
+// requireRole 检查操作者角色不低于 required，返回其角色
+func (m *AdminManager) requireRole(actorID int, required string) (string, error) {
+	role, err := m.userRepo.GetRole(actorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrAdminForbidden
+	}
+	if err != nil {
+		return "", err
+	}
+	if !models.RoleAtLeast(role, required) {
+		return "", ErrAdminForbidden
+	}
+	return role, nil
+}
+
+// requireOutranks 检查操作者角色不低于 required 且高于目标玩家
+func (m *AdminManager) requireOutranks(actorID, userID int, required string) error {
+	role, err := m.requireRole(actorID, required)
+	if err != nil {
+		return err
+	}
+	targetRole, err := m.userRepo.GetRole(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAdminUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if actorID == userID || models.RoleAtLeast(targetRole, role) {
+		return ErrAdminTargetRank
+	}
+	return nil
+}
+
+func (m *AdminManager) getUser(userID int) (*models.User, error) {
+	user, err := m.userRepo.GetByID(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAdminUserNotFound
+	}
+	return user, err
+}
+
+// audit 写入审计日志（失败只记录日志，操作本身已生效）
+func (m *AdminManager) audit(actorID int, action string, targetUserID *int, details string) {
+	err := m.adminRepo.LogAction(&models.AdminAuditEntry{
+		ActorID:      actorID,
+		Action:       action,
+		TargetUserID: targetUserID,
+		Details:      details,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		fmt.Printf("[WARN] Failed to write admin audit log (%s): %v\n", action, err)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
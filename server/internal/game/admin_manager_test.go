@@ -0,0 +1,239 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAdminTest(t *testing.T, roles ...string) (*AdminManager, []int, func()) {
+	names := []string{"admin", "gm", "mod", "player"}[:len(roles)]
+	testDB, _, users := setupTradingTest(t, names...)
+	userRepo := repository.NewUserRepository()
+	for i, role := range roles {
+		assert.NoError(t, userRepo.SetRole(users[i], role))
+	}
+
+	am := NewAdminManager()
+	am.battleMgr = NewBattleManager()
+	am.eventHub = NewEventHub()
+	return am, users, func() { database.TeardownTestDB(testDB) }
+}
+
+func TestAdminManager_GameMasterActions(t *testing.T) {
+	am, users, cleanup := setupAdminTest(t, models.RoleAdmin, models.RoleGameMaster, models.RoleModerator, models.RolePlayer)
+	defer cleanup()
+	admin, gm, mod, player := users[0], users[1], users[2], users[3]
+
+	char, err := repository.NewCharacterRepository().Create(&models.Character{
+		UserID: player, Name: "Playerchar", RaceID: "human", ClassID: "warrior", Faction: "alliance",
+		TeamSlot: 1, IsActive: true, Level: 1, HP: 100, MaxHP: 100, ResourceType: "rage", MaxResource: 100,
+	})
+	assert.NoError(t, err)
+
+	// 版主只能查看，不能发放
+	_, err = am.GrantGold(mod, player, 500, "")
+	assert.ErrorIs(t, err, ErrAdminForbidden)
+	_, err = am.GetUserState(player, mod)
+	assert.ErrorIs(t, err, ErrAdminForbidden)
+
+	balance, err := am.GrantGold(gm, player, 500, "补偿")
+	assert.NoError(t, err)
+	assert.Equal(t, 1500, balance)
+	entries, err := NewEconomyManager().GetLedger(player, repository.GoldReasonAdminAdjust, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 500, entries[0].Delta)
+	assert.Equal(t, 1500, entries[0].BalanceAfter)
+	_, err = am.GrantGold(gm, player, -5000, "")
+	assert.ErrorIs(t, err, repository.ErrInsufficientGold)
+	_, err = am.GrantGold(gm, player, 0, "")
+	assert.ErrorIs(t, err, ErrAdminInvalidAmount)
+
+	assert.ErrorIs(t, am.GrantItem(gm, gm, char.ID, "trade_sword", 1), ErrAdminCharacterNotFound)
+	assert.ErrorIs(t, am.GrantItem(gm, player, char.ID, "no_such_item", 1), ErrAdminItemNotFound)
+	assert.NoError(t, am.GrantItem(gm, player, char.ID, "trade_sword", 1))
+
+	_, err = am.Teleport(gm, player, "nowhere")
+	assert.ErrorIs(t, err, ErrAdminZoneNotFound)
+	zone, err := am.Teleport(gm, player, "durotar")
+	assert.NoError(t, err)
+	assert.Equal(t, "durotar", zone.ID)
+
+	assert.NoError(t, am.StopBattle(gm, player, true))
+	assert.NoError(t, am.ResetSession(gm, player))
+	assert.Nil(t, am.battleMgr.GetSession(player))
+
+	state, err := am.GetUserState(mod, player)
+	assert.NoError(t, err)
+	assert.Equal(t, 1500, state.User.Gold)
+	assert.Equal(t, "durotar", state.User.CurrentZoneID)
+	assert.Len(t, state.Characters, 1)
+	assert.Nil(t, state.Ban)
+
+	// 角色与配置只有管理员可修改
+	assert.ErrorIs(t, am.SetRole(gm, player, models.RoleModerator), ErrAdminForbidden)
+	assert.ErrorIs(t, am.SetRole(admin, player, "king"), ErrAdminInvalidRole)
+	assert.ErrorIs(t, am.SetRole(admin, admin, models.RolePlayer), ErrAdminTargetRank)
+	assert.NoError(t, am.SetRole(admin, player, models.RoleModerator))
+	_, err = am.ReloadConfig(admin, []string{"weather"})
+	assert.ErrorIs(t, err, ErrAdminUnknownConfig)
+	reloaded, err := am.ReloadConfig(admin, []string{AdminConfigZones, AdminConfigStamina})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{AdminConfigZones, AdminConfigStamina}, reloaded)
+
+	// 每个成功的操作都有审计记录
+	_, err = am.GetAuditLog(gm, 0, 0, 0)
+	assert.ErrorIs(t, err, ErrAdminForbidden)
+	log, err := am.GetAuditLog(admin, player, 0, 0)
+	assert.NoError(t, err)
+	actions := make([]string, 0, len(log))
+	for _, e := range log {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{AdminActionSetRole, AdminActionResetSession, AdminActionKillBattle,
+		AdminActionTeleport, AdminActionGrantItem, AdminActionGrantGold}, actions)
+	assert.Equal(t, "admin", log[0].ActorName)
+}
+
+func TestAdminManager_BanAndUnban(t *testing.T) {
+	am, users, cleanup := setupAdminTest(t, models.RoleAdmin, models.RoleGameMaster, models.RoleModerator, models.RolePlayer)
+	defer cleanup()
+	admin, gm, mod, player := users[0], users[1], users[2], users[3]
+
+	sessionRepo := repository.NewSessionRepository()
+	now := time.Now()
+	for _, id := range []string{"phone", "laptop"} {
+		assert.NoError(t, sessionRepo.Create(&models.AuthSession{
+			ID: id, UserID: player, CreatedAt: now, LastUsedAt: now, ExpiresAt: now.Add(time.Hour),
+		}, id+"-hash"))
+	}
+	sub := am.eventHub.Subscribe(player, "player", "alliance", "elwynn")
+
+	// 只能处罚角色低于自己的玩家
+	_, err := am.Ban(mod, gm, "", 0)
+	assert.ErrorIs(t, err, ErrAdminTargetRank)
+	_, err = am.Ban(mod, mod, "", 0)
+	assert.ErrorIs(t, err, ErrAdminTargetRank)
+	_, err = am.Ban(player, mod, "", 0)
+	assert.ErrorIs(t, err, ErrAdminForbidden)
+	_, err = am.Ban(mod, player, "", 400*24*time.Hour)
+	assert.ErrorIs(t, err, ErrAdminInvalidBan)
+
+	ban, err := am.Ban(mod, player, " 使用外挂 ", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, "使用外挂", ban.Reason)
+	assert.NotNil(t, ban.ExpiresAt)
+
+	// 封禁注销所有会话并断开实时连接
+	active, _ := sessionRepo.CountActive(player, time.Now())
+	assert.Equal(t, 0, active)
+	select {
+	case <-sub.Done():
+	default:
+		t.Fatal("Expected realtime connection to be closed")
+	}
+	state, err := am.GetUserState(admin, player)
+	assert.NoError(t, err)
+	if assert.NotNil(t, state.Ban) {
+		assert.Equal(t, ban.ID, state.Ban.ID)
+	}
+
+	assert.NoError(t, am.Unban(mod, player))
+	assert.ErrorIs(t, am.Unban(mod, player), ErrAdminNotBanned)
+	ban, err = repository.NewAdminRepository().GetActiveBan(player, time.Now())
+	assert.NoError(t, err)
+	assert.Nil(t, ban)
+
+	// 管理员可处罚GM
+	_, err = am.Ban(admin, gm, "", 0)
+	assert.NoError(t, err)
+	ban, _ = repository.NewAdminRepository().GetActiveBan(gm, time.Now())
+	if assert.NotNil(t, ban) {
+		assert.Nil(t, ban.ExpiresAt)
+	}
+}
+
+func TestAdminManager_StartLeaderboardSeason(t *testing.T) {
+	am, users, cleanup := setupAdminTest(t, models.RoleAdmin, models.RoleGameMaster)
+	defer cleanup()
+	admin, gm := users[0], users[1]
+	am.leaderboard = NewLeaderboardManager()
+	now := time.Now()
+
+	_, err := am.StartLeaderboardSeason(gm, "第2赛季", now)
+	assert.ErrorIs(t, err, ErrAdminForbidden)
+	_, err = am.StartLeaderboardSeason(admin, "  ", now)
+	assert.ErrorIs(t, err, ErrInvalidSeasonName)
+
+	season, err := am.StartLeaderboardSeason(admin, "第2赛季", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "第2赛季", season.Name)
+
+	log, err := am.GetAuditLog(admin, 0, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, log, 1)
+	assert.Equal(t, AdminActionStartSeason, log[0].Action)
+	assert.Nil(t, log[0].TargetUserID)
+}
+
+func TestAdminManager_PostAnnouncement(t *testing.T) {
+	am, users, cleanup := setupAdminTest(t, models.RoleAdmin, models.RoleGameMaster)
+	defer cleanup()
+	admin, gm := users[0], users[1]
+	am.announcer = NewAnnouncementManager()
+	am.announcer.eventHub = am.eventHub
+
+	_, err := am.PostAnnouncement(gm, "hello", 3, 0)
+	assert.ErrorIs(t, err, ErrAdminForbidden)
+	_, err = am.PostAnnouncement(admin, "   ", 3, 0)
+	assert.ErrorIs(t, err, ErrInvalidAnnouncement)
+
+	announcement, err := am.PostAnnouncement(admin, "服务器将在今晚维护", 5, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AnnouncementAdmin, announcement.Type)
+
+	log, err := am.GetAuditLog(admin, 0, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, log, 1)
+	assert.Equal(t, AdminActionAnnounce, log[0].Action)
+}
+
+func TestAdminManager_BootstrapAdmins(t *testing.T) {
+	am, users, cleanup := setupAdminTest(t, models.RolePlayer, models.RoleAdmin)
+	defer cleanup()
+	first, existing := users[0], users[1]
+
+	usernames := ParseAdminUsernames(" admin, ,gm,nobody,admin ")
+	assert.Equal(t, []string{"admin", "gm", "nobody"}, usernames)
+
+	promoted, missing, err := am.BootstrapAdmins(usernames)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, promoted, "已是管理员的账号不重复提升")
+	assert.Equal(t, []string{"nobody"}, missing)
+
+	role, err := repository.NewUserRepository().GetRole(first)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, role)
+
+	// 重复执行不再产生审计记录
+	promoted, _, err = am.BootstrapAdmins(usernames)
+	assert.NoError(t, err)
+	assert.Empty(t, promoted)
+	log, err := am.GetAuditLog(first, first, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, log, 1)
+	assert.Equal(t, AdminActionBootstrap, log[0].Action)
+
+	// 管理员之间不能互相修改角色，避免单个管理员账号被盗后降级其他管理员
+	assert.ErrorIs(t, am.SetRole(first, existing, models.RolePlayer), ErrAdminTargetRank)
+	assert.ErrorIs(t, am.SetRole(existing, first, models.RolePlayer), ErrAdminTargetRank)
+	role, err = repository.NewUserRepository().GetRole(existing)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, role)
+}
@@ -0,0 +1,264 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 全服公告错误
+var (
+	ErrInvalidAnnouncement       = errors.New("announcement must be 1-200 characters")
+	ErrInvalidAnnouncementLevel  = errors.New("importance must be between 1 and 5")
+	ErrInvalidAnnouncementExpiry = errors.New("announcement duration must be between 0 and 30 days")
+)
+
+const (
+	announcementDefaultExpiry = 24 * time.Hour      // 自动公告有效期
+	announcementMaxExpiry     = 30 * 24 * time.Hour // 管理员公告最长有效期
+	announcementMaxLength     = 200
+	announcementDefaultLimit  = 50
+	announcementMaxLimit      = 100
+	announcementStreakStep    = 5 // 每达到5连胜公告一次
+	systemSenderName          = "系统"
+)
+
+// AnnouncementManager 全服公告管理器 - 自动事件公告、管理员公告与系统频道推送
+type AnnouncementManager struct {
+	repo     *repository.AnnouncementRepository
+	userRepo *repository.UserRepository
+	eventHub *EventHub
+}
+
+// NewAnnouncementManager 创建全服公告管理器
+func NewAnnouncementManager() *AnnouncementManager {
+	return &AnnouncementManager{
+		repo:     repository.NewAnnouncementRepository(),
+		userRepo: repository.NewUserRepository(),
+		eventHub: GetEventHub(),
+	}
+}
+
+// 全局公告管理器实例
+var announcementManager *AnnouncementManager
+var announcementOnce sync.Once
+
+// GetAnnouncementManager 获取全服公告管理器单例
+func GetAnnouncementManager() *AnnouncementManager {
+	announcementOnce.Do(func() {
+		announcementManager = NewAnnouncementManager()
+	})
+	return announcementManager
+}
+
+// ═══════════════════════════════════════════════════════════
+// 发布与查询
+// ═══════════════════════════════════════════════════════════
+
+// Post 保存公告并推送到所有在线玩家的系统频道
+func (m *AnnouncementManager) Post(a *models.ServerAnnouncement) (*models.ServerAnnouncement, error) {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	if a.Importance == 0 {
+		a.Importance = 1
+	}
+	saved, err := m.repo.Create(a)
+	if err != nil {
+		return nil, err
+	}
+	m.eventHub.Broadcast(EventChat, AnnouncementChatMessage(saved), nil)
+	return saved, nil
+}
+
+// PostAdmin 发布管理员公告（duration 为 0 时永不过期；权限检查与审计由 AdminManager 负责）
+func (m *AnnouncementManager) PostAdmin(content string, importance int, duration time.Duration) (*models.ServerAnnouncement, error) {
+	content = strings.TrimSpace(content)
+	if content == "" || utf8.RuneCountInString(content) > announcementMaxLength {
+		return nil, ErrInvalidAnnouncement
+	}
+	if importance < 1 || importance > 5 {
+		return nil, ErrInvalidAnnouncementLevel
+	}
+	if duration < 0 || duration > announcementMaxExpiry {
+		return nil, ErrInvalidAnnouncementExpiry
+	}
+
+	now := time.Now()
+	a := &models.ServerAnnouncement{
+		Type:       models.AnnouncementAdmin,
+		Content:    content,
+		Importance: importance,
+		CreatedAt:  now,
+	}
+	if duration > 0 {
+		expiresAt := now.Add(duration)
+		a.ExpiresAt = &expiresAt
+	}
+	return m.Post(a)
+}
+
+// GetFeed 获取未过期的公告（最新在前）
+func (m *AnnouncementManager) GetFeed(announcementType string, beforeID, limit int) ([]*models.ServerAnnouncement, error) {
+	if limit <= 0 {
+		limit = announcementDefaultLimit
+	}
+	if limit > announcementMaxLimit {
+		limit = announcementMaxLimit
+	}
+	return m.repo.GetActive(time.Now(), announcementType, beforeID, limit)
+}
+
+// GetSystemMessages 以聊天消息形式获取系统频道内容（最新在前）
+func (m *AnnouncementManager) GetSystemMessages(limit int) ([]repository.ChatMessage, error) {
+	announcements, err := m.GetFeed("", 0, limit)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]repository.ChatMessage, 0, len(announcements))
+	for _, a := range announcements {
+		messages = append(messages, *AnnouncementChatMessage(a))
+	}
+	return messages, nil
+}
+
+// AnnouncementChatMessage 将公告转换为系统频道消息
+func AnnouncementChatMessage(a *models.ServerAnnouncement) *repository.ChatMessage {
+	return &repository.ChatMessage{
+		ID:          a.ID,
+		Channel:     "system",
+		ZoneID:      a.ZoneID,
+		SenderName:  systemSenderName,
+		Content:     a.Content,
+		MessageType: "announcement",
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// ═══════════════════════════════════════════════════════════
+// 自动公告（失败只记录日志，不影响游戏流程）
+// ═══════════════════════════════════════════════════════════
+
+// AnnounceBossKill 首领被击杀时检查并公告全服首杀
+func (m *AnnouncementManager) AnnounceBossKill(userID int, monster *models.Monster) {
+	if monster == nil || monster.Type != "boss" {
+		return
+	}
+	first, err := m.repo.RecordFirstKill(monster.ID, userID, time.Now())
+	if err != nil || !first {
+		m.logError("first kill", err)
+		return
+	}
+	m.postAuto(&models.ServerAnnouncement{
+		Type:         models.AnnouncementFirstKill,
+		Content:      fmt.Sprintf("⚔️ %s 的队伍首次击败了首领【%s】，达成全服首杀！", m.playerName(userID), monster.Name),
+		WinnerUserID: &userID,
+		Importance:   4,
+	})
+}
+
+// AnnounceLegendaryDrop 公告传说及以上品质的装备掉落
+func (m *AnnouncementManager) AnnounceLegendaryDrop(userID int, characterName, itemName, quality string, monster *models.Monster) {
+	if quality != "legendary" && quality != "mythic" {
+		return
+	}
+	qualityName := "传说"
+	importance := 3
+	if quality == "mythic" {
+		qualityName = "神话"
+		importance = 4
+	}
+	m.postAuto(&models.ServerAnnouncement{
+		Type:         models.AnnouncementLegendaryDrop,
+		Content:      fmt.Sprintf("✨ %s 击败【%s】获得了%s装备【%s】！", characterName, monster.Name, qualityName, itemName),
+		WinnerUserID: &userID,
+		Importance:   importance,
+	})
+}
+
+// AnnounceZoneCaptured 公告争夺区域被阵营占领
+func (m *AnnouncementManager) AnnounceZoneCaptured(zone *models.Zone, faction string, enc *models.PvPEncounter) {
+	a := &models.ServerAnnouncement{
+		Type:           models.AnnouncementZoneCaptured,
+		Content:        fmt.Sprintf("🏴 %s占领了【%s】！", factionDisplayName(faction), zone.Name),
+		ZoneID:         zone.ID,
+		PvPEncounterID: &enc.ID,
+		Importance:     4,
+	}
+	setEncounterParticipants(a, enc)
+	m.postAuto(a)
+}
+
+// AnnounceWinStreak PVP连胜达到里程碑（每5场）时公告
+func (m *AnnouncementManager) AnnounceWinStreak(enc *models.PvPEncounter, streak int) {
+	if enc.WinnerUserID == nil || streak < announcementStreakStep || streak%announcementStreakStep != 0 {
+		return
+	}
+	importance := 1 + streak/announcementStreakStep
+	if importance > 5 {
+		importance = 5
+	}
+	a := &models.ServerAnnouncement{
+		Type:           models.AnnouncementKillStreak,
+		ZoneID:         enc.ZoneID,
+		PvPEncounterID: &enc.ID,
+		Importance:     importance,
+	}
+	setEncounterParticipants(a, enc)
+	a.Content = fmt.Sprintf("🔥 %s 在PVP中取得了 %d 连胜！", m.playerName(*a.WinnerUserID), streak)
+	m.postAuto(a)
+}
+
+// postAuto 发布有效期为默认时长的自动公告
+func (m *AnnouncementManager) postAuto(a *models.ServerAnnouncement) {
+	a.CreatedAt = time.Now()
+	expiresAt := a.CreatedAt.Add(announcementDefaultExpiry)
+	a.ExpiresAt = &expiresAt
+	_, err := m.Post(a)
+	m.logError(a.Type, err)
+}
+
+func (m *AnnouncementManager) playerName(userID int) string {
+	user, err := m.userRepo.GetByID(userID)
+	if err != nil {
+		return "某位勇士"
+	}
+	return user.Username
+}
+
+func (m *AnnouncementManager) logError(kind string, err error) {
+	if err != nil {
+		fmt.Printf("[WARN] Failed to post %s announcement: %v\n", kind, err)
+	}
+}
+
+// setEncounterParticipants 填写PVP遭遇战的胜负双方
+func setEncounterParticipants(a *models.ServerAnnouncement, enc *models.PvPEncounter) {
+	if enc.WinnerUserID == nil {
+		return
+	}
+	winnerID := *enc.WinnerUserID
+	loserID := enc.DefenderUserID
+	if winnerID == enc.DefenderUserID {
+		loserID = enc.AttackerUserID
+	}
+	a.WinnerUserID = &winnerID
+	a.LoserUserID = &loserID
+}
+
+// factionDisplayName 阵营中文名
+func factionDisplayName(faction string) string {
+	switch faction {
+	case "alliance":
+		return "联盟"
+	case "horde":
+		return "部落"
+	}
+	return faction
+}
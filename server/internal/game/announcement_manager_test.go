@@ -0,0 +1,118 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnouncementManager_AdminPostAndFeed(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "admin", "player", "orc")
+	defer database.TeardownTestDB(testDB)
+	admin, orc := users[0], users[2]
+
+	am := NewAnnouncementManager()
+	am.eventHub = NewEventHub()
+	adminSub := am.eventHub.Subscribe(admin, "admin", "alliance", "elwynn")
+	orcSub := am.eventHub.Subscribe(orc, "orc", "horde", "durotar")
+
+	_, err := am.PostAdmin("   ", 3, 0)
+	assert.ErrorIs(t, err, ErrInvalidAnnouncement)
+	_, err = am.PostAdmin("hello", 6, 0)
+	assert.ErrorIs(t, err, ErrInvalidAnnouncementLevel)
+	_, err = am.PostAdmin("hello", 3, 31*24*time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidAnnouncementExpiry)
+
+	posted, err := am.PostAdmin(" 服务器将在今晚维护 ", 5, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AnnouncementAdmin, posted.Type)
+	assert.Equal(t, "服务器将在今晚维护", posted.Content)
+	assert.NotNil(t, posted.ExpiresAt)
+
+	// 通过系统频道推送给两个阵营
+	for _, sub := range []*Subscriber{adminSub, orcSub} {
+		event := receiveEvent(t, sub)
+		assert.Equal(t, EventChat, event.Type)
+		msg := event.Data.(*repository.ChatMessage)
+		assert.Equal(t, "system", msg.Channel)
+		assert.Equal(t, "announcement", msg.MessageType)
+		assert.Equal(t, posted.ID, msg.ID)
+	}
+
+	// 过期公告不出现在公告栏
+	expired := time.Now().Add(-time.Minute)
+	_, err = am.Post(&models.ServerAnnouncement{
+		Type: models.AnnouncementAdmin, Content: "old", ExpiresAt: &expired, CreatedAt: time.Now().Add(-time.Hour),
+	})
+	assert.NoError(t, err)
+	feed, err := am.GetFeed("", 0, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, feed, 1) {
+		assert.Equal(t, posted.ID, feed[0].ID)
+	}
+	messages, err := am.GetSystemMessages(10)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func TestAnnouncementManager_GameEvents(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice", "orc")
+	defer database.TeardownTestDB(testDB)
+	alice, orc := users[0], users[1]
+
+	am := NewAnnouncementManager()
+	am.eventHub = NewEventHub()
+
+	// 首领首杀只公告一次，普通怪物不公告
+	_, err := testDB.Exec(`
+		INSERT INTO monsters (id, zone_id, name, level, type, hp, physical_attack, magic_attack, physical_defense, magic_defense, speed, exp_reward, gold_min, gold_max, spawn_weight)
+		VALUES ('hogger', 'elwynn', '霍格', 11, 'boss', 500, 20, 5, 5, 5, 10, 200, 10, 20, 1)`)
+	assert.NoError(t, err)
+	boss := &models.Monster{ID: "hogger", Name: "霍格", Type: "boss"}
+	am.AnnounceBossKill(alice, &models.Monster{ID: "wolf", Name: "森林狼", Type: "normal"})
+	am.AnnounceBossKill(alice, boss)
+	am.AnnounceBossKill(orc, boss)
+	feed, _ := am.GetFeed(models.AnnouncementFirstKill, 0, 0)
+	if assert.Len(t, feed, 1) {
+		assert.Contains(t, feed[0].Content, "alice")
+		assert.Equal(t, alice, *feed[0].WinnerUserID)
+	}
+
+	// 传说及以上品质的掉落才公告
+	am.AnnounceLegendaryDrop(alice, "Alicechar", "霜之哀伤", "epic", boss)
+	am.AnnounceLegendaryDrop(alice, "Alicechar", "霜之哀伤", "legendary", boss)
+	feed, _ = am.GetFeed(models.AnnouncementLegendaryDrop, 0, 0)
+	if assert.Len(t, feed, 1) {
+		assert.Contains(t, feed[0].Content, "霜之哀伤")
+	}
+
+	// PVP：区域占领与连胜里程碑
+	result, err := testDB.Exec(`
+		INSERT INTO pvp_encounters (zone_id, attacker_user_id, defender_user_id, attacker_faction, defender_faction)
+		VALUES ('elwynn', ?, ?, 'alliance', 'horde')`, alice, orc)
+	assert.NoError(t, err)
+	encounterID, _ := result.LastInsertId()
+	winner := orc
+	enc := &models.PvPEncounter{ID: int(encounterID), ZoneID: "elwynn", AttackerUserID: alice, DefenderUserID: orc, WinnerUserID: &winner}
+	am.AnnounceZoneCaptured(&models.Zone{ID: "elwynn", Name: "艾尔文森林"}, "horde", enc)
+	am.AnnounceWinStreak(enc, 4)
+	am.AnnounceWinStreak(enc, 10)
+
+	feed, _ = am.GetFeed(models.AnnouncementZoneCaptured, 0, 0)
+	if assert.Len(t, feed, 1) {
+		assert.Equal(t, "🏴 部落占领了【艾尔文森林】！", feed[0].Content)
+		assert.Equal(t, "elwynn", feed[0].ZoneID)
+	}
+	feed, _ = am.GetFeed(models.AnnouncementKillStreak, 0, 0)
+	if assert.Len(t, feed, 1) {
+		assert.Equal(t, orc, *feed[0].WinnerUserID)
+		assert.Equal(t, alice, *feed[0].LoserUserID)
+		assert.Equal(t, 3, feed[0].Importance)
+		assert.Contains(t, feed[0].Content, "10 连胜")
+	}
+}
@@ -19,6 +19,7 @@ type BattleManager struct {
 	sessions            map[int]*BattleSession // key: userID
 	gameRepo            *repository.GameRepository
 	charRepo            *repository.CharacterRepository
+	userRepo            *repository.UserRepository
 	explorationRepo     *repository.ExplorationRepository // 探索度仓库
 	inventoryRepo       *repository.InventoryRepository   // 背包仓库
 	skillManager        *SkillManager
@@ -34,6 +35,14 @@ type BattleManager struct {
 	zoneManager          *ZoneManager          // 地图管理系统
 	equipmentManager     *EquipmentManager     // 装备管理系统
 	battleStatsCollector *BattleStatsCollector // 战斗统计收集器
+	codexManager         *CodexManager         // 图鉴系统
+	pvpManager           *PvPManager           // 阵营PVP系统
+	honorManager         *HonorManager         // 荣誉军衔与荣誉商店
+	staminaManager       *StaminaManager       // 体力消耗与恢复
+	consumableManager    *ConsumableManager    // 药水、药剂与食物
+	gatheringManager     *GatheringManager     // 区域采集
+	eventHub             *EventHub             // 实时推送
+	announcementMgr      *AnnouncementManager  // 全服公告
 
 	// 用户自定义统计会话管理
 	statsSessions   map[int]*StatsSession // key: userID, 用户自定义的统计会话
@@ -80,6 +89,7 @@ type BattleSession struct {
 
 	// 威胁值系统
 	ThreatTable map[string]map[int]int // 怪物ID -> 角色ID -> 威胁值
+	ThreatLog   *ThreatLogCollector    // 本场战斗的仇恨日志
 
 	// 速度排序回合系统
 	TurnOrder             []*TurnParticipant // 回合顺序队列（按速度排序）
@@ -167,23 +177,34 @@ type SkillUsageStats struct {
 
 // NewBattleManager 创建战斗管理器
 func NewBattleManager() *BattleManager {
+	zoneManager := NewZoneManager()
+	buffManager := NewBuffManager()
 	return &BattleManager{
 		sessions:             make(map[int]*BattleSession),
 		gameRepo:             repository.NewGameRepository(),
 		charRepo:             repository.NewCharacterRepository(),
+		userRepo:             repository.NewUserRepository(),
 		explorationRepo:      repository.NewExplorationRepository(),
 		inventoryRepo:        repository.NewInventoryRepository(),
 		skillManager:         NewSkillManager(),
-		buffManager:          NewBuffManager(),
+		buffManager:          buffManager,
 		passiveSkillManager:  NewPassiveSkillManager(),
 		strategyExecutor:     NewStrategyExecutor(),
 		battleStatsRepo:      repository.NewBattleStatsRepository(),
 		calculator:           NewCalculator(),
 		monsterManager:       NewMonsterManager(),
 		teamManager:          NewTeamManager(),
-		zoneManager:          NewZoneManager(),
+		zoneManager:          zoneManager,
 		equipmentManager:     NewEquipmentManager(),
 		battleStatsCollector: NewBattleStatsCollector(),
+		codexManager:         NewCodexManager(),
+		pvpManager:           NewPvPManager(zoneManager),
+		honorManager:         NewHonorManager(),
+		staminaManager:       NewStaminaManager(),
+		consumableManager:    NewConsumableManager(buffManager),
+		gatheringManager:     GetGatheringManager(),
+		eventHub:             GetEventHub(),
+		announcementMgr:      GetAnnouncementManager(),
 		statsSessions:        make(map[int]*StatsSession),
 	}
 }
@@ -200,6 +221,31 @@ func GetBattleManager() *BattleManager {
 	return battleManager
 }
 
+// GetCodexManager 获取图鉴管理器（与战斗共享加成缓存）
+func (m *BattleManager) GetCodexManager() *CodexManager {
+	return m.codexManager
+}
+
+// GetPvPManager 获取PVP管理器（与区域收益共享控制状态缓存）
+func (m *BattleManager) GetPvPManager() *PvPManager {
+	return m.pvpManager
+}
+
+// GetHonorManager 获取荣誉管理器
+func (m *BattleManager) GetHonorManager() *HonorManager {
+	return m.honorManager
+}
+
+// GetStaminaManager 获取体力管理器
+func (m *BattleManager) GetStaminaManager() *StaminaManager {
+	return m.staminaManager
+}
+
+// GetConsumableManager 获取消耗品管理器（与战斗共享Buff状态）
+func (m *BattleManager) GetConsumableManager() *ConsumableManager {
+	return m.consumableManager
+}
+
 // GetOrCreateSession 获取或创建战斗会话
 func (m *BattleManager) GetOrCreateSession(userID int) *BattleSession {
 	m.mu.Lock()
@@ -255,6 +301,11 @@ func (m *BattleManager) ToggleBattle(userID int) (bool, error) {
 				session.CurrentZone = zone
 			}
 		}
+		// 体力不足时不允许开始战斗
+		if err := m.checkBattleStamina(userID, session.CurrentZone); err != nil {
+			session.IsRunning = false
+			return false, err
+		}
 		session.CurrentTurnIndex = -1 // 重置为玩家回合
 		m.addLog(session, "system", ">> 开始自动战斗...", "#33ff33")
 	} else {
@@ -275,10 +326,6 @@ func (m *BattleManager) StartBattle(userID int) (bool, error) {
 		return true, nil
 	}
 
-	session.IsRunning = true
-	session.LastTick = time.Now()
-	session.CurrentTurnIndex = -1 // 重置为玩家回合
-
 	// 设置默认区域
 	if session.CurrentZone == nil {
 		zone, err := m.gameRepo.GetZoneByID("elwynn")
@@ -287,6 +334,15 @@ func (m *BattleManager) StartBattle(userID int) (bool, error) {
 		}
 	}
 
+	// 体力不足时不允许开始战斗
+	if err := m.checkBattleStamina(userID, session.CurrentZone); err != nil {
+		return false, err
+	}
+
+	session.IsRunning = true
+	session.LastTick = time.Now()
+	session.CurrentTurnIndex = -1 // 重置为玩家回合
+
 	// 战斗开始时，重置所有战士角色的怒气为0
 	characters, err := m.charRepo.GetByUserID(userID)
 	if err == nil {
@@ -321,6 +377,40 @@ func (m *BattleManager) StopBattle(userID int) error {
 	return nil
 }
 
+// AbortBattle GM强制结束当前遭遇（不结算奖励）并停止自动战斗，返回是否正在遭遇中
+func (m *BattleManager) AbortBattle(userID int) bool {
+	session := m.GetSession(userID)
+	if session == nil {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inCombat := len(session.CurrentEnemies) > 0 || session.CurrentEnemy != nil
+	session.IsRunning = false
+	session.CurrentEnemy = nil
+	session.CurrentEnemies = make([]*models.Monster, 0)
+	session.JustEncountered = false
+	session.CurrentTurnIndex = -1
+	session.TurnOrder = make([]*TurnParticipant, 0)
+	session.CurrentTurnOrderIndex = -1
+	session.ThreatTable = make(map[string]map[int]int)
+	m.addLog(session, "system", ">> 战斗已被GM终止", "#ff6600")
+	return inCombat
+}
+
+// ResetSession 丢弃用户的战斗会话与统计会话（下次访问时重新创建），返回会话是否存在
+func (m *BattleManager) ResetSession(userID int) bool {
+	m.mu.Lock()
+	_, existed := m.sessions[userID]
+	delete(m.sessions, userID)
+	m.mu.Unlock()
+
+	m.ResetStatsSession(userID)
+	return existed
+}
+
 // ExecuteBattleTick 执行战斗回合（回合制：每tick只执行一个动作）
 func (m *BattleManager) ExecuteBattleTick(userID int, characters []*models.Character) (*BattleTickResult, error) {
 	session := m.GetOrCreateSession(userID)
@@ -443,6 +533,9 @@ func (m *BattleManager) ExecuteBattleTick(userID int, characters []*models.Chara
 				}
 			}
 
+			// 休息期间采集（不能剥皮）
+			m.processGathering(session, []*models.Character{char}, false, &logs)
+
 			// 如果角色已经复活（不再死亡），自动恢复战斗
 			if !char.IsDead {
 				session.IsRunning = true
@@ -494,6 +587,23 @@ func (m *BattleManager) ExecuteBattleTick(userID int, characters []*models.Chara
 
 	// 如果没有敌人，生成新的
 	if len(aliveEnemies) == 0 {
+		// 每场战斗开始前扣除体力，体力不足时暂停自动战斗
+		if !m.consumeBattleStamina(session, &logs, userID, char) {
+			return &BattleTickResult{
+				Character:    char,
+				Enemy:        nil,
+				Enemies:      nil,
+				Logs:         logs,
+				IsRunning:    session.IsRunning,
+				IsResting:    session.IsResting,
+				RestUntil:    session.RestUntil,
+				SessionKills: session.SessionKills,
+				SessionGold:  session.SessionGold,
+				SessionExp:   session.SessionExp,
+				BattleCount:  session.BattleCount,
+			}, nil
+		}
+
 		// 重置本场战斗统计
 		session.CurrentBattleExp = 0
 		session.CurrentBattleGold = 0
@@ -698,865 +808,167 @@ func (m *BattleManager) ExecuteBattleTick(userID int, characters []*models.Chara
 	if session.CurrentTurnIndex == -1 {
 		// 玩家回合：攻击第一个存活的敌人
 		if len(aliveEnemies) > 0 {
-			target := aliveEnemies[0]
-			targetHPPercent := float64(target.HP) / float64(target.MaxHP)
-			hasMultipleEnemies := len(aliveEnemies) > 1
-			targetIndex := 0
-
-			// 使用技能管理器选择技能
-			var skillState *CharacterSkillState
-			var strategyDecision *SkillDecision
-			var strategy *models.BattleStrategy
-			var battleCtx *BattleContext
-
-			// 优先使用策略执行器
-			hasStrategy := false
-			if m.strategyExecutor != nil {
-				strategy = m.strategyExecutor.GetActiveStrategy(char.ID)
-				if strategy != nil {
-					hasStrategy = true
-					// 构建战斗上下文
-					battleCtx = &BattleContext{
-						Character:    char,
-						Enemies:      aliveEnemies,
-						Allies:       characters,
-						Target:       target,
-						CurrentRound: session.BattleCount,
-						SkillManager: m.skillManager,
-						BuffManager:  m.buffManager,
-					}
-					strategyDecision = m.strategyExecutor.ExecuteStrategy(strategy, battleCtx)
-				}
-			}
-
-			// 根据策略决策或默认逻辑选择技能
-			if strategyDecision != nil {
-				// 更新目标（无论是普通攻击还是技能，都应该使用策略选择的目标）
-				if strategyDecision.TargetIndex >= 0 && strategyDecision.TargetIndex < len(aliveEnemies) {
-					targetIndex = strategyDecision.TargetIndex
-					target = aliveEnemies[targetIndex]
-					targetHPPercent = float64(target.HP) / float64(target.MaxHP)
-				}
-
-				if strategyDecision.IsNormalAttack {
-					// 策略决定使用普通攻击
-					skillState = nil
-				} else if strategyDecision.SkillID != "" {
-					// 策略决定使用特定技能
-					skillState = m.skillManager.GetSkillState(char.ID, strategyDecision.SkillID)
-					if skillState == nil {
-						// 尝试带 warrior_ 前缀
-						skillState = m.skillManager.GetSkillState(char.ID, "warrior_"+strategyDecision.SkillID)
-					}
-				}
-			} else if hasStrategy {
-				// 有策略但返回 nil，表示没有可用技能或应该使用普通攻击
-				// 不使用 SelectBestSkill，因为它不检查条件规则限制
-				skillState = nil
-				// 即使策略返回nil，也应该根据策略的目标优先级选择目标
-				if strategy != nil {
-					targetIndex = m.strategyExecutor.SelectTargetByStrategy(strategy, battleCtx, "")
-					if targetIndex >= 0 && targetIndex < len(aliveEnemies) {
-						target = aliveEnemies[targetIndex]
-						targetHPPercent = float64(target.HP) / float64(target.MaxHP)
-					}
-				}
-			} else if m.skillManager != nil {
-				// 没有策略，使用默认逻辑
-				skillState = m.skillManager.SelectBestSkill(char.ID, char.Resource, targetHPPercent, hasMultipleEnemies, m.buffManager)
+			target, _, usedItem := m.executeCharacterAction(session, char, characters, aliveEnemies, &logs)
+			if usedItem {
+				// 本回合使用物品代替攻击
+				m.charRepo.UpdateAfterBattle(char.ID, char.HP, char.Resource, char.Exp, char.Level,
+					char.ExpToNext, char.MaxHP, char.MaxResource, char.PhysicalAttack, char.MagicAttack, char.PhysicalDefense, char.MagicDefense,
+					char.Strength, char.Agility, char.Intellect, char.Stamina, char.Spirit, char.UnspentPoints, char.TotalKills)
+				m.moveToNextTurn(session, characters, aliveEnemies)
+				return &BattleTickResult{
+					Character:    char,
+					Enemy:        session.CurrentEnemy,
+					Enemies:      session.CurrentEnemies,
+					Logs:         logs,
+					IsRunning:    session.IsRunning,
+					IsResting:    session.IsResting,
+					RestUntil:    session.RestUntil,
+					SessionKills: session.SessionKills,
+					SessionGold:  session.SessionGold,
+					SessionExp:   session.SessionExp,
+					BattleCount:  session.BattleCount,
+				}, nil
 			}
-			_ = targetIndex // 避免未使用警告
-
-			var skillName string
-			var playerDamage int
-			var resourceCost int
-			var usedSkill bool
-			var skillEffects map[string]interface{}
-			var isCrit bool
-			var damageDetails *DamageCalculationDetails
-			var shouldDealDamage bool // 是否应该造成伤害（只有attack类型的技能才造成伤害）
-			var isDodged bool         // 是否被闪避
-			var ignoresDodge bool     // 技能是否无视闪避
-			var originalResource int  // 资源变化前的值（用于日志显示）
 
-			// 保存资源变化前的值
-			originalResource = char.Resource
-
-			if skillState != nil && skillState.Skill != nil {
-				// 使用技能
-				skillName = skillState.Skill.Name
-				resourceCost = m.skillManager.GetSkillResourceCost(skillState)
-
-				// 判断技能是否应该造成伤害（只有attack类型的技能才造成伤害）
-				shouldDealDamage = skillState.Skill.Type == "attack"
-
-				// 检查技能是否无视闪避
-				ignoresDodge = m.skillIgnoresDodge(skillState.Skill)
-
-				// 检查资源是否足够
-				if resourceCost <= char.Resource {
-
-					var baseDamage int
-					// playerDamage, isCrit, and damageDetails are already declared in outer scope
-					// Do not redeclare them here to avoid shadowing outer scope variables
-
-					if shouldDealDamage {
-						// 计算技能伤害（基础伤害，暴击在后面处理）
-						baseDamage = m.skillManager.CalculateSkillDamage(skillState, char, target, m.passiveSkillManager, m.buffManager)
-
-						// 计算实际攻击力（用于公式显示，需要包含Buff加成）
-						skillRatio := skillState.Skill.ScalingRatio
-						actualAttackForFormula := float64(char.PhysicalAttack)
-						attackModifiers := []string{}
-
-						// 检查被动技能的攻击力加成
-						if m.passiveSkillManager != nil {
-							attackModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "attack")
-							if attackModifier > 0 {
-								actualAttackForFormula = actualAttackForFormula * (1.0 + attackModifier/100.0)
-								attackModifiers = append(attackModifiers, fmt.Sprintf("被动攻击+%.0f%%", attackModifier))
-							}
-						}
-
-						// 检查Buff的攻击力加成（战斗怒吼等）
-						if m.buffManager != nil {
-							attackBuffValue := m.buffManager.GetBuffValue(char.ID, "attack")
-							if attackBuffValue > 0 {
-								actualAttackForFormula = actualAttackForFormula * (1.0 + attackBuffValue/100.0)
-								attackModifiers = append(attackModifiers, fmt.Sprintf("Buff攻击+%.0f%%", attackBuffValue))
-							}
-						}
+			// 检查目标是否死亡
+			if target.HP <= 0 {
+				// 确保HP归零
+				target.HP = 0
 
-						scaledDamage := actualAttackForFormula * skillRatio
-
-						// 创建技能伤害详情
-						damageDetails = &DamageCalculationDetails{
-							BaseAttack:       char.PhysicalAttack,
-							ActualAttack:     actualAttackForFormula,
-							BaseDefense:      target.PhysicalDefense,
-							BaseDamage:       float64(baseDamage),
-							AttackModifiers:  attackModifiers,
-							DefenseModifiers: []string{},
-							ActualCritRate:   -1, // -1 表示未设置
-							RandomRoll:       -1, // -1 表示未设置
-							SkillRatio:       skillRatio,
-							ScaledDamage:     scaledDamage,
-						}
+				// 处理战争机器的击杀回怒效果
+				m.handleWarMachineRageGain(char, session, &logs)
 
-						// 计算暴击（技能也可以暴击，应用被动技能和Buff加成）
-						// 根据伤害类型选择使用物理暴击率还是法术暴击率
-						var baseCritRate, baseCritDamage float64
-						var critType string
-						if skillState.Skill.DamageType == "physical" {
-							baseCritRate = char.PhysCritRate
-							baseCritDamage = char.PhysCritDamage
-							critType = "phys_crit_rate"
-						} else {
-							// 法术伤害（magic/fire/frost/shadow/holy/nature）
-							baseCritRate = char.SpellCritRate
-							baseCritDamage = char.SpellCritDamage
-							critType = "spell_crit_rate"
-						}
+				// 处理被动技能的击杀时效果
+				m.handlePassiveOnKillEffects(char, target, session, &logs)
 
-						actualCritRate := baseCritRate
-						damageDetails.BaseCritRate = baseCritRate
-						damageDetails.CritModifiers = []string{}
-
-						if m.passiveSkillManager != nil {
-							// 检查特定类型暴击率加成
-							critModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, critType)
-							if critModifier > 0 {
-								actualCritRate = baseCritRate + critModifier/100.0
-								damageDetails.CritModifiers = append(damageDetails.CritModifiers,
-									fmt.Sprintf("被动暴击+%.0f%%", critModifier))
-							}
-							// 检查通用暴击率加成（同时影响物理和法术）
-							generalCritModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "crit_rate")
-							if generalCritModifier > 0 {
-								actualCritRate = actualCritRate + generalCritModifier/100.0
-								damageDetails.CritModifiers = append(damageDetails.CritModifiers,
-									fmt.Sprintf("被动暴击+%.0f%%", generalCritModifier))
-							}
-						}
-						// 应用Buff的暴击率加成（鲁莽等）
-						if m.buffManager != nil {
-							// 检查特定类型暴击率加成
-							critBuffValue := m.buffManager.GetBuffValue(char.ID, critType)
-							if critBuffValue > 0 {
-								actualCritRate = actualCritRate + critBuffValue/100.0
-								damageDetails.CritModifiers = append(damageDetails.CritModifiers,
-									fmt.Sprintf("Buff暴击+%.0f%%", critBuffValue))
-							}
-							// 检查通用暴击率加成（同时影响物理和法术）
-							generalCritBuffValue := m.buffManager.GetBuffValue(char.ID, "crit_rate")
-							if generalCritBuffValue > 0 {
-								actualCritRate = actualCritRate + generalCritBuffValue/100.0
-								damageDetails.CritModifiers = append(damageDetails.CritModifiers,
-									fmt.Sprintf("Buff暴击+%.0f%%", generalCritBuffValue))
-							}
-						}
-						if actualCritRate > 1.0 {
-							actualCritRate = 1.0
-						}
-						damageDetails.ActualCritRate = actualCritRate
-						randomRoll := rand.Float64()
-						damageDetails.RandomRoll = randomRoll
-						isCrit = randomRoll < actualCritRate
-						damageDetails.IsCrit = isCrit
-						damageDetails.CritMultiplier = baseCritDamage
-
-						if isCrit {
-							playerDamage = int(float64(baseDamage) * baseCritDamage)
-						} else {
-							playerDamage = baseDamage
-						}
-						damageDetails.FinalDamage = playerDamage
-					}
+				// 敌人死亡
+				expGain := target.ExpReward
+				goldGain := target.GoldMin + rand.Intn(target.GoldMax-target.GoldMin+1)
 
-					// 应用技能效果
-					skillEffects = m.skillManager.ApplySkillEffects(skillState, char, target)
+				// 应用区域收益倍率
+				if session.CurrentZone != nil && m.zoneManager != nil {
+					expMulti := m.zoneManager.CalculateExpMultiplier(session.CurrentZone.ID, char.Faction)
+					goldMulti := m.zoneManager.CalculateGoldMultiplier(session.CurrentZone.ID, char.Faction)
+					expGain = int(float64(expGain) * expMulti)
+					goldGain = int(float64(goldGain) * goldMulti)
+				}
 
-					// 应用Buff/Debuff效果
-					m.applySkillBuffs(skillState, char, target, skillEffects)
+				// 记录敌人死亡日志（敌人名字用红色，避免前端错误着色）
+				m.addLog(session, "kill", fmt.Sprintf("💀 <span style=\"color: #ff7777\">%s</span> 被击杀！获得 <span style=\"color: #3d85c6\">%d</span> 经验、<span style=\"color: #ffd700\">%d</span> 金币", target.Name, expGain, goldGain), "#ff6b6b")
+				logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
 
-					// 应用Debuff到敌人（挫志怒吼、旋风斩等）
-					m.applySkillDebuffs(skillState, char, target, aliveEnemies, skillEffects)
+				// 记录击杀统计
+				m.recordKill(session, char.ID, char.TeamSlot)
 
-					// 保存资源变化前的值
-					originalResource := char.Resource
+				// 解锁怪物图鉴
+				if m.codexManager != nil {
+					entries, err := m.codexManager.RecordMonsterKill(session.UserID, target.ID)
+					m.logCodexUnlocks(session, &logs, entries, err)
+				}
 
-					// 消耗资源
-					char.Resource -= resourceCost
-					if char.Resource < 0 {
-						char.Resource = 0
+				// 增加探索度（每击杀一个怪物增加1点探索度）
+				if session.CurrentZone != nil && m.explorationRepo != nil {
+					err := m.explorationRepo.AddExploration(session.UserID, session.CurrentZone.ID, 1)
+					if err != nil {
+						fmt.Printf("[WARN] Failed to add exploration: %v\n", err)
 					}
+				}
 
-					// 使用技能（设置冷却）
-					m.skillManager.UseSkill(char.ID, skillState.SkillID)
-					usedSkill = true
-
-					// 处理被动技能的使用技能时效果
-					m.handlePassiveOnSkillUseEffects(char, skillState.SkillID, session, &logs)
+				session.CurrentBattleExp += expGain
+				session.CurrentBattleGold += goldGain
+				session.CurrentBattleKills++
+				session.SessionExp += expGain
+				session.SessionGold += goldGain
+				session.SessionKills++
 
-					// 处理技能特殊效果（怒气获得等）
-					if rageGain, ok := skillEffects["rageGain"].(int); ok {
-						// 应用被动技能的怒气获得加成（愤怒掌握等）
-						actualRageGain := m.applyRageGenerationModifiers(char.ID, rageGain)
-						char.Resource += actualRageGain
-						if char.Resource > char.MaxResource {
-							char.Resource = char.MaxResource
-						}
-					}
+				char.Exp += expGain
+				char.TotalKills++
 
-					// 只有attack类型的技能才造成伤害
-					if shouldDealDamage {
-						// 【闪避判定】检查主目标是否闪避（非AOE技能）
-						if skillState.Skill.TargetType != "enemy_all" {
-							if m.checkDodge(target.DodgeRate, ignoresDodge) {
-								isDodged = true
-							}
-						}
+				// 检查升级
+				for char.Exp >= char.ExpToNext {
+					char.Exp -= char.ExpToNext
+					char.Level++
+					char.ExpToNext = int(float64(char.ExpToNext) * 1.5)
 
-						// 处理AOE技能（旋风斩等）
-						if skillState.Skill.TargetType == "enemy_all" {
-							// 根据技能伤害类型获取暴击伤害
-							var aoeCritDamage float64
-							if skillState.Skill.DamageType == "physical" {
-								aoeCritDamage = char.PhysCritDamage
-							} else {
-								aoeCritDamage = char.SpellCritDamage
-							}
-							// 对所有敌人造成伤害（AOE技能每个敌人单独判定闪避）
-							for _, enemy := range aliveEnemies {
-								if enemy.HP > 0 {
-									// AOE 技能每个敌人单独判定闪避
-									if m.checkDodge(enemy.DodgeRate, ignoresDodge) {
-										m.addLog(session, "dodge", fmt.Sprintf("%s 闪避了 %s 的攻击！", enemy.Name, char.Name), "#00ffff")
-										logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-										continue
-									}
-									damage := m.skillManager.CalculateSkillDamage(skillState, char, enemy, m.passiveSkillManager, m.buffManager)
-									if isCrit {
-										// 根据技能伤害类型选择暴击伤害
-										damage = int(float64(damage) * aoeCritDamage)
-									}
-									enemy.HP -= damage
-									if enemy.HP < 0 {
-										enemy.HP = 0
-									}
-									// 更新威胁值（AOE技能对每个目标都产生威胁）
-									m.updateThreat(session, enemy.ID, char.ID, damage)
-								}
-							}
-							// playerDamage用于日志显示（主目标伤害）
-						} else if skillState.SkillID == "warrior_cleave" {
-							// 顺劈斩：主目标+相邻目标
-							// 主目标闪避检查已在上方完成，如果未闪避则造成伤害
-							if !isDodged {
-								target.HP -= playerDamage
-							}
+					// 获得可分配属性点（不再自动增加主属性）
+					char.UnspentPoints += 5
 
-							// 对相邻目标造成伤害（最多2个）
-							// 收集相邻目标的日志信息，稍后记录（在主目标日志之后）
-							adjacentLogs := make([]models.BattleLog, 0)
-							adjacentTotalDamage := 0 // 累计波及伤害总和，用于统计
-							adjacentCount := 0
-							processedEnemies := make(map[*models.Monster]bool) // 记录已处理的敌人，避免重复
-							for _, enemy := range aliveEnemies {
-								// 确保不是主目标，且未处理过，且还有空位
-								if enemy != target && enemy.HP > 0 && adjacentCount < 2 && !processedEnemies[enemy] {
-									processedEnemies[enemy] = true // 标记为已处理
-									// 相邻目标单独判定闪避
-									if m.checkDodge(enemy.DodgeRate, ignoresDodge) {
-										// 先创建日志但不立即添加到session，稍后统一添加
-										adjacentLog := models.BattleLog{
-											LogType: "dodge",
-											Message: fmt.Sprintf("%s 闪避了 %s 的攻击！", enemy.Name, char.Name),
-											Color:   "#00ffff",
-										}
-										adjacentLogs = append(adjacentLogs, adjacentLog)
-										adjacentCount++
-										continue
-									}
-									// 计算相邻目标伤害
-									if effect, ok := skillState.Effect["adjacentMultiplier"].(float64); ok {
-										adjacentDamage := int(float64(char.PhysicalAttack) * effect)
-										// 基础伤害 = 实际攻击力 - 目标防御力（不再除以2）
-										adjacentDamage = adjacentDamage - enemy.PhysicalDefense
-										if adjacentDamage < 1 {
-											adjacentDamage = 1
-										}
-										if isCrit {
-											// 顺劈斩是物理技能，使用物理暴击伤害
-											adjacentDamage = int(float64(adjacentDamage) * char.PhysCritDamage)
-										}
-										adjacentOldHP := enemy.HP
-										enemy.HP -= adjacentDamage
-										if enemy.HP < 0 {
-											enemy.HP = 0
-										}
-										// 更新威胁值（顺劈斩对相邻目标也产生威胁）
-										m.updateThreat(session, enemy.ID, char.ID, adjacentDamage)
-										adjacentCount++
-										adjacentTotalDamage += adjacentDamage // 累计伤害用于统计
-										adjacentHPChange := m.formatHPChange(enemy.Name, adjacentOldHP, enemy.HP, enemy.MaxHP)
-										// 先创建日志但不立即添加到session，稍后统一添加
-										adjacentLog := models.BattleLog{
-											LogType:    "combat",
-											Message:    fmt.Sprintf("%s 的顺劈斩波及到 %s，造成 %d 点伤害%s", char.Name, enemy.Name, adjacentDamage, adjacentHPChange),
-											Color:      "#ffaa00",
-											DamageType: "physical",
-										}
-										adjacentLogs = append(adjacentLogs, adjacentLog)
-									}
-								}
-							}
-							// 将相邻目标日志信息和总伤害存储到skillState中，稍后记录
-							if skillState.Effect == nil {
-								skillState.Effect = make(map[string]interface{})
-							}
-							skillState.Effect["_adjacentLogs"] = adjacentLogs
-							skillState.Effect["_adjacentTotalDamage"] = adjacentTotalDamage
-						} else {
-							// 单体技能 - 如果未闪避则造成伤害
-							if !isDodged {
-								target.HP -= playerDamage
-								// 更新威胁值（威胁值等于伤害值）
-								m.updateThreat(session, target.ID, char.ID, playerDamage)
-							}
-						}
+					// 升级时回满生命与资源（不改变上限）
+					char.HP = char.MaxHP
+					if char.ResourceType == "rage" {
+						// 战士怒气上限固定为100，不重置怒气值
+						char.MaxResource = 100
+					} else if char.ResourceType == "energy" {
+						// 盗贼等能量职业上限固定100，升级回满
+						char.MaxResource = 100
+						char.Resource = char.MaxResource
 					} else {
-						// buff技能使用后，还需要进行普通攻击
-						// 先记录buff技能使用日志
-						buffResourceChangeText := m.formatResourceChange(char.ResourceType, originalResource, char.Resource)
-						m.addLog(session, "combat", fmt.Sprintf("%s 使用 [%s]%s", char.Name, skillName, buffResourceChangeText), "#8888ff")
-						logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-						// 重置资源消耗，避免普通攻击日志重复显示
-						resourceCost = 0
-						// 设置skillState为nil，让后续代码进行普通攻击
-						skillState = nil
+						char.Resource = char.MaxResource
 					}
-				} else {
-					// 资源不足，使用普通攻击
-					skillState = nil
+
+					m.addLog(session, "levelup", fmt.Sprintf("🎉【升级】恭喜！%s 升到了 %d 级！", char.Name, char.Level), "#ffd700")
+					logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
 				}
 			}
 
-			// 如果没有使用技能或资源不足，或使用了buff技能，使用普通攻击
-			if skillState == nil {
-				skillName = "普通攻击"
-				shouldDealDamage = true // 普通攻击造成伤害
-				ignoresDodge = false    // 普通攻击不无视闪避
+			// 移动到下一个回合（使用TurnOrder系统）
+			m.moveToNextTurn(session, characters, aliveEnemies)
+		}
+	} else {
+		// 敌人回合：当前索引的敌人攻击玩家
+		if session.CurrentTurnIndex < len(aliveEnemies) {
+			enemy := aliveEnemies[session.CurrentTurnIndex]
 
-				// 【闪避判定】检查目标是否闪避普通攻击
-				if m.checkDodge(target.DodgeRate, ignoresDodge) {
-					isDodged = true
-				}
-				// 计算实际物理攻击力（应用被动技能加成）
-				actualAttack := float64(char.PhysicalAttack)
-				damageDetails = &DamageCalculationDetails{
-					BaseAttack:       char.PhysicalAttack,
-					BaseDefense:      target.PhysicalDefense,
-					AttackModifiers:  []string{},
-					DefenseModifiers: []string{},
-					ActualCritRate:   -1, // -1 表示未设置
-					RandomRoll:       -1, // -1 表示未设置
+			// 检查敌人是否处于眩晕状态
+			enemyDebuffs := m.buffManager.GetEnemyDebuffs(enemy.ID)
+			isStunned := false
+			for _, debuff := range enemyDebuffs {
+				if debuff.Type == "stun" {
+					isStunned = true
+					break
 				}
+			}
 
-				if m.passiveSkillManager != nil {
-					attackModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "attack")
-					if attackModifier > 0 {
-						actualAttack = actualAttack * (1.0 + attackModifier/100.0)
-						damageDetails.AttackModifiers = append(damageDetails.AttackModifiers,
-							fmt.Sprintf("被动攻击+%.0f%%", attackModifier))
-					}
-					// 应用被动技能的伤害加成
-					damageModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "damage")
-					if damageModifier > 0 {
-						actualAttack = actualAttack * (1.0 + damageModifier/100.0)
-						damageDetails.AttackModifiers = append(damageDetails.AttackModifiers,
-							fmt.Sprintf("被动伤害+%.0f%%", damageModifier))
-					}
+			if isStunned {
+				// 敌人被眩晕，无法行动
+				m.addLog(session, "combat", fmt.Sprintf("%s 处于眩晕状态，无法行动！", enemy.Name), "#ff00ff")
+				logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
 
-					// 处理低血量时的攻击力加成（狂暴之心）
-					hpPercent := float64(char.HP) / float64(char.MaxHP)
-					passives := m.passiveSkillManager.GetPassiveSkills(char.ID)
-					for _, passive := range passives {
-						if passive.Passive.EffectType == "stat_mod" && passive.Passive.ID == "warrior_passive_berserker_heart" {
-							// 根据等级计算触发阈值（1级50%，5级30%）
-							threshold := 0.50 - float64(passive.Level-1)*0.05
-							if hpPercent < threshold {
-								// 根据等级计算攻击力加成（1级20%，5级60%）
-								attackBonus := 20.0 + float64(passive.Level-1)*10.0
-								actualAttack = actualAttack * (1.0 + attackBonus/100.0)
-								damageDetails.AttackModifiers = append(damageDetails.AttackModifiers,
-									fmt.Sprintf("狂暴之心+%.0f%%", attackBonus))
-							}
-						}
-					}
-				}
-				// 应用Buff的攻击力加成（战斗怒吼、狂暴之怒、天神下凡等）
-				if m.buffManager != nil {
-					attackBuffValue := m.buffManager.GetBuffValue(char.ID, "attack")
-					if attackBuffValue > 0 {
-						actualAttack = actualAttack * (1.0 + attackBuffValue/100.0)
-						damageDetails.AttackModifiers = append(damageDetails.AttackModifiers,
-							fmt.Sprintf("Buff攻击+%.0f%%", attackBuffValue))
+				// 减少敌人debuff持续时间
+				expiredDebuffs := m.buffManager.TickEnemyDebuffs(enemy.ID)
+				for _, expiredID := range expiredDebuffs {
+					// 检查是否是眩晕debuff
+					if expiredID == "charge_stun" {
+						m.addLog(session, "buff", fmt.Sprintf("%s 的眩晕效果消失了", enemy.Name), "#888888")
+						logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
 					}
 				}
 
-				damageDetails.ActualAttack = actualAttack
-				damageDetails.ActualDefense = float64(target.PhysicalDefense)
+				// 移动到下一个回合（使用TurnOrder系统）
+				m.moveToNextTurn(session, characters, aliveEnemies)
 
-				// 计算实际用于伤害计算的攻击力（四舍五入）
-				attackUsedInCalc := int(math.Round(actualAttack))
-				baseDamage, calcDetails := m.calculatePhysicalDamageWithDetails(attackUsedInCalc, target.PhysicalDefense)
-				damageDetails.BaseDamage = calcDetails.BaseDamage
-				damageDetails.BaseAttack = attackUsedInCalc // 确保公式显示的是实际用于计算的值
-				damageDetails.Variance = calcDetails.Variance
+				// 返回结果（眩晕，无行动）
+				return &BattleTickResult{
+					Character:    char,
+					Enemy:        session.CurrentEnemy,
+					Enemies:      session.CurrentEnemies,
+					Logs:         logs,
+					IsRunning:    session.IsRunning,
+					IsResting:    session.IsResting,
+					RestUntil:    session.RestUntil,
+					SessionKills: session.SessionKills,
+					SessionGold:  session.SessionGold,
+					SessionExp:   session.SessionExp,
+					BattleCount:  session.BattleCount,
+				}, nil
+			}
 
-				// 计算暴击率（普通攻击使用物理暴击率，应用被动技能和Buff加成）
-				actualCritRate := char.PhysCritRate
-				damageDetails.BaseCritRate = char.PhysCritRate
-				damageDetails.CritModifiers = []string{}
-
-				if m.passiveSkillManager != nil {
-					// 检查物理暴击率加成
-					critModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "phys_crit_rate")
-					if critModifier > 0 {
-						actualCritRate = char.PhysCritRate + critModifier/100.0
-						damageDetails.CritModifiers = append(damageDetails.CritModifiers,
-							fmt.Sprintf("被动暴击+%.0f%%", critModifier))
-					}
-					// 检查通用暴击率加成（同时影响物理和法术）
-					generalCritModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "crit_rate")
-					if generalCritModifier > 0 {
-						actualCritRate = actualCritRate + generalCritModifier/100.0
-						damageDetails.CritModifiers = append(damageDetails.CritModifiers,
-							fmt.Sprintf("被动暴击+%.0f%%", generalCritModifier))
-					}
-				}
-				// 应用Buff的暴击率加成（鲁莽等）
-				if m.buffManager != nil {
-					// 检查物理暴击率加成
-					critBuffValue := m.buffManager.GetBuffValue(char.ID, "phys_crit_rate")
-					if critBuffValue > 0 {
-						actualCritRate = actualCritRate + critBuffValue/100.0
-						damageDetails.CritModifiers = append(damageDetails.CritModifiers,
-							fmt.Sprintf("Buff暴击+%.0f%%", critBuffValue))
-					}
-					// 检查通用暴击率加成（同时影响物理和法术）
-					generalCritBuffValue := m.buffManager.GetBuffValue(char.ID, "crit_rate")
-					if generalCritBuffValue > 0 {
-						actualCritRate = actualCritRate + generalCritBuffValue/100.0
-						damageDetails.CritModifiers = append(damageDetails.CritModifiers,
-							fmt.Sprintf("Buff暴击+%.0f%%", generalCritBuffValue))
-					}
-				}
-				if actualCritRate > 1.0 {
-					actualCritRate = 1.0
-				}
-				damageDetails.ActualCritRate = actualCritRate
-				// 使用 Calculator 进行暴击判定（内部会处理上限）
-				isCrit = m.calculator.ShouldCrit(actualCritRate)
-				damageDetails.IsCrit = isCrit
-				damageDetails.CritMultiplier = char.PhysCritDamage
-				damageDetails.RandomRoll = 0 // Calculator内部处理随机数
-
-				if isCrit {
-					playerDamage = int(float64(baseDamage) * char.PhysCritDamage)
-				} else {
-					playerDamage = baseDamage
-				}
-				damageDetails.FinalDamage = playerDamage
-
-				// 如果未闪避，造成伤害
-				if !isDodged {
-					target.HP -= playerDamage
-					// 更新威胁值（威胁值等于伤害值）
-					m.updateThreat(session, target.ID, char.ID, playerDamage)
-					// 记录伤害统计
-					if m.battleStatsCollector != nil {
-						m.battleStatsCollector.RecordDamage(char.ID, playerDamage, "physical", isCrit)
-					}
-				}
-				// 注意：闪避统计只记录角色的闪避，怪物的闪避不记录到角色统计中
-				resourceCost = 0
-				usedSkill = false
-			}
-			// 如果使用了技能，isCrit已经在上面计算了
-
-			// 普通攻击获得怒气（只有普通攻击才获得怒气，使用技能时不获得，闪避时不获得）
-			if char.ResourceType == "rage" && !usedSkill && !isDodged {
-				var baseRageGain int
-				if isCrit {
-					baseRageGain = 10 // 暴击获得10点怒气
-				} else {
-					baseRageGain = 5 // 普通攻击获得5点怒气
-				}
-
-				// 应用被动技能的怒气获得加成（愤怒掌握等）
-				rageGain := m.applyRageGenerationModifiers(char.ID, baseRageGain)
-
-				char.Resource += rageGain
-				// 确保不超过最大值
-				if char.Resource > char.MaxResource {
-					char.Resource = char.MaxResource
-				}
-			}
-
-			// 处理被动技能的特殊效果（攻击时触发）- 闪避时不触发
-			if !isDodged {
-				m.handlePassiveOnHitEffects(char, playerDamage, usedSkill, session, &logs)
-
-				// 处理被动技能的暴击时效果（如果暴击）
-				if isCrit {
-					m.handlePassiveOnCritEffects(char, playerDamage, usedSkill, session, &logs)
-				}
-			}
-
-			// 构建战斗日志消息，包含资源变化（带颜色）
-			resourceChangeText := m.formatResourceChange(char.ResourceType, originalResource, char.Resource)
-
-			// 格式化伤害公式
-			formulaText := ""
-			if damageDetails != nil {
-				formulaText = m.formatDamageFormula(damageDetails)
-			}
-
-			// 记录技能使用日志
-			if shouldDealDamage {
-				if isDodged {
-					// 被闪避时显示闪避日志
-					m.addLog(session, "dodge", fmt.Sprintf("%s 闪避了 %s 使用的 [%s]！%s", target.Name, char.Name, skillName, resourceChangeText), "#00ffff")
-				} else {
-					// 计算目标HP变化（需要在造成伤害前记录原始HP）
-					// 注意：此时伤害已经造成，target.HP已经是伤害后的值
-					// 所以我们需要在造成伤害前记录原始HP，这里使用伤害值反推
-					targetOldHP := target.HP + playerDamage
-					if targetOldHP > target.MaxHP {
-						targetOldHP = target.MaxHP
-					}
-					hpChangeText := m.formatHPChange(target.Name, targetOldHP, target.HP, target.MaxHP)
-					playerDamageType := "physical"
-					if skillState != nil && skillState.Skill != nil {
-						if dt := normalizeDamageType(skillState.Skill.DamageType); dt != "" {
-							playerDamageType = dt
-						}
-					}
-
-					// 攻击类技能：记录伤害
-					if isCrit {
-						m.addLog(session, "combat", fmt.Sprintf("%s 使用 [%s] 💥暴击！对 %s 造成 %d 点伤害%s%s%s", char.Name, skillName, target.Name, playerDamage, formulaText, hpChangeText, resourceChangeText), "#ff6b6b", withDamageType(playerDamageType))
-					} else {
-						m.addLog(session, "combat", fmt.Sprintf("%s 使用 [%s] 对 %s 造成 %d 点伤害%s%s%s", char.Name, skillName, target.Name, playerDamage, formulaText, hpChangeText, resourceChangeText), "#ffaa00", withDamageType(playerDamageType))
-					}
-
-					// 如果是顺劈斩，在主目标日志后记录相邻目标的日志
-					if skillState != nil && skillState.SkillID == "warrior_cleave" {
-						// 先添加主目标日志到logs
-						logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-
-						// 然后添加相邻目标的日志
-						if adjacentLogsRaw, ok := skillState.Effect["_adjacentLogs"]; ok {
-							if adjacentLogs, ok := adjacentLogsRaw.([]models.BattleLog); ok {
-								for _, adjacentLog := range adjacentLogs {
-									// 将日志添加到session并记录到logs
-									if adjacentLog.LogType == "dodge" {
-										// 闪避日志不需要伤害类型
-										m.addLog(session, adjacentLog.LogType, adjacentLog.Message, adjacentLog.Color)
-									} else {
-										// 伤害日志需要伤害类型（使用日志中存储的DamageType，如果没有则使用physical）
-										damageType := adjacentLog.DamageType
-										if damageType == "" {
-											damageType = "physical"
-										}
-										m.addLog(session, adjacentLog.LogType, adjacentLog.Message, adjacentLog.Color, withDamageType(damageType))
-									}
-									logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-								}
-								// 清理临时数据
-								delete(skillState.Effect, "_adjacentLogs")
-							}
-						}
-					}
-
-					// 记录造成伤害的统计
-					m.recordDamageDealt(session, char.ID, char.TeamSlot, playerDamage, playerDamageType, isCrit)
-
-					// 如果是顺劈斩，记录波及伤害到统计
-					totalSkillDamage := playerDamage // 技能总伤害（用于技能使用统计）
-					if skillState != nil && skillState.SkillID == "warrior_cleave" {
-						if adjacentTotalDamageRaw, ok := skillState.Effect["_adjacentTotalDamage"]; ok {
-							if adjacentTotalDamage, ok := adjacentTotalDamageRaw.(int); ok && adjacentTotalDamage > 0 {
-								// 波及伤害也计入统计（物理伤害，是否暴击取决于主目标是否暴击）
-								m.recordDamageDealt(session, char.ID, char.TeamSlot, adjacentTotalDamage, "physical", isCrit)
-								totalSkillDamage += adjacentTotalDamage // 累计到技能总伤害
-								// 清理临时数据
-								delete(skillState.Effect, "_adjacentTotalDamage")
-							}
-						}
-					}
-
-					// 记录技能使用统计（包含主目标和波及伤害的总和）
-					skillID := ""
-					if skillState != nil {
-						skillID = skillState.SkillID
-					}
-					m.recordSkillUsage(session, char.ID, char.TeamSlot, skillID, totalSkillDamage, 0, resourceCost, true, isCrit)
-				}
-			} else {
-				// 非攻击类技能（buff/debuff/control等）：只记录使用，不记录伤害
-				m.addLog(session, "combat", fmt.Sprintf("%s 使用 [%s]%s", char.Name, skillName, resourceChangeText), "#8888ff")
-
-				// 记录非伤害技能使用统计
-				skillID := ""
-				if skillState != nil {
-					skillID = skillState.SkillID
-				}
-				m.recordSkillUsage(session, char.ID, char.TeamSlot, skillID, 0, 0, resourceCost, true, false)
-			}
-			// 对于顺劈斩，主目标和相邻目标的日志都已经在上面添加了，这里跳过避免重复
-			// 对于其他技能，添加技能使用日志
-			if skillState == nil || skillState.SkillID != "warrior_cleave" {
-				logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-			}
-			// 顺劈斩的日志已经在上面处理完毕，不需要再添加
-
-			// 处理技能特殊效果日志（在技能使用日志之后，闪避时不触发伤害相关效果）
-			if skillEffects != nil && !isDodged {
-				if stun, ok := skillEffects["stun"].(bool); ok && stun {
-					m.addLog(session, "combat", fmt.Sprintf("%s 被眩晕了！", target.Name), "#ff00ff")
-					logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-				}
-				// 处理基于伤害的恢复（嗜血等）
-				if healPercent, ok := skillEffects["healPercent"].(float64); ok && usedSkill {
-					healAmount := int(float64(playerDamage) * healPercent / 100.0)
-					char.HP += healAmount
-					if char.HP > char.MaxHP {
-						char.HP = char.MaxHP
-					}
-					m.addLog(session, "heal", fmt.Sprintf("%s 恢复了 %d 点生命值", char.Name, healAmount), "#00ff00")
-					logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-				}
-				// 处理破釜沉舟的立即恢复（基于最大HP）
-				if healMaxHpPercent, ok := skillEffects["healMaxHpPercent"].(float64); ok && usedSkill {
-					healAmount := int(float64(char.MaxHP) * healMaxHpPercent / 100.0)
-					char.HP += healAmount
-					if char.HP > char.MaxHP {
-						char.HP = char.MaxHP
-					}
-					m.addLog(session, "heal", fmt.Sprintf("%s 的破釜沉舟恢复了 %d 点生命值", char.Name, healAmount), "#00ff00")
-					logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-				}
-			}
-
-			// 减少技能冷却时间
-			m.skillManager.TickCooldowns(char.ID)
-
-			// 减少Buff/Debuff持续时间
-			expiredBuffs := m.buffManager.TickBuffs(char.ID)
-			for _, expired := range expiredBuffs {
-				m.addLog(session, "buff", fmt.Sprintf("%s 的 %s 效果消失了", char.Name, expired.Name), "#888888")
-				logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-			}
-
-			// 处理DOT/HOT效果（在Buff持续时间减少之后）
-			dotDamage, hotHealing := m.buffManager.ProcessDOTEffects(char.ID, session.CurrentBattleRound)
-			if dotDamage > 0 {
-				char.HP -= dotDamage
-				if char.HP < 0 {
-					char.HP = 0
-				}
-				m.addLog(session, "dot", fmt.Sprintf("%s 受到持续伤害，损失 %d 点生命值", char.Name, dotDamage), "#ff6666")
-				logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-			}
-			if hotHealing > 0 {
-				originalHP := char.HP
-				char.HP += hotHealing
-				if char.HP > char.MaxHP {
-					char.HP = char.MaxHP
-				}
-				actualHealing := char.HP - originalHP
-				if actualHealing > 0 {
-					m.addLog(session, "hot", fmt.Sprintf("%s 的持续恢复效果恢复了 %d 点生命值", char.Name, actualHealing), "#00ff00")
-					logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-				}
-			}
-
-			// 检查目标是否死亡
-			if target.HP <= 0 {
-				// 确保HP归零
-				target.HP = 0
-
-				// 处理战争机器的击杀回怒效果
-				m.handleWarMachineRageGain(char, session, &logs)
-
-				// 处理被动技能的击杀时效果
-				m.handlePassiveOnKillEffects(char, target, session, &logs)
-
-				// 敌人死亡
-				expGain := target.ExpReward
-				goldGain := target.GoldMin + rand.Intn(target.GoldMax-target.GoldMin+1)
-
-				// 应用区域收益倍率
-				if session.CurrentZone != nil && m.zoneManager != nil {
-					expMulti := m.zoneManager.CalculateExpMultiplier(session.CurrentZone.ID)
-					goldMulti := m.zoneManager.CalculateGoldMultiplier(session.CurrentZone.ID)
-					expGain = int(float64(expGain) * expMulti)
-					goldGain = int(float64(goldGain) * goldMulti)
-				}
-
-				// 记录敌人死亡日志（敌人名字用红色，避免前端错误着色）
-				m.addLog(session, "kill", fmt.Sprintf("💀 <span style=\"color: #ff7777\">%s</span> 被击杀！获得 <span style=\"color: #3d85c6\">%d</span> 经验、<span style=\"color: #ffd700\">%d</span> 金币", target.Name, expGain, goldGain), "#ff6b6b")
-				logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-
-				// 记录击杀统计
-				m.recordKill(session, char.ID, char.TeamSlot)
-
-				// 增加探索度（每击杀一个怪物增加1点探索度）
-				if session.CurrentZone != nil && m.explorationRepo != nil {
-					err := m.explorationRepo.AddExploration(session.UserID, session.CurrentZone.ID, 1)
-					if err != nil {
-						fmt.Printf("[WARN] Failed to add exploration: %v\n", err)
-					}
-				}
-
-				session.CurrentBattleExp += expGain
-				session.CurrentBattleGold += goldGain
-				session.CurrentBattleKills++
-				session.SessionExp += expGain
-				session.SessionGold += goldGain
-				session.SessionKills++
-
-				char.Exp += expGain
-				char.TotalKills++
-
-				// 检查升级
-				for char.Exp >= char.ExpToNext {
-					char.Exp -= char.ExpToNext
-					char.Level++
-					char.ExpToNext = int(float64(char.ExpToNext) * 1.5)
-
-					// 获得可分配属性点（不再自动增加主属性）
-					char.UnspentPoints += 5
-
-					// 升级时回满生命与资源（不改变上限）
-					char.HP = char.MaxHP
-					if char.ResourceType == "rage" {
-						// 战士怒气上限固定为100，不重置怒气值
-						char.MaxResource = 100
-					} else if char.ResourceType == "energy" {
-						// 盗贼等能量职业上限固定100，升级回满
-						char.MaxResource = 100
-						char.Resource = char.MaxResource
-					} else {
-						char.Resource = char.MaxResource
-					}
-
-					m.addLog(session, "levelup", fmt.Sprintf("🎉【升级】恭喜！%s 升到了 %d 级！", char.Name, char.Level), "#ffd700")
-					logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-				}
-			}
-
-			// 移动到下一个回合（使用TurnOrder系统）
-			m.moveToNextTurn(session, characters, aliveEnemies)
-		}
-	} else {
-		// 敌人回合：当前索引的敌人攻击玩家
-		if session.CurrentTurnIndex < len(aliveEnemies) {
-			enemy := aliveEnemies[session.CurrentTurnIndex]
-
-			// 检查敌人是否处于眩晕状态
-			enemyDebuffs := m.buffManager.GetEnemyDebuffs(enemy.ID)
-			isStunned := false
-			for _, debuff := range enemyDebuffs {
-				if debuff.Type == "stun" {
-					isStunned = true
-					break
-				}
-			}
-
-			if isStunned {
-				// 敌人被眩晕，无法行动
-				m.addLog(session, "combat", fmt.Sprintf("%s 处于眩晕状态，无法行动！", enemy.Name), "#ff00ff")
-				logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-
-				// 减少敌人debuff持续时间
-				expiredDebuffs := m.buffManager.TickEnemyDebuffs(enemy.ID)
-				for _, expiredID := range expiredDebuffs {
-					// 检查是否是眩晕debuff
-					if expiredID == "charge_stun" {
-						m.addLog(session, "buff", fmt.Sprintf("%s 的眩晕效果消失了", enemy.Name), "#888888")
-						logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-					}
-				}
-
-				// 移动到下一个回合（使用TurnOrder系统）
-				m.moveToNextTurn(session, characters, aliveEnemies)
-
-				// 返回结果（眩晕，无行动）
-				return &BattleTickResult{
-					Character:    char,
-					Enemy:        session.CurrentEnemy,
-					Enemies:      session.CurrentEnemies,
-					Logs:         logs,
-					IsRunning:    session.IsRunning,
-					IsResting:    session.IsResting,
-					RestUntil:    session.RestUntil,
-					SessionKills: session.SessionKills,
-					SessionGold:  session.SessionGold,
-					SessionExp:   session.SessionExp,
-					BattleCount:  session.BattleCount,
-				}, nil
-			}
-
-			// 【闪避判定】玩家尝试闪避敌人攻击
-			playerDodgeRate := m.calculateCharacterDodgeRate(char)
-			if m.checkDodge(playerDodgeRate, false) {
-				// 闪避成功！
-				m.addLog(session, "dodge", fmt.Sprintf("%s 闪避了 %s 的攻击！", char.Name, enemy.Name), "#00ffff")
-				logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
+			// 【闪避判定】玩家尝试闪避敌人攻击
+			playerDodgeRate := m.calculateCharacterDodgeRate(char)
+			if m.checkDodge(playerDodgeRate, false) {
+				// 闪避成功！
+				m.addLog(session, "dodge", fmt.Sprintf("%s 闪避了 %s 的攻击！", char.Name, enemy.Name), "#00ffff")
+				logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
 
 				// 记录闪避统计
 				m.recordDodge(session, char.ID, char.TeamSlot)
@@ -1620,124 +1032,20 @@ func (m *BattleManager) ExecuteBattleTick(userID int, characters []*models.Chara
 				enemyDamage = int(float64(enemyDamage) * baseCritDamage)
 			}
 
-			// 应用buff/debuff效果（如盾牌格挡的减伤等）
-			originalDamage := enemyDamage
-			enemyDamage = m.buffManager.CalculateDamageTakenWithBuffs(enemyDamage, char.ID, true)
-			if enemyDamage != originalDamage {
-				reduction := float64(originalDamage-enemyDamage) / float64(originalDamage) * 100.0
-				enemyDamageDetails.DefenseModifiers = append(enemyDamageDetails.DefenseModifiers,
-					fmt.Sprintf("减伤Buff -%.0f%%", reduction))
-			}
-
-			// 处理被动技能的减伤效果（不灭意志等）
-			originalDamage2 := enemyDamage
-			enemyDamage = m.handlePassiveDamageReduction(char, enemyDamage)
-			if enemyDamage != originalDamage2 {
-				reduction := float64(originalDamage2-enemyDamage) / float64(originalDamage2) * 100.0
-				enemyDamageDetails.DefenseModifiers = append(enemyDamageDetails.DefenseModifiers,
-					fmt.Sprintf("被动减伤 -%.0f%%", reduction))
-			}
+			// 承受伤害（减伤、护盾、反击与反射等）
+			enemyDamage, originalHP, originalResource := m.applyDamageToCharacter(session, char, enemy, enemyDamage, attackType, enemyDamageDetails, &logs)
 
-			// 处理被动技能的受到伤害时效果
-			m.handlePassiveOnDamageTakenEffects(char, enemyDamage, session, &logs)
-
-			// 处理被动技能的受到伤害时效果
-			m.handlePassiveOnDamageTakenEffects(char, enemyDamage, session, &logs)
-
-			// 处理护盾效果（不灭壁垒等）
-			shieldAmount := m.buffManager.GetBuffValue(char.ID, "shield")
-			if shieldAmount > 0 {
-				// 有护盾，先消耗护盾
-				shieldInt := int(shieldAmount)
-				if enemyDamage <= shieldInt {
-					// 伤害完全被护盾吸收
-					shieldInt -= enemyDamage
-					absorbedDamage := enemyDamage
-					enemyDamage = 0
-					m.addLog(session, "shield", fmt.Sprintf("%s 的护盾吸收了 %d 点伤害", char.Name, absorbedDamage), "#00ffff")
-					logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-					// 更新护盾值（通过更新Buff的value）
-					m.updateShieldValue(char.ID, float64(shieldInt))
-				} else {
-					// 护盾被击破，剩余伤害继续
-					absorbedDamage := shieldInt
-					enemyDamage -= shieldInt
-					m.addLog(session, "shield", fmt.Sprintf("%s 的护盾吸收了 %d 点伤害后被击破", char.Name, absorbedDamage), "#00ffff")
-					logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-					m.updateShieldValue(char.ID, 0)
-				}
-			}
+			// 构建战斗日志消息，包含资源变化（带颜色）
+			resourceChangeText := m.formatResourceChange(char.ResourceType, originalResource, char.Resource)
 
+			// 格式化伤害公式
+			enemyFormulaText := ""
 			if enemyDamageDetails != nil {
-				enemyDamageDetails.FinalDamage = enemyDamage
+				enemyFormulaText = m.formatDamageFormula(enemyDamageDetails)
 			}
 
-			// 处理被动技能的生存效果（坚韧不拔等）- 在受到伤害前检查
-			originalHP := char.HP
-			char.HP -= enemyDamage
-
-			// 如果受到致命伤害，检查坚韧不拔效果
-			if originalHP > 0 && char.HP <= 0 {
-				if m.passiveSkillManager != nil {
-					passives := m.passiveSkillManager.GetPassiveSkills(char.ID)
-					for _, passive := range passives {
-						if passive.Passive.EffectType == "survival" && passive.Passive.ID == "warrior_passive_unbreakable" {
-							// 坚韧不拔：受到致命伤害时保留1点HP
-							char.HP = 1
-							m.addLog(session, "survival", fmt.Sprintf("%s 的坚韧不拔效果触发，保留了1点生命值！", char.Name), "#ff00ff")
-							logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
-							break // 只触发一次
-						}
-					}
-				}
-			}
-
-			// 处理反击效果（反击风暴、复仇被动等）
-			m.handleCounterAttacks(char, enemy, enemyDamage, session, &logs)
-
-			// 处理被动技能的反射效果（盾牌反射被动等）
-			m.handlePassiveReflectEffects(char, enemy, enemyDamage, session, &logs)
-
-			// 处理主动技能的反射效果（盾牌反射技能等）
-			m.handleActiveReflectEffects(char, enemy, enemyDamage, session, &logs)
-
-			// 记录受到伤害的统计
-			m.recordDamageTaken(session, char.ID, char.TeamSlot, enemyDamage, attackType, 0, 0)
-
-			// 保存资源变化前的值（用于日志显示）
-			originalResource := char.Resource
-
-			// 战士受到伤害时获得怒气
-			if char.ResourceType == "rage" && enemyDamage > 0 {
-				// 受到伤害获得怒气: 伤害/最大HP × 50，至少1点
-				baseRageGain := int(float64(enemyDamage) / float64(char.MaxHP) * 50)
-				if baseRageGain < 1 {
-					baseRageGain = 1
-				}
-
-				// 应用被动技能的怒气获得加成（愤怒掌握等）
-				rageGain := m.applyRageGenerationModifiers(char.ID, baseRageGain)
-
-				char.Resource += rageGain
-				if char.Resource > char.MaxResource {
-					char.Resource = char.MaxResource
-				}
-
-				// 记录资源获得统计
-				m.recordResourceGenerated(session, char.ID, char.TeamSlot, rageGain)
-			}
-
-			// 构建战斗日志消息，包含资源变化（带颜色）
-			resourceChangeText := m.formatResourceChange(char.ResourceType, originalResource, char.Resource)
-
-			// 格式化伤害公式
-			enemyFormulaText := ""
-			if enemyDamageDetails != nil {
-				enemyFormulaText = m.formatDamageFormula(enemyDamageDetails)
-			}
-
-			// 格式化HP变化（使用已保存的originalHP）
-			playerHPChangeText := m.formatHPChange(char.Name, originalHP, char.HP, char.MaxHP)
+			// 格式化HP变化（使用已保存的originalHP）
+			playerHPChangeText := m.formatHPChange(char.Name, originalHP, char.HP, char.MaxHP)
 
 			damageColor := "#ff4444"
 			if isEnemyCrit {
@@ -1881,114 +1189,1013 @@ func (m *BattleManager) ExecuteBattleTick(userID int, characters []*models.Chara
 			}
 		}
 
-		// 处理怪物掉落
-		m.processMonsterDrops(session, session.CurrentEnemies, &logs, characters)
+		// 处理怪物掉落
+		m.processMonsterDrops(session, session.CurrentEnemies, &logs, characters)
+
+		// 战斗后采集（可以剥皮）
+		m.processGathering(session, characters, true, &logs)
+
+		// 战斗胜利总结
+		m.addBattleSummary(session, true, &logs)
+
+		// 保存战斗统计到数据库
+		monsterID := ""
+		if len(session.CurrentEnemies) > 0 && session.CurrentEnemies[0] != nil {
+			monsterID = session.CurrentEnemies[0].ID
+		}
+		zoneID := ""
+		if session.CurrentZone != nil {
+			zoneID = session.CurrentZone.ID
+		}
+		m.saveBattleStats(session, session.UserID, zoneID, monsterID, true, characters)
+
+		// 首领全服首杀公告
+		if m.announcementMgr != nil {
+			for _, enemy := range session.CurrentEnemies {
+				m.announcementMgr.AnnounceBossKill(session.UserID, enemy)
+			}
+		}
+
+		// 战斗结束后，清除所有角色的buff和debuff，怒气归0，技能冷却重置
+		for _, c := range characters {
+			// 清除所有buff和debuff
+			if m.buffManager != nil {
+				m.buffManager.ClearBuffs(c.ID)
+			}
+			// 清除技能状态（包括冷却时间）
+			if m.skillManager != nil {
+				m.skillManager.ClearCharacterSkills(c.ID)
+			}
+			// 战士的怒气归0
+			if c.ResourceType == "rage" {
+				c.Resource = 0
+			}
+			// 保存所有角色的数据（包括战士的怒气归0）
+			m.charRepo.UpdateAfterBattle(c.ID, c.HP, c.Resource, c.Exp, c.Level,
+				c.ExpToNext, c.MaxHP, c.MaxResource, c.PhysicalAttack, c.MagicAttack, c.PhysicalDefense, c.MagicDefense,
+				c.Strength, c.Agility, c.Intellect, c.Stamina, c.Spirit, c.UnspentPoints, c.TotalKills)
+		}
+
+		// 争夺区域中可能遭遇敌对阵营队伍
+		if m.pvpManager != nil && session.CurrentZone != nil {
+			encounter, err := m.pvpManager.TryEncounter(userID, session.CurrentZone, characters)
+			m.logPvPEncounter(session, &logs, userID, encounter, err)
+		}
+
+		// 计算并开始休息
+		restDuration := m.calculateRestTime(char)
+		now := time.Now()
+		restUntil := now.Add(restDuration)
+		session.IsResting = true
+		session.RestUntil = &restUntil
+		session.RestStartedAt = &now
+		session.LastRestTick = &now
+		session.RestSpeed = 1.0 // 默认恢复速度
+
+		m.addLog(session, "system", fmt.Sprintf(">> 开始休息恢复 (预计 %d 秒)", int(restDuration.Seconds())+1), "#33ff33")
+		logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
+
+		// 清除战斗统计收集器
+		m.clearBattleStats(session)
+
+		// 重置本场战斗统计
+		session.CurrentBattleExp = 0
+		session.CurrentBattleGold = 0
+		session.CurrentBattleKills = 0
+		session.CurrentTurnIndex = -1
+
+		// 先返回一次带有HP=0的敌人状态，让前端更新显示
+		// 创建敌人副本，确保HP为0
+		defeatedEnemies := make([]*models.Monster, len(session.CurrentEnemies))
+		for i, enemy := range session.CurrentEnemies {
+			if enemy != nil {
+				defeatedEnemy := *enemy
+				defeatedEnemy.HP = 0
+				defeatedEnemies[i] = &defeatedEnemy
+			}
+		}
+
+		// 清除敌人（在返回结果之后）
+		session.CurrentEnemies = nil
+		session.CurrentEnemy = nil
+
+		// 返回带有HP=0的敌人状态
+		return &BattleTickResult{
+			Character:    char,
+			Enemy:        nil,
+			Enemies:      defeatedEnemies, // 返回HP=0的敌人副本
+			Logs:         logs,
+			IsRunning:    session.IsRunning,
+			IsResting:    session.IsResting,
+			RestUntil:    session.RestUntil,
+			SessionKills: session.SessionKills,
+			SessionGold:  session.SessionGold,
+			SessionExp:   session.SessionExp,
+			BattleCount:  session.BattleCount,
+		}, nil
+	}
+
+	// 保存角色数据更新
+	m.charRepo.UpdateAfterBattle(char.ID, char.HP, char.Resource, char.Exp, char.Level,
+		char.ExpToNext, char.MaxHP, char.MaxResource, char.PhysicalAttack, char.MagicAttack, char.PhysicalDefense, char.MagicDefense,
+		char.Strength, char.Agility, char.Intellect, char.Stamina, char.Spirit, char.UnspentPoints, char.TotalKills)
+
+	return &BattleTickResult{
+		Character:    char,
+		Enemy:        session.CurrentEnemy,
+		Enemies:      session.CurrentEnemies,
+		Logs:         logs,
+		IsRunning:    session.IsRunning,
+		IsResting:    session.IsResting,
+		RestUntil:    session.RestUntil,
+		SessionKills: session.SessionKills,
+		SessionGold:  session.SessionGold,
+		SessionExp:   session.SessionExp,
+		BattleCount:  session.BattleCount,
+	}, nil
+}
+
+// executeCharacterAction 执行角色的一次行动：策略决策 → 物品/技能/普通攻击 → Buff/Debuff与被动效果 → 冷却与持续效果结算
+// 返回主目标和伤害类型；使用物品代替攻击时 usedItem 为 true
+func (m *BattleManager) executeCharacterAction(session *BattleSession, char *models.Character, characters []*models.Character, aliveEnemies []*models.Monster, logs *[]models.BattleLog) (target *models.Monster, damageType string, usedItem bool) {
+	target = aliveEnemies[0]
+	targetHPPercent := float64(target.HP) / float64(target.MaxHP)
+	hasMultipleEnemies := len(aliveEnemies) > 1
+	targetIndex := 0
+
+	// 使用技能管理器选择技能
+	var skillState *CharacterSkillState
+	var strategyDecision *SkillDecision
+	var strategy *models.BattleStrategy
+	var battleCtx *BattleContext
+
+	// 优先使用策略执行器
+	hasStrategy := false
+	if m.strategyExecutor != nil {
+		strategy = m.strategyExecutor.GetActiveStrategy(char.ID)
+		if strategy != nil {
+			hasStrategy = true
+			// 构建战斗上下文
+			battleCtx = &BattleContext{
+				Character:    char,
+				Enemies:      aliveEnemies,
+				Allies:       characters,
+				Target:       target,
+				CurrentRound: session.BattleCount,
+				SkillManager: m.skillManager,
+				BuffManager:  m.buffManager,
+			}
+			if m.consumableManager != nil && m.strategyExecutor.UsesItems(strategy) {
+				battleCtx.ItemCounts, _ = m.consumableManager.ItemCounts(char.ID)
+			}
+			strategyDecision = m.strategyExecutor.ExecuteStrategy(strategy, battleCtx)
+		}
+	}
+
+	// 策略决定使用物品：本回合使用物品代替攻击
+	if strategyDecision != nil && strategyDecision.ItemID != "" {
+		if m.useItemInBattle(session, char, strategyDecision.ItemID, logs) {
+			if m.skillManager != nil {
+				m.skillManager.TickCooldowns(char.ID)
+			}
+			return nil, "", true
+		}
+		// 物品无法使用（如已满血），改为普通攻击
+		strategyDecision = &SkillDecision{
+			IsNormalAttack: true,
+			TargetIndex:    strategyDecision.TargetIndex,
+			Reason:         "物品无法使用，改为普通攻击",
+		}
+	}
+
+	// 根据策略决策或默认逻辑选择技能
+	if strategyDecision != nil {
+		// 更新目标（无论是普通攻击还是技能，都应该使用策略选择的目标）
+		if strategyDecision.TargetIndex >= 0 && strategyDecision.TargetIndex < len(aliveEnemies) {
+			targetIndex = strategyDecision.TargetIndex
+			target = aliveEnemies[targetIndex]
+			targetHPPercent = float64(target.HP) / float64(target.MaxHP)
+		}
+
+		if strategyDecision.IsNormalAttack {
+			// 策略决定使用普通攻击
+			skillState = nil
+		} else if strategyDecision.SkillID != "" {
+			// 策略决定使用特定技能
+			skillState = m.skillManager.GetSkillState(char.ID, strategyDecision.SkillID)
+			if skillState == nil {
+				// 尝试带 warrior_ 前缀
+				skillState = m.skillManager.GetSkillState(char.ID, "warrior_"+strategyDecision.SkillID)
+			}
+		}
+	} else if hasStrategy {
+		// 有策略但返回 nil，表示没有可用技能或应该使用普通攻击
+		// 不使用 SelectBestSkill，因为它不检查条件规则限制
+		skillState = nil
+		// 即使策略返回nil，也应该根据策略的目标优先级选择目标
+		if strategy != nil {
+			targetIndex = m.strategyExecutor.SelectTargetByStrategy(strategy, battleCtx, "")
+			if targetIndex >= 0 && targetIndex < len(aliveEnemies) {
+				target = aliveEnemies[targetIndex]
+				targetHPPercent = float64(target.HP) / float64(target.MaxHP)
+			}
+		}
+	} else if m.skillManager != nil {
+		// 没有策略，使用默认逻辑
+		skillState = m.skillManager.SelectBestSkill(char.ID, char.Resource, targetHPPercent, hasMultipleEnemies, m.buffManager)
+	}
+	_ = targetIndex // 避免未使用警告
+
+	var skillName string
+	var playerDamage int
+	var resourceCost int
+	var usedSkill bool
+	var skillEffects map[string]interface{}
+	var isCrit bool
+	var damageDetails *DamageCalculationDetails
+	var shouldDealDamage bool // 是否应该造成伤害（只有attack类型的技能才造成伤害）
+	var isDodged bool         // 是否被闪避
+	var ignoresDodge bool     // 技能是否无视闪避
+	var originalResource int  // 资源变化前的值（用于日志显示）
+
+	// 保存资源变化前的值
+	originalResource = char.Resource
+
+	if skillState != nil && skillState.Skill != nil {
+		// 使用技能
+		skillName = skillState.Skill.Name
+		resourceCost = m.skillManager.GetSkillResourceCost(skillState)
+
+		// 判断技能是否应该造成伤害（只有attack类型的技能才造成伤害）
+		shouldDealDamage = skillState.Skill.Type == "attack"
+
+		// 检查技能是否无视闪避
+		ignoresDodge = m.skillIgnoresDodge(skillState.Skill)
+
+		// 检查资源是否足够
+		if resourceCost <= char.Resource {
+
+			var baseDamage int
+			// playerDamage, isCrit, and damageDetails are already declared in outer scope
+			// Do not redeclare them here to avoid shadowing outer scope variables
+
+			if shouldDealDamage {
+				// 计算技能伤害（基础伤害，暴击在后面处理）
+				baseDamage = m.skillManager.CalculateSkillDamage(skillState, char, target, m.passiveSkillManager, m.buffManager)
+
+				// 计算实际攻击力（用于公式显示，需要包含Buff加成）
+				skillRatio := skillState.Skill.ScalingRatio
+				actualAttackForFormula := float64(char.PhysicalAttack)
+				attackModifiers := []string{}
+
+				// 检查被动技能的攻击力加成
+				if m.passiveSkillManager != nil {
+					attackModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "attack")
+					if attackModifier > 0 {
+						actualAttackForFormula = actualAttackForFormula * (1.0 + attackModifier/100.0)
+						attackModifiers = append(attackModifiers, fmt.Sprintf("被动攻击+%.0f%%", attackModifier))
+					}
+				}
+
+				// 检查Buff的攻击力加成（战斗怒吼等）
+				if m.buffManager != nil {
+					attackBuffValue := m.buffManager.GetBuffValue(char.ID, "attack")
+					if attackBuffValue > 0 {
+						actualAttackForFormula = actualAttackForFormula * (1.0 + attackBuffValue/100.0)
+						attackModifiers = append(attackModifiers, fmt.Sprintf("Buff攻击+%.0f%%", attackBuffValue))
+					}
+				}
+
+				scaledDamage := actualAttackForFormula * skillRatio
+
+				// 创建技能伤害详情
+				damageDetails = &DamageCalculationDetails{
+					BaseAttack:       char.PhysicalAttack,
+					ActualAttack:     actualAttackForFormula,
+					BaseDefense:      target.PhysicalDefense,
+					BaseDamage:       float64(baseDamage),
+					AttackModifiers:  attackModifiers,
+					DefenseModifiers: []string{},
+					ActualCritRate:   -1, // -1 表示未设置
+					RandomRoll:       -1, // -1 表示未设置
+					SkillRatio:       skillRatio,
+					ScaledDamage:     scaledDamage,
+				}
+
+				// 应用图鉴伤害加成
+				baseDamage = m.applyCodexDamageBonus(session.UserID, target.ID, baseDamage, damageDetails)
+
+				// 计算暴击（技能也可以暴击，应用被动技能和Buff加成）
+				// 根据伤害类型选择使用物理暴击率还是法术暴击率
+				var baseCritRate, baseCritDamage float64
+				var critType string
+				if skillState.Skill.DamageType == "physical" {
+					baseCritRate = char.PhysCritRate
+					baseCritDamage = char.PhysCritDamage
+					critType = "phys_crit_rate"
+				} else {
+					// 法术伤害（magic/fire/frost/shadow/holy/nature）
+					baseCritRate = char.SpellCritRate
+					baseCritDamage = char.SpellCritDamage
+					critType = "spell_crit_rate"
+				}
+
+				actualCritRate := baseCritRate
+				damageDetails.BaseCritRate = baseCritRate
+				damageDetails.CritModifiers = []string{}
+
+				if m.passiveSkillManager != nil {
+					// 检查特定类型暴击率加成
+					critModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, critType)
+					if critModifier > 0 {
+						actualCritRate = baseCritRate + critModifier/100.0
+						damageDetails.CritModifiers = append(damageDetails.CritModifiers,
+							fmt.Sprintf("被动暴击+%.0f%%", critModifier))
+					}
+					// 检查通用暴击率加成（同时影响物理和法术）
+					generalCritModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "crit_rate")
+					if generalCritModifier > 0 {
+						actualCritRate = actualCritRate + generalCritModifier/100.0
+						damageDetails.CritModifiers = append(damageDetails.CritModifiers,
+							fmt.Sprintf("被动暴击+%.0f%%", generalCritModifier))
+					}
+				}
+				// 应用Buff的暴击率加成（鲁莽等）
+				if m.buffManager != nil {
+					// 检查特定类型暴击率加成
+					critBuffValue := m.buffManager.GetBuffValue(char.ID, critType)
+					if critBuffValue > 0 {
+						actualCritRate = actualCritRate + critBuffValue/100.0
+						damageDetails.CritModifiers = append(damageDetails.CritModifiers,
+							fmt.Sprintf("Buff暴击+%.0f%%", critBuffValue))
+					}
+					// 检查通用暴击率加成（同时影响物理和法术）
+					generalCritBuffValue := m.buffManager.GetBuffValue(char.ID, "crit_rate")
+					if generalCritBuffValue > 0 {
+						actualCritRate = actualCritRate + generalCritBuffValue/100.0
+						damageDetails.CritModifiers = append(damageDetails.CritModifiers,
+							fmt.Sprintf("Buff暴击+%.0f%%", generalCritBuffValue))
+					}
+				}
+				if actualCritRate > 1.0 {
+					actualCritRate = 1.0
+				}
+				damageDetails.ActualCritRate = actualCritRate
+				randomRoll := rand.Float64()
+				damageDetails.RandomRoll = randomRoll
+				isCrit = randomRoll < actualCritRate
+				damageDetails.IsCrit = isCrit
+				damageDetails.CritMultiplier = baseCritDamage
+
+				if isCrit {
+					playerDamage = int(float64(baseDamage) * baseCritDamage)
+				} else {
+					playerDamage = baseDamage
+				}
+				damageDetails.FinalDamage = playerDamage
+			}
+
+			// 应用技能效果
+			skillEffects = m.skillManager.ApplySkillEffects(skillState, char, target)
+
+			// 应用Buff/Debuff效果
+			m.applySkillBuffs(skillState, char, target, skillEffects)
+
+			// 应用Debuff到敌人（挫志怒吼、旋风斩等）
+			m.applySkillDebuffs(skillState, char, target, aliveEnemies, skillEffects)
+
+			// 保存资源变化前的值
+			originalResource := char.Resource
+
+			// 消耗资源
+			char.Resource -= resourceCost
+			if char.Resource < 0 {
+				char.Resource = 0
+			}
+
+			// 使用技能（设置冷却）
+			m.skillManager.UseSkill(char.ID, skillState.SkillID)
+			usedSkill = true
+
+			// 处理被动技能的使用技能时效果
+			m.handlePassiveOnSkillUseEffects(char, skillState.SkillID, session, logs)
+
+			// 嘲讽技能：提升威胁值并记录嘲讽
+			if skillState.Skill != nil && skillState.Skill.ThreatType == "taunt" {
+				if skillState.Skill.TargetType == "enemy_all" {
+					m.applyTaunt(session, char, aliveEnemies)
+				} else {
+					m.applyTaunt(session, char, []*models.Monster{target})
+				}
+			}
+
+			// 处理技能特殊效果（怒气获得等）
+			if rageGain, ok := skillEffects["rageGain"].(int); ok {
+				// 应用被动技能的怒气获得加成（愤怒掌握等）
+				actualRageGain := m.applyRageGenerationModifiers(char.ID, rageGain)
+				char.Resource += actualRageGain
+				if char.Resource > char.MaxResource {
+					char.Resource = char.MaxResource
+				}
+			}
+
+			// 只有attack类型的技能才造成伤害
+			if shouldDealDamage {
+				// 【闪避判定】检查主目标是否闪避（非AOE技能）
+				if skillState.Skill.TargetType != "enemy_all" {
+					if m.checkDodge(target.DodgeRate, ignoresDodge) {
+						isDodged = true
+					}
+				}
+
+				// 处理AOE技能（旋风斩等）
+				if skillState.Skill.TargetType == "enemy_all" {
+					// 根据技能伤害类型获取暴击伤害
+					var aoeCritDamage float64
+					if skillState.Skill.DamageType == "physical" {
+						aoeCritDamage = char.PhysCritDamage
+					} else {
+						aoeCritDamage = char.SpellCritDamage
+					}
+					// 对所有敌人造成伤害（AOE技能每个敌人单独判定闪避）
+					for _, enemy := range aliveEnemies {
+						if enemy.HP > 0 {
+							// AOE 技能每个敌人单独判定闪避
+							if m.checkDodge(enemy.DodgeRate, ignoresDodge) {
+								m.addLog(session, "dodge", fmt.Sprintf("%s 闪避了 %s 的攻击！", enemy.Name, char.Name), "#00ffff")
+								*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+								continue
+							}
+							damage := m.skillManager.CalculateSkillDamage(skillState, char, enemy, m.passiveSkillManager, m.buffManager)
+							damage = m.applyCodexDamageBonus(session.UserID, enemy.ID, damage, nil)
+							if isCrit {
+								// 根据技能伤害类型选择暴击伤害
+								damage = int(float64(damage) * aoeCritDamage)
+							}
+							enemy.HP -= damage
+							if enemy.HP < 0 {
+								enemy.HP = 0
+							}
+							// 更新威胁值（AOE技能对每个目标都产生威胁）
+							m.updateThreat(session, enemy.ID, char.ID, damage)
+						}
+					}
+					// playerDamage用于日志显示（主目标伤害）
+				} else if skillState.SkillID == "warrior_cleave" {
+					// 顺劈斩：主目标+相邻目标
+					// 主目标闪避检查已在上方完成，如果未闪避则造成伤害
+					if !isDodged {
+						target.HP -= playerDamage
+					}
+
+					// 对相邻目标造成伤害（最多2个）
+					// 收集相邻目标的日志信息，稍后记录（在主目标日志之后）
+					adjacentLogs := make([]models.BattleLog, 0)
+					adjacentTotalDamage := 0 // 累计波及伤害总和，用于统计
+					adjacentCount := 0
+					processedEnemies := make(map[*models.Monster]bool) // 记录已处理的敌人，避免重复
+					for _, enemy := range aliveEnemies {
+						// 确保不是主目标，且未处理过，且还有空位
+						if enemy != target && enemy.HP > 0 && adjacentCount < 2 && !processedEnemies[enemy] {
+							processedEnemies[enemy] = true // 标记为已处理
+							// 相邻目标单独判定闪避
+							if m.checkDodge(enemy.DodgeRate, ignoresDodge) {
+								// 先创建日志但不立即添加到session，稍后统一添加
+								adjacentLog := models.BattleLog{
+									LogType: "dodge",
+									Message: fmt.Sprintf("%s 闪避了 %s 的攻击！", enemy.Name, char.Name),
+									Color:   "#00ffff",
+								}
+								adjacentLogs = append(adjacentLogs, adjacentLog)
+								adjacentCount++
+								continue
+							}
+							// 计算相邻目标伤害
+							if effect, ok := skillState.Effect["adjacentMultiplier"].(float64); ok {
+								adjacentDamage := int(float64(char.PhysicalAttack) * effect)
+								// 基础伤害 = 实际攻击力 - 目标防御力（不再除以2）
+								adjacentDamage = adjacentDamage - enemy.PhysicalDefense
+								if adjacentDamage < 1 {
+									adjacentDamage = 1
+								}
+								if isCrit {
+									// 顺劈斩是物理技能，使用物理暴击伤害
+									adjacentDamage = int(float64(adjacentDamage) * char.PhysCritDamage)
+								}
+								adjacentOldHP := enemy.HP
+								enemy.HP -= adjacentDamage
+								if enemy.HP < 0 {
+									enemy.HP = 0
+								}
+								// 更新威胁值（顺劈斩对相邻目标也产生威胁）
+								m.updateThreat(session, enemy.ID, char.ID, adjacentDamage)
+								adjacentCount++
+								adjacentTotalDamage += adjacentDamage // 累计伤害用于统计
+								adjacentHPChange := m.formatHPChange(enemy.Name, adjacentOldHP, enemy.HP, enemy.MaxHP)
+								// 先创建日志但不立即添加到session，稍后统一添加
+								adjacentLog := models.BattleLog{
+									LogType:    "combat",
+									Message:    fmt.Sprintf("%s 的顺劈斩波及到 %s，造成 %d 点伤害%s", char.Name, enemy.Name, adjacentDamage, adjacentHPChange),
+									Color:      "#ffaa00",
+									DamageType: "physical",
+								}
+								adjacentLogs = append(adjacentLogs, adjacentLog)
+							}
+						}
+					}
+					// 将相邻目标日志信息和总伤害存储到skillState中，稍后记录
+					if skillState.Effect == nil {
+						skillState.Effect = make(map[string]interface{})
+					}
+					skillState.Effect["_adjacentLogs"] = adjacentLogs
+					skillState.Effect["_adjacentTotalDamage"] = adjacentTotalDamage
+				} else {
+					// 单体技能 - 如果未闪避则造成伤害
+					if !isDodged {
+						target.HP -= playerDamage
+						// 更新威胁值（威胁值等于伤害值）
+						m.updateThreat(session, target.ID, char.ID, playerDamage)
+					}
+				}
+			} else {
+				// buff技能使用后，还需要进行普通攻击
+				// 先记录buff技能使用日志
+				buffResourceChangeText := m.formatResourceChange(char.ResourceType, originalResource, char.Resource)
+				m.addLog(session, "combat", fmt.Sprintf("%s 使用 [%s]%s", char.Name, skillName, buffResourceChangeText), "#8888ff")
+				*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+				// 重置资源消耗，避免普通攻击日志重复显示
+				resourceCost = 0
+				// 设置skillState为nil，让后续代码进行普通攻击
+				skillState = nil
+			}
+		} else {
+			// 资源不足，使用普通攻击
+			skillState = nil
+		}
+	}
+
+	// 如果没有使用技能或资源不足，或使用了buff技能，使用普通攻击
+	if skillState == nil {
+		skillName = "普通攻击"
+		shouldDealDamage = true // 普通攻击造成伤害
+		ignoresDodge = false    // 普通攻击不无视闪避
+
+		// 【闪避判定】检查目标是否闪避普通攻击
+		if m.checkDodge(target.DodgeRate, ignoresDodge) {
+			isDodged = true
+		}
+		// 计算实际物理攻击力（应用被动技能加成）
+		actualAttack := float64(char.PhysicalAttack)
+		damageDetails = &DamageCalculationDetails{
+			BaseAttack:       char.PhysicalAttack,
+			BaseDefense:      target.PhysicalDefense,
+			AttackModifiers:  []string{},
+			DefenseModifiers: []string{},
+			ActualCritRate:   -1, // -1 表示未设置
+			RandomRoll:       -1, // -1 表示未设置
+		}
+
+		if m.passiveSkillManager != nil {
+			attackModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "attack")
+			if attackModifier > 0 {
+				actualAttack = actualAttack * (1.0 + attackModifier/100.0)
+				damageDetails.AttackModifiers = append(damageDetails.AttackModifiers,
+					fmt.Sprintf("被动攻击+%.0f%%", attackModifier))
+			}
+			// 应用被动技能的伤害加成
+			damageModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "damage")
+			if damageModifier > 0 {
+				actualAttack = actualAttack * (1.0 + damageModifier/100.0)
+				damageDetails.AttackModifiers = append(damageDetails.AttackModifiers,
+					fmt.Sprintf("被动伤害+%.0f%%", damageModifier))
+			}
+
+			// 处理低血量时的攻击力加成（狂暴之心）
+			hpPercent := float64(char.HP) / float64(char.MaxHP)
+			passives := m.passiveSkillManager.GetPassiveSkills(char.ID)
+			for _, passive := range passives {
+				if passive.Passive.EffectType == "stat_mod" && passive.Passive.ID == "warrior_passive_berserker_heart" {
+					// 根据等级计算触发阈值（1级50%，5级30%）
+					threshold := 0.50 - float64(passive.Level-1)*0.05
+					if hpPercent < threshold {
+						// 根据等级计算攻击力加成（1级20%，5级60%）
+						attackBonus := 20.0 + float64(passive.Level-1)*10.0
+						actualAttack = actualAttack * (1.0 + attackBonus/100.0)
+						damageDetails.AttackModifiers = append(damageDetails.AttackModifiers,
+							fmt.Sprintf("狂暴之心+%.0f%%", attackBonus))
+					}
+				}
+			}
+		}
+		// 应用Buff的攻击力加成（战斗怒吼、狂暴之怒、天神下凡等）
+		if m.buffManager != nil {
+			attackBuffValue := m.buffManager.GetBuffValue(char.ID, "attack")
+			if attackBuffValue > 0 {
+				actualAttack = actualAttack * (1.0 + attackBuffValue/100.0)
+				damageDetails.AttackModifiers = append(damageDetails.AttackModifiers,
+					fmt.Sprintf("Buff攻击+%.0f%%", attackBuffValue))
+			}
+		}
+
+		damageDetails.ActualAttack = actualAttack
+		damageDetails.ActualDefense = float64(target.PhysicalDefense)
+
+		// 计算实际用于伤害计算的攻击力（四舍五入）
+		attackUsedInCalc := int(math.Round(actualAttack))
+		baseDamage, calcDetails := m.calculatePhysicalDamageWithDetails(attackUsedInCalc, target.PhysicalDefense)
+		damageDetails.BaseDamage = calcDetails.BaseDamage
+		damageDetails.BaseAttack = attackUsedInCalc // 确保公式显示的是实际用于计算的值
+		damageDetails.Variance = calcDetails.Variance
+
+		// 应用图鉴伤害加成
+		baseDamage = m.applyCodexDamageBonus(session.UserID, target.ID, baseDamage, damageDetails)
+
+		// 计算暴击率（普通攻击使用物理暴击率，应用被动技能和Buff加成）
+		actualCritRate := char.PhysCritRate
+		damageDetails.BaseCritRate = char.PhysCritRate
+		damageDetails.CritModifiers = []string{}
+
+		if m.passiveSkillManager != nil {
+			// 检查物理暴击率加成
+			critModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "phys_crit_rate")
+			if critModifier > 0 {
+				actualCritRate = char.PhysCritRate + critModifier/100.0
+				damageDetails.CritModifiers = append(damageDetails.CritModifiers,
+					fmt.Sprintf("被动暴击+%.0f%%", critModifier))
+			}
+			// 检查通用暴击率加成（同时影响物理和法术）
+			generalCritModifier := m.passiveSkillManager.GetPassiveModifier(char.ID, "crit_rate")
+			if generalCritModifier > 0 {
+				actualCritRate = actualCritRate + generalCritModifier/100.0
+				damageDetails.CritModifiers = append(damageDetails.CritModifiers,
+					fmt.Sprintf("被动暴击+%.0f%%", generalCritModifier))
+			}
+		}
+		// 应用Buff的暴击率加成（鲁莽等）
+		if m.buffManager != nil {
+			// 检查物理暴击率加成
+			critBuffValue := m.buffManager.GetBuffValue(char.ID, "phys_crit_rate")
+			if critBuffValue > 0 {
+				actualCritRate = actualCritRate + critBuffValue/100.0
+				damageDetails.CritModifiers = append(damageDetails.CritModifiers,
+					fmt.Sprintf("Buff暴击+%.0f%%", critBuffValue))
+			}
+			// 检查通用暴击率加成（同时影响物理和法术）
+			generalCritBuffValue := m.buffManager.GetBuffValue(char.ID, "crit_rate")
+			if generalCritBuffValue > 0 {
+				actualCritRate = actualCritRate + generalCritBuffValue/100.0
+				damageDetails.CritModifiers = append(damageDetails.CritModifiers,
+					fmt.Sprintf("Buff暴击+%.0f%%", generalCritBuffValue))
+			}
+		}
+		if actualCritRate > 1.0 {
+			actualCritRate = 1.0
+		}
+		damageDetails.ActualCritRate = actualCritRate
+		// 使用 Calculator 进行暴击判定（内部会处理上限）
+		isCrit = m.calculator.ShouldCrit(actualCritRate)
+		damageDetails.IsCrit = isCrit
+		damageDetails.CritMultiplier = char.PhysCritDamage
+		damageDetails.RandomRoll = 0 // Calculator内部处理随机数
+
+		if isCrit {
+			playerDamage = int(float64(baseDamage) * char.PhysCritDamage)
+		} else {
+			playerDamage = baseDamage
+		}
+		damageDetails.FinalDamage = playerDamage
+
+		// 如果未闪避，造成伤害
+		if !isDodged {
+			target.HP -= playerDamage
+			// 更新威胁值（威胁值等于伤害值）
+			m.updateThreat(session, target.ID, char.ID, playerDamage)
+			// 记录伤害统计
+			if m.battleStatsCollector != nil {
+				m.battleStatsCollector.RecordDamage(char.ID, playerDamage, "physical", isCrit)
+			}
+		}
+		// 注意：闪避统计只记录角色的闪避，怪物的闪避不记录到角色统计中
+		resourceCost = 0
+		usedSkill = false
+	}
+	// 如果使用了技能，isCrit已经在上面计算了
+
+	// 普通攻击获得怒气（只有普通攻击才获得怒气，使用技能时不获得，闪避时不获得）
+	if char.ResourceType == "rage" && !usedSkill && !isDodged {
+		var baseRageGain int
+		if isCrit {
+			baseRageGain = 10 // 暴击获得10点怒气
+		} else {
+			baseRageGain = 5 // 普通攻击获得5点怒气
+		}
+
+		// 应用被动技能的怒气获得加成（愤怒掌握等）
+		rageGain := m.applyRageGenerationModifiers(char.ID, baseRageGain)
+
+		char.Resource += rageGain
+		// 确保不超过最大值
+		if char.Resource > char.MaxResource {
+			char.Resource = char.MaxResource
+		}
+	}
+
+	// 处理被动技能的特殊效果（攻击时触发）- 闪避时不触发
+	if !isDodged {
+		m.handlePassiveOnHitEffects(char, playerDamage, usedSkill, session, logs)
+
+		// 处理被动技能的暴击时效果（如果暴击）
+		if isCrit {
+			m.handlePassiveOnCritEffects(char, playerDamage, usedSkill, session, logs)
+		}
+	}
+
+	// 构建战斗日志消息，包含资源变化（带颜色）
+	resourceChangeText := m.formatResourceChange(char.ResourceType, originalResource, char.Resource)
+
+	// 格式化伤害公式
+	formulaText := ""
+	if damageDetails != nil {
+		formulaText = m.formatDamageFormula(damageDetails)
+	}
+
+	// 记录技能使用日志
+	if shouldDealDamage {
+		if isDodged {
+			// 被闪避时显示闪避日志
+			m.addLog(session, "dodge", fmt.Sprintf("%s 闪避了 %s 使用的 [%s]！%s", target.Name, char.Name, skillName, resourceChangeText), "#00ffff")
+		} else {
+			// 计算目标HP变化（需要在造成伤害前记录原始HP）
+			// 注意：此时伤害已经造成，target.HP已经是伤害后的值
+			// 所以我们需要在造成伤害前记录原始HP，这里使用伤害值反推
+			targetOldHP := target.HP + playerDamage
+			if targetOldHP > target.MaxHP {
+				targetOldHP = target.MaxHP
+			}
+			hpChangeText := m.formatHPChange(target.Name, targetOldHP, target.HP, target.MaxHP)
+			playerDamageType := "physical"
+			if skillState != nil && skillState.Skill != nil {
+				if dt := normalizeDamageType(skillState.Skill.DamageType); dt != "" {
+					playerDamageType = dt
+				}
+			}
+
+			// 攻击类技能：记录伤害
+			if isCrit {
+				m.addLog(session, "combat", fmt.Sprintf("%s 使用 [%s] 💥暴击！对 %s 造成 %d 点伤害%s%s%s", char.Name, skillName, target.Name, playerDamage, formulaText, hpChangeText, resourceChangeText), "#ff6b6b", withDamageType(playerDamageType))
+			} else {
+				m.addLog(session, "combat", fmt.Sprintf("%s 使用 [%s] 对 %s 造成 %d 点伤害%s%s%s", char.Name, skillName, target.Name, playerDamage, formulaText, hpChangeText, resourceChangeText), "#ffaa00", withDamageType(playerDamageType))
+			}
+
+			// 如果是顺劈斩，在主目标日志后记录相邻目标的日志
+			if skillState != nil && skillState.SkillID == "warrior_cleave" {
+				// 先添加主目标日志到logs
+				*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+
+				// 然后添加相邻目标的日志
+				if adjacentLogsRaw, ok := skillState.Effect["_adjacentLogs"]; ok {
+					if adjacentLogs, ok := adjacentLogsRaw.([]models.BattleLog); ok {
+						for _, adjacentLog := range adjacentLogs {
+							// 将日志添加到session并记录到logs
+							if adjacentLog.LogType == "dodge" {
+								// 闪避日志不需要伤害类型
+								m.addLog(session, adjacentLog.LogType, adjacentLog.Message, adjacentLog.Color)
+							} else {
+								// 伤害日志需要伤害类型（使用日志中存储的DamageType，如果没有则使用physical）
+								damageType := adjacentLog.DamageType
+								if damageType == "" {
+									damageType = "physical"
+								}
+								m.addLog(session, adjacentLog.LogType, adjacentLog.Message, adjacentLog.Color, withDamageType(damageType))
+							}
+							*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+						}
+						// 清理临时数据
+						delete(skillState.Effect, "_adjacentLogs")
+					}
+				}
+			}
+
+			// 记录造成伤害的统计
+			m.recordDamageDealt(session, char.ID, char.TeamSlot, playerDamage, playerDamageType, isCrit)
+
+			// 如果是顺劈斩，记录波及伤害到统计
+			totalSkillDamage := playerDamage // 技能总伤害（用于技能使用统计）
+			if skillState != nil && skillState.SkillID == "warrior_cleave" {
+				if adjacentTotalDamageRaw, ok := skillState.Effect["_adjacentTotalDamage"]; ok {
+					if adjacentTotalDamage, ok := adjacentTotalDamageRaw.(int); ok && adjacentTotalDamage > 0 {
+						// 波及伤害也计入统计（物理伤害，是否暴击取决于主目标是否暴击）
+						m.recordDamageDealt(session, char.ID, char.TeamSlot, adjacentTotalDamage, "physical", isCrit)
+						totalSkillDamage += adjacentTotalDamage // 累计到技能总伤害
+						// 清理临时数据
+						delete(skillState.Effect, "_adjacentTotalDamage")
+					}
+				}
+			}
+
+			// 记录技能使用统计（包含主目标和波及伤害的总和）
+			skillID := ""
+			if skillState != nil {
+				skillID = skillState.SkillID
+			}
+			m.recordSkillUsage(session, char.ID, char.TeamSlot, skillID, totalSkillDamage, 0, resourceCost, true, isCrit)
+		}
+	} else {
+		// 非攻击类技能（buff/debuff/control等）：只记录使用，不记录伤害
+		m.addLog(session, "combat", fmt.Sprintf("%s 使用 [%s]%s", char.Name, skillName, resourceChangeText), "#8888ff")
+
+		// 记录非伤害技能使用统计
+		skillID := ""
+		if skillState != nil {
+			skillID = skillState.SkillID
+		}
+		m.recordSkillUsage(session, char.ID, char.TeamSlot, skillID, 0, 0, resourceCost, true, false)
+	}
+	// 对于顺劈斩，主目标和相邻目标的日志都已经在上面添加了，这里跳过避免重复
+	// 对于其他技能，添加技能使用日志
+	if skillState == nil || skillState.SkillID != "warrior_cleave" {
+		*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+	}
+	// 顺劈斩的日志已经在上面处理完毕，不需要再添加
+
+	// 处理技能特殊效果日志（在技能使用日志之后，闪避时不触发伤害相关效果）
+	if skillEffects != nil && !isDodged {
+		if stun, ok := skillEffects["stun"].(bool); ok && stun {
+			m.addLog(session, "combat", fmt.Sprintf("%s 被眩晕了！", target.Name), "#ff00ff")
+			*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+		}
+		// 处理基于伤害的恢复（嗜血等）
+		if healPercent, ok := skillEffects["healPercent"].(float64); ok && usedSkill {
+			healAmount := int(float64(playerDamage) * healPercent / 100.0)
+			char.HP += healAmount
+			if char.HP > char.MaxHP {
+				char.HP = char.MaxHP
+			}
+			m.addLog(session, "heal", fmt.Sprintf("%s 恢复了 %d 点生命值", char.Name, healAmount), "#00ff00")
+			*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+		}
+		// 处理破釜沉舟的立即恢复（基于最大HP）
+		if healMaxHpPercent, ok := skillEffects["healMaxHpPercent"].(float64); ok && usedSkill {
+			healAmount := int(float64(char.MaxHP) * healMaxHpPercent / 100.0)
+			char.HP += healAmount
+			if char.HP > char.MaxHP {
+				char.HP = char.MaxHP
+			}
+			m.addLog(session, "heal", fmt.Sprintf("%s 的破釜沉舟恢复了 %d 点生命值", char.Name, healAmount), "#00ff00")
+			*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+		}
+	}
+
+	// 减少技能冷却时间
+	m.skillManager.TickCooldowns(char.ID)
+
+	// 减少Buff/Debuff持续时间
+	expiredBuffs := m.buffManager.TickBuffs(char.ID)
+	for _, expired := range expiredBuffs {
+		m.addLog(session, "buff", fmt.Sprintf("%s 的 %s 效果消失了", char.Name, expired.Name), "#888888")
+		*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+	}
+
+	// 处理DOT/HOT效果（在Buff持续时间减少之后）
+	dotDamage, hotHealing := m.buffManager.ProcessDOTEffects(char.ID, session.CurrentBattleRound)
+	if dotDamage > 0 {
+		char.HP -= dotDamage
+		if char.HP < 0 {
+			char.HP = 0
+		}
+		m.addLog(session, "dot", fmt.Sprintf("%s 受到持续伤害，损失 %d 点生命值", char.Name, dotDamage), "#ff6666")
+		*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+	}
+	if hotHealing > 0 {
+		originalHP := char.HP
+		char.HP += hotHealing
+		if char.HP > char.MaxHP {
+			char.HP = char.MaxHP
+		}
+		actualHealing := char.HP - originalHP
+		if actualHealing > 0 {
+			m.addLog(session, "hot", fmt.Sprintf("%s 的持续恢复效果恢复了 %d 点生命值", char.Name, actualHealing), "#00ff00")
+			*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+		}
+	}
+
+	damageType = "physical"
+	if skillState != nil && skillState.Skill != nil {
+		if dt := normalizeDamageType(skillState.Skill.DamageType); dt != "" {
+			damageType = dt
+		}
+	}
+	return target, damageType, false
+}
+
+// applyDamageToCharacter 角色承受一次攻击：减伤Buff、被动减伤、护盾、坚韧不拔、反击与反射、受击回怒
+// 返回最终伤害以及承伤前的生命值和资源值（用于日志显示）
+func (m *BattleManager) applyDamageToCharacter(session *BattleSession, char *models.Character, attacker *models.Monster, damage int, damageType string, details *DamageCalculationDetails, logs *[]models.BattleLog) (int, int, int) {
+	// 应用buff/debuff效果（如盾牌格挡的减伤等）
+	originalDamage := damage
+	damage = m.buffManager.CalculateDamageTakenWithBuffs(damage, char.ID, damageType == "physical")
+	if damage != originalDamage && details != nil {
+		reduction := float64(originalDamage-damage) / float64(originalDamage) * 100.0
+		details.DefenseModifiers = append(details.DefenseModifiers,
+			fmt.Sprintf("减伤Buff -%.0f%%", reduction))
+	}
+
+	// 处理被动技能的减伤效果（不灭意志等）
+	originalDamage2 := damage
+	damage = m.handlePassiveDamageReduction(char, damage)
+	if damage != originalDamage2 && details != nil {
+		reduction := float64(originalDamage2-damage) / float64(originalDamage2) * 100.0
+		details.DefenseModifiers = append(details.DefenseModifiers,
+			fmt.Sprintf("被动减伤 -%.0f%%", reduction))
+	}
+
+	// 处理被动技能的受到伤害时效果
+	m.handlePassiveOnDamageTakenEffects(char, damage, session, logs)
+
+	// 处理护盾效果（不灭壁垒等）
+	shieldAmount := m.buffManager.GetBuffValue(char.ID, "shield")
+	if shieldAmount > 0 {
+		// 有护盾，先消耗护盾
+		shieldInt := int(shieldAmount)
+		if damage <= shieldInt {
+			// 伤害完全被护盾吸收
+			shieldInt -= damage
+			absorbedDamage := damage
+			damage = 0
+			m.addLog(session, "shield", fmt.Sprintf("%s 的护盾吸收了 %d 点伤害", char.Name, absorbedDamage), "#00ffff")
+			*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+			// 更新护盾值（通过更新Buff的value）
+			m.updateShieldValue(char.ID, float64(shieldInt))
+		} else {
+			// 护盾被击破，剩余伤害继续
+			absorbedDamage := shieldInt
+			damage -= shieldInt
+			m.addLog(session, "shield", fmt.Sprintf("%s 的护盾吸收了 %d 点伤害后被击破", char.Name, absorbedDamage), "#00ffff")
+			*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+			m.updateShieldValue(char.ID, 0)
+		}
+	}
 
-		// 战斗胜利总结
-		m.addBattleSummary(session, true, &logs)
+	if details != nil {
+		details.FinalDamage = damage
+	}
 
-		// 保存战斗统计到数据库
-		monsterID := ""
-		if len(session.CurrentEnemies) > 0 && session.CurrentEnemies[0] != nil {
-			monsterID = session.CurrentEnemies[0].ID
-		}
-		zoneID := ""
-		if session.CurrentZone != nil {
-			zoneID = session.CurrentZone.ID
-		}
-		m.saveBattleStats(session, session.UserID, zoneID, monsterID, true, characters)
+	// 处理被动技能的生存效果（坚韧不拔等）- 在受到伤害前检查
+	originalHP := char.HP
+	char.HP -= damage
 
-		// 战斗结束后，清除所有角色的buff和debuff，怒气归0，技能冷却重置
-		for _, c := range characters {
-			// 清除所有buff和debuff
-			if m.buffManager != nil {
-				m.buffManager.ClearBuffs(c.ID)
-			}
-			// 清除技能状态（包括冷却时间）
-			if m.skillManager != nil {
-				m.skillManager.ClearCharacterSkills(c.ID)
-			}
-			// 战士的怒气归0
-			if c.ResourceType == "rage" {
-				c.Resource = 0
+	// 如果受到致命伤害，检查坚韧不拔效果
+	if originalHP > 0 && char.HP <= 0 {
+		if m.passiveSkillManager != nil {
+			passives := m.passiveSkillManager.GetPassiveSkills(char.ID)
+			for _, passive := range passives {
+				if passive.Passive.EffectType == "survival" && passive.Passive.ID == "warrior_passive_unbreakable" {
+					// 坚韧不拔：受到致命伤害时保留1点HP
+					char.HP = 1
+					m.addLog(session, "survival", fmt.Sprintf("%s 的坚韧不拔效果触发，保留了1点生命值！", char.Name), "#ff00ff")
+					*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+					break // 只触发一次
+				}
 			}
-			// 保存所有角色的数据（包括战士的怒气归0）
-			m.charRepo.UpdateAfterBattle(c.ID, c.HP, c.Resource, c.Exp, c.Level,
-				c.ExpToNext, c.MaxHP, c.MaxResource, c.PhysicalAttack, c.MagicAttack, c.PhysicalDefense, c.MagicDefense,
-				c.Strength, c.Agility, c.Intellect, c.Stamina, c.Spirit, c.UnspentPoints, c.TotalKills)
 		}
+	}
 
-		// 计算并开始休息
-		restDuration := m.calculateRestTime(char)
-		now := time.Now()
-		restUntil := now.Add(restDuration)
-		session.IsResting = true
-		session.RestUntil = &restUntil
-		session.RestStartedAt = &now
-		session.LastRestTick = &now
-		session.RestSpeed = 1.0 // 默认恢复速度
+	// 处理反击效果（反击风暴、复仇被动等）
+	m.handleCounterAttacks(char, attacker, damage, session, logs)
 
-		m.addLog(session, "system", fmt.Sprintf(">> 开始休息恢复 (预计 %d 秒)", int(restDuration.Seconds())+1), "#33ff33")
-		logs = append(logs, session.BattleLogs[len(session.BattleLogs)-1])
+	// 处理被动技能的反射效果（盾牌反射被动等）
+	m.handlePassiveReflectEffects(char, attacker, damage, session, logs)
 
-		// 清除战斗统计收集器
-		m.clearBattleStats(session)
+	// 处理主动技能的反射效果（盾牌反射技能等）
+	m.handleActiveReflectEffects(char, attacker, damage, session, logs)
 
-		// 重置本场战斗统计
-		session.CurrentBattleExp = 0
-		session.CurrentBattleGold = 0
-		session.CurrentBattleKills = 0
-		session.CurrentTurnIndex = -1
+	// 记录受到伤害的统计
+	m.recordDamageTaken(session, char.ID, char.TeamSlot, damage, damageType, 0, 0)
 
-		// 先返回一次带有HP=0的敌人状态，让前端更新显示
-		// 创建敌人副本，确保HP为0
-		defeatedEnemies := make([]*models.Monster, len(session.CurrentEnemies))
-		for i, enemy := range session.CurrentEnemies {
-			if enemy != nil {
-				defeatedEnemy := *enemy
-				defeatedEnemy.HP = 0
-				defeatedEnemies[i] = &defeatedEnemy
-			}
+	// 保存资源变化前的值（用于日志显示）
+	originalResource := char.Resource
+
+	// 战士受到伤害时获得怒气
+	if char.ResourceType == "rage" && damage > 0 {
+		// 受到伤害获得怒气: 伤害/最大HP × 50，至少1点
+		baseRageGain := int(float64(damage) / float64(char.MaxHP) * 50)
+		if baseRageGain < 1 {
+			baseRageGain = 1
 		}
 
-		// 清除敌人（在返回结果之后）
-		session.CurrentEnemies = nil
-		session.CurrentEnemy = nil
+		// 应用被动技能的怒气获得加成（愤怒掌握等）
+		rageGain := m.applyRageGenerationModifiers(char.ID, baseRageGain)
 
-		// 返回带有HP=0的敌人状态
-		return &BattleTickResult{
-			Character:    char,
-			Enemy:        nil,
-			Enemies:      defeatedEnemies, // 返回HP=0的敌人副本
-			Logs:         logs,
-			IsRunning:    session.IsRunning,
-			IsResting:    session.IsResting,
-			RestUntil:    session.RestUntil,
-			SessionKills: session.SessionKills,
-			SessionGold:  session.SessionGold,
-			SessionExp:   session.SessionExp,
-			BattleCount:  session.BattleCount,
-		}, nil
-	}
+		char.Resource += rageGain
+		if char.Resource > char.MaxResource {
+			char.Resource = char.MaxResource
+		}
 
-	// 保存角色数据更新
-	m.charRepo.UpdateAfterBattle(char.ID, char.HP, char.Resource, char.Exp, char.Level,
-		char.ExpToNext, char.MaxHP, char.MaxResource, char.PhysicalAttack, char.MagicAttack, char.PhysicalDefense, char.MagicDefense,
-		char.Strength, char.Agility, char.Intellect, char.Stamina, char.Spirit, char.UnspentPoints, char.TotalKills)
+		// 记录资源获得统计
+		m.recordResourceGenerated(session, char.ID, char.TeamSlot, rageGain)
+	}
 
-	return &BattleTickResult{
-		Character:    char,
-		Enemy:        session.CurrentEnemy,
-		Enemies:      session.CurrentEnemies,
-		Logs:         logs,
-		IsRunning:    session.IsRunning,
-		IsResting:    session.IsResting,
-		RestUntil:    session.RestUntil,
-		SessionKills: session.SessionKills,
-		SessionGold:  session.SessionGold,
-		SessionExp:   session.SessionExp,
-		BattleCount:  session.BattleCount,
-	}, nil
+	return damage, originalHP, originalResource
 }
 
 // spawnEnemies 生成多个敌人
@@ -2210,6 +2417,28 @@ func (m *BattleManager) ChangeZone(userID int, zoneID string, playerLevel int, p
 		return fmt.Errorf("zone not found: %s", zoneID)
 	}
 
+	m.enterZone(userID, session, zone)
+	return nil
+}
+
+// TeleportZone GM传送：跳过等级/阵营等进入条件直接进入区域
+func (m *BattleManager) TeleportZone(userID int, zoneID string) (*models.Zone, error) {
+	var zone *models.Zone
+	var err error
+	if m.zoneManager != nil {
+		zone, err = m.zoneManager.GetZone(zoneID)
+	} else {
+		zone, err = m.gameRepo.GetZoneByID(zoneID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("zone not found: %s", zoneID)
+	}
+	m.enterZone(userID, m.GetOrCreateSession(userID), zone)
+	return zone, nil
+}
+
+// enterZone 切换会话所在区域，清空当前遭遇并持久化
+func (m *BattleManager) enterZone(userID int, session *BattleSession, zone *models.Zone) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -2218,10 +2447,18 @@ func (m *BattleManager) ChangeZone(userID int, zoneID string, playerLevel int, p
 	session.CurrentEnemies = make([]*models.Monster, 0) // 清空所有敌人
 	session.JustEncountered = false                     // 重置遭遇标志
 
+	// 持久化当前区域（PVP匹配按区域查找敌对队伍）
+	if m.userRepo != nil {
+		if err := m.userRepo.UpdateZone(userID, zone.ID); err != nil {
+			fmt.Printf("[WARN] Failed to persist zone for user %d: %v\n", userID, err)
+		}
+	}
+	if m.eventHub != nil {
+		m.eventHub.SetZone(userID, zone.ID)
+	}
+
 	m.addLog(session, "zone", fmt.Sprintf(">> 你来到了 [%s]", zone.Name), "#00ffff")
 	m.addLog(session, "zone", zone.Description, "#888888")
-
-	return nil
 }
 
 // GetBattleStatus 获取战斗状态
@@ -2255,6 +2492,26 @@ func (m *BattleManager) GetBattleStatus(userID int) *models.BattleStatus {
 	return status
 }
 
+// GetStaminaStatus 获取体力状态（按当前所在区域计算战斗消耗）
+func (m *BattleManager) GetStaminaStatus(userID int) (*models.StaminaStatus, error) {
+	if m.staminaManager == nil {
+		return &models.StaminaStatus{Enabled: false}, nil
+	}
+
+	var zone *models.Zone
+	if session := m.GetSession(userID); session != nil {
+		m.mu.RLock()
+		zone = session.CurrentZone
+		m.mu.RUnlock()
+	}
+	if zone == nil && m.userRepo != nil {
+		if user, err := m.userRepo.GetByID(userID); err == nil && user.CurrentZoneID != "" {
+			zone, _ = m.gameRepo.GetZoneByID(user.CurrentZoneID)
+		}
+	}
+	return m.staminaManager.GetStatus(userID, zone)
+}
+
 // GetCharacterBuffs 获取角色的所有Buff/Debuff信息（用于API返回）
 func (m *BattleManager) GetCharacterBuffs(characterID int) []*models.BuffInfo {
 	if m.buffManager == nil {
@@ -2418,6 +2675,7 @@ type DamageCalculationDetails struct {
 	CritModifiers    []string // 暴击率加成说明
 	SkillRatio       float64  // 技能倍率（0表示普通攻击）
 	ScaledDamage     float64  // 技能倍率后的伤害（攻击×倍率）
+	CodexBonus       float64  // 图鉴伤害加成（百分比）
 }
 
 // calculateMagicDamageWithDetails 计算魔法伤害（返回详情）
@@ -2524,6 +2782,7 @@ func (m *BattleManager) addLog(session *BattleSession, logType, message, color s
 		opt(&log)
 	}
 	session.BattleLogs = append(session.BattleLogs, log)
+	m.publishLog(session.UserID, log)
 
 	// 保持日志数量在合理范围
 	if len(session.BattleLogs) > 200 {
@@ -2531,6 +2790,21 @@ func (m *BattleManager) addLog(session *BattleSession, logType, message, color s
 	}
 }
 
+// publishLog 将战斗日志推送给在线连接（掉落与升级使用独立的事件类型）
+func (m *BattleManager) publishLog(userID int, log models.BattleLog) {
+	if m.eventHub == nil {
+		return
+	}
+	eventType := EventBattleLog
+	switch log.LogType {
+	case "loot":
+		eventType = EventLoot
+	case "levelup":
+		eventType = EventLevelUp
+	}
+	m.eventHub.Publish(userID, eventType, log)
+}
+
 // addBattleSummary 添加战斗总结和分割线
 func (m *BattleManager) addBattleSummary(session *BattleSession, isVictory bool, logs *[]models.BattleLog) {
 	// 生成战斗总结，使用不同颜色标记不同指标
@@ -2653,6 +2927,11 @@ func (m *BattleManager) formatDamageFormula(details *DamageCalculationDetails) s
 		}
 	}
 
+	// 如果有图鉴加成，显示加成后的伤害
+	if details.CodexBonus > 0 {
+		parts = append(parts, fmt.Sprintf("图鉴伤害+%.0f%% = %.0f", details.CodexBonus, details.BaseDamage))
+	}
+
 	// 如果暴击，显示暴击计算
 	if details.IsCrit && details.CritMultiplier > 0 {
 		critFormula := fmt.Sprintf("%.0f × %.1f暴击 = %d", details.BaseDamage, details.CritMultiplier, details.FinalDamage)
@@ -2788,8 +3067,8 @@ func (m *BattleManager) calculateRestTime(char *models.Character) time.Duration
 		restSeconds = 1.0
 	}
 
-	// 应用恢复速度倍率（未来可以从装备获取）
-	restSpeed := 1.0 // 默认恢复速度
+	// 应用恢复速度倍率（食物等效果）
+	restSpeed := m.restSpeed(char.ID)
 	if restSpeed > 0 {
 		restSeconds = restSeconds / restSpeed
 	}
@@ -2797,6 +3076,92 @@ func (m *BattleManager) calculateRestTime(char *models.Character) time.Duration
 	return time.Duration(restSeconds) * time.Second
 }
 
+// restSpeed 计算休息恢复速度倍率（默认1.0，食物等Buff按百分比提高）
+func (m *BattleManager) restSpeed(characterID int) float64 {
+	speed := 1.0
+	if m.buffManager != nil {
+		speed += m.buffManager.GetBuffValue(characterID, "rest_speed") / 100
+	}
+	return speed
+}
+
+// UseItem 玩家手动使用消耗品（空闲、休息或战斗中均可，食物仅限休息时）
+func (m *BattleManager) UseItem(userID, characterID int, itemID string) (*models.ConsumableUseResult, error) {
+	session := m.GetOrCreateSession(userID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	char, err := m.charRepo.GetByID(characterID)
+	if err != nil || char == nil || char.UserID != userID {
+		return nil, ErrConsumableNoCharacter
+	}
+	if char.ResourceType == "rage" {
+		char.MaxResource = 100
+	}
+
+	inBattle := len(session.CurrentEnemies) > 0
+	resting := session.IsResting && session.RestUntil != nil
+	result, err := m.consumableManager.UseItem(char, itemID, inBattle, resting)
+	if err != nil {
+		return nil, err
+	}
+
+	m.charRepo.UpdateAfterBattle(char.ID, char.HP, char.Resource, char.Exp, char.Level,
+		char.ExpToNext, char.MaxHP, char.MaxResource, char.PhysicalAttack, char.MagicAttack, char.PhysicalDefense, char.MagicDefense,
+		char.Strength, char.Agility, char.Intellect, char.Stamina, char.Spirit, char.UnspentPoints, char.TotalKills)
+
+	// 休息中使用物品：按当前状态和恢复速度重新计算休息结束时间
+	if resting {
+		session.RestSpeed = m.restSpeed(char.ID)
+		if restDuration := m.calculateRestTime(char); restDuration > 0 {
+			restUntil := time.Now().Add(restDuration)
+			session.RestUntil = &restUntil
+			result.RestUntil = &restUntil
+		}
+	}
+
+	m.addLog(session, m.itemLogType(result), m.formatItemUseLog(char, result), "#00ff00")
+	return result, nil
+}
+
+// useItemInBattle 战斗中使用物品（策略 use_item 动作），成功返回true
+func (m *BattleManager) useItemInBattle(session *BattleSession, char *models.Character, itemID string, logs *[]models.BattleLog) bool {
+	if m.consumableManager == nil {
+		return false
+	}
+	result, err := m.consumableManager.UseItem(char, itemID, true, false)
+	if err != nil {
+		return false
+	}
+	m.addLog(session, m.itemLogType(result), m.formatItemUseLog(char, result), "#00ff00")
+	*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+	return true
+}
+
+// itemLogType 物品使用日志类型
+func (m *BattleManager) itemLogType(result *models.ConsumableUseResult) string {
+	if result.EffectType == ConsumableEffectHealHP || result.EffectType == ConsumableEffectHealMP {
+		return "heal"
+	}
+	return "buff"
+}
+
+// formatItemUseLog 格式化物品使用日志
+func (m *BattleManager) formatItemUseLog(char *models.Character, result *models.ConsumableUseResult) string {
+	switch result.EffectType {
+	case ConsumableEffectHealHP:
+		return fmt.Sprintf("%s 使用了 %s，恢复了 %d 点生命值", char.Name, result.ItemName, result.Amount)
+	case ConsumableEffectHealMP:
+		return fmt.Sprintf("%s 使用了 %s，恢复了 %d 点法力值", char.Name, result.ItemName, result.Amount)
+	case ConsumableEffectBuff:
+		return fmt.Sprintf("%s 使用了 %s，获得增益效果（持续 %d 回合）", char.Name, result.ItemName, result.Duration)
+	case ConsumableEffectRestSpeed:
+		return fmt.Sprintf("%s 食用了 %s，恢复速度提高 %d%%", char.Name, result.ItemName, result.Amount)
+	}
+	return fmt.Sprintf("%s 使用了 %s", char.Name, result.ItemName)
+}
+
 // processRest 处理休息期间的恢复
 func (m *BattleManager) processRest(session *BattleSession, char *models.Character) {
 	if !session.IsResting || session.RestUntil == nil || session.RestStartedAt == nil {
@@ -3546,6 +3911,7 @@ func (m *BattleManager) initBattleStats(session *BattleSession, characters []*mo
 	session.CurrentBattleRound = 0
 	session.CharacterStats = make(map[int]*CharacterBattleStatsCollector)
 	session.SkillBreakdown = make(map[int]map[string]*SkillUsageStats)
+	session.ThreatLog = m.newThreatLogCollector(characters)
 
 	// 使用新的 BattleStatsCollector 初始化
 	if m.battleStatsCollector != nil {
@@ -3751,7 +4117,7 @@ func (m *BattleManager) processMonsterDrops(session *BattleSession, enemies []*m
 	// 获取区域掉落倍率（如果区域管理器可用）
 	dropMultiplier := 1.0
 	if m.zoneManager != nil && session.CurrentZone != nil {
-		dropMultiplier = m.zoneManager.CalculateDropMultiplier(session.CurrentZone.ID)
+		dropMultiplier = m.zoneManager.CalculateDropMultiplier(session.CurrentZone.ID, character.Faction)
 	}
 
 	// 遍历所有被击败的敌人，计算掉落
@@ -3771,6 +4137,12 @@ func (m *BattleManager) processMonsterDrops(session *BattleSession, enemies []*m
 		if len(drops) > 0 {
 			dropMessages := make([]string, 0)
 			for _, drop := range drops {
+				// 解锁物品图鉴
+				if m.codexManager != nil {
+					entries, err := m.codexManager.RecordItemAcquired(session.UserID, drop.ItemID)
+					m.logCodexUnlocks(session, logs, entries, err)
+				}
+
 				// 检查物品类型
 				itemData, err := m.gameRepo.GetItemByID(drop.ItemID)
 				if err != nil {
@@ -3805,6 +4177,10 @@ func (m *BattleManager) processMonsterDrops(session *BattleSession, enemies []*m
 							// 暂时使用ItemID
 							m.inventoryRepo.AddItem(character.ID, drop.ItemID, 1)
 						}
+						if m.announcementMgr != nil {
+							itemName, _ := itemData["name"].(string)
+							m.announcementMgr.AnnounceLegendaryDrop(character.UserID, character.Name, itemName, quality, enemy)
+						}
 						qualityName := m.getQualityDisplayName(quality)
 						dropMessages = append(dropMessages, fmt.Sprintf("<span style=\"color: %s\">%s</span> x1",
 							m.getQualityColor(quality), qualityName))
@@ -3831,6 +4207,170 @@ func (m *BattleManager) processMonsterDrops(session *BattleSession, enemies []*m
 	}
 }
 
+// processGathering 为存活角色在当前区域掷骰采集并记录日志
+func (m *BattleManager) processGathering(session *BattleSession, characters []*models.Character, afterKill bool, logs *[]models.BattleLog) {
+	if m.gatheringManager == nil || session.CurrentZone == nil {
+		return
+	}
+	for _, c := range characters {
+		if c == nil || c.IsDead {
+			continue
+		}
+		results, err := m.gatheringManager.Gather(c, session.CurrentZone.ID, afterKill)
+		if err != nil {
+			fmt.Printf("[WARN] Failed to gather for character %d: %v\n", c.ID, err)
+		}
+		for _, result := range results {
+			m.addLog(session, "gather", formatGatherLog(c.Name, result), "#7cc576")
+			*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+		}
+	}
+}
+
+// formatGatherLog 格式化采集日志
+func formatGatherLog(charName string, result *models.GatherResult) string {
+	icon := "🌿"
+	switch result.NodeType {
+	case "ore":
+		icon = "⛏️"
+	case "leather":
+		icon = "🔪"
+	}
+	msg := fmt.Sprintf("%s %s 采集了%s，获得 %s x%d", icon, charName, result.NodeName, result.ItemName, result.Quantity)
+	if result.SkillUp > 0 {
+		msg += fmt.Sprintf("（技能提升至 %d）", result.SkillLevel)
+	}
+	return msg
+}
+
+// applyCodexDamageBonus 应用图鉴伤害加成（details不为nil时记录到伤害详情）
+func (m *BattleManager) applyCodexDamageBonus(userID int, monsterID string, damage int, details *DamageCalculationDetails) int {
+	if m.codexManager == nil || m.calculator == nil {
+		return damage
+	}
+	bonus := m.codexManager.GetDamageBonus(userID, monsterID)
+	if bonus <= 0 {
+		return damage
+	}
+	damage = m.calculator.ApplyDamageBonus(damage, bonus)
+	if details != nil {
+		details.CodexBonus = bonus
+		details.BaseDamage = float64(damage)
+	}
+	return damage
+}
+
+// logCodexUnlocks 记录图鉴解锁/加成生效日志
+func (m *BattleManager) logCodexUnlocks(session *BattleSession, logs *[]models.BattleLog, entries []*models.CodexEntry, err error) {
+	if err != nil {
+		fmt.Printf("[WARN] Failed to record codex unlock: %v\n", err)
+	}
+	for _, entry := range entries {
+		if entry.UnlockCount == 1 {
+			m.addLog(session, "codex", fmt.Sprintf("📖 图鉴解锁：<span style=\"color: #e0c068\">%s</span>", entry.Name), "#e0c068")
+			*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+		}
+		if entry.BonusActive && entry.UnlockCount == entry.RequiredCount {
+			m.addLog(session, "codex", fmt.Sprintf("📖 图鉴【%s】加成生效：%s", entry.Name, describeCodexBonus(entry)), "#e0c068")
+			*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+		}
+	}
+}
+
+// logPvPEncounter 记录PVP遭遇战结果日志
+func (m *BattleManager) logPvPEncounter(session *BattleSession, logs *[]models.BattleLog, userID int, encounter *models.PvPEncounter, err error) {
+	if err != nil {
+		fmt.Printf("[WARN] Failed to resolve pvp encounter: %v\n", err)
+	}
+	if encounter == nil {
+		return
+	}
+
+	opponent := "部落"
+	if encounter.DefenderFaction == "alliance" {
+		opponent = "联盟"
+	}
+	m.addLog(session, "pvp", fmt.Sprintf("⚔️ 遭遇敌对%s队伍！激战 %d 回合", opponent, encounter.BattleRounds), "#ff4444")
+	*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+
+	switch {
+	case encounter.WinnerUserID == nil:
+		m.addLog(session, "pvp", "⚔️ 双方势均力敌，战斗以平局收场", "#cccccc")
+	case *encounter.WinnerUserID == userID:
+		m.addLog(session, "pvp", fmt.Sprintf("⚔️ 击败了敌对阵营队伍！获得 <span style=\"color: #e6b422\">%d</span> 荣誉", encounter.HonorReward), "#33ff33")
+	default:
+		m.addLog(session, "pvp", "⚔️ 被敌对阵营队伍击败……", "#ff6b6b")
+	}
+	*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+}
+
+// checkBattleStamina 检查体力是否足够开始战斗（未启用体力系统时不限制）
+func (m *BattleManager) checkBattleStamina(userID int, zone *models.Zone) error {
+	if m.staminaManager == nil {
+		return nil
+	}
+	ok, err := m.staminaManager.HasEnoughForBattle(userID, zone)
+	if err != nil {
+		fmt.Printf("[WARN] Failed to check stamina: %v\n", err)
+		return nil
+	}
+	if !ok {
+		return ErrNotEnoughStamina
+	}
+	return nil
+}
+
+// consumeBattleStamina 扣除一场战斗的体力并发放溢出奖励，体力不足时暂停自动战斗并返回false
+func (m *BattleManager) consumeBattleStamina(session *BattleSession, logs *[]models.BattleLog, userID int, char *models.Character) bool {
+	if m.staminaManager == nil {
+		return true
+	}
+	result, err := m.staminaManager.ConsumeForBattle(userID, session.CurrentZone)
+	if err != nil {
+		fmt.Printf("[WARN] Failed to consume stamina: %v\n", err)
+		return true
+	}
+	if result.Stamina == nil {
+		return true
+	}
+
+	if !result.Allowed {
+		session.IsRunning = false
+		cost := m.staminaManager.GetBattleCost(session.CurrentZone)
+		m.addLog(session, "system", fmt.Sprintf(">> 体力不足（%d/%d，每场战斗消耗 %d），自动战斗已暂停", result.Stamina.CurrentStamina, result.Stamina.MaxStamina, cost), "#ffaa00")
+		*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+		return false
+	}
+
+	if result.OverflowExp > 0 || result.OverflowGold > 0 {
+		char.Exp += result.OverflowExp
+		session.SessionExp += result.OverflowExp
+		session.SessionGold += result.OverflowGold
+		m.charRepo.UpdateAfterBattle(char.ID, char.HP, char.Resource, char.Exp, char.Level,
+			char.ExpToNext, char.MaxHP, char.MaxResource, char.PhysicalAttack, char.MagicAttack, char.PhysicalDefense, char.MagicDefense,
+			char.Strength, char.Agility, char.Intellect, char.Stamina, char.Spirit, char.UnspentPoints, char.TotalKills)
+		m.addLog(session, "system", fmt.Sprintf(">> 体力溢出转化：获得 <span style=\"color: #3d85c6\">%d</span> 经验、<span style=\"color: #ffd700\">%d</span> 金币", result.OverflowExp, result.OverflowGold), "#33ff33")
+		*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+	}
+	return true
+}
+
+// describeCodexBonus 图鉴加成说明
+func describeCodexBonus(entry *models.CodexEntry) string {
+	switch entry.BonusType {
+	case "damage_vs_monster":
+		return fmt.Sprintf("对%s伤害 +%.0f%%", entry.Name, entry.BonusValue)
+	case "damage_vs_family":
+		if entry.BonusFamily != "" {
+			return fmt.Sprintf("对%s族群伤害 +%.0f%%", entry.BonusFamily, entry.BonusValue)
+		}
+		return fmt.Sprintf("对%s伤害 +%.0f%%", entry.Name, entry.BonusValue)
+	case "damage_all":
+		return fmt.Sprintf("所有伤害 +%.0f%%", entry.BonusValue)
+	}
+	return entry.BonusType
+}
+
 // determineEquipmentQuality 根据怪物类型确定装备品质
 func (m *BattleManager) determineEquipmentQuality(monsterType string, dropMultiplier float64) string {
 	// 基础品质分布（根据文档）
@@ -3991,6 +4531,9 @@ func (m *BattleManager) saveBattleStats(session *BattleSession, userID int, zone
 		return
 	}
 
+	// 保存仇恨日志
+	m.saveThreatLogs(session, int(battleID))
+
 	// 保存每个角色的统计数据
 	today := time.Now().Format("2006-01-02")
 	for characterID, collector := range session.CharacterStats {
@@ -4087,6 +4630,7 @@ func (m *BattleManager) saveBattleStats(session *BattleSession, userID int, zone
 func (m *BattleManager) clearBattleStats(session *BattleSession) {
 	session.CharacterStats = nil
 	session.SkillBreakdown = nil
+	session.ThreatLog = nil
 	session.CurrentBattleRound = 0
 }
 
@@ -4242,6 +4786,8 @@ func (m *BattleManager) moveToNextTurn(session *BattleSession, characters []*mod
 
 	// 如果所有参与者都行动完毕，开始新的一轮
 	if session.CurrentTurnOrderIndex >= len(session.TurnOrder) {
+		// 记录本回合结束时的仇恨快照
+		m.recordThreatSnapshots(session, enemies)
 		// 重新构建回合队列（因为可能有角色/敌人死亡，速度可能变化）
 		m.buildTurnOrder(session, characters, enemies)
 		// 增加回合数
@@ -385,6 +385,7 @@ func (bm *BuffManager) getStackingRule(effectID string) string {
 		"unbreakable_barrier": "refresh", // 不破壁垒：刷新
 		"shield_reflection": "refresh", // 盾牌反射：刷新
 		"retaliation":       "refresh", // 反击：刷新
+		"well_fed":          "replace", // 食物：新食物替换旧效果
 		// DOT/HOT效果可以叠加
 		"dot_poison":        "stack",   // 毒药DOT：叠加
 		"dot_bleed":         "stack",   // 流血DOT：叠加
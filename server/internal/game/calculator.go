@@ -394,6 +394,19 @@ func (c *Calculator) ShouldDodge(dodgeRate float64) bool {
 	return c.rng.Float64() < dodgeRate
 }
 
+// ApplyDamageBonus 应用百分比伤害加成（图鉴等）
+// 边界处理: 加成<=0时原样返回，伤害至少为1
+func (c *Calculator) ApplyDamageBonus(damage int, bonusPercent float64) int {
+	if damage <= 0 || bonusPercent <= 0 {
+		return damage
+	}
+	result := int(math.Round(float64(damage) * (1.0 + bonusPercent/100.0)))
+	if result < 1 {
+		result = 1
+	}
+	return result
+}
+
 // ═══════════════════════════════════════════════════════════
 // 治疗计算
 // ═══════════════════════════════════════════════════════════
@@ -649,3 +649,12 @@ func TestCalculator_EdgeCases(t *testing.T) {
 	})
 }
 
+func TestApplyDamageBonus(t *testing.T) {
+	calc := NewCalculator()
+
+	assert.Equal(t, 100, calc.ApplyDamageBonus(100, 0), "无加成时伤害不变")
+	assert.Equal(t, 105, calc.ApplyDamageBonus(100, 5), "5%加成")
+	assert.Equal(t, 1, calc.ApplyDamageBonus(1, 10), "小伤害四舍五入后至少为1")
+	assert.Equal(t, 0, calc.ApplyDamageBonus(0, 10), "0伤害（闪避）不受加成影响")
+	assert.Equal(t, 50, calc.ApplyDamageBonus(50, -10), "负数加成视为无加成")
+}
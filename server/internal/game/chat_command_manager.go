@@ -0,0 +1,439 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 聊天命令错误
+var (
+	ErrUnknownChatCommand = errors.New("unknown command")
+	ErrInvalidChatCommand = errors.New("invalid command arguments")
+	ErrChatPlayerNotFound = errors.New("player not found")
+	ErrChatTargetSelf     = errors.New("cannot target yourself")
+	ErrChatNoReplyTarget  = errors.New("no one has whispered you yet")
+	ErrChatUnknownChannel = errors.New("channel cannot be joined")
+	ErrChatEnemyFaction   = errors.New("cannot inspect enemy faction")
+)
+
+const (
+	maxWhoResults  = 50
+	defaultRollMax = 100
+	maxRollValue   = 1000000
+)
+
+// joinableChatChannels 需要 /join 加入后才会收到实时推送的频道
+var joinableChatChannels = map[string]bool{
+	"lfg": true,
+}
+
+// chatCommandAliases 命令别名 -> 命令名
+var chatCommandAliases = map[string]string{
+	"w":        "whisper",
+	"whisper":  "whisper",
+	"tell":     "whisper",
+	"r":        "reply",
+	"reply":    "reply",
+	"who":      "who",
+	"ignore":   "ignore",
+	"unignore": "unignore",
+	"roll":     "roll",
+	"random":   "roll",
+	"me":       "me",
+	"emote":    "me",
+	"join":     "join",
+	"leave":    "leave",
+	"inspect":  "inspect",
+}
+
+// chatCommandUsage 命令用法（参数错误时返回给客户端）
+var chatCommandUsage = map[string]string{
+	"whisper":  "/w <玩家名> <消息>",
+	"reply":    "/r <消息>",
+	"who":      "/who [区域|职业|等级|最低等级-最高等级]",
+	"ignore":   "/ignore <玩家名>",
+	"unignore": "/unignore <玩家名>",
+	"roll":     "/roll [最大值|最小值-最大值]",
+	"me":       "/me <动作>",
+	"join":     "/join <频道>",
+	"leave":    "/leave <频道>",
+	"inspect":  "/inspect <玩家名>",
+}
+
+// ChatCommand 解析后的斜杠命令
+type ChatCommand struct {
+	Name   string // whisper/reply/who/ignore/unignore/roll/me/join/leave/inspect
+	Target string // 玩家名、频道或 /who 过滤条件
+	Text   string // 消息内容
+	Min    int    // /roll 范围
+	Max    int
+}
+
+// ChatCommandContext 执行命令的玩家与所在频道
+type ChatCommandContext struct {
+	UserID     int
+	PlayerName string
+	Faction    string
+	Channel    string // 输入命令时所在的频道
+	ZoneID     string
+}
+
+// ChatDraft 命令产生的待发送消息（由聊天处理器按普通消息流程校验并发送）
+type ChatDraft struct {
+	Channel     string
+	Receiver    string
+	Content     string
+	MessageType string
+}
+
+// ChatRoll 掷骰结果
+type ChatRoll struct {
+	Value int `json:"value"`
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+}
+
+// InspectCharacter 观察到的角色
+type InspectCharacter struct {
+	Name      string                      `json:"name"`
+	RaceID    string                      `json:"raceId"`
+	ClassID   string                      `json:"classId"`
+	Level     int                         `json:"level"`
+	Equipment []*models.EquipmentInstance `json:"equipment"`
+}
+
+// InspectResult /inspect 结果
+type InspectResult struct {
+	PlayerName string              `json:"playerName"`
+	Faction    string              `json:"faction"`
+	Online     bool                `json:"online"`
+	ZoneID     string              `json:"zoneId,omitempty"`
+	Characters []*InspectCharacter `json:"characters"`
+}
+
+// ChatCommandResult 命令的结构化结果
+type ChatCommandResult struct {
+	Command  string                  `json:"command"`
+	Notice   string                  `json:"notice,omitempty"`
+	Message  *repository.ChatMessage `json:"message,omitempty"` // 命令发送的消息
+	Who      []*repository.WhoEntry  `json:"who,omitempty"`
+	Roll     *ChatRoll               `json:"roll,omitempty"`
+	Inspect  *InspectResult          `json:"inspect,omitempty"`
+	Channels []string                `json:"channels,omitempty"`
+	Draft    *ChatDraft              `json:"-"`
+}
+
+// ChatCommandManager 聊天命令管理器 - 解析并执行 MUD 风格的斜杠命令
+type ChatCommandManager struct {
+	chatRepo      *repository.ChatRepository
+	charRepo      *repository.CharacterRepository
+	gameRepo      *repository.GameRepository
+	equipmentRepo *repository.EquipmentRepository
+}
+
+// NewChatCommandManager 创建聊天命令管理器
+func NewChatCommandManager() *ChatCommandManager {
+	return &ChatCommandManager{
+		chatRepo:      repository.NewChatRepository(),
+		charRepo:      repository.NewCharacterRepository(),
+		gameRepo:      repository.NewGameRepository(),
+		equipmentRepo: repository.NewEquipmentRepository(),
+	}
+}
+
+// 全局聊天命令管理器实例
+var chatCommandManager *ChatCommandManager
+var chatCommandOnce sync.Once
+
+// GetChatCommandManager 获取聊天命令管理器单例
+func GetChatCommandManager() *ChatCommandManager {
+	chatCommandOnce.Do(func() {
+		chatCommandManager = NewChatCommandManager()
+	})
+	return chatCommandManager
+}
+
+// IsJoinableChannel 频道是否需要 /join 加入
+func IsJoinableChannel(channel string) bool {
+	return joinableChatChannels[channel]
+}
+
+// ═══════════════════════════════════════════════════════════
+// 解析
+// ═══════════════════════════════════════════════════════════
+
+// IsChatCommand 消息是否为斜杠命令
+func IsChatCommand(content string) bool {
+	return strings.HasPrefix(strings.TrimSpace(content), "/")
+}
+
+// ParseChatCommand 解析斜杠命令并校验参数
+func ParseChatCommand(input string) (*ChatCommand, error) {
+	word, rest := splitCommandWord(strings.TrimPrefix(strings.TrimSpace(input), "/"))
+	name, ok := chatCommandAliases[strings.ToLower(word)]
+	if !ok {
+		return nil, fmt.Errorf("%w: /%s", ErrUnknownChatCommand, word)
+	}
+
+	cmd := &ChatCommand{Name: name}
+	valid := true
+	switch name {
+	case "whisper":
+		cmd.Target, cmd.Text = splitCommandWord(rest)
+		valid = cmd.Target != "" && cmd.Text != ""
+	case "reply", "me":
+		cmd.Text = rest
+		valid = cmd.Text != ""
+	case "who":
+		cmd.Target = strings.ToLower(rest)
+		valid = !strings.ContainsFunc(cmd.Target, unicode.IsSpace)
+	case "ignore", "unignore", "inspect":
+		var extra string
+		cmd.Target, extra = splitCommandWord(rest)
+		valid = cmd.Target != "" && extra == ""
+	case "join", "leave":
+		var extra string
+		cmd.Target, extra = splitCommandWord(strings.ToLower(rest))
+		valid = cmd.Target != "" && extra == ""
+	case "roll":
+		cmd.Min, cmd.Max, valid = parseRollRange(rest)
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w, usage: %s", ErrInvalidChatCommand, chatCommandUsage[name])
+	}
+	return cmd, nil
+}
+
+// parseRollRange 解析掷骰范围：空=1-100，N=1-N，N-M
+func parseRollRange(arg string) (int, int, bool) {
+	if arg == "" {
+		return 1, defaultRollMax, true
+	}
+	lo, hi, ok := parseIntRange(arg)
+	if !ok {
+		return 0, 0, false
+	}
+	if !strings.Contains(arg, "-") {
+		lo = 1
+	}
+	return lo, hi, lo >= 0 && hi >= 1 && lo <= hi && hi <= maxRollValue
+}
+
+// parseIntRange 解析 "N" 或 "N-M"
+func parseIntRange(s string) (int, int, bool) {
+	loStr, hiStr, isRange := strings.Cut(s, "-")
+	lo, err := strconv.Atoi(loStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !isRange {
+		return lo, lo, true
+	}
+	hi, err := strconv.Atoi(hiStr)
+	if err != nil || lo > hi {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
+
+// splitCommandWord 拆分第一个单词与剩余部分
+func splitCommandWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	idx := strings.IndexFunc(s, unicode.IsSpace)
+	if idx < 0 {
+		return s, ""
+	}
+	return s[:idx], strings.TrimSpace(s[idx:])
+}
+
+// ═══════════════════════════════════════════════════════════
+// 执行
+// ═══════════════════════════════════════════════════════════
+
+// Execute 执行命令；/w /r /me /roll 返回 Draft，由调用方按普通消息发送
+func (cm *ChatCommandManager) Execute(ctx *ChatCommandContext, cmd *ChatCommand) (*ChatCommandResult, error) {
+	result := &ChatCommandResult{Command: cmd.Name}
+
+	switch cmd.Name {
+	case "whisper":
+		result.Draft = &ChatDraft{Channel: "whisper", Receiver: cmd.Target, Content: cmd.Text}
+	case "reply":
+		_, senderName, err := cm.chatRepo.GetLastWhisperSender(ctx.UserID)
+		if err == sql.ErrNoRows {
+			return nil, ErrChatNoReplyTarget
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Draft = &ChatDraft{Channel: "whisper", Receiver: senderName, Content: cmd.Text}
+	case "me":
+		result.Draft = &ChatDraft{Channel: publicChannel(ctx.Channel), Content: cmd.Text, MessageType: "emote"}
+	case "roll":
+		roll := &ChatRoll{Value: cmd.Min + rand.Intn(cmd.Max-cmd.Min+1), Min: cmd.Min, Max: cmd.Max}
+		result.Roll = roll
+		result.Draft = &ChatDraft{
+			Channel:     publicChannel(ctx.Channel),
+			Content:     fmt.Sprintf("掷出 %d（%d-%d）", roll.Value, roll.Min, roll.Max),
+			MessageType: "roll",
+		}
+	case "who":
+		filter, err := cm.whoFilter(cmd.Target)
+		if err != nil {
+			return nil, err
+		}
+		if result.Who, err = cm.chatRepo.GetWhoList(ctx.Faction, filter); err != nil {
+			return nil, err
+		}
+		result.Notice = fmt.Sprintf("找到 %d 名玩家", len(result.Who))
+	case "ignore", "unignore":
+		if err := cm.ignore(ctx, cmd, result); err != nil {
+			return nil, err
+		}
+	case "join", "leave":
+		if err := cm.setChannel(ctx, cmd, result); err != nil {
+			return nil, err
+		}
+	case "inspect":
+		inspect, err := cm.inspect(ctx, cmd.Target)
+		if err != nil {
+			return nil, err
+		}
+		result.Inspect = inspect
+	}
+	return result, nil
+}
+
+// whoFilter /who 参数：数字或数字范围按等级过滤，职业ID按职业过滤，其他按区域ID过滤
+func (cm *ChatCommandManager) whoFilter(arg string) (repository.WhoFilter, error) {
+	filter := repository.WhoFilter{Limit: maxWhoResults}
+	if arg == "" {
+		return filter, nil
+	}
+	if lo, hi, ok := parseIntRange(arg); ok {
+		filter.MinLevel, filter.MaxLevel = lo, hi
+		return filter, nil
+	}
+	_, err := cm.gameRepo.GetClassByID(arg)
+	switch {
+	case err == nil:
+		filter.ClassID = arg
+	case errors.Is(err, sql.ErrNoRows):
+		filter.ZoneID = arg
+	default:
+		return filter, err
+	}
+	return filter, nil
+}
+
+// ignore 屏蔽或取消屏蔽玩家
+func (cm *ChatCommandManager) ignore(ctx *ChatCommandContext, cmd *ChatCommand, result *ChatCommandResult) error {
+	target, err := cm.findPlayer(cmd.Target)
+	if err != nil {
+		return err
+	}
+	if target.UserID == ctx.UserID {
+		return ErrChatTargetSelf
+	}
+	if cmd.Name == "unignore" {
+		if err := cm.chatRepo.UnblockUser(ctx.UserID, target.UserID); err != nil {
+			return err
+		}
+		result.Notice = fmt.Sprintf("已取消屏蔽 %s", target.CharacterName)
+		return nil
+	}
+	if err := cm.chatRepo.BlockUser(ctx.UserID, target.UserID); err != nil {
+		return err
+	}
+	result.Notice = fmt.Sprintf("已屏蔽 %s", target.CharacterName)
+	return nil
+}
+
+// setChannel 加入或离开频道
+func (cm *ChatCommandManager) setChannel(ctx *ChatCommandContext, cmd *ChatCommand, result *ChatCommandResult) error {
+	if !IsJoinableChannel(cmd.Target) {
+		return fmt.Errorf("%w: %s", ErrChatUnknownChannel, cmd.Target)
+	}
+	if cmd.Name == "join" {
+		if err := cm.chatRepo.JoinChannel(ctx.UserID, cmd.Target); err != nil {
+			return err
+		}
+		result.Notice = fmt.Sprintf("已加入频道 %s", cmd.Target)
+	} else {
+		if err := cm.chatRepo.LeaveChannel(ctx.UserID, cmd.Target); err != nil {
+			return err
+		}
+		result.Notice = fmt.Sprintf("已离开频道 %s", cmd.Target)
+	}
+
+	channels, err := cm.chatRepo.GetJoinedChannels(ctx.UserID)
+	if err != nil {
+		return err
+	}
+	result.Channels = channels
+	return nil
+}
+
+// inspect 观察同阵营玩家的出战角色与装备
+func (cm *ChatCommandManager) inspect(ctx *ChatCommandContext, name string) (*InspectResult, error) {
+	target, err := cm.findPlayer(name)
+	if err != nil {
+		return nil, err
+	}
+	if target.Faction != ctx.Faction {
+		return nil, ErrChatEnemyFaction
+	}
+
+	chars, err := cm.charRepo.GetActiveByUserID(target.UserID)
+	if err != nil {
+		return nil, err
+	}
+	result := &InspectResult{
+		PlayerName: target.CharacterName,
+		Faction:    target.Faction,
+		Online:     target.IsOnline,
+		Characters: make([]*InspectCharacter, 0, len(chars)),
+	}
+	if target.IsOnline {
+		result.ZoneID = target.ZoneID
+	}
+	for _, char := range chars {
+		equipment, err := cm.equipmentRepo.GetByCharacterID(char.ID)
+		if err != nil {
+			return nil, err
+		}
+		result.Characters = append(result.Characters, &InspectCharacter{
+			Name:      char.Name,
+			RaceID:    char.RaceID,
+			ClassID:   char.ClassID,
+			Level:     char.Level,
+			Equipment: equipment,
+		})
+	}
+	return result, nil
+}
+
+// findPlayer 按玩家名查找（与私聊一致，使用在线状态表中的玩家名）
+func (cm *ChatCommandManager) findPlayer(name string) (*repository.OnlineUser, error) {
+	target, err := cm.chatRepo.GetUserByName(name)
+	if err == sql.ErrNoRows {
+		return nil, ErrChatPlayerNotFound
+	}
+	return target, err
+}
+
+// publicChannel 表情与掷骰发送到当前公共频道（在私聊窗口中输入时发送到区域频道）
+func publicChannel(channel string) string {
+	if channel == "" || channel == "whisper" {
+		return "zone"
+	}
+	return channel
+}
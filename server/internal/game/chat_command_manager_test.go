@@ -0,0 +1,132 @@
+package game
+
+import (
+	"testing"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChatCommand(t *testing.T) {
+	cmd, err := ParseChatCommand("/w Bob  hello there ")
+	assert.NoError(t, err)
+	assert.Equal(t, "whisper", cmd.Name)
+	assert.Equal(t, "Bob", cmd.Target)
+	assert.Equal(t, "hello there", cmd.Text)
+
+	cmd, err = ParseChatCommand("/ROLL 5-50")
+	assert.NoError(t, err)
+	assert.Equal(t, "roll", cmd.Name)
+	assert.Equal(t, 5, cmd.Min)
+	assert.Equal(t, 50, cmd.Max)
+
+	cmd, err = ParseChatCommand("/roll 20")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cmd.Min, "单个数字表示 1-N")
+	assert.Equal(t, 20, cmd.Max)
+
+	cmd, err = ParseChatCommand("/join LFG")
+	assert.NoError(t, err)
+	assert.Equal(t, "lfg", cmd.Target)
+
+	for _, input := range []string{"/w Bob", "/r", "/me", "/roll 50-5", "/roll abc", "/ignore", "/inspect a b", "/who 10 20"} {
+		_, err := ParseChatCommand(input)
+		assert.ErrorIs(t, err, ErrInvalidChatCommand, input)
+	}
+	_, err = ParseChatCommand("/dance")
+	assert.ErrorIs(t, err, ErrUnknownChatCommand)
+}
+
+func TestChatCommandManager_Execute(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice", "bob", "grom")
+	defer database.TeardownTestDB(testDB)
+
+	charRepo := repository.NewCharacterRepository()
+	chatRepo := repository.NewChatRepository()
+	for i, spec := range []struct {
+		name, class, faction string
+		level                int
+	}{
+		{"alicechar", "warrior", "alliance", 10},
+		{"bobchar", "mage", "alliance", 20},
+		{"gromchar", "warrior", "horde", 20},
+	} {
+		_, err := charRepo.Create(&models.Character{
+			UserID: users[i], Name: spec.name, RaceID: "human", ClassID: spec.class, Faction: spec.faction,
+			TeamSlot: 1, IsActive: true, Level: spec.level, HP: 100, MaxHP: 100, ResourceType: "rage", MaxResource: 100,
+		})
+		assert.NoError(t, err)
+		player := []string{"alice", "bob", "grom"}[i]
+		assert.NoError(t, chatRepo.SetOnlineStatus(users[i], 0, player, spec.faction, "elwynn", true))
+	}
+
+	cm := NewChatCommandManager()
+	ctx := &ChatCommandContext{UserID: users[0], PlayerName: "alice", Faction: "alliance", Channel: "world"}
+	run := func(input string) (*ChatCommandResult, error) {
+		cmd, err := ParseChatCommand(input)
+		assert.NoError(t, err, input)
+		return cm.Execute(ctx, cmd)
+	}
+
+	result, err := run("/who")
+	assert.NoError(t, err)
+	assert.Len(t, result.Who, 2, "只列出同阵营玩家")
+	result, err = run("/who mage")
+	assert.NoError(t, err)
+	if assert.Len(t, result.Who, 1) {
+		assert.Equal(t, "bob", result.Who[0].PlayerName)
+	}
+	result, err = run("/who 15-25")
+	assert.NoError(t, err)
+	assert.Len(t, result.Who, 1)
+	result, err = run("/who durotar")
+	assert.NoError(t, err)
+	assert.Empty(t, result.Who)
+
+	result, err = run("/roll 6")
+	assert.NoError(t, err)
+	assert.True(t, result.Roll.Value >= 1 && result.Roll.Value <= 6)
+	assert.Equal(t, "roll", result.Draft.MessageType)
+	assert.Equal(t, "world", result.Draft.Channel)
+
+	_, err = run("/r thanks")
+	assert.ErrorIs(t, err, ErrChatNoReplyTarget)
+	_, err = chatRepo.SendMessage(&repository.ChatMessage{
+		Channel: "whisper", SenderID: users[1], SenderName: "bob", ReceiverID: users[0], Content: "hi",
+	})
+	assert.NoError(t, err)
+	result, err = run("/r thanks")
+	assert.NoError(t, err)
+	assert.Equal(t, &ChatDraft{Channel: "whisper", Receiver: "bob", Content: "thanks"}, result.Draft)
+
+	_, err = run("/ignore alice")
+	assert.ErrorIs(t, err, ErrChatTargetSelf)
+	_, err = run("/ignore nobody")
+	assert.ErrorIs(t, err, ErrChatPlayerNotFound)
+	_, err = run("/ignore bob")
+	assert.NoError(t, err)
+	blocked, err := chatRepo.IsBlocked(users[0], users[1])
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+
+	result, err = run("/join lfg")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lfg"}, result.Channels)
+	_, err = run("/join world")
+	assert.ErrorIs(t, err, ErrChatUnknownChannel)
+	result, err = run("/leave lfg")
+	assert.NoError(t, err)
+	assert.Empty(t, result.Channels)
+
+	_, err = run("/inspect grom")
+	assert.ErrorIs(t, err, ErrChatEnemyFaction)
+	result, err = run("/inspect bob")
+	assert.NoError(t, err)
+	if assert.Len(t, result.Inspect.Characters, 1) {
+		assert.Equal(t, "bobchar", result.Inspect.Characters[0].Name)
+		assert.Equal(t, 20, result.Inspect.Characters[0].Level)
+	}
+}
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 聊天链接错误
+var (
+	ErrChatTooManyLinks = errors.New("too many links in message")
+	ErrChatLinkNotFound = errors.New("linked object not found")
+)
+
+// maxChatLinks 单条消息最多包含的链接数
+const maxChatLinks = 3
+
+// chatLinkPattern 链接标记格式：[item:装备实例ID] / [skill:技能ID] / [achievement:成就ID]
+var chatLinkPattern = regexp.MustCompile(`\[(item|skill|achievement):([A-Za-z0-9_]{1,32})\]`)
+
+// ChatLinkManager 聊天链接管理器 - 解析消息中的链接标记并生成快照
+type ChatLinkManager struct {
+	linkRepo  *repository.ChatLinkRepository
+	skillRepo *repository.SkillRepository
+}
+
+// NewChatLinkManager 创建聊天链接管理器
+func NewChatLinkManager() *ChatLinkManager {
+	return &ChatLinkManager{
+		linkRepo:  repository.NewChatLinkRepository(),
+		skillRepo: repository.NewSkillRepository(),
+	}
+}
+
+// 全局聊天链接管理器实例
+var chatLinkManager *ChatLinkManager
+var chatLinkOnce sync.Once
+
+// GetChatLinkManager 获取聊天链接管理器单例
+func GetChatLinkManager() *ChatLinkManager {
+	chatLinkOnce.Do(func() {
+		chatLinkManager = NewChatLinkManager()
+	})
+	return chatLinkManager
+}
+
+// Resolve 解析消息内容中的链接并生成快照（相同标记只保留一个；物品链接只能引用自己的装备）
+func (m *ChatLinkManager) Resolve(userID int, content string) ([]*models.ChatLink, error) {
+	matches := make([][]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range chatLinkPattern.FindAllStringSubmatch(content, -1) {
+		if !seen[match[0]] {
+			seen[match[0]] = true
+			matches = append(matches, match)
+		}
+	}
+	if len(matches) > maxChatLinks {
+		return nil, fmt.Errorf("%w: at most %d", ErrChatTooManyLinks, maxChatLinks)
+	}
+
+	links := make([]*models.ChatLink, 0, len(matches))
+	for _, match := range matches {
+		link, err := m.snapshot(userID, match[1], match[2])
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrChatLinkNotFound, match[0])
+		}
+		if err != nil {
+			return nil, err
+		}
+		link.Token = match[0]
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// snapshot 生成单个链接对象的快照
+func (m *ChatLinkManager) snapshot(userID int, linkType, targetID string) (*models.ChatLink, error) {
+	link := &models.ChatLink{Type: linkType, TargetID: targetID}
+	switch linkType {
+	case "item":
+		equipmentID, err := strconv.Atoi(targetID)
+		if err != nil {
+			return nil, sql.ErrNoRows
+		}
+		item, err := m.linkRepo.GetItemSnapshot(userID, equipmentID)
+		if err != nil {
+			return nil, err
+		}
+		link.Name, link.Quality, link.Snapshot = item.Name, item.Quality, item
+	case "skill":
+		skill, err := m.skillRepo.GetSkillByID(targetID)
+		if err != nil {
+			return nil, err
+		}
+		link.Name, link.Snapshot = skill.Name, skill
+	case "achievement":
+		achievement, err := m.linkRepo.GetAchievementSnapshot(userID, targetID)
+		if err != nil {
+			return nil, err
+		}
+		link.Name, link.Snapshot = achievement.Name, achievement
+	}
+	return link, nil
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatLinkManager_Resolve(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice", "bob")
+	defer database.TeardownTestDB(testDB)
+
+	_, err := testDB.Exec(`
+		UPDATE items SET attack = 12, crit_rate = 0.05 WHERE id = 'trade_sword';
+		INSERT INTO affixes (id, name, type, rarity, effect_type, effect_stat, min_value, max_value, value_type) VALUES
+			('test_prefix', '锋利的', 'prefix', 'common', 'stat_mod', 'attack', 1, 5, 'flat');
+		INSERT INTO skills (id, name, description, class_id, type, target_type) VALUES
+			('test_strike', '测试打击', '造成伤害', 'warrior', 'attack', 'enemy');
+		INSERT INTO achievements (id, name, description, category, condition_type, condition_value, is_hidden) VALUES
+			('test_kills', '初出茅庐', '击杀10个怪物', 'combat', 'kills', 10, 0),
+			('test_secret', '隐藏成就', '???', 'special', 'secret', 1, 1);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert link config: %v", err)
+	}
+
+	equipmentRepo := repository.NewEquipmentRepository()
+	prefixID, prefixValue := "test_prefix", 4.0
+	create := func(ownerID int) int {
+		equipment, err := equipmentRepo.Create(&models.EquipmentInstance{
+			ItemID: "trade_sword", OwnerID: ownerID, Slot: "main_hand", Quality: "epic",
+			EvolutionStage: 1, PrefixID: &prefixID, PrefixValue: &prefixValue,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create equipment: %v", err)
+		}
+		return equipment.ID
+	}
+	aliceItem := create(users[0])
+	bobItem := create(users[1])
+
+	lm := NewChatLinkManager()
+	content := fmt.Sprintf("看我的 [item:%d] 和 [skill:test_strike]，还有 [item:%d]", aliceItem, aliceItem)
+	links, err := lm.Resolve(users[0], content)
+	assert.NoError(t, err)
+	if assert.Len(t, links, 2, "重复的链接只保留一个") {
+		assert.Equal(t, fmt.Sprintf("[item:%d]", aliceItem), links[0].Token)
+		assert.Equal(t, "epic", links[0].Quality)
+		assert.Equal(t, "测试打击", links[1].Name)
+	}
+
+	// 消息保存快照，装备分解后仍可查看
+	chatRepo := repository.NewChatRepository()
+	_, err = chatRepo.SendMessage(&repository.ChatMessage{
+		Channel: "world", Faction: "alliance", SenderID: users[0], SenderName: "alice", Content: content, Links: links,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, equipmentRepo.Delete(aliceItem))
+
+	messages, err := chatRepo.GetChannelMessages("world", "alliance", "", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 1) && assert.Len(t, messages[0].Links, 2) {
+		item := messages[0].Links[0].Snapshot.(*models.ItemLinkSnapshot)
+		assert.Equal(t, "交易长剑", item.Name)
+		assert.Equal(t, map[string]float64{"attack": 12, "crit_rate": 0.05}, item.Stats)
+		if assert.Len(t, item.Affixes, 1) {
+			assert.Equal(t, "锋利的", item.Affixes[0].Name)
+			assert.Equal(t, 4.0, item.Affixes[0].Value)
+		}
+		assert.Equal(t, "test_strike", messages[0].Links[1].Snapshot.(*models.Skill).ID)
+	}
+
+	_, err = lm.Resolve(users[0], fmt.Sprintf("[item:%d]", bobItem))
+	assert.ErrorIs(t, err, ErrChatLinkNotFound, "不能链接别人的装备")
+	_, err = lm.Resolve(users[0], "[item:abc]")
+	assert.ErrorIs(t, err, ErrChatLinkNotFound)
+	_, err = lm.Resolve(users[0], "[achievement:test_secret]")
+	assert.ErrorIs(t, err, ErrChatLinkNotFound, "未完成的隐藏成就不能链接")
+
+	links, err = lm.Resolve(users[0], "[achievement:test_kills]")
+	assert.NoError(t, err)
+	if assert.Len(t, links, 1) {
+		achievement := links[0].Snapshot.(*models.AchievementLinkSnapshot)
+		assert.Equal(t, 10, achievement.ConditionValue)
+		assert.Nil(t, achievement.CompletedAt)
+	}
+
+	_, err = lm.Resolve(users[0], "[skill:a] [skill:b] [skill:c] [skill:d]")
+	assert.ErrorIs(t, err, ErrChatTooManyLinks)
+}
@@ -0,0 +1,364 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"text-wow/internal/config"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 聊天管理错误
+var (
+	ErrChatMuted            = errors.New("you are muted")
+	ErrChatBanned           = errors.New("you are banned from chat")
+	ErrChatRateLimited      = errors.New("you are sending messages too fast")
+	ErrChatDuplicateMessage = errors.New("duplicate message")
+	ErrChatNotModerator     = errors.New("moderator permission required")
+	ErrChatMessageNotFound  = errors.New("message not found")
+	ErrChatReportOwnMessage = errors.New("cannot report your own message")
+	ErrChatCannotSanction   = errors.New("cannot sanction this player")
+	ErrInvalidChatSanction  = errors.New("invalid sanction")
+)
+
+// maxModerationResults 举报/处罚列表最多返回条数
+const maxModerationResults = 100
+
+// ChatSanctionInput 禁言/封禁参数
+type ChatSanctionInput struct {
+	PlayerName string
+	Type       string        // mute/ban
+	Channel    string        // 仅 mute 可指定频道，为空表示全部公共频道
+	Duration   time.Duration // 0 表示永久
+	Reason     string
+}
+
+// ChatModerationManager 聊天管理器 - 敏感词过滤、频道限流、重复消息检测、举报与禁言/封禁
+type ChatModerationManager struct {
+	mu             sync.Mutex // 保护配置的延迟加载
+	configLoaded   bool
+	bannedWords    *regexp.Regexp
+	configManager  *config.ConfigManager
+	moderationRepo *repository.ChatModerationRepository
+	chatRepo       *repository.ChatRepository
+	userRepo       *repository.UserRepository
+}
+
+// NewChatModerationManager 创建聊天管理器
+func NewChatModerationManager() *ChatModerationManager {
+	return &ChatModerationManager{
+		configManager:  config.NewConfigManager(),
+		moderationRepo: repository.NewChatModerationRepository(),
+		chatRepo:       repository.NewChatRepository(),
+		userRepo:       repository.NewUserRepository(),
+	}
+}
+
+// 全局聊天管理器实例
+var chatModerationManager *ChatModerationManager
+var chatModerationOnce sync.Once
+
+// GetChatModerationManager 获取聊天管理器单例
+func GetChatModerationManager() *ChatModerationManager {
+	chatModerationOnce.Do(func() {
+		chatModerationManager = NewChatModerationManager()
+	})
+	return chatModerationManager
+}
+
+// getConfig 获取聊天管理配置与敏感词正则（首次使用时从配置表加载，失败时使用默认配置）
+func (m *ChatModerationManager) getConfig() (config.ChatModerationConfig, *regexp.Regexp) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.configLoaded {
+		if err := m.configManager.LoadConfig("chat_moderation"); err != nil {
+			fmt.Printf("[WARN] Failed to load chat moderation config, using defaults: %v\n", err)
+		}
+		m.configLoaded = true
+		m.compileBannedWords()
+	}
+
+	cfg, err := m.configManager.GetChatModerationConfig()
+	if err != nil {
+		return config.DefaultChatModerationConfig(), m.bannedWords
+	}
+	return cfg, m.bannedWords
+}
+
+// ReloadConfig 重新加载聊天管理配置（敏感词与限流参数热更新）
+func (m *ChatModerationManager) ReloadConfig() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.configLoaded = true
+	err := m.configManager.ReloadConfig("chat_moderation")
+	m.compileBannedWords()
+	return err
+}
+
+// compileBannedWords 根据当前配置编译敏感词正则（调用方持有锁）
+func (m *ChatModerationManager) compileBannedWords() {
+	m.bannedWords = nil
+	cfg, err := m.configManager.GetChatModerationConfig()
+	if err != nil {
+		return
+	}
+	words := make([]string, 0, len(cfg.BannedWords))
+	for _, word := range cfg.BannedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+	}
+	if len(words) > 0 {
+		m.bannedWords = regexp.MustCompile("(?i)" + strings.Join(words, "|"))
+	}
+}
+
+// ═══════════════════════════════════════════════════════════
+// 发言检查
+// ═══════════════════════════════════════════════════════════
+
+// FilterContent 去除首尾空白并替换敏感词
+func (m *ChatModerationManager) FilterContent(content string) string {
+	content = strings.TrimSpace(content)
+	if _, bannedWords := m.getConfig(); bannedWords != nil {
+		content = bannedWords.ReplaceAllString(content, "***")
+	}
+	return content
+}
+
+// Admit 检查玩家能否在频道发送该消息（禁言/封禁、重复消息、令牌桶限流），通过时消耗一次额度
+func (m *ChatModerationManager) Admit(userID int, channel, content string) error {
+	now := time.Now()
+	if err := m.CheckSanctions(userID, channel, now); err != nil {
+		return err
+	}
+
+	cfg, _ := m.getConfig()
+	limit := cfg.RateLimitFor(channel)
+	normalized := normalizeChatContent(content)
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		bucket, err := m.moderationRepo.GetRateBucketTx(tx, userID, channel)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			bucket = &models.ChatRateBucket{UserID: userID, Channel: channel, Tokens: float64(limit.Capacity), UpdatedAt: now}
+		}
+
+		window := time.Duration(cfg.DuplicateWindowSeconds) * time.Second
+		if bucket.LastSentAt != nil && bucket.LastContent == normalized && now.Sub(*bucket.LastSentAt) < window {
+			return ErrChatDuplicateMessage
+		}
+
+		RefillChatTokens(bucket, limit, now)
+		if bucket.Tokens < 1 {
+			wait := math.Ceil((1 - bucket.Tokens) * limit.RefillSeconds)
+			return fmt.Errorf("%w, retry in %ds", ErrChatRateLimited, int(wait))
+		}
+
+		bucket.Tokens--
+		bucket.LastContent = normalized
+		bucket.LastSentAt = &now
+		return m.moderationRepo.SaveRateBucketTx(tx, bucket)
+	})
+}
+
+// CheckSanctions 检查玩家在频道是否被禁言或封禁（禁言不影响私聊）
+func (m *ChatModerationManager) CheckSanctions(userID int, channel string, now time.Time) error {
+	sanctions, err := m.moderationRepo.GetActiveSanctions(userID, now)
+	if err != nil {
+		return err
+	}
+	for _, sanction := range sanctions {
+		switch {
+		case sanction.Type == models.ChatSanctionBan:
+			return fmt.Errorf("%w %s", ErrChatBanned, sanctionExpiry(sanction))
+		case channel != "whisper" && (sanction.Channel == "" || sanction.Channel == channel):
+			return fmt.Errorf("%w %s", ErrChatMuted, sanctionExpiry(sanction))
+		}
+	}
+	return nil
+}
+
+// GetSanctions 获取玩家自己生效中的禁言/封禁
+func (m *ChatModerationManager) GetSanctions(userID int) ([]*models.ChatSanction, error) {
+	return m.moderationRepo.GetActiveSanctions(userID, time.Now())
+}
+
+// RefillChatTokens 按经过的时间恢复令牌（不超过桶容量）
+func RefillChatTokens(bucket *models.ChatRateBucket, limit config.ChatRateLimit, now time.Time) {
+	capacity := float64(limit.Capacity)
+	if elapsed := now.Sub(bucket.UpdatedAt).Seconds(); elapsed > 0 && limit.RefillSeconds > 0 {
+		bucket.Tokens += elapsed / limit.RefillSeconds
+	}
+	if bucket.Tokens > capacity {
+		bucket.Tokens = capacity
+	}
+	bucket.UpdatedAt = now
+}
+
+// normalizeChatContent 归一化消息内容（忽略大小写和空白差异）用于重复检测
+func normalizeChatContent(content string) string {
+	return strings.ToLower(strings.Join(strings.Fields(content), " "))
+}
+
+// sanctionExpiry 处罚到期说明
+func sanctionExpiry(sanction *models.ChatSanction) string {
+	if sanction.ExpiresAt == nil {
+		return "permanently"
+	}
+	return "until " + sanction.ExpiresAt.UTC().Format(time.RFC3339)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 举报
+// ═══════════════════════════════════════════════════════════
+
+// Report 举报消息（私聊消息只能由收发双方举报）
+func (m *ChatModerationManager) Report(reporterID, messageID int, reason string) (*models.ChatReport, error) {
+	msg, err := m.chatRepo.GetMessageByID(messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrChatMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if msg.Channel == "whisper" && msg.SenderID != reporterID && msg.ReceiverID != reporterID {
+		return nil, ErrChatMessageNotFound
+	}
+	if msg.SenderID == reporterID {
+		return nil, ErrChatReportOwnMessage
+	}
+	reporter, err := m.userRepo.GetByID(reporterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.moderationRepo.CreateReport(&models.ChatReport{
+		MessageID:      msg.ID,
+		ReporterID:     reporterID,
+		ReporterName:   reporter.Username,
+		ReportedUserID: msg.SenderID,
+		ReportedName:   msg.SenderName,
+		Content:        msg.Content,
+		Reason:         strings.TrimSpace(reason),
+	})
+}
+
+// ListReports 获取举报列表（管理员）
+func (m *ChatModerationManager) ListReports(moderatorID int, status string) ([]*models.ChatReport, error) {
+	if _, err := m.requireModerator(moderatorID); err != nil {
+		return nil, err
+	}
+	return m.moderationRepo.GetReports(status, maxModerationResults)
+}
+
+// ResolveReport 处理举报：resolved/dismissed（管理员）
+func (m *ChatModerationManager) ResolveReport(moderatorID, reportID int, status string) error {
+	if _, err := m.requireModerator(moderatorID); err != nil {
+		return err
+	}
+	return m.moderationRepo.ResolveReport(reportID, status, moderatorID)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 禁言/封禁
+// ═══════════════════════════════════════════════════════════
+
+// Sanction 对玩家禁言或封禁（管理员；版主只能处罚普通玩家）
+func (m *ChatModerationManager) Sanction(moderatorID int, input *ChatSanctionInput) (*models.ChatSanction, error) {
+	role, err := m.requireModerator(moderatorID)
+	if err != nil {
+		return nil, err
+	}
+	if input.Type != models.ChatSanctionMute && input.Type != models.ChatSanctionBan {
+		return nil, fmt.Errorf("%w: type must be mute or ban", ErrInvalidChatSanction)
+	}
+	if input.Type == models.ChatSanctionBan && input.Channel != "" {
+		return nil, fmt.Errorf("%w: bans apply to all channels", ErrInvalidChatSanction)
+	}
+	if input.Channel == "whisper" {
+		return nil, fmt.Errorf("%w: use a ban to block whispers", ErrInvalidChatSanction)
+	}
+	if input.Duration < 0 {
+		return nil, fmt.Errorf("%w: duration must not be negative", ErrInvalidChatSanction)
+	}
+
+	target, err := m.userRepo.GetByUsername(input.PlayerName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrChatPlayerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == moderatorID {
+		return nil, ErrChatTargetSelf
+	}
+	targetRole, err := m.userRepo.GetRole(target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if targetRole != models.RolePlayer && role != models.RoleAdmin {
+		return nil, ErrChatCannotSanction
+	}
+
+	sanction := &models.ChatSanction{
+		UserID:     target.ID,
+		PlayerName: target.Username,
+		Type:       input.Type,
+		Channel:    input.Channel,
+		Reason:     strings.TrimSpace(input.Reason),
+		IssuedBy:   moderatorID,
+	}
+	if input.Duration > 0 {
+		expiresAt := time.Now().Add(input.Duration).UTC()
+		sanction.ExpiresAt = &expiresAt
+	}
+	return m.moderationRepo.CreateSanction(sanction)
+}
+
+// ListSanctions 获取生效中的禁言/封禁（管理员；playerName 为空时返回全部）
+func (m *ChatModerationManager) ListSanctions(moderatorID int, playerName string) ([]*models.ChatSanction, error) {
+	if _, err := m.requireModerator(moderatorID); err != nil {
+		return nil, err
+	}
+	userID := 0
+	if playerName != "" {
+		target, err := m.userRepo.GetByUsername(playerName)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChatPlayerNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		userID = target.ID
+	}
+	return m.moderationRepo.GetActiveSanctions(userID, time.Now())
+}
+
+// RevokeSanction 解除禁言/封禁（管理员）
+func (m *ChatModerationManager) RevokeSanction(moderatorID, sanctionID int) error {
+	if _, err := m.requireModerator(moderatorID); err != nil {
+		return err
+	}
+	return m.moderationRepo.RevokeSanction(sanctionID, time.Now())
+}
+
+// requireModerator 检查用户是否为版主及以上角色，返回其角色
+func (m *ChatModerationManager) requireModerator(userID int) (string, error) {
+	role, err := m.userRepo.GetRole(userID)
+	if err != nil {
+		return "", err
+	}
+	if !models.RoleAtLeast(role, models.RoleModerator) {
+		return "", ErrChatNotModerator
+	}
+	return role, nil
+}
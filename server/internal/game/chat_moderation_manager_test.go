@@ -0,0 +1,126 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/config"
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefillChatTokens(t *testing.T) {
+	now := time.Now()
+	limit := config.ChatRateLimit{Capacity: 3, RefillSeconds: 10}
+
+	bucket := &models.ChatRateBucket{Tokens: 0, UpdatedAt: now.Add(-25 * time.Second)}
+	RefillChatTokens(bucket, limit, now)
+	assert.InDelta(t, 2.5, bucket.Tokens, 0.001)
+	assert.Equal(t, now, bucket.UpdatedAt)
+
+	bucket = &models.ChatRateBucket{Tokens: 2, UpdatedAt: now.Add(-time.Hour)}
+	RefillChatTokens(bucket, limit, now)
+	assert.Equal(t, 3.0, bucket.Tokens, "不超过桶容量")
+}
+
+func TestChatModerationManager_Admit(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice")
+	defer database.TeardownTestDB(testDB)
+
+	err := config.NewConfigManager().SaveConfigVersion("chat_moderation", 1, map[string]interface{}{
+		"banned_words": []string{"gold.com"},
+	}, "test banned words")
+	assert.NoError(t, err)
+
+	mm := NewChatModerationManager()
+	assert.Equal(t, "buy at *** now", mm.FilterContent("  buy at GOLD.COM now "))
+	assert.Equal(t, "buy at goldxcom", mm.FilterContent("buy at goldxcom"), "敏感词按字面匹配")
+
+	// 世界频道默认容量为3
+	for i, content := range []string{"one", "two", "three"} {
+		assert.NoError(t, mm.Admit(users[0], "world", content), i)
+	}
+	assert.ErrorIs(t, mm.Admit(users[0], "world", "four"), ErrChatRateLimited)
+	assert.NoError(t, mm.Admit(users[0], "zone", "four"), "各频道独立限流")
+
+	assert.ErrorIs(t, mm.Admit(users[0], "zone", "  FOUR "), ErrChatDuplicateMessage)
+	assert.NoError(t, mm.Admit(users[0], "zone", "five"))
+}
+
+func TestChatModerationManager_ReportsAndSanctions(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice", "bob", "mod", "admin")
+	defer database.TeardownTestDB(testDB)
+	alice, bob, mod, admin := users[0], users[1], users[2], users[3]
+
+	userRepo := repository.NewUserRepository()
+	assert.NoError(t, userRepo.SetRole(mod, models.RoleModerator))
+	assert.NoError(t, userRepo.SetRole(admin, models.RoleAdmin))
+
+	chatRepo := repository.NewChatRepository()
+	msg, err := chatRepo.SendMessage(&repository.ChatMessage{
+		Channel: "world", Faction: "alliance", SenderID: bob, SenderName: "bob", Content: "spam spam",
+	})
+	assert.NoError(t, err)
+
+	mm := NewChatModerationManager()
+
+	// 举报
+	_, err = mm.Report(bob, msg.ID, "")
+	assert.ErrorIs(t, err, ErrChatReportOwnMessage)
+	_, err = mm.Report(alice, 99999, "")
+	assert.ErrorIs(t, err, ErrChatMessageNotFound)
+	report, err := mm.Report(alice, msg.ID, "spamming")
+	assert.NoError(t, err)
+	_, err = mm.Report(alice, msg.ID, "again")
+	assert.ErrorIs(t, err, repository.ErrChatAlreadyReported)
+
+	_, err = mm.ListReports(alice, "pending")
+	assert.ErrorIs(t, err, ErrChatNotModerator)
+	reports, err := mm.ListReports(mod, "pending")
+	assert.NoError(t, err)
+	if assert.Len(t, reports, 1) {
+		assert.Equal(t, "bob", reports[0].ReportedName)
+		assert.Equal(t, "spam spam", reports[0].Content)
+	}
+	assert.NoError(t, mm.ResolveReport(mod, report.ID, "resolved"))
+	assert.ErrorIs(t, mm.ResolveReport(mod, report.ID, "dismissed"), repository.ErrChatReportNotPending)
+
+	// 禁言：只影响公共频道
+	_, err = mm.Sanction(alice, &ChatSanctionInput{PlayerName: "bob", Type: models.ChatSanctionMute})
+	assert.ErrorIs(t, err, ErrChatNotModerator)
+	_, err = mm.Sanction(mod, &ChatSanctionInput{PlayerName: "admin", Type: models.ChatSanctionMute})
+	assert.ErrorIs(t, err, ErrChatCannotSanction, "版主不能处罚管理员")
+	_, err = mm.Sanction(mod, &ChatSanctionInput{PlayerName: "bob", Type: models.ChatSanctionBan, Channel: "world"})
+	assert.ErrorIs(t, err, ErrInvalidChatSanction)
+
+	mute, err := mm.Sanction(mod, &ChatSanctionInput{PlayerName: "bob", Type: models.ChatSanctionMute, Duration: time.Hour})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, mm.Admit(bob, "world", "hello"), ErrChatMuted)
+	assert.NoError(t, mm.Admit(bob, "whisper", "hello"), "禁言不影响私聊")
+
+	sanctions, err := mm.ListSanctions(mod, "bob")
+	assert.NoError(t, err)
+	assert.Len(t, sanctions, 1)
+	assert.NoError(t, mm.RevokeSanction(mod, mute.ID))
+	assert.ErrorIs(t, mm.RevokeSanction(mod, mute.ID), repository.ErrChatSanctionNotActive)
+	assert.NoError(t, mm.Admit(bob, "world", "hello again"))
+
+	// 封禁：禁止一切聊天（管理员可以处罚版主）
+	_, err = mm.Sanction(admin, &ChatSanctionInput{PlayerName: "mod", Type: models.ChatSanctionBan})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, mm.Admit(mod, "whisper", "hi"), ErrChatBanned)
+
+	// 已过期的处罚不再生效
+	_, err = repository.NewChatModerationRepository().CreateSanction(&models.ChatSanction{
+		UserID: alice, Type: models.ChatSanctionBan, IssuedBy: admin, ExpiresAt: timePtr(time.Now().Add(-time.Minute)),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mm.Admit(alice, "world", "still here"))
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
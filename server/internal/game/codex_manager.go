@@ -0,0 +1,171 @@
+package game
+
+import (
+	"sync"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// CodexBonuses 玩家已生效的图鉴加成（百分比）
+type CodexBonuses struct {
+	DamageAll       float64            // 对所有怪物的伤害加成
+	DamageByMonster map[string]float64 // key: monsterID
+	DamageByFamily  map[string]float64 // key: 怪物族群
+}
+
+// CodexManager 图鉴管理器 - 处理图鉴解锁与加成
+type CodexManager struct {
+	mu        sync.RWMutex
+	codexRepo *repository.CodexRepository
+	gameRepo  *repository.GameRepository
+	bonuses   map[int]*CodexBonuses // key: userID, 加成缓存
+	families  map[string]string     // key: monsterID, 怪物族群缓存
+}
+
+// NewCodexManager 创建图鉴管理器
+func NewCodexManager() *CodexManager {
+	return &CodexManager{
+		codexRepo: repository.NewCodexRepository(),
+		gameRepo:  repository.NewGameRepository(),
+		bonuses:   make(map[int]*CodexBonuses),
+		families:  make(map[string]string),
+	}
+}
+
+// RecordMonsterKill 记录击杀，解锁对应的怪物/首领图鉴，返回本次首次解锁或加成刚生效的条目
+func (cm *CodexManager) RecordMonsterKill(userID int, monsterID string) ([]*models.CodexEntry, error) {
+	return cm.record(userID, monsterID, "monster", "boss")
+}
+
+// RecordItemAcquired 记录获得物品，解锁对应的物品图鉴
+func (cm *CodexManager) RecordItemAcquired(userID int, itemID string) ([]*models.CodexEntry, error) {
+	return cm.record(userID, itemID, "item")
+}
+
+// record 解锁目标对应的图鉴条目
+func (cm *CodexManager) record(userID int, targetID string, categories ...string) ([]*models.CodexEntry, error) {
+	ids, err := cm.codexRepo.GetEntryIDsByTarget(targetID, categories...)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var milestones []*models.CodexEntry
+	for _, id := range ids {
+		count, err := cm.codexRepo.Unlock(userID, id)
+		if err != nil {
+			return milestones, err
+		}
+
+		entry, err := cm.codexRepo.GetEntryByID(userID, id)
+		if err != nil {
+			return milestones, err
+		}
+		bonusReached := entry.BonusType != "" && count == entry.RequiredCount
+		if bonusReached {
+			cm.InvalidateUser(userID)
+		}
+		// 首次解锁或加成刚好生效时通知
+		if count == 1 || bonusReached {
+			milestones = append(milestones, entry)
+		}
+	}
+	return milestones, nil
+}
+
+// GetCodex 获取玩家图鉴
+func (cm *CodexManager) GetCodex(userID int, category string) ([]*models.CodexEntry, error) {
+	return cm.codexRepo.GetEntries(userID, category)
+}
+
+// GetCodexEntry 获取单个图鉴条目
+func (cm *CodexManager) GetCodexEntry(userID int, codexID string) (*models.CodexEntry, error) {
+	return cm.codexRepo.GetEntryByID(userID, codexID)
+}
+
+// GetBonuses 获取玩家已生效的图鉴加成（带缓存）
+func (cm *CodexManager) GetBonuses(userID int) (*CodexBonuses, error) {
+	cm.mu.RLock()
+	if bonuses, exists := cm.bonuses[userID]; exists {
+		cm.mu.RUnlock()
+		return bonuses, nil
+	}
+	cm.mu.RUnlock()
+
+	entries, err := cm.codexRepo.GetUnlockedEntries(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bonuses := &CodexBonuses{
+		DamageByMonster: make(map[string]float64),
+		DamageByFamily:  make(map[string]float64),
+	}
+	for _, entry := range entries {
+		if !entry.BonusActive {
+			continue
+		}
+		switch entry.BonusType {
+		case "damage_all":
+			bonuses.DamageAll += entry.BonusValue
+		case "damage_vs_monster":
+			bonuses.DamageByMonster[entry.TargetID] += entry.BonusValue
+		case "damage_vs_family":
+			if entry.BonusFamily != "" {
+				bonuses.DamageByFamily[entry.BonusFamily] += entry.BonusValue
+			} else {
+				// 没有族群的怪物仅对自身生效
+				bonuses.DamageByMonster[entry.TargetID] += entry.BonusValue
+			}
+		}
+	}
+
+	cm.mu.Lock()
+	cm.bonuses[userID] = bonuses
+	cm.mu.Unlock()
+
+	return bonuses, nil
+}
+
+// GetDamageBonus 获取玩家对指定怪物的图鉴伤害加成（百分比）
+func (cm *CodexManager) GetDamageBonus(userID int, monsterID string) float64 {
+	bonuses, err := cm.GetBonuses(userID)
+	if err != nil || bonuses == nil {
+		return 0
+	}
+
+	total := bonuses.DamageAll + bonuses.DamageByMonster[monsterID]
+	if len(bonuses.DamageByFamily) > 0 {
+		if family := cm.getMonsterFamily(monsterID); family != "" {
+			total += bonuses.DamageByFamily[family]
+		}
+	}
+	return total
+}
+
+// InvalidateUser 清除玩家的加成缓存
+func (cm *CodexManager) InvalidateUser(userID int) {
+	cm.mu.Lock()
+	delete(cm.bonuses, userID)
+	cm.mu.Unlock()
+}
+
+// getMonsterFamily 获取怪物族群（带缓存）
+func (cm *CodexManager) getMonsterFamily(monsterID string) string {
+	cm.mu.RLock()
+	family, exists := cm.families[monsterID]
+	cm.mu.RUnlock()
+	if exists {
+		return family
+	}
+
+	family, err := cm.gameRepo.GetMonsterFamily(monsterID)
+	if err != nil {
+		return ""
+	}
+
+	cm.mu.Lock()
+	cm.families[monsterID] = family
+	cm.mu.Unlock()
+	return family
+}
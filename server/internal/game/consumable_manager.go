@@ -0,0 +1,154 @@
+package game
+
+import (
+	"errors"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 消耗品错误
+var (
+	ErrConsumableNoEffect    = errors.New("item would have no effect right now")
+	ErrFoodRequiresRest      = errors.New("food can only be eaten while resting")
+	ErrCannotUseWhileDead    = errors.New("dead characters cannot use items")
+	ErrItemNotUsableInBattle = errors.New("item cannot be used in battle")
+	ErrConsumableNoCharacter = errors.New("character not found")
+)
+
+// 消耗品效果类型
+const (
+	ConsumableEffectHealHP    = "heal_hp"    // 立即恢复生命值
+	ConsumableEffectHealMP    = "heal_mp"    // 立即恢复法力值
+	ConsumableEffectBuff      = "buff"       // 施加持续若干回合的增益
+	ConsumableEffectRestSpeed = "rest_speed" // 提高休息恢复速度（百分比）
+)
+
+// wellFedBuffID 食物增益的Buff ID（新食物替换旧食物效果）
+const wellFedBuffID = "well_fed"
+
+// ConsumableManager 消耗品管理器 - 校验并应用药水、药剂、食物的效果
+type ConsumableManager struct {
+	consumableRepo *repository.ConsumableRepository
+	buffManager    *BuffManager
+}
+
+// NewConsumableManager 创建消耗品管理器（与战斗共享Buff管理器）
+func NewConsumableManager(buffManager *BuffManager) *ConsumableManager {
+	return &ConsumableManager{
+		consumableRepo: repository.NewConsumableRepository(),
+		buffManager:    buffManager,
+	}
+}
+
+// GetConsumables 获取角色背包中的消耗品
+func (m *ConsumableManager) GetConsumables(characterID int) ([]*models.ConsumableItem, error) {
+	return m.consumableRepo.GetCharacterConsumables(characterID)
+}
+
+// ItemCounts 获取角色背包中各消耗品的数量（供策略条件使用）
+func (m *ConsumableManager) ItemCounts(characterID int) (map[string]int, error) {
+	items, err := m.consumableRepo.GetCharacterConsumables(characterID)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(items))
+	for _, item := range items {
+		counts[item.ItemID] = item.Quantity
+	}
+	return counts, nil
+}
+
+// UseItem 使用消耗品：校验效果 -> 扣除物品 -> 修改角色状态（调用方负责保存角色）
+// inBattle 为 true 时不允许食物类物品；resting 表示角色正在休息
+func (m *ConsumableManager) UseItem(char *models.Character, itemID string, inBattle, resting bool) (*models.ConsumableUseResult, error) {
+	if char.IsDead || char.HP <= 0 {
+		return nil, ErrCannotUseWhileDead
+	}
+
+	item, err := m.consumableRepo.GetCharacterConsumable(char.ID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Quantity <= 0 {
+		return nil, repository.ErrItemNotInInventory
+	}
+	if err := m.checkUsable(char, item, inBattle, resting); err != nil {
+		return nil, err
+	}
+
+	remaining, err := m.consumableRepo.ConsumeItem(char.ID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ConsumableUseResult{
+		CharacterID: char.ID,
+		ItemID:      item.ItemID,
+		ItemName:    item.Name,
+		EffectType:  item.EffectType,
+		Remaining:   remaining,
+	}
+	m.applyEffect(char, item, result)
+	result.HP = char.HP
+	result.Resource = char.Resource
+	return result, nil
+}
+
+// checkUsable 检查消耗品当前是否能产生效果
+func (m *ConsumableManager) checkUsable(char *models.Character, item *models.ConsumableItem, inBattle, resting bool) error {
+	switch item.EffectType {
+	case ConsumableEffectHealHP:
+		if char.HP >= char.MaxHP {
+			return ErrConsumableNoEffect
+		}
+	case ConsumableEffectHealMP:
+		if char.ResourceType == "rage" || char.Resource >= char.MaxResource {
+			return ErrConsumableNoEffect
+		}
+	case ConsumableEffectBuff:
+		if item.EffectStat == "" || item.EffectDuration <= 0 {
+			return ErrConsumableNoEffect
+		}
+	case ConsumableEffectRestSpeed:
+		if inBattle {
+			return ErrItemNotUsableInBattle
+		}
+		if !resting {
+			return ErrFoodRequiresRest
+		}
+	default:
+		return ErrConsumableNoEffect
+	}
+	return nil
+}
+
+// applyEffect 将消耗品效果应用到角色
+func (m *ConsumableManager) applyEffect(char *models.Character, item *models.ConsumableItem, result *models.ConsumableUseResult) {
+	switch item.EffectType {
+	case ConsumableEffectHealHP:
+		before := char.HP
+		char.HP += int(item.EffectValue)
+		if char.HP > char.MaxHP {
+			char.HP = char.MaxHP
+		}
+		result.Amount = char.HP - before
+	case ConsumableEffectHealMP:
+		before := char.Resource
+		char.Resource += int(item.EffectValue)
+		if char.Resource > char.MaxResource {
+			char.Resource = char.MaxResource
+		}
+		result.Amount = char.Resource - before
+	case ConsumableEffectBuff:
+		m.buffManager.ApplyBuff(char.ID, "item_"+item.ItemID, item.Name, "buff", true,
+			item.EffectDuration, item.EffectValue, item.EffectStat, "")
+		result.Amount = int(item.EffectValue)
+		result.Duration = item.EffectDuration
+	case ConsumableEffectRestSpeed:
+		// 食物效果只在本次休息中生效，下一场战斗结束时随其他Buff一起清除
+		m.buffManager.ApplyBuff(char.ID, wellFedBuffID, item.Name, "buff", true,
+			1, item.EffectValue, "rest_speed", "")
+		result.Amount = int(item.EffectValue)
+	}
+}
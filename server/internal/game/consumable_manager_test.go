@@ -0,0 +1,158 @@
+package game
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupConsumableTest(t *testing.T) (*sql.DB, int, *models.Character) {
+	testDB, _, users := setupTradingTest(t, "drinker")
+
+	_, err := testDB.Exec(`
+		INSERT INTO items (id, name, type, subtype, stackable, max_stack, effect_type, effect_value, effect_stat, effect_duration) VALUES
+			('test_potion', '测试药水', 'consumable', 'potion', 1, 20, 'heal_hp', 30, NULL, NULL),
+			('test_elixir', '测试药剂', 'consumable', 'elixir', 1, 10, 'buff', 10, 'attack', 5),
+			('test_bread', '测试面包', 'consumable', 'food', 1, 20, 'rest_speed', 100, NULL, NULL);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert consumables: %v", err)
+	}
+
+	char, err := repository.NewCharacterRepository().Create(&models.Character{
+		UserID: users[0], Name: "drinkchar", RaceID: "human", ClassID: "warrior", Faction: "alliance",
+		TeamSlot: 1, IsActive: true, Level: 10, HP: 40, MaxHP: 100, ResourceType: "rage", MaxResource: 100,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	inventory := repository.NewInventoryRepository()
+	for itemID, quantity := range map[string]int{"test_potion": 2, "test_elixir": 1, "test_bread": 1} {
+		if err := inventory.AddItem(char.ID, itemID, quantity); err != nil {
+			t.Fatalf("Failed to add %s: %v", itemID, err)
+		}
+	}
+	return testDB, users[0], char
+}
+
+func TestConsumableManager_UseItem(t *testing.T) {
+	testDB, _, char := setupConsumableTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	buffs := NewBuffManager()
+	cm := NewConsumableManager(buffs)
+
+	result, err := cm.UseItem(char, "test_potion", true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, result.Amount)
+	assert.Equal(t, 70, char.HP)
+	assert.Equal(t, 1, result.Remaining)
+
+	result, err = cm.UseItem(char, "test_potion", true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, result.Amount, "恢复量不超过生命上限")
+	assert.Equal(t, 100, char.HP)
+	assert.Equal(t, 0, result.Remaining)
+	_, quantity := inventoryOf(t, testDB, char.ID, "test_potion")
+	assert.Equal(t, 0, quantity, "用完后背包格被清除")
+
+	_, err = cm.UseItem(char, "test_potion", true, false)
+	assert.ErrorIs(t, err, repository.ErrItemNotInInventory)
+	_, err = cm.UseItem(char, "trade_sword", true, false)
+	assert.ErrorIs(t, err, repository.ErrItemNotConsumable)
+
+	result, err = cm.UseItem(char, "test_elixir", true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Duration)
+	assert.Equal(t, 10.0, buffs.GetBuffValue(char.ID, "attack"))
+
+	_, err = cm.UseItem(char, "test_bread", true, true)
+	assert.ErrorIs(t, err, ErrItemNotUsableInBattle)
+	_, err = cm.UseItem(char, "test_bread", false, false)
+	assert.ErrorIs(t, err, ErrFoodRequiresRest)
+	_, quantity = inventoryOf(t, testDB, char.ID, "test_bread")
+	assert.Equal(t, 1, quantity, "无法使用时不消耗物品")
+}
+
+func TestStrategyExecutor_UseItemRule(t *testing.T) {
+	executor := &StrategyExecutor{}
+	strategy := &models.BattleStrategy{
+		ConditionalRules: []models.ConditionalRule{
+			{
+				ID: "potion", Priority: 1, Enabled: true,
+				Condition: models.RuleCondition{
+					Type: "self_hp_percent", Operator: "<", Value: 25,
+					And: []models.RuleCondition{{Type: "item_count", ItemID: "test_potion", Operator: ">", Value: 0}},
+				},
+				Action: models.RuleAction{Type: "use_item", ItemID: "test_potion"},
+			},
+		},
+	}
+	assert.True(t, executor.UsesItems(strategy))
+
+	char := &models.Character{ID: 1, HP: 20, MaxHP: 100, Resource: 50, MaxResource: 100}
+	enemies := []*models.Monster{{ID: "wolf", HP: 50, MaxHP: 50}}
+	ctx := &BattleContext{
+		Character:  char,
+		Enemies:    enemies,
+		Target:     enemies[0],
+		ItemCounts: map[string]int{"test_potion": 2},
+	}
+
+	decision := executor.ExecuteStrategy(strategy, ctx)
+	if assert.NotNil(t, decision) {
+		assert.Equal(t, "test_potion", decision.ItemID)
+	}
+
+	ctx.ItemCounts["test_potion"] = 0
+	decision = executor.ExecuteStrategy(strategy, ctx)
+	assert.True(t, decision == nil || decision.ItemID == "", "没有药水时不使用物品")
+
+	ctx.ItemCounts["test_potion"] = 2
+	char.HP = 50
+	decision = executor.ExecuteStrategy(strategy, ctx)
+	assert.True(t, decision == nil || decision.ItemID == "", "血量充足时不使用物品")
+}
+
+func TestBattleManager_UseItem_FoodShortensRest(t *testing.T) {
+	testDB, userID, char := setupConsumableTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	buffs := NewBuffManager()
+	manager := &BattleManager{
+		sessions:          make(map[int]*BattleSession),
+		charRepo:          repository.NewCharacterRepository(),
+		buffManager:       buffs,
+		consumableManager: NewConsumableManager(buffs),
+	}
+
+	normalRest := manager.calculateRestTime(char)
+	assert.Equal(t, 30*time.Second, normalRest)
+
+	_, err := manager.UseItem(userID, char.ID, "test_bread")
+	assert.ErrorIs(t, err, ErrFoodRequiresRest)
+
+	session := manager.GetOrCreateSession(userID)
+	now := time.Now()
+	restUntil := now.Add(normalRest)
+	session.IsResting = true
+	session.RestStartedAt = &now
+	session.RestUntil = &restUntil
+
+	result, err := manager.UseItem(userID, char.ID, "test_bread")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, session.RestSpeed)
+	if assert.NotNil(t, result.RestUntil) {
+		assert.WithinDuration(t, now.Add(15*time.Second), *result.RestUntil, 2*time.Second, "恢复速度翻倍，休息时间减半")
+	}
+
+	_, err = manager.UseItem(userID+1, char.ID, "test_potion")
+	assert.ErrorIs(t, err, ErrConsumableNoCharacter)
+}
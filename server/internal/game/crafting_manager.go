@@ -0,0 +1,467 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 制造错误
+var (
+	ErrCraftingNoCharacter  = errors.New("character not found")
+	ErrInvalidCraftQuantity = errors.New("invalid craft quantity")
+	ErrCraftingQueueFull    = errors.New("crafting queue is full")
+	ErrRecipeSkillTooLow    = errors.New("profession skill is too low for this recipe")
+)
+
+const (
+	maxProfessionsPerType   = 2  // 每种类型（制造/采集）最多学习的专业数
+	maxCraftingQueueEntries = 5  // 每个角色未完成的队列条目上限
+	maxCraftQuantity        = 20 // 单个队列条目的制造数量上限
+)
+
+// craftQualityOrder 装备品质提升顺序（制造触发时提升一档）
+var craftQualityOrder = []string{"common", "uncommon", "rare", "epic", "legendary"}
+
+// CraftingManager 专业与制造管理器 - 专业学习、配方、制造队列与技能提升
+type CraftingManager struct {
+	mu               sync.Mutex
+	craftingRepo     *repository.CraftingRepository
+	charRepo         *repository.CharacterRepository
+	equipmentRepo    *repository.EquipmentRepository
+	equipmentManager *EquipmentManager
+}
+
+// NewCraftingManager 创建制造管理器
+func NewCraftingManager() *CraftingManager {
+	return &CraftingManager{
+		craftingRepo:     repository.NewCraftingRepository(),
+		charRepo:         repository.NewCharacterRepository(),
+		equipmentRepo:    repository.NewEquipmentRepository(),
+		equipmentManager: NewEquipmentManager(),
+	}
+}
+
+// 全局制造管理器实例
+var craftingManager *CraftingManager
+var craftingOnce sync.Once
+
+// GetCraftingManager 获取制造管理器单例
+func GetCraftingManager() *CraftingManager {
+	craftingOnce.Do(func() {
+		craftingManager = NewCraftingManager()
+	})
+	return craftingManager
+}
+
+// ═══════════════════════════════════════════════════════════
+// 专业
+// ═══════════════════════════════════════════════════════════
+
+// GetProfessions 获取所有专业
+func (cm *CraftingManager) GetProfessions() ([]*models.Profession, error) {
+	return cm.craftingRepo.GetProfessions("")
+}
+
+// GetRecipes 获取专业配方（指定角色时按其技能点标注难度）
+func (cm *CraftingManager) GetRecipes(userID, characterID int, professionID string) ([]*models.Recipe, error) {
+	if _, err := cm.craftingRepo.GetProfession(professionID); err != nil {
+		return nil, err
+	}
+	recipes, err := cm.craftingRepo.GetRecipes(professionID)
+	if err != nil {
+		return nil, err
+	}
+	if characterID == 0 {
+		return recipes, nil
+	}
+
+	if _, err := cm.getOwnedCharacter(userID, characterID); err != nil {
+		return nil, err
+	}
+	skill := 0
+	cp, err := cm.craftingRepo.GetCharacterProfession(characterID, professionID)
+	if err == nil {
+		skill = cp.SkillLevel
+	} else if !errors.Is(err, repository.ErrProfessionNotLearned) {
+		return nil, err
+	}
+	for _, recipe := range recipes {
+		recipe.Difficulty = RecipeDifficulty(recipe, skill)
+	}
+	return recipes, nil
+}
+
+// GetCharacterProfessions 获取角色的专业进度
+func (cm *CraftingManager) GetCharacterProfessions(userID, characterID int) ([]*models.CharacterProfession, error) {
+	if _, err := cm.getOwnedCharacter(userID, characterID); err != nil {
+		return nil, err
+	}
+	return cm.craftingRepo.GetCharacterProfessions(characterID)
+}
+
+// LearnProfession 学习专业
+func (cm *CraftingManager) LearnProfession(userID, characterID int, professionID string) (*models.CharacterProfession, error) {
+	if _, err := cm.getOwnedCharacter(userID, characterID); err != nil {
+		return nil, err
+	}
+	return cm.craftingRepo.LearnProfession(characterID, professionID, maxProfessionsPerType)
+}
+
+// AbandonProfession 放弃专业（技能点清零）
+func (cm *CraftingManager) AbandonProfession(userID, characterID int, professionID string) error {
+	if _, err := cm.getOwnedCharacter(userID, characterID); err != nil {
+		return err
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.craftingRepo.AbandonProfession(characterID, professionID)
+}
+
+// RecipeDifficulty 配方相对技能点的难度：unavailable/orange/yellow/green/gray
+func RecipeDifficulty(recipe *models.Recipe, skill int) string {
+	switch {
+	case skill < recipe.RequiredSkill:
+		return "unavailable"
+	case skill < recipe.SkillUpYellow:
+		return "orange"
+	case skill < (recipe.SkillUpYellow+recipe.SkillUpGray)/2:
+		return "yellow"
+	case skill < recipe.SkillUpGray:
+		return "green"
+	default:
+		return "gray"
+	}
+}
+
+// SkillUpChance 制造一次获得技能点的概率
+func SkillUpChance(recipe *models.Recipe, skill int) float64 {
+	return skillUpChance(skill, recipe.SkillUpYellow, recipe.SkillUpGray)
+}
+
+// skillUpChance 专业技能提升概率：黄色阈值前必定提升，之后线性降低到灰色阈值为0
+func skillUpChance(skill, yellow, gray int) float64 {
+	if skill < yellow {
+		return 1
+	}
+	if skill >= gray || gray <= yellow {
+		return 0
+	}
+	return float64(gray-skill) / float64(gray-yellow)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 制造队列
+// ═══════════════════════════════════════════════════════════
+
+// QueueCraft 加入制造队列（立即扣除全部材料，队列空闲时立即开始）
+func (cm *CraftingManager) QueueCraft(userID, characterID int, recipeID string, quantity int) (*models.CraftingQueueEntry, error) {
+	if quantity <= 0 || quantity > maxCraftQuantity {
+		return nil, ErrInvalidCraftQuantity
+	}
+	if _, err := cm.getOwnedCharacter(userID, characterID); err != nil {
+		return nil, err
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	now := time.Now().UTC()
+	if _, err := cm.processCharacter(characterID, now); err != nil {
+		return nil, err
+	}
+
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.CraftingQueueEntry, error) {
+		recipe, err := cm.craftingRepo.GetRecipeTx(tx, recipeID)
+		if err != nil {
+			return nil, err
+		}
+		profession, err := cm.craftingRepo.GetCharacterProfessionTx(tx, characterID, recipe.ProfessionID)
+		if err != nil {
+			return nil, err
+		}
+		if profession.SkillLevel < recipe.RequiredSkill {
+			return nil, ErrRecipeSkillTooLow
+		}
+
+		pending, err := cm.craftingRepo.CountPendingTx(tx, characterID)
+		if err != nil {
+			return nil, err
+		}
+		if pending >= maxCraftingQueueEntries {
+			return nil, ErrCraftingQueueFull
+		}
+		if err := cm.craftingRepo.ConsumeMaterialsTx(tx, characterID, recipe.Materials, quantity); err != nil {
+			return nil, err
+		}
+
+		entry := &models.CraftingQueueEntry{
+			UserID:       userID,
+			CharacterID:  characterID,
+			RecipeID:     recipe.ID,
+			RecipeName:   recipe.Name,
+			ProfessionID: recipe.ProfessionID,
+			Quantity:     quantity,
+			Status:       "queued",
+		}
+		active, err := cm.craftingRepo.GetActiveEntryTx(tx, characterID)
+		if err != nil {
+			return nil, err
+		}
+		if active == nil {
+			nextCraftAt := now.Add(time.Duration(recipe.CraftSeconds) * time.Second)
+			entry.Status = "active"
+			entry.NextCraftAt = &nextCraftAt
+		}
+		if err := cm.craftingRepo.CreateQueueEntryTx(tx, entry); err != nil {
+			return nil, err
+		}
+		return entry, nil
+	})
+}
+
+// CancelCraft 取消队列条目，返还未完成部分的材料
+func (cm *CraftingManager) CancelCraft(userID, characterID, queueID int) (*models.CraftingQueueEntry, error) {
+	if _, err := cm.getOwnedCharacter(userID, characterID); err != nil {
+		return nil, err
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	now := time.Now().UTC()
+	if _, err := cm.processCharacter(characterID, now); err != nil {
+		return nil, err
+	}
+
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.CraftingQueueEntry, error) {
+		entry, err := cm.craftingRepo.GetQueueEntryTx(tx, queueID)
+		if err != nil {
+			return nil, err
+		}
+		if entry.CharacterID != characterID {
+			return nil, repository.ErrCraftNotFound
+		}
+		if entry.Status != "queued" && entry.Status != "active" {
+			return nil, repository.ErrCraftFinished
+		}
+
+		recipe, err := cm.craftingRepo.GetRecipeTx(tx, entry.RecipeID)
+		if err != nil {
+			return nil, err
+		}
+		if err := cm.craftingRepo.ReturnMaterialsTx(tx, characterID, recipe.Materials, entry.Quantity-entry.Crafted); err != nil {
+			return nil, err
+		}
+
+		wasActive := entry.Status == "active"
+		entry.Status = "cancelled"
+		entry.NextCraftAt = nil
+		entry.FinishedAt = &now
+		if err := cm.craftingRepo.UpdateQueueEntryTx(tx, entry); err != nil {
+			return nil, err
+		}
+		if wasActive {
+			if err := cm.startNextTx(tx, characterID, now); err != nil {
+				return nil, err
+			}
+		}
+		return entry, nil
+	})
+}
+
+// GetQueue 获取角色的制造队列（先结算已到期的制造，返回本次结算的产出）
+func (cm *CraftingManager) GetQueue(userID, characterID int) ([]*models.CraftingQueueEntry, []*models.CraftResult, error) {
+	if _, err := cm.getOwnedCharacter(userID, characterID); err != nil {
+		return nil, nil, err
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	results, err := cm.processCharacter(characterID, time.Now().UTC())
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := cm.craftingRepo.GetQueue(characterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, results, nil
+}
+
+// ProcessDueCrafts 结算所有到期的制造，返回完成的件数
+func (cm *CraftingManager) ProcessDueCrafts(now time.Time) (int, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	characterIDs, err := cm.craftingRepo.GetDueCharacterIDs(now)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, characterID := range characterIDs {
+		results, err := cm.processCharacter(characterID, now.UTC())
+		count += len(results)
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// StartQueueJob 启动制造队列结算任务（启动时立即执行一次，之后按间隔执行）
+func (cm *CraftingManager) StartQueueJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			count, err := cm.ProcessDueCrafts(time.Now())
+			if err != nil {
+				fmt.Printf("[ERROR] Crafting queue sweep failed: %v\n", err)
+			} else if count > 0 {
+				fmt.Printf("[INFO] Crafting queue sweep completed %d crafts\n", count)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ═══════════════════════════════════════════════════════════
+// 内部辅助
+// ═══════════════════════════════════════════════════════════
+
+// processCharacter 依次完成角色所有到期的制造（调用方持有 cm.mu）
+func (cm *CraftingManager) processCharacter(characterID int, now time.Time) ([]*models.CraftResult, error) {
+	results := make([]*models.CraftResult, 0)
+	for {
+		result, err := cm.completeNextCraft(characterID, now)
+		if err != nil || result == nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+}
+
+// completeNextCraft 完成当前条目中到期的一件制造（没有到期的返回 nil）
+func (cm *CraftingManager) completeNextCraft(characterID int, now time.Time) (*models.CraftResult, error) {
+	entry, err := cm.craftingRepo.GetActiveEntry(characterID)
+	if err != nil || entry == nil || entry.NextCraftAt == nil || entry.NextCraftAt.After(now) {
+		return nil, err
+	}
+	recipe, err := cm.craftingRepo.GetRecipe(entry.RecipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CraftResult{
+		QueueID:   entry.ID,
+		RecipeID:  recipe.ID,
+		ItemID:    recipe.ResultItemID,
+		Quantity:  recipe.ResultQuantity,
+		Quality:   recipe.ResultQuality,
+		Proc:      recipe.ProcChance > 0 && rand.Float64() < recipe.ProcChance,
+		CraftedAt: *entry.NextCraftAt,
+	}
+
+	// 装备通过词缀生成器产出（触发时品质提升一档），其他物品触发时额外产出一个
+	if recipe.ResultType == "equipment" {
+		if result.Proc {
+			result.Quality = nextCraftQuality(result.Quality)
+		}
+		equipment, err := cm.equipmentManager.GenerateEquipment(recipe.ResultItemID, result.Quality, recipe.ResultLevel, entry.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate crafted equipment: %w", err)
+		}
+		result.EquipmentID = &equipment.ID
+		result.Quantity = 1
+	} else if result.Proc {
+		result.Quantity++
+	}
+
+	err = repository.WithTransaction(func(tx *sql.Tx) error {
+		if result.EquipmentID == nil {
+			if err := cm.craftingRepo.AddItemTx(tx, characterID, recipe.ResultItemID, result.Quantity); err != nil {
+				return err
+			}
+		}
+
+		profession, err := cm.craftingRepo.GetCharacterProfessionTx(tx, characterID, recipe.ProfessionID)
+		if err != nil {
+			return err
+		}
+		result.SkillLevel = profession.SkillLevel
+		if profession.SkillLevel < profession.MaxSkill && rand.Float64() < SkillUpChance(recipe, profession.SkillLevel) {
+			result.SkillUp = 1
+			result.SkillLevel++
+			if err := cm.craftingRepo.SetSkillLevelTx(tx, characterID, recipe.ProfessionID, result.SkillLevel); err != nil {
+				return err
+			}
+		}
+
+		// 下一件从本件完成时刻开始计时，离线期间的制造也能依次补算
+		finishedAt := *entry.NextCraftAt
+		entry.Crafted++
+		if entry.Crafted < entry.Quantity {
+			nextCraftAt := finishedAt.Add(time.Duration(recipe.CraftSeconds) * time.Second)
+			entry.NextCraftAt = &nextCraftAt
+			return cm.craftingRepo.UpdateQueueEntryTx(tx, entry)
+		}
+		entry.Status = "completed"
+		entry.NextCraftAt = nil
+		entry.FinishedAt = &finishedAt
+		if err := cm.craftingRepo.UpdateQueueEntryTx(tx, entry); err != nil {
+			return err
+		}
+		return cm.startNextTx(tx, characterID, finishedAt)
+	})
+	if err != nil {
+		if result.EquipmentID != nil {
+			cm.equipmentRepo.Delete(*result.EquipmentID)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// startNextTx 将下一个排队条目设为进行中，从 startAt 开始计时
+func (cm *CraftingManager) startNextTx(tx *sql.Tx, characterID int, startAt time.Time) error {
+	next, err := cm.craftingRepo.GetNextQueuedTx(tx, characterID)
+	if err != nil || next == nil {
+		return err
+	}
+	recipe, err := cm.craftingRepo.GetRecipeTx(tx, next.RecipeID)
+	if err != nil {
+		return err
+	}
+	nextCraftAt := startAt.Add(time.Duration(recipe.CraftSeconds) * time.Second)
+	next.Status = "active"
+	next.NextCraftAt = &nextCraftAt
+	return cm.craftingRepo.UpdateQueueEntryTx(tx, next)
+}
+
+// getOwnedCharacter 获取属于该用户的角色
+func (cm *CraftingManager) getOwnedCharacter(userID, characterID int) (*models.Character, error) {
+	char, err := cm.charRepo.GetByID(characterID)
+	if err != nil || char == nil || char.UserID != userID {
+		return nil, ErrCraftingNoCharacter
+	}
+	return char, nil
+}
+
+// nextCraftQuality 品质提升一档（已是最高档时不变）
+func nextCraftQuality(quality string) string {
+	for i, q := range craftQualityOrder {
+		if q == quality && i+1 < len(craftQualityOrder) {
+			return craftQualityOrder[i+1]
+		}
+	}
+	return quality
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCraftingTest(t *testing.T) (*sql.DB, int, *models.Character) {
+	testDB, _, users := setupTradingTest(t, "crafter")
+
+	_, err := testDB.Exec(`
+		INSERT INTO items (id, name, type, subtype, stackable, max_stack) VALUES
+			('test_ore', '测试矿石', 'material', 'ore', 1, 20),
+			('test_bar', '测试锭', 'material', 'bar', 1, 20);
+		INSERT INTO professions (id, name, type, max_skill) VALUES
+			('test_smithing', '测试锻造', 'crafting', 150),
+			('test_alchemy', '测试炼金', 'crafting', 150),
+			('test_tailoring', '测试裁缝', 'crafting', 150);
+		INSERT INTO recipes (id, profession_id, name, required_skill, skill_up_yellow, skill_up_gray,
+			result_item_id, result_quantity, result_quality, proc_chance, craft_seconds) VALUES
+			('test_smelt', 'test_smithing', '熔炼测试锭', 1, 10, 20, 'test_bar', 1, 'common', 0, 10),
+			('test_forge', 'test_smithing', '锻造测试剑', 1, 10, 20, 'trade_sword', 1, 'common', 0, 30),
+			('test_master', 'test_smithing', '大师配方', 100, 110, 120, 'test_bar', 1, 'common', 0, 10);
+		INSERT INTO recipe_materials (recipe_id, item_id, quantity) VALUES
+			('test_smelt', 'test_ore', 2),
+			('test_forge', 'test_bar', 1),
+			('test_master', 'test_ore', 1);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert crafting config: %v", err)
+	}
+
+	char, err := repository.NewCharacterRepository().Create(&models.Character{
+		UserID: users[0], Name: "craftchar", RaceID: "human", ClassID: "warrior", Faction: "alliance",
+		TeamSlot: 1, IsActive: true, Level: 10, HP: 100, MaxHP: 100, ResourceType: "rage", MaxResource: 100,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	inventory := repository.NewInventoryRepository()
+	for itemID, quantity := range map[string]int{"test_ore": 10, "test_bar": 1} {
+		if err := inventory.AddItem(char.ID, itemID, quantity); err != nil {
+			t.Fatalf("Failed to add %s: %v", itemID, err)
+		}
+	}
+	return testDB, users[0], char
+}
+
+func TestCraftingManager_LearnProfession(t *testing.T) {
+	testDB, userID, char := setupCraftingTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	cm := NewCraftingManager()
+
+	profession, err := cm.LearnProfession(userID, char.ID, "test_smithing")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, profession.SkillLevel)
+
+	_, err = cm.LearnProfession(userID, char.ID, "test_smithing")
+	assert.ErrorIs(t, err, repository.ErrProfessionAlreadyLearned)
+	_, err = cm.LearnProfession(userID, char.ID, "test_alchemy")
+	assert.NoError(t, err)
+	_, err = cm.LearnProfession(userID, char.ID, "test_tailoring")
+	assert.ErrorIs(t, err, repository.ErrProfessionLimit, "同类型专业最多学习两个")
+	_, err = cm.LearnProfession(userID+1, char.ID, "test_tailoring")
+	assert.ErrorIs(t, err, ErrCraftingNoCharacter)
+
+	recipes, err := cm.GetRecipes(userID, char.ID, "test_smithing")
+	assert.NoError(t, err)
+	difficulty := make(map[string]string)
+	for _, recipe := range recipes {
+		difficulty[recipe.ID] = recipe.Difficulty
+	}
+	assert.Equal(t, "orange", difficulty["test_smelt"])
+	assert.Equal(t, "unavailable", difficulty["test_master"])
+
+	_, err = cm.QueueCraft(userID, char.ID, "test_master", 1)
+	assert.ErrorIs(t, err, ErrRecipeSkillTooLow)
+
+	assert.NoError(t, cm.AbandonProfession(userID, char.ID, "test_alchemy"))
+	_, err = cm.LearnProfession(userID, char.ID, "test_tailoring")
+	assert.NoError(t, err, "放弃后可以学习新专业")
+}
+
+func TestCraftingManager_QueueCompletesOverTime(t *testing.T) {
+	testDB, userID, char := setupCraftingTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	cm := NewCraftingManager()
+	_, err := cm.LearnProfession(userID, char.ID, "test_smithing")
+	assert.NoError(t, err)
+
+	_, err = cm.QueueCraft(userID, char.ID, "test_smelt", 6)
+	assert.ErrorIs(t, err, repository.ErrMissingMaterials)
+
+	smelt, err := cm.QueueCraft(userID, char.ID, "test_smelt", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "active", smelt.Status)
+	_, ore := inventoryOf(t, testDB, char.ID, "test_ore")
+	assert.Equal(t, 4, ore, "加入队列时扣除全部材料")
+
+	forge, err := cm.QueueCraft(userID, char.ID, "test_forge", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "queued", forge.Status)
+	_, bars := inventoryOf(t, testDB, char.ID, "test_bar")
+	assert.Equal(t, 0, bars)
+
+	// 熔炼3件共30秒，之后锻造从熔炼完成时刻开始计时
+	count, err := cm.ProcessDueCrafts(time.Now().Add(35 * time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	_, bars = inventoryOf(t, testDB, char.ID, "test_bar")
+	assert.Equal(t, 3, bars)
+
+	profession, err := repository.NewCraftingRepository().GetCharacterProfession(char.ID, "test_smithing")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, profession.SkillLevel, "橙色配方每次制造必定提升技能")
+
+	count, err = cm.ProcessDueCrafts(time.Now().Add(65 * time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	_, bars = inventoryOf(t, testDB, char.ID, "test_bar")
+	assert.Equal(t, 3, bars, "锻造材料在加入队列时已扣除")
+
+	var crafted int
+	err = testDB.QueryRow(`SELECT COUNT(*) FROM equipment_instance WHERE item_id = 'trade_sword' AND owner_id = ?`, userID).Scan(&crafted)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, crafted, "装备通过装备生成器产出")
+
+	entries, _, err := cm.GetQueue(userID, char.ID)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.Equal(t, "completed", entry.Status)
+	}
+}
+
+func TestCraftingManager_CancelRefundsMaterials(t *testing.T) {
+	testDB, userID, char := setupCraftingTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	cm := NewCraftingManager()
+	_, err := cm.LearnProfession(userID, char.ID, "test_smithing")
+	assert.NoError(t, err)
+
+	entry, err := cm.QueueCraft(userID, char.ID, "test_smelt", 4)
+	assert.NoError(t, err)
+	_, ore := inventoryOf(t, testDB, char.ID, "test_ore")
+	assert.Equal(t, 2, ore)
+
+	assert.ErrorIs(t, cm.AbandonProfession(userID, char.ID, "test_smithing"), repository.ErrProfessionInUse)
+
+	cancelled, err := cm.CancelCraft(userID, char.ID, entry.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.Status)
+	_, ore = inventoryOf(t, testDB, char.ID, "test_ore")
+	assert.Equal(t, 10, ore, "未完成的制造返还全部材料")
+
+	_, err = cm.CancelCraft(userID, char.ID, entry.ID)
+	assert.ErrorIs(t, err, repository.ErrCraftFinished)
+}
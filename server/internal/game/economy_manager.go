@@ -1,17 +1,20 @@
 package game
 
 import (
+	"database/sql"
 	"fmt"
 	"sync"
 
+	"text-wow/internal/models"
 	"text-wow/internal/repository"
 )
 
 // EconomyManager 经济管理器 - 管理金币获取与消耗
 type EconomyManager struct {
-	mu       sync.RWMutex
-	userRepo *repository.UserRepository
-	config   *EconomyConfig
+	mu         sync.RWMutex
+	userRepo   *repository.UserRepository
+	ledgerRepo *repository.GoldLedgerRepository
+	config     *EconomyConfig
 }
 
 // EconomyConfig 经济配置
@@ -24,7 +27,8 @@ type EconomyConfig struct {
 // NewEconomyManager 创建经济管理器
 func NewEconomyManager() *EconomyManager {
 	return &EconomyManager{
-		userRepo: repository.NewUserRepository(),
+		userRepo:   repository.NewUserRepository(),
+		ledgerRepo: repository.NewGoldLedgerRepository(),
 		config: &EconomyConfig{
 			GoldMultiplier:           1.0,
 			MaterialPriceMultiplier:  1.0,
@@ -57,33 +61,97 @@ func (em *EconomyManager) CalculateMaterialPrice(basePrice int, rarity string) i
 	return int(float64(basePrice) * rarityMultiplier * em.config.MaterialPriceMultiplier)
 }
 
-// AddGold 增加金币
-func (em *EconomyManager) AddGold(userID int, amount int) error {
-	user, err := em.userRepo.GetByID(userID)
-	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+// CalculateEquipmentSellPrice 计算装备卖给商人的价格
+// 公式: 基础售价 × 品质倍率 × 装备价格倍率
+func (em *EconomyManager) CalculateEquipmentSellPrice(basePrice int, quality string) int {
+	qualityMultiplier := 1.0
+	switch quality {
+	case "uncommon":
+		qualityMultiplier = 1.5
+	case "rare":
+		qualityMultiplier = 2.0
+	case "epic":
+		qualityMultiplier = 3.0
+	case "legendary":
+		qualityMultiplier = 5.0
+	case "mythic":
+		qualityMultiplier = 8.0
 	}
 
-	user.Gold += amount
-	user.TotalGoldGained += amount
+	return int(float64(basePrice) * qualityMultiplier * em.config.EquipmentPriceMultiplier)
+}
 
-	// 使用 UpdateGold 方法更新金币
-	return em.userRepo.UpdateGold(userID, amount)
+// AddGold 增加金币（计入总获得金币），同时写入金币流水
+func (em *EconomyManager) AddGold(userID int, amount int, reason string, ref repository.GoldRef) error {
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		return em.AddGoldTx(tx, userID, amount, reason, ref)
+	})
 }
 
-// SpendGold 消耗金币
-func (em *EconomyManager) SpendGold(userID int, amount int) error {
-	user, err := em.userRepo.GetByID(userID)
-	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+// SpendGold 消耗金币，余额不足时返回 repository.ErrInsufficientGold
+func (em *EconomyManager) SpendGold(userID int, amount int, reason string, ref repository.GoldRef) error {
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		return em.SpendGoldTx(tx, userID, amount, reason, ref)
+	})
+}
+
+// AddGoldTx 在事务中增加新产出的金币（计入总获得金币，与其他写入一起提交或回滚）
+func (em *EconomyManager) AddGoldTx(tx *sql.Tx, userID int, amount int, reason string, ref repository.GoldRef) error {
+	if amount <= 0 {
+		return nil
+	}
+	_, err := em.ledgerRepo.ApplyTx(tx, repository.GoldChange{
+		UserID: userID, Delta: amount, Reason: reason, Ref: ref, CountAsGained: true,
+	})
+	return err
+}
+
+// SpendGoldTx 在事务中消耗金币，余额不足时返回 repository.ErrInsufficientGold
+func (em *EconomyManager) SpendGoldTx(tx *sql.Tx, userID int, amount int, reason string, ref repository.GoldRef) error {
+	if amount <= 0 {
+		return nil
 	}
+	_, err := em.ledgerRepo.ApplyTx(tx, repository.GoldChange{
+		UserID: userID, Delta: -amount, Reason: reason, Ref: ref,
+	})
+	return err
+}
 
-	if user.Gold < amount {
-		return fmt.Errorf("insufficient gold: have %d, need %d", user.Gold, amount)
+// RefundGoldTx 在事务中退还托管的金币（如被超过的拍卖出价，不计入总获得金币）
+func (em *EconomyManager) RefundGoldTx(tx *sql.Tx, userID int, amount int, reason string, ref repository.GoldRef) error {
+	if amount <= 0 {
+		return nil
 	}
+	_, err := em.ledgerRepo.ApplyTx(tx, repository.GoldChange{
+		UserID: userID, Delta: amount, Reason: reason, Ref: ref,
+	})
+	return err
+}
+
+// ReceiveGoldTx 在事务中收入其他玩家或商人转来的金币（交易、邮件、出售物品，不计入总获得金币，避免来回转手刷榜）
+func (em *EconomyManager) ReceiveGoldTx(tx *sql.Tx, userID int, amount int, reason string, ref repository.GoldRef) error {
+	return em.RefundGoldTx(tx, userID, amount, reason, ref)
+}
 
-	// 使用 UpdateGold 方法更新金币（传入负数表示减少）
-	return em.userRepo.UpdateGold(userID, -amount)
+// GetLedger 获取金币流水
+func (em *EconomyManager) GetLedger(userID int, reason string, beforeID, limit int) ([]*models.GoldLedgerEntry, error) {
+	return em.ledgerRepo.GetEntries(userID, reason, beforeID, limit)
+}
+
+// Reconcile 金币对账：返回余额与流水不一致的用户，fix 为 true 时补记修正流水
+func (em *EconomyManager) Reconcile(fix bool) ([]*models.GoldDiscrepancy, error) {
+	discrepancies, err := em.ledgerRepo.FindDiscrepancies()
+	if err != nil {
+		return nil, err
+	}
+	if fix {
+		for _, d := range discrepancies {
+			if _, err := em.ledgerRepo.RecordReconciliation(d); err != nil {
+				return discrepancies, fmt.Errorf("failed to reconcile user %d: %w", d.UserID, err)
+			}
+		}
+	}
+	return discrepancies, nil
 }
 
 // GetGold 获取金币
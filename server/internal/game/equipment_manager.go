@@ -27,6 +27,7 @@ type EquipmentManager struct {
 	mu          sync.RWMutex
 	charRepo    *repository.CharacterRepository
 	equipmentRepo *repository.EquipmentRepository
+	auctionRepo *repository.AuctionRepository
 	gameRepo    *repository.GameRepository
 	affixGenerator *AffixGenerator
 	calculator  *Calculator
@@ -61,6 +62,7 @@ func NewEquipmentManager() *EquipmentManager {
 	return &EquipmentManager{
 		charRepo:      repository.NewCharacterRepository(),
 		equipmentRepo: repository.NewEquipmentRepository(),
+		auctionRepo:   repository.NewAuctionRepository(),
 		gameRepo:      repository.NewGameRepository(),
 		affixGenerator: NewAffixGenerator(),
 		calculator:    NewCalculator(),
@@ -487,6 +489,11 @@ func (em *EquipmentManager) EquipItem(characterID int, equipmentID int) error {
 		return fmt.Errorf("equipment does not belong to user")
 	}
 
+	// 拍卖行托管中的装备不能穿戴
+	if err := em.checkNotEscrowed(equipmentID); err != nil {
+		return err
+	}
+
 	// 验证装备要求（等级、职业等）
 	if err := em.validateEquipmentRequirements(char, equipment); err != nil {
 		return err
@@ -516,6 +523,21 @@ func (em *EquipmentManager) EquipItem(characterID int, equipmentID int) error {
 	return em.updateCharacterAttributes(char)
 }
 
+// checkNotEscrowed 检查装备未被拍卖行托管
+func (em *EquipmentManager) checkNotEscrowed(equipmentID int) error {
+	if em.auctionRepo == nil {
+		return nil
+	}
+	escrowed, err := em.auctionRepo.IsEquipmentEscrowed(equipmentID)
+	if err != nil {
+		return fmt.Errorf("failed to check equipment escrow: %w", err)
+	}
+	if escrowed {
+		return repository.ErrEquipmentInEscrow
+	}
+	return nil
+}
+
 // UnequipItem 卸下装备
 func (em *EquipmentManager) UnequipItem(characterID int, equipmentID int) error {
 	em.mu.Lock()
@@ -810,6 +832,11 @@ func (em *EquipmentManager) EnhanceEquipment(characterID int, equipmentID int, m
 		return fmt.Errorf("equipment does not belong to character")
 	}
 
+	// 拍卖行托管中的装备不能强化
+	if err := em.checkNotEscrowed(equipmentID); err != nil {
+		return err
+	}
+
 	// 处理强化材料
 	for _, material := range materials {
 		switch material.Type {
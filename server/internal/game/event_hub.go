@@ -0,0 +1,274 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 实时事件类型
+const (
+	EventBattleLog = "battle_log"
+	EventLoot      = "loot"
+	EventLevelUp   = "level_up"
+	EventChat      = "chat"
+	EventPresence  = "presence"
+	EventMail      = "mail"
+	EventTrade     = "trade"
+	EventGuild     = "guild"
+	EventPing      = "ping"
+)
+
+const (
+	eventBufferSize       = 64 // 每个连接的待发送缓冲，写满视为慢消费者并断开（客户端重连后通过 REST 重新同步）
+	maxConnectionsPerUser = 5  // 单个用户同时保持的连接数上限，超出时断开最早的连接
+)
+
+// Subscriber 一个实时连接的订阅
+type Subscriber struct {
+	id      uint64
+	UserID  int
+	Name    string
+	Faction string
+	zoneID  string // 由 EventHub.mu 保护
+
+	events    chan *models.RealtimeEvent
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Events 待发送的事件
+func (s *Subscriber) Events() <-chan *models.RealtimeEvent {
+	return s.events
+}
+
+// Done 连接被服务端断开（慢消费者或超出连接数）时关闭
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// Zone 连接当前所在区域（仅在 Broadcast 过滤器中调用，此时已持有读锁）
+func (s *Subscriber) Zone() string {
+	return s.zoneID
+}
+
+func (s *Subscriber) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// EventHub 实时事件中心 - 按用户扇出事件，在线状态由连接状态决定
+type EventHub struct {
+	mu          sync.RWMutex
+	subscribers map[int][]*Subscriber
+	nextID      uint64
+	chatRepo    *repository.ChatRepository
+}
+
+// NewEventHub 创建事件中心
+func NewEventHub() *EventHub {
+	return &EventHub{
+		subscribers: make(map[int][]*Subscriber),
+		chatRepo:    repository.NewChatRepository(),
+	}
+}
+
+// 全局事件中心实例
+var eventHub *EventHub
+var eventHubOnce sync.Once
+
+// GetEventHub 获取事件中心单例
+func GetEventHub() *EventHub {
+	eventHubOnce.Do(func() {
+		eventHub = NewEventHub()
+	})
+	return eventHub
+}
+
+// ═══════════════════════════════════════════════════════════
+// 连接管理
+// ═══════════════════════════════════════════════════════════
+
+// Subscribe 注册一个连接；用户的第一个连接会将其标记为在线并通知同阵营玩家
+func (h *EventHub) Subscribe(userID int, name, faction, zoneID string) *Subscriber {
+	h.mu.Lock()
+	h.nextID++
+	sub := &Subscriber{
+		id:      h.nextID,
+		UserID:  userID,
+		Name:    name,
+		Faction: faction,
+		zoneID:  zoneID,
+		events:  make(chan *models.RealtimeEvent, eventBufferSize),
+		done:    make(chan struct{}),
+	}
+	subs := append(h.subscribers[userID], sub)
+	var evicted *Subscriber
+	if len(subs) > maxConnectionsPerUser {
+		evicted, subs = subs[0], subs[1:]
+	}
+	h.subscribers[userID] = subs
+	first := len(subs) == 1
+	h.mu.Unlock()
+
+	if evicted != nil {
+		evicted.close()
+	}
+	if first {
+		if err := h.chatRepo.SetOnlineStatus(userID, 0, name, faction, zoneID, true); err != nil {
+			fmt.Printf("[WARN] Failed to set user %d online: %v\n", userID, err)
+		}
+		h.broadcastPresence(sub, true)
+	}
+	return sub
+}
+
+// Unsubscribe 注销连接（可重复调用）；用户的最后一个连接断开时标记为离线
+func (h *EventHub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	subs := h.subscribers[sub.UserID]
+	removed := false
+	for i, s := range subs {
+		if s.id == sub.id {
+			subs = append(subs[:i:i], subs[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	last := removed && len(subs) == 0
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.UserID)
+	} else {
+		h.subscribers[sub.UserID] = subs
+	}
+	h.mu.Unlock()
+
+	sub.close()
+	if last {
+		if err := h.chatRepo.MarkOffline(sub.UserID); err != nil {
+			fmt.Printf("[WARN] Failed to set user %d offline: %v\n", sub.UserID, err)
+		}
+		h.broadcastPresence(sub, false)
+	}
+}
+
+// Disconnect 断开用户的所有连接（账号被封禁时），连接处理协程退出时自行注销
+func (h *EventHub) Disconnect(userID int) {
+	h.mu.RLock()
+	subs := append([]*Subscriber(nil), h.subscribers[userID]...)
+	h.mu.RUnlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+// KeepAlive 连接保活时刷新最后活跃时间，避免被不活跃清理标记为离线
+func (h *EventHub) KeepAlive(sub *Subscriber) {
+	if err := h.chatRepo.UpdateLastActive(sub.UserID); err != nil {
+		fmt.Printf("[WARN] Failed to refresh user %d activity: %v\n", sub.UserID, err)
+	}
+}
+
+// SetZone 更新用户所在区域（区域频道按此投递）
+func (h *EventHub) SetZone(userID int, zoneID string) {
+	h.mu.Lock()
+	for _, sub := range h.subscribers[userID] {
+		sub.zoneID = zoneID
+	}
+	h.mu.Unlock()
+}
+
+// IsOnline 用户是否有活跃连接
+func (h *EventHub) IsOnline(userID int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers[userID]) > 0
+}
+
+// ═══════════════════════════════════════════════════════════
+// 事件投递
+// ═══════════════════════════════════════════════════════════
+
+// Publish 推送事件给指定用户的所有连接
+func (h *EventHub) Publish(userID int, eventType string, data interface{}) {
+	event := newRealtimeEvent(eventType, data)
+
+	h.mu.RLock()
+	var slow []*Subscriber
+	for _, sub := range h.subscribers[userID] {
+		if !deliver(sub, event) {
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	h.dropSlow(slow)
+}
+
+// Broadcast 推送事件给满足过滤条件的所有连接（filter 为 nil 时推送给所有人）
+// filter 在持有读锁时调用，不能回调 EventHub
+func (h *EventHub) Broadcast(eventType string, data interface{}, filter func(sub *Subscriber) bool) {
+	event := newRealtimeEvent(eventType, data)
+
+	h.mu.RLock()
+	var slow []*Subscriber
+	for _, subs := range h.subscribers {
+		for _, sub := range subs {
+			if filter != nil && !filter(sub) {
+				continue
+			}
+			if !deliver(sub, event) {
+				slow = append(slow, sub)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	h.dropSlow(slow)
+}
+
+// deliver 非阻塞投递，缓冲已满时返回 false
+func deliver(sub *Subscriber, event *models.RealtimeEvent) bool {
+	select {
+	case sub.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// dropSlow 断开慢消费者；异步执行，发布方（如战斗循环）不会被阻塞
+func (h *EventHub) dropSlow(slow []*Subscriber) {
+	for _, sub := range slow {
+		go h.Unsubscribe(sub)
+	}
+}
+
+// broadcastPresence 通知同阵营的其他玩家上线/下线
+func (h *EventHub) broadcastPresence(sub *Subscriber, online bool) {
+	h.mu.RLock()
+	zoneID := sub.zoneID
+	h.mu.RUnlock()
+
+	presence := &models.PresenceEvent{
+		UserID:  sub.UserID,
+		Name:    sub.Name,
+		Faction: sub.Faction,
+		ZoneID:  zoneID,
+		Online:  online,
+	}
+	h.Broadcast(EventPresence, presence, func(other *Subscriber) bool {
+		return other.UserID != sub.UserID && other.Faction == sub.Faction
+	})
+}
+
+func newRealtimeEvent(eventType string, data interface{}) *models.RealtimeEvent {
+	return &models.RealtimeEvent{
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+}
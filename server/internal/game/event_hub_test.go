@@ -0,0 +1,89 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func receiveEvent(t *testing.T, sub *Subscriber) *models.RealtimeEvent {
+	select {
+	case event := <-sub.Events():
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("no event received for user %d", sub.UserID)
+		return nil
+	}
+}
+
+func TestEventHub_PresenceAndFanOut(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice", "bob", "orc")
+	defer database.TeardownTestDB(testDB)
+
+	hub := NewEventHub()
+	chatRepo := repository.NewChatRepository()
+
+	alice := hub.Subscribe(users[0], "alice", "alliance", "elwynn")
+	orc := hub.Subscribe(users[2], "orc", "horde", "durotar")
+	status, err := chatRepo.GetUserByName("alice")
+	assert.NoError(t, err)
+	assert.True(t, status.IsOnline, "第一个连接建立时标记为在线")
+
+	bob := hub.Subscribe(users[1], "bob", "alliance", "elwynn")
+	presence := receiveEvent(t, alice)
+	assert.Equal(t, EventPresence, presence.Type)
+	assert.Equal(t, users[1], presence.Data.(*models.PresenceEvent).UserID)
+	assert.Empty(t, orc.Events(), "上线通知只发给同阵营玩家")
+
+	// 同一用户的多个连接都能收到事件
+	bobTab := hub.Subscribe(users[1], "bob", "alliance", "elwynn")
+	hub.Publish(users[1], EventMail, &models.MailEvent{Unread: 1})
+	assert.Equal(t, EventMail, receiveEvent(t, bob).Type)
+	assert.Equal(t, EventMail, receiveEvent(t, bobTab).Type)
+	assert.Empty(t, alice.Events())
+
+	hub.Unsubscribe(bobTab)
+	assert.True(t, hub.IsOnline(users[1]), "仍有连接时保持在线")
+	assert.Empty(t, alice.Events())
+
+	hub.Unsubscribe(bob)
+	hub.Unsubscribe(bob)
+	assert.False(t, hub.IsOnline(users[1]))
+	offline := receiveEvent(t, alice)
+	assert.False(t, offline.Data.(*models.PresenceEvent).Online)
+	assert.Empty(t, alice.Events(), "重复注销不会重复通知")
+	status, err = chatRepo.GetUserByName("bob")
+	assert.NoError(t, err)
+	assert.False(t, status.IsOnline, "最后一个连接断开时标记为离线")
+}
+
+func TestEventHub_DropsSlowConsumer(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "slow")
+	defer database.TeardownTestDB(testDB)
+
+	hub := NewEventHub()
+	sub := hub.Subscribe(users[0], "slow", "alliance", "elwynn")
+
+	for i := 0; i < eventBufferSize; i++ {
+		hub.Publish(users[0], EventBattleLog, i)
+	}
+	select {
+	case <-sub.Done():
+		t.Fatal("buffer not yet full, subscriber should stay connected")
+	default:
+	}
+
+	// 缓冲写满后发布方不会阻塞，慢消费者被断开
+	hub.Publish(users[0], EventBattleLog, eventBufferSize)
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("slow subscriber was not dropped")
+	}
+	assert.Eventually(t, func() bool { return !hub.IsOnline(users[0]) }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,193 @@
+package game
+
+import (
+	"math/rand"
+	"sync"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+const (
+	gatheringFullExploration = 200 // 区域探索度达到该值视为完全探索（发现概率翻倍）
+	gatheringSkillPerBonus   = 50  // 技能点每高出节点需求该值，额外产出一个
+)
+
+// GatheringManager 采集管理器 - 按采集专业技能与区域探索度在战斗后和休息时掷骰
+type GatheringManager struct {
+	gatheringRepo   *repository.GatheringRepository
+	craftingRepo    *repository.CraftingRepository
+	inventoryRepo   *repository.InventoryRepository
+	explorationRepo *repository.ExplorationRepository
+	charRepo        *repository.CharacterRepository
+}
+
+// NewGatheringManager 创建采集管理器
+func NewGatheringManager() *GatheringManager {
+	return &GatheringManager{
+		gatheringRepo:   repository.NewGatheringRepository(),
+		craftingRepo:    repository.NewCraftingRepository(),
+		inventoryRepo:   repository.NewInventoryRepository(),
+		explorationRepo: repository.NewExplorationRepository(),
+		charRepo:        repository.NewCharacterRepository(),
+	}
+}
+
+// 全局采集管理器实例
+var gatheringManager *GatheringManager
+var gatheringOnce sync.Once
+
+// GetGatheringManager 获取采集管理器单例
+func GetGatheringManager() *GatheringManager {
+	gatheringOnce.Do(func() {
+		gatheringManager = NewGatheringManager()
+	})
+	return gatheringManager
+}
+
+// GetZoneNodes 获取区域采集表（指定角色时计算其实际发现概率）
+func (gm *GatheringManager) GetZoneNodes(userID, characterID int, zoneID string) ([]*models.GatheringNode, error) {
+	nodes, err := gm.gatheringRepo.GetZoneNodes(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	exploration, err := gm.explorationRepo.GetExploration(userID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	professions := map[string]*models.CharacterProfession{}
+	if characterID != 0 {
+		char, err := gm.charRepo.GetByID(characterID)
+		if err != nil || char == nil || char.UserID != userID {
+			return nil, ErrCraftingNoCharacter
+		}
+		if professions, err = gm.gatheringProfessions(characterID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, node := range nodes {
+		node.Discovered = exploration.Exploration >= node.RequiredExploration
+		if p, ok := professions[node.ProfessionID]; ok && node.Discovered && p.SkillLevel >= node.RequiredSkill {
+			node.EffectiveChance = GatherChance(node, exploration.Exploration)
+		}
+	}
+	return nodes, nil
+}
+
+// Gather 为角色的每个采集专业在区域中掷骰一次，产出放入背包
+// afterKill 为 false（休息时）不能剥皮
+func (gm *GatheringManager) Gather(char *models.Character, zoneID string, afterKill bool) ([]*models.GatherResult, error) {
+	professions, err := gm.gatheringProfessions(char.ID)
+	if err != nil || len(professions) == 0 {
+		return nil, err
+	}
+	nodes, err := gm.gatheringRepo.GetZoneNodes(zoneID)
+	if err != nil || len(nodes) == 0 {
+		return nil, err
+	}
+	exploration, err := gm.explorationRepo.GetExploration(char.UserID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 随机顺序尝试，每个专业每次最多采集一个节点
+	results := make([]*models.GatherResult, 0)
+	gathered := make(map[string]bool)
+	for _, i := range rand.Perm(len(nodes)) {
+		node := nodes[i]
+		profession, ok := professions[node.ProfessionID]
+		if !ok || gathered[node.ProfessionID] || profession.SkillLevel < node.RequiredSkill {
+			continue
+		}
+		if exploration.Exploration < node.RequiredExploration || (node.NodeType == "leather" && !afterKill) {
+			continue
+		}
+		if rand.Float64() >= GatherChance(node, exploration.Exploration) {
+			continue
+		}
+		gathered[node.ProfessionID] = true
+
+		result, err := gm.gatherNode(char, node, profession, exploration.Exploration)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// GatherChance 节点发现概率：随区域探索度线性提升，完全探索时翻倍
+func GatherChance(node *models.GatheringNode, exploration int) float64 {
+	chance := node.Chance * (1 + explorationPercent(exploration))
+	if chance > 1 {
+		chance = 1
+	}
+	return chance
+}
+
+// gatherNode 采集一个节点：计算产量、放入背包并尝试提升技能
+func (gm *GatheringManager) gatherNode(char *models.Character, node *models.GatheringNode, profession *models.CharacterProfession, exploration int) (*models.GatherResult, error) {
+	skill := profession.SkillLevel
+	quantity := node.MinQuantity
+	if node.MaxQuantity > node.MinQuantity {
+		quantity += rand.Intn(node.MaxQuantity - node.MinQuantity + 1)
+	}
+	quantity += (skill - node.RequiredSkill) / gatheringSkillPerBonus
+	if rand.Float64() < explorationPercent(exploration)/2 {
+		quantity++
+	}
+
+	if err := gm.inventoryRepo.AddItem(char.ID, node.ItemID, quantity); err != nil {
+		return nil, err
+	}
+
+	result := &models.GatherResult{
+		CharacterID:  char.ID,
+		NodeID:       node.ID,
+		NodeName:     node.Name,
+		NodeType:     node.NodeType,
+		ItemID:       node.ItemID,
+		ItemName:     node.ItemName,
+		Quantity:     quantity,
+		ProfessionID: node.ProfessionID,
+		SkillLevel:   skill,
+	}
+
+	if skill < profession.MaxSkill && rand.Float64() < skillUpChance(skill, node.SkillUpYellow, node.SkillUpGray) {
+		result.SkillUp = 1
+		result.SkillLevel++
+		if err := gm.craftingRepo.SetSkillLevel(char.ID, node.ProfessionID, result.SkillLevel); err != nil {
+			return nil, err
+		}
+		profession.SkillLevel = result.SkillLevel
+	}
+	return result, nil
+}
+
+// gatheringProfessions 获取角色已学习的采集专业（按专业ID索引）
+func (gm *GatheringManager) gatheringProfessions(characterID int) (map[string]*models.CharacterProfession, error) {
+	professions, err := gm.craftingRepo.GetCharacterProfessions(characterID)
+	if err != nil {
+		return nil, err
+	}
+	gathering := make(map[string]*models.CharacterProfession)
+	for _, p := range professions {
+		if p.Type == "gathering" {
+			gathering[p.ProfessionID] = p
+		}
+	}
+	return gathering, nil
+}
+
+// explorationPercent 区域探索进度（0-1）
+func explorationPercent(exploration int) float64 {
+	if exploration >= gatheringFullExploration {
+		return 1
+	}
+	if exploration <= 0 {
+		return 0
+	}
+	return float64(exploration) / gatheringFullExploration
+}
@@ -0,0 +1,82 @@
+package game
+
+import (
+	"testing"
+
+	"text-wow/internal/database"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatheringManager_Gather(t *testing.T) {
+	testDB, userID, char := setupCraftingTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	_, err := testDB.Exec(`
+		INSERT INTO items (id, name, type, subtype, stackable, max_stack) VALUES
+			('test_herb', '测试草药', 'material', 'herb', 1, 99),
+			('test_rare_herb', '稀有草药', 'material', 'herb', 1, 99),
+			('test_hide', '测试皮革', 'material', 'leather', 1, 99);
+		INSERT INTO zones (id, name) VALUES ('test_zone', '测试区域');
+		INSERT INTO professions (id, name, type, max_skill) VALUES
+			('test_herbalism', '测试草药学', 'gathering', 150),
+			('test_skinning', '测试剥皮', 'gathering', 150);
+		INSERT INTO zone_gathering_nodes (id, zone_id, profession_id, node_type, name, item_id,
+			required_skill, skill_up_yellow, skill_up_gray, chance, min_quantity, max_quantity, required_exploration) VALUES
+			('test_herb_node', 'test_zone', 'test_herbalism', 'herb', '草药丛', 'test_herb', 1, 10, 20, 1, 2, 2, 0),
+			('test_rare_node', 'test_zone', 'test_herbalism', 'herb', '稀有草药丛', 'test_rare_herb', 1, 10, 20, 1, 2, 2, 500),
+			('test_hide_node', 'test_zone', 'test_skinning', 'leather', '野兽毛皮', 'test_hide', 1, 10, 20, 1, 1, 1, 0);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert gathering config: %v", err)
+	}
+
+	gm := NewGatheringManager()
+
+	results, err := gm.Gather(char, "test_zone", true)
+	assert.NoError(t, err)
+	assert.Empty(t, results, "没有采集专业时不采集")
+
+	crafting := NewCraftingManager()
+	for _, professionID := range []string{"test_herbalism", "test_skinning"} {
+		_, err := crafting.LearnProfession(userID, char.ID, professionID)
+		assert.NoError(t, err)
+	}
+
+	results, err = gm.Gather(char, "test_zone", false)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1, "休息时不能剥皮") {
+		assert.Equal(t, "test_herb_node", results[0].NodeID, "探索度不足的节点不会出现")
+		assert.Equal(t, 2, results[0].Quantity)
+		assert.Equal(t, 1, results[0].SkillUp)
+	}
+	_, herbs := inventoryOf(t, testDB, char.ID, "test_herb")
+	assert.Equal(t, 2, herbs)
+
+	results, err = gm.Gather(char, "test_zone", true)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2, "每个专业各采集一次")
+	_, hides := inventoryOf(t, testDB, char.ID, "test_hide")
+	assert.Equal(t, 1, hides)
+
+	profession, err := repository.NewCraftingRepository().GetCharacterProfession(char.ID, "test_herbalism")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, profession.SkillLevel)
+
+	nodes, err := gm.GetZoneNodes(userID, char.ID, "test_zone")
+	assert.NoError(t, err)
+	discovered := make(map[string]bool)
+	for _, node := range nodes {
+		discovered[node.ID] = node.Discovered
+	}
+	assert.True(t, discovered["test_herb_node"])
+	assert.False(t, discovered["test_rare_node"])
+
+	assert.NoError(t, repository.NewExplorationRepository().AddExploration(userID, "test_zone", 500))
+	nodes, err = gm.GetZoneNodes(userID, char.ID, "test_zone")
+	assert.NoError(t, err)
+	for _, node := range nodes {
+		assert.True(t, node.Discovered, "探索度达到后节点出现")
+	}
+}
@@ -0,0 +1,912 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 公会错误
+var (
+	ErrNotInGuild             = errors.New("you are not in a guild")
+	ErrAlreadyInGuild         = errors.New("already in a guild")
+	ErrGuildNameTaken         = errors.New("guild name is already taken")
+	ErrInvalidGuildName       = errors.New("guild name must be 2-24 letters, digits or spaces")
+	ErrGuildNoCharacter       = errors.New("create a character before joining a guild")
+	ErrGuildPermissionDenied  = errors.New("your guild rank does not allow this")
+	ErrGuildPlayerNotFound    = errors.New("player not found")
+	ErrGuildTargetSelf        = errors.New("cannot target yourself")
+	ErrGuildEnemyFaction      = errors.New("cannot invite players of the enemy faction")
+	ErrGuildTargetInGuild     = errors.New("player is already in a guild")
+	ErrGuildFull              = errors.New("guild is full")
+	ErrGuildInviteNotFound    = errors.New("guild invite not found or expired")
+	ErrGuildTargetNotMember   = errors.New("player is not a member of your guild")
+	ErrGuildRankTooHigh       = errors.New("target rank must be lower than your own")
+	ErrGuildInvalidRank       = errors.New("invalid guild rank")
+	ErrGuildLeaderCannotLeave = errors.New("guild leader must transfer leadership or disband the guild")
+	ErrGuildMOTDTooLong       = errors.New("message of the day must be at most 200 characters")
+	ErrInvalidGuildGold       = errors.New("invalid gold amount")
+	ErrGuildWithdrawLimit     = errors.New("daily guild bank withdraw limit reached")
+)
+
+const (
+	guildCreateCost      = 100            // 创建公会费用
+	guildMaxMembers      = 100            // 公会人数上限
+	guildInviteExpiry    = 48 * time.Hour // 邀请有效期
+	guildWithdrawWindow  = 24 * time.Hour // 提取额度统计窗口
+	guildLeaderRank      = 0              // 会长会阶
+	maxGuildNameLength   = 24
+	minGuildNameLength   = 2
+	maxGuildMOTDLength   = 200
+	maxGuildRankName     = 16
+	guildLogDefaultLimit = 50
+)
+
+// guildPermissions 全部可分配的公会权限
+var guildPermissions = []string{
+	models.GuildPermInvite,
+	models.GuildPermKick,
+	models.GuildPermPromote,
+	models.GuildPermEditMOTD,
+	models.GuildPermWithdrawGold,
+	models.GuildPermWithdrawItems,
+}
+
+// DefaultGuildRanks 新建公会的默认会阶
+func DefaultGuildRanks() []*models.GuildRank {
+	return []*models.GuildRank{
+		{Index: 0, Name: "会长", Permissions: append([]string(nil), guildPermissions...), GoldWithdrawLimit: -1, ItemWithdrawLimit: -1},
+		{Index: 1, Name: "官员", Permissions: append([]string(nil), guildPermissions...), GoldWithdrawLimit: 1000, ItemWithdrawLimit: 5},
+		{Index: 2, Name: "成员", Permissions: []string{models.GuildPermWithdrawItems}, GoldWithdrawLimit: 0, ItemWithdrawLimit: 1},
+		{Index: 3, Name: "新人", Permissions: []string{}, GoldWithdrawLimit: 0, ItemWithdrawLimit: 0},
+	}
+}
+
+// GuildManager 公会管理器 - 成员、会阶、公告与公会银行
+type GuildManager struct {
+	mu            sync.Mutex
+	guildRepo     *repository.GuildRepository
+	userRepo      *repository.UserRepository
+	charRepo      *repository.CharacterRepository
+	equipmentRepo *repository.EquipmentRepository
+	economyMgr    *EconomyManager
+	eventHub      *EventHub
+}
+
+// NewGuildManager 创建公会管理器
+func NewGuildManager() *GuildManager {
+	return &GuildManager{
+		guildRepo:     repository.NewGuildRepository(),
+		userRepo:      repository.NewUserRepository(),
+		charRepo:      repository.NewCharacterRepository(),
+		equipmentRepo: repository.NewEquipmentRepository(),
+		economyMgr:    NewEconomyManager(),
+		eventHub:      GetEventHub(),
+	}
+}
+
+// 全局公会管理器实例
+var guildManager *GuildManager
+var guildOnce sync.Once
+
+// GetGuildManager 获取公会管理器单例
+func GetGuildManager() *GuildManager {
+	guildOnce.Do(func() {
+		guildManager = NewGuildManager()
+	})
+	return guildManager
+}
+
+// ═══════════════════════════════════════════════════════════
+// 创建与解散
+// ═══════════════════════════════════════════════════════════
+
+// CreateGuild 创建公会（阵营取自玩家的第一个角色，创建者成为会长）
+func (gm *GuildManager) CreateGuild(userID int, name string) (*models.Guild, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if !isValidGuildName(name) {
+		return nil, ErrInvalidGuildName
+	}
+	faction, err := gm.playerFaction(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	exists, err := gm.guildRepo.NameExists(name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrGuildNameTaken
+	}
+
+	now := time.Now()
+	guild, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.Guild, error) {
+		membership, err := gm.guildRepo.GetMembershipTx(tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if membership != nil {
+			return nil, ErrAlreadyInGuild
+		}
+		guild, err := gm.guildRepo.CreateGuildTx(tx, &models.Guild{
+			Name: name, Faction: faction, LeaderID: userID, CreatedAt: now,
+		}, DefaultGuildRanks())
+		if err != nil {
+			return nil, err
+		}
+		if err := gm.economyMgr.SpendGoldTx(tx, userID, guildCreateCost,
+			repository.GoldReasonGuildCreate, repository.NewGoldRef("guild", guild.ID)); err != nil {
+			return nil, err
+		}
+		if err := gm.guildRepo.AddMemberTx(tx, guild.ID, userID, guildLeaderRank, now); err != nil {
+			return nil, err
+		}
+		if err := gm.guildRepo.DeleteUserInvitesTx(tx, userID); err != nil {
+			return nil, err
+		}
+		if err := gm.addLog(tx, guild.ID, userID, repository.GuildLogCreate, name, 0, now); err != nil {
+			return nil, err
+		}
+		return gm.guildRepo.GetGuildTx(tx, guild.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return guild, nil
+}
+
+// DisbandGuild 解散公会（仅会长，银行中的金币和装备归还会长）
+func (gm *GuildManager) DisbandGuild(userID int) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	var guild *models.Guild
+	var memberIDs []int
+	err := repository.WithTransaction(func(tx *sql.Tx) error {
+		member, err := gm.requireMembershipTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		if member.RankIndex != guildLeaderRank {
+			return ErrGuildPermissionDenied
+		}
+		if guild, err = gm.guildRepo.GetGuildTx(tx, member.GuildID); err != nil {
+			return err
+		}
+		if memberIDs, err = gm.guildRepo.GetMemberIDsTx(tx, guild.ID); err != nil {
+			return err
+		}
+
+		if err := gm.economyMgr.RefundGoldTx(tx, userID, guild.BankGold,
+			repository.GoldReasonGuildWithdraw, repository.NewGoldRef("guild", guild.ID)); err != nil {
+			return err
+		}
+		equipmentIDs, err := gm.guildRepo.GetBankItemIDsTx(tx, guild.ID)
+		if err != nil {
+			return err
+		}
+		for _, equipmentID := range equipmentIDs {
+			if err := gm.equipmentRepo.TransferTx(tx, equipmentID, userID); err != nil {
+				return err
+			}
+		}
+		return gm.guildRepo.DeleteGuildTx(tx, guild.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, memberID := range memberIDs {
+		if memberID != userID {
+			gm.notify(memberID, guild, "disband", fmt.Sprintf("公会 <%s> 已被解散", guild.Name))
+		}
+	}
+	return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// 邀请
+// ═══════════════════════════════════════════════════════════
+
+// Invite 邀请同阵营玩家加入公会
+func (gm *GuildManager) Invite(userID int, playerName string) (*models.GuildInvite, error) {
+	target, err := gm.userRepo.GetByUsername(playerName)
+	if err != nil || target == nil {
+		return nil, ErrGuildPlayerNotFound
+	}
+	if target.ID == userID {
+		return nil, ErrGuildTargetSelf
+	}
+	targetFaction, err := gm.playerFaction(target.ID)
+	if errors.Is(err, ErrGuildNoCharacter) {
+		return nil, ErrGuildPlayerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	now := time.Now()
+	var guild *models.Guild
+	invite, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.GuildInvite, error) {
+		member, _, err := gm.requirePermissionTx(tx, userID, models.GuildPermInvite)
+		if err != nil {
+			return nil, err
+		}
+		if guild, err = gm.guildRepo.GetGuildTx(tx, member.GuildID); err != nil {
+			return nil, err
+		}
+		if guild.Faction != targetFaction {
+			return nil, ErrGuildEnemyFaction
+		}
+		if guild.MemberCount >= guildMaxMembers {
+			return nil, ErrGuildFull
+		}
+		targetMembership, err := gm.guildRepo.GetMembershipTx(tx, target.ID)
+		if err != nil {
+			return nil, err
+		}
+		if targetMembership != nil {
+			return nil, ErrGuildTargetInGuild
+		}
+
+		invite := &models.GuildInvite{
+			GuildID:     guild.ID,
+			GuildName:   guild.Name,
+			UserID:      target.ID,
+			InvitedBy:   userID,
+			InviterName: member.Name,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(guildInviteExpiry),
+		}
+		if err := gm.guildRepo.SaveInviteTx(tx, invite); err != nil {
+			return nil, err
+		}
+		if err := gm.addLog(tx, guild.ID, userID, repository.GuildLogInvite, target.Username, 0, now); err != nil {
+			return nil, err
+		}
+		return invite, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gm.notify(target.ID, guild, "invite", fmt.Sprintf("%s 邀请你加入公会 <%s>", invite.InviterName, guild.Name))
+	return invite, nil
+}
+
+// GetInvites 获取收到的公会邀请
+func (gm *GuildManager) GetInvites(userID int) ([]*models.GuildInvite, error) {
+	return gm.guildRepo.GetInvites(userID, time.Now())
+}
+
+// AcceptInvite 接受邀请，以最低会阶加入公会（其余邀请全部失效）
+func (gm *GuildManager) AcceptInvite(userID, guildID int) (*models.Guild, error) {
+	faction, err := gm.playerFaction(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	now := time.Now()
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.Guild, error) {
+		membership, err := gm.guildRepo.GetMembershipTx(tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if membership != nil {
+			return nil, ErrAlreadyInGuild
+		}
+		invited, err := gm.guildRepo.HasInviteTx(tx, guildID, userID, now)
+		if err != nil {
+			return nil, err
+		}
+		if !invited {
+			return nil, ErrGuildInviteNotFound
+		}
+		guild, err := gm.guildRepo.GetGuildTx(tx, guildID)
+		if err != nil {
+			return nil, err
+		}
+		if guild.Faction != faction {
+			return nil, ErrGuildEnemyFaction
+		}
+		if guild.MemberCount >= guildMaxMembers {
+			return nil, ErrGuildFull
+		}
+
+		lowestRank, err := gm.guildRepo.GetLowestRankTx(tx, guildID)
+		if err != nil {
+			return nil, err
+		}
+		if err := gm.guildRepo.AddMemberTx(tx, guildID, userID, lowestRank, now); err != nil {
+			return nil, err
+		}
+		if err := gm.guildRepo.DeleteUserInvitesTx(tx, userID); err != nil {
+			return nil, err
+		}
+		if err := gm.addLog(tx, guildID, userID, repository.GuildLogJoin, "", 0, now); err != nil {
+			return nil, err
+		}
+		return gm.guildRepo.GetGuildTx(tx, guildID)
+	})
+}
+
+// DeclineInvite 拒绝邀请
+func (gm *GuildManager) DeclineInvite(userID, guildID int) error {
+	deleted, err := gm.guildRepo.DeleteInvite(guildID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrGuildInviteNotFound
+	}
+	return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// 成员管理
+// ═══════════════════════════════════════════════════════════
+
+// Leave 退出公会（会长需先转让会长或解散公会）
+func (gm *GuildManager) Leave(userID int) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		member, err := gm.requireMembershipTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		if member.RankIndex == guildLeaderRank {
+			return ErrGuildLeaderCannotLeave
+		}
+		if err := gm.guildRepo.RemoveMemberTx(tx, userID); err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogLeave, "", 0, time.Now())
+	})
+}
+
+// Kick 踢出会阶低于自己的成员
+func (gm *GuildManager) Kick(userID int, playerName string) error {
+	targetID, err := gm.findPlayer(playerName)
+	if err != nil {
+		return err
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	var guild *models.Guild
+	var target *models.GuildMember
+	err = repository.WithTransaction(func(tx *sql.Tx) error {
+		member, _, err := gm.requirePermissionTx(tx, userID, models.GuildPermKick)
+		if err != nil {
+			return err
+		}
+		if target, err = gm.findMemberTx(tx, member, targetID); err != nil {
+			return err
+		}
+		if target.RankIndex <= member.RankIndex {
+			return ErrGuildRankTooHigh
+		}
+		if guild, err = gm.guildRepo.GetGuildTx(tx, member.GuildID); err != nil {
+			return err
+		}
+		if err := gm.guildRepo.RemoveMemberTx(tx, target.UserID); err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogKick, target.Name, 0, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+
+	gm.notify(target.UserID, guild, "kick", fmt.Sprintf("你已被移出公会 <%s>", guild.Name))
+	return nil
+}
+
+// SetMemberRank 调整成员会阶（目标当前会阶和新会阶都必须低于自己）
+func (gm *GuildManager) SetMemberRank(userID int, playerName string, rankIndex int) error {
+	targetID, err := gm.findPlayer(playerName)
+	if err != nil {
+		return err
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		member, _, err := gm.requirePermissionTx(tx, userID, models.GuildPermPromote)
+		if err != nil {
+			return err
+		}
+		target, err := gm.findMemberTx(tx, member, targetID)
+		if err != nil {
+			return err
+		}
+		if target.RankIndex <= member.RankIndex || rankIndex <= member.RankIndex {
+			return ErrGuildRankTooHigh
+		}
+		rank, err := gm.guildRepo.GetRankTx(tx, member.GuildID, rankIndex)
+		if err == sql.ErrNoRows {
+			return ErrGuildInvalidRank
+		}
+		if err != nil {
+			return err
+		}
+		if err := gm.guildRepo.SetMemberRankTx(tx, target.UserID, rankIndex); err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogRankChange,
+			fmt.Sprintf("%s → %s", target.Name, rank.Name), 0, time.Now())
+	})
+}
+
+// TransferLeadership 转让会长（原会长降为第二会阶）
+func (gm *GuildManager) TransferLeadership(userID int, playerName string) error {
+	targetID, err := gm.findPlayer(playerName)
+	if err != nil {
+		return err
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		member, err := gm.requireMembershipTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		if member.RankIndex != guildLeaderRank {
+			return ErrGuildPermissionDenied
+		}
+		target, err := gm.findMemberTx(tx, member, targetID)
+		if err != nil {
+			return err
+		}
+		if err := gm.guildRepo.SetMemberRankTx(tx, target.UserID, guildLeaderRank); err != nil {
+			return err
+		}
+		if err := gm.guildRepo.SetMemberRankTx(tx, userID, guildLeaderRank+1); err != nil {
+			return err
+		}
+		if err := gm.guildRepo.SetLeaderTx(tx, member.GuildID, target.UserID); err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogLeaderChange, target.Name, 0, time.Now())
+	})
+}
+
+// ═══════════════════════════════════════════════════════════
+// 会阶与公告
+// ═══════════════════════════════════════════════════════════
+
+// UpdateRank 修改会阶名称、权限和提取额度（仅会长，会长会阶不可修改）
+func (gm *GuildManager) UpdateRank(userID int, rank *models.GuildRank) error {
+	rank.Name = strings.TrimSpace(rank.Name)
+	if rank.Index <= guildLeaderRank || rank.Name == "" || utf8.RuneCountInString(rank.Name) > maxGuildRankName ||
+		rank.GoldWithdrawLimit < -1 || rank.ItemWithdrawLimit < -1 {
+		return ErrGuildInvalidRank
+	}
+	permissions, err := normalizeGuildPermissions(rank.Permissions)
+	if err != nil {
+		return err
+	}
+	rank.Permissions = permissions
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		member, err := gm.requireMembershipTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		if member.RankIndex != guildLeaderRank {
+			return ErrGuildPermissionDenied
+		}
+		if _, err := gm.guildRepo.GetRankTx(tx, member.GuildID, rank.Index); err == sql.ErrNoRows {
+			return ErrGuildInvalidRank
+		} else if err != nil {
+			return err
+		}
+		if err := gm.guildRepo.SaveRankTx(tx, member.GuildID, rank); err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogRankEdit, rank.Name, 0, time.Now())
+	})
+}
+
+// SetMOTD 修改每日公告
+func (gm *GuildManager) SetMOTD(userID int, motd string) error {
+	motd = strings.TrimSpace(motd)
+	if utf8.RuneCountInString(motd) > maxGuildMOTDLength {
+		return ErrGuildMOTDTooLong
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		member, _, err := gm.requirePermissionTx(tx, userID, models.GuildPermEditMOTD)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := gm.guildRepo.SetMOTDTx(tx, member.GuildID, motd, now); err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogMOTD, "", 0, now)
+	})
+}
+
+// ═══════════════════════════════════════════════════════════
+// 公会银行
+// ═══════════════════════════════════════════════════════════
+
+// DepositGold 存入金币（所有成员均可存入）
+func (gm *GuildManager) DepositGold(userID, amount int) error {
+	if amount <= 0 {
+		return ErrInvalidGuildGold
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		member, err := gm.requireMembershipTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := gm.economyMgr.SpendGoldTx(tx, userID, amount,
+			repository.GoldReasonGuildDeposit, repository.NewGoldRef("guild", member.GuildID)); err != nil {
+			return err
+		}
+		if err := gm.guildRepo.AddBankGoldTx(tx, member.GuildID, amount); err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogDepositGold, "", amount, time.Now())
+	})
+}
+
+// WithdrawGold 提取金币（受会阶权限和24小时额度限制，不计入总获得金币）
+func (gm *GuildManager) WithdrawGold(userID, amount int) error {
+	if amount <= 0 {
+		return ErrInvalidGuildGold
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		member, rank, err := gm.requirePermissionTx(tx, userID, models.GuildPermWithdrawGold)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := gm.checkWithdrawLimitTx(tx, member, repository.GuildLogWithdrawGold, rank.GoldWithdrawLimit, amount, now); err != nil {
+			return err
+		}
+		if err := gm.guildRepo.TakeBankGoldTx(tx, member.GuildID, amount); err != nil {
+			return err
+		}
+		if err := gm.economyMgr.RefundGoldTx(tx, userID, amount,
+			repository.GoldReasonGuildWithdraw, repository.NewGoldRef("guild", member.GuildID)); err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogWithdrawGold, "", amount, now)
+	})
+}
+
+// DepositItem 存入装备（装备需可交易，存放期间处于托管状态）
+func (gm *GuildManager) DepositItem(userID, equipmentID int) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		member, err := gm.requireMembershipTx(tx, userID)
+		if err != nil {
+			return err
+		}
+		if _, err := gm.equipmentRepo.CheckTradableTx(tx, userID, equipmentID); err != nil {
+			return err
+		}
+		now := time.Now()
+		name, err := gm.guildRepo.StoreItemTx(tx, member.GuildID, equipmentID, userID, now)
+		if err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogDepositItem, name, 1, now)
+	})
+}
+
+// WithdrawItem 提取装备（受会阶权限和24小时件数限制）
+func (gm *GuildManager) WithdrawItem(userID, equipmentID int) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		member, rank, err := gm.requirePermissionTx(tx, userID, models.GuildPermWithdrawItems)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := gm.checkWithdrawLimitTx(tx, member, repository.GuildLogWithdrawItem, rank.ItemWithdrawLimit, 1, now); err != nil {
+			return err
+		}
+		name, err := gm.guildRepo.TakeItemTx(tx, member.GuildID, equipmentID)
+		if err != nil {
+			return err
+		}
+		if err := gm.equipmentRepo.TransferTx(tx, equipmentID, userID); err != nil {
+			return err
+		}
+		return gm.addLog(tx, member.GuildID, userID, repository.GuildLogWithdrawItem, name, 1, now)
+	})
+}
+
+// ═══════════════════════════════════════════════════════════
+// 查询
+// ═══════════════════════════════════════════════════════════
+
+// GetGuild 获取自己所在公会的详情（成员在线状态取自实时连接）
+func (gm *GuildManager) GetGuild(userID int) (*models.GuildInfo, error) {
+	member, err := gm.requireMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	guild, err := gm.guildRepo.GetGuild(member.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	ranks, err := gm.guildRepo.GetRanks(member.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := gm.guildRepo.GetMembers(member.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		m.Online = gm.eventHub.IsOnline(m.UserID)
+	}
+	return &models.GuildInfo{
+		Guild:   guild,
+		Ranks:   ranks,
+		Members: members,
+		MyRank:  member.RankIndex,
+	}, nil
+}
+
+// GetBank 获取公会银行内容及本人剩余提取额度
+func (gm *GuildManager) GetBank(userID int) (*models.GuildBank, error) {
+	member, err := gm.requireMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	guild, err := gm.guildRepo.GetGuild(member.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := gm.guildRepo.GetBankItems(member.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	bank := &models.GuildBank{Gold: guild.BankGold, Items: items}
+
+	ranks, err := gm.guildRepo.GetRanks(member.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rank := range ranks {
+		if rank.Index != member.RankIndex {
+			continue
+		}
+		if rank.HasPermission(models.GuildPermWithdrawGold) {
+			bank.GoldWithdrawLimit = rank.GoldWithdrawLimit
+		}
+		if rank.HasPermission(models.GuildPermWithdrawItems) {
+			bank.ItemWithdrawLimit = rank.ItemWithdrawLimit
+		}
+	}
+
+	since := time.Now().Add(-guildWithdrawWindow)
+	if bank.GoldWithdrawnToday, err = gm.guildRepo.SumWithdrawals(member.GuildID, userID, repository.GuildLogWithdrawGold, since); err != nil {
+		return nil, err
+	}
+	if bank.ItemsWithdrawnToday, err = gm.guildRepo.SumWithdrawals(member.GuildID, userID, repository.GuildLogWithdrawItem, since); err != nil {
+		return nil, err
+	}
+	return bank, nil
+}
+
+// GetLog 获取公会活动日志（beforeID 用于翻页）
+func (gm *GuildManager) GetLog(userID, beforeID, limit int) ([]*models.GuildLogEntry, error) {
+	member, err := gm.requireMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > guildLogDefaultLimit {
+		limit = guildLogDefaultLimit
+	}
+	return gm.guildRepo.GetLogs(member.GuildID, beforeID, limit)
+}
+
+// GetMembership 获取玩家的公会成员信息（未加入公会时返回 nil），供公会频道使用
+func (gm *GuildManager) GetMembership(userID int) (*models.GuildMember, error) {
+	return gm.guildRepo.GetMembership(userID)
+}
+
+// GetMemberIDs 获取公会全部成员ID，供公会频道推送使用
+func (gm *GuildManager) GetMemberIDs(guildID int) ([]int, error) {
+	return gm.guildRepo.GetMemberIDs(guildID)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 辅助函数
+// ═══════════════════════════════════════════════════════════
+
+// playerFaction 获取玩家阵营（由第一个角色决定）
+func (gm *GuildManager) playerFaction(userID int) (string, error) {
+	chars, err := gm.charRepo.GetByUserID(userID)
+	if err != nil {
+		return "", err
+	}
+	if len(chars) == 0 {
+		return "", ErrGuildNoCharacter
+	}
+	return chars[0].Faction, nil
+}
+
+// requireMembership 获取成员信息，未加入公会时返回 ErrNotInGuild
+func (gm *GuildManager) requireMembership(userID int) (*models.GuildMember, error) {
+	member, err := gm.guildRepo.GetMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrNotInGuild
+	}
+	return member, nil
+}
+
+// requireMembershipTx 在事务中获取成员信息，未加入公会时返回 ErrNotInGuild
+func (gm *GuildManager) requireMembershipTx(tx *sql.Tx, userID int) (*models.GuildMember, error) {
+	member, err := gm.guildRepo.GetMembershipTx(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrNotInGuild
+	}
+	return member, nil
+}
+
+// requirePermissionTx 在事务中检查成员会阶是否拥有指定权限
+func (gm *GuildManager) requirePermissionTx(tx *sql.Tx, userID int, permission string) (*models.GuildMember, *models.GuildRank, error) {
+	member, err := gm.requireMembershipTx(tx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rank, err := gm.guildRepo.GetRankTx(tx, member.GuildID, member.RankIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rank.HasPermission(permission) {
+		return nil, nil, ErrGuildPermissionDenied
+	}
+	return member, rank, nil
+}
+
+// findPlayer 按玩家名查找玩家ID（需在事务外调用）
+func (gm *GuildManager) findPlayer(playerName string) (int, error) {
+	user, err := gm.userRepo.GetByUsername(playerName)
+	if err != nil || user == nil {
+		return 0, ErrGuildPlayerNotFound
+	}
+	return user.ID, nil
+}
+
+// findMemberTx 在事务中获取同公会的其他成员
+func (gm *GuildManager) findMemberTx(tx *sql.Tx, actor *models.GuildMember, targetID int) (*models.GuildMember, error) {
+	if targetID == actor.UserID {
+		return nil, ErrGuildTargetSelf
+	}
+	target, err := gm.guildRepo.GetMembershipTx(tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil || target.GuildID != actor.GuildID {
+		return nil, ErrGuildTargetNotMember
+	}
+	return target, nil
+}
+
+// checkWithdrawLimitTx 检查24小时内的提取总量是否超过会阶额度（-1 表示不限）
+func (gm *GuildManager) checkWithdrawLimitTx(tx *sql.Tx, member *models.GuildMember, action string, limit, amount int, now time.Time) error {
+	if limit < 0 {
+		return nil
+	}
+	withdrawn, err := gm.guildRepo.SumWithdrawalsTx(tx, member.GuildID, member.UserID, action, now.Add(-guildWithdrawWindow))
+	if err != nil {
+		return err
+	}
+	if withdrawn+amount > limit {
+		return fmt.Errorf("%w: %d/%d used", ErrGuildWithdrawLimit, withdrawn, limit)
+	}
+	return nil
+}
+
+// addLog 记录公会活动日志
+func (gm *GuildManager) addLog(tx *sql.Tx, guildID, userID int, action, targetName string, amount int, now time.Time) error {
+	return gm.guildRepo.AddLogTx(tx, &models.GuildLogEntry{
+		GuildID:    guildID,
+		UserID:     userID,
+		Action:     action,
+		TargetName: targetName,
+		Amount:     amount,
+		CreatedAt:  now,
+	})
+}
+
+// notify 推送公会通知
+func (gm *GuildManager) notify(userID int, guild *models.Guild, action, message string) {
+	gm.eventHub.Publish(userID, EventGuild, &models.GuildEvent{
+		GuildID:   guild.ID,
+		GuildName: guild.Name,
+		Action:    action,
+		Message:   message,
+	})
+}
+
+// isValidGuildName 公会名只能包含文字、数字和空格
+func isValidGuildName(name string) bool {
+	length := utf8.RuneCountInString(name)
+	if length < minGuildNameLength || length > maxGuildNameLength {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeGuildPermissions 校验并去重权限列表
+func normalizeGuildPermissions(permissions []string) ([]string, error) {
+	valid := make(map[string]bool, len(guildPermissions))
+	for _, p := range guildPermissions {
+		valid[p] = true
+	}
+	result := make([]string, 0, len(permissions))
+	seen := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		if !valid[p] {
+			return nil, fmt.Errorf("%w: unknown permission %q", ErrGuildInvalidRank, p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
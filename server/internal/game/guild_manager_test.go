@@ -0,0 +1,196 @@
+package game
+
+import (
+	"testing"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupGuildTest 创建玩家并为每人创建一个指定阵营的角色
+func setupGuildTest(t *testing.T, factions map[string]string, names ...string) []int {
+	_, _, users := setupTradingTest(t, names...)
+	charRepo := repository.NewCharacterRepository()
+	for i, name := range names {
+		_, err := charRepo.Create(&models.Character{
+			UserID: users[i], Name: name + "char", RaceID: "human", ClassID: "warrior", Faction: factions[name],
+			TeamSlot: 1, IsActive: true, Level: 10, HP: 100, MaxHP: 100, ResourceType: "rage", MaxResource: 100,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create character: %v", err)
+		}
+	}
+	return users
+}
+
+func TestGuildManager_MembershipAndRanks(t *testing.T) {
+	factions := map[string]string{"leader": "alliance", "officer": "alliance", "member": "alliance", "orc": "horde"}
+	users := setupGuildTest(t, factions, "leader", "officer", "member", "orc")
+	defer database.TeardownTestDB(database.DB)
+	leader, officer, member, orc := users[0], users[1], users[2], users[3]
+
+	gm := NewGuildManager()
+
+	_, err := gm.CreateGuild(leader, " x ")
+	assert.ErrorIs(t, err, ErrInvalidGuildName)
+	guild, err := gm.CreateGuild(leader, "Stormwind  Guard")
+	assert.NoError(t, err)
+	assert.Equal(t, "Stormwind Guard", guild.Name)
+	assert.Equal(t, "alliance", guild.Faction)
+	assert.Equal(t, 1, guild.MemberCount)
+	gold, _ := NewEconomyManager().GetGold(leader)
+	assert.Equal(t, 1000-guildCreateCost, gold)
+
+	_, err = gm.CreateGuild(officer, "stormwind guard")
+	assert.ErrorIs(t, err, ErrGuildNameTaken)
+	_, err = gm.CreateGuild(leader, "Another")
+	assert.ErrorIs(t, err, ErrAlreadyInGuild)
+
+	// 邀请：阵营限制
+	_, err = gm.Invite(leader, "orc")
+	assert.ErrorIs(t, err, ErrGuildEnemyFaction)
+	_, err = gm.AcceptInvite(orc, guild.ID)
+	assert.ErrorIs(t, err, ErrGuildInviteNotFound)
+
+	for _, name := range []string{"officer", "member"} {
+		_, err = gm.Invite(leader, name)
+		assert.NoError(t, err)
+	}
+	invites, err := gm.GetInvites(member)
+	assert.NoError(t, err)
+	if assert.Len(t, invites, 1) {
+		assert.Equal(t, "leader", invites[0].InviterName)
+	}
+	for _, id := range []int{officer, member} {
+		_, err = gm.AcceptInvite(id, guild.ID)
+		assert.NoError(t, err)
+	}
+	invites, _ = gm.GetInvites(member)
+	assert.Empty(t, invites, "加入后邀请失效")
+
+	// 新成员为最低会阶，没有邀请权限
+	_, err = gm.Invite(member, "orc")
+	assert.ErrorIs(t, err, ErrGuildPermissionDenied)
+
+	// 会阶调整：只能调整低于自己的成员，且不能提升到与自己同级
+	assert.NoError(t, gm.SetMemberRank(leader, "officer", 1))
+	assert.ErrorIs(t, gm.SetMemberRank(officer, "member", 1), ErrGuildRankTooHigh)
+	assert.NoError(t, gm.SetMemberRank(officer, "member", 2))
+	assert.ErrorIs(t, gm.SetMemberRank(officer, "leader", 3), ErrGuildRankTooHigh)
+	assert.ErrorIs(t, gm.SetMemberRank(leader, "member", 9), ErrGuildInvalidRank)
+	assert.ErrorIs(t, gm.Kick(member, "officer"), ErrGuildPermissionDenied)
+
+	// 公告与会阶编辑
+	assert.NoError(t, gm.SetMOTD(officer, "raid tonight"))
+	assert.ErrorIs(t, gm.SetMOTD(member, "hi"), ErrGuildPermissionDenied)
+	assert.ErrorIs(t, gm.UpdateRank(officer, &models.GuildRank{Index: 2, Name: "成员"}), ErrGuildPermissionDenied)
+	assert.ErrorIs(t, gm.UpdateRank(leader, &models.GuildRank{Index: 0, Name: "boss"}), ErrGuildInvalidRank)
+	assert.ErrorIs(t, gm.UpdateRank(leader, &models.GuildRank{Index: 2, Name: "成员", Permissions: []string{"fly"}}), ErrGuildInvalidRank)
+	assert.NoError(t, gm.UpdateRank(leader, &models.GuildRank{
+		Index: 2, Name: "精英", Permissions: []string{models.GuildPermEditMOTD}, GoldWithdrawLimit: 0, ItemWithdrawLimit: 0,
+	}))
+	assert.NoError(t, gm.SetMOTD(member, "hi"))
+
+	info, err := gm.GetGuild(member)
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", info.Guild.MOTD)
+	assert.Equal(t, 2, info.MyRank)
+	assert.Len(t, info.Members, 3)
+	assert.Equal(t, "精英", info.Ranks[2].Name)
+
+	// 退出、踢出与会长转让
+	assert.ErrorIs(t, gm.Leave(leader), ErrGuildLeaderCannotLeave)
+	assert.NoError(t, gm.Kick(officer, "member"))
+	_, err = gm.GetGuild(member)
+	assert.ErrorIs(t, err, ErrNotInGuild)
+	assert.NoError(t, gm.TransferLeadership(leader, "officer"))
+	assert.NoError(t, gm.Leave(leader))
+	info, err = gm.GetGuild(officer)
+	assert.NoError(t, err)
+	assert.Equal(t, officer, info.Guild.LeaderID)
+	assert.Equal(t, 0, info.MyRank)
+
+	logs, err := gm.GetLog(officer, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, repository.GuildLogLeave, logs[0].Action)
+	assert.Equal(t, repository.GuildLogCreate, logs[len(logs)-1].Action)
+}
+
+func TestGuildManager_Bank(t *testing.T) {
+	factions := map[string]string{"leader": "alliance", "officer": "alliance", "member": "alliance"}
+	users := setupGuildTest(t, factions, "leader", "officer", "member")
+	defer database.TeardownTestDB(database.DB)
+	leader, officer, member := users[0], users[1], users[2]
+
+	gm := NewGuildManager()
+	guild, err := gm.CreateGuild(leader, "Bankers")
+	assert.NoError(t, err)
+	for _, name := range []string{"officer", "member"} {
+		_, err = gm.Invite(leader, name)
+		assert.NoError(t, err)
+	}
+	for _, id := range []int{officer, member} {
+		_, err = gm.AcceptInvite(id, guild.ID)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, gm.SetMemberRank(leader, "officer", 1))
+	assert.NoError(t, gm.SetMemberRank(leader, "member", 2))
+
+	// 金币：存入后按会阶额度提取
+	assert.ErrorIs(t, gm.DepositGold(member, 0), ErrInvalidGuildGold)
+	assert.ErrorIs(t, gm.DepositGold(member, 5000), repository.ErrInsufficientGold)
+	assert.NoError(t, gm.DepositGold(member, 800))
+	assert.NoError(t, gm.DepositGold(leader, 700))
+
+	assert.ErrorIs(t, gm.WithdrawGold(member, 10), ErrGuildPermissionDenied)
+	assert.NoError(t, gm.WithdrawGold(officer, 600))
+	assert.ErrorIs(t, gm.WithdrawGold(officer, 500), ErrGuildWithdrawLimit, "官员每日1000")
+	assert.NoError(t, gm.WithdrawGold(officer, 400))
+	assert.ErrorIs(t, gm.WithdrawGold(leader, 1000), repository.ErrGuildBankInsufficientGold)
+
+	economy := NewEconomyManager()
+	gold, _ := economy.GetGold(officer)
+	assert.Equal(t, 2000, gold)
+
+	// 装备：存入期间托管，成员每日只能提取1件
+	sword1 := createTradeEquipment(t, officer)
+	sword2 := createTradeEquipment(t, officer)
+	assert.NoError(t, gm.DepositItem(officer, sword1))
+	assert.NoError(t, gm.DepositItem(officer, sword2))
+	assert.ErrorIs(t, gm.DepositItem(officer, sword1), repository.ErrEquipmentInEscrow)
+	_, err = NewMailManager().SendMail(officer, "leader", "gift", "", 0, []int{sword1}, 0)
+	assert.ErrorIs(t, err, repository.ErrEquipmentInEscrow)
+
+	bank, err := gm.GetBank(member)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, bank.Gold)
+	assert.Len(t, bank.Items, 2)
+	assert.Equal(t, "交易长剑", bank.Items[0].ItemName)
+	assert.Equal(t, 1, bank.ItemWithdrawLimit)
+	assert.Equal(t, 0, bank.GoldWithdrawLimit)
+
+	assert.NoError(t, gm.WithdrawItem(member, sword1))
+	assert.ErrorIs(t, gm.WithdrawItem(member, sword2), ErrGuildWithdrawLimit)
+	assert.ErrorIs(t, gm.WithdrawItem(officer, sword1), repository.ErrGuildBankItemNotFound)
+	equipment, err := repository.NewEquipmentRepository().GetByID(sword1)
+	assert.NoError(t, err)
+	assert.Equal(t, member, equipment.OwnerID)
+
+	bank, err = gm.GetBank(member)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bank.ItemsWithdrawnToday)
+
+	// 解散：仅会长，银行内容归还会长
+	assert.ErrorIs(t, gm.DisbandGuild(officer), ErrGuildPermissionDenied)
+	assert.NoError(t, gm.DisbandGuild(leader))
+	gold, _ = economy.GetGold(leader)
+	assert.Equal(t, 1000-guildCreateCost-700+500, gold)
+	equipment, err = repository.NewEquipmentRepository().GetByID(sword2)
+	assert.NoError(t, err)
+	assert.Equal(t, leader, equipment.OwnerID)
+	_, err = gm.GetGuild(member)
+	assert.ErrorIs(t, err, ErrNotInGuild)
+}
@@ -0,0 +1,224 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 荣誉军衔参数
+const (
+	honorMaxRank      = 14
+	honorWeeklyDecay  = 0.2 // 每周结算时累计荣誉（军衔积分）衰减比例
+	honorDefaultLimit = 50  // 排名默认条数
+)
+
+// honorRankThresholds 各军衔所需累计荣誉（下标即军衔等级）
+var honorRankThresholds = [honorMaxRank + 1]int{
+	0, 50, 150, 300, 500, 800, 1200, 1700, 2300, 3000, 4000, 5200, 6600, 8200, 10000,
+}
+
+// 军衔名称（下标即军衔等级）
+var (
+	allianceRankNames = [honorMaxRank + 1]string{
+		"无军衔", "列兵", "下士", "中士", "军士长", "士官长", "骑士", "骑士中尉",
+		"骑士队长", "骑士统领", "少校", "司令官", "元帅", "陆军元帅", "大元帅",
+	}
+	hordeRankNames = [honorMaxRank + 1]string{
+		"无军衔", "斥候", "步兵", "中士", "高阶军士", "一等军士长", "石头守卫", "血卫士",
+		"军团士兵", "百夫长", "勇士", "中将", "将军", "督军", "高阶督军",
+	}
+)
+
+// HonorManager 荣誉管理器 - 军衔每周结算、荣誉衰减与荣誉商店
+type HonorManager struct {
+	mu        sync.Mutex // 保证每周结算不会并发执行
+	honorRepo *repository.HonorRepository
+	pvpRepo   *repository.PvPRepository
+	charRepo  *repository.CharacterRepository
+}
+
+// NewHonorManager 创建荣誉管理器
+func NewHonorManager() *HonorManager {
+	return &HonorManager{
+		honorRepo: repository.NewHonorRepository(),
+		pvpRepo:   repository.NewPvPRepository(),
+		charRepo:  repository.NewCharacterRepository(),
+	}
+}
+
+// ═══════════════════════════════════════════════════════════
+// 军衔
+// ═══════════════════════════════════════════════════════════
+
+// CalculateHonorRank 根据累计荣誉计算军衔等级 (0-14)
+func CalculateHonorRank(totalHonor int) int {
+	rank := 0
+	for i, threshold := range honorRankThresholds {
+		if totalHonor >= threshold {
+			rank = i
+		}
+	}
+	return rank
+}
+
+// HonorRankName 获取阵营军衔名称
+func HonorRankName(faction string, rank int) string {
+	if rank < 0 || rank > honorMaxRank {
+		return ""
+	}
+	if faction == "horde" {
+		return hordeRankNames[rank]
+	}
+	return allianceRankNames[rank]
+}
+
+// HonorWeekStart 获取荣誉周的起始时间（UTC 周一 00:00）
+func HonorWeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+}
+
+// decorateHonor 填充军衔名称和下一军衔所需荣誉
+func decorateHonor(honor *models.UserHonor) *models.UserHonor {
+	honor.RankName = HonorRankName(honor.Faction, honor.HonorRank)
+	honor.NextRankHonor = 0
+	if honor.HonorRank < honorMaxRank {
+		honor.NextRankHonor = honorRankThresholds[honor.HonorRank+1]
+	}
+	return honor
+}
+
+// GetHonor 获取玩家荣誉战绩（包含军衔名称）
+func (hm *HonorManager) GetHonor(userID int) (*models.UserHonor, error) {
+	honor, err := hm.pvpRepo.GetUserHonor(userID)
+	if err != nil {
+		return nil, err
+	}
+	return decorateHonor(honor), nil
+}
+
+// GetStandings 获取军衔排名及玩家自身排名
+func (hm *HonorManager) GetStandings(userID int, faction string, limit int) ([]*models.HonorStanding, int, error) {
+	if limit <= 0 {
+		limit = honorDefaultLimit
+	}
+	standings, err := hm.honorRepo.GetStandings(faction, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, s := range standings {
+		s.RankName = HonorRankName(s.Faction, s.HonorRank)
+	}
+
+	position, err := hm.honorRepo.GetStandingPosition(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return standings, position, nil
+}
+
+// RunWeeklyReset 执行每周结算：按上周累计荣誉结算军衔，随后衰减累计荣誉并清零本周荣誉
+// 同一荣誉周内重复调用不会重复结算，返回本次结算的玩家数
+func (hm *HonorManager) RunWeeklyReset(now time.Time) (int, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	weekStart := HonorWeekStart(now)
+	records, err := hm.honorRepo.GetResetRecords()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, record := range records {
+		if record.WeeklyResetAt != nil && !record.WeeklyResetAt.Before(weekStart) {
+			continue
+		}
+		rank := CalculateHonorRank(record.TotalHonor)
+		decayed := int(float64(record.TotalHonor) * (1 - honorWeeklyDecay))
+		if err := hm.honorRepo.ApplyWeeklyReset(record.UserID, decayed, rank, weekStart); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// StartWeeklyResetJob 启动每周结算任务（启动时立即检查一次，之后按间隔检查）
+func (hm *HonorManager) StartWeeklyResetJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			count, err := hm.RunWeeklyReset(time.Now())
+			if err != nil {
+				fmt.Printf("[ERROR] Honor weekly reset failed: %v\n", err)
+			} else if count > 0 {
+				fmt.Printf("[INFO] Honor weekly reset settled %d players\n", count)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ═══════════════════════════════════════════════════════════
+// 荣誉商店
+// ═══════════════════════════════════════════════════════════
+
+// GetShop 获取玩家可见的荣誉商店商品（含本周已购数量）
+func (hm *HonorManager) GetShop(userID int) ([]*models.HonorShopItem, error) {
+	honor, err := hm.pvpRepo.GetUserHonor(userID)
+	if err != nil {
+		return nil, err
+	}
+	faction := honor.Faction
+	if faction == "" {
+		faction = hm.getUserFaction(userID)
+	}
+
+	items, err := hm.honorRepo.GetShopItems(faction)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := hm.honorRepo.GetWeeklyPurchaseCounts(userID, HonorWeekStart(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		item.PurchasedThisWeek = counts[item.ID]
+	}
+	return items, nil
+}
+
+// Purchase 购买荣誉商店商品（消耗品发放到指定角色，未指定时发放到小队第一个角色）
+func (hm *HonorManager) Purchase(userID int, shopItemID string, characterID int) (*models.HonorPurchase, error) {
+	if characterID == 0 {
+		chars, err := hm.charRepo.GetByUserID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(chars) > 0 {
+			characterID = chars[0].ID
+		}
+	}
+	return hm.honorRepo.Purchase(userID, shopItemID, characterID, HonorWeekStart(time.Now()))
+}
+
+// GetPurchases 获取购买历史
+func (hm *HonorManager) GetPurchases(userID int, limit int) ([]*models.HonorPurchase, error) {
+	return hm.honorRepo.GetPurchases(userID, limit)
+}
+
+// getUserFaction 获取玩家阵营（尚无荣誉记录时根据角色判断）
+func (hm *HonorManager) getUserFaction(userID int) string {
+	chars, err := hm.charRepo.GetByUserID(userID)
+	if err != nil || len(chars) == 0 {
+		return ""
+	}
+	return chars[0].Faction
+}
@@ -0,0 +1,78 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ═══════════════════════════════════════════════════════════
+// 军衔计算测试
+// ═══════════════════════════════════════════════════════════
+
+func TestCalculateHonorRank(t *testing.T) {
+	assert.Equal(t, 0, CalculateHonorRank(0))
+	assert.Equal(t, 0, CalculateHonorRank(49))
+	assert.Equal(t, 1, CalculateHonorRank(50))
+	assert.Equal(t, 7, CalculateHonorRank(1700))
+	assert.Equal(t, 14, CalculateHonorRank(999999), "军衔最高14级")
+}
+
+func TestHonorRankName(t *testing.T) {
+	assert.Equal(t, "骑士中尉", HonorRankName("alliance", 7))
+	assert.Equal(t, "血卫士", HonorRankName("horde", 7))
+	assert.Equal(t, "", HonorRankName("horde", 15))
+}
+
+func TestHonorWeekStart(t *testing.T) {
+	// 2026-10-21 是周三
+	wednesday := time.Date(2026, 10, 21, 15, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), HonorWeekStart(wednesday))
+
+	sunday := time.Date(2026, 10, 25, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), HonorWeekStart(sunday))
+}
+
+// ═══════════════════════════════════════════════════════════
+// 每周结算测试
+// ═══════════════════════════════════════════════════════════
+
+func TestHonorManager_RunWeeklyReset(t *testing.T) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer database.TeardownTestDB(testDB)
+
+	user, err := repository.NewUserRepository().Create("resetuser", "hash", "")
+	assert.NoError(t, err)
+	lastWeek := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	_, err = testDB.Exec(`
+		INSERT INTO user_honor (user_id, faction, total_honor, current_honor, weekly_honor, weekly_reset_at)
+		VALUES (?, 'horde', 1000, 300, 200, ?)
+	`, user.ID, lastWeek)
+	assert.NoError(t, err)
+
+	hm := NewHonorManager()
+	now := time.Date(2026, 10, 21, 8, 0, 0, 0, time.UTC)
+
+	count, err := hm.RunWeeklyReset(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	honor, err := hm.GetHonor(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, honor.HonorRank, "按衰减前的累计荣誉结算军衔")
+	assert.Equal(t, "一等军士长", honor.RankName)
+	assert.Equal(t, 800, honor.TotalHonor, "累计荣誉衰减20%")
+	assert.Equal(t, 0, honor.WeeklyHonor)
+	assert.Equal(t, 300, honor.CurrentHonor, "可用荣誉不衰减")
+
+	count, err = hm.RunWeeklyReset(now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count, "同一周内不重复结算")
+}
@@ -0,0 +1,289 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 排行榜错误
+var (
+	ErrLeaderboardNotFound      = errors.New("leaderboard not found")
+	ErrInvalidLeaderboardFilter = errors.New("faction must be alliance or horde")
+	ErrInvalidSeasonName        = errors.New("season name must be 1-32 characters")
+)
+
+const (
+	leaderboardDefaultLimit      = 50
+	leaderboardMaxLimit          = 100
+	leaderboardDefaultNeighbours = 5
+	leaderboardMaxNeighbours     = 25
+	maxSeasonNameLength          = 32
+	firstSeasonName              = "第1赛季"
+)
+
+// leaderboardBoards 全部排行榜（顺序即展示顺序）
+var leaderboardBoards = []*models.LeaderboardBoard{
+	{ID: models.LeaderboardLevel, Name: "等级", Subject: "character"},
+	{ID: models.LeaderboardKills, Name: "击杀数", Subject: "player", Seasonal: true},
+	{ID: models.LeaderboardAbyssFloor, Name: "深渊层数", Subject: "player"},
+	{ID: models.LeaderboardHonor, Name: "荣誉", Subject: "player", Seasonal: true},
+	{ID: models.LeaderboardGoldEarned, Name: "金币收入", Subject: "player", Seasonal: true},
+	{ID: models.LeaderboardHighestHit, Name: "单次最高伤害", Subject: "character"},
+	{ID: models.LeaderboardFastestBossKill, Name: "最快首领击杀", Subject: "player", Ascending: true, Seasonal: true},
+}
+
+// LeaderboardQuery 排行榜查询条件
+type LeaderboardQuery struct {
+	Board    string
+	SeasonID int    // 0 表示当前赛季
+	Faction  string // alliance/horde，空表示不限
+	ClassID  string // 空表示不限
+}
+
+// LeaderboardManager 排行榜管理器 - 定时快照、赛季与排名查询
+type LeaderboardManager struct {
+	mu   sync.Mutex
+	repo *repository.LeaderboardRepository
+}
+
+// NewLeaderboardManager 创建排行榜管理器
+func NewLeaderboardManager() *LeaderboardManager {
+	return &LeaderboardManager{
+		repo: repository.NewLeaderboardRepository(),
+	}
+}
+
+// 全局排行榜管理器实例
+var leaderboardManager *LeaderboardManager
+var leaderboardOnce sync.Once
+
+// GetLeaderboardManager 获取排行榜管理器单例
+func GetLeaderboardManager() *LeaderboardManager {
+	leaderboardOnce.Do(func() {
+		leaderboardManager = NewLeaderboardManager()
+	})
+	return leaderboardManager
+}
+
+// Boards 获取全部排行榜定义
+func (m *LeaderboardManager) Boards() []*models.LeaderboardBoard {
+	return leaderboardBoards
+}
+
+// ═══════════════════════════════════════════════════════════
+// 快照与赛季
+// ═══════════════════════════════════════════════════════════
+
+// Snapshot 重建当前赛季的全部榜单（没有进行中的赛季时开启第一个赛季）
+func (m *LeaderboardManager) Snapshot(now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		season, err := m.repo.GetCurrentSeasonTx(tx)
+		if err != nil {
+			return err
+		}
+		if season == nil {
+			// 第一个赛季没有基线，累计类榜单即为历史累计
+			if season, err = m.repo.CreateSeasonTx(tx, firstSeasonName, now); err != nil {
+				return err
+			}
+		}
+		return m.snapshotSeasonTx(tx, season, now)
+	})
+}
+
+// StartSeason 结束当前赛季并开启新赛季（权限检查与审计由 AdminManager 负责）
+// 当前赛季结束前会做最后一次快照，作为该赛季的最终排名
+func (m *LeaderboardManager) StartSeason(name string, now time.Time) (*models.LeaderboardSeason, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxSeasonNameLength {
+		return nil, ErrInvalidSeasonName
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.LeaderboardSeason, error) {
+		current, err := m.repo.GetCurrentSeasonTx(tx)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			if err := m.snapshotSeasonTx(tx, current, now); err != nil {
+				return nil, err
+			}
+			if err := m.repo.EndSeasonTx(tx, current.ID, now); err != nil {
+				return nil, err
+			}
+		}
+
+		season, err := m.repo.CreateSeasonTx(tx, name, now)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.repo.SaveBaselinesTx(tx, season.ID); err != nil {
+			return nil, err
+		}
+		if err := m.snapshotSeasonTx(tx, season, now); err != nil {
+			return nil, err
+		}
+		season.LastSnapshotAt = &season.StartedAt
+		return season, nil
+	})
+}
+
+// snapshotSeasonTx 在事务中重建赛季的全部榜单
+func (m *LeaderboardManager) snapshotSeasonTx(tx *sql.Tx, season *models.LeaderboardSeason, now time.Time) error {
+	for _, board := range leaderboardBoards {
+		entries, err := m.repo.CollectScoresTx(tx, board.ID, season)
+		if err != nil {
+			return fmt.Errorf("collect %s: %w", board.ID, err)
+		}
+		if err := m.repo.ReplaceEntriesTx(tx, season.ID, board.ID, entries); err != nil {
+			return err
+		}
+	}
+	return m.repo.SetSnapshotTimeTx(tx, season.ID, now)
+}
+
+// GetSeasons 获取全部赛季（最新在前）
+func (m *LeaderboardManager) GetSeasons() ([]*models.LeaderboardSeason, error) {
+	return m.repo.GetSeasons()
+}
+
+// StartSnapshotJob 启动排行榜定时快照任务（启动时立即快照一次）
+func (m *LeaderboardManager) StartSnapshotJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := m.Snapshot(time.Now()); err != nil {
+				fmt.Printf("[ERROR] Leaderboard snapshot failed: %v\n", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ═══════════════════════════════════════════════════════════
+// 排名查询
+// ═══════════════════════════════════════════════════════════
+
+// GetLeaderboard 获取排行榜的一页
+func (m *LeaderboardManager) GetLeaderboard(query *LeaderboardQuery, limit, offset int) (*models.Leaderboard, error) {
+	board, season, filter, err := m.resolve(query)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = leaderboardDefaultLimit
+	}
+	if limit > leaderboardMaxLimit {
+		limit = leaderboardMaxLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	total, err := m.repo.CountEntries(filter)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.repo.GetEntries(filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Leaderboard{
+		Board:      board,
+		Season:     season,
+		Faction:    query.Faction,
+		ClassID:    query.ClassID,
+		Total:      total,
+		Entries:    entries,
+		SnapshotAt: season.LastSnapshotAt,
+	}, nil
+}
+
+// GetPosition 获取玩家自己的排名及前后各 neighbours 名（角色榜取玩家最好的角色）
+func (m *LeaderboardManager) GetPosition(userID int, query *LeaderboardQuery, neighbours int) (*models.LeaderboardPosition, error) {
+	board, season, filter, err := m.resolve(query)
+	if err != nil {
+		return nil, err
+	}
+	if neighbours <= 0 {
+		neighbours = leaderboardDefaultNeighbours
+	}
+	if neighbours > leaderboardMaxNeighbours {
+		neighbours = leaderboardMaxNeighbours
+	}
+
+	position := &models.LeaderboardPosition{
+		Board:  board,
+		Season: season,
+		Above:  []*models.LeaderboardEntry{},
+		Below:  []*models.LeaderboardEntry{},
+	}
+	entry, err := m.repo.GetBestEntry(filter, userID)
+	if err != nil || entry == nil {
+		return position, err
+	}
+	position.Entry = entry
+	if position.Above, err = m.repo.GetNeighbours(filter, entry, neighbours, true); err != nil {
+		return nil, err
+	}
+	if position.Below, err = m.repo.GetNeighbours(filter, entry, neighbours, false); err != nil {
+		return nil, err
+	}
+	return position, nil
+}
+
+// resolve 校验查询条件并定位赛季（尚无赛季时立即做一次快照）
+func (m *LeaderboardManager) resolve(query *LeaderboardQuery) (*models.LeaderboardBoard, *models.LeaderboardSeason, *repository.LeaderboardFilter, error) {
+	var board *models.LeaderboardBoard
+	for _, b := range leaderboardBoards {
+		if b.ID == query.Board {
+			board = b
+			break
+		}
+	}
+	if board == nil {
+		return nil, nil, nil, ErrLeaderboardNotFound
+	}
+	if query.Faction != "" && query.Faction != "alliance" && query.Faction != "horde" {
+		return nil, nil, nil, ErrInvalidLeaderboardFilter
+	}
+
+	var season *models.LeaderboardSeason
+	var err error
+	if query.SeasonID > 0 {
+		season, err = m.repo.GetSeason(query.SeasonID)
+	} else {
+		season, err = m.repo.GetCurrentSeason()
+		if err == nil && season == nil {
+			if err = m.Snapshot(time.Now()); err == nil {
+				season, err = m.repo.GetCurrentSeason()
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	filter := &repository.LeaderboardFilter{
+		SeasonID: season.ID,
+		Board:    board.ID,
+		Faction:  query.Faction,
+		ClassID:  query.ClassID,
+	}
+	return board, season, filter, nil
+}
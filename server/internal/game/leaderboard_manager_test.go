@@ -0,0 +1,224 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupLeaderboardTest 创建三名玩家：两名联盟（战士、法师）和一名部落战士
+func setupLeaderboardTest(t *testing.T) []int {
+	db, _, users := setupTradingTest(t, "alice", "bob", "carl")
+	charRepo := repository.NewCharacterRepository()
+	chars := []struct {
+		faction, classID, resource string
+		level                      int
+	}{
+		{"alliance", "warrior", "rage", 20},
+		{"alliance", "mage", "mana", 10},
+		{"horde", "warrior", "rage", 15},
+	}
+	for i, c := range chars {
+		char, err := charRepo.Create(&models.Character{
+			UserID: users[i], Name: []string{"alice", "bob", "carl"}[i] + "char", RaceID: "human", ClassID: c.classID,
+			Faction: c.faction, TeamSlot: 1, IsActive: true, Level: c.level, HP: 100, MaxHP: 100,
+			ResourceType: c.resource, MaxResource: 100,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create character: %v", err)
+		}
+		_, err = db.Exec(`INSERT INTO character_lifetime_stats (character_id, highest_damage_single) VALUES (?, ?)`,
+			char.ID, []int{300, 900, 500}[i])
+		assert.NoError(t, err)
+	}
+
+	past := time.Now().Add(-time.Hour).UTC()
+	_, err := db.Exec(`
+		INSERT INTO monsters (id, zone_id, name, level, type, hp, physical_attack, magic_attack, physical_defense, magic_defense, speed, exp_reward, gold_min, gold_max, spawn_weight)
+		VALUES ('hogger', 'elwynn', '霍格', 11, 'boss', 500, 20, 5, 5, 5, 10, 200, 10, 20, 1)`)
+	assert.NoError(t, err)
+	for i, kills := range []int{50, 80, 30} {
+		_, err = db.Exec(`UPDATE users SET total_kills = ?, total_gold_gained = ? WHERE id = ?`, kills, kills*10, users[i])
+		assert.NoError(t, err)
+	}
+	for _, record := range []struct {
+		user, duration int
+		result         string
+	}{
+		{users[0], 90, "victory"},
+		{users[1], 60, "victory"},
+		{users[2], 30, "defeat"},
+	} {
+		_, err = db.Exec(`
+			INSERT INTO battle_records (user_id, zone_id, battle_type, monster_id, duration_seconds, result, created_at)
+			VALUES (?, 'elwynn', 'pve', 'hogger', ?, ?, ?)`, record.user, record.duration, record.result, past)
+		assert.NoError(t, err)
+	}
+	return users
+}
+
+func TestLeaderboardManager_BoardsAndFilters(t *testing.T) {
+	users := setupLeaderboardTest(t)
+	defer database.TeardownTestDB(database.DB)
+	alice, bob, carl := users[0], users[1], users[2]
+
+	lm := NewLeaderboardManager()
+
+	_, err := lm.GetLeaderboard(&LeaderboardQuery{Board: "wealth"}, 0, 0)
+	assert.ErrorIs(t, err, ErrLeaderboardNotFound)
+	_, err = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardKills, Faction: "scourge"}, 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidLeaderboardFilter)
+
+	// 首次查询时自动开启第一个赛季并生成快照
+	board, err := lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardKills}, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, firstSeasonName, board.Season.Name)
+	assert.NotNil(t, board.SnapshotAt)
+	assert.Equal(t, 3, board.Total)
+	assert.Equal(t, []int{bob, alice, carl}, leaderboardUsers(board.Entries))
+
+	board, err = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardKills, Faction: "alliance"}, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, board.Total)
+	if assert.Len(t, board.Entries, 1) {
+		assert.Equal(t, alice, board.Entries[0].UserID)
+		assert.Equal(t, 2, board.Entries[0].Rank)
+		assert.Equal(t, 2, board.Entries[0].GlobalRank)
+	}
+
+	board, err = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardKills, ClassID: "warrior"}, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{alice, carl}, leaderboardUsers(board.Entries))
+	assert.Equal(t, 2, board.Entries[1].Rank)
+	assert.Equal(t, 3, board.Entries[1].GlobalRank)
+
+	board, _ = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardLevel}, 0, 0)
+	assert.Equal(t, []int{alice, carl, bob}, leaderboardUsers(board.Entries))
+	assert.Equal(t, "alicechar", board.Entries[0].Name)
+	board, _ = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardHighestHit}, 0, 0)
+	assert.Equal(t, []int{bob, carl, alice}, leaderboardUsers(board.Entries))
+	board, _ = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardGoldEarned}, 0, 0)
+	assert.Equal(t, 800, board.Entries[0].Score)
+
+	// 最快击杀按用时升序，失败的战斗不计入
+	board, _ = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardFastestBossKill}, 0, 0)
+	assert.Equal(t, []int{bob, alice}, leaderboardUsers(board.Entries))
+	assert.Equal(t, 60, board.Entries[0].Score)
+
+	// 自己的排名与相邻条目
+	position, err := lm.GetPosition(carl, &LeaderboardQuery{Board: models.LeaderboardKills}, 1)
+	assert.NoError(t, err)
+	if assert.NotNil(t, position.Entry) {
+		assert.Equal(t, 3, position.Entry.Rank)
+	}
+	assert.Equal(t, []int{alice}, leaderboardUsers(position.Above))
+	assert.Equal(t, 2, position.Above[0].Rank)
+	assert.Empty(t, position.Below)
+
+	position, _ = lm.GetPosition(carl, &LeaderboardQuery{Board: models.LeaderboardKills, Faction: "horde"}, 0)
+	assert.Equal(t, 1, position.Entry.Rank)
+	assert.Empty(t, position.Above)
+
+	position, _ = lm.GetPosition(bob, &LeaderboardQuery{Board: models.LeaderboardKills, ClassID: "warrior"}, 0)
+	assert.Nil(t, position.Entry, "不符合筛选条件时不在榜上")
+
+	position, _ = lm.GetPosition(alice, &LeaderboardQuery{Board: models.LeaderboardKills}, 5)
+	assert.Equal(t, 2, position.Entry.Rank)
+	assert.Equal(t, []int{bob}, leaderboardUsers(position.Above))
+	assert.Equal(t, []int{carl}, leaderboardUsers(position.Below))
+	assert.Equal(t, 3, position.Below[0].Rank)
+}
+
+func TestLeaderboardManager_Seasons(t *testing.T) {
+	users := setupLeaderboardTest(t)
+	defer database.TeardownTestDB(database.DB)
+	alice, bob, carl := users[0], users[1], users[2]
+
+	lm := NewLeaderboardManager()
+	now := time.Now()
+	assert.NoError(t, lm.Snapshot(now))
+
+	_, err := lm.StartSeason("  ", now)
+	assert.ErrorIs(t, err, ErrInvalidSeasonName)
+	season, err := lm.StartSeason("第2赛季", now)
+	assert.NoError(t, err)
+
+	// 新赛季：累计类榜单从零开始，等级等状态类榜单保留
+	board, _ := lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardKills}, 0, 0)
+	assert.Equal(t, season.ID, board.Season.ID)
+	assert.Empty(t, board.Entries)
+	board, _ = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardFastestBossKill}, 0, 0)
+	assert.Empty(t, board.Entries, "赛季开始前的击杀不计入")
+	board, _ = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardLevel}, 0, 0)
+	assert.Len(t, board.Entries, 3)
+
+	_, err = database.DB.Exec(`UPDATE users SET total_kills = total_kills + 5 WHERE id = ?`, carl)
+	assert.NoError(t, err)
+	assert.NoError(t, lm.Snapshot(now.Add(time.Hour)))
+	board, _ = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardKills}, 0, 0)
+	if assert.Len(t, board.Entries, 1) {
+		assert.Equal(t, carl, board.Entries[0].UserID)
+		assert.Equal(t, 5, board.Entries[0].Score)
+	}
+
+	// 上个赛季保留最终排名
+	seasons, err := lm.GetSeasons()
+	assert.NoError(t, err)
+	if assert.Len(t, seasons, 2) {
+		assert.Nil(t, seasons[0].EndedAt)
+		assert.NotNil(t, seasons[1].EndedAt)
+	}
+	board, err = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardKills, SeasonID: seasons[1].ID}, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{bob, alice, carl}, leaderboardUsers(board.Entries))
+	_, err = lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardKills, SeasonID: 99}, 0, 0)
+	assert.ErrorIs(t, err, repository.ErrLeaderboardSeasonNotFound)
+}
+
+func TestLeaderboardManager_GoldEarnedIgnoresTransfers(t *testing.T) {
+	users := setupLeaderboardTest(t)
+	defer database.TeardownTestDB(database.DB)
+	alice, bob := users[0], users[1]
+
+	lm := NewLeaderboardManager()
+	now := time.Now()
+	assert.NoError(t, lm.Snapshot(now))
+	before, err := lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardGoldEarned}, 0, 0)
+	assert.NoError(t, err)
+
+	// 两名玩家来回交易同一笔金币，不应刷高金币榜
+	tm := NewTradingManager()
+	for _, pair := range [][2]int{{alice, bob}, {bob, alice}} {
+		partner, err := repository.NewUserRepository().GetByID(pair[1])
+		assert.NoError(t, err)
+		trade, err := tm.OpenTradeByName(pair[0], partner.Username)
+		assert.NoError(t, err)
+		_, err = tm.SetTradeGold(pair[0], trade.ID, 500)
+		assert.NoError(t, err)
+		_, err = tm.ConfirmTrade(pair[1], trade.ID)
+		assert.NoError(t, err)
+		trade, err = tm.ConfirmTrade(pair[0], trade.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "completed", trade.Status)
+	}
+	assert.Equal(t, 1000, goldOf(t, alice))
+	assert.Equal(t, 1000, goldOf(t, bob))
+
+	assert.NoError(t, lm.Snapshot(now.Add(time.Hour)))
+	after, err := lm.GetLeaderboard(&LeaderboardQuery{Board: models.LeaderboardGoldEarned}, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, before.Entries, after.Entries)
+}
+
+func leaderboardUsers(entries []*models.LeaderboardEntry) []int {
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.UserID)
+	}
+	return ids
+}
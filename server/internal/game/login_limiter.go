@@ -0,0 +1,278 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 登录防护错误
+var (
+	ErrLoginLocked = errors.New("too many login attempts, please try again later")
+)
+
+// loginAttemptRetention 登录尝试审计记录保留时长
+const loginAttemptRetention = 30 * 24 * time.Hour
+
+// loginPendingTimeout 预占后超过该时长仍未结算的尝试（如处理中途崩溃）不再计入
+const loginPendingTimeout = time.Minute
+
+// loginPendingRetryAfter 因并发尝试已占满额度被拒绝时的建议等待时长
+const loginPendingRetryAfter = time.Second
+
+// LoginLimiterConfig 登录防护参数
+type LoginLimiterConfig struct {
+	Window              time.Duration // 失败次数统计的滑动窗口
+	MaxIPFailures       int           // 窗口内单个IP允许的失败次数（共享出口IP较多，阈值应较宽松）
+	MaxUsernameFailures int           // 窗口内单个用户名允许的失败次数
+	BaseLockout         time.Duration // 首次锁定时长，之后每次连续锁定翻倍
+	MaxLockout          time.Duration // 锁定时长上限
+	LevelResetAfter     time.Duration // 上次锁定到期后超过该时长未再锁定，锁定等级归零
+}
+
+// DefaultLoginLimiterConfig 默认登录防护参数
+func DefaultLoginLimiterConfig() LoginLimiterConfig {
+	return LoginLimiterConfig{
+		Window:              15 * time.Minute,
+		MaxIPFailures:       20,
+		MaxUsernameFailures: 5,
+		BaseLockout:         time.Minute,
+		MaxLockout:          time.Hour,
+		LevelResetAfter:     24 * time.Hour,
+	}
+}
+
+// LoginLimiter 登录防护 - 按IP与用户名的滑动窗口失败计数、指数退避锁定与登录审计
+type LoginLimiter struct {
+	mu          sync.Mutex // 保证检查与预占/结算串行执行，并发请求无法越过上限
+	config      LoginLimiterConfig
+	attemptRepo *repository.LoginAttemptRepository
+}
+
+// NewLoginLimiter 创建登录防护
+func NewLoginLimiter(config LoginLimiterConfig) *LoginLimiter {
+	return &LoginLimiter{
+		config:      config,
+		attemptRepo: repository.NewLoginAttemptRepository(),
+	}
+}
+
+// 全局登录防护实例
+var loginLimiter *LoginLimiter
+var loginLimiterOnce sync.Once
+
+// GetLoginLimiter 获取登录防护单例（默认参数）
+func GetLoginLimiter() *LoginLimiter {
+	loginLimiterOnce.Do(func() {
+		loginLimiter = NewLoginLimiter(DefaultLoginLimiterConfig())
+	})
+	return loginLimiter
+}
+
+// NormalizeLoginUsername 归一化用户名作为限流键（忽略大小写与首尾空白）
+func NormalizeLoginUsername(username string) string {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if len(username) > 64 {
+		username = username[:64]
+	}
+	return username
+}
+
+// Check 检查IP与用户名是否处于锁定期，锁定时返回 ErrLoginLocked 及剩余等待时长
+func (l *LoginLimiter) Check(ip, username string, now time.Time) (time.Duration, error) {
+	lockout, err := l.attemptRepo.GetActiveLockout(ip, NormalizeLoginUsername(username), now)
+	if err != nil {
+		return 0, err
+	}
+	if lockout == nil {
+		return 0, nil
+	}
+	return lockout.LockedUntil.Sub(now), ErrLoginLocked
+}
+
+// Begin 在校验凭据前预占一次登录尝试，返回预占记录ID，处理完成后须调用 Finish 结算
+// 处于锁定期，或未结算的尝试加上窗口内失败次数已达上限时，记录为 locked 并返回 ErrLoginLocked 及等待时长
+func (l *LoginLimiter) Begin(ip, username, userAgent string, now time.Time) (int, time.Duration, error) {
+	attempt := &models.LoginAttempt{
+		Username:  NormalizeLoginUsername(username),
+		IPAddress: ip,
+		UserAgent: userAgent,
+		Result:    models.LoginResultPending,
+		CreatedAt: now,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var retryAfter time.Duration
+	err := repository.WithTransaction(func(tx *sql.Tx) error {
+		lockout, err := l.attemptRepo.GetActiveLockoutTx(tx, ip, attempt.Username, now)
+		if err != nil {
+			return err
+		}
+		if lockout != nil {
+			retryAfter = lockout.LockedUntil.Sub(now)
+		} else {
+			full, err := l.pendingLimitReachedTx(tx, ip, attempt.Username, now)
+			if err != nil {
+				return err
+			}
+			if full {
+				retryAfter = loginPendingRetryAfter
+			}
+		}
+		if retryAfter > 0 {
+			attempt.Result = models.LoginResultLocked
+		}
+		return l.attemptRepo.RecordAttemptTx(tx, attempt)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if retryAfter > 0 {
+		return 0, retryAfter, ErrLoginLocked
+	}
+	return attempt.ID, 0, nil
+}
+
+// Finish 结算 Begin 预占的尝试；失败时按滑动窗口判断是否锁定，成功时清除该用户名的锁定
+// result 为空表示请求未进行凭据校验（如参数错误），预占记录直接删除
+func (l *LoginLimiter) Finish(attemptID int, ip, username, result string, now time.Time) error {
+	username = NormalizeLoginUsername(username)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		if result == "" {
+			return l.attemptRepo.DeleteAttemptTx(tx, attemptID)
+		}
+		if err := l.attemptRepo.UpdateAttemptResultTx(tx, attemptID, result); err != nil {
+			return err
+		}
+
+		switch result {
+		case models.LoginResultSuccess:
+			// IP锁定不因成功登录解除，避免攻击者用自有账号重置计数
+			return l.attemptRepo.ClearLockoutTx(tx, models.LoginScopeUsername, username)
+		case models.LoginResultFailure:
+			since := now.Add(-l.config.Window)
+			ipFailures, err := l.attemptRepo.CountIPFailuresTx(tx, ip, since)
+			if err != nil {
+				return err
+			}
+			if ipFailures >= l.config.MaxIPFailures {
+				if err := l.lockTx(tx, models.LoginScopeIP, ip, now); err != nil {
+					return err
+				}
+			}
+			if username == "" {
+				return nil
+			}
+			userFailures, err := l.attemptRepo.CountUsernameFailuresTx(tx, username, since)
+			if err != nil {
+				return err
+			}
+			if userFailures >= l.config.MaxUsernameFailures {
+				return l.lockTx(tx, models.LoginScopeUsername, username, now)
+			}
+		}
+		return nil
+	})
+}
+
+// pendingLimitReachedTx 未结算的尝试按失败计，与窗口内失败次数合计达到IP或用户名上限时返回 true
+// 没有未结算的尝试时始终放行，由结算结果决定是否锁定（锁定到期后允许再试一次）
+func (l *LoginLimiter) pendingLimitReachedTx(tx *sql.Tx, ip, username string, now time.Time) (bool, error) {
+	since := now.Add(-l.config.Window)
+	pendingSince := now.Add(-loginPendingTimeout)
+
+	ipPending, err := l.attemptRepo.CountIPPendingTx(tx, ip, pendingSince)
+	if err != nil {
+		return false, err
+	}
+	if ipPending > 0 {
+		ipFailures, err := l.attemptRepo.CountIPFailuresTx(tx, ip, since)
+		if err != nil {
+			return false, err
+		}
+		if ipFailures+ipPending >= l.config.MaxIPFailures {
+			return true, nil
+		}
+	}
+
+	if username == "" {
+		return false, nil
+	}
+	userPending, err := l.attemptRepo.CountUsernamePendingTx(tx, username, pendingSince)
+	if err != nil {
+		return false, err
+	}
+	if userPending == 0 {
+		return false, nil
+	}
+	userFailures, err := l.attemptRepo.CountUsernameFailuresTx(tx, username, since)
+	if err != nil {
+		return false, err
+	}
+	return userFailures+userPending >= l.config.MaxUsernameFailures, nil
+}
+
+// lockTx 锁定IP或用户名，连续锁定时时长翻倍
+func (l *LoginLimiter) lockTx(tx *sql.Tx, scope, key string, now time.Time) error {
+	lockout, err := l.attemptRepo.GetLockoutTx(tx, scope, key)
+	if err != nil {
+		return err
+	}
+	level := 1
+	if lockout != nil && now.Sub(lockout.LockedUntil) < l.config.LevelResetAfter {
+		level = lockout.Level + 1
+	}
+
+	return l.attemptRepo.SaveLockoutTx(tx, &models.LoginLockout{
+		Scope:       scope,
+		Key:         key,
+		Level:       level,
+		LockedUntil: now.Add(l.lockoutDuration(level)),
+	})
+}
+
+// lockoutDuration 第 level 次连续锁定的时长：基础时长 × 2^(level-1)，不超过上限
+func (l *LoginLimiter) lockoutDuration(level int) time.Duration {
+	duration := l.config.BaseLockout
+	for i := 1; i < level && duration < l.config.MaxLockout; i++ {
+		duration *= 2
+	}
+	if duration > l.config.MaxLockout {
+		duration = l.config.MaxLockout
+	}
+	return duration
+}
+
+// GetAttempts 查询登录尝试审计记录
+func (l *LoginLimiter) GetAttempts(username, ip string, beforeID, limit int) ([]*models.LoginAttempt, error) {
+	return l.attemptRepo.GetAttempts(NormalizeLoginUsername(username), ip, beforeID, limit)
+}
+
+// StartPruneJob 启动登录审计清理任务（启动时立即执行一次，之后按间隔执行）
+func (l *LoginLimiter) StartPruneJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			count, err := l.attemptRepo.PruneBefore(time.Now().Add(-loginAttemptRetention))
+			if err != nil {
+				fmt.Printf("[ERROR] Login attempt prune failed: %v\n", err)
+			} else if count > 0 {
+				fmt.Printf("[INFO] Login attempt prune removed %d records\n", count)
+			}
+			<-ticker.C
+		}
+	}()
+}
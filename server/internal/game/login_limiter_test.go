@@ -0,0 +1,177 @@
+package game
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupLoginLimiterTest(t *testing.T) (*LoginLimiter, func()) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	limiter := NewLoginLimiter(LoginLimiterConfig{
+		Window:              10 * time.Minute,
+		MaxIPFailures:       6,
+		MaxUsernameFailures: 3,
+		BaseLockout:         time.Minute,
+		MaxLockout:          5 * time.Minute,
+		LevelResetAfter:     time.Hour,
+	})
+	return limiter, func() { database.TeardownTestDB(testDB) }
+}
+
+// recordLoginAttempt 预占并立即结算一次登录尝试
+func recordLoginAttempt(t *testing.T, limiter *LoginLimiter, ip, username, result string, now time.Time) {
+	attemptID, _, err := limiter.Begin(ip, username, "", now)
+	assert.NoError(t, err)
+	assert.NoError(t, limiter.Finish(attemptID, ip, username, result, now))
+}
+
+func TestLoginLimiter_UsernameBackoff(t *testing.T) {
+	limiter, cleanup := setupLoginLimiterTest(t)
+	defer cleanup()
+	now := time.Now()
+
+	fail := func(ip, username string) {
+		recordLoginAttempt(t, limiter, ip, username, models.LoginResultFailure, now)
+	}
+
+	fail("1.1.1.1", "Arthas")
+	fail("1.1.1.2", "arthas ")
+	_, err := limiter.Check("1.1.1.3", "ARTHAS", now)
+	assert.NoError(t, err)
+
+	// 第3次失败（不同IP、大小写不同也计入同一用户名）触发1分钟锁定
+	fail("1.1.1.3", "arthas")
+	retryAfter, err := limiter.Check("9.9.9.9", "Arthas", now)
+	assert.ErrorIs(t, err, ErrLoginLocked)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	// 锁定到期后窗口内仍有失败记录，再次失败立即锁定且时长翻倍，直至上限
+	for _, expected := range []time.Duration{2 * time.Minute, 4 * time.Minute, 5 * time.Minute} {
+		now = now.Add(retryAfter)
+		_, err = limiter.Check("1.1.1.1", "arthas", now)
+		assert.NoError(t, err)
+		fail("1.1.1.1", "arthas")
+		retryAfter, err = limiter.Check("1.1.1.1", "arthas", now)
+		assert.ErrorIs(t, err, ErrLoginLocked)
+		assert.Equal(t, expected, retryAfter)
+	}
+
+	// 成功登录清除用户名锁定与失败计数
+	now = now.Add(retryAfter)
+	recordLoginAttempt(t, limiter, "1.1.1.1", "arthas", models.LoginResultSuccess, now)
+	fail("1.1.1.1", "arthas")
+	_, err = limiter.Check("1.1.1.1", "arthas", now)
+	assert.NoError(t, err)
+
+	// 成功登录后再次锁定从基础时长重新计算
+	now = now.Add(2 * time.Hour)
+	for i := 0; i < 3; i++ {
+		fail("2.2.2.2", "arthas")
+	}
+	retryAfter, err = limiter.Check("2.2.2.2", "arthas", now)
+	assert.ErrorIs(t, err, ErrLoginLocked)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	attempts, err := limiter.GetAttempts("Arthas", "", 0, 100)
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 11)
+	assert.Equal(t, "2.2.2.2", attempts[0].IPAddress)
+}
+
+func TestLoginLimiter_IPLockout(t *testing.T) {
+	limiter, cleanup := setupLoginLimiterTest(t)
+	defer cleanup()
+	now := time.Now()
+
+	// 同一IP尝试多个用户名，达到IP上限后整个IP被锁定
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		recordLoginAttempt(t, limiter, "3.3.3.3", name, models.LoginResultFailure, now.Add(time.Duration(i)*time.Second))
+	}
+	_, err := limiter.Check("3.3.3.3", "f", now)
+	assert.NoError(t, err)
+
+	// 成功登录不解除IP计数
+	recordLoginAttempt(t, limiter, "3.3.3.3", "mine", models.LoginResultSuccess, now)
+	recordLoginAttempt(t, limiter, "3.3.3.3", "f", models.LoginResultFailure, now)
+	_, err = limiter.Check("3.3.3.3", "mine", now)
+	assert.ErrorIs(t, err, ErrLoginLocked)
+	_, err = limiter.Check("4.4.4.4", "mine", now)
+	assert.NoError(t, err)
+
+	// 超出滑动窗口的失败不再计入
+	later := now.Add(11 * time.Minute)
+	recordLoginAttempt(t, limiter, "3.3.3.3", "g", models.LoginResultFailure, later)
+	_, err = limiter.Check("3.3.3.3", "g", later)
+	assert.NoError(t, err)
+
+	// 上次锁定到期后长时间未再锁定，锁定等级归零
+	later = now.Add(2 * time.Hour)
+	for _, name := range []string{"h", "i", "j", "k", "l", "m"} {
+		recordLoginAttempt(t, limiter, "3.3.3.3", name, models.LoginResultFailure, later)
+	}
+	retryAfter, err := limiter.Check("3.3.3.3", "n", later)
+	assert.ErrorIs(t, err, ErrLoginLocked)
+	assert.Equal(t, time.Minute, retryAfter)
+}
+
+func TestLoginLimiter_ConcurrentBurst(t *testing.T) {
+	limiter, cleanup := setupLoginLimiterTest(t)
+	defer cleanup()
+	// 内存数据库每个连接相互独立，并发请求需共用同一连接
+	database.DB.SetMaxOpenConns(1)
+	now := time.Now()
+
+	// 凭据校验完成前同时到达的请求，只有上限内的能预占成功
+	var wg sync.WaitGroup
+	reserved := make(chan int, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attemptID, retryAfter, err := limiter.Begin("5.5.5.5", "Jaina", "", now)
+			if err != nil {
+				assert.ErrorIs(t, err, ErrLoginLocked)
+				assert.Equal(t, time.Second, retryAfter)
+				return
+			}
+			reserved <- attemptID
+		}()
+	}
+	wg.Wait()
+	close(reserved)
+
+	ids := make([]int, 0)
+	for id := range reserved {
+		ids = append(ids, id)
+	}
+	assert.Len(t, ids, 3)
+
+	// 未进行凭据校验的预占不计入，释放出的名额可再次预占
+	assert.NoError(t, limiter.Finish(ids[0], "5.5.5.5", "Jaina", "", now))
+	assert.NoError(t, limiter.Finish(ids[1], "5.5.5.5", "Jaina", models.LoginResultFailure, now))
+	assert.NoError(t, limiter.Finish(ids[2], "5.5.5.5", "Jaina", models.LoginResultFailure, now))
+	_, err := limiter.Check("5.5.5.5", "jaina", now)
+	assert.NoError(t, err)
+	recordLoginAttempt(t, limiter, "5.5.5.6", "jaina", models.LoginResultFailure, now)
+
+	retryAfter, err := limiter.Check("7.7.7.7", "jaina", now)
+	assert.ErrorIs(t, err, ErrLoginLocked)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	attempts, err := limiter.GetAttempts("jaina", "", 0, 100)
+	assert.NoError(t, err)
+	results := map[string]int{}
+	for _, a := range attempts {
+		results[a.Result]++
+	}
+	assert.Equal(t, map[string]int{models.LoginResultFailure: 3, models.LoginResultLocked: 17}, results)
+}
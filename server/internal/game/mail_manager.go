@@ -0,0 +1,449 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 邮箱错误
+var (
+	ErrMailRecipientNotFound = errors.New("mail recipient not found")
+	ErrMailToSelf            = errors.New("cannot send mail to yourself")
+	ErrInvalidMailSubject    = errors.New("subject must be 1-64 characters")
+	ErrMailBodyTooLong       = errors.New("mail body must be at most 1000 characters")
+	ErrTooManyAttachments    = errors.New("too many attachments")
+	ErrInvalidMailGold       = errors.New("invalid gold amount")
+	ErrCODWithoutAttachment  = errors.New("cash on delivery requires at least one attachment")
+	ErrMailNotRecipient      = errors.New("mail does not belong to you")
+)
+
+const (
+	maxMailSubjectLength = 64
+	maxMailBodyLength    = 1000
+	maxMailAttachments   = 6                   // 每封邮件最多附件数
+	codMailExpiry        = 3 * 24 * time.Hour  // 货到付款邮件保留时间
+	returnedMailExpiry   = 30 * 24 * time.Hour // 退信保留时间
+	mailboxLimit         = 100                 // 收件箱显示上限
+)
+
+// SystemMail 系统邮件（拍卖行、荣誉商店、GM补偿等）
+type SystemMail struct {
+	RecipientID  int
+	SenderName   string // 为空时显示为"系统"
+	Type         string // system/auction
+	Subject      string
+	Body         string
+	Gold         int
+	EquipmentIDs []int // 投递时装备归属转给收件人，领取前处于托管状态
+}
+
+// MailManager 邮箱管理器 - 异步投递金币与装备
+type MailManager struct {
+	mu            sync.Mutex
+	mailRepo      *repository.MailRepository
+	userRepo      *repository.UserRepository
+	equipmentRepo *repository.EquipmentRepository
+	economyMgr    *EconomyManager
+	eventHub      *EventHub
+}
+
+// NewMailManager 创建邮箱管理器
+func NewMailManager() *MailManager {
+	return &MailManager{
+		mailRepo:      repository.NewMailRepository(),
+		userRepo:      repository.NewUserRepository(),
+		equipmentRepo: repository.NewEquipmentRepository(),
+		economyMgr:    NewEconomyManager(),
+		eventHub:      GetEventHub(),
+	}
+}
+
+// 全局邮箱管理器实例
+var mailManager *MailManager
+var mailOnce sync.Once
+
+// GetMailManager 获取邮箱管理器单例
+func GetMailManager() *MailManager {
+	mailOnce.Do(func() {
+		mailManager = NewMailManager()
+	})
+	return mailManager
+}
+
+// ═══════════════════════════════════════════════════════════
+// 发送邮件
+// ═══════════════════════════════════════════════════════════
+
+// SendMail 玩家发送邮件（金币立即扣除，附件装备托管至领取或退回）
+func (mm *MailManager) SendMail(senderID int, recipientName, subject, body string, gold int, equipmentIDs []int, codAmount int) (*models.Mail, error) {
+	subject = strings.TrimSpace(subject)
+	if subject == "" || utf8.RuneCountInString(subject) > maxMailSubjectLength {
+		return nil, ErrInvalidMailSubject
+	}
+	if utf8.RuneCountInString(body) > maxMailBodyLength {
+		return nil, ErrMailBodyTooLong
+	}
+	if len(equipmentIDs) > maxMailAttachments {
+		return nil, ErrTooManyAttachments
+	}
+	if gold < 0 || codAmount < 0 {
+		return nil, ErrInvalidMailGold
+	}
+	if codAmount > 0 && len(equipmentIDs) == 0 {
+		return nil, ErrCODWithoutAttachment
+	}
+
+	sender, err := mm.userRepo.GetByID(senderID)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := mm.userRepo.GetByUsername(recipientName)
+	if err != nil || recipient == nil {
+		return nil, ErrMailRecipientNotFound
+	}
+	if recipient.ID == senderID {
+		return nil, ErrMailToSelf
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	now := time.Now()
+	expiresAt := now.Add(repository.DefaultMailExpiry)
+	if codAmount > 0 {
+		expiresAt = now.Add(codMailExpiry)
+	}
+
+	sent, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.Mail, error) {
+		attachments := make([]int, 0, len(equipmentIDs))
+		seen := make(map[int]bool, len(equipmentIDs))
+		for _, equipmentID := range equipmentIDs {
+			if seen[equipmentID] {
+				continue
+			}
+			seen[equipmentID] = true
+			if _, err := mm.equipmentRepo.CheckTradableTx(tx, senderID, equipmentID); err != nil {
+				return nil, err
+			}
+			attachments = append(attachments, equipmentID)
+		}
+		mail, err := mm.mailRepo.SendMailTx(tx, &models.Mail{
+			RecipientID: recipient.ID,
+			SenderID:    &senderID,
+			SenderName:  sender.Username,
+			Type:        "player",
+			Subject:     subject,
+			Body:        body,
+			Gold:        gold,
+			CODAmount:   codAmount,
+			CreatedAt:   now,
+			ExpiresAt:   expiresAt,
+		}, attachments)
+		if err != nil {
+			return nil, err
+		}
+		if err := mm.economyMgr.SpendGoldTx(tx, senderID, gold,
+			repository.GoldReasonMailSend, repository.NewGoldRef("mail", mail.ID)); err != nil {
+			return nil, err
+		}
+		return mail, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	mm.NotifyMailbox(recipient.ID)
+	return sent, nil
+}
+
+// SendSystemMail 发送系统邮件（如GM补偿）
+func (mm *MailManager) SendSystemMail(mail SystemMail) (*models.Mail, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	sent, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.Mail, error) {
+		return mm.DeliverTx(tx, mail)
+	})
+	if err != nil {
+		return nil, err
+	}
+	mm.NotifyMailbox(mail.RecipientID)
+	return sent, nil
+}
+
+// DeliverTx 在调用方事务中投递系统邮件，供拍卖行等经济系统使用
+// 调用方在事务提交后负责调用 NotifyMailbox
+func (mm *MailManager) DeliverTx(tx *sql.Tx, mail SystemMail) (*models.Mail, error) {
+	if mail.SenderName == "" {
+		mail.SenderName = "系统"
+	}
+	if mail.Type == "" {
+		mail.Type = "system"
+	}
+	for _, equipmentID := range mail.EquipmentIDs {
+		if err := mm.equipmentRepo.TransferTx(tx, equipmentID, mail.RecipientID); err != nil {
+			return nil, err
+		}
+	}
+	return mm.mailRepo.SendMailTx(tx, &models.Mail{
+		RecipientID: mail.RecipientID,
+		SenderName:  mail.SenderName,
+		Type:        mail.Type,
+		Subject:     mail.Subject,
+		Body:        mail.Body,
+		Gold:        mail.Gold,
+		CreatedAt:   time.Now(),
+	}, mail.EquipmentIDs)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 收件箱
+// ═══════════════════════════════════════════════════════════
+
+// GetMailbox 获取收件箱
+func (mm *MailManager) GetMailbox(userID int) ([]*models.Mail, error) {
+	return mm.mailRepo.GetMailbox(userID, time.Now(), mailboxLimit)
+}
+
+// CountUnread 获取未读邮件数
+func (mm *MailManager) CountUnread(userID int) (int, error) {
+	return mm.mailRepo.CountUnread(userID, time.Now())
+}
+
+// ReadMail 阅读邮件（标记为已读）
+func (mm *MailManager) ReadMail(userID, mailID int) (*models.Mail, error) {
+	mail, err := mm.mailRepo.GetMail(mailID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMailAccess(mail, userID, time.Now()); err != nil {
+		return nil, err
+	}
+	if !mail.IsRead {
+		if err := mm.mailRepo.MarkRead(mailID, time.Now()); err != nil {
+			return nil, err
+		}
+		return mm.mailRepo.GetMail(mailID)
+	}
+	return mail, nil
+}
+
+// ClaimMail 领取邮件中的金币与附件（货到付款邮件需先支付，款项以邮件寄给发件人）
+func (mm *MailManager) ClaimMail(userID, mailID int) (*models.Mail, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	now := time.Now()
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.Mail, error) {
+		mail, err := mm.mailRepo.GetMailTx(tx, mailID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkMailAccess(mail, userID, now); err != nil {
+			return nil, err
+		}
+		if !mail.HasUnclaimedContent() {
+			return nil, repository.ErrMailAlreadyClaimed
+		}
+
+		if mail.CODAmount > 0 {
+			if err := mm.economyMgr.SpendGoldTx(tx, userID, mail.CODAmount,
+				repository.GoldReasonMailCOD, repository.NewGoldRef("mail", mail.ID)); err != nil {
+				return nil, err
+			}
+			if mail.SenderID != nil {
+				if _, err := mm.mailRepo.SendMailTx(tx, &models.Mail{
+					RecipientID: *mail.SenderID,
+					SenderName:  mail.RecipientName,
+					Type:        "cod_payment",
+					Subject:     fmt.Sprintf("货到付款：%s", mail.Subject),
+					Gold:        mail.CODAmount,
+					CreatedAt:   now,
+				}, nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if err := mm.deliverContentTx(tx, mail); err != nil {
+			return nil, err
+		}
+		if err := mm.mailRepo.MarkClaimedTx(tx, mail.ID, now); err != nil {
+			return nil, err
+		}
+		return mm.mailRepo.GetMailTx(tx, mail.ID)
+	})
+}
+
+// DeleteMail 删除邮件（有未领取附件时不能删除）
+func (mm *MailManager) DeleteMail(userID, mailID int) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	now := time.Now()
+	return repository.WithTransaction(func(tx *sql.Tx) error {
+		mail, err := mm.mailRepo.GetMailTx(tx, mailID)
+		if err != nil {
+			return err
+		}
+		if mail.RecipientID != userID {
+			return ErrMailNotRecipient
+		}
+		if mail.Status != "active" {
+			return repository.ErrMailNotActive
+		}
+		if mail.HasUnclaimedContent() {
+			return repository.ErrMailHasAttachments
+		}
+		return mm.mailRepo.CloseMailTx(tx, mail.ID, "deleted", now)
+	})
+}
+
+// ExpireMails 处理到期邮件：未领取的玩家邮件退回发件人；
+// 系统邮件与退信没有可退回的对象，到期时内容直接发放给收件人
+func (mm *MailManager) ExpireMails(now time.Time) (int, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	ids, err := mm.mailRepo.GetDueMailIDs(now)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, id := range ids {
+		processed, returnedTo := false, 0
+		err := repository.WithTransaction(func(tx *sql.Tx) error {
+			mail, err := mm.mailRepo.GetMailTx(tx, id)
+			if err != nil {
+				return err
+			}
+			if mail.Status != "active" || mail.ExpiresAt.After(now) {
+				return nil
+			}
+			processed = true
+
+			if !mail.HasUnclaimedContent() {
+				return mm.mailRepo.CloseMailTx(tx, mail.ID, "expired", now)
+			}
+			if mail.SenderID != nil && mail.Type == "player" {
+				returnedTo = *mail.SenderID
+				return mm.returnToSenderTx(tx, mail, now)
+			}
+			if err := mm.deliverContentTx(tx, mail); err != nil {
+				return err
+			}
+			return mm.mailRepo.CloseMailTx(tx, mail.ID, "expired", now)
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to expire mail %d: %w", id, err)
+		}
+		if processed {
+			count++
+		}
+		if returnedTo != 0 {
+			mm.NotifyMailbox(returnedTo)
+		}
+	}
+	return count, nil
+}
+
+// NotifyMailbox 向在线玩家推送收件箱变化（在投递邮件的事务提交后调用）
+func (mm *MailManager) NotifyMailbox(userIDs ...int) {
+	if mm.eventHub == nil {
+		return
+	}
+	for _, userID := range userIDs {
+		if !mm.eventHub.IsOnline(userID) {
+			continue
+		}
+		unread, err := mm.CountUnread(userID)
+		if err != nil {
+			fmt.Printf("[WARN] Failed to count unread mail for user %d: %v\n", userID, err)
+			continue
+		}
+		mm.eventHub.Publish(userID, EventMail, &models.MailEvent{Unread: unread})
+	}
+}
+
+// StartExpiryJob 启动邮件到期处理任务（启动时立即执行一次，之后按间隔执行）
+func (mm *MailManager) StartExpiryJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			count, err := mm.ExpireMails(time.Now())
+			if err != nil {
+				fmt.Printf("[ERROR] Mail expiry sweep failed: %v\n", err)
+			} else if count > 0 {
+				fmt.Printf("[INFO] Mail expiry sweep processed %d mails\n", count)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// returnToSenderTx 将未领取的金币与附件退回发件人（装备归属始终是发件人，只需转入退信托管）
+func (mm *MailManager) returnToSenderTx(tx *sql.Tx, mail *models.Mail, now time.Time) error {
+	if err := mm.mailRepo.CloseMailTx(tx, mail.ID, "returned", now); err != nil {
+		return err
+	}
+	equipmentIDs := make([]int, 0, len(mail.Attachments))
+	for _, attachment := range mail.Attachments {
+		equipmentIDs = append(equipmentIDs, attachment.EquipmentID)
+	}
+	_, err := mm.mailRepo.SendMailTx(tx, &models.Mail{
+		RecipientID:    *mail.SenderID,
+		SenderName:     mail.RecipientName,
+		Type:           "returned",
+		Subject:        fmt.Sprintf("退信：%s", mail.Subject),
+		Body:           mail.Body,
+		Gold:           mail.Gold,
+		ReturnedFromID: &mail.ID,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(returnedMailExpiry),
+	}, equipmentIDs)
+	return err
+}
+
+// deliverContentTx 将邮件金币与附件发放给收件人
+func (mm *MailManager) deliverContentTx(tx *sql.Tx, mail *models.Mail) error {
+	if mail.Gold > 0 {
+		ref := repository.NewGoldRef("mail", mail.ID)
+		// 退信是发件人自己的金币，不计入累计获得
+		if mail.Type == "returned" {
+			if err := mm.economyMgr.RefundGoldTx(tx, mail.RecipientID, mail.Gold, repository.GoldReasonMailReturn, ref); err != nil {
+				return err
+			}
+		} else if err := mm.economyMgr.ReceiveGoldTx(tx, mail.RecipientID, mail.Gold, repository.GoldReasonMailClaim, ref); err != nil {
+			return err
+		}
+	}
+	for _, attachment := range mail.Attachments {
+		if err := mm.equipmentRepo.TransferTx(tx, attachment.EquipmentID, mail.RecipientID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMailAccess 检查邮件是否属于该玩家且仍在收件箱中
+func checkMailAccess(mail *models.Mail, userID int, now time.Time) error {
+	if mail.RecipientID != userID {
+		return ErrMailNotRecipient
+	}
+	if mail.Status != "active" {
+		return repository.ErrMailNotActive
+	}
+	if !mail.ExpiresAt.After(now) {
+		return repository.ErrMailExpired
+	}
+	return nil
+}
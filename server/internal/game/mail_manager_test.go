@@ -0,0 +1,153 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ═══════════════════════════════════════════════════════════
+// 邮件发送与领取测试
+// ═══════════════════════════════════════════════════════════
+
+func TestMailManager_SendAndClaim(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice", "bob")
+	defer database.TeardownTestDB(testDB)
+	alice, bob := users[0], users[1]
+	mm := NewMailManager()
+
+	_, err := mm.SendMail(alice, "alice", "hi", "", 0, nil, 0)
+	assert.ErrorIs(t, err, ErrMailToSelf)
+	_, err = mm.SendMail(alice, "bob", "", "", 0, nil, 0)
+	assert.ErrorIs(t, err, ErrInvalidMailSubject)
+	_, err = mm.SendMail(alice, "bob", "cod", "", 0, nil, 50)
+	assert.ErrorIs(t, err, ErrCODWithoutAttachment)
+
+	equipmentID := createTradeEquipment(t, alice)
+	mail, err := mm.SendMail(alice, "bob", "礼物", "送你一把剑", 100, []int{equipmentID}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, mail.Attachments, 1)
+	assert.Equal(t, 900, goldOf(t, alice), "邮寄的金币立即扣除")
+
+	escrowed, err := repository.NewAuctionRepository().IsEquipmentEscrowed(equipmentID)
+	assert.NoError(t, err)
+	assert.True(t, escrowed, "邮件附件在领取前处于托管状态")
+
+	unread, err := mm.CountUnread(bob)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, unread)
+
+	err = mm.DeleteMail(bob, mail.ID)
+	assert.ErrorIs(t, err, repository.ErrMailHasAttachments)
+	_, err = mm.ClaimMail(alice, mail.ID)
+	assert.ErrorIs(t, err, ErrMailNotRecipient)
+
+	claimed, err := mm.ClaimMail(bob, mail.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, claimed.ClaimedAt)
+	assert.True(t, claimed.IsRead)
+	assert.Equal(t, 1100, goldOf(t, bob))
+
+	equipment, err := repository.NewEquipmentRepository().GetByID(equipmentID)
+	assert.NoError(t, err)
+	assert.Equal(t, bob, equipment.OwnerID)
+
+	_, err = mm.ClaimMail(bob, mail.ID)
+	assert.ErrorIs(t, err, repository.ErrMailAlreadyClaimed)
+	assert.NoError(t, mm.DeleteMail(bob, mail.ID))
+
+	mails, err := mm.GetMailbox(bob)
+	assert.NoError(t, err)
+	assert.Empty(t, mails)
+}
+
+func TestMailManager_CashOnDelivery(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice", "bob")
+	defer database.TeardownTestDB(testDB)
+	alice, bob := users[0], users[1]
+	mm := NewMailManager()
+
+	equipmentID := createTradeEquipment(t, alice)
+	mail, err := mm.SendMail(alice, "bob", "货到付款", "", 0, []int{equipmentID}, 1500)
+	assert.NoError(t, err)
+
+	_, err = mm.ClaimMail(bob, mail.ID)
+	assert.ErrorIs(t, err, repository.ErrInsufficientGold)
+	equipment, err := repository.NewEquipmentRepository().GetByID(equipmentID)
+	assert.NoError(t, err)
+	assert.Equal(t, alice, equipment.OwnerID, "付款失败时不应交付附件")
+
+	_, err = testDB.Exec(`UPDATE users SET gold = 2000 WHERE id = ?`, bob)
+	assert.NoError(t, err)
+	_, err = mm.ClaimMail(bob, mail.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, goldOf(t, bob))
+
+	mails, err := mm.GetMailbox(alice)
+	assert.NoError(t, err)
+	assert.Len(t, mails, 1)
+	assert.Equal(t, "cod_payment", mails[0].Type)
+	assert.Equal(t, 1500, mails[0].Gold, "货款通过邮件寄给发件人")
+}
+
+// ═══════════════════════════════════════════════════════════
+// 邮件到期测试
+// ═══════════════════════════════════════════════════════════
+
+func TestMailManager_ExpiredMailReturnsToSender(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice", "bob")
+	defer database.TeardownTestDB(testDB)
+	alice, bob := users[0], users[1]
+	mm := NewMailManager()
+
+	equipmentID := createTradeEquipment(t, alice)
+	mail, err := mm.SendMail(alice, "bob", "未领取", "", 200, []int{equipmentID}, 0)
+	assert.NoError(t, err)
+
+	count, err := mm.ExpireMails(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count, "未到期的邮件不应处理")
+
+	count, err = mm.ExpireMails(time.Now().Add(repository.DefaultMailExpiry + time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	original, err := repository.NewMailRepository().GetMail(mail.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "returned", original.Status)
+
+	mails, err := mm.GetMailbox(alice)
+	assert.NoError(t, err)
+	assert.Len(t, mails, 1)
+	assert.Equal(t, "returned", mails[0].Type)
+	assert.Equal(t, "bob", mails[0].SenderName)
+	assert.Equal(t, mail.ID, *mails[0].ReturnedFromID)
+
+	_, err = mm.ClaimMail(alice, mails[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, goldOf(t, alice), "退信金币归还发件人")
+	assert.Equal(t, 1000, goldOf(t, bob))
+
+	escrowed, err := repository.NewAuctionRepository().IsEquipmentEscrowed(equipmentID)
+	assert.NoError(t, err)
+	assert.False(t, escrowed)
+}
+
+func TestMailManager_ExpiredSystemMailIsDelivered(t *testing.T) {
+	testDB, _, users := setupTradingTest(t, "alice")
+	defer database.TeardownTestDB(testDB)
+	alice := users[0]
+	mm := NewMailManager()
+
+	_, err := mm.SendSystemMail(SystemMail{RecipientID: alice, Subject: "补偿", Gold: 300})
+	assert.NoError(t, err)
+
+	count, err := mm.ExpireMails(time.Now().Add(repository.DefaultMailExpiry + time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1300, goldOf(t, alice), "系统邮件无法退回，到期时直接发放")
+}
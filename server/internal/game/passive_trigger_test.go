@@ -402,3 +402,41 @@ func TestPassiveTrigger_MultipleTriggers(t *testing.T) {
 	assert.Equal(t, 2, len(logs)) // 应该产生2条日志
 }
 
+
+func TestPassiveTrigger_OnDamageTakenInPvEHit(t *testing.T) {
+	manager, char, cleanup := setupPassiveTriggerTest(t)
+	defer cleanup()
+
+	// 受到伤害时恢复10%伤害值的生命
+	manager.passiveSkillManager.mu.Lock()
+	manager.passiveSkillManager.characterPassives[char.ID] = []*CharacterPassiveState{
+		{
+			PassiveID:   "test_on_damage_taken_heal",
+			Level:       1,
+			Passive:     &models.PassiveSkill{ID: "test_on_damage_taken_heal", EffectType: "on_damage_taken_heal", EffectValue: 10.0},
+			EffectValue: 10.0,
+		},
+	}
+	manager.passiveSkillManager.mu.Unlock()
+
+	session := &BattleSession{
+		UserID:     1,
+		BattleLogs: make([]models.BattleLog, 0),
+	}
+	manager.sessions[1] = session
+	enemy := &models.Monster{ID: "test_enemy", Name: "测试怪物", HP: 100, MaxHP: 100}
+
+	// 一次怪物攻击只触发一次受到伤害被动
+	logs := make([]models.BattleLog, 0)
+	char.HP = 900
+	damage, _, _ := manager.applyDamageToCharacter(session, char, enemy, 100, "physical", nil, &logs)
+	assert.Equal(t, 100, damage)
+	assert.Equal(t, 810, char.HP)
+
+	// 物理减伤Buff不应减免法术伤害
+	manager.buffManager.ApplyBuff(char.ID, "shield_block", "盾牌格挡", "buff", true, 3, -50, "physical_damage_taken", "")
+	damage, _, _ = manager.applyDamageToCharacter(session, char, enemy, 100, "magic", nil, &logs)
+	assert.Equal(t, 100, damage)
+	damage, _, _ = manager.applyDamageToCharacter(session, char, enemy, 100, "physical", nil, &logs)
+	assert.Equal(t, 50, damage)
+}
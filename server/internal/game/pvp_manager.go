@@ -0,0 +1,553 @@
+package game
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// PVP遭遇战参数
+const (
+	pvpEncounterChance      = 0.15 // 争夺区域每场胜利后遭遇敌对阵营的概率
+	pvpLevelRange           = 5    // 匹配等级范围（按出战角色最高等级）
+	pvpMaxRounds            = 30   // 超过回合数判定平局
+	pvpBaseHonor            = 10   // 基础荣誉奖励
+	pvpControlThreshold     = 20   // 控制积分达到该值后取得控制权
+	pvpMaxControlScore      = 100  // 控制积分上限
+	pvpMaxEfficiencyBonus   = 0.2  // 控制方最大效率加成
+	pvpMaxEfficiencyPenalty = 0.1  // 被控方最大效率惩罚
+	pvpRoundSeconds         = 3    // 每回合折算的战斗时长（秒）
+)
+
+// PvPManager 阵营PVP管理器 - 异步遭遇战匹配、结算与地图控制
+type PvPManager struct {
+	pvpRepo     *repository.PvPRepository
+	charRepo    *repository.CharacterRepository
+	zoneManager *ZoneManager
+	announcer   *AnnouncementManager
+}
+
+// NewPvPManager 创建PVP管理器
+func NewPvPManager(zoneManager *ZoneManager) *PvPManager {
+	return &PvPManager{
+		pvpRepo:     repository.NewPvPRepository(),
+		charRepo:    repository.NewCharacterRepository(),
+		zoneManager: zoneManager,
+		announcer:   GetAnnouncementManager(),
+	}
+}
+
+// pvpCombatant PVP战斗参与者（角色快照）
+type pvpCombatant struct {
+	char        *models.Character
+	view        *models.Monster // 敌方视角下的目标快照（作为PVE行动流程中的目标）
+	attacker    bool
+	damageDealt int
+	kills       int
+}
+
+// pvpBattleResult 战斗结果
+type pvpBattleResult struct {
+	attackers []*pvpCombatant
+	defenders []*pvpCombatant
+	rounds    int
+	winner    int // 1=进攻方 -1=防守方 0=平局
+	logs      []models.BattleLog
+}
+
+// TryEncounter 在争夺区域中按概率触发一次PVP遭遇战，未触发或未匹配到对手时返回nil
+func (pm *PvPManager) TryEncounter(userID int, zone *models.Zone, characters []*models.Character) (*models.PvPEncounter, error) {
+	if zone == nil || zone.Faction != "" {
+		return nil, nil // 只有无阵营归属的区域才会发生PVP
+	}
+	if rand.Float64() >= pvpEncounterChance {
+		return nil, nil
+	}
+	return pm.StartEncounter(userID, zone.ID, characters)
+}
+
+// StartEncounter 为玩家队伍匹配敌对阵营队伍快照并结算遭遇战
+func (pm *PvPManager) StartEncounter(userID int, zoneID string, characters []*models.Character) (*models.PvPEncounter, error) {
+	attackers := pvpEligible(characters)
+	if len(attackers) == 0 {
+		return nil, nil
+	}
+
+	faction := attackers[0].Faction
+	opponentFaction := opposingFaction(faction)
+	if opponentFaction == "" {
+		return nil, nil
+	}
+
+	level := pvpTeamLevel(attackers)
+	defenderUserID, err := pm.pvpRepo.FindOpponentUserID(zoneID, opponentFaction, userID,
+		level-pvpLevelRange, level+pvpLevelRange)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defenderChars, err := pm.charRepo.GetActiveByUserID(defenderUserID)
+	if err != nil {
+		return nil, err
+	}
+	defenders := pvpEligible(defenderChars)
+	if len(defenders) == 0 {
+		return nil, nil
+	}
+
+	result := pm.resolveBattle(attackers, defenders)
+	now := time.Now()
+
+	enc := &models.PvPEncounter{
+		ZoneID:          zoneID,
+		AttackerUserID:  userID,
+		DefenderUserID:  defenderUserID,
+		AttackerFaction: faction,
+		DefenderFaction: opponentFaction,
+		AttackerTeam:    pvpTeamSnapshot(result.attackers),
+		DefenderTeam:    pvpTeamSnapshot(result.defenders),
+		BattleRounds:    result.rounds,
+		BattleDuration:  result.rounds * pvpRoundSeconds,
+		BattleLog:       result.logs,
+		CreatedAt:       now,
+	}
+	for _, c := range result.attackers {
+		enc.AttackerDamageDealt += c.damageDealt
+	}
+	for _, c := range result.defenders {
+		enc.DefenderDamageDealt += c.damageDealt
+	}
+
+	attackerResult, defenderResult := repository.PvPResultDraw, repository.PvPResultDraw
+	attackerHonor, defenderHonor := 0, 0
+	switch result.winner {
+	case 1:
+		winnerID := userID
+		enc.WinnerUserID = &winnerID
+		enc.WinnerFaction = faction
+		enc.HonorReward = CalculatePvPHonor(level, pvpTeamLevel(defenders))
+		attackerResult, defenderResult = repository.PvPResultWin, repository.PvPResultLoss
+		attackerHonor = enc.HonorReward
+	case -1:
+		winnerID := defenderUserID
+		enc.WinnerUserID = &winnerID
+		enc.WinnerFaction = opponentFaction
+		enc.HonorReward = CalculatePvPHonor(pvpTeamLevel(defenders), level)
+		attackerResult, defenderResult = repository.PvPResultLoss, repository.PvPResultWin
+		defenderHonor = enc.HonorReward
+	}
+
+	participants := []repository.PvPParticipantResult{
+		pvpParticipant(userID, faction, attackerResult, attackerHonor, result.attackers),
+		pvpParticipant(defenderUserID, opponentFaction, defenderResult, defenderHonor, result.defenders),
+	}
+
+	previousFaction := ""
+	control, err := pm.pvpRepo.RecordEncounter(enc, participants, func(control *models.ZoneFactionControl) {
+		previousFaction = control.ControllingFaction
+		ApplyPvPControlResult(control, enc.WinnerFaction, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if pm.zoneManager != nil {
+		pm.zoneManager.SetZoneControl(control)
+	}
+	pm.announceEncounter(enc, previousFaction, control)
+
+	return enc, nil
+}
+
+// announceEncounter 区域易主或胜者连胜达到里程碑时发布全服公告
+func (pm *PvPManager) announceEncounter(enc *models.PvPEncounter, previousFaction string, control *models.ZoneFactionControl) {
+	if pm.announcer == nil || enc.WinnerUserID == nil {
+		return
+	}
+	if control.ControllingFaction != previousFaction && control.ControllingFaction != "neutral" && pm.zoneManager != nil {
+		if zone, err := pm.zoneManager.GetZone(enc.ZoneID); err == nil {
+			pm.announcer.AnnounceZoneCaptured(zone, control.ControllingFaction, enc)
+		}
+	}
+	if honor, err := pm.pvpRepo.GetUserHonor(*enc.WinnerUserID); err == nil && honor != nil {
+		pm.announcer.AnnounceWinStreak(enc, honor.WinStreak)
+	}
+}
+
+// GetZoneControls 获取所有争夺区域的阵营控制状态
+func (pm *PvPManager) GetZoneControls() ([]*models.ZoneFactionControl, error) {
+	zones, err := pm.zoneManager.GetAllZones()
+	if err != nil {
+		return nil, err
+	}
+
+	controls := make([]*models.ZoneFactionControl, 0)
+	for _, zone := range zones {
+		if zone.Faction != "" {
+			continue
+		}
+		control, err := pm.zoneManager.GetZoneControl(zone.ID)
+		if err != nil {
+			return nil, err
+		}
+		controls = append(controls, control)
+	}
+	return controls, nil
+}
+
+// GetEncounters 获取玩家的遭遇战记录
+func (pm *PvPManager) GetEncounters(userID int, limit int) ([]*models.PvPEncounter, error) {
+	return pm.pvpRepo.GetUserEncounters(userID, limit)
+}
+
+// GetEncounter 获取遭遇战详情
+func (pm *PvPManager) GetEncounter(encounterID int) (*models.PvPEncounter, error) {
+	return pm.pvpRepo.GetEncounterByID(encounterID)
+}
+
+// GetHonor 获取玩家荣誉战绩（包含军衔名称）
+func (pm *PvPManager) GetHonor(userID int) (*models.UserHonor, error) {
+	honor, err := pm.pvpRepo.GetUserHonor(userID)
+	if err != nil {
+		return nil, err
+	}
+	return decorateHonor(honor), nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// 战斗结算
+// ═══════════════════════════════════════════════════════════
+
+// newPvPBattleEngine 创建单场PVP使用的战斗系统：与PVE共用行动与承伤流程，
+// 但技能冷却、Buff、被动和策略使用独立实例，也不接入图鉴、物品、统计和实时推送
+func newPvPBattleEngine() *BattleManager {
+	return &BattleManager{
+		sessions:            make(map[int]*BattleSession),
+		skillManager:        NewSkillManager(),
+		buffManager:         NewBuffManager(),
+		passiveSkillManager: NewPassiveSkillManager(),
+		strategyExecutor:    NewStrategyExecutor(),
+		calculator:          NewCalculator(),
+	}
+}
+
+// resolveBattle 使用PVE战斗流程模拟双方满状态快照的对战
+func (pm *PvPManager) resolveBattle(attackerChars, defenderChars []*models.Character) *pvpBattleResult {
+	engine := newPvPBattleEngine()
+	session := &BattleSession{ThreatTable: make(map[string]map[int]int)}
+
+	result := &pvpBattleResult{
+		attackers: pm.prepareCombatants(engine, attackerChars, true),
+		defenders: pm.prepareCombatants(engine, defenderChars, false),
+	}
+
+	for round := 1; round <= pvpMaxRounds; round++ {
+		result.rounds = round
+		session.BattleCount = round
+		session.CurrentBattleRound = round
+
+		// 按速度决定行动顺序
+		order := append(append([]*pvpCombatant{}, result.attackers...), result.defenders...)
+		sort.SliceStable(order, func(i, j int) bool {
+			return engine.calculator.CalculateSpeed(order[i].char) > engine.calculator.CalculateSpeed(order[j].char)
+		})
+
+		for _, actor := range order {
+			if actor.char.HP <= 0 {
+				continue
+			}
+			allies, enemies := result.attackers, result.defenders
+			if !actor.attacker {
+				allies, enemies = result.defenders, result.attackers
+			}
+			aliveEnemies := pvpAlive(enemies)
+			if len(aliveEnemies) == 0 {
+				break
+			}
+			pm.takeTurn(engine, session, actor, pvpAlive(allies), aliveEnemies, &result.logs)
+		}
+
+		attackersAlive, defendersAlive := len(pvpAlive(result.attackers)), len(pvpAlive(result.defenders))
+		if attackersAlive == 0 || defendersAlive == 0 {
+			if defendersAlive == 0 && attackersAlive > 0 {
+				result.winner = 1
+			} else if attackersAlive == 0 && defendersAlive > 0 {
+				result.winner = -1
+			}
+			break
+		}
+	}
+
+	if result.winner == 0 {
+		result.logs = append(result.logs, pvpLog("system", fmt.Sprintf("⏳ 战斗超过 %d 回合，双方平局", pvpMaxRounds), "#cccccc"))
+	}
+	return result
+}
+
+// prepareCombatants 加载角色技能与被动，生成满状态快照（策略在行动时由战斗系统读取）
+func (pm *PvPManager) prepareCombatants(engine *BattleManager, chars []*models.Character, attacker bool) []*pvpCombatant {
+	combatants := make([]*pvpCombatant, 0, len(chars))
+	for _, original := range chars {
+		char := *original
+		char.HP = char.MaxHP
+		if char.ResourceType == "rage" {
+			char.MaxResource = 100
+			char.Resource = 0
+		} else {
+			char.Resource = char.MaxResource
+		}
+		char.Buffs = nil
+
+		engine.skillManager.LoadCharacterSkills(char.ID)
+		engine.passiveSkillManager.LoadCharacterPassiveSkills(char.ID)
+
+		combatants = append(combatants, &pvpCombatant{
+			char:     &char,
+			attacker: attacker,
+			view: &models.Monster{
+				ID:              fmt.Sprintf("pvp_%d", char.ID),
+				Name:            char.Name,
+				Level:           char.Level,
+				Type:            "player",
+				PhysicalAttack:  char.PhysicalAttack,
+				MagicAttack:     char.MagicAttack,
+				PhysicalDefense: char.PhysicalDefense,
+				MagicDefense:    char.MagicDefense,
+			},
+		})
+	}
+	return combatants
+}
+
+// takeTurn 执行一个角色的行动：以敌方角色的快照视图为目标走PVE行动流程，
+// 视图上受到的伤害再经目标角色的承伤流程（减伤、护盾、反击与反射等）结算
+func (pm *PvPManager) takeTurn(engine *BattleManager, session *BattleSession, actor *pvpCombatant, allies, enemies []*pvpCombatant, logs *[]models.BattleLog) {
+	// 被眩晕时跳过本回合
+	if pvpIsStunned(engine, actor.view.ID) {
+		engine.addLog(session, "combat", fmt.Sprintf("%s 处于眩晕状态，无法行动！", actor.char.Name), "#ff00ff")
+		*logs = append(*logs, session.BattleLogs[len(session.BattleLogs)-1])
+		engine.buffManager.TickEnemyDebuffs(actor.view.ID)
+		return
+	}
+
+	views := make([]*models.Monster, len(enemies))
+	for i, e := range enemies {
+		pm.syncView(engine, e)
+		views[i] = e.view
+	}
+	allyChars := make([]*models.Character, len(allies))
+	for i, a := range allies {
+		allyChars[i] = a.char
+	}
+
+	_, damageType, _ := engine.executeCharacterAction(session, actor.char, allyChars, views, logs)
+
+	for _, e := range enemies {
+		damage := e.char.HP - e.view.HP
+		if damage <= 0 || e.char.HP <= 0 {
+			continue
+		}
+		pm.syncView(engine, actor)
+		_, hpBefore, _ := engine.applyDamageToCharacter(session, e.char, actor.view, damage, damageType, nil, logs)
+		if e.char.HP < 0 {
+			e.char.HP = 0
+		}
+		actor.damageDealt += hpBefore - e.char.HP
+		if e.char.HP == 0 {
+			actor.kills++
+			*logs = append(*logs, pvpLog("kill", fmt.Sprintf("💀 %s 被 %s 击败！", e.char.Name, actor.char.Name), "#ff6b6b"))
+		}
+
+		// 反击与反射作用在行动者的视图上
+		if actor.view.HP < actor.char.HP {
+			if actor.view.HP < 0 {
+				actor.view.HP = 0
+			}
+			e.damageDealt += actor.char.HP - actor.view.HP
+			actor.char.HP = actor.view.HP
+			if actor.char.HP == 0 {
+				e.kills++
+				*logs = append(*logs, pvpLog("kill", fmt.Sprintf("💀 %s 被 %s 击败！", actor.char.Name, e.char.Name), "#ff6b6b"))
+				break
+			}
+		}
+	}
+	for _, e := range enemies {
+		pm.syncView(engine, e)
+	}
+
+	// 施加在行动者身上的减益（眩晕等）按其行动结算持续时间
+	engine.buffManager.TickEnemyDebuffs(actor.view.ID)
+}
+
+// syncView 将角色当前状态同步到敌方视角的快照
+func (pm *PvPManager) syncView(engine *BattleManager, c *pvpCombatant) {
+	c.view.HP = c.char.HP
+	c.view.MaxHP = c.char.MaxHP
+	c.view.DodgeRate = engine.calculateCharacterDodgeRate(c.char)
+}
+
+// pvpIsStunned 视图是否处于眩晕状态
+func pvpIsStunned(engine *BattleManager, viewID string) bool {
+	for _, debuff := range engine.buffManager.GetEnemyDebuffs(viewID) {
+		if debuff.Type == "stun" {
+			return true
+		}
+	}
+	return false
+}
+
+// ═══════════════════════════════════════════════════════════
+// 荣誉与地图控制
+// ═══════════════════════════════════════════════════════════
+
+// CalculatePvPHonor 计算胜利荣誉：击败高等级对手获得更多荣誉
+func CalculatePvPHonor(winnerLevel, loserLevel int) int {
+	honor := int(math.Round(float64(pvpBaseHonor) * (1.0 + float64(loserLevel-winnerLevel)*0.1)))
+	if honor < 1 {
+		honor = 1
+	}
+	return honor
+}
+
+// ApplyPvPControlResult 根据胜负更新地图控制积分、控制方和效率修正
+func ApplyPvPControlResult(control *models.ZoneFactionControl, winnerFaction string, at time.Time) {
+	switch winnerFaction {
+	case "alliance":
+		control.AllianceWins++
+		control.ControlScore++
+	case "horde":
+		control.HordeWins++
+		control.ControlScore--
+	}
+	if control.ControlScore > pvpMaxControlScore {
+		control.ControlScore = pvpMaxControlScore
+	}
+	if control.ControlScore < -pvpMaxControlScore {
+		control.ControlScore = -pvpMaxControlScore
+	}
+
+	if total := control.AllianceWins + control.HordeWins; total > 0 {
+		control.AllianceWinRate = float64(control.AllianceWins) / float64(total)
+	}
+
+	switch {
+	case control.ControlScore >= pvpControlThreshold:
+		control.ControllingFaction = "alliance"
+	case control.ControlScore <= -pvpControlThreshold:
+		control.ControllingFaction = "horde"
+	default:
+		control.ControllingFaction = "neutral"
+	}
+
+	if control.ControllingFaction == "neutral" {
+		control.EfficiencyBonus = 0
+		control.EfficiencyPenalty = 0
+	} else {
+		ratio := math.Abs(float64(control.ControlScore)) / float64(pvpMaxControlScore)
+		control.EfficiencyBonus = ratio * pvpMaxEfficiencyBonus
+		control.EfficiencyPenalty = ratio * pvpMaxEfficiencyPenalty
+	}
+
+	control.LastBattleAt = &at
+}
+
+// ═══════════════════════════════════════════════════════════
+// 辅助函数
+// ═══════════════════════════════════════════════════════════
+
+// opposingFaction 获取敌对阵营
+func opposingFaction(faction string) string {
+	switch faction {
+	case "alliance":
+		return "horde"
+	case "horde":
+		return "alliance"
+	default:
+		return ""
+	}
+}
+
+// pvpEligible 过滤出可参战的角色（出战且未死亡）
+func pvpEligible(chars []*models.Character) []*models.Character {
+	eligible := make([]*models.Character, 0, len(chars))
+	for _, c := range chars {
+		if c != nil && c.IsActive && !c.IsDead {
+			eligible = append(eligible, c)
+		}
+	}
+	return eligible
+}
+
+// pvpTeamLevel 队伍等级（取最高等级）
+func pvpTeamLevel(chars []*models.Character) int {
+	level := 0
+	for _, c := range chars {
+		if c.Level > level {
+			level = c.Level
+		}
+	}
+	return level
+}
+
+// pvpAlive 存活的参战者
+func pvpAlive(combatants []*pvpCombatant) []*pvpCombatant {
+	alive := make([]*pvpCombatant, 0, len(combatants))
+	for _, c := range combatants {
+		if c.char.HP > 0 {
+			alive = append(alive, c)
+		}
+	}
+	return alive
+}
+
+// pvpTeamSnapshot 生成队伍快照
+func pvpTeamSnapshot(combatants []*pvpCombatant) []models.PvPTeamMember {
+	team := make([]models.PvPTeamMember, 0, len(combatants))
+	for _, c := range combatants {
+		team = append(team, models.PvPTeamMember{
+			CharacterID: c.char.ID,
+			Name:        c.char.Name,
+			ClassID:     c.char.ClassID,
+			Level:       c.char.Level,
+			MaxHP:       c.char.MaxHP,
+			RemainingHP: c.char.HP,
+		})
+	}
+	return team
+}
+
+// pvpParticipant 汇总一方的战绩变化
+func pvpParticipant(userID int, faction, result string, honor int, team []*pvpCombatant) repository.PvPParticipantResult {
+	p := repository.PvPParticipantResult{
+		UserID:  userID,
+		Faction: faction,
+		Result:  result,
+		Honor:   honor,
+	}
+	for _, c := range team {
+		p.Kills += c.kills
+		p.DamageDealt += c.damageDealt
+		if c.char.HP <= 0 {
+			p.Deaths++
+		}
+	}
+	return p
+}
+
+func pvpLog(logType, message, color string) models.BattleLog {
+	return models.BattleLog{
+		Message:   message,
+		LogType:   logType,
+		Color:     color,
+		CreatedAt: time.Now(),
+	}
+}
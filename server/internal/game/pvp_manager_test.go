@@ -0,0 +1,139 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ═══════════════════════════════════════════════════════════
+// 地图控制测试
+// ═══════════════════════════════════════════════════════════
+
+func TestApplyPvPControlResult(t *testing.T) {
+	now := time.Now()
+
+	t.Run("未达阈值保持中立", func(t *testing.T) {
+		control := &models.ZoneFactionControl{ZoneID: "duskwood", ControllingFaction: "neutral", ControlScore: 18}
+		ApplyPvPControlResult(control, "alliance", now)
+		assert.Equal(t, 19, control.ControlScore)
+		assert.Equal(t, "neutral", control.ControllingFaction)
+		assert.Zero(t, control.EfficiencyBonus)
+		assert.NotNil(t, control.LastBattleAt)
+	})
+
+	t.Run("达到阈值取得控制权", func(t *testing.T) {
+		control := &models.ZoneFactionControl{ZoneID: "duskwood", ControlScore: -19, AllianceWins: 1, HordeWins: 3}
+		ApplyPvPControlResult(control, "horde", now)
+		assert.Equal(t, -20, control.ControlScore)
+		assert.Equal(t, "horde", control.ControllingFaction)
+		assert.InDelta(t, 0.04, control.EfficiencyBonus, 0.0001)   // 20/100 * 0.2
+		assert.InDelta(t, 0.02, control.EfficiencyPenalty, 0.0001) // 20/100 * 0.1
+		assert.InDelta(t, 0.2, control.AllianceWinRate, 0.0001)
+	})
+
+	t.Run("积分不超过上限", func(t *testing.T) {
+		control := &models.ZoneFactionControl{ZoneID: "duskwood", ControlScore: 100}
+		ApplyPvPControlResult(control, "alliance", now)
+		assert.Equal(t, 100, control.ControlScore)
+		assert.InDelta(t, 0.2, control.EfficiencyBonus, 0.0001)
+	})
+
+	t.Run("平局不改变积分", func(t *testing.T) {
+		control := &models.ZoneFactionControl{ZoneID: "duskwood", ControlScore: 5}
+		ApplyPvPControlResult(control, "", now)
+		assert.Equal(t, 5, control.ControlScore)
+	})
+}
+
+func TestZoneManager_GetFactionControlMultiplier(t *testing.T) {
+	zm := &ZoneManager{zones: make(map[string]*models.Zone)}
+	zm.SetZoneControl(&models.ZoneFactionControl{
+		ZoneID: "duskwood", ControllingFaction: "alliance", ControlScore: 50,
+		EfficiencyBonus: 0.1, EfficiencyPenalty: 0.05,
+	})
+
+	assert.InDelta(t, 1.1, zm.GetFactionControlMultiplier("duskwood", "alliance"), 0.0001)
+	assert.InDelta(t, 0.95, zm.GetFactionControlMultiplier("duskwood", "horde"), 0.0001)
+	assert.Equal(t, 1.0, zm.GetFactionControlMultiplier("duskwood", ""))
+}
+
+// ═══════════════════════════════════════════════════════════
+// 荣誉计算测试
+// ═══════════════════════════════════════════════════════════
+
+func TestCalculatePvPHonor(t *testing.T) {
+	assert.Equal(t, 10, CalculatePvPHonor(20, 20))
+	assert.Equal(t, 15, CalculatePvPHonor(20, 25), "击败高等级对手获得更多荣誉")
+	assert.Equal(t, 5, CalculatePvPHonor(25, 20))
+	assert.Equal(t, 1, CalculatePvPHonor(40, 20), "荣誉至少为1")
+}
+
+// ═══════════════════════════════════════════════════════════
+// 战斗结算测试
+// ═══════════════════════════════════════════════════════════
+
+func newPvPTestCharacter(id int, name string, physicalAttack, maxHP int) *models.Character {
+	return &models.Character{
+		ID:              id,
+		UserID:          id,
+		Name:            name,
+		ClassID:         "warrior",
+		Level:           10,
+		HP:              maxHP,
+		MaxHP:           maxHP,
+		ResourceType:    "rage",
+		MaxResource:     100,
+		PhysicalAttack:  physicalAttack,
+		PhysicalDefense: 10,
+		MagicDefense:    10,
+	}
+}
+
+func TestPvPManager_ResolveBattle(t *testing.T) {
+	testDB, err := database.SetupTestDB()
+	require.NoError(t, err)
+	defer database.TeardownTestDB(testDB)
+
+	pm := &PvPManager{}
+	attacker := newPvPTestCharacter(9001, "强者", 200, 2000)
+	defender := newPvPTestCharacter(9002, "弱者", 20, 300)
+
+	result := pm.resolveBattle([]*models.Character{attacker}, []*models.Character{defender})
+
+	assert.Equal(t, 1, result.winner)
+	assert.Zero(t, result.defenders[0].char.HP)
+	assert.Equal(t, 1, result.attackers[0].kills)
+	assert.Equal(t, 300, result.attackers[0].damageDealt, "伤害统计不超过目标生命值")
+	assert.Equal(t, 2000-result.attackers[0].char.HP, result.defenders[0].damageDealt)
+	assert.Equal(t, 2000, attacker.HP, "快照不修改原角色")
+	assert.NotEmpty(t, result.logs)
+}
+
+func TestPvPManager_TakeTurnUsesDefenderBuffs(t *testing.T) {
+	testDB, err := database.SetupTestDB()
+	require.NoError(t, err)
+	defer database.TeardownTestDB(testDB)
+
+	pm := &PvPManager{}
+	engine := newPvPBattleEngine()
+	session := &BattleSession{ThreatTable: make(map[string]map[int]int)}
+	attackers := pm.prepareCombatants(engine, []*models.Character{newPvPTestCharacter(9001, "进攻者", 100, 1000)}, true)
+	defenders := pm.prepareCombatants(engine, []*models.Character{newPvPTestCharacter(9002, "防守者", 100, 1000)}, false)
+
+	// 防守方开启盾牌反射：承受的伤害按比例反射给进攻方
+	engine.buffManager.ApplyBuff(9002, "shield_reflection", "盾牌反射", "buff", true, 5, 50.0, "reflect", "")
+
+	var logs []models.BattleLog
+	pm.takeTurn(engine, session, attackers[0], attackers, defenders, &logs)
+
+	assert.Less(t, defenders[0].char.HP, 1000)
+	assert.Equal(t, 1000-defenders[0].char.HP, attackers[0].damageDealt)
+	assert.Less(t, attackers[0].char.HP, 1000, "反射伤害作用到进攻方角色")
+	assert.Equal(t, 1000-attackers[0].char.HP, defenders[0].damageDealt)
+}
@@ -0,0 +1,224 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 分解错误
+var (
+	ErrSalvageNoCharacter = errors.New("character not found")
+	ErrSalvageNoSelection = errors.New("select equipment ids or a filter to salvage")
+	ErrSalvageTooMany     = errors.New("too many equipment pieces selected")
+	ErrNothingToSalvage   = errors.New("no salvageable equipment matched")
+)
+
+// maxSalvageBatch 单次分解的装备数量上限
+const maxSalvageBatch = 100
+
+// 分解产出的强化材料（物品子类型与 Material.Type 一致）
+const (
+	salvageDust       = "strange_dust"         // 所有装备
+	salvageBase       = "reforging_powder"     // 优秀及以上：base
+	salvageCatalyst   = "minor_catalyst"       // 每条词缀：catalyst
+	salvageEssence    = "lesser_magic_essence" // 精良及以上：essence
+	salvageProtection = "rune_of_warding"      // 史诗及以上：protection
+)
+
+// salvageMaterialOrder 产出材料的展示顺序
+var salvageMaterialOrder = []string{salvageDust, salvageBase, salvageCatalyst, salvageEssence, salvageProtection}
+
+// salvageQualityTier 品质等级（决定高级材料的产量）
+var salvageQualityTier = map[string]int{
+	"common":    0,
+	"uncommon":  1,
+	"rare":      2,
+	"epic":      3,
+	"legendary": 4,
+	"mythic":    5,
+}
+
+// SalvageManager 装备分解管理器 - 将背包中未锁定的装备分解为强化材料
+type SalvageManager struct {
+	mu            sync.Mutex
+	salvageRepo   *repository.SalvageRepository
+	equipmentRepo *repository.EquipmentRepository
+	charRepo      *repository.CharacterRepository
+}
+
+// NewSalvageManager 创建分解管理器
+func NewSalvageManager() *SalvageManager {
+	return &SalvageManager{
+		salvageRepo:   repository.NewSalvageRepository(),
+		equipmentRepo: repository.NewEquipmentRepository(),
+		charRepo:      repository.NewCharacterRepository(),
+	}
+}
+
+// 全局分解管理器实例
+var salvageManager *SalvageManager
+var salvageOnce sync.Once
+
+// GetSalvageManager 获取分解管理器单例
+func GetSalvageManager() *SalvageManager {
+	salvageOnce.Do(func() {
+		salvageManager = NewSalvageManager()
+	})
+	return salvageManager
+}
+
+// Preview 预览分解产出（不修改任何数据）
+func (sm *SalvageManager) Preview(userID int, equipmentIDs []int, filter *models.SalvageFilter) (*models.SalvageReport, error) {
+	return sm.buildReport(userID, equipmentIDs, filter)
+}
+
+// Salvage 分解装备，材料放入指定角色背包；不能分解的装备会在 Skipped 中说明原因
+func (sm *SalvageManager) Salvage(userID, characterID int, equipmentIDs []int, filter *models.SalvageFilter) (*models.SalvageReport, error) {
+	char, err := sm.charRepo.GetByID(characterID)
+	if err != nil || char == nil || char.UserID != userID {
+		return nil, ErrSalvageNoCharacter
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	report, err := sm.buildReport(userID, equipmentIDs, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Salvaged) == 0 {
+		return nil, ErrNothingToSalvage
+	}
+	report.CharacterID = characterID
+
+	err = repository.WithTransaction(func(tx *sql.Tx) error {
+		for _, entry := range report.Salvaged {
+			// 事务内再次校验，防止预览后装备被穿戴、锁定或上架
+			if _, err := sm.equipmentRepo.CheckTradableTx(tx, userID, entry.EquipmentID); err != nil {
+				return err
+			}
+			if err := sm.salvageRepo.DeleteEquipmentTx(tx, entry.EquipmentID); err != nil {
+				return err
+			}
+		}
+		for _, material := range report.Materials {
+			if err := sm.salvageRepo.AddMaterialTx(tx, characterID, material.ItemID, material.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// SalvageYield 计算单件装备的分解产出：粉尘随等级增加，高级材料随品质与词缀数量增加
+func SalvageYield(quality string, level, affixCount int, hasLegendary bool) []models.SalvageMaterial {
+	tier := salvageQualityTier[quality]
+	amounts := map[string]int{
+		salvageDust:     1 + level/10,
+		salvageCatalyst: affixCount,
+	}
+	if tier >= 1 {
+		amounts[salvageBase] = tier + level/20
+	}
+	if tier >= 2 {
+		amounts[salvageEssence] = tier - 1
+	}
+	if tier >= 3 {
+		amounts[salvageProtection] = tier - 2
+		if hasLegendary {
+			amounts[salvageProtection]++
+		}
+	}
+	return orderedSalvageMaterials(amounts)
+}
+
+// buildReport 校验选择并计算每件装备的产出
+func (sm *SalvageManager) buildReport(userID int, equipmentIDs []int, filter *models.SalvageFilter) (*models.SalvageReport, error) {
+	if len(equipmentIDs) == 0 && filter == nil {
+		return nil, ErrSalvageNoSelection
+	}
+	if len(equipmentIDs) > maxSalvageBatch {
+		return nil, ErrSalvageTooMany
+	}
+	if len(equipmentIDs) == 0 && len(filter.Qualities) == 0 {
+		defaults := *filter
+		defaults.Qualities = []string{"common", "uncommon"}
+		filter = &defaults
+	}
+
+	candidates, err := sm.salvageRepo.GetCandidates(userID, equipmentIDs, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SalvageReport{
+		Salvaged: make([]*models.SalvageEntry, 0),
+		Skipped:  make([]*models.SalvageEntry, 0),
+	}
+	found := make(map[int]bool, len(candidates))
+	totals := make(map[string]int)
+	for _, c := range candidates {
+		found[c.EquipmentID] = true
+		entry := &models.SalvageEntry{
+			EquipmentID: c.EquipmentID,
+			ItemID:      c.ItemID,
+			ItemName:    c.ItemName,
+			Quality:     c.Quality,
+			Level:       c.Level,
+			AffixCount:  c.AffixCount,
+			Materials:   SalvageYield(c.Quality, c.Level, c.AffixCount, c.HasLegendary),
+		}
+		switch {
+		case c.Locked:
+			entry.Reason = repository.ErrEquipmentLocked.Error()
+		case c.Equipped:
+			entry.Reason = repository.ErrEquipmentEquipped.Error()
+		case c.Escrowed:
+			entry.Reason = repository.ErrEquipmentInEscrow.Error()
+		}
+		if entry.Reason != "" {
+			report.Skipped = append(report.Skipped, entry)
+			continue
+		}
+		if len(report.Salvaged) >= maxSalvageBatch {
+			break
+		}
+		report.Salvaged = append(report.Salvaged, entry)
+		for _, material := range entry.Materials {
+			totals[material.ItemID] += material.Quantity
+		}
+	}
+
+	// 指定的装备不存在或不属于该用户
+	for _, id := range equipmentIDs {
+		if !found[id] {
+			found[id] = true
+			report.Skipped = append(report.Skipped, &models.SalvageEntry{
+				EquipmentID: id,
+				Reason:      repository.ErrEquipmentNotOwned.Error(),
+			})
+		}
+	}
+
+	report.Materials = orderedSalvageMaterials(totals)
+	return report, nil
+}
+
+// orderedSalvageMaterials 按固定顺序输出非零的材料数量
+func orderedSalvageMaterials(amounts map[string]int) []models.SalvageMaterial {
+	materials := make([]models.SalvageMaterial, 0, len(amounts))
+	for _, itemID := range salvageMaterialOrder {
+		if amounts[itemID] > 0 {
+			materials = append(materials, models.SalvageMaterial{ItemID: itemID, Quantity: amounts[itemID]})
+		}
+	}
+	return materials
+}
@@ -0,0 +1,104 @@
+package game
+
+import (
+	"testing"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSalvageYield(t *testing.T) {
+	assert.Equal(t, []models.SalvageMaterial{{ItemID: salvageDust, Quantity: 1}},
+		SalvageYield("common", 5, 0, false))
+	assert.Equal(t, []models.SalvageMaterial{
+		{ItemID: salvageDust, Quantity: 3},
+		{ItemID: salvageBase, Quantity: 4},
+		{ItemID: salvageCatalyst, Quantity: 3},
+		{ItemID: salvageEssence, Quantity: 2},
+		{ItemID: salvageProtection, Quantity: 2},
+	}, SalvageYield("epic", 20, 3, true), "品质、等级和词缀都提高产量")
+}
+
+func TestSalvageManager_Salvage(t *testing.T) {
+	testDB, userID, char := setupCraftingTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	_, err := testDB.Exec(`
+		INSERT INTO affixes (id, name, type, rarity, effect_type, min_value, max_value, value_type) VALUES
+			('test_prefix', '测试前缀', 'prefix', 'common', 'stat_mod', 1, 5, 'flat');
+		INSERT INTO items (id, name, type, subtype, stackable, max_stack) VALUES
+			('strange_dust', '奇异之尘', 'material', 'dust', 1, 99),
+			('reforging_powder', '重铸粉末', 'material', 'base', 1, 20),
+			('minor_catalyst', '次级催化剂', 'material', 'catalyst', 1, 20),
+			('lesser_magic_essence', '次级魔法精华', 'material', 'essence', 1, 20),
+			('rune_of_warding', '守护符文', 'material', 'protection', 1, 20);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert salvage config: %v", err)
+	}
+
+	equipmentRepo := repository.NewEquipmentRepository()
+	create := func(quality string, locked bool, characterID *int) int {
+		prefixID := "test_prefix"
+		equipment, err := equipmentRepo.Create(&models.EquipmentInstance{
+			ItemID: "trade_sword", OwnerID: userID, CharacterID: characterID, Slot: "main_hand",
+			Quality: quality, EvolutionStage: 1, PrefixID: &prefixID, IsLocked: locked,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create equipment: %v", err)
+		}
+		return equipment.ID
+	}
+	rareID := create("rare", false, nil)
+	lockedID := create("rare", true, nil)
+	equippedID := create("rare", false, &char.ID)
+	commonID := create("common", false, nil)
+
+	sm := NewSalvageManager()
+	selected := []int{rareID, lockedID, equippedID, 99999}
+
+	preview, err := sm.Preview(userID, selected, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, preview.Salvaged, 1) {
+		assert.Equal(t, rareID, preview.Salvaged[0].EquipmentID)
+	}
+	reasons := make(map[int]string)
+	for _, entry := range preview.Skipped {
+		reasons[entry.EquipmentID] = entry.Reason
+	}
+	assert.Equal(t, repository.ErrEquipmentLocked.Error(), reasons[lockedID])
+	assert.Equal(t, repository.ErrEquipmentEquipped.Error(), reasons[equippedID])
+	assert.Equal(t, repository.ErrEquipmentNotOwned.Error(), reasons[99999])
+	_, err = equipmentRepo.GetByID(rareID)
+	assert.NoError(t, err, "预览不会销毁装备")
+
+	_, err = sm.Salvage(userID+1, char.ID, selected, nil)
+	assert.ErrorIs(t, err, ErrSalvageNoCharacter)
+
+	report, err := sm.Salvage(userID, char.ID, selected, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, preview.Materials, report.Materials, "实际产出与预览一致")
+	for _, material := range report.Materials {
+		_, quantity := inventoryOf(t, testDB, char.ID, material.ItemID)
+		assert.Equal(t, material.Quantity, quantity, material.ItemID)
+	}
+	_, catalysts := inventoryOf(t, testDB, char.ID, salvageCatalyst)
+	assert.Equal(t, 1, catalysts, "每条词缀产出一个催化剂")
+	_, err = equipmentRepo.GetByID(rareID)
+	assert.Error(t, err, "分解后装备被销毁")
+
+	// 按筛选批量分解：默认只匹配普通和优秀品质的背包装备
+	report, err = sm.Salvage(userID, char.ID, nil, &models.SalvageFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, report.Salvaged, 1) {
+		assert.Equal(t, commonID, report.Salvaged[0].EquipmentID)
+	}
+	_, err = equipmentRepo.GetByID(lockedID)
+	assert.NoError(t, err, "锁定的装备不会被分解")
+
+	_, err = sm.Salvage(userID, char.ID, nil, &models.SalvageFilter{Qualities: []string{"rare"}})
+	assert.ErrorIs(t, err, ErrNothingToSalvage, "剩下的精良装备已锁定")
+}
@@ -0,0 +1,192 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"text-wow/internal/config"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// ErrNotEnoughStamina 体力不足
+var ErrNotEnoughStamina = errors.New("not enough stamina")
+
+// StaminaManager 体力管理器 - 战斗/深渊体力消耗、惰性恢复与溢出转化
+type StaminaManager struct {
+	mu            sync.Mutex // 保护配置的延迟加载
+	configLoaded  bool
+	configManager *config.ConfigManager
+	staminaRepo   *repository.StaminaRepository
+	economyMgr    *EconomyManager
+}
+
+// StaminaConsumeResult 体力消耗结果
+type StaminaConsumeResult struct {
+	Allowed      bool                // 是否允许进入战斗（未启用体力系统时始终为true）
+	Cost         int                 // 实际消耗的体力
+	Stamina      *models.UserStamina // 消耗后的体力（未启用时为空）
+	OverflowExp  int                 // 本次领取的溢出经验
+	OverflowGold int                 // 本次领取的溢出金币
+}
+
+// NewStaminaManager 创建体力管理器
+func NewStaminaManager() *StaminaManager {
+	return &StaminaManager{
+		configManager: config.NewConfigManager(),
+		staminaRepo:   repository.NewStaminaRepository(),
+		economyMgr:    NewEconomyManager(),
+	}
+}
+
+// getConfig 获取体力配置（首次使用时从配置表加载，失败时使用默认配置）
+func (sm *StaminaManager) getConfig() config.StaminaConfig {
+	sm.mu.Lock()
+	if !sm.configLoaded {
+		if err := sm.configManager.LoadConfig("stamina"); err != nil {
+			fmt.Printf("[WARN] Failed to load stamina config, using defaults: %v\n", err)
+		}
+		sm.configLoaded = true
+	}
+	sm.mu.Unlock()
+
+	cfg, err := sm.configManager.GetStaminaConfig()
+	if err != nil {
+		return config.DefaultStaminaConfig()
+	}
+	return cfg
+}
+
+// ReloadConfig 重新加载体力配置（用于服务器热切换体力系统）
+func (sm *StaminaManager) ReloadConfig() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.configLoaded = true
+	return sm.configManager.ReloadConfig("stamina")
+}
+
+// IsEnabled 本服是否启用体力系统
+func (sm *StaminaManager) IsEnabled() bool {
+	return sm.getConfig().Enabled
+}
+
+// ApplyStaminaRegen 根据上次恢复时间惰性计算体力恢复，体力已满时恢复量转化为溢出经验/金币
+func ApplyStaminaRegen(stamina *models.UserStamina, cfg config.StaminaConfig, now time.Time) {
+	if cfg.RegenIntervalSeconds <= 0 {
+		return
+	}
+	interval := time.Duration(cfg.RegenIntervalSeconds) * time.Second
+	elapsed := now.Sub(stamina.LastRegenAt)
+	if elapsed < interval {
+		return
+	}
+
+	points := int(elapsed / interval)
+	// 只推进整数个恢复周期，保留未满一个周期的进度
+	stamina.LastRegenAt = stamina.LastRegenAt.Add(time.Duration(points) * interval)
+
+	room := stamina.MaxStamina - stamina.CurrentStamina
+	if room < 0 {
+		room = 0
+	}
+	regen := points
+	if regen > room {
+		regen = room
+	}
+	stamina.CurrentStamina += regen
+
+	overflow := points - regen
+	stamina.OverflowExp += overflow * cfg.OverflowExpPerPoint
+	stamina.OverflowGold += overflow * cfg.OverflowGoldPerPoint
+}
+
+// GetBattleCost 获取区域每场战斗的体力消耗（区域未配置时使用服务器默认值）
+func (sm *StaminaManager) GetBattleCost(zone *models.Zone) int {
+	if zone != nil && zone.StaminaCost != nil {
+		return *zone.StaminaCost
+	}
+	return sm.getConfig().BattleCost
+}
+
+// GetStatus 获取玩家体力状态（同时结算惰性恢复）
+func (sm *StaminaManager) GetStatus(userID int, zone *models.Zone) (*models.StaminaStatus, error) {
+	cfg := sm.getConfig()
+	status := &models.StaminaStatus{
+		Enabled:              cfg.Enabled,
+		RegenIntervalSeconds: cfg.RegenIntervalSeconds,
+		BattleCost:           sm.GetBattleCost(zone),
+	}
+	if !cfg.Enabled {
+		return status, nil
+	}
+
+	now := time.Now()
+	stamina, err := sm.staminaRepo.Update(userID, cfg.MaxStamina, now, func(_ *sql.Tx, s *models.UserStamina) error {
+		ApplyStaminaRegen(s, cfg, now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status.CurrentStamina = stamina.CurrentStamina
+	status.MaxStamina = stamina.MaxStamina
+	status.OverflowExp = stamina.OverflowExp
+	status.OverflowGold = stamina.OverflowGold
+	if stamina.CurrentStamina < stamina.MaxStamina && cfg.RegenIntervalSeconds > 0 {
+		nextRegenAt := stamina.LastRegenAt.Add(time.Duration(cfg.RegenIntervalSeconds) * time.Second)
+		status.NextRegenAt = &nextRegenAt
+	}
+	return status, nil
+}
+
+// HasEnoughForBattle 检查是否有足够体力进行一场战斗（未启用体力系统时始终为true）
+func (sm *StaminaManager) HasEnoughForBattle(userID int, zone *models.Zone) (bool, error) {
+	cfg := sm.getConfig()
+	if !cfg.Enabled {
+		return true, nil
+	}
+	now := time.Now()
+	stamina, err := sm.staminaRepo.Get(userID, cfg.MaxStamina, now)
+	if err != nil {
+		return false, err
+	}
+	ApplyStaminaRegen(stamina, cfg, now)
+	return stamina.CurrentStamina >= sm.GetBattleCost(zone), nil
+}
+
+// ConsumeForBattle 开始一场战斗时扣除体力
+func (sm *StaminaManager) ConsumeForBattle(userID int, zone *models.Zone) (*StaminaConsumeResult, error) {
+	return sm.consume(userID, sm.GetBattleCost(zone))
+}
+
+// consume 结算恢复后扣除体力，并领取累积的溢出奖励（溢出金币在同一事务中发放到账户）
+func (sm *StaminaManager) consume(userID int, cost int) (*StaminaConsumeResult, error) {
+	cfg := sm.getConfig()
+	if !cfg.Enabled {
+		return &StaminaConsumeResult{Allowed: true}, nil
+	}
+
+	result := &StaminaConsumeResult{}
+	now := time.Now()
+	stamina, err := sm.staminaRepo.Update(userID, cfg.MaxStamina, now, func(tx *sql.Tx, s *models.UserStamina) error {
+		ApplyStaminaRegen(s, cfg, now)
+		if s.CurrentStamina < cost {
+			return nil
+		}
+		s.CurrentStamina -= cost
+		result.Allowed = true
+		result.Cost = cost
+		result.OverflowExp, s.OverflowExp = s.OverflowExp, 0
+		result.OverflowGold, s.OverflowGold = s.OverflowGold, 0
+		return sm.economyMgr.AddGoldTx(tx, userID, result.OverflowGold, repository.GoldReasonStaminaOverflow, repository.GoldRef{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Stamina = stamina
+	return result, nil
+}
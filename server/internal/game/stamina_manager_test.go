@@ -0,0 +1,158 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/config"
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ═══════════════════════════════════════════════════════════
+// 体力恢复测试
+// ═══════════════════════════════════════════════════════════
+
+func TestApplyStaminaRegen(t *testing.T) {
+	cfg := config.DefaultStaminaConfig()
+	start := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	// 10个半周期：恢复10点，保留半个周期的进度
+	stamina := &models.UserStamina{CurrentStamina: 50, MaxStamina: 100, LastRegenAt: start}
+	ApplyStaminaRegen(stamina, cfg, start.Add(time.Duration(cfg.RegenIntervalSeconds*21/2)*time.Second))
+	assert.Equal(t, 60, stamina.CurrentStamina)
+	assert.Equal(t, start.Add(time.Duration(cfg.RegenIntervalSeconds*10)*time.Second), stamina.LastRegenAt)
+	assert.Equal(t, 0, stamina.OverflowExp)
+
+	// 体力回满后，多出的恢复量转化为经验和金币
+	stamina = &models.UserStamina{CurrentStamina: 98, MaxStamina: 100, LastRegenAt: start}
+	ApplyStaminaRegen(stamina, cfg, start.Add(time.Duration(cfg.RegenIntervalSeconds*5)*time.Second))
+	assert.Equal(t, 100, stamina.CurrentStamina)
+	assert.Equal(t, 3*cfg.OverflowExpPerPoint, stamina.OverflowExp)
+	assert.Equal(t, 3*cfg.OverflowGoldPerPoint, stamina.OverflowGold)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 体力消耗测试
+// ═══════════════════════════════════════════════════════════
+
+func TestStaminaManager_DisabledByDefault(t *testing.T) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer database.TeardownTestDB(testDB)
+
+	sm := NewStaminaManager()
+	assert.False(t, sm.IsEnabled())
+
+	result, err := sm.ConsumeForBattle(1, nil)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Cost)
+}
+
+func TestStaminaManager_ConsumeForBattle(t *testing.T) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer database.TeardownTestDB(testDB)
+
+	_, err = testDB.Exec(`
+		INSERT INTO config_versions (config_type, version, config_data)
+		VALUES ('stamina', 1, '{"enabled": true, "max_stamina": 10, "battle_cost": 3}')
+	`)
+	assert.NoError(t, err)
+
+	user, err := repository.NewUserRepository().Create("stamina_player", "hash", "")
+	assert.NoError(t, err)
+
+	sm := NewStaminaManager()
+	assert.True(t, sm.IsEnabled())
+
+	// 区域声明的消耗优先于默认消耗
+	cost := 4
+	zone := &models.Zone{ID: "duskwood", StaminaCost: &cost}
+	assert.Equal(t, 4, sm.GetBattleCost(zone))
+	assert.Equal(t, 3, sm.GetBattleCost(nil))
+
+	result, err := sm.ConsumeForBattle(user.ID, zone)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 6, result.Stamina.CurrentStamina)
+
+	result, err = sm.ConsumeForBattle(user.ID, zone)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Stamina.CurrentStamina)
+
+	enough, err := sm.HasEnoughForBattle(user.ID, zone)
+	assert.NoError(t, err)
+	assert.False(t, enough)
+
+	result, err = sm.ConsumeForBattle(user.ID, zone)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed, "体力不足时不允许战斗")
+	assert.Equal(t, 2, result.Stamina.CurrentStamina)
+
+	// 溢出奖励在下一次消耗体力时领取，金币发放到账户
+	_, err = testDB.Exec(`UPDATE user_stamina SET current_stamina = 10, overflow_exp = 20, overflow_gold = 8 WHERE user_id = ?`, user.ID)
+	assert.NoError(t, err)
+	result, err = sm.ConsumeForBattle(user.ID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, result.OverflowExp)
+	assert.Equal(t, 8, result.OverflowGold)
+	assert.Equal(t, 0, result.Stamina.OverflowExp)
+
+	updated, err := repository.NewUserRepository().GetByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user.Gold+8, updated.Gold)
+}
+
+func TestStaminaManager_OverflowGoldRollsBackWithStamina(t *testing.T) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer database.TeardownTestDB(testDB)
+
+	_, err = testDB.Exec(`
+		INSERT INTO config_versions (config_type, version, config_data)
+		VALUES ('stamina', 1, '{"enabled": true, "max_stamina": 10, "battle_cost": 3}')
+	`)
+	assert.NoError(t, err)
+
+	user, err := repository.NewUserRepository().Create("stamina_rollback", "hash", "")
+	assert.NoError(t, err)
+	sm := NewStaminaManager()
+
+	_, err = testDB.Exec(`INSERT INTO user_stamina (user_id, current_stamina, max_stamina, last_regen_at, overflow_exp, overflow_gold)
+		VALUES (?, 10, 10, ?, 20, 8)`, user.ID, time.Now().UTC())
+	assert.NoError(t, err)
+
+	// 金币流水写入失败时，体力扣除与溢出领取一并回滚
+	_, err = testDB.Exec(`CREATE TRIGGER fail_gold_ledger BEFORE INSERT ON gold_ledger BEGIN SELECT RAISE(ABORT, 'ledger unavailable'); END`)
+	assert.NoError(t, err)
+	_, err = sm.ConsumeForBattle(user.ID, nil)
+	assert.Error(t, err)
+
+	stamina, err := repository.NewStaminaRepository().Get(user.ID, 10, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 10, stamina.CurrentStamina)
+	assert.Equal(t, 8, stamina.OverflowGold)
+
+	_, err = testDB.Exec(`DROP TRIGGER fail_gold_ledger`)
+	assert.NoError(t, err)
+	result, err := sm.ConsumeForBattle(user.ID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, result.OverflowGold)
+	assert.Equal(t, 7, result.Stamina.CurrentStamina)
+
+	updated, err := repository.NewUserRepository().GetByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user.Gold+8, updated.Gold)
+}
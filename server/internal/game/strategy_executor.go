@@ -21,11 +21,13 @@ type BattleContext struct {
 	CurrentRound int
 	SkillManager *SkillManager
 	BuffManager  *BuffManager
+	ItemCounts   map[string]int // 背包消耗品数量（用于 item_count 条件和 use_item 动作）
 }
 
 // SkillDecision 技能决策结果
 type SkillDecision struct {
 	SkillID        string
+	ItemID         string // 非空时表示本回合使用物品
 	IsNormalAttack bool
 	TargetIndex    int // 目标索引（敌人）
 	Reason         string
@@ -111,6 +113,17 @@ func (e *StrategyExecutor) ExecuteStrategy(strategy *models.BattleStrategy, ctx
 				}
 			}
 
+			if rule.Action.Type == "use_item" {
+				if e.isItemAvailable(rule.Action.ItemID, ctx) {
+					return &SkillDecision{
+						ItemID:      rule.Action.ItemID,
+						TargetIndex: e.selectTarget(strategy, ctx, ""),
+						Reason:      "条件规则触发: 使用物品",
+					}
+				}
+				continue
+			}
+
 			if rule.Action.SkillID != "" {
 				// 检查技能是否可用（冷却、资源）
 				if e.isSkillAvailable(rule.Action.SkillID, ctx) {
@@ -158,7 +171,14 @@ func (e *StrategyExecutor) checkUrgentRules(strategy *models.BattleStrategy, ctx
 
 		// 只检查 self_hp_percent < 某值的紧急规则
 		if rule.Condition.Type == "self_hp_percent" && rule.Condition.Operator == "<" {
-			if hpPercent < rule.Condition.Value {
+			if hpPercent < rule.Condition.Value && e.evaluateAndConditions(&rule.Condition, ctx) {
+				if rule.Action.Type == "use_item" && e.isItemAvailable(rule.Action.ItemID, ctx) {
+					return &SkillDecision{
+						ItemID:      rule.Action.ItemID,
+						TargetIndex: e.selectTarget(strategy, ctx, ""),
+						Reason:      "紧急规则触发: HP低，使用物品",
+					}
+				}
 				if rule.Action.Type == "use_skill" && rule.Action.SkillID != "" {
 					if e.isSkillAvailable(rule.Action.SkillID, ctx) {
 						return &SkillDecision{
@@ -176,6 +196,10 @@ func (e *StrategyExecutor) checkUrgentRules(strategy *models.BattleStrategy, ctx
 
 // evaluateCondition 评估条件是否满足
 func (e *StrategyExecutor) evaluateCondition(cond *models.RuleCondition, ctx *BattleContext) bool {
+	if !e.evaluateAndConditions(cond, ctx) {
+		return false
+	}
+
 	var currentValue float64
 
 	switch cond.Type {
@@ -276,6 +300,9 @@ func (e *StrategyExecutor) evaluateCondition(cond *models.RuleCondition, ctx *Ba
 		}
 		return true
 
+	case "item_count":
+		currentValue = float64(ctx.ItemCounts[cond.ItemID])
+
 	case "always":
 		return true
 
@@ -287,6 +314,16 @@ func (e *StrategyExecutor) evaluateCondition(cond *models.RuleCondition, ctx *Ba
 	return e.compareValues(currentValue, cond.Operator, cond.Value)
 }
 
+// evaluateAndConditions 评估附加条件（全部满足才返回true）
+func (e *StrategyExecutor) evaluateAndConditions(cond *models.RuleCondition, ctx *BattleContext) bool {
+	for i := range cond.And {
+		if !e.evaluateCondition(&cond.And[i], ctx) {
+			return false
+		}
+	}
+	return true
+}
+
 // compareValues 比较数值
 func (e *StrategyExecutor) compareValues(current float64, operator string, target float64) bool {
 	switch operator {
@@ -323,6 +360,36 @@ func (e *StrategyExecutor) isSkillAvailable(skillID string, ctx *BattleContext)
 	return false
 }
 
+// isItemAvailable 检查背包中是否还有该物品
+func (e *StrategyExecutor) isItemAvailable(itemID string, ctx *BattleContext) bool {
+	return itemID != "" && ctx.ItemCounts[itemID] > 0
+}
+
+// UsesItems 检查策略是否引用了物品（需要在战斗上下文中加载背包数量）
+func (e *StrategyExecutor) UsesItems(strategy *models.BattleStrategy) bool {
+	if strategy == nil {
+		return false
+	}
+	for _, rule := range strategy.ConditionalRules {
+		if rule.Enabled && (rule.Action.Type == "use_item" || conditionUsesItems(&rule.Condition)) {
+			return true
+		}
+	}
+	return false
+}
+
+func conditionUsesItems(cond *models.RuleCondition) bool {
+	if cond.Type == "item_count" {
+		return true
+	}
+	for i := range cond.And {
+		if conditionUsesItems(&cond.And[i]) {
+			return true
+		}
+	}
+	return false
+}
+
 // isReservedSkill 检查是否是保留技能（且条件未满足）
 func (e *StrategyExecutor) isReservedSkill(strategy *models.BattleStrategy, skillID string, ctx *BattleContext) bool {
 	for _, reserved := range strategy.ReservedSkills {
@@ -0,0 +1,176 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+
+	"text-wow/internal/models"
+)
+
+// ThreatLogCollector 仇恨日志收集器 - 记录每回合各敌人的仇恨快照、目标切换、OT与嘲讽
+type ThreatLogCollector struct {
+	Entries  []*models.BattleThreatLog // 本场战斗待保存的快照
+	names    map[int]string            // 角色ID -> 名称
+	tanks    map[int]bool              // 角色ID -> 是否坦克
+	targets  map[string]int            // 敌人ID -> 上一快照的仇恨目标
+	lastTurn map[string]int            // 敌人ID -> 上一快照的回合
+	taunts   map[string]int            // 敌人ID -> 本回合嘲讽者角色ID
+}
+
+// NewThreatLogCollector 创建仇恨日志收集器
+func NewThreatLogCollector(characters []*models.Character, tanks map[int]bool) *ThreatLogCollector {
+	c := &ThreatLogCollector{
+		Entries:  make([]*models.BattleThreatLog, 0),
+		names:    make(map[int]string),
+		tanks:    make(map[int]bool),
+		targets:  make(map[string]int),
+		lastTurn: make(map[string]int),
+		taunts:   make(map[string]int),
+	}
+	for _, char := range characters {
+		if char != nil {
+			c.names[char.ID] = char.Name
+			c.tanks[char.ID] = tanks[char.ID]
+		}
+	}
+	return c
+}
+
+// RecordTaunt 记录本回合角色对敌人使用了嘲讽
+func (c *ThreatLogCollector) RecordTaunt(enemyID string, characterID int) {
+	c.taunts[enemyID] = characterID
+}
+
+// HasSnapshot 敌人在指定回合是否已有快照
+func (c *ThreatLogCollector) HasSnapshot(enemyID string, turn int) bool {
+	last, exists := c.lastTurn[enemyID]
+	return exists && last >= turn
+}
+
+// Snapshot 记录敌人在某回合的仇恨快照
+// 仇恨目标为威胁值最高的角色，威胁值相同时保持原目标；本回合被嘲讽时强制为嘲讽者
+// OT：目标从坦克切换到非坦克（嘲讽导致的切换不算OT）
+func (c *ThreatLogCollector) Snapshot(turn int, enemyID string, threat map[int]int) *models.BattleThreatLog {
+	entry := &models.BattleThreatLog{
+		Turn:       turn,
+		EnemyID:    enemyID,
+		ThreatList: make([]models.ThreatEntry, 0, len(threat)),
+	}
+	for characterID, value := range threat {
+		entry.ThreatList = append(entry.ThreatList, models.ThreatEntry{
+			CharacterID:   characterID,
+			CharacterName: c.names[characterID],
+			Threat:        value,
+			IsTank:        c.tanks[characterID],
+		})
+	}
+	sort.Slice(entry.ThreatList, func(i, j int) bool {
+		if entry.ThreatList[i].Threat != entry.ThreatList[j].Threat {
+			return entry.ThreatList[i].Threat > entry.ThreatList[j].Threat
+		}
+		return entry.ThreatList[i].CharacterID < entry.ThreatList[j].CharacterID
+	})
+
+	previous, hadTarget := c.targets[enemyID]
+	taunter, taunted := c.taunts[enemyID]
+	switch {
+	case taunted:
+		entry.CurrentTarget = taunter
+		entry.TauntUsed = true
+	case len(entry.ThreatList) > 0:
+		entry.CurrentTarget = entry.ThreatList[0].CharacterID
+		if hadTarget && threat[previous] >= entry.ThreatList[0].Threat {
+			entry.CurrentTarget = previous
+		}
+	}
+
+	if hadTarget && entry.CurrentTarget != 0 && entry.CurrentTarget != previous {
+		entry.TargetChanged = true
+		entry.OTOccurred = !taunted && c.tanks[previous] && !c.tanks[entry.CurrentTarget]
+	}
+
+	if entry.CurrentTarget != 0 {
+		c.targets[enemyID] = entry.CurrentTarget
+	}
+	c.lastTurn[enemyID] = turn
+	delete(c.taunts, enemyID)
+	c.Entries = append(c.Entries, entry)
+	return entry
+}
+
+// ═══════════════════════════════════════════════════════════
+// BattleManager 仇恨日志集成
+// ═══════════════════════════════════════════════════════════
+
+// newThreatLogCollector 根据职业定位创建本场战斗的仇恨日志收集器
+func (m *BattleManager) newThreatLogCollector(characters []*models.Character) *ThreatLogCollector {
+	tanks := make(map[int]bool)
+	for _, char := range characters {
+		if char == nil || m.gameRepo == nil {
+			continue
+		}
+		class, err := m.gameRepo.GetClassByID(char.ClassID)
+		if err != nil {
+			continue
+		}
+		tanks[char.ID] = class.Role == "tank" || class.CombatRole == "tank"
+	}
+	return NewThreatLogCollector(characters, tanks)
+}
+
+// applyTaunt 嘲讽：将施法者的威胁值提升至敌人威胁表最高值，并记录嘲讽
+func (m *BattleManager) applyTaunt(session *BattleSession, char *models.Character, targets []*models.Monster) {
+	for _, enemy := range targets {
+		if enemy == nil || enemy.HP <= 0 {
+			continue
+		}
+		highest := 0
+		for _, threat := range m.getThreatTableForMonster(session, enemy.ID) {
+			if threat > highest {
+				highest = threat
+			}
+		}
+		current := m.getThreatTableForMonster(session, enemy.ID)[char.ID]
+		if highest > current {
+			m.updateThreat(session, enemy.ID, char.ID, highest-current)
+		}
+		if session.ThreatLog != nil {
+			session.ThreatLog.RecordTaunt(enemy.ID, char.ID)
+		}
+	}
+}
+
+// recordThreatSnapshots 记录本回合存活敌人的仇恨快照（同ID的敌人共享威胁表，只记录一次）
+func (m *BattleManager) recordThreatSnapshots(session *BattleSession, enemies []*models.Monster) {
+	if session == nil || session.ThreatLog == nil {
+		return
+	}
+	for _, enemy := range enemies {
+		if enemy == nil || enemy.HP <= 0 || session.ThreatLog.HasSnapshot(enemy.ID, session.CurrentBattleRound) {
+			continue
+		}
+		session.ThreatLog.Snapshot(session.CurrentBattleRound, enemy.ID, m.getThreatTableForMonster(session, enemy.ID))
+	}
+}
+
+// saveThreatLogs 补记最后一回合的快照并保存本场战斗的仇恨日志
+func (m *BattleManager) saveThreatLogs(session *BattleSession, battleID int) {
+	if session.ThreatLog == nil {
+		return
+	}
+
+	enemyIDs := make([]string, 0, len(session.ThreatTable))
+	for enemyID := range session.ThreatTable {
+		enemyIDs = append(enemyIDs, enemyID)
+	}
+	sort.Strings(enemyIDs)
+	for _, enemyID := range enemyIDs {
+		if !session.ThreatLog.HasSnapshot(enemyID, session.CurrentBattleRound) {
+			session.ThreatLog.Snapshot(session.CurrentBattleRound, enemyID, session.ThreatTable[enemyID])
+		}
+	}
+
+	if err := m.battleStatsRepo.CreateThreatLogs(battleID, session.ThreatLog.Entries); err != nil {
+		fmt.Printf("[ERROR] Failed to save threat logs: %v\n", err)
+	}
+}
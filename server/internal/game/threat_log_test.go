@@ -0,0 +1,59 @@
+package game
+
+import (
+	"testing"
+
+	"text-wow/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestThreatCollector() *ThreatLogCollector {
+	characters := []*models.Character{
+		{ID: 1, Name: "坦克"},
+		{ID: 2, Name: "法师"},
+	}
+	return NewThreatLogCollector(characters, map[int]bool{1: true})
+}
+
+func TestThreatLogCollector_TargetAndOT(t *testing.T) {
+	c := newTestThreatCollector()
+
+	first := c.Snapshot(1, "wolf", map[int]int{1: 50, 2: 30})
+	assert.Equal(t, 1, first.CurrentTarget)
+	assert.False(t, first.TargetChanged, "第一回合没有目标切换")
+	assert.Equal(t, "坦克", first.ThreatList[0].CharacterName)
+
+	// 威胁值相同时保持原目标
+	tie := c.Snapshot(2, "wolf", map[int]int{1: 60, 2: 60})
+	assert.Equal(t, 1, tie.CurrentTarget)
+	assert.False(t, tie.TargetChanged)
+
+	// 非坦克超过坦克：OT
+	ot := c.Snapshot(3, "wolf", map[int]int{1: 70, 2: 90})
+	assert.Equal(t, 2, ot.CurrentTarget)
+	assert.True(t, ot.TargetChanged)
+	assert.True(t, ot.OTOccurred)
+
+	assert.True(t, c.HasSnapshot("wolf", 3))
+	assert.False(t, c.HasSnapshot("wolf", 4))
+	assert.Len(t, c.Entries, 3)
+}
+
+func TestThreatLogCollector_Taunt(t *testing.T) {
+	c := newTestThreatCollector()
+	c.Snapshot(1, "wolf", map[int]int{1: 10, 2: 40})
+
+	// 坦克嘲讽夺回仇恨：切换目标但不算OT
+	c.RecordTaunt("wolf", 1)
+	taunt := c.Snapshot(2, "wolf", map[int]int{1: 40, 2: 40})
+	assert.Equal(t, 1, taunt.CurrentTarget)
+	assert.True(t, taunt.TauntUsed)
+	assert.True(t, taunt.TargetChanged)
+	assert.False(t, taunt.OTOccurred)
+
+	// 嘲讽标记只作用于当前回合
+	next := c.Snapshot(3, "wolf", map[int]int{1: 45, 2: 40})
+	assert.False(t, next.TauntUsed)
+	assert.Equal(t, 1, next.CurrentTarget)
+}
@@ -1,441 +1,775 @@
 package game
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"text-wow/internal/models"
 	"text-wow/internal/repository"
 )
 
+// 拍卖行错误
+var (
+	ErrInvalidAuctionPrice    = errors.New("price must be greater than 0")
+	ErrInvalidAuctionDuration = errors.New("duration must be between 1 and 7 days")
+	ErrInvalidBuyoutPrice     = errors.New("buyout price must be greater than the starting bid")
+)
+
+// 交易窗口错误
+var (
+	ErrTradeWithSelf        = errors.New("cannot trade with yourself")
+	ErrTradePartnerNotFound = errors.New("trade partner not found")
+	ErrInvalidTradeGold     = errors.New("invalid gold amount")
+)
+
+const (
+	defaultAuctionDurationDays = 2    // 默认上架时长
+	maxAuctionDurationDays     = 7    // 最长上架时长
+	transactionFeeRate         = 0.05 // 成交手续费率
+	listingFeeRate             = 0.05 // 上架费率
+	minListingFee              = 10   // 最低上架费
+	minBidIncrementRate        = 0.05 // 未指定最低加价时按起拍价的比例
+
+	antiSnipeWindow    = 5 * time.Minute // 结束前该时间内出价会延长拍卖
+	antiSnipeExtension = 5 * time.Minute // 延长后距结束的剩余时间
+
+	tradeWindowTimeout = 10 * time.Minute // 交易窗口无操作超时时间
+
+	auctionMailSender = "拍卖行" // 拍卖行邮件的发件人名称
+)
+
 // TradingManager 交易管理器 - 管理玩家间装备交易和拍卖行
 type TradingManager struct {
-	mu          sync.RWMutex
-	userRepo    *repository.UserRepository
-	gameRepo    *repository.GameRepository
-	economyMgr  *EconomyManager
-	equipmentMgr *EquipmentManager
-}
-
-// AuctionListing 拍卖行上架信息
-type AuctionListing struct {
-	ID          int
-	SellerID    int
-	EquipmentID int
-	ItemID      string
-	Price       int
-	ListedAt    time.Time
-	ExpiresAt   time.Time
-	Status      string // active/sold/expired/cancelled
-	BuyerID     *int
-	SoldAt      *time.Time
-}
-
-// TradeOffer 交易报价
-type TradeOffer struct {
-	ID          int
-	FromUserID  int
-	ToUserID    int
-	EquipmentID int
-	Price       int
-	Status      string // pending/accepted/rejected/cancelled
-	CreatedAt   time.Time
-	ExpiresAt   time.Time
+	mu            sync.RWMutex
+	userRepo      *repository.UserRepository
+	gameRepo      *repository.GameRepository
+	auctionRepo   *repository.AuctionRepository
+	equipmentRepo *repository.EquipmentRepository
+	tradeRepo     *repository.TradeRepository
+	chatRepo      *repository.ChatRepository
+	economyMgr    *EconomyManager
+	mailMgr       *MailManager
+	eventHub      *EventHub
+	equipmentMgr  *EquipmentManager
+}
+
+// AuctionOptions 竞价拍卖参数
+type AuctionOptions struct {
+	StartingBid  int // 起拍价
+	MinIncrement int // 最低加价（0=起拍价的5%，至少1金币）
+	BuyoutPrice  int // 一口价（0=无一口价）
+	DurationDays int // 拍卖天数（默认2天，最长7天）
 }
 
 // NewTradingManager 创建交易管理器
 func NewTradingManager() *TradingManager {
 	return &TradingManager{
-		userRepo:     repository.NewUserRepository(),
-		gameRepo:     repository.NewGameRepository(),
-		economyMgr:   NewEconomyManager(),
-		equipmentMgr: NewEquipmentManager(),
+		userRepo:      repository.NewUserRepository(),
+		gameRepo:      repository.NewGameRepository(),
+		auctionRepo:   repository.NewAuctionRepository(),
+		equipmentRepo: repository.NewEquipmentRepository(),
+		tradeRepo:     repository.NewTradeRepository(),
+		chatRepo:      repository.NewChatRepository(),
+		economyMgr:    NewEconomyManager(),
+		mailMgr:       GetMailManager(),
+		eventHub:      GetEventHub(),
+		equipmentMgr:  NewEquipmentManager(),
 	}
 }
 
-// ListItem 上架装备到拍卖行
-// 功能：玩家将装备上架到拍卖行，设置价格，其他玩家可以购买
-func (tm *TradingManager) ListItem(sellerID int, equipmentID int, price int, durationDays int) error {
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
+// 全局交易管理器实例
+var tradingManager *TradingManager
+var tradingOnce sync.Once
+
+// GetTradingManager 获取交易管理器单例
+func GetTradingManager() *TradingManager {
+	tradingOnce.Do(func() {
+		tradingManager = NewTradingManager()
+	})
+	return tradingManager
+}
 
-	// 验证价格
+// ═══════════════════════════════════════════════════════════
+// 拍卖行
+// ═══════════════════════════════════════════════════════════
+
+// ListItem 上架装备到拍卖行（一口价）
+// 功能：扣除上架费并托管装备，到期未售出时自动归还卖家
+func (tm *TradingManager) ListItem(sellerID int, equipmentID int, price int, durationDays int) (*models.AuctionListing, error) {
 	if price <= 0 {
-		return fmt.Errorf("price must be greater than 0")
+		return nil, ErrInvalidAuctionPrice
 	}
+	return tm.createListing(sellerID, equipmentID, &models.AuctionListing{Price: price}, tm.CalculateListingFee(price), durationDays)
+}
 
-	// 验证装备所有权
-	equipmentRepo := repository.NewEquipmentRepository()
-	equipment, err := equipmentRepo.GetByID(equipmentID)
-	if err != nil {
-		return fmt.Errorf("failed to get equipment: %w", err)
+// ListAuction 发起竞价拍卖
+// 功能：设置起拍价、最低加价与可选一口价，出价金币由拍卖行托管，被超过时自动退还
+func (tm *TradingManager) ListAuction(sellerID int, equipmentID int, opts AuctionOptions) (*models.AuctionListing, error) {
+	if opts.StartingBid <= 0 || opts.MinIncrement < 0 {
+		return nil, ErrInvalidAuctionPrice
 	}
-	if equipment.OwnerID != sellerID {
-		return fmt.Errorf("equipment does not belong to seller")
+	if opts.BuyoutPrice != 0 && opts.BuyoutPrice <= opts.StartingBid {
+		return nil, ErrInvalidBuyoutPrice
 	}
-	if equipment.CharacterID != nil {
-		return fmt.Errorf("equipment is currently equipped, please unequip first")
+	minIncrement := opts.MinIncrement
+	if minIncrement == 0 {
+		minIncrement = int(float64(opts.StartingBid) * minBidIncrementRate)
+		if minIncrement < 1 {
+			minIncrement = 1
+		}
 	}
 
-	// 计算上架费（价格的5%）
-	listingFee := int(float64(price) * 0.05)
-	if listingFee < 10 {
-		listingFee = 10 // 最低上架费10金币
+	startingBid := opts.StartingBid
+	listing := &models.AuctionListing{
+		Price:        opts.BuyoutPrice,
+		StartingBid:  &startingBid,
+		MinIncrement: minIncrement,
 	}
+	return tm.createListing(sellerID, equipmentID, listing, tm.CalculateListingFee(startingBid), opts.DurationDays)
+}
 
-	// 检查卖家是否有足够金币支付上架费
-	seller, err := tm.userRepo.GetByID(sellerID)
-	if err != nil {
-		return fmt.Errorf("failed to get seller: %w", err)
+// createListing 校验装备、扣除上架费并创建上架记录（同一事务）
+func (tm *TradingManager) createListing(sellerID, equipmentID int, listing *models.AuctionListing, listingFee, durationDays int) (*models.AuctionListing, error) {
+	if durationDays == 0 {
+		durationDays = defaultAuctionDurationDays
 	}
-	if seller.Gold < listingFee {
-		return fmt.Errorf("insufficient gold for listing fee: need %d, have %d", listingFee, seller.Gold)
+	if durationDays < 1 || durationDays > maxAuctionDurationDays {
+		return nil, ErrInvalidAuctionDuration
 	}
 
-	// 扣除上架费
-	if err := tm.economyMgr.SpendGold(sellerID, listingFee); err != nil {
-		return fmt.Errorf("failed to pay listing fee: %w", err)
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	now := time.Now()
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.AuctionListing, error) {
+		itemID, err := tm.equipmentRepo.CheckTradableTx(tx, sellerID, equipmentID)
+		if err != nil {
+			return nil, err
+		}
+
+		listing.SellerID = sellerID
+		listing.EquipmentID = equipmentID
+		listing.ItemID = itemID
+		listing.ListingFee = listingFee
+		listing.ListedAt = now
+		listing.ExpiresAt = now.AddDate(0, 0, durationDays)
+		created, err := tm.auctionRepo.InsertListingTx(tx, listing)
+		if err != nil {
+			return nil, err
+		}
+		if err := tm.economyMgr.SpendGoldTx(tx, sellerID, listingFee,
+			repository.GoldReasonAuctionListingFee, repository.NewGoldRef("auction_listing", created.ID)); err != nil {
+			return nil, err
+		}
+		return created, nil
+	})
+}
+
+// MinNextBid 计算下一次出价的最低金额
+func MinNextBid(listing *models.AuctionListing) int {
+	if listing.StartingBid == nil {
+		return 0
 	}
+	if listing.CurrentBid == nil {
+		return *listing.StartingBid
+	}
+	return *listing.CurrentBid + listing.MinIncrement
+}
 
-	// 创建上架记录
-	// TODO: 需要实现 AuctionRepository
-	// listing := &AuctionListing{
-	// 	SellerID:    sellerID,
-	// 	EquipmentID: equipmentID,
-	// 	Price:       price,
-	// 	ListedAt:    time.Now(),
-	// 	ExpiresAt:   time.Now().AddDate(0, 0, durationDays),
-	// 	Status:      "active",
-	// }
-	// if err := tm.auctionRepo.CreateListing(listing); err != nil {
-	// 	// 如果创建失败，退还上架费
-	// 	tm.economyMgr.AddGold(sellerID, listingFee)
-	// 	return fmt.Errorf("failed to create listing: %w", err)
-	// }
+// PlaceBid 竞价出价
+// 功能：托管出价金币并退还上一位最高出价者；达到一口价时直接成交；结束前5分钟内出价会延长拍卖
+func (tm *TradingManager) PlaceBid(bidderID int, listingID int, amount int) (*models.AuctionListing, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
-	return fmt.Errorf("AuctionRepository not implemented yet")
+	now := time.Now()
+	listing, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.AuctionListing, error) {
+		listing, err := tm.getOpenListingTx(tx, bidderID, listingID, now)
+		if err != nil {
+			return nil, err
+		}
+		if !listing.IsBidding() {
+			return nil, repository.ErrAuctionNoBidding
+		}
+		if listing.CurrentBidderID != nil && *listing.CurrentBidderID == bidderID {
+			return nil, repository.ErrAuctionAlreadyHighest
+		}
+		if amount < MinNextBid(listing) {
+			return nil, fmt.Errorf("%w: minimum bid is %d", repository.ErrAuctionBidTooLow, MinNextBid(listing))
+		}
+
+		// 出价达到一口价时直接成交
+		if listing.Price > 0 && amount >= listing.Price {
+			return tm.buyoutTx(tx, listing, bidderID, now)
+		}
+
+		if err := tm.economyMgr.SpendGoldTx(tx, bidderID, amount,
+			repository.GoldReasonAuctionBid, repository.NewGoldRef("auction_listing", listing.ID)); err != nil {
+			return nil, err
+		}
+		if err := tm.refundCurrentBidTx(tx, listing, "outbid"); err != nil {
+			return nil, err
+		}
+
+		expiresAt := listing.ExpiresAt
+		if expiresAt.Sub(now) < antiSnipeWindow {
+			expiresAt = now.Add(antiSnipeExtension)
+		}
+		if _, err := tm.auctionRepo.RecordBidTx(tx, listing.ID, bidderID, amount, expiresAt, now); err != nil {
+			return nil, err
+		}
+		return tm.auctionRepo.GetListingTx(tx, listing.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tm.notifyAuctionMail(listing)
+	return listing, nil
 }
 
 // BuyItem 从拍卖行购买装备
-// 功能：玩家从拍卖行购买装备，扣除金币，转移装备所有权
-func (tm *TradingManager) BuyItem(buyerID int, listingID int) error {
+// 功能：一口价购买，买家付款、退还竞价者、卖家收款（扣除手续费）与装备转移在同一事务中完成
+func (tm *TradingManager) BuyItem(buyerID int, listingID int) (*models.AuctionListing, error) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	// 获取上架信息
-	// TODO: 需要实现 AuctionRepository
-	// listing, err := tm.auctionRepo.GetListingByID(listingID)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to get listing: %w", err)
-	// }
-	// if listing.Status != "active" {
-	// 	return fmt.Errorf("listing is not active")
-	// }
-	// if listing.ExpiresAt.Before(time.Now()) {
-	// 	return fmt.Errorf("listing has expired")
-	// }
-	// if listing.SellerID == buyerID {
-	// 	return fmt.Errorf("cannot buy your own item")
-	// }
-
-	// TODO: 实现完整的购买逻辑
-	// 检查买家是否有足够金币
-	_, err := tm.userRepo.GetByID(buyerID)
+	now := time.Now()
+	listing, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.AuctionListing, error) {
+		listing, err := tm.getOpenListingTx(tx, buyerID, listingID, now)
+		if err != nil {
+			return nil, err
+		}
+		if listing.Price <= 0 {
+			return nil, repository.ErrAuctionNoBuyout
+		}
+		return tm.buyoutTx(tx, listing, buyerID, now)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get buyer: %w", err)
-	}
-	
-	// 获取拍卖列表
-	// listing, err := tm.auctionRepo.GetListingByID(listingID)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to get listing: %w", err)
-	// }
-	
-	// if buyer.Gold < listing.Price {
-	// 	return fmt.Errorf("insufficient gold: need %d, have %d", listing.Price, buyer.Gold)
-	// }
-
-	// 扣除买家金币
-	// if err := tm.economyMgr.SpendGold(buyerID, listing.Price); err != nil {
-	// 	return fmt.Errorf("failed to deduct gold: %w", err)
-	// }
-
-	// 计算交易手续费（价格的5%）
-	// transactionFee := int(float64(listing.Price) * 0.05)
-	// sellerGold := listing.Price - transactionFee
-
-	// 转移装备所有权
-	// equipmentRepo := repository.NewEquipmentRepository()
-	// equipment, err := equipmentRepo.GetByID(listing.EquipmentID)
-	// if err != nil {
-	// 	// 如果转移失败，退还金币
-	// 	tm.economyMgr.AddGold(buyerID, listing.Price)
-	// 	return fmt.Errorf("failed to get equipment: %w", err)
-	// }
-	// equipment.OwnerID = buyerID
-	// equipment.CharacterID = nil // 确保装备未装备
-	// if err := equipmentRepo.Update(equipment); err != nil {
-	// 	// 如果更新失败，退还金币
-	// 	tm.economyMgr.AddGold(buyerID, listing.Price)
-	// 	return fmt.Errorf("failed to transfer equipment: %w", err)
-	// }
-	
-	return fmt.Errorf("BuyFromAuction not fully implemented yet")
-
-	// 支付卖家金币（扣除手续费）
-	// if err := tm.economyMgr.AddGold(listing.SellerID, sellerGold); err != nil {
-	// 	// 如果支付失败，记录错误但不回滚（装备已转移）
-	// 	fmt.Printf("[ERROR] Failed to pay seller: %v\n", err)
-	// }
-
-	// 更新上架状态
-	// listing.Status = "sold"
-	// listing.BuyerID = &buyerID
-	// now := time.Now()
-	// listing.SoldAt = &now
-	// if err := tm.auctionRepo.UpdateListing(listing); err != nil {
-	// 	fmt.Printf("[ERROR] Failed to update listing status: %v\n", err)
-	// }
-
-	return fmt.Errorf("AuctionRepository and EquipmentRepository not implemented yet")
+		return nil, err
+	}
+	tm.notifyAuctionMail(listing)
+	return listing, nil
 }
 
 // CancelListing 取消上架
-// 功能：卖家取消上架，装备归还，上架费不退还
-func (tm *TradingManager) CancelListing(sellerID int, listingID int) error {
+// 功能：卖家取消上架，装备归还，上架费不退还（防止频繁上架/取消）；已有出价的拍卖不能取消
+func (tm *TradingManager) CancelListing(sellerID int, listingID int) (*models.AuctionListing, error) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	// 获取上架信息
-	// TODO: 需要实现 AuctionRepository
-	// listing, err := tm.auctionRepo.GetListingByID(listingID)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to get listing: %w", err)
-	// }
-	// if listing.SellerID != sellerID {
-	// 	return fmt.Errorf("listing does not belong to seller")
-	// }
-	// if listing.Status != "active" {
-	// 	return fmt.Errorf("listing is not active")
-	// }
-
-	// 更新上架状态
-	// listing.Status = "cancelled"
-	// if err := tm.auctionRepo.UpdateListing(listing); err != nil {
-	// 	return fmt.Errorf("failed to cancel listing: %w", err)
-	// }
-
-	// 注意：上架费不退还，这是为了防止玩家频繁上架/取消
-
-	return fmt.Errorf("AuctionRepository not implemented yet")
-}
-
-// CreateDirectTrade 创建直接交易
-// 功能：两个玩家之间的直接交易，需要双方确认
-func (tm *TradingManager) CreateDirectTrade(fromUserID int, toUserID int, equipmentID int, price int) error {
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
+	now := time.Now()
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.AuctionListing, error) {
+		listing, err := tm.auctionRepo.GetListingTx(tx, listingID)
+		if err != nil {
+			return nil, err
+		}
+		if listing.SellerID != sellerID {
+			return nil, repository.ErrAuctionNotSeller
+		}
+		if listing.Status != "active" {
+			return nil, repository.ErrAuctionListingNotActive
+		}
+		if listing.CurrentBidderID != nil {
+			return nil, repository.ErrAuctionHasBids
+		}
+		if err := tm.auctionRepo.CloseListingTx(tx, listingID, "cancelled", nil, nil, 0, now); err != nil {
+			return nil, err
+		}
+		return tm.auctionRepo.GetListingTx(tx, listingID)
+	})
+}
 
-	// 验证交易双方
-	if fromUserID == toUserID {
-		return fmt.Errorf("cannot trade with yourself")
+// getOpenListingTx 获取可购买/出价的上架（在售、未到期、非自己的上架）
+func (tm *TradingManager) getOpenListingTx(tx *sql.Tx, userID, listingID int, now time.Time) (*models.AuctionListing, error) {
+	listing, err := tm.auctionRepo.GetListingTx(tx, listingID)
+	if err != nil {
+		return nil, err
 	}
+	if listing.Status != "active" {
+		return nil, repository.ErrAuctionListingNotActive
+	}
+	if !listing.ExpiresAt.After(now) {
+		return nil, repository.ErrAuctionListingExpired
+	}
+	if listing.SellerID == userID {
+		return nil, repository.ErrAuctionOwnListing
+	}
+	return listing, nil
+}
 
-	// 验证价格
-	if price <= 0 {
-		return fmt.Errorf("price must be greater than 0")
-	}
-
-	// 验证装备所有权
-	// TODO: 需要实现 GetEquipmentByID
-	// equipment, err := tm.equipmentMgr.GetEquipmentByID(equipmentID)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to get equipment: %w", err)
-	// }
-	// if equipment.OwnerID != fromUserID {
-	// 	return fmt.Errorf("equipment does not belong to seller")
-	// }
-
-	// 创建交易报价
-	// offer := &TradeOffer{
-	// 	FromUserID:  fromUserID,
-	// 	ToUserID:    toUserID,
-	// 	EquipmentID: equipmentID,
-	// 	Price:       price,
-	// 	Status:      "pending",
-	// 	CreatedAt:   time.Now(),
-	// 	ExpiresAt:   time.Now().AddDate(0, 0, 7), // 7天过期
-	// }
-	// if err := tm.tradeRepo.CreateOffer(offer); err != nil {
-	// 	return fmt.Errorf("failed to create trade offer: %w", err)
-	// }
-
-	return fmt.Errorf("TradeRepository not implemented yet")
-}
-
-// AcceptTrade 接受交易
-// 功能：买家接受交易报价，完成交易
-func (tm *TradingManager) AcceptTrade(buyerID int, offerID int) error {
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
-
-	// 获取交易报价
-	// TODO: 需要实现 TradeRepository
-	// offer, err := tm.tradeRepo.GetOfferByID(offerID)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to get trade offer: %w", err)
-	// }
-	// if offer.ToUserID != buyerID {
-	// 	return fmt.Errorf("trade offer does not belong to buyer")
-	// }
-	// if offer.Status != "pending" {
-	// 	return fmt.Errorf("trade offer is not pending")
-	// }
-	// if offer.ExpiresAt.Before(time.Now()) {
-	// 	return fmt.Errorf("trade offer has expired")
-	// }
-
-	// 检查买家是否有足够金币
-	// buyer, err := tm.userRepo.GetByID(buyerID)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to get buyer: %w", err)
-	// }
-	// if buyer.Gold < offer.Price {
-	// 	return fmt.Errorf("insufficient gold: need %d, have %d", offer.Price, buyer.Gold)
-	// }
-
-	// 扣除买家金币
-	// if err := tm.economyMgr.SpendGold(buyerID, offer.Price); err != nil {
-	// 	return fmt.Errorf("failed to deduct gold: %w", err)
-	// }
-
-	// 计算交易手续费（价格的5%）
-	// transactionFee := int(float64(offer.Price) * 0.05)
-	// sellerGold := offer.Price - transactionFee
-
-	// 转移装备所有权
-	// equipment, err := tm.equipmentMgr.GetEquipmentByID(offer.EquipmentID)
-	// if err != nil {
-	// 	// 如果转移失败，退还金币
-	// 	tm.economyMgr.AddGold(buyerID, offer.Price)
-	// 	return fmt.Errorf("failed to get equipment: %w", err)
-	// }
-	// equipment.OwnerID = buyerID
-	// equipment.CharacterID = nil
-	// if err := tm.equipmentRepo.UpdateEquipment(equipment); err != nil {
-	// 	// 如果更新失败，退还金币
-	// 	tm.economyMgr.AddGold(buyerID, offer.Price)
-	// 	return fmt.Errorf("failed to transfer equipment: %w", err)
-	// }
-
-	// 支付卖家金币（扣除手续费）
-	// if err := tm.economyMgr.AddGold(offer.FromUserID, sellerGold); err != nil {
-	// 	fmt.Printf("[ERROR] Failed to pay seller: %v\n", err)
-	// }
-
-	// 更新交易状态
-	// offer.Status = "accepted"
-	// if err := tm.tradeRepo.UpdateOffer(offer); err != nil {
-	// 	fmt.Printf("[ERROR] Failed to update trade offer: %v\n", err)
-	// }
-
-	return fmt.Errorf("TradeRepository and EquipmentRepository not implemented yet")
-}
-
-// RejectTrade 拒绝交易
-// 功能：买家拒绝交易报价
-func (tm *TradingManager) RejectTrade(buyerID int, offerID int) error {
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
+// buyoutTx 以一口价成交：扣除买家金币、退还当前最高出价、支付卖家并转移装备
+func (tm *TradingManager) buyoutTx(tx *sql.Tx, listing *models.AuctionListing, buyerID int, now time.Time) (*models.AuctionListing, error) {
+	if err := tm.economyMgr.SpendGoldTx(tx, buyerID, listing.Price,
+		repository.GoldReasonAuctionPurchase, repository.NewGoldRef("auction_listing", listing.ID)); err != nil {
+		return nil, err
+	}
+	if err := tm.refundCurrentBidTx(tx, listing, "refunded"); err != nil {
+		return nil, err
+	}
+	if err := tm.settleSaleTx(tx, listing, buyerID, listing.Price, now); err != nil {
+		return nil, err
+	}
+	return tm.auctionRepo.GetListingTx(tx, listing.ID)
+}
 
-	// 获取交易报价
-	// TODO: 需要实现 TradeRepository
-	// offer, err := tm.tradeRepo.GetOfferByID(offerID)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to get trade offer: %w", err)
-	// }
-	// if offer.ToUserID != buyerID {
-	// 	return fmt.Errorf("trade offer does not belong to buyer")
-	// }
-	// if offer.Status != "pending" {
-	// 	return fmt.Errorf("trade offer is not pending")
-	// }
+// refundCurrentBidTx 退还当前最高出价者托管的金币
+func (tm *TradingManager) refundCurrentBidTx(tx *sql.Tx, listing *models.AuctionListing, status string) error {
+	if listing.CurrentBidderID == nil || listing.CurrentBid == nil {
+		return nil
+	}
+	if err := tm.economyMgr.RefundGoldTx(tx, *listing.CurrentBidderID, *listing.CurrentBid,
+		repository.GoldReasonAuctionBidRefund, repository.NewGoldRef("auction_listing", listing.ID)); err != nil {
+		return err
+	}
+	return tm.auctionRepo.SetActiveBidStatusTx(tx, listing.ID, status)
+}
 
-	// 更新交易状态
-	// offer.Status = "rejected"
-	// if err := tm.tradeRepo.UpdateOffer(offer); err != nil {
-	// 	return fmt.Errorf("failed to reject trade offer: %w", err)
-	// }
+// settleSaleTx 成交结算：结束上架，货款（扣除手续费）与装备分别通过邮件寄给卖家和买家
+func (tm *TradingManager) settleSaleTx(tx *sql.Tx, listing *models.AuctionListing, buyerID, price int, now time.Time) error {
+	fee := tm.CalculateTransactionFee(price)
+	if err := tm.auctionRepo.CloseListingTx(tx, listing.ID, "sold", &buyerID, &price, fee, now); err != nil {
+		return err
+	}
+	if _, err := tm.mailMgr.DeliverTx(tx, SystemMail{
+		RecipientID: listing.SellerID,
+		SenderName:  auctionMailSender,
+		Type:        "auction",
+		Subject:     fmt.Sprintf("拍卖成功：%s", listing.ItemName),
+		Body:        fmt.Sprintf("成交价 %d 金币，扣除手续费 %d 金币。", price, fee),
+		Gold:        price - fee,
+	}); err != nil {
+		return err
+	}
+	_, err := tm.mailMgr.DeliverTx(tx, SystemMail{
+		RecipientID:  buyerID,
+		SenderName:   auctionMailSender,
+		Type:         "auction",
+		Subject:      fmt.Sprintf("拍卖购得：%s", listing.ItemName),
+		Body:         fmt.Sprintf("你以 %d 金币购得了 %s。", price, listing.ItemName),
+		EquipmentIDs: []int{listing.EquipmentID},
+	})
+	return err
+}
 
-	return fmt.Errorf("TradeRepository not implemented yet")
+// returnUnsoldTx 流拍：结束上架并将装备通过邮件退回卖家
+func (tm *TradingManager) returnUnsoldTx(tx *sql.Tx, listing *models.AuctionListing, now time.Time) error {
+	if err := tm.auctionRepo.CloseListingTx(tx, listing.ID, "expired", nil, nil, 0, now); err != nil {
+		return err
+	}
+	_, err := tm.mailMgr.DeliverTx(tx, SystemMail{
+		RecipientID:  listing.SellerID,
+		SenderName:   auctionMailSender,
+		Type:         "auction",
+		Subject:      fmt.Sprintf("拍卖到期：%s", listing.ItemName),
+		Body:         "你的物品未能售出，已退回。",
+		EquipmentIDs: []int{listing.EquipmentID},
+	})
+	return err
 }
 
 // GetActiveListings 获取活跃的上架列表
-// 功能：获取拍卖行中所有活跃的上架装备
-func (tm *TradingManager) GetActiveListings(filters map[string]interface{}) ([]*AuctionListing, error) {
+// 功能：按槽位、品质、需求等级、词缀等条件搜索拍卖行
+func (tm *TradingManager) GetActiveListings(filter repository.AuctionSearchFilter) ([]*models.AuctionListing, error) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	// TODO: 需要实现 AuctionRepository
-	// listings, err := tm.auctionRepo.GetActiveListings(filters)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("failed to get listings: %w", err)
-	// }
-
-	// return listings, nil
-	return nil, fmt.Errorf("AuctionRepository not implemented yet")
+	return tm.auctionRepo.SearchListings(filter, time.Now())
 }
 
 // GetUserListings 获取用户的上架列表
-// 功能：获取指定用户的所有上架装备
-func (tm *TradingManager) GetUserListings(userID int) ([]*AuctionListing, error) {
+// 功能：获取指定用户最近的上架记录（含已售出/过期/取消）
+func (tm *TradingManager) GetUserListings(userID int) ([]*models.AuctionListing, error) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	// TODO: 需要实现 AuctionRepository
-	// listings, err := tm.auctionRepo.GetListingsBySellerID(userID)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("failed to get user listings: %w", err)
-	// }
+	return tm.auctionRepo.GetListingsBySeller(userID, 50)
+}
+
+// ExpireListings 处理到期的上架：有出价的拍卖由最高出价者得标，否则装备通过邮件退回卖家
+func (tm *TradingManager) ExpireListings(now time.Time) (int, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	ids, err := tm.auctionRepo.GetDueListingIDs(now)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, id := range ids {
+		var settled *models.AuctionListing
+		err := repository.WithTransaction(func(tx *sql.Tx) error {
+			listing, err := tm.auctionRepo.GetListingTx(tx, id)
+			if err != nil {
+				return err
+			}
+			// 已结束或因防狙击被延长的拍卖跳过
+			if listing.Status != "active" || listing.ExpiresAt.After(now) {
+				return nil
+			}
+			if listing.CurrentBidderID != nil && listing.CurrentBid != nil {
+				if err := tm.auctionRepo.SetActiveBidStatusTx(tx, listing.ID, "won"); err != nil {
+					return err
+				}
+				if err := tm.settleSaleTx(tx, listing, *listing.CurrentBidderID, *listing.CurrentBid, now); err != nil {
+					return err
+				}
+			} else if err := tm.returnUnsoldTx(tx, listing, now); err != nil {
+				return err
+			}
+			settled, err = tm.auctionRepo.GetListingTx(tx, listing.ID)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to settle listing %d: %w", id, err)
+		}
+		if settled != nil {
+			count++
+			tm.notifyAuctionMail(settled)
+		}
+	}
+	return count, nil
+}
+
+// GetListingBids 获取上架的出价记录
+func (tm *TradingManager) GetListingBids(listingID int) ([]*models.AuctionBid, error) {
+	if _, err := tm.auctionRepo.GetListingByID(listingID); err != nil {
+		return nil, err
+	}
+	return tm.auctionRepo.GetBids(listingID)
+}
+
+// GetUserBids 获取玩家的出价记录
+func (tm *TradingManager) GetUserBids(userID int) ([]*models.AuctionBid, error) {
+	return tm.auctionRepo.GetBidsByBidder(userID, 50)
+}
 
-	// return listings, nil
-	return nil, fmt.Errorf("AuctionRepository not implemented yet")
+// StartExpiryJob 启动拍卖与交易窗口到期清理任务（启动时立即执行一次，之后按间隔执行）
+func (tm *TradingManager) StartExpiryJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			count, err := tm.ExpireListings(now)
+			if err != nil {
+				fmt.Printf("[ERROR] Auction expiry sweep failed: %v\n", err)
+			} else if count > 0 {
+				fmt.Printf("[INFO] Auction expiry sweep settled %d listings\n", count)
+			}
+			count, err = tm.ExpireTrades(now)
+			if err != nil {
+				fmt.Printf("[ERROR] Trade expiry sweep failed: %v\n", err)
+			} else if count > 0 {
+				fmt.Printf("[INFO] Trade expiry sweep closed %d trades\n", count)
+			}
+			<-ticker.C
+		}
+	}()
 }
 
-// GetUserTradeOffers 获取用户的交易报价
-// 功能：获取指定用户收到和发出的交易报价
-func (tm *TradingManager) GetUserTradeOffers(userID int) (sent []*TradeOffer, received []*TradeOffer, err error) {
+// ═══════════════════════════════════════════════════════════
+// 玩家直接交易
+// ═══════════════════════════════════════════════════════════
+
+// OpenTrade 发起交易窗口
+// 功能：双方各自放入装备与金币，双方都确认后原子完成
+func (tm *TradingManager) OpenTrade(initiatorID int, partnerID int) (*models.TradeSession, error) {
+	if initiatorID == partnerID {
+		return nil, ErrTradeWithSelf
+	}
+	if _, err := tm.userRepo.GetByID(partnerID); err != nil {
+		return nil, ErrTradePartnerNotFound
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	now := time.Now()
+	trade, err := tm.tradeRepo.CreateTrade(initiatorID, partnerID, now.Add(tradeWindowTimeout), now)
+	if err != nil {
+		return nil, err
+	}
+	tm.notifyTradeParty(trade, initiatorID, fmt.Sprintf("%s 向你发起了交易", trade.InitiatorName))
+	return trade, nil
+}
+
+// OpenTradeByName 按角色名发起交易窗口
+func (tm *TradingManager) OpenTradeByName(initiatorID int, partnerName string) (*models.TradeSession, error) {
+	partner, err := tm.userRepo.GetByUsername(partnerName)
+	if err != nil || partner == nil {
+		return nil, ErrTradePartnerNotFound
+	}
+	return tm.OpenTrade(initiatorID, partner.ID)
+}
+
+// GetTrade 获取交易窗口（仅交易双方可见）
+func (tm *TradingManager) GetTrade(userID int, tradeID int) (*models.TradeSession, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	trade, err := tm.tradeRepo.GetTrade(tradeID)
+	if err != nil {
+		return nil, err
+	}
+	if !trade.IsParticipant(userID) {
+		return nil, repository.ErrTradeNotParticipant
+	}
+	return trade, nil
+}
+
+// GetUserTrades 获取用户未结束的交易窗口
+func (tm *TradingManager) GetUserTrades(userID int) ([]*models.TradeSession, error) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	// TODO: 需要实现 TradeRepository
-	// sent, err = tm.tradeRepo.GetOffersByFromUserID(userID)
-	// if err != nil {
-	// 	return nil, nil, fmt.Errorf("failed to get sent offers: %w", err)
-	// }
+	return tm.tradeRepo.GetOpenTradesByUser(userID, time.Now())
+}
+
+// AddTradeItem 放入装备（重置双方确认）
+func (tm *TradingManager) AddTradeItem(userID int, tradeID int, equipmentID int) (*models.TradeSession, error) {
+	return tm.modifyTrade(userID, tradeID, func(tx *sql.Tx, trade *models.TradeSession, now time.Time) (string, error) {
+		if _, err := tm.equipmentRepo.CheckTradableTx(tx, userID, equipmentID); err != nil {
+			return "", err
+		}
+		if err := tm.tradeRepo.AddItemTx(tx, trade.ID, userID, equipmentID, now); err != nil {
+			return "", err
+		}
+		itemName, err := tm.equipmentRepo.GetNameTx(tx, equipmentID)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("放入了物品 %s", itemName), nil
+	})
+}
+
+// RemoveTradeItem 取回装备（重置双方确认）
+func (tm *TradingManager) RemoveTradeItem(userID int, tradeID int, equipmentID int) (*models.TradeSession, error) {
+	return tm.modifyTrade(userID, tradeID, func(tx *sql.Tx, trade *models.TradeSession, now time.Time) (string, error) {
+		if err := tm.tradeRepo.RemoveItemTx(tx, trade.ID, userID, equipmentID); err != nil {
+			return "", err
+		}
+		return "取回了一件物品", nil
+	})
+}
+
+// SetTradeGold 设置放入的金币（重置双方确认）
+func (tm *TradingManager) SetTradeGold(userID int, tradeID int, amount int) (*models.TradeSession, error) {
+	if amount < 0 {
+		return nil, ErrInvalidTradeGold
+	}
+	gold, err := tm.economyMgr.GetGold(userID)
+	if err != nil {
+		return nil, err
+	}
+	if gold < amount {
+		return nil, repository.ErrInsufficientGold
+	}
+
+	return tm.modifyTrade(userID, tradeID, func(tx *sql.Tx, trade *models.TradeSession, now time.Time) (string, error) {
+		if err := tm.tradeRepo.SetGoldTx(tx, trade, userID, amount); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("将交易金币设置为 %d", amount), nil
+	})
+}
+
+// ConfirmTrade 确认交易，双方都确认后在同一事务中交换装备与金币
+func (tm *TradingManager) ConfirmTrade(userID int, tradeID int) (*models.TradeSession, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	now := time.Now()
+	trade, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.TradeSession, error) {
+		trade, err := tm.getOpenTradeTx(tx, userID, tradeID, now)
+		if err != nil {
+			return nil, err
+		}
+		if err := tm.tradeRepo.ConfirmTx(tx, trade, userID, now); err != nil {
+			return nil, err
+		}
+		trade, err = tm.tradeRepo.GetTradeTx(tx, tradeID)
+		if err != nil {
+			return nil, err
+		}
+		if trade.InitiatorConfirmed && trade.PartnerConfirmed {
+			if err := tm.completeTradeTx(tx, trade, now); err != nil {
+				return nil, err
+			}
+			return tm.tradeRepo.GetTradeTx(tx, tradeID)
+		}
+		return trade, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if trade.Status == "completed" {
+		tm.notifyTradeParty(trade, userID, "交易已完成")
+	} else {
+		tm.notifyTradeParty(trade, userID, "已确认交易")
+	}
+	return trade, nil
+}
 
-	// received, err = tm.tradeRepo.GetOffersByToUserID(userID)
-	// if err != nil {
-	// 	return nil, nil, fmt.Errorf("failed to get received offers: %w", err)
-	// }
+// CancelTrade 取消交易
+func (tm *TradingManager) CancelTrade(userID int, tradeID int) (*models.TradeSession, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
-	// return sent, received, nil
-	return nil, nil, fmt.Errorf("TradeRepository not implemented yet")
+	now := time.Now()
+	trade, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.TradeSession, error) {
+		if _, err := tm.getOpenTradeTx(tx, userID, tradeID, now); err != nil {
+			return nil, err
+		}
+		if err := tm.tradeRepo.CloseTradeTx(tx, tradeID, "cancelled", now); err != nil {
+			return nil, err
+		}
+		return tm.tradeRepo.GetTradeTx(tx, tradeID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tm.notifyTradeParty(trade, userID, "取消了交易")
+	return trade, nil
+}
+
+// ExpireTrades 关闭超时的交易窗口
+func (tm *TradingManager) ExpireTrades(now time.Time) (int, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	expired, err := tm.tradeRepo.ExpireTrades(now)
+	return len(expired), err
+}
+
+// modifyTrade 修改交易内容：执行修改、重置双方确认、顺延超时并通知另一方
+func (tm *TradingManager) modifyTrade(userID, tradeID int, fn func(tx *sql.Tx, trade *models.TradeSession, now time.Time) (string, error)) (*models.TradeSession, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	now := time.Now()
+	var notice string
+	trade, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.TradeSession, error) {
+		trade, err := tm.getOpenTradeTx(tx, userID, tradeID, now)
+		if err != nil {
+			return nil, err
+		}
+		if notice, err = fn(tx, trade, now); err != nil {
+			return nil, err
+		}
+		if err := tm.tradeRepo.TouchTx(tx, tradeID, now.Add(tradeWindowTimeout), now); err != nil {
+			return nil, err
+		}
+		return tm.tradeRepo.GetTradeTx(tx, tradeID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tm.notifyTradeParty(trade, userID, notice)
+	return trade, nil
+}
+
+// getOpenTradeTx 获取用户参与的进行中交易窗口
+func (tm *TradingManager) getOpenTradeTx(tx *sql.Tx, userID, tradeID int, now time.Time) (*models.TradeSession, error) {
+	trade, err := tm.tradeRepo.GetTradeTx(tx, tradeID)
+	if err != nil {
+		return nil, err
+	}
+	if !trade.IsParticipant(userID) {
+		return nil, repository.ErrTradeNotParticipant
+	}
+	if trade.Status != "open" {
+		return nil, repository.ErrTradeNotOpen
+	}
+	if !trade.ExpiresAt.After(now) {
+		return nil, repository.ErrTradeExpired
+	}
+	return trade, nil
+}
+
+// completeTradeTx 完成交易：重新校验并交换双方装备，交换双方金币
+func (tm *TradingManager) completeTradeTx(tx *sql.Tx, trade *models.TradeSession, now time.Time) error {
+	for _, item := range trade.Items {
+		if _, err := tm.equipmentRepo.CheckTradableTx(tx, item.UserID, item.EquipmentID); err != nil {
+			return fmt.Errorf("item %s can no longer be traded: %w", item.ItemName, err)
+		}
+		if err := tm.equipmentRepo.TransferTx(tx, item.EquipmentID, trade.OtherParty(item.UserID)); err != nil {
+			return err
+		}
+	}
+
+	ref := repository.NewGoldRef("trade", trade.ID)
+	if err := tm.economyMgr.SpendGoldTx(tx, trade.InitiatorID, trade.InitiatorGold, repository.GoldReasonTradeGive, ref); err != nil {
+		return fmt.Errorf("%s: %w", trade.InitiatorName, err)
+	}
+	if err := tm.economyMgr.SpendGoldTx(tx, trade.PartnerID, trade.PartnerGold, repository.GoldReasonTradeGive, ref); err != nil {
+		return fmt.Errorf("%s: %w", trade.PartnerName, err)
+	}
+	if err := tm.economyMgr.ReceiveGoldTx(tx, trade.PartnerID, trade.InitiatorGold, repository.GoldReasonTradeReceive, ref); err != nil {
+		return err
+	}
+	if err := tm.economyMgr.ReceiveGoldTx(tx, trade.InitiatorID, trade.PartnerGold, repository.GoldReasonTradeReceive, ref); err != nil {
+		return err
+	}
+
+	return tm.tradeRepo.CloseTradeTx(tx, trade.ID, "completed", now)
+}
+
+// notifyAuctionMail 拍卖结束后通知卖家与买家查收邮件
+func (tm *TradingManager) notifyAuctionMail(listing *models.AuctionListing) {
+	switch listing.Status {
+	case "sold":
+		if listing.BuyerID != nil {
+			tm.mailMgr.NotifyMailbox(listing.SellerID, *listing.BuyerID)
+		}
+	case "expired":
+		tm.mailMgr.NotifyMailbox(listing.SellerID)
+	}
+}
+
+// notifyTradeParty 以私聊形式通知交易另一方，并推送交易状态
+func (tm *TradingManager) notifyTradeParty(trade *models.TradeSession, fromUserID int, content string) {
+	if tm.chatRepo == nil || content == "" {
+		return
+	}
+	senderName := trade.PartnerName
+	if trade.InitiatorID == fromUserID {
+		senderName = trade.InitiatorName
+	}
+	receiverID := trade.OtherParty(fromUserID)
+	msg, err := tm.chatRepo.SendMessage(&repository.ChatMessage{
+		Channel:    "whisper",
+		SenderID:   fromUserID,
+		SenderName: senderName,
+		ReceiverID: receiverID,
+		Content:    fmt.Sprintf("[交易#%d] %s", trade.ID, content),
+	})
+	if err != nil {
+		fmt.Printf("[WARN] Failed to send trade notification: %v\n", err)
+		return
+	}
+	if tm.eventHub != nil {
+		tm.eventHub.Publish(receiverID, EventChat, msg)
+		tm.eventHub.Publish(receiverID, EventTrade, &models.TradeEvent{Trade: trade, Message: content})
+	}
 }
 
 // CalculateTransactionFee 计算交易手续费
 // 功能：计算交易手续费（价格的5%）
 func (tm *TradingManager) CalculateTransactionFee(price int) int {
-	return int(float64(price) * 0.05)
+	return int(float64(price) * transactionFeeRate)
 }
 
 // CalculateListingFee 计算上架费
 // 功能：计算上架费（价格的5%，最低10金币）
 func (tm *TradingManager) CalculateListingFee(price int) int {
-	fee := int(float64(price) * 0.05)
-	if fee < 10 {
-		fee = 10
+	fee := int(float64(price) * listingFeeRate)
+	if fee < minListingFee {
+		fee = minListingFee
 	}
 	return fee
 }
-
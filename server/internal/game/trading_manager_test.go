@@ -0,0 +1,381 @@
+package game
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ═══════════════════════════════════════════════════════════
+// 测试辅助函数
+// ═══════════════════════════════════════════════════════════
+
+func setupTradingTest(t *testing.T, usernames ...string) (*sql.DB, *TradingManager, []int) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = testDB.Exec(`
+		INSERT INTO items (id, name, type, quality, slot, level_required)
+		VALUES ('trade_sword', '交易长剑', 'equipment', 'rare', 'main_hand', 10)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert item: %v", err)
+	}
+
+	userIDs := make([]int, 0, len(usernames))
+	for _, name := range usernames {
+		user, err := repository.NewUserRepository().Create(name, "hash", "")
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if _, err := testDB.Exec(`UPDATE users SET gold = 1000 WHERE id = ?`, user.ID); err != nil {
+			t.Fatalf("Failed to set gold: %v", err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+	return testDB, NewTradingManager(), userIDs
+}
+
+func createTradeEquipment(t *testing.T, ownerID int) int {
+	equipment, err := repository.NewEquipmentRepository().Create(&models.EquipmentInstance{
+		ItemID:         "trade_sword",
+		OwnerID:        ownerID,
+		Slot:           "main_hand",
+		Quality:        "rare",
+		EvolutionStage: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create equipment: %v", err)
+	}
+	return equipment.ID
+}
+
+func claimAllMail(t *testing.T, userID int) int {
+	mails, err := GetMailManager().GetMailbox(userID)
+	if err != nil {
+		t.Fatalf("Failed to get mailbox: %v", err)
+	}
+	claimed := 0
+	for _, mail := range mails {
+		if !mail.HasUnclaimedContent() {
+			continue
+		}
+		if _, err := GetMailManager().ClaimMail(userID, mail.ID); err != nil {
+			t.Fatalf("Failed to claim mail: %v", err)
+		}
+		claimed++
+	}
+	return claimed
+}
+
+func goldOf(t *testing.T, userID int) int {
+	user, err := repository.NewUserRepository().GetByID(userID)
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	return user.Gold
+}
+
+// ═══════════════════════════════════════════════════════════
+// 一口价测试
+// ═══════════════════════════════════════════════════════════
+
+func TestTradingManager_ListAndBuyItem(t *testing.T) {
+	testDB, tm, users := setupTradingTest(t, "seller", "buyer")
+	defer database.TeardownTestDB(testDB)
+	seller, buyer := users[0], users[1]
+
+	equipmentID := createTradeEquipment(t, seller)
+	listing, err := tm.ListItem(seller, equipmentID, 200, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 990, goldOf(t, seller), "上架费最低10金币")
+
+	escrowed, err := repository.NewAuctionRepository().IsEquipmentEscrowed(equipmentID)
+	assert.NoError(t, err)
+	assert.True(t, escrowed)
+
+	_, err = tm.BuyItem(seller, listing.ID)
+	assert.ErrorIs(t, err, repository.ErrAuctionOwnListing)
+	_, err = tm.PlaceBid(buyer, listing.ID, 300)
+	assert.ErrorIs(t, err, repository.ErrAuctionNoBidding, "一口价上架不接受出价")
+
+	sold, err := tm.BuyItem(buyer, listing.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "sold", sold.Status)
+	assert.Equal(t, 200, *sold.SoldPrice)
+	assert.Equal(t, 800, goldOf(t, buyer))
+	assert.Equal(t, 990, goldOf(t, seller), "货款通过邮件发放")
+	assert.Equal(t, 1, claimAllMail(t, seller))
+	assert.Equal(t, 1180, goldOf(t, seller), "卖家收到扣除5%手续费后的金币")
+
+	equipment, err := repository.NewEquipmentRepository().GetByID(equipmentID)
+	assert.NoError(t, err)
+	assert.Equal(t, buyer, equipment.OwnerID)
+	escrowed, err = repository.NewAuctionRepository().IsEquipmentEscrowed(equipmentID)
+	assert.NoError(t, err)
+	assert.True(t, escrowed, "购得的装备在邮件领取前处于托管状态")
+	assert.Equal(t, 1, claimAllMail(t, buyer))
+
+	_, err = tm.BuyItem(buyer, listing.ID)
+	assert.ErrorIs(t, err, repository.ErrAuctionListingNotActive)
+}
+
+func TestTradingManager_BuyItemInsufficientGold(t *testing.T) {
+	testDB, tm, users := setupTradingTest(t, "seller", "buyer")
+	defer database.TeardownTestDB(testDB)
+	seller, buyer := users[0], users[1]
+
+	listing, err := tm.ListItem(seller, createTradeEquipment(t, seller), 5000, 1)
+	assert.NoError(t, err)
+
+	_, err = tm.BuyItem(buyer, listing.ID)
+	assert.ErrorIs(t, err, repository.ErrInsufficientGold)
+	assert.Equal(t, 1000, goldOf(t, buyer), "失败的购买不应扣除金币")
+
+	current, err := repository.NewAuctionRepository().GetListingByID(listing.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "active", current.Status)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 竞价测试
+// ═══════════════════════════════════════════════════════════
+
+func TestTradingManager_BidOutbidRefundAndSettle(t *testing.T) {
+	testDB, tm, users := setupTradingTest(t, "seller", "bidder1", "bidder2")
+	defer database.TeardownTestDB(testDB)
+	seller, bidder1, bidder2 := users[0], users[1], users[2]
+
+	equipmentID := createTradeEquipment(t, seller)
+	listing, err := tm.ListAuction(seller, equipmentID, AuctionOptions{StartingBid: 100, BuyoutPrice: 500, DurationDays: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, listing.MinIncrement, "默认最低加价为起拍价的5%")
+	assert.Equal(t, 100, MinNextBid(listing))
+
+	_, err = tm.PlaceBid(bidder1, listing.ID, 99)
+	assert.ErrorIs(t, err, repository.ErrAuctionBidTooLow)
+
+	listing, err = tm.PlaceBid(bidder1, listing.ID, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 900, goldOf(t, bidder1), "出价金币由拍卖行托管")
+	_, err = tm.PlaceBid(bidder1, listing.ID, 200)
+	assert.ErrorIs(t, err, repository.ErrAuctionAlreadyHighest)
+
+	_, err = tm.PlaceBid(bidder2, listing.ID, 104)
+	assert.ErrorIs(t, err, repository.ErrAuctionBidTooLow)
+	listing, err = tm.PlaceBid(bidder2, listing.ID, 120)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, goldOf(t, bidder1), "被超过的出价应退还")
+	assert.Equal(t, 880, goldOf(t, bidder2))
+	assert.Equal(t, 2, listing.BidCount)
+
+	_, err = tm.CancelListing(seller, listing.ID)
+	assert.ErrorIs(t, err, repository.ErrAuctionHasBids)
+
+	count, err := tm.ExpireListings(time.Now().Add(48 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	settled, err := repository.NewAuctionRepository().GetListingByID(listing.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "sold", settled.Status)
+	assert.Equal(t, bidder2, *settled.BuyerID)
+	assert.Equal(t, 880, goldOf(t, bidder2))
+	assert.Equal(t, 1, claimAllMail(t, seller))
+	assert.Equal(t, 1000-10+120-6, goldOf(t, seller))
+
+	equipment, err := repository.NewEquipmentRepository().GetByID(equipmentID)
+	assert.NoError(t, err)
+	assert.Equal(t, bidder2, equipment.OwnerID, "拍卖结束后装备归最高出价者")
+}
+
+func TestTradingManager_AntiSnipingAndBuyoutBid(t *testing.T) {
+	testDB, tm, users := setupTradingTest(t, "seller", "bidder1", "bidder2")
+	defer database.TeardownTestDB(testDB)
+	seller, bidder1, bidder2 := users[0], users[1], users[2]
+
+	listing, err := tm.ListAuction(seller, createTradeEquipment(t, seller), AuctionOptions{StartingBid: 100, MinIncrement: 20, BuyoutPrice: 400})
+	assert.NoError(t, err)
+
+	// 距结束仅剩2分钟
+	_, err = testDB.Exec(`UPDATE auction_listings SET expires_at = ? WHERE id = ?`, time.Now().Add(2*time.Minute).UTC(), listing.ID)
+	assert.NoError(t, err)
+
+	listing, err = tm.PlaceBid(bidder1, listing.ID, 150)
+	assert.NoError(t, err)
+	assert.True(t, listing.ExpiresAt.After(time.Now().Add(4*time.Minute)), "结束前出价应延长拍卖")
+
+	listing, err = tm.PlaceBid(bidder2, listing.ID, 400)
+	assert.NoError(t, err)
+	assert.Equal(t, "sold", listing.Status, "出价达到一口价时直接成交")
+	assert.Equal(t, bidder2, *listing.BuyerID)
+	assert.Equal(t, 1000, goldOf(t, bidder1), "一口价成交时退还最高出价者")
+	assert.Equal(t, 600, goldOf(t, bidder2))
+
+	bids, err := tm.GetListingBids(listing.ID)
+	assert.NoError(t, err)
+	assert.Len(t, bids, 1)
+	assert.Equal(t, "refunded", bids[0].Status)
+}
+
+func TestTradingManager_ExpireWithoutBidsReturnsItem(t *testing.T) {
+	testDB, tm, users := setupTradingTest(t, "seller")
+	defer database.TeardownTestDB(testDB)
+	seller := users[0]
+
+	equipmentID := createTradeEquipment(t, seller)
+	listing, err := tm.ListAuction(seller, equipmentID, AuctionOptions{StartingBid: 100, DurationDays: 1})
+	assert.NoError(t, err)
+
+	count, err := tm.ExpireListings(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count, "未到期的拍卖不应处理")
+
+	count, err = tm.ExpireListings(time.Now().Add(48 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	expired, err := repository.NewAuctionRepository().GetListingByID(listing.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "expired", expired.Status)
+	assert.Equal(t, seller, expired.Equipment.OwnerID)
+
+	assert.Equal(t, 1, claimAllMail(t, seller), "流拍的装备通过邮件退回")
+	escrowed, err := repository.NewAuctionRepository().IsEquipmentEscrowed(equipmentID)
+	assert.NoError(t, err)
+	assert.False(t, escrowed, "领取邮件后装备解除托管")
+}
+
+// ═══════════════════════════════════════════════════════════
+// 玩家直接交易测试
+// ═══════════════════════════════════════════════════════════
+
+func TestTradingManager_DirectTradeCompletes(t *testing.T) {
+	testDB, tm, users := setupTradingTest(t, "alice", "bob")
+	defer database.TeardownTestDB(testDB)
+	alice, bob := users[0], users[1]
+
+	_, err := tm.OpenTradeByName(alice, "alice")
+	assert.ErrorIs(t, err, ErrTradeWithSelf)
+	_, err = tm.OpenTradeByName(alice, "nobody")
+	assert.ErrorIs(t, err, ErrTradePartnerNotFound)
+
+	trade, err := tm.OpenTradeByName(alice, "bob")
+	assert.NoError(t, err)
+
+	equipmentID := createTradeEquipment(t, alice)
+	_, err = tm.AddTradeItem(bob, trade.ID, equipmentID)
+	assert.ErrorIs(t, err, repository.ErrEquipmentNotOwned)
+	_, err = tm.AddTradeItem(alice, trade.ID, equipmentID)
+	assert.NoError(t, err)
+	_, err = tm.SetTradeGold(bob, trade.ID, 300)
+	assert.NoError(t, err)
+
+	trade, err = tm.ConfirmTrade(alice, trade.ID)
+	assert.NoError(t, err)
+	assert.True(t, trade.InitiatorConfirmed)
+
+	// 任一方修改交易内容都会重置双方确认
+	trade, err = tm.SetTradeGold(bob, trade.ID, 250)
+	assert.NoError(t, err)
+	assert.False(t, trade.InitiatorConfirmed)
+
+	_, err = tm.ConfirmTrade(bob, trade.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, goldOf(t, bob), "仅一方确认时不应交换")
+
+	trade, err = tm.ConfirmTrade(alice, trade.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "completed", trade.Status)
+	assert.Equal(t, 1250, goldOf(t, alice))
+	assert.Equal(t, 750, goldOf(t, bob))
+
+	ledger, err := NewEconomyManager().GetLedger(bob, repository.GoldReasonTradeGive, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, ledger, 1, "交易金币变动应写入流水")
+	assert.Equal(t, -250, ledger[0].Delta)
+	assert.Equal(t, 750, ledger[0].BalanceAfter)
+	assert.Equal(t, "trade", ledger[0].RefType)
+
+	equipment, err := repository.NewEquipmentRepository().GetByID(equipmentID)
+	assert.NoError(t, err)
+	assert.Equal(t, bob, equipment.OwnerID)
+
+	var whispers int
+	err = testDB.QueryRow(`SELECT COUNT(*) FROM chat_messages WHERE channel = 'whisper' AND receiver_id = ?`, bob).Scan(&whispers)
+	assert.NoError(t, err)
+	assert.Greater(t, whispers, 0, "交易操作应通知另一方")
+	var itemWhisper int
+	err = testDB.QueryRow(`SELECT COUNT(*) FROM chat_messages WHERE channel = 'whisper' AND receiver_id = ? AND content LIKE '%放入了物品 交易长剑'`, bob).Scan(&itemWhisper)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, itemWhisper, "放入物品的通知应显示物品名称")
+
+	_, err = tm.CancelTrade(alice, trade.ID)
+	assert.ErrorIs(t, err, repository.ErrTradeNotOpen)
+}
+
+func TestTradingManager_DirectTradeRollsBackOnInsufficientGold(t *testing.T) {
+	testDB, tm, users := setupTradingTest(t, "alice", "bob")
+	defer database.TeardownTestDB(testDB)
+	alice, bob := users[0], users[1]
+
+	trade, err := tm.OpenTrade(alice, bob)
+	assert.NoError(t, err)
+	equipmentID := createTradeEquipment(t, alice)
+	_, err = tm.AddTradeItem(alice, trade.ID, equipmentID)
+	assert.NoError(t, err)
+	_, err = tm.SetTradeGold(bob, trade.ID, 800)
+	assert.NoError(t, err)
+	_, err = tm.ConfirmTrade(alice, trade.ID)
+	assert.NoError(t, err)
+
+	// 确认后金币被花掉
+	_, err = testDB.Exec(`UPDATE users SET gold = 100 WHERE id = ?`, bob)
+	assert.NoError(t, err)
+
+	_, err = tm.ConfirmTrade(bob, trade.ID)
+	assert.ErrorIs(t, err, repository.ErrInsufficientGold)
+
+	equipment, err := repository.NewEquipmentRepository().GetByID(equipmentID)
+	assert.NoError(t, err)
+	assert.Equal(t, alice, equipment.OwnerID, "交易失败时装备不应转移")
+	assert.Equal(t, 1000, goldOf(t, alice))
+
+	current, err := tm.GetTrade(bob, trade.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "open", current.Status)
+	assert.False(t, current.PartnerConfirmed)
+}
+
+func TestTradingManager_DirectTradeExpiry(t *testing.T) {
+	testDB, tm, users := setupTradingTest(t, "alice", "bob", "carol")
+	defer database.TeardownTestDB(testDB)
+	alice, bob, carol := users[0], users[1], users[2]
+
+	trade, err := tm.OpenTrade(alice, bob)
+	assert.NoError(t, err)
+	_, err = tm.GetTrade(carol, trade.ID)
+	assert.ErrorIs(t, err, repository.ErrTradeNotParticipant)
+
+	_, err = testDB.Exec(`UPDATE trade_sessions SET expires_at = ? WHERE id = ?`, time.Now().Add(-time.Minute).UTC(), trade.ID)
+	assert.NoError(t, err)
+
+	_, err = tm.SetTradeGold(alice, trade.ID, 10)
+	assert.ErrorIs(t, err, repository.ErrTradeExpired)
+
+	count, err := tm.ExpireTrades(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	trades, err := tm.GetUserTrades(alice)
+	assert.NoError(t, err)
+	assert.Empty(t, trades)
+}
@@ -0,0 +1,392 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+)
+
+// 商人错误
+var (
+	ErrVendorNotInZone       = errors.New("vendor is not in your current zone")
+	ErrInvalidVendorQuantity = errors.New("invalid quantity")
+)
+
+const (
+	maxVendorPurchaseQuantity = 20            // 单次购买数量上限
+	vendorBuybackSlots        = 12            // 回购栏容量
+	vendorBuybackExpiry       = 2 * time.Hour // 卖出物品的回购期限
+)
+
+// VendorManager NPC商人管理器 - 区域商人购买、出售与回购
+type VendorManager struct {
+	mu            sync.Mutex
+	vendorRepo    *repository.VendorRepository
+	userRepo      *repository.UserRepository
+	charRepo      *repository.CharacterRepository
+	equipmentRepo *repository.EquipmentRepository
+	economyMgr    *EconomyManager
+}
+
+// NewVendorManager 创建商人管理器
+func NewVendorManager() *VendorManager {
+	return &VendorManager{
+		vendorRepo:    repository.NewVendorRepository(),
+		userRepo:      repository.NewUserRepository(),
+		charRepo:      repository.NewCharacterRepository(),
+		equipmentRepo: repository.NewEquipmentRepository(),
+		economyMgr:    NewEconomyManager(),
+	}
+}
+
+// 全局商人管理器实例
+var vendorManager *VendorManager
+var vendorOnce sync.Once
+
+// GetVendorManager 获取商人管理器单例
+func GetVendorManager() *VendorManager {
+	vendorOnce.Do(func() {
+		vendorManager = NewVendorManager()
+	})
+	return vendorManager
+}
+
+// ═══════════════════════════════════════════════════════════
+// 查询
+// ═══════════════════════════════════════════════════════════
+
+// GetZoneVendors 获取玩家当前区域的商人
+func (vm *VendorManager) GetZoneVendors(userID int) (string, []*models.Vendor, error) {
+	user, err := vm.userRepo.GetByID(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	vendors, err := vm.vendorRepo.GetZoneVendors(user.CurrentZoneID)
+	if err != nil {
+		return "", nil, err
+	}
+	if vendors == nil {
+		vendors = make([]*models.Vendor, 0)
+	}
+	return user.CurrentZoneID, vendors, nil
+}
+
+// GetVendor 获取商人详情（必须位于玩家当前区域）
+func (vm *VendorManager) GetVendor(userID int, vendorID string) (*models.Vendor, error) {
+	vendor, err := vm.vendorRepo.GetVendor(vendorID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := vm.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if vendor.ZoneID != user.CurrentZoneID {
+		return nil, ErrVendorNotInZone
+	}
+	return vendor, nil
+}
+
+// GetBuyback 获取回购栏
+func (vm *VendorManager) GetBuyback(userID int) ([]*models.VendorBuyback, error) {
+	return vm.vendorRepo.GetBuybackList(userID, time.Now())
+}
+
+// ═══════════════════════════════════════════════════════════
+// 购买
+// ═══════════════════════════════════════════════════════════
+
+// BuyItem 从商人处购买物品（装备放入背包，其他物品放入指定角色背包，未指定时放入小队第一个角色）
+func (vm *VendorManager) BuyItem(userID int, vendorID, itemID string, quantity, characterID int) (*models.VendorPurchase, error) {
+	if quantity < 1 || quantity > maxVendorPurchaseQuantity {
+		return nil, ErrInvalidVendorQuantity
+	}
+	if _, err := vm.GetVendor(userID, vendorID); err != nil {
+		return nil, err
+	}
+	characterID, err := vm.resolveCharacter(userID, characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.VendorPurchase, error) {
+		vendor, err := vm.vendorRepo.GetVendorTx(tx, vendorID)
+		if err != nil {
+			return nil, err
+		}
+		var item *models.VendorItem
+		for _, candidate := range vendor.Items {
+			if candidate.ItemID == itemID {
+				item = candidate
+				break
+			}
+		}
+		if item == nil {
+			return nil, repository.ErrVendorItemNotFound
+		}
+		if item.ItemType == "equipment" && quantity != 1 {
+			return nil, ErrInvalidVendorQuantity
+		}
+
+		if err := vm.vendorRepo.TakeStockTx(tx, vendorID, itemID, quantity); err != nil {
+			return nil, err
+		}
+		total := item.Price * quantity
+		if err := vm.economyMgr.SpendGoldTx(tx, userID, total, repository.GoldReasonVendorBuy,
+			repository.GoldRef{Type: "vendor", ID: vendorID}); err != nil {
+			return nil, err
+		}
+
+		purchase := &models.VendorPurchase{
+			VendorID:   vendorID,
+			ItemID:     itemID,
+			ItemName:   item.ItemName,
+			Quantity:   quantity,
+			TotalPrice: total,
+		}
+		purchase.EquipmentID, err = vm.vendorRepo.GrantItemTx(tx, userID, characterID, itemID, quantity)
+		if err != nil {
+			return nil, err
+		}
+		if purchase.EquipmentID == nil {
+			purchase.CharacterID = &characterID
+		}
+		return purchase, nil
+	})
+}
+
+// ═══════════════════════════════════════════════════════════
+// 出售与回购
+// ═══════════════════════════════════════════════════════════
+
+// SellInventoryItem 将背包物品卖给商人（quantity为0时出售整组），价格按物品品质计算
+func (vm *VendorManager) SellInventoryItem(userID int, vendorID string, inventoryID, quantity int) (*models.VendorBuyback, error) {
+	if quantity < 0 {
+		return nil, ErrInvalidVendorQuantity
+	}
+	if _, err := vm.GetVendor(userID, vendorID); err != nil {
+		return nil, err
+	}
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	now := time.Now()
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.VendorBuyback, error) {
+		item, err := vm.vendorRepo.GetInventorySellableTx(tx, userID, inventoryID)
+		if err != nil {
+			return nil, err
+		}
+		if quantity == 0 {
+			quantity = item.Quantity
+		}
+		if quantity > item.Quantity {
+			return nil, repository.ErrInsufficientQuantity
+		}
+		price := vm.economyMgr.CalculateMaterialPrice(item.SellPrice, item.Quality) * quantity
+		if price <= 0 {
+			return nil, repository.ErrItemNotSellable
+		}
+
+		if err := vm.vendorRepo.RemoveInventoryItemTx(tx, inventoryID, quantity); err != nil {
+			return nil, err
+		}
+		characterID := item.CharacterID
+		return vm.recordSaleTx(tx, &models.VendorBuyback{
+			UserID:      userID,
+			CharacterID: &characterID,
+			ItemID:      item.ItemID,
+			Quantity:    quantity,
+			Price:       price,
+			SoldAt:      now,
+			ExpiresAt:   now.Add(vendorBuybackExpiry),
+		})
+	})
+}
+
+// SellEquipment 将背包中的装备卖给商人，回购期内装备处于托管状态，过期后销毁
+func (vm *VendorManager) SellEquipment(userID int, vendorID string, equipmentID int) (*models.VendorBuyback, error) {
+	if _, err := vm.GetVendor(userID, vendorID); err != nil {
+		return nil, err
+	}
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	now := time.Now()
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.VendorBuyback, error) {
+		if _, err := vm.equipmentRepo.CheckTradableTx(tx, userID, equipmentID); err != nil {
+			return nil, err
+		}
+		item, err := vm.vendorRepo.GetEquipmentSellableTx(tx, equipmentID)
+		if err != nil {
+			return nil, err
+		}
+		price := vm.economyMgr.CalculateEquipmentSellPrice(item.SellPrice, item.Quality)
+		if price <= 0 {
+			return nil, repository.ErrItemNotSellable
+		}
+
+		return vm.recordSaleTx(tx, &models.VendorBuyback{
+			UserID:      userID,
+			ItemID:      item.ItemID,
+			Quantity:    1,
+			EquipmentID: &equipmentID,
+			Price:       price,
+			SoldAt:      now,
+			ExpiresAt:   now.Add(vendorBuybackExpiry),
+		})
+	})
+}
+
+// Buyback 按卖出价买回最近卖出的物品（背包物品放回原角色，原角色不存在时放入指定角色）
+func (vm *VendorManager) Buyback(userID int, vendorID string, buybackID, characterID int) (*models.VendorBuyback, error) {
+	if _, err := vm.GetVendor(userID, vendorID); err != nil {
+		return nil, err
+	}
+	fallbackCharacterID, err := vm.resolveCharacter(userID, characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	now := time.Now()
+	return repository.WithTransactionResult(func(tx *sql.Tx) (*models.VendorBuyback, error) {
+		buyback, err := vm.vendorRepo.GetBuybackTx(tx, buybackID)
+		if err != nil {
+			return nil, err
+		}
+		if buyback.UserID != userID {
+			return nil, repository.ErrBuybackNotFound
+		}
+		if buyback.Status != "available" || !now.Before(buyback.ExpiresAt) {
+			return nil, repository.ErrBuybackNotAvailable
+		}
+
+		if err := vm.vendorRepo.CloseBuybackTx(tx, buyback.ID, "bought_back", now); err != nil {
+			return nil, err
+		}
+		if err := vm.economyMgr.SpendGoldTx(tx, userID, buyback.Price, repository.GoldReasonVendorBuyback,
+			repository.NewGoldRef("vendor_buyback", buyback.ID)); err != nil {
+			return nil, err
+		}
+		// 卖出的装备始终归玩家所有，结束回购记录即解除托管
+		if buyback.EquipmentID == nil {
+			targetID := fallbackCharacterID
+			if buyback.CharacterID != nil {
+				targetID = *buyback.CharacterID
+			}
+			if _, err := vm.vendorRepo.GrantItemTx(tx, userID, targetID, buyback.ItemID, buyback.Quantity); err != nil {
+				return nil, err
+			}
+		}
+		return vm.vendorRepo.GetBuybackTx(tx, buyback.ID)
+	})
+}
+
+// recordSaleTx 支付卖出所得并写入回购栏，超出容量的旧记录直接过期
+func (vm *VendorManager) recordSaleTx(tx *sql.Tx, sale *models.VendorBuyback) (*models.VendorBuyback, error) {
+	buyback, err := vm.vendorRepo.CreateBuybackTx(tx, sale)
+	if err != nil {
+		return nil, err
+	}
+	if err := vm.economyMgr.ReceiveGoldTx(tx, sale.UserID, sale.Price, repository.GoldReasonVendorSell,
+		repository.NewGoldRef("vendor_buyback", buyback.ID)); err != nil {
+		return nil, err
+	}
+
+	overflow, err := vm.vendorRepo.GetOverflowBuybackIDsTx(tx, sale.UserID, vendorBuybackSlots)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range overflow {
+		if err := vm.vendorRepo.ExpireBuybackTx(tx, id, sale.SoldAt); err != nil {
+			return nil, err
+		}
+	}
+	return buyback, nil
+}
+
+// resolveCharacter 未指定角色时使用小队第一个角色
+func (vm *VendorManager) resolveCharacter(userID, characterID int) (int, error) {
+	if characterID != 0 {
+		return characterID, nil
+	}
+	chars, err := vm.charRepo.GetByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+	if len(chars) > 0 {
+		return chars[0].ID, nil
+	}
+	return 0, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// 定时任务
+// ═══════════════════════════════════════════════════════════
+
+// RestockVendors 为到达补货间隔的商人恢复限量商品库存
+func (vm *VendorManager) RestockVendors(now time.Time) (int, error) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	return vm.vendorRepo.RestockVendors(now)
+}
+
+// ExpireBuybacks 清理过期的回购记录（卖出的装备被销毁），返回处理的记录数
+func (vm *VendorManager) ExpireBuybacks(now time.Time) (int, error) {
+	ids, err := vm.vendorRepo.GetDueBuybackIDs(now)
+	if err != nil {
+		return 0, err
+	}
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	processed := 0
+	for _, id := range ids {
+		err := repository.WithTransaction(func(tx *sql.Tx) error {
+			return vm.vendorRepo.ExpireBuybackTx(tx, id, now)
+		})
+		if errors.Is(err, repository.ErrBuybackNotAvailable) {
+			continue
+		}
+		if err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// StartRestockJob 启动商人补货与回购过期任务（启动时立即执行一次，之后按间隔执行）
+func (vm *VendorManager) StartRestockJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			if count, err := vm.RestockVendors(now); err != nil {
+				fmt.Printf("[ERROR] Vendor restock failed: %v\n", err)
+			} else if count > 0 {
+				fmt.Printf("[INFO] Restocked %d vendors\n", count)
+			}
+			if count, err := vm.ExpireBuybacks(now); err != nil {
+				fmt.Printf("[ERROR] Vendor buyback sweep failed: %v\n", err)
+			} else if count > 0 {
+				fmt.Printf("[INFO] Vendor buyback sweep expired %d entries\n", count)
+			}
+			<-ticker.C
+		}
+	}()
+}
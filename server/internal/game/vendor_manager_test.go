@@ -0,0 +1,182 @@
+package game
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+	"text-wow/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupVendorTest(t *testing.T) (*sql.DB, *VendorManager, int, int) {
+	testDB, _, users := setupTradingTest(t, "shopper")
+
+	_, err := testDB.Exec(`
+		UPDATE items SET sell_price = 20 WHERE id = 'trade_sword';
+		INSERT INTO items (id, name, type, quality, stackable, max_stack, sell_price, buy_price) VALUES
+			('vendor_potion', '商人药水', 'consumable', 'uncommon', 1, 20, 2, 10);
+		INSERT INTO vendors (id, name, zone_id, restock_interval_minutes) VALUES
+			('elwynn_vendor', '艾尔文商人', 'elwynn', 60),
+			('durotar_vendor', '杜隆塔尔商人', 'durotar', 60);
+		INSERT INTO vendor_items (vendor_id, item_id, price, max_stock, current_stock) VALUES
+			('elwynn_vendor', 'vendor_potion', NULL, 5, 5),
+			('elwynn_vendor', 'trade_sword', 100, NULL, NULL),
+			('durotar_vendor', 'vendor_potion', NULL, NULL, NULL);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert vendor data: %v", err)
+	}
+
+	char, err := repository.NewCharacterRepository().Create(&models.Character{
+		UserID: users[0], Name: "shopchar", RaceID: "human", ClassID: "warrior", Faction: "alliance",
+		TeamSlot: 1, IsActive: true, Level: 10, HP: 100, MaxHP: 100,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	return testDB, NewVendorManager(), users[0], char.ID
+}
+
+func inventoryOf(t *testing.T, testDB *sql.DB, characterID int, itemID string) (int, int) {
+	var inventoryID, quantity int
+	err := testDB.QueryRow(`
+		SELECT id, quantity FROM inventory WHERE character_id = ? AND item_id = ?
+	`, characterID, itemID).Scan(&inventoryID, &quantity)
+	if err == sql.ErrNoRows {
+		return 0, 0
+	}
+	if err != nil {
+		t.Fatalf("Failed to query inventory: %v", err)
+	}
+	return inventoryID, quantity
+}
+
+// ═══════════════════════════════════════════════════════════
+// 购买测试
+// ═══════════════════════════════════════════════════════════
+
+func TestVendorManager_BuyItem(t *testing.T) {
+	testDB, vm, userID, charID := setupVendorTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	zoneID, vendors, err := vm.GetZoneVendors(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "elwynn", zoneID)
+	assert.Len(t, vendors, 1, "只显示当前区域的商人")
+
+	_, err = vm.BuyItem(userID, "durotar_vendor", "vendor_potion", 1, 0)
+	assert.ErrorIs(t, err, ErrVendorNotInZone)
+	_, err = vm.BuyItem(userID, "elwynn_vendor", "vendor_potion", 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidVendorQuantity)
+	_, err = vm.BuyItem(userID, "elwynn_vendor", "vendor_potion", 6, 0)
+	assert.ErrorIs(t, err, repository.ErrVendorOutOfStock)
+
+	purchase, err := vm.BuyItem(userID, "elwynn_vendor", "vendor_potion", 3, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, purchase.TotalPrice)
+	assert.Equal(t, charID, *purchase.CharacterID, "未指定角色时放入第一个角色背包")
+	assert.Equal(t, 970, goldOf(t, userID))
+	_, quantity := inventoryOf(t, testDB, charID, "vendor_potion")
+	assert.Equal(t, 3, quantity)
+
+	purchase, err = vm.BuyItem(userID, "elwynn_vendor", "trade_sword", 1, 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, purchase.EquipmentID)
+	equipment, err := repository.NewEquipmentRepository().GetByID(*purchase.EquipmentID)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, equipment.OwnerID)
+	assert.Equal(t, 870, goldOf(t, userID))
+
+	_, err = testDB.Exec(`UPDATE users SET gold = 5 WHERE id = ?`, userID)
+	assert.NoError(t, err)
+	_, err = vm.BuyItem(userID, "elwynn_vendor", "vendor_potion", 1, 0)
+	assert.ErrorIs(t, err, repository.ErrInsufficientGold)
+
+	vendor, err := vm.GetVendor(userID, "elwynn_vendor")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *vendor.Items[0].Stock, "金币不足时不扣库存")
+}
+
+// ═══════════════════════════════════════════════════════════
+// 出售与回购测试
+// ═══════════════════════════════════════════════════════════
+
+func TestVendorManager_SellAndBuybackInventory(t *testing.T) {
+	testDB, vm, userID, charID := setupVendorTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	assert.NoError(t, repository.NewInventoryRepository().AddItem(charID, "vendor_potion", 5))
+	inventoryID, _ := inventoryOf(t, testDB, charID, "vendor_potion")
+
+	_, err := vm.SellInventoryItem(userID, "elwynn_vendor", inventoryID, 6)
+	assert.ErrorIs(t, err, repository.ErrInsufficientQuantity)
+
+	sold, err := vm.SellInventoryItem(userID, "elwynn_vendor", inventoryID, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, sold.Price, "优秀品质售价 2×1.5×2")
+	assert.Equal(t, 1006, goldOf(t, userID))
+	_, quantity := inventoryOf(t, testDB, charID, "vendor_potion")
+	assert.Equal(t, 3, quantity)
+
+	_, err = vm.SellInventoryItem(userID, "elwynn_vendor", inventoryID, 0)
+	assert.NoError(t, err)
+	inventoryID, _ = inventoryOf(t, testDB, charID, "vendor_potion")
+	assert.Zero(t, inventoryID, "整组出售后移除背包格")
+
+	list, err := vm.GetBuyback(userID)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	bought, err := vm.Buyback(userID, "elwynn_vendor", sold.ID, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "bought_back", bought.Status)
+	assert.Equal(t, 1009, goldOf(t, userID), "按卖出价回购")
+	_, quantity = inventoryOf(t, testDB, charID, "vendor_potion")
+	assert.Equal(t, 2, quantity)
+
+	_, err = vm.Buyback(userID, "elwynn_vendor", sold.ID, 0)
+	assert.ErrorIs(t, err, repository.ErrBuybackNotAvailable)
+
+	entries, err := NewEconomyManager().GetLedger(userID, repository.GoldReasonVendorBuyback, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestVendorManager_SellEquipmentEscrowAndExpiry(t *testing.T) {
+	testDB, vm, userID, _ := setupVendorTest(t)
+	defer database.TeardownTestDB(testDB)
+
+	kept := createTradeEquipment(t, userID)
+	sold, err := vm.SellEquipment(userID, "elwynn_vendor", kept)
+	assert.NoError(t, err)
+	assert.Equal(t, 40, sold.Price, "精良品质售价 20×2")
+	assert.Equal(t, 1040, goldOf(t, userID))
+
+	escrowed, err := repository.NewAuctionRepository().IsEquipmentEscrowed(kept)
+	assert.NoError(t, err)
+	assert.True(t, escrowed, "回购期内装备处于托管状态")
+	_, err = vm.SellEquipment(userID, "elwynn_vendor", kept)
+	assert.ErrorIs(t, err, repository.ErrEquipmentInEscrow)
+
+	_, err = vm.Buyback(userID, "elwynn_vendor", sold.ID, 0)
+	assert.NoError(t, err)
+	escrowed, err = repository.NewAuctionRepository().IsEquipmentEscrowed(kept)
+	assert.NoError(t, err)
+	assert.False(t, escrowed)
+
+	destroyed := createTradeEquipment(t, userID)
+	_, err = vm.SellEquipment(userID, "elwynn_vendor", destroyed)
+	assert.NoError(t, err)
+
+	count, err := vm.ExpireBuybacks(time.Now().Add(vendorBuybackExpiry + time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	_, err = repository.NewEquipmentRepository().GetByID(destroyed)
+	assert.Error(t, err, "过期未回购的装备被销毁")
+	_, err = repository.NewEquipmentRepository().GetByID(kept)
+	assert.NoError(t, err)
+}
@@ -14,6 +14,8 @@ type ZoneManager struct {
 	zones       map[string]*models.Zone // 区域缓存
 	gameRepo    *repository.GameRepository
 	explorationRepo *repository.ExplorationRepository
+	pvpRepo     *repository.PvPRepository
+	controls    map[string]*models.ZoneFactionControl // 阵营控制状态缓存
 }
 
 // NewZoneManager 创建地图管理器
@@ -22,6 +24,8 @@ func NewZoneManager() *ZoneManager {
 		zones:          make(map[string]*models.Zone),
 		gameRepo:       repository.NewGameRepository(),
 		explorationRepo: repository.NewExplorationRepository(),
+		pvpRepo:        repository.NewPvPRepository(),
+		controls:       make(map[string]*models.ZoneFactionControl),
 	}
 }
 
@@ -204,34 +208,82 @@ type ZoneInfo struct {
 	UnlockZoneName      string        `json:"unlockZoneName,omitempty"`
 }
 
-// CalculateExpMultiplier 计算经验倍率
-func (zm *ZoneManager) CalculateExpMultiplier(zoneID string) float64 {
-	zone, err := zm.GetZone(zoneID)
-	if err != nil {
-		return 1.0 // 默认倍率
+// CalculateExpMultiplier 计算经验倍率（包含阵营控制修正）
+func (zm *ZoneManager) CalculateExpMultiplier(zoneID string, faction string) float64 {
+	multi := 1.0 // 默认倍率
+	if zone, err := zm.GetZone(zoneID); err == nil && zone.ExpMulti > 0 {
+		multi = zone.ExpMulti
 	}
-	if zone.ExpMulti <= 0 {
-		return 1.0
+	return multi * zm.GetFactionControlMultiplier(zoneID, faction)
+}
+
+// CalculateGoldMultiplier 计算金币倍率（包含阵营控制修正）
+func (zm *ZoneManager) CalculateGoldMultiplier(zoneID string, faction string) float64 {
+	multi := 1.0 // 默认倍率
+	if zone, err := zm.GetZone(zoneID); err == nil && zone.GoldMulti > 0 {
+		multi = zone.GoldMulti
 	}
-	return zone.ExpMulti
+	return multi * zm.GetFactionControlMultiplier(zoneID, faction)
 }
 
-// CalculateGoldMultiplier 计算金币倍率
-func (zm *ZoneManager) CalculateGoldMultiplier(zoneID string) float64 {
-	zone, err := zm.GetZone(zoneID)
+// CalculateDropMultiplier 计算掉落倍率（与金币倍率相同）
+func (zm *ZoneManager) CalculateDropMultiplier(zoneID string, faction string) float64 {
+	// 掉落倍率通常与金币倍率相同
+	return zm.CalculateGoldMultiplier(zoneID, faction)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 阵营控制
+// ═══════════════════════════════════════════════════════════
+
+// GetZoneControl 获取区域阵营控制状态（带缓存）
+func (zm *ZoneManager) GetZoneControl(zoneID string) (*models.ZoneFactionControl, error) {
+	zm.mu.RLock()
+	if control, exists := zm.controls[zoneID]; exists {
+		zm.mu.RUnlock()
+		return control, nil
+	}
+	zm.mu.RUnlock()
+
+	if zm.pvpRepo == nil {
+		return nil, fmt.Errorf("pvp repository not initialized")
+	}
+	control, err := zm.pvpRepo.GetZoneControl(zoneID)
 	if err != nil {
-		return 1.0 // 默认倍率
+		return nil, err
 	}
-	if zone.GoldMulti <= 0 {
-		return 1.0
+
+	zm.SetZoneControl(control)
+	return control, nil
+}
+
+// SetZoneControl 更新区域阵营控制状态缓存
+func (zm *ZoneManager) SetZoneControl(control *models.ZoneFactionControl) {
+	if control == nil {
+		return
+	}
+	zm.mu.Lock()
+	if zm.controls == nil {
+		zm.controls = make(map[string]*models.ZoneFactionControl)
 	}
-	return zone.GoldMulti
+	zm.controls[control.ZoneID] = control
+	zm.mu.Unlock()
 }
 
-// CalculateDropMultiplier 计算掉落倍率（与金币倍率相同）
-func (zm *ZoneManager) CalculateDropMultiplier(zoneID string) float64 {
-	// 掉落倍率通常与金币倍率相同
-	return zm.CalculateGoldMultiplier(zoneID)
+// GetFactionControlMultiplier 获取阵营控制带来的收益倍率
+// 控制方获得 1+加成，被控方承受 1-惩罚，中立或无阵营时为 1
+func (zm *ZoneManager) GetFactionControlMultiplier(zoneID string, faction string) float64 {
+	if faction == "" {
+		return 1.0
+	}
+	control, err := zm.GetZoneControl(zoneID)
+	if err != nil || control.ControllingFaction == "" || control.ControllingFaction == "neutral" {
+		return 1.0
+	}
+	if control.ControllingFaction == faction {
+		return 1.0 + control.EfficiencyBonus
+	}
+	return 1.0 - control.EfficiencyPenalty
 }
 
 // ReloadZone 重新加载区域（用于热更新）
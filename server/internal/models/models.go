@@ -22,6 +22,33 @@ type User struct {
 	LastLoginAt     *time.Time `json:"lastLoginAt,omitempty"`
 }
 
+// 用户角色（权限由低到高）
+const (
+	RolePlayer     = "player"
+	RoleModerator  = "moderator"
+	RoleGameMaster = "gamemaster"
+	RoleAdmin      = "admin"
+)
+
+// roleLevels 角色权限等级
+var roleLevels = map[string]int{
+	RolePlayer:     0,
+	RoleModerator:  1,
+	RoleGameMaster: 2,
+	RoleAdmin:      3,
+}
+
+// IsValidRole 是否为已知角色
+func IsValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleAtLeast 角色权限是否不低于 required（未知角色按玩家处理）
+func RoleAtLeast(role, required string) bool {
+	return roleLevels[role] >= roleLevels[required]
+}
+
 // UserCredentials 用户登录凭据
 type UserCredentials struct {
 	Username string `json:"username" binding:"required,min=2,max=32"`
@@ -37,8 +64,29 @@ type UserRegister struct {
 
 // AuthResponse 认证响应
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token            string    `json:"token"`            // 短期访问令牌
+	RefreshToken     string    `json:"refreshToken"`     // 刷新令牌（每次使用后轮换）
+	ExpiresIn        int       `json:"expiresIn"`        // 访问令牌有效期(秒)
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"` // 刷新令牌过期时间
+	User             User      `json:"user"`
+}
+
+// StreamTicketResponse 实时推送连接票据（一次性，短期有效）
+type StreamTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// AuthSession 登录会话（一个设备一次登录）
+type AuthSession struct {
+	ID         string     `json:"id"`
+	UserID     int        `json:"userId"`
+	UserAgent  string     `json:"userAgent,omitempty"`
+	IPAddress  string     `json:"ipAddress,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt time.Time  `json:"lastUsedAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
 }
 
 // ═══════════════════════════════════════════════════════════
@@ -175,6 +223,7 @@ type Zone struct {
 	GoldMulti           float64   `json:"goldMulti"`
 	UnlockZoneID        *string   `json:"unlockZoneId,omitempty"`        // 需要探索的前置地图ID
 	RequiredExploration int       `json:"requiredExploration"`            // 解锁所需探索度
+	StaminaCost         *int      `json:"staminaCost,omitempty"`          // 每场战斗消耗体力（为空时使用服务器默认值）
 	Monsters            []Monster `json:"monsters,omitempty"`
 }
 
@@ -552,6 +601,64 @@ type BattleDPSAnalysis struct {
 	TeamDamageComposition *DamageComposition      `json:"teamDamageComposition"` // 队伍伤害构成
 }
 
+// ThreatEntry 仇恨快照中单个角色的威胁值
+type ThreatEntry struct {
+	CharacterID   int    `json:"characterId"`
+	CharacterName string `json:"characterName"`
+	Threat        int    `json:"threat"`
+	IsTank        bool   `json:"isTank"`
+}
+
+// BattleThreatLog 战斗仇恨日志 - 某回合某敌人的仇恨快照
+type BattleThreatLog struct {
+	ID            int           `json:"id"`
+	BattleID      int           `json:"battleId"`
+	Turn          int           `json:"turn"`
+	EnemyID       string        `json:"enemyId"`
+	CurrentTarget int           `json:"currentTarget"` // 当前仇恨目标角色ID（0表示无目标）
+	ThreatList    []ThreatEntry `json:"threatList"`    // 按威胁值从高到低排序
+	TargetChanged bool          `json:"targetChanged"` // 本回合是否切换目标
+	OTOccurred    bool          `json:"otOccurred"`    // 是否发生OT（非坦克从坦克身上拉走仇恨）
+	TauntUsed     bool          `json:"tauntUsed"`     // 本回合是否被嘲讽
+	CreatedAt     time.Time     `json:"createdAt"`
+}
+
+// ThreatOTEvent OT事件
+type ThreatOTEvent struct {
+	Turn            int    `json:"turn"`
+	EnemyID         string `json:"enemyId"`
+	FromCharacterID int    `json:"fromCharacterId"` // 失去仇恨的坦克
+	FromName        string `json:"fromName"`
+	FromThreat      int    `json:"fromThreat"`
+	ToCharacterID   int    `json:"toCharacterId"` // 拉走仇恨的角色
+	ToName          string `json:"toName"`
+	ToThreat        int    `json:"toThreat"`
+}
+
+// CharacterThreatSummary 角色仇恨汇总
+type CharacterThreatSummary struct {
+	CharacterID   int    `json:"characterId"`
+	CharacterName string `json:"characterName"`
+	IsTank        bool   `json:"isTank"`
+	PeakThreat    int    `json:"peakThreat"`    // 单个敌人上的最高威胁值
+	TurnsAsTarget int    `json:"turnsAsTarget"` // 作为仇恨目标的快照数
+	OTCount       int    `json:"otCount"`       // 造成OT的次数
+	TauntCount    int    `json:"tauntCount"`    // 嘲讽次数
+}
+
+// BattleThreatAnalysis 战斗仇恨分析 - 仇恨时间线与OT汇总
+type BattleThreatAnalysis struct {
+	BattleID      int                       `json:"battleId"`
+	TotalRounds   int                       `json:"totalRounds"`
+	Timeline      []*BattleThreatLog        `json:"timeline"`
+	TargetChanges int                       `json:"targetChanges"` // 目标切换次数
+	OTCount       int                       `json:"otCount"`       // OT次数
+	TauntCount    int                       `json:"tauntCount"`    // 嘲讽次数
+	TankHoldRate  float64                   `json:"tankHoldRate"`  // 坦克持有仇恨的快照占比(%)
+	OTEvents      []*ThreatOTEvent          `json:"otEvents"`
+	Characters    []*CharacterThreatSummary `json:"characters"`
+}
+
 // ═══════════════════════════════════════════════════════════
 // 技能
 // ═══════════════════════════════════════════════════════════
@@ -672,17 +779,20 @@ type ConditionalRule struct {
 
 // RuleCondition 规则条件
 type RuleCondition struct {
-	Type     string  `json:"type"`              // 条件类型: self_hp_percent, alive_enemy_count, target_hp_percent, etc.
-	Operator string  `json:"operator"`          // 比较运算符: <, >, <=, >=, =, !=
-	Value    float64 `json:"value"`             // 条件值
-	SkillID  string  `json:"skillId,omitempty"` // 技能ID (用于 skill_ready 条件)
-	BuffID   string  `json:"buffId,omitempty"`  // Buff ID (用于 has_buff 条件)
+	Type     string          `json:"type"`              // 条件类型: self_hp_percent, alive_enemy_count, target_hp_percent, etc.
+	Operator string          `json:"operator"`          // 比较运算符: <, >, <=, >=, =, !=
+	Value    float64         `json:"value"`             // 条件值
+	SkillID  string          `json:"skillId,omitempty"` // 技能ID (用于 skill_ready 条件)
+	BuffID   string          `json:"buffId,omitempty"`  // Buff ID (用于 has_buff 条件)
+	ItemID   string          `json:"itemId,omitempty"`  // 物品ID (用于 item_count 条件)
+	And      []RuleCondition `json:"and,omitempty"`     // 附加条件，需全部满足
 }
 
 // RuleAction 规则动作
 type RuleAction struct {
-	Type    string `json:"type"`              // 动作类型: use_skill, normal_attack
+	Type    string `json:"type"`              // 动作类型: use_skill, normal_attack, use_item
 	SkillID string `json:"skillId,omitempty"` // 使用的技能ID
+	ItemID  string `json:"itemId,omitempty"`  // 使用的物品ID (用于 use_item 动作)
 	Comment string `json:"comment,omitempty"` // 备注
 }
 
@@ -746,6 +856,991 @@ type EquipmentInstance struct {
 	IsLocked        bool       `json:"isLocked"`         // 是否锁定
 }
 
+// SalvageFilter 批量分解筛选条件（只匹配背包中的装备）
+type SalvageFilter struct {
+	Qualities []string `json:"qualities"` // 为空时只分解普通和优秀品质
+	MaxLevel  int      `json:"maxLevel"`  // 物品需求等级上限，0=不限
+	Slot      string   `json:"slot"`      // 装备槽位，空=不限
+}
+
+// SalvageMaterial 分解产出的材料
+type SalvageMaterial struct {
+	ItemID   string `json:"itemId"`
+	Quantity int    `json:"quantity"`
+}
+
+// SalvageEntry 单件装备的分解产出（Reason 非空表示不能分解）
+type SalvageEntry struct {
+	EquipmentID int               `json:"equipmentId"`
+	ItemID      string            `json:"itemId"`
+	ItemName    string            `json:"itemName"`
+	Quality     string            `json:"quality"`
+	Level       int               `json:"level"`
+	AffixCount  int               `json:"affixCount"`
+	Materials   []SalvageMaterial `json:"materials"`
+	Reason      string            `json:"reason,omitempty"`
+}
+
+// SalvageReport 分解预览/结果
+type SalvageReport struct {
+	CharacterID int               `json:"characterId,omitempty"` // 接收材料的角色（预览时为空）
+	Salvaged    []*SalvageEntry   `json:"salvaged"`
+	Skipped     []*SalvageEntry   `json:"skipped"`
+	Materials   []SalvageMaterial `json:"materials"` // 合计产出
+}
+
+// ═══════════════════════════════════════════════════════════
+// 图鉴相关
+// ═══════════════════════════════════════════════════════════
+
+// CodexEntry 图鉴条目（含玩家解锁进度）
+type CodexEntry struct {
+	ID              string     `json:"id"`
+	Category        string     `json:"category"` // monster/item/boss/zone
+	TargetID        string     `json:"targetId"`
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	Lore            string     `json:"lore,omitempty"`            // 来自怪物/物品描述的背景故事
+	UnlockCondition string     `json:"unlockCondition,omitempty"` // 加成生效所需解锁次数
+	RequiredCount   int        `json:"requiredCount"`
+	BonusType       string     `json:"bonusType,omitempty"` // damage_vs_monster/damage_vs_family/damage_all
+	BonusValue      float64    `json:"bonusValue"`          // 百分比加成
+	BonusFamily     string     `json:"bonusFamily,omitempty"` // damage_vs_family 生效的怪物族群
+	Unlocked        bool       `json:"unlocked"`
+	UnlockCount     int        `json:"unlockCount"`
+	FirstUnlockAt   *time.Time `json:"firstUnlockAt,omitempty"`
+	BonusActive     bool       `json:"bonusActive"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 阵营PVP相关
+// ═══════════════════════════════════════════════════════════
+
+// ZoneFactionControl 地图阵营控制状态
+type ZoneFactionControl struct {
+	ZoneID             string     `json:"zoneId"`
+	ControllingFaction string     `json:"controllingFaction"` // alliance/horde/neutral
+	AllianceWins       int        `json:"allianceWins"`
+	HordeWins          int        `json:"hordeWins"`
+	AllianceWinRate    float64    `json:"allianceWinRate"`
+	ControlScore       int        `json:"controlScore"`      // 正=联盟, 负=部落, 范围-100~+100
+	EfficiencyBonus    float64    `json:"efficiencyBonus"`   // 控制方效率加成 (0.0-0.2)
+	EfficiencyPenalty  float64    `json:"efficiencyPenalty"` // 被控方效率惩罚 (0.0-0.1)
+	LastBattleAt       *time.Time `json:"lastBattleAt,omitempty"`
+	StatsResetAt       *time.Time `json:"statsResetAt,omitempty"`
+}
+
+// PvPTeamMember PVP队伍快照中的角色信息
+type PvPTeamMember struct {
+	CharacterID int    `json:"characterId"`
+	Name        string `json:"name"`
+	ClassID     string `json:"classId"`
+	Level       int    `json:"level"`
+	MaxHP       int    `json:"maxHp"`
+	RemainingHP int    `json:"remainingHp"`
+}
+
+// PvPEncounter PVP遭遇战记录
+type PvPEncounter struct {
+	ID                  int             `json:"id"`
+	ZoneID              string          `json:"zoneId"`
+	AttackerUserID      int             `json:"attackerUserId"`
+	DefenderUserID      int             `json:"defenderUserId"`
+	AttackerFaction     string          `json:"attackerFaction"`
+	DefenderFaction     string          `json:"defenderFaction"`
+	WinnerUserID        *int            `json:"winnerUserId,omitempty"` // nil=平局
+	WinnerFaction       string          `json:"winnerFaction,omitempty"`
+	AttackerTeam        []PvPTeamMember `json:"attackerTeam"`
+	DefenderTeam        []PvPTeamMember `json:"defenderTeam"`
+	BattleRounds        int             `json:"battleRounds"`
+	BattleDuration      int             `json:"battleDuration"` // 秒
+	AttackerDamageDealt int             `json:"attackerDamageDealt"`
+	DefenderDamageDealt int             `json:"defenderDamageDealt"`
+	HonorReward         int             `json:"honorReward"`
+	BattleLog           []BattleLog     `json:"battleLog,omitempty"`
+	CreatedAt           time.Time       `json:"createdAt"`
+}
+
+// UserHonor 玩家荣誉与PVP战绩
+type UserHonor struct {
+	UserID           int        `json:"userId"`
+	Faction          string     `json:"faction"`
+	TotalHonor       int        `json:"totalHonor"`
+	CurrentHonor     int        `json:"currentHonor"`
+	HonorRank        int        `json:"honorRank"` // 0-14
+	PvPWins          int        `json:"pvpWins"`
+	PvPLosses        int        `json:"pvpLosses"`
+	PvPDraws         int        `json:"pvpDraws"`
+	WinStreak        int        `json:"winStreak"`
+	BestWinStreak    int        `json:"bestWinStreak"`
+	TotalKills       int        `json:"totalKills"`
+	TotalDeaths      int        `json:"totalDeaths"`
+	TotalDamageDealt int        `json:"totalDamageDealt"`
+	WeeklyHonor      int        `json:"weeklyHonor"`
+	WeeklyResetAt    *time.Time `json:"weeklyResetAt,omitempty"`
+	RankName         string     `json:"rankName"`
+	NextRankHonor    int        `json:"nextRankHonor,omitempty"` // 下一军衔所需累计荣誉 (0=已满级)
+}
+
+// HonorStanding 军衔排名条目
+type HonorStanding struct {
+	Position    int    `json:"position"`
+	UserID      int    `json:"userId"`
+	Username    string `json:"username"`
+	Faction     string `json:"faction"`
+	HonorRank   int    `json:"honorRank"`
+	RankName    string `json:"rankName"`
+	TotalHonor  int    `json:"totalHonor"`
+	WeeklyHonor int    `json:"weeklyHonor"`
+}
+
+// HonorShopItem 荣誉商店商品
+type HonorShopItem struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	ItemType          string `json:"itemType"` // equipment/consumable/cosmetic/title
+	ItemID            string `json:"itemId,omitempty"`
+	ItemQuality       string `json:"itemQuality,omitempty"`
+	HonorCost         int    `json:"honorCost"`
+	RankRequired      int    `json:"rankRequired"`
+	Faction           string `json:"faction,omitempty"` // 空=双阵营
+	WeeklyLimit       *int   `json:"weeklyLimit,omitempty"`
+	Stock             *int   `json:"stock,omitempty"` // nil=无限
+	PurchasedThisWeek int    `json:"purchasedThisWeek"`
+}
+
+// HonorPurchase 荣誉商店购买记录
+type HonorPurchase struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"userId"`
+	ShopItemID   string    `json:"shopItemId"`
+	ShopItemName string    `json:"shopItemName"`
+	ItemType     string    `json:"itemType"`
+	ItemID       string    `json:"itemId,omitempty"`
+	CharacterID  *int      `json:"characterId,omitempty"`
+	HonorCost    int       `json:"honorCost"`
+	EquipmentID  *int      `json:"equipmentId,omitempty"`
+	PurchasedAt  time.Time `json:"purchasedAt"`
+}
+
+// 全服公告类型
+const (
+	AnnouncementZoneCaptured  = "zone_captured"  // 阵营占领争夺区域
+	AnnouncementKillStreak    = "kill_streak"    // PVP连胜
+	AnnouncementFirstKill     = "first_kill"     // 全服首杀首领
+	AnnouncementLegendaryDrop = "legendary_drop" // 传说装备掉落
+	AnnouncementAdmin         = "admin"          // 管理员公告
+)
+
+// ServerAnnouncement 全服公告
+type ServerAnnouncement struct {
+	ID             int        `json:"id"`
+	Type           string     `json:"type"` // zone_captured/kill_streak/first_kill/legendary_drop/admin
+	Content        string     `json:"content"`
+	ZoneID         string     `json:"zoneId,omitempty"`
+	WinnerUserID   *int       `json:"winnerUserId,omitempty"`
+	LoserUserID    *int       `json:"loserUserId,omitempty"`
+	PvPEncounterID *int       `json:"pvpEncounterId,omitempty"`
+	Importance     int        `json:"importance"` // 1-5
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 体力系统相关
+// ═══════════════════════════════════════════════════════════
+
+// UserStamina 玩家体力
+type UserStamina struct {
+	UserID         int       `json:"userId"`
+	CurrentStamina int       `json:"currentStamina"`
+	MaxStamina     int       `json:"maxStamina"`
+	LastRegenAt    time.Time `json:"lastRegenAt"`
+	OverflowExp    int       `json:"overflowExp"`  // 体力满时溢出转化、尚未领取的经验
+	OverflowGold   int       `json:"overflowGold"` // 体力满时溢出转化、尚未领取的金币
+}
+
+// StaminaStatus 体力状态（返回给前端）
+type StaminaStatus struct {
+	Enabled              bool       `json:"enabled"`
+	CurrentStamina       int        `json:"currentStamina"`
+	MaxStamina           int        `json:"maxStamina"`
+	NextRegenAt          *time.Time `json:"nextRegenAt,omitempty"` // 体力已满时为空
+	RegenIntervalSeconds int        `json:"regenIntervalSeconds"`
+	BattleCost           int        `json:"battleCost"` // 当前区域每场战斗消耗
+	OverflowExp          int        `json:"overflowExp"`
+	OverflowGold         int        `json:"overflowGold"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 拍卖行相关
+// ═══════════════════════════════════════════════════════════
+
+// AuctionListing 拍卖行上架信息（上架期间装备由拍卖行托管）
+type AuctionListing struct {
+	ID              int                `json:"id"`
+	SellerID        int                `json:"sellerId"`
+	SellerName      string             `json:"sellerName"`
+	EquipmentID     int                `json:"equipmentId"`
+	ItemID          string             `json:"itemId"`
+	ItemName        string             `json:"itemName"`
+	LevelRequired   int                `json:"levelRequired"`
+	Equipment       *EquipmentInstance `json:"equipment,omitempty"` // 托管装备（槽位、品质、词缀）
+	Price           int                `json:"price"`                 // 一口价（0=竞拍无一口价）
+	StartingBid     *int               `json:"startingBid,omitempty"` // 起拍价（为空表示一口价上架）
+	MinIncrement    int                `json:"minIncrement"`
+	CurrentBid      *int               `json:"currentBid,omitempty"`
+	CurrentBidderID *int               `json:"currentBidderId,omitempty"`
+	BidCount        int                `json:"bidCount"`
+	ListingFee      int                `json:"listingFee"`
+	TransactionFee  int                `json:"transactionFee"`
+	Status          string             `json:"status"` // active/sold/expired/cancelled
+	BuyerID         *int               `json:"buyerId,omitempty"`
+	SoldPrice       *int               `json:"soldPrice,omitempty"`
+	ListedAt        time.Time          `json:"listedAt"`
+	ExpiresAt       time.Time          `json:"expiresAt"`
+	ClosedAt        *time.Time         `json:"closedAt,omitempty"`
+}
+
+// IsBidding 是否为竞价拍卖
+func (l *AuctionListing) IsBidding() bool {
+	return l.StartingBid != nil
+}
+
+// AuctionBid 拍卖出价记录
+type AuctionBid struct {
+	ID         int       `json:"id"`
+	ListingID  int       `json:"listingId"`
+	BidderID   int       `json:"bidderId"`
+	BidderName string    `json:"bidderName"`
+	Amount     int       `json:"amount"`
+	Status     string    `json:"status"` // active/outbid/won/refunded
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 玩家直接交易相关
+// ═══════════════════════════════════════════════════════════
+
+// TradeSession 交易窗口
+type TradeSession struct {
+	ID                 int          `json:"id"`
+	InitiatorID        int          `json:"initiatorId"`
+	InitiatorName      string       `json:"initiatorName"`
+	PartnerID          int          `json:"partnerId"`
+	PartnerName        string       `json:"partnerName"`
+	Status             string       `json:"status"` // open/completed/cancelled/expired
+	InitiatorGold      int          `json:"initiatorGold"`
+	PartnerGold        int          `json:"partnerGold"`
+	InitiatorConfirmed bool         `json:"initiatorConfirmed"`
+	PartnerConfirmed   bool         `json:"partnerConfirmed"`
+	Items              []*TradeItem `json:"items"`
+	CreatedAt          time.Time    `json:"createdAt"`
+	UpdatedAt          time.Time    `json:"updatedAt"`
+	ExpiresAt          time.Time    `json:"expiresAt"`
+	ClosedAt           *time.Time   `json:"closedAt,omitempty"`
+}
+
+// IsParticipant 用户是否为交易双方之一
+func (t *TradeSession) IsParticipant(userID int) bool {
+	return t.InitiatorID == userID || t.PartnerID == userID
+}
+
+// OtherParty 获取交易另一方的用户ID
+func (t *TradeSession) OtherParty(userID int) int {
+	if t.InitiatorID == userID {
+		return t.PartnerID
+	}
+	return t.InitiatorID
+}
+
+// TradeItem 交易窗口中的装备
+type TradeItem struct {
+	ID          int    `json:"id"`
+	TradeID     int    `json:"tradeId"`
+	UserID      int    `json:"userId"` // 放入物品的一方
+	EquipmentID int    `json:"equipmentId"`
+	ItemID      string `json:"itemId"`
+	ItemName    string `json:"itemName"`
+	Slot        string `json:"slot"`
+	Quality     string `json:"quality"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 邮箱相关
+// ═══════════════════════════════════════════════════════════
+
+// Mail 邮件
+type Mail struct {
+	ID             int               `json:"id"`
+	RecipientID    int               `json:"recipientId"`
+	RecipientName  string            `json:"recipientName"`
+	SenderID       *int              `json:"senderId,omitempty"` // nil=系统邮件
+	SenderName     string            `json:"senderName"`
+	Type           string            `json:"type"` // player/system/auction/returned/cod_payment
+	Subject        string            `json:"subject"`
+	Body           string            `json:"body"`
+	Gold           int               `json:"gold"`
+	CODAmount      int               `json:"codAmount"`
+	Status         string            `json:"status"` // active/returned/expired/deleted
+	IsRead         bool              `json:"isRead"`
+	ReturnedFromID *int              `json:"returnedFromId,omitempty"`
+	Attachments    []*MailAttachment `json:"attachments"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	ReadAt         *time.Time        `json:"readAt,omitempty"`
+	ClaimedAt      *time.Time        `json:"claimedAt,omitempty"`
+	ExpiresAt      time.Time         `json:"expiresAt"`
+}
+
+// HasUnclaimedContent 是否还有未领取的金币或附件
+func (m *Mail) HasUnclaimedContent() bool {
+	return m.ClaimedAt == nil && (m.Gold > 0 || len(m.Attachments) > 0)
+}
+
+// MailAttachment 邮件附件
+type MailAttachment struct {
+	ID          int    `json:"id"`
+	MailID      int    `json:"mailId"`
+	EquipmentID int    `json:"equipmentId"`
+	ItemID      string `json:"itemId"`
+	ItemName    string `json:"itemName"`
+	Slot        string `json:"slot"`
+	Quality     string `json:"quality"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 金币流水相关
+// ═══════════════════════════════════════════════════════════
+
+// GoldLedgerEntry 金币流水记录
+type GoldLedgerEntry struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"userId"`
+	Delta        int       `json:"delta"`
+	BalanceAfter int       `json:"balanceAfter"`
+	Reason       string    `json:"reason"`
+	RefType      string    `json:"refType,omitempty"`
+	RefID        string    `json:"refId,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// GoldDiscrepancy 金币对账差异（账户余额与流水合计不一致）
+type GoldDiscrepancy struct {
+	UserID        int    `json:"userId"`
+	Username      string `json:"username"`
+	Gold          int    `json:"gold"`
+	LedgerBalance int    `json:"ledgerBalance"`
+	Difference    int    `json:"difference"` // gold - ledgerBalance
+}
+
+// ═══════════════════════════════════════════════════════════
+// NPC商人相关
+// ═══════════════════════════════════════════════════════════
+
+// Vendor NPC商人
+type Vendor struct {
+	ID                     string        `json:"id"`
+	Name                   string        `json:"name"`
+	Title                  string        `json:"title,omitempty"`
+	ZoneID                 string        `json:"zoneId"`
+	Description            string        `json:"description,omitempty"`
+	RestockIntervalMinutes int           `json:"restockIntervalMinutes"`
+	LastRestockAt          *time.Time    `json:"lastRestockAt,omitempty"`
+	Items                  []*VendorItem `json:"items,omitempty"`
+}
+
+// VendorItem 商人出售的商品
+type VendorItem struct {
+	VendorID  string `json:"vendorId"`
+	ItemID    string `json:"itemId"`
+	ItemName  string `json:"itemName"`
+	ItemType  string `json:"itemType"`
+	Quality   string `json:"quality"`
+	Price     int    `json:"price"`
+	MaxStock  *int   `json:"maxStock,omitempty"` // nil=无限
+	Stock     *int   `json:"stock,omitempty"`
+	Stackable bool   `json:"stackable"`
+}
+
+// VendorPurchase 从商人处购买的结果
+type VendorPurchase struct {
+	VendorID    string `json:"vendorId"`
+	ItemID      string `json:"itemId"`
+	ItemName    string `json:"itemName"`
+	Quantity    int    `json:"quantity"`
+	TotalPrice  int    `json:"totalPrice"`
+	CharacterID *int   `json:"characterId,omitempty"` // 物品放入的角色背包
+	EquipmentID *int   `json:"equipmentId,omitempty"` // 生成的装备实例
+}
+
+// VendorBuyback 回购记录
+type VendorBuyback struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"userId"`
+	CharacterID *int       `json:"characterId,omitempty"`
+	ItemID      string     `json:"itemId"`
+	ItemName    string     `json:"itemName"`
+	Quantity    int        `json:"quantity"`
+	EquipmentID *int       `json:"equipmentId,omitempty"`
+	Quality     string     `json:"quality"`
+	Price       int        `json:"price"`
+	Status      string     `json:"status"` // available/bought_back/expired
+	SoldAt      time.Time  `json:"soldAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	ClosedAt    *time.Time `json:"closedAt,omitempty"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 消耗品相关
+// ═══════════════════════════════════════════════════════════
+
+// ConsumableItem 角色持有的消耗品
+type ConsumableItem struct {
+	ItemID         string  `json:"itemId"`
+	Name           string  `json:"name"`
+	Description    string  `json:"description,omitempty"`
+	Subtype        string  `json:"subtype"` // potion/elixir/food
+	Quality        string  `json:"quality"`
+	EffectType     string  `json:"effectType"` // heal_hp/heal_mp/buff/rest_speed
+	EffectValue    float64 `json:"effectValue"`
+	EffectStat     string  `json:"effectStat,omitempty"`     // buff影响的属性
+	EffectDuration int     `json:"effectDuration,omitempty"` // buff持续回合数
+	Quantity       int     `json:"quantity"`
+}
+
+// ConsumableUseResult 使用消耗品的结果
+type ConsumableUseResult struct {
+	CharacterID int        `json:"characterId"`
+	ItemID      string     `json:"itemId"`
+	ItemName    string     `json:"itemName"`
+	EffectType  string     `json:"effectType"`
+	Amount      int        `json:"amount"`    // 实际恢复量或buff数值
+	Remaining   int        `json:"remaining"` // 剩余数量
+	HP          int        `json:"hp"`
+	Resource    int        `json:"resource"`
+	Duration    int        `json:"duration,omitempty"`  // buff持续回合数
+	RestUntil   *time.Time `json:"restUntil,omitempty"` // 食物缩短后的休息结束时间
+}
+
+// ═══════════════════════════════════════════════════════════
+// 专业与制造相关
+// ═══════════════════════════════════════════════════════════
+
+// Profession 专业
+type Profession struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"` // crafting/gathering
+	Description string `json:"description"`
+	MaxSkill    int    `json:"maxSkill"`
+}
+
+// RecipeMaterial 配方材料
+type RecipeMaterial struct {
+	ItemID   string `json:"itemId"`
+	ItemName string `json:"itemName"`
+	Quantity int    `json:"quantity"`
+}
+
+// Recipe 配方
+type Recipe struct {
+	ID             string           `json:"id"`
+	ProfessionID   string           `json:"professionId"`
+	Name           string           `json:"name"`
+	Description    string           `json:"description"`
+	RequiredSkill  int              `json:"requiredSkill"`
+	SkillUpYellow  int              `json:"skillUpYellow"`
+	SkillUpGray    int              `json:"skillUpGray"`
+	ResultItemID   string           `json:"resultItemId"`
+	ResultItemName string           `json:"resultItemName"`
+	ResultType     string           `json:"resultType"` // equipment/consumable/material
+	ResultLevel    int              `json:"resultLevel"`
+	ResultQuantity int              `json:"resultQuantity"`
+	ResultQuality  string           `json:"resultQuality"`
+	ProcChance     float64          `json:"procChance"`
+	CraftSeconds   int              `json:"craftSeconds"`
+	Materials      []RecipeMaterial `json:"materials"`
+	Difficulty     string           `json:"difficulty,omitempty"` // 相对角色技能: unavailable/orange/yellow/green/gray
+}
+
+// CharacterProfession 角色专业进度
+type CharacterProfession struct {
+	CharacterID  int       `json:"characterId"`
+	ProfessionID string    `json:"professionId"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	SkillLevel   int       `json:"skillLevel"`
+	MaxSkill     int       `json:"maxSkill"`
+	LearnedAt    time.Time `json:"learnedAt"`
+}
+
+// CraftingQueueEntry 制造队列条目
+type CraftingQueueEntry struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"userId"`
+	CharacterID  int        `json:"characterId"`
+	RecipeID     string     `json:"recipeId"`
+	RecipeName   string     `json:"recipeName"`
+	ProfessionID string     `json:"professionId"`
+	Quantity     int        `json:"quantity"`
+	Crafted      int        `json:"crafted"`
+	Status       string     `json:"status"` // queued/active/completed/cancelled
+	NextCraftAt  *time.Time `json:"nextCraftAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+}
+
+// CraftResult 一次制造的产出
+type CraftResult struct {
+	QueueID     int       `json:"queueId"`
+	RecipeID    string    `json:"recipeId"`
+	ItemID      string    `json:"itemId"`
+	Quantity    int       `json:"quantity"`
+	Quality     string    `json:"quality"`
+	EquipmentID *int      `json:"equipmentId,omitempty"`
+	Proc        bool      `json:"proc"`    // 是否触发品质提升/额外产出
+	SkillUp     int       `json:"skillUp"` // 本次提升的技能点
+	SkillLevel  int       `json:"skillLevel"`
+	CraftedAt   time.Time `json:"craftedAt"`
+}
+
+// GatheringNode 区域采集节点
+type GatheringNode struct {
+	ID                  string  `json:"id"`
+	ZoneID              string  `json:"zoneId"`
+	ProfessionID        string  `json:"professionId"`
+	NodeType            string  `json:"nodeType"` // herb/ore/leather
+	Name                string  `json:"name"`
+	ItemID              string  `json:"itemId"`
+	ItemName            string  `json:"itemName"`
+	RequiredSkill       int     `json:"requiredSkill"`
+	SkillUpYellow       int     `json:"skillUpYellow"`
+	SkillUpGray         int     `json:"skillUpGray"`
+	Chance              float64 `json:"chance"` // 基础发现概率
+	MinQuantity         int     `json:"minQuantity"`
+	MaxQuantity         int     `json:"maxQuantity"`
+	RequiredExploration int     `json:"requiredExploration"`
+	Discovered          bool    `json:"discovered"`                // 区域探索度是否已满足
+	EffectiveChance     float64 `json:"effectiveChance,omitempty"` // 按角色技能与探索度计算后的概率
+}
+
+// GatherResult 一次采集的产出
+type GatherResult struct {
+	CharacterID  int    `json:"characterId"`
+	NodeID       string `json:"nodeId"`
+	NodeName     string `json:"nodeName"`
+	NodeType     string `json:"nodeType"`
+	ItemID       string `json:"itemId"`
+	ItemName     string `json:"itemName"`
+	Quantity     int    `json:"quantity"`
+	ProfessionID string `json:"professionId"`
+	SkillUp      int    `json:"skillUp"`
+	SkillLevel   int    `json:"skillLevel"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 聊天链接相关
+// ═══════════════════════════════════════════════════════════
+
+// ChatLink 聊天消息中的链接（发送时保存快照，物品出售或分解后仍可查看）
+type ChatLink struct {
+	Token    string      `json:"token"` // 原文中的链接标记，如 [item:123]
+	Type     string      `json:"type"`  // item/skill/achievement
+	TargetID string      `json:"targetId"`
+	Name     string      `json:"name"`
+	Quality  string      `json:"quality,omitempty"`
+	Snapshot interface{} `json:"snapshot"` // *ItemLinkSnapshot / *Skill / *AchievementLinkSnapshot
+}
+
+// ItemLinkSnapshot 装备链接快照
+type ItemLinkSnapshot struct {
+	EquipmentID     int                    `json:"equipmentId"`
+	ItemID          string                 `json:"itemId"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description,omitempty"`
+	Quality         string                 `json:"quality"`
+	Slot            string                 `json:"slot"`
+	LevelRequired   int                    `json:"levelRequired"`
+	EvolutionStage  int                    `json:"evolutionStage"`
+	EvolutionPath   string                 `json:"evolutionPath,omitempty"`
+	Stats           map[string]float64     `json:"stats"` // 基础属性（只含非零项）
+	Affixes         []*LinkedAffix         `json:"affixes"`
+	LegendaryEffect *LinkedLegendaryEffect `json:"legendaryEffect,omitempty"`
+}
+
+// LinkedAffix 装备链接中的词缀
+type LinkedAffix struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Position    string  `json:"position"` // prefix/suffix/bonus
+	EffectStat  string  `json:"effectStat,omitempty"`
+	ValueType   string  `json:"valueType"` // flat/percent
+	Value       float64 `json:"value"`
+	Description string  `json:"description,omitempty"`
+}
+
+// LinkedLegendaryEffect 装备链接中的传说效果
+type LinkedLegendaryEffect struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// AchievementLinkSnapshot 成就链接快照（包含发送者的完成进度）
+type AchievementLinkSnapshot struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description,omitempty"`
+	Category       string     `json:"category"`
+	Points         int        `json:"points"`
+	ConditionValue int        `json:"conditionValue"`
+	Progress       int        `json:"progress"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 聊天管理相关
+// ═══════════════════════════════════════════════════════════
+
+// 聊天处罚类型
+const (
+	ChatSanctionMute = "mute" // 禁止在公共频道发言（可限定频道）
+	ChatSanctionBan  = "ban"  // 禁止一切聊天（包括私聊）
+)
+
+// ChatSanction 聊天禁言/封禁记录
+type ChatSanction struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"userId"`
+	PlayerName string     `json:"playerName"`
+	Type       string     `json:"type"`              // mute/ban
+	Channel    string     `json:"channel,omitempty"` // 为空表示全部公共频道
+	Reason     string     `json:"reason,omitempty"`
+	IssuedBy   int        `json:"issuedBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"` // 为空表示永久
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// ChatReport 聊天举报
+type ChatReport struct {
+	ID             int        `json:"id"`
+	MessageID      int        `json:"messageId"`
+	ReporterID     int        `json:"reporterId"`
+	ReporterName   string     `json:"reporterName"`
+	ReportedUserID int        `json:"reportedUserId"`
+	ReportedName   string     `json:"reportedName"`
+	Content        string     `json:"content"` // 举报时的消息内容
+	Reason         string     `json:"reason,omitempty"`
+	Status         string     `json:"status"` // pending/resolved/dismissed
+	HandledBy      *int       `json:"handledBy,omitempty"`
+	HandledAt      *time.Time `json:"handledAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// ChatRateBucket 聊天令牌桶状态（按用户和频道）
+type ChatRateBucket struct {
+	UserID      int        `json:"userId"`
+	Channel     string     `json:"channel"`
+	Tokens      float64    `json:"tokens"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	LastContent string     `json:"-"`
+	LastSentAt  *time.Time `json:"lastSentAt,omitempty"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 公会相关
+// ═══════════════════════════════════════════════════════════
+
+// 公会权限
+const (
+	GuildPermInvite        = "invite"         // 邀请玩家
+	GuildPermKick          = "kick"           // 踢出低会阶成员
+	GuildPermPromote       = "promote"        // 调整低会阶成员的会阶
+	GuildPermEditMOTD      = "edit_motd"      // 修改每日公告
+	GuildPermWithdrawGold  = "withdraw_gold"  // 从公会银行提取金币
+	GuildPermWithdrawItems = "withdraw_items" // 从公会银行提取装备
+)
+
+// Guild 公会
+type Guild struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	Faction       string     `json:"faction"`
+	LeaderID      int        `json:"leaderId"`
+	LeaderName    string     `json:"leaderName"`
+	MOTD          string     `json:"motd"`
+	MOTDUpdatedAt *time.Time `json:"motdUpdatedAt,omitempty"`
+	BankGold      int        `json:"bankGold"`
+	MemberCount   int        `json:"memberCount"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// GuildRank 公会会阶（Index 为 0 的是会长，数字越大权限越低）
+type GuildRank struct {
+	Index             int      `json:"index"`
+	Name              string   `json:"name"`
+	Permissions       []string `json:"permissions"`
+	GoldWithdrawLimit int      `json:"goldWithdrawLimit"` // 每24小时，-1表示不限
+	ItemWithdrawLimit int      `json:"itemWithdrawLimit"` // 每24小时，-1表示不限
+}
+
+// HasPermission 会阶是否拥有指定权限
+func (r *GuildRank) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// GuildMember 公会成员
+type GuildMember struct {
+	UserID    int       `json:"userId"`
+	GuildID   int       `json:"guildId"`
+	Name      string    `json:"name"`
+	RankIndex int       `json:"rankIndex"`
+	RankName  string    `json:"rankName"`
+	Online    bool      `json:"online"`
+	JoinedAt  time.Time `json:"joinedAt"`
+}
+
+// GuildInvite 公会邀请
+type GuildInvite struct {
+	GuildID     int       `json:"guildId"`
+	GuildName   string    `json:"guildName"`
+	UserID      int       `json:"userId"`
+	InvitedBy   int       `json:"invitedBy"`
+	InviterName string    `json:"inviterName"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// GuildBankItem 公会银行中的装备
+type GuildBankItem struct {
+	ID            int       `json:"id"`
+	EquipmentID   int       `json:"equipmentId"`
+	ItemID        string    `json:"itemId"`
+	ItemName      string    `json:"itemName"`
+	Slot          string    `json:"slot"`
+	Quality       string    `json:"quality"`
+	DepositedBy   int       `json:"depositedBy"`
+	DepositorName string    `json:"depositorName"`
+	DepositedAt   time.Time `json:"depositedAt"`
+}
+
+// GuildLogEntry 公会活动日志
+type GuildLogEntry struct {
+	ID         int       `json:"id"`
+	GuildID    int       `json:"guildId"`
+	UserID     int       `json:"userId"`
+	PlayerName string    `json:"playerName"`
+	Action     string    `json:"action"`               // create/invite/join/leave/kick/rank_change/...
+	TargetName string    `json:"targetName,omitempty"` // 目标玩家/会阶/装备名称
+	Amount     int       `json:"amount,omitempty"`     // 金币数量或装备件数
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// GuildInfo 公会详情（成员视角）
+type GuildInfo struct {
+	Guild   *Guild         `json:"guild"`
+	Ranks   []*GuildRank   `json:"ranks"`
+	Members []*GuildMember `json:"members"`
+	MyRank  int            `json:"myRank"`
+}
+
+// GuildBank 公会银行（含本人24小时内的提取额度）
+type GuildBank struct {
+	Gold                int              `json:"gold"`
+	Items               []*GuildBankItem `json:"items"`
+	GoldWithdrawLimit   int              `json:"goldWithdrawLimit"` // -1表示不限
+	ItemWithdrawLimit   int              `json:"itemWithdrawLimit"` // -1表示不限
+	GoldWithdrawnToday  int              `json:"goldWithdrawnToday"`
+	ItemsWithdrawnToday int              `json:"itemsWithdrawnToday"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 排行榜相关
+// ═══════════════════════════════════════════════════════════
+
+// 排行榜类型
+const (
+	LeaderboardLevel           = "level"             // 角色等级
+	LeaderboardKills           = "kills"             // 总击杀（赛季累计）
+	LeaderboardAbyssFloor      = "abyss_floor"       // 深渊最高层数
+	LeaderboardHonor           = "honor"             // 荣誉（赛季累计）
+	LeaderboardGoldEarned      = "gold_earned"       // 获得金币（赛季累计）
+	LeaderboardHighestHit      = "highest_hit"       // 单次最高伤害
+	LeaderboardFastestBossKill = "fastest_boss_kill" // 最快击杀首领（秒，赛季内）
+)
+
+// LeaderboardBoard 排行榜定义
+type LeaderboardBoard struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Subject   string `json:"subject"`   // character/player
+	Ascending bool   `json:"ascending"` // 分数越低排名越高
+	Seasonal  bool   `json:"seasonal"`  // 成绩按赛季重新计算
+}
+
+// LeaderboardSeason 排行榜赛季
+type LeaderboardSeason struct {
+	ID             int        `json:"id"`
+	Name           string     `json:"name"`
+	StartedAt      time.Time  `json:"startedAt"`
+	EndedAt        *time.Time `json:"endedAt,omitempty"`
+	LastSnapshotAt *time.Time `json:"lastSnapshotAt,omitempty"`
+}
+
+// LeaderboardEntry 排行榜条目（Rank 为筛选后的排名，GlobalRank 为全服排名）
+type LeaderboardEntry struct {
+	Rank        int    `json:"rank"`
+	GlobalRank  int    `json:"globalRank"`
+	UserID      int    `json:"userId"`
+	CharacterID int    `json:"characterId,omitempty"`
+	Name        string `json:"name"`
+	Faction     string `json:"faction"`
+	ClassID     string `json:"classId"`
+	Level       int    `json:"level"`
+	Score       int    `json:"score"`
+}
+
+// Leaderboard 排行榜一页
+type Leaderboard struct {
+	Board      *LeaderboardBoard   `json:"board"`
+	Season     *LeaderboardSeason  `json:"season"`
+	Faction    string              `json:"faction,omitempty"`
+	ClassID    string              `json:"classId,omitempty"`
+	Total      int                 `json:"total"`
+	Entries    []*LeaderboardEntry `json:"entries"`
+	SnapshotAt *time.Time          `json:"snapshotAt,omitempty"`
+}
+
+// LeaderboardPosition 玩家自己的排名及前后相邻条目
+type LeaderboardPosition struct {
+	Board  *LeaderboardBoard   `json:"board"`
+	Season *LeaderboardSeason  `json:"season"`
+	Entry  *LeaderboardEntry   `json:"entry"` // 未上榜为 nil
+	Above  []*LeaderboardEntry `json:"above"`
+	Below  []*LeaderboardEntry `json:"below"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// GM管理相关
+// ═══════════════════════════════════════════════════════════
+
+// AccountBan 账号封禁
+type AccountBan struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"userId"`
+	Reason    string     `json:"reason,omitempty"`
+	IssuedBy  int        `json:"issuedBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // 为空表示永久
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// AdminAuditEntry GM操作审计记录
+type AdminAuditEntry struct {
+	ID           int       `json:"id"`
+	ActorID      int       `json:"actorId"`
+	ActorName    string    `json:"actorName"`
+	Action       string    `json:"action"` // grant_gold/grant_item/teleport/reset_session/stop_battle/kill_battle/ban/unban/set_role/reload_config
+	TargetUserID *int      `json:"targetUserId,omitempty"`
+	Details      string    `json:"details,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// AdminUserState GM查看的玩家状态
+type AdminUserState struct {
+	User           *User         `json:"user"`
+	Role           string        `json:"role"`
+	Characters     []*Character  `json:"characters"`
+	Battle         *BattleStatus `json:"battle"`
+	Online         bool          `json:"online"`
+	ActiveSessions int           `json:"activeSessions"`
+	Ban            *AccountBan   `json:"ban,omitempty"` // 生效中的封禁
+}
+
+// ═══════════════════════════════════════════════════════════
+// 登录防护相关
+// ═══════════════════════════════════════════════════════════
+
+// 登录尝试结果
+const (
+	LoginResultSuccess = "success" // 登录成功
+	LoginResultFailure = "failure" // 用户名或密码错误
+	LoginResultLocked  = "locked"  // 锁定期间被拒绝
+	LoginResultDenied  = "denied"  // 凭据正确但被拒绝（如账号封禁）
+	LoginResultPending = "pending" // 已预占、尚未完成凭据校验
+)
+
+// 登录锁定范围
+const (
+	LoginScopeIP       = "ip"
+	LoginScopeUsername = "username"
+)
+
+// LoginAttempt 登录尝试审计记录
+type LoginAttempt struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	UserID    *int      `json:"userId,omitempty"`
+	IPAddress string    `json:"ipAddress"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	Result    string    `json:"result"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LoginLockout 登录锁定状态（按IP或用户名）
+type LoginLockout struct {
+	Scope       string    `json:"scope"`
+	Key         string    `json:"key"`
+	Level       int       `json:"level"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// 实时推送相关
+// ═══════════════════════════════════════════════════════════
+
+// RealtimeEvent 通过 WebSocket/SSE 推送给客户端的事件
+type RealtimeEvent struct {
+	Type      string      `json:"type"` // battle_log/loot/level_up/chat/presence/mail/trade/guild/ping
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// PresenceEvent 玩家上线/下线通知
+type PresenceEvent struct {
+	UserID  int    `json:"userId"`
+	Name    string `json:"name"`
+	Faction string `json:"faction"`
+	ZoneID  string `json:"zoneId,omitempty"`
+	Online  bool   `json:"online"`
+}
+
+// MailEvent 收件箱变化通知（客户端据此刷新邮箱）
+type MailEvent struct {
+	Unread int `json:"unread"`
+}
+
+// TradeEvent 面对面交易状态变化通知
+type TradeEvent struct {
+	Trade   *TradeSession `json:"trade"`
+	Message string        `json:"message"`
+}
+
+// GuildEvent 公会通知（邀请、被踢出、公会解散等）
+type GuildEvent struct {
+	GuildID   int    `json:"guildId"`
+	GuildName string `json:"guildName"`
+	Action    string `json:"action"` // invite/kick/disband
+	Message   string `json:"message"`
+}
+
 // ═══════════════════════════════════════════════════════════
 // API 响应
 // ═══════════════════════════════════════════════════════════
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// AdminRepository 账号封禁与GM审计日志数据仓库
+type AdminRepository struct{}
+
+// NewAdminRepository 创建GM管理仓库
+func NewAdminRepository() *AdminRepository {
+	return &AdminRepository{}
+}
+
+// CreateBan 保存账号封禁
+func (r *AdminRepository) CreateBan(ban *models.AccountBan) (*models.AccountBan, error) {
+	var expiresAt interface{}
+	if ban.ExpiresAt != nil {
+		expiresAt = ban.ExpiresAt.UTC()
+	}
+	result, err := database.DB.Exec(`
+		INSERT INTO account_bans (user_id, reason, issued_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		ban.UserID, nullString(ban.Reason), ban.IssuedBy, ban.CreatedAt.UTC(), expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	ban.ID = int(id)
+	return ban, nil
+}
+
+// GetActiveBan 获取用户生效中的封禁（到期最晚的一条），没有时返回 nil
+func (r *AdminRepository) GetActiveBan(userID int, now time.Time) (*models.AccountBan, error) {
+	ban := &models.AccountBan{}
+	var reason sql.NullString
+	var expiresAt sql.NullTime
+	err := database.DB.QueryRow(`
+		SELECT id, user_id, reason, issued_by, created_at, expires_at
+		FROM account_bans
+		WHERE user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY expires_at IS NULL DESC, expires_at DESC
+		LIMIT 1`,
+		userID, now.UTC(),
+	).Scan(&ban.ID, &ban.UserID, &reason, &ban.IssuedBy, &ban.CreatedAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ban.Reason = reason.String
+	if expiresAt.Valid {
+		ban.ExpiresAt = &expiresAt.Time
+	}
+	return ban, nil
+}
+
+// RevokeBans 解除用户所有生效中的封禁，返回解除数量
+func (r *AdminRepository) RevokeBans(userID int, now time.Time) (int, error) {
+	result, err := database.DB.Exec(`
+		UPDATE account_bans SET revoked_at = ?
+		WHERE user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)`,
+		now.UTC(), userID, now.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// LogAction 写入GM操作审计记录
+func (r *AdminRepository) LogAction(entry *models.AdminAuditEntry) error {
+	result, err := database.DB.Exec(`
+		INSERT INTO admin_audit_log (actor_id, action, target_user_id, details, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		entry.ActorID, entry.Action, intPtrValue(entry.TargetUserID), nullString(entry.Details), entry.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = int(id)
+	return nil
+}
+
+// GetAuditLog 获取GM操作审计记录（最新在前，targetUserID 为0表示不限，beforeID>0 时向前翻页）
+func (r *AdminRepository) GetAuditLog(targetUserID, beforeID, limit int) ([]*models.AdminAuditEntry, error) {
+	query := `
+		SELECT l.id, l.actor_id, COALESCE(u.username, ''), l.action, l.target_user_id, COALESCE(l.details, ''), l.created_at
+		FROM admin_audit_log l
+		LEFT JOIN users u ON u.id = l.actor_id
+		WHERE 1 = 1`
+	args := []interface{}{}
+	if targetUserID > 0 {
+		query += " AND l.target_user_id = ?"
+		args = append(args, targetUserID)
+	}
+	if beforeID > 0 {
+		query += " AND l.id < ?"
+		args = append(args, beforeID)
+	}
+	query += " ORDER BY l.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.AdminAuditEntry, 0)
+	for rows.Next() {
+		e := &models.AdminAuditEntry{}
+		var targetID sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.ActorName, &e.Action, &targetID, &e.Details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.TargetUserID = nullIntPtr(targetID)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// AnnouncementRepository 全服公告数据仓库
+type AnnouncementRepository struct{}
+
+// NewAnnouncementRepository 创建全服公告仓库
+func NewAnnouncementRepository() *AnnouncementRepository {
+	return &AnnouncementRepository{}
+}
+
+// Create 保存公告
+func (r *AnnouncementRepository) Create(a *models.ServerAnnouncement) (*models.ServerAnnouncement, error) {
+	var expiresAt interface{}
+	if a.ExpiresAt != nil {
+		expiresAt = a.ExpiresAt.UTC()
+	}
+	result, err := database.DB.Exec(`
+		INSERT INTO server_announcements (
+			type, content, zone_id, winner_user_id, loser_user_id, pvp_encounter_id,
+			importance, expires_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.Type, a.Content, nullString(a.ZoneID), intPtrValue(a.WinnerUserID), intPtrValue(a.LoserUserID),
+		intPtrValue(a.PvPEncounterID), a.Importance, expiresAt, a.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	a.ID = int(id)
+	return a, nil
+}
+
+// GetActive 获取未过期的公告（最新在前，beforeID > 0 时向前翻页，announcementType 为空表示不限）
+func (r *AnnouncementRepository) GetActive(now time.Time, announcementType string, beforeID, limit int) ([]*models.ServerAnnouncement, error) {
+	query := `
+		SELECT id, type, content, COALESCE(zone_id, ''), winner_user_id, loser_user_id, pvp_encounter_id,
+		       importance, expires_at, created_at
+		FROM server_announcements
+		WHERE (expires_at IS NULL OR expires_at > ?)`
+	args := []interface{}{now.UTC()}
+	if announcementType != "" {
+		query += " AND type = ?"
+		args = append(args, announcementType)
+	}
+	if beforeID > 0 {
+		query += " AND id < ?"
+		args = append(args, beforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := make([]*models.ServerAnnouncement, 0)
+	for rows.Next() {
+		a := &models.ServerAnnouncement{}
+		var winnerID, loserID, encounterID sql.NullInt64
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&a.ID, &a.Type, &a.Content, &a.ZoneID, &winnerID, &loserID, &encounterID,
+			&a.Importance, &expiresAt, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.WinnerUserID = nullIntPtr(winnerID)
+		a.LoserUserID = nullIntPtr(loserID)
+		a.PvPEncounterID = nullIntPtr(encounterID)
+		if expiresAt.Valid {
+			a.ExpiresAt = &expiresAt.Time
+		}
+		announcements = append(announcements, a)
+	}
+	return announcements, rows.Err()
+}
+
+// RecordFirstKill 记录首领的全服首杀，已被击杀过时返回 false
+func (r *AnnouncementRepository) RecordFirstKill(monsterID string, userID int, now time.Time) (bool, error) {
+	result, err := database.DB.Exec(`
+		INSERT OR IGNORE INTO server_first_kills (monster_id, user_id, killed_at) VALUES (?, ?, ?)`,
+		monsterID, userID, now.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func intPtrValue(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
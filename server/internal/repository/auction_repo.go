@@ -0,0 +1,395 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 拍卖行错误
+var (
+	ErrAuctionListingNotFound  = errors.New("auction listing not found")
+	ErrAuctionListingNotActive = errors.New("auction listing is not active")
+	ErrAuctionListingExpired   = errors.New("auction listing has expired")
+	ErrAuctionOwnListing       = errors.New("cannot buy or bid on your own listing")
+	ErrAuctionNotSeller        = errors.New("listing does not belong to you")
+	ErrAuctionHasBids          = errors.New("cannot cancel a listing that has bids")
+	ErrAuctionNoBidding        = errors.New("listing does not accept bids")
+	ErrAuctionNoBuyout         = errors.New("listing has no buyout price")
+	ErrAuctionBidTooLow        = errors.New("bid is below the minimum")
+	ErrAuctionAlreadyHighest   = errors.New("you are already the highest bidder")
+)
+
+// AuctionRepository 拍卖行数据仓库
+type AuctionRepository struct{}
+
+// NewAuctionRepository 创建拍卖行仓库
+func NewAuctionRepository() *AuctionRepository {
+	return &AuctionRepository{}
+}
+
+// AuctionSearchFilter 拍卖行搜索条件（零值表示不限）
+type AuctionSearchFilter struct {
+	Slot     string
+	Quality  string
+	MinLevel int    // 最低需求等级
+	MaxLevel int    // 最高需求等级
+	Affix    string // 前缀/后缀/额外词缀ID
+	MaxPrice int
+	Limit    int
+	Offset   int
+}
+
+const auctionListingColumns = `
+	a.id, a.seller_id, COALESCE(u.username, ''), a.equipment_id, a.item_id,
+	COALESCE(i.name, a.item_id), COALESCE(i.level_required, 1),
+	e.owner_id, e.slot, e.quality, e.evolution_stage,
+	e.prefix_id, e.prefix_value, e.suffix_id, e.suffix_value,
+	e.bonus_affix_1, e.bonus_affix_1_value, e.bonus_affix_2, e.bonus_affix_2_value,
+	e.legendary_effect_id,
+	a.price, a.starting_bid, COALESCE(a.min_increment, 0), a.current_bid, a.current_bidder_id,
+	COALESCE(a.bid_count, 0), COALESCE(a.listing_fee, 0), COALESCE(a.transaction_fee, 0),
+	a.status, a.buyer_id, a.sold_price, a.listed_at, a.expires_at, a.closed_at
+	FROM auction_listings a
+	JOIN equipment_instance e ON e.id = a.equipment_id
+	LEFT JOIN users u ON u.id = a.seller_id
+	LEFT JOIN items i ON i.id = a.item_id`
+
+// InsertListingTx 创建上架记录，装备进入托管
+func (r *AuctionRepository) InsertListingTx(tx *sql.Tx, listing *models.AuctionListing) (*models.AuctionListing, error) {
+	result, err := tx.Exec(`
+		INSERT INTO auction_listings (seller_id, equipment_id, item_id, price, starting_bid, min_increment,
+		                              listing_fee, status, listed_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 'active', ?, ?)
+	`, listing.SellerID, listing.EquipmentID, listing.ItemID, listing.Price, listing.StartingBid, listing.MinIncrement,
+		listing.ListingFee, listing.ListedAt.UTC(), listing.ExpiresAt.UTC())
+	if err != nil {
+		return nil, err
+	}
+	id, _ := result.LastInsertId()
+	return getAuctionListing(tx, int(id))
+}
+
+// GetListingByID 获取上架信息
+func (r *AuctionRepository) GetListingByID(id int) (*models.AuctionListing, error) {
+	return getAuctionListing(database.DB, id)
+}
+
+// GetListingTx 在事务中获取上架信息
+func (r *AuctionRepository) GetListingTx(tx *sql.Tx, id int) (*models.AuctionListing, error) {
+	return getAuctionListing(tx, id)
+}
+
+// CloseListingTx 结束上架（成交/过期/取消），装备解除托管
+func (r *AuctionRepository) CloseListingTx(tx *sql.Tx, listingID int, status string, buyerID, soldPrice *int, fee int, now time.Time) error {
+	result, err := tx.Exec(`
+		UPDATE auction_listings SET status = ?, buyer_id = ?, sold_price = ?, transaction_fee = ?, closed_at = ?
+		WHERE id = ? AND status = 'active'
+	`, status, buyerID, soldPrice, fee, now.UTC(), listingID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrAuctionListingNotActive
+	}
+	return nil
+}
+
+// RecordBidTx 记录新的最高出价：原最高出价标记为被超过，更新当前出价与结束时间
+func (r *AuctionRepository) RecordBidTx(tx *sql.Tx, listingID, bidderID, amount int, expiresAt, now time.Time) (*models.AuctionBid, error) {
+	if err := r.SetActiveBidStatusTx(tx, listingID, "outbid"); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO auction_bids (listing_id, bidder_id, amount, status, created_at)
+		VALUES (?, ?, ?, 'active', ?)
+	`, listingID, bidderID, amount, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	id, _ := result.LastInsertId()
+
+	result, err = tx.Exec(`
+		UPDATE auction_listings
+		SET current_bid = ?, current_bidder_id = ?, bid_count = COALESCE(bid_count, 0) + 1, expires_at = ?
+		WHERE id = ? AND status = 'active'
+	`, amount, bidderID, expiresAt.UTC(), listingID)
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrAuctionListingNotActive
+	}
+
+	return &models.AuctionBid{
+		ID:        int(id),
+		ListingID: listingID,
+		BidderID:  bidderID,
+		Amount:    amount,
+		Status:    "active",
+		CreatedAt: now.UTC(),
+	}, nil
+}
+
+// SetActiveBidStatusTx 更新上架当前有效出价的状态（outbid/won/refunded）
+func (r *AuctionRepository) SetActiveBidStatusTx(tx *sql.Tx, listingID int, status string) error {
+	_, err := tx.Exec(`
+		UPDATE auction_bids SET status = ? WHERE listing_id = ? AND status = 'active'
+	`, status, listingID)
+	return err
+}
+
+// GetDueListingIDs 获取已到期但仍在售的上架ID
+func (r *AuctionRepository) GetDueListingIDs(now time.Time) ([]int, error) {
+	rows, err := database.DB.Query(`
+		SELECT id FROM auction_listings
+		WHERE status = 'active' AND expires_at <= ?
+		ORDER BY expires_at ASC, id ASC
+	`, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetBids 获取上架的出价记录（最高在前）
+func (r *AuctionRepository) GetBids(listingID int) ([]*models.AuctionBid, error) {
+	rows, err := database.DB.Query(`
+		SELECT b.id, b.listing_id, b.bidder_id, COALESCE(u.username, ''), b.amount, b.status, b.created_at
+		FROM auction_bids b
+		LEFT JOIN users u ON u.id = b.bidder_id
+		WHERE b.listing_id = ?
+		ORDER BY b.amount DESC, b.id DESC
+	`, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAuctionBids(rows)
+}
+
+// GetBidsByBidder 获取玩家的出价记录（最新在前）
+func (r *AuctionRepository) GetBidsByBidder(bidderID int, limit int) ([]*models.AuctionBid, error) {
+	rows, err := database.DB.Query(`
+		SELECT b.id, b.listing_id, b.bidder_id, COALESCE(u.username, ''), b.amount, b.status, b.created_at
+		FROM auction_bids b
+		LEFT JOIN users u ON u.id = b.bidder_id
+		WHERE b.bidder_id = ?
+		ORDER BY b.created_at DESC, b.id DESC
+		LIMIT ?
+	`, bidderID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAuctionBids(rows)
+}
+
+// auctionDisplayPrice 搜索排序与价格筛选使用的价格：一口价上架为一口价，竞价拍卖为当前出价或起拍价
+const auctionDisplayPrice = `CASE WHEN a.starting_bid IS NULL THEN a.price ELSE COALESCE(a.current_bid, a.starting_bid) END`
+
+// SearchListings 搜索在售的上架（按价格升序）
+func (r *AuctionRepository) SearchListings(filter AuctionSearchFilter, now time.Time) ([]*models.AuctionListing, error) {
+	conditions := []string{"a.status = 'active'", "a.expires_at > ?"}
+	args := []interface{}{now.UTC()}
+	if filter.Slot != "" {
+		conditions = append(conditions, "e.slot = ?")
+		args = append(args, filter.Slot)
+	}
+	if filter.Quality != "" {
+		conditions = append(conditions, "e.quality = ?")
+		args = append(args, filter.Quality)
+	}
+	if filter.MinLevel > 0 {
+		conditions = append(conditions, "COALESCE(i.level_required, 1) >= ?")
+		args = append(args, filter.MinLevel)
+	}
+	if filter.MaxLevel > 0 {
+		conditions = append(conditions, "COALESCE(i.level_required, 1) <= ?")
+		args = append(args, filter.MaxLevel)
+	}
+	if filter.Affix != "" {
+		conditions = append(conditions, "(e.prefix_id = ? OR e.suffix_id = ? OR e.bonus_affix_1 = ? OR e.bonus_affix_2 = ?)")
+		args = append(args, filter.Affix, filter.Affix, filter.Affix, filter.Affix)
+	}
+	if filter.MaxPrice > 0 {
+		conditions = append(conditions, auctionDisplayPrice+" <= ?")
+		args = append(args, filter.MaxPrice)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit, filter.Offset)
+
+	rows, err := database.DB.Query(`
+		SELECT `+auctionListingColumns+`
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY `+auctionDisplayPrice+` ASC, a.id ASC
+		LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAuctionListings(rows)
+}
+
+// GetListingsBySeller 获取卖家的上架记录（最新在前）
+func (r *AuctionRepository) GetListingsBySeller(sellerID int, limit int) ([]*models.AuctionListing, error) {
+	rows, err := database.DB.Query(`
+		SELECT `+auctionListingColumns+`
+		WHERE a.seller_id = ?
+		ORDER BY a.listed_at DESC, a.id DESC
+		LIMIT ?`, sellerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAuctionListings(rows)
+}
+
+// IsEquipmentEscrowed 装备是否被拍卖行、邮箱、商人回购栏或公会银行托管
+func (r *AuctionRepository) IsEquipmentEscrowed(equipmentID int) (bool, error) {
+	return isEquipmentEscrowed(database.DB, equipmentID)
+}
+
+// isEquipmentEscrowed 装备是否被拍卖行上架、未领取的邮件、商人回购栏或公会银行托管
+func isEquipmentEscrowed(db dbExecutor, equipmentID int) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM auction_listings WHERE equipment_id = ? AND status = 'active'
+	`, equipmentID).Scan(&count)
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	inMail, err := isEquipmentInMail(db, equipmentID)
+	if err != nil || inMail {
+		return inMail, err
+	}
+	inGuildBank, err := isEquipmentInGuildBank(db, equipmentID)
+	if err != nil || inGuildBank {
+		return inGuildBank, err
+	}
+	return isEquipmentSoldToVendor(db, equipmentID)
+}
+
+func getAuctionListing(db dbExecutor, id int) (*models.AuctionListing, error) {
+	listing, err := scanAuctionListing(db.QueryRow(`SELECT `+auctionListingColumns+` WHERE a.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrAuctionListingNotFound
+	}
+	return listing, err
+}
+
+func scanAuctionListings(rows *sql.Rows) ([]*models.AuctionListing, error) {
+	listings := make([]*models.AuctionListing, 0)
+	for rows.Next() {
+		listing, err := scanAuctionListing(rows)
+		if err != nil {
+			return nil, err
+		}
+		listings = append(listings, listing)
+	}
+	return listings, rows.Err()
+}
+
+func scanAuctionListing(row rowScanner) (*models.AuctionListing, error) {
+	listing := &models.AuctionListing{}
+	equipment := &models.EquipmentInstance{}
+	var prefixID, suffixID, bonusAffix1, bonusAffix2, legendaryEffectID sql.NullString
+	var prefixValue, suffixValue, bonusAffix1Value, bonusAffix2Value sql.NullFloat64
+	var startingBid, currentBid, currentBidderID, buyerID, soldPrice sql.NullInt64
+	var closedAt sql.NullTime
+
+	err := row.Scan(
+		&listing.ID, &listing.SellerID, &listing.SellerName, &listing.EquipmentID, &listing.ItemID,
+		&listing.ItemName, &listing.LevelRequired,
+		&equipment.OwnerID, &equipment.Slot, &equipment.Quality, &equipment.EvolutionStage,
+		&prefixID, &prefixValue, &suffixID, &suffixValue,
+		&bonusAffix1, &bonusAffix1Value, &bonusAffix2, &bonusAffix2Value,
+		&legendaryEffectID,
+		&listing.Price, &startingBid, &listing.MinIncrement, &currentBid, &currentBidderID,
+		&listing.BidCount, &listing.ListingFee, &listing.TransactionFee,
+		&listing.Status, &buyerID, &soldPrice, &listing.ListedAt, &listing.ExpiresAt, &closedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	equipment.ID = listing.EquipmentID
+	equipment.ItemID = listing.ItemID
+	if prefixID.Valid {
+		equipment.PrefixID = &prefixID.String
+	}
+	if prefixValue.Valid {
+		equipment.PrefixValue = &prefixValue.Float64
+	}
+	if suffixID.Valid {
+		equipment.SuffixID = &suffixID.String
+	}
+	if suffixValue.Valid {
+		equipment.SuffixValue = &suffixValue.Float64
+	}
+	if bonusAffix1.Valid {
+		equipment.BonusAffix1 = &bonusAffix1.String
+	}
+	if bonusAffix1Value.Valid {
+		equipment.BonusAffix1Value = &bonusAffix1Value.Float64
+	}
+	if bonusAffix2.Valid {
+		equipment.BonusAffix2 = &bonusAffix2.String
+	}
+	if bonusAffix2Value.Valid {
+		equipment.BonusAffix2Value = &bonusAffix2Value.Float64
+	}
+	if legendaryEffectID.Valid {
+		equipment.LegendaryEffectID = &legendaryEffectID.String
+	}
+	listing.Equipment = equipment
+
+	listing.StartingBid = nullIntPtr(startingBid)
+	listing.CurrentBid = nullIntPtr(currentBid)
+	listing.CurrentBidderID = nullIntPtr(currentBidderID)
+	listing.BuyerID = nullIntPtr(buyerID)
+	listing.SoldPrice = nullIntPtr(soldPrice)
+	if closedAt.Valid {
+		listing.ClosedAt = &closedAt.Time
+	}
+	return listing, nil
+}
+
+func scanAuctionBids(rows *sql.Rows) ([]*models.AuctionBid, error) {
+	bids := make([]*models.AuctionBid, 0)
+	for rows.Next() {
+		bid := &models.AuctionBid{}
+		if err := rows.Scan(&bid.ID, &bid.ListingID, &bid.BidderID, &bid.BidderName, &bid.Amount, &bid.Status, &bid.CreatedAt); err != nil {
+			return nil, err
+		}
+		bids = append(bids, bid)
+	}
+	return bids, rows.Err()
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
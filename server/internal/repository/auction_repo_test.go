@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ═══════════════════════════════════════════════════════════
+// 测试辅助函数
+// ═══════════════════════════════════════════════════════════
+
+func setupAuctionRepoTest(t *testing.T) (*AuctionRepository, int, int, func()) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = testDB.Exec(`
+		INSERT INTO items (id, name, type, quality, slot, level_required) VALUES
+			('auction_sword', '拍卖长剑', 'equipment', 'rare', 'main_hand', 20),
+			('auction_helm', '拍卖头盔', 'equipment', 'uncommon', 'head', 5);
+		INSERT INTO affixes (id, name, type, rarity, effect_type, min_value, max_value, value_type) VALUES
+			('prefix_strength', '力量的', 'prefix', 'common', 'stat', 1, 5, 'flat');
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert items: %v", err)
+	}
+
+	userRepo := NewUserRepository()
+	seller, err := userRepo.Create("seller", "hash", "")
+	if err != nil {
+		t.Fatalf("Failed to create seller: %v", err)
+	}
+	buyer, err := userRepo.Create("buyer", "hash", "")
+	if err != nil {
+		t.Fatalf("Failed to create buyer: %v", err)
+	}
+	cleanup := func() {
+		database.TeardownTestDB(testDB)
+	}
+	return NewAuctionRepository(), seller.ID, buyer.ID, cleanup
+}
+
+func createAuctionEquipment(t *testing.T, ownerID int, itemID, slot, quality string, prefixID *string) int {
+	equipment, err := NewEquipmentRepository().Create(&models.EquipmentInstance{
+		ItemID:         itemID,
+		OwnerID:        ownerID,
+		Slot:           slot,
+		Quality:        quality,
+		EvolutionStage: 1,
+		PrefixID:       prefixID,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create equipment: %v", err)
+	}
+	return equipment.ID
+}
+
+func insertAuctionListingForTest(t *testing.T, repo *AuctionRepository, sellerID, equipmentID, price int, startingBid *int, expiresAt time.Time) *models.AuctionListing {
+	listing, err := WithTransactionResult(func(tx *sql.Tx) (*models.AuctionListing, error) {
+		itemID, err := NewEquipmentRepository().CheckTradableTx(tx, sellerID, equipmentID)
+		if err != nil {
+			return nil, err
+		}
+		return repo.InsertListingTx(tx, &models.AuctionListing{
+			SellerID:     sellerID,
+			EquipmentID:  equipmentID,
+			ItemID:       itemID,
+			Price:        price,
+			StartingBid:  startingBid,
+			MinIncrement: 10,
+			ListedAt:     time.Now(),
+			ExpiresAt:    expiresAt,
+		})
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert listing: %v", err)
+	}
+	return listing
+}
+
+// ═══════════════════════════════════════════════════════════
+// 上架与托管测试
+// ═══════════════════════════════════════════════════════════
+
+func TestAuctionRepository_CheckListableAndEscrow(t *testing.T) {
+	repo, sellerID, buyerID, cleanup := setupAuctionRepoTest(t)
+	defer cleanup()
+
+	equipmentID := createAuctionEquipment(t, sellerID, "auction_sword", "main_hand", "rare", nil)
+	listing := insertAuctionListingForTest(t, repo, sellerID, equipmentID, 200, nil, time.Now().Add(time.Hour))
+	assert.Equal(t, "active", listing.Status)
+	assert.Equal(t, "拍卖长剑", listing.ItemName)
+	assert.Equal(t, 20, listing.LevelRequired)
+	assert.False(t, listing.IsBidding())
+
+	escrowed, err := repo.IsEquipmentEscrowed(equipmentID)
+	assert.NoError(t, err)
+	assert.True(t, escrowed, "上架期间装备应被托管")
+
+	err = WithTransaction(func(tx *sql.Tx) error {
+		_, err := NewEquipmentRepository().CheckTradableTx(tx, sellerID, equipmentID)
+		return err
+	})
+	assert.ErrorIs(t, err, ErrEquipmentInEscrow, "托管中的装备不能重复上架")
+
+	err = WithTransaction(func(tx *sql.Tx) error {
+		_, err := NewEquipmentRepository().CheckTradableTx(tx, buyerID, equipmentID)
+		return err
+	})
+	assert.ErrorIs(t, err, ErrEquipmentNotOwned)
+
+	err = WithTransaction(func(tx *sql.Tx) error {
+		return repo.CloseListingTx(tx, listing.ID, "cancelled", nil, nil, 0, time.Now())
+	})
+	assert.NoError(t, err)
+	escrowed, err = repo.IsEquipmentEscrowed(equipmentID)
+	assert.NoError(t, err)
+	assert.False(t, escrowed, "结束上架后装备解除托管")
+}
+
+func TestAuctionRepository_RecordBid(t *testing.T) {
+	repo, sellerID, buyerID, cleanup := setupAuctionRepoTest(t)
+	defer cleanup()
+
+	startingBid := 100
+	expiresAt := time.Now().Add(time.Hour)
+	equipmentID := createAuctionEquipment(t, sellerID, "auction_sword", "main_hand", "rare", nil)
+	listing := insertAuctionListingForTest(t, repo, sellerID, equipmentID, 0, &startingBid, expiresAt)
+	assert.True(t, listing.IsBidding())
+
+	extended := expiresAt.Add(10 * time.Minute)
+	err := WithTransaction(func(tx *sql.Tx) error {
+		if _, err := repo.RecordBidTx(tx, listing.ID, buyerID, 100, expiresAt, time.Now()); err != nil {
+			return err
+		}
+		_, err := repo.RecordBidTx(tx, listing.ID, sellerID, 150, extended, time.Now())
+		return err
+	})
+	assert.NoError(t, err)
+
+	current, err := repo.GetListingByID(listing.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 150, *current.CurrentBid)
+	assert.Equal(t, sellerID, *current.CurrentBidderID)
+	assert.Equal(t, 2, current.BidCount)
+	assert.WithinDuration(t, extended, current.ExpiresAt, time.Second)
+
+	bids, err := repo.GetBids(listing.ID)
+	assert.NoError(t, err)
+	assert.Len(t, bids, 2)
+	assert.Equal(t, "active", bids[0].Status)
+	assert.Equal(t, "outbid", bids[1].Status, "被超过的出价应标记为outbid")
+	assert.Equal(t, "buyer", bids[1].BidderName)
+}
+
+func TestAuctionRepository_SearchListings(t *testing.T) {
+	repo, sellerID, _, cleanup := setupAuctionRepoTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	prefix := "prefix_strength"
+	startingBid := 80
+	swordID := createAuctionEquipment(t, sellerID, "auction_sword", "main_hand", "rare", &prefix)
+	helmID := createAuctionEquipment(t, sellerID, "auction_helm", "head", "uncommon", nil)
+	insertAuctionListingForTest(t, repo, sellerID, swordID, 300, nil, now.Add(time.Hour))
+	insertAuctionListingForTest(t, repo, sellerID, helmID, 0, &startingBid, now.Add(time.Hour))
+
+	all, err := repo.SearchListings(AuctionSearchFilter{}, now)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.Equal(t, helmID, all[0].EquipmentID, "应按价格升序排列（竞价拍卖按起拍价）")
+
+	bySlot, err := repo.SearchListings(AuctionSearchFilter{Slot: "main_hand"}, now)
+	assert.NoError(t, err)
+	assert.Len(t, bySlot, 1)
+
+	byQuality, err := repo.SearchListings(AuctionSearchFilter{Quality: "uncommon"}, now)
+	assert.NoError(t, err)
+	assert.Len(t, byQuality, 1)
+
+	byLevel, err := repo.SearchListings(AuctionSearchFilter{MinLevel: 10, MaxLevel: 30}, now)
+	assert.NoError(t, err)
+	assert.Len(t, byLevel, 1)
+	assert.Equal(t, swordID, byLevel[0].EquipmentID)
+
+	byAffix, err := repo.SearchListings(AuctionSearchFilter{Affix: prefix}, now)
+	assert.NoError(t, err)
+	assert.Len(t, byAffix, 1)
+	assert.Equal(t, prefix, *byAffix[0].Equipment.PrefixID)
+
+	byPrice, err := repo.SearchListings(AuctionSearchFilter{MaxPrice: 100}, now)
+	assert.NoError(t, err)
+	assert.Len(t, byPrice, 1)
+
+	expired, err := repo.SearchListings(AuctionSearchFilter{}, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, expired, "已到期的上架不应出现在搜索结果中")
+}
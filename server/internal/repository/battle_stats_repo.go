@@ -2,7 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"text-wow/internal/database"
@@ -1300,7 +1303,197 @@ func (r *BattleStatsRepository) GetCumulativeDPSAnalysis(userID int, startTime t
 	return analysis, nil
 }
 
+// ═══════════════════════════════════════════════════════════
+// 仇恨日志 (battle_threat_log)
+// ═══════════════════════════════════════════════════════════
+
+// threatSnapshotData 仇恨快照的存储格式 {current_target, threat_list[]}
+type threatSnapshotData struct {
+	CurrentTarget int               `json:"current_target"`
+	ThreatList    []threatEntryData `json:"threat_list"`
+}
+
+type threatEntryData struct {
+	CharacterID   int    `json:"character_id"`
+	CharacterName string `json:"character_name"`
+	Threat        int    `json:"threat"`
+	IsTank        bool   `json:"is_tank"`
+}
+
+// CreateThreatLogs 批量保存一场战斗的仇恨快照
+func (r *BattleStatsRepository) CreateThreatLogs(battleID int, logs []*models.BattleThreatLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return WithTransaction(func(tx *sql.Tx) error {
+		stmt, err := tx.Prepare(`
+			INSERT INTO battle_threat_log (
+				battle_id, turn, enemy_id, threat_snapshot,
+				target_changed, ot_occurred, taunt_used, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		now := time.Now()
+		for _, entry := range logs {
+			snapshot := threatSnapshotData{CurrentTarget: entry.CurrentTarget}
+			for _, t := range entry.ThreatList {
+				snapshot.ThreatList = append(snapshot.ThreatList, threatEntryData{
+					CharacterID: t.CharacterID, CharacterName: t.CharacterName, Threat: t.Threat, IsTank: t.IsTank,
+				})
+			}
+			snapshotJSON, err := json.Marshal(snapshot)
+			if err != nil {
+				return err
+			}
+			_, err = stmt.Exec(strconv.Itoa(battleID), entry.Turn, entry.EnemyID, string(snapshotJSON),
+				boolToInt(entry.TargetChanged), boolToInt(entry.OTOccurred), boolToInt(entry.TauntUsed), now)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetThreatLogs 获取一场战斗的仇恨快照（按回合排序）
+func (r *BattleStatsRepository) GetThreatLogs(battleID int) ([]*models.BattleThreatLog, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, turn, enemy_id, threat_snapshot, target_changed, ot_occurred, taunt_used, created_at
+		FROM battle_threat_log
+		WHERE battle_id = ?
+		ORDER BY turn, id`, strconv.Itoa(battleID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := make([]*models.BattleThreatLog, 0)
+	for rows.Next() {
+		entry := &models.BattleThreatLog{BattleID: battleID}
+		var snapshotJSON string
+		var targetChanged, otOccurred, tauntUsed int
+		if err := rows.Scan(&entry.ID, &entry.Turn, &entry.EnemyID, &snapshotJSON,
+			&targetChanged, &otOccurred, &tauntUsed, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		var snapshot threatSnapshotData
+		if err := json.Unmarshal([]byte(snapshotJSON), &snapshot); err != nil {
+			return nil, fmt.Errorf("invalid threat snapshot %d: %w", entry.ID, err)
+		}
+		entry.CurrentTarget = snapshot.CurrentTarget
+		entry.ThreatList = make([]models.ThreatEntry, 0, len(snapshot.ThreatList))
+		for _, t := range snapshot.ThreatList {
+			entry.ThreatList = append(entry.ThreatList, models.ThreatEntry{
+				CharacterID: t.CharacterID, CharacterName: t.CharacterName, Threat: t.Threat, IsTank: t.IsTank,
+			})
+		}
+		entry.TargetChanged = targetChanged == 1
+		entry.OTOccurred = otOccurred == 1
+		entry.TauntUsed = tauntUsed == 1
+		logs = append(logs, entry)
+	}
+	return logs, rows.Err()
+}
+
+// GetBattleThreatAnalysis 获取单场战斗的仇恨时间线与OT汇总
+func (r *BattleStatsRepository) GetBattleThreatAnalysis(battleID int) (*models.BattleThreatAnalysis, error) {
+	battle, err := r.GetBattleRecordByID(battleID)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := r.GetThreatLogs(battleID)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &models.BattleThreatAnalysis{
+		BattleID:    battleID,
+		TotalRounds: battle.TotalRounds,
+		Timeline:    logs,
+		OTEvents:    make([]*models.ThreatOTEvent, 0),
+		Characters:  make([]*models.CharacterThreatSummary, 0),
+	}
+
+	characters := make(map[int]*models.CharacterThreatSummary)
+	getCharacter := func(t models.ThreatEntry) *models.CharacterThreatSummary {
+		summary, exists := characters[t.CharacterID]
+		if !exists {
+			summary = &models.CharacterThreatSummary{
+				CharacterID: t.CharacterID, CharacterName: t.CharacterName, IsTank: t.IsTank,
+			}
+			characters[t.CharacterID] = summary
+		}
+		return summary
+	}
+
+	targetedSnapshots, tankHeldSnapshots := 0, 0
+	previousTargets := make(map[string]models.ThreatEntry)
+	for _, entry := range logs {
+		var target *models.ThreatEntry
+		for i := range entry.ThreatList {
+			t := entry.ThreatList[i]
+			summary := getCharacter(t)
+			if t.Threat > summary.PeakThreat {
+				summary.PeakThreat = t.Threat
+			}
+			if t.CharacterID == entry.CurrentTarget {
+				target = &entry.ThreatList[i]
+			}
+		}
 
+		if target != nil {
+			targetedSnapshots++
+			summary := getCharacter(*target)
+			summary.TurnsAsTarget++
+			if target.IsTank {
+				tankHeldSnapshots++
+			}
+			if entry.TauntUsed {
+				summary.TauntCount++
+			}
+			if entry.OTOccurred {
+				summary.OTCount++
+				event := &models.ThreatOTEvent{
+					Turn: entry.Turn, EnemyID: entry.EnemyID,
+					ToCharacterID: target.CharacterID, ToName: target.CharacterName, ToThreat: target.Threat,
+				}
+				if previous, exists := previousTargets[entry.EnemyID]; exists {
+					event.FromCharacterID = previous.CharacterID
+					event.FromName = previous.CharacterName
+					for _, t := range entry.ThreatList {
+						if t.CharacterID == previous.CharacterID {
+							event.FromThreat = t.Threat
+						}
+					}
+				}
+				analysis.OTEvents = append(analysis.OTEvents, event)
+			}
+			previousTargets[entry.EnemyID] = *target
+		}
 
+		if entry.TargetChanged {
+			analysis.TargetChanges++
+		}
+		if entry.OTOccurred {
+			analysis.OTCount++
+		}
+		if entry.TauntUsed {
+			analysis.TauntCount++
+		}
+	}
 
+	if targetedSnapshots > 0 {
+		analysis.TankHoldRate = float64(tankHeldSnapshots) / float64(targetedSnapshots) * 100
+	}
+	for _, summary := range characters {
+		analysis.Characters = append(analysis.Characters, summary)
+	}
+	sort.Slice(analysis.Characters, func(i, j int) bool {
+		return analysis.Characters[i].PeakThreat > analysis.Characters[j].PeakThreat
+	})
 
+	return analysis, nil
+}
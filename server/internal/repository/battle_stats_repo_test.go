@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"testing"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBattleStatsRepository_ThreatAnalysis(t *testing.T) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer database.TeardownTestDB(testDB)
+
+	user, err := NewUserRepository().Create("threat_user", "hash", "")
+	assert.NoError(t, err)
+
+	repo := NewBattleStatsRepository()
+	battleID, err := repo.CreateBattleRecord(&models.BattleRecord{
+		UserID: user.ID, ZoneID: "elwynn", BattleType: "pve", MonsterID: "wolf", TotalRounds: 3, Result: "victory",
+	})
+	assert.NoError(t, err)
+
+	tank := models.ThreatEntry{CharacterID: 1, CharacterName: "坦克", IsTank: true}
+	mage := models.ThreatEntry{CharacterID: 2, CharacterName: "法师"}
+	entry := func(turn, target, tankThreat, mageThreat int, changed, ot, taunt bool) *models.BattleThreatLog {
+		t1, t2 := tank, mage
+		t1.Threat, t2.Threat = tankThreat, mageThreat
+		return &models.BattleThreatLog{
+			Turn: turn, EnemyID: "wolf", CurrentTarget: target, ThreatList: []models.ThreatEntry{t1, t2},
+			TargetChanged: changed, OTOccurred: ot, TauntUsed: taunt,
+		}
+	}
+	err = repo.CreateThreatLogs(int(battleID), []*models.BattleThreatLog{
+		entry(1, 1, 50, 30, false, false, false),
+		entry(2, 2, 50, 80, true, true, false),
+		entry(3, 1, 80, 80, true, false, true),
+	})
+	assert.NoError(t, err)
+
+	logs, err := repo.GetThreatLogs(int(battleID))
+	assert.NoError(t, err)
+	assert.Len(t, logs, 3)
+	assert.Equal(t, 2, logs[1].CurrentTarget)
+	assert.True(t, logs[1].OTOccurred)
+	assert.Equal(t, "法师", logs[1].ThreatList[1].CharacterName)
+
+	analysis, err := repo.GetBattleThreatAnalysis(int(battleID))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, analysis.TargetChanges)
+	assert.Equal(t, 1, analysis.OTCount)
+	assert.Equal(t, 1, analysis.TauntCount)
+	assert.InDelta(t, 66.67, analysis.TankHoldRate, 0.01)
+	if assert.Len(t, analysis.OTEvents, 1) {
+		assert.Equal(t, 1, analysis.OTEvents[0].FromCharacterID)
+		assert.Equal(t, 2, analysis.OTEvents[0].ToCharacterID)
+		assert.Equal(t, 80, analysis.OTEvents[0].ToThreat)
+	}
+	assert.Len(t, analysis.Characters, 2)
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"database/sql"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// ChatLinkRepository 聊天链接快照数据仓库
+type ChatLinkRepository struct{}
+
+// NewChatLinkRepository 创建聊天链接仓库
+func NewChatLinkRepository() *ChatLinkRepository {
+	return &ChatLinkRepository{}
+}
+
+// GetItemSnapshot 生成玩家拥有的装备快照（不属于该玩家时返回 sql.ErrNoRows）
+func (r *ChatLinkRepository) GetItemSnapshot(ownerID, equipmentID int) (*models.ItemLinkSnapshot, error) {
+	snapshot := &models.ItemLinkSnapshot{
+		Stats:   make(map[string]float64),
+		Affixes: make([]*models.LinkedAffix, 0),
+	}
+	var affixIDs [4]sql.NullString
+	var affixValues [4]sql.NullFloat64
+	var legendaryID sql.NullString
+	var strength, agility, intellect, stamina, spirit, attack, defense, hpBonus, mpBonus int
+	var critRate float64
+	err := database.DB.QueryRow(`
+		SELECT e.id, e.item_id, i.name, COALESCE(i.description, ''), e.quality, COALESCE(i.slot, ''),
+		       i.level_required, COALESCE(e.evolution_stage, 1), COALESCE(e.evolution_path, ''),
+		       i.strength, i.agility, i.intellect, i.stamina, i.spirit,
+		       i.attack, i.defense, i.hp_bonus, i.mp_bonus, i.crit_rate,
+		       e.prefix_id, e.prefix_value, e.suffix_id, e.suffix_value,
+		       e.bonus_affix_1, e.bonus_affix_1_value, e.bonus_affix_2, e.bonus_affix_2_value,
+		       e.legendary_effect_id
+		FROM equipment_instance e
+		JOIN items i ON i.id = e.item_id
+		WHERE e.id = ? AND e.owner_id = ?`,
+		equipmentID, ownerID,
+	).Scan(
+		&snapshot.EquipmentID, &snapshot.ItemID, &snapshot.Name, &snapshot.Description, &snapshot.Quality, &snapshot.Slot,
+		&snapshot.LevelRequired, &snapshot.EvolutionStage, &snapshot.EvolutionPath,
+		&strength, &agility, &intellect, &stamina, &spirit,
+		&attack, &defense, &hpBonus, &mpBonus, &critRate,
+		&affixIDs[0], &affixValues[0], &affixIDs[1], &affixValues[1],
+		&affixIDs[2], &affixValues[2], &affixIDs[3], &affixValues[3],
+		&legendaryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for stat, value := range map[string]float64{
+		"strength": float64(strength), "agility": float64(agility), "intellect": float64(intellect),
+		"stamina": float64(stamina), "spirit": float64(spirit), "attack": float64(attack),
+		"defense": float64(defense), "hp_bonus": float64(hpBonus), "mp_bonus": float64(mpBonus),
+		"crit_rate": critRate,
+	} {
+		if value != 0 {
+			snapshot.Stats[stat] = value
+		}
+	}
+
+	positions := [4]string{"prefix", "suffix", "bonus", "bonus"}
+	for i, affixID := range affixIDs {
+		if !affixID.Valid {
+			continue
+		}
+		affix := &models.LinkedAffix{ID: affixID.String, Position: positions[i], Value: affixValues[i].Float64}
+		err := database.DB.QueryRow(`
+			SELECT name, COALESCE(effect_stat, ''), value_type, COALESCE(description, '')
+			FROM affixes WHERE id = ?`, affixID.String,
+		).Scan(&affix.Name, &affix.EffectStat, &affix.ValueType, &affix.Description)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Affixes = append(snapshot.Affixes, affix)
+	}
+
+	if legendaryID.Valid {
+		effect := &models.LinkedLegendaryEffect{ID: legendaryID.String}
+		err := database.DB.QueryRow(`
+			SELECT name, description FROM legendary_effects WHERE id = ?`, legendaryID.String,
+		).Scan(&effect.Name, &effect.Description)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.LegendaryEffect = effect
+	}
+	return snapshot, nil
+}
+
+// GetAchievementSnapshot 生成成就快照（包含玩家进度；未完成的隐藏成就返回 sql.ErrNoRows）
+func (r *ChatLinkRepository) GetAchievementSnapshot(userID int, achievementID string) (*models.AchievementLinkSnapshot, error) {
+	snapshot := &models.AchievementLinkSnapshot{}
+	var completedAt sql.NullTime
+	err := database.DB.QueryRow(`
+		SELECT a.id, a.name, COALESCE(a.description, ''), a.category, COALESCE(a.points, 0), a.condition_value,
+		       COALESCE(ua.progress, 0), ua.completed_at
+		FROM achievements a
+		LEFT JOIN user_achievements ua ON ua.achievement_id = a.id AND ua.user_id = ?
+		WHERE a.id = ? AND (COALESCE(a.is_hidden, 0) = 0 OR ua.completed_at IS NOT NULL)`,
+		userID, achievementID,
+	).Scan(
+		&snapshot.ID, &snapshot.Name, &snapshot.Description, &snapshot.Category, &snapshot.Points, &snapshot.ConditionValue,
+		&snapshot.Progress, &completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		snapshot.CompletedAt = &completedAt.Time
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 聊天管理错误
+var (
+	ErrChatAlreadyReported   = errors.New("message already reported")
+	ErrChatReportNotPending  = errors.New("report not found or already handled")
+	ErrChatSanctionNotActive = errors.New("sanction not found or no longer active")
+)
+
+// ChatModerationRepository 聊天管理数据仓库（禁言/封禁、举报、限流状态）
+type ChatModerationRepository struct{}
+
+// NewChatModerationRepository 创建聊天管理仓库
+func NewChatModerationRepository() *ChatModerationRepository {
+	return &ChatModerationRepository{}
+}
+
+// ═══════════════════════════════════════════════════════════
+// 禁言/封禁
+// ═══════════════════════════════════════════════════════════
+
+// CreateSanction 创建禁言/封禁记录
+func (r *ChatModerationRepository) CreateSanction(sanction *models.ChatSanction) (*models.ChatSanction, error) {
+	var expiresAt interface{}
+	if sanction.ExpiresAt != nil {
+		expiresAt = sanction.ExpiresAt.UTC()
+	}
+	sanction.CreatedAt = time.Now().UTC()
+	result, err := database.DB.Exec(`
+		INSERT INTO chat_sanctions (user_id, sanction_type, channel, reason, issued_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		sanction.UserID, sanction.Type, nullString(sanction.Channel), nullString(sanction.Reason),
+		sanction.IssuedBy, sanction.CreatedAt, expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	sanction.ID = int(id)
+	return sanction, nil
+}
+
+// GetActiveSanctions 获取生效中的禁言/封禁（userID 为 0 时返回所有玩家的）
+func (r *ChatModerationRepository) GetActiveSanctions(userID int, now time.Time) ([]*models.ChatSanction, error) {
+	query := `
+		SELECT s.id, s.user_id, u.username, s.sanction_type, COALESCE(s.channel, ''), COALESCE(s.reason, ''),
+		       s.issued_by, s.created_at, s.expires_at, s.revoked_at
+		FROM chat_sanctions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.revoked_at IS NULL AND (s.expires_at IS NULL OR s.expires_at > ?)`
+	args := []interface{}{now.UTC()}
+	if userID != 0 {
+		query += " AND s.user_id = ?"
+		args = append(args, userID)
+	}
+	query += " ORDER BY s.created_at DESC, s.id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sanctions := make([]*models.ChatSanction, 0)
+	for rows.Next() {
+		sanction := &models.ChatSanction{}
+		var expiresAt, revokedAt sql.NullTime
+		err := rows.Scan(
+			&sanction.ID, &sanction.UserID, &sanction.PlayerName, &sanction.Type, &sanction.Channel, &sanction.Reason,
+			&sanction.IssuedBy, &sanction.CreatedAt, &expiresAt, &revokedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			sanction.ExpiresAt = &expiresAt.Time
+		}
+		if revokedAt.Valid {
+			sanction.RevokedAt = &revokedAt.Time
+		}
+		sanctions = append(sanctions, sanction)
+	}
+	return sanctions, rows.Err()
+}
+
+// RevokeSanction 解除禁言/封禁
+func (r *ChatModerationRepository) RevokeSanction(id int, now time.Time) error {
+	result, err := database.DB.Exec(`
+		UPDATE chat_sanctions SET revoked_at = ?
+		WHERE id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)`,
+		now.UTC(), id, now.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrChatSanctionNotActive
+	}
+	return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// 举报
+// ═══════════════════════════════════════════════════════════
+
+// CreateReport 创建举报（同一玩家对同一条消息只能举报一次）
+func (r *ChatModerationRepository) CreateReport(report *models.ChatReport) (*models.ChatReport, error) {
+	report.Status = "pending"
+	report.CreatedAt = time.Now().UTC()
+	result, err := database.DB.Exec(`
+		INSERT OR IGNORE INTO chat_reports (message_id, reporter_id, reported_user_id, content, reason, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		report.MessageID, report.ReporterID, report.ReportedUserID, report.Content,
+		nullString(report.Reason), report.Status, report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrChatAlreadyReported
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	report.ID = int(id)
+	return report, nil
+}
+
+// GetReports 获取举报列表（status 为空时返回全部，最早的在前）
+func (r *ChatModerationRepository) GetReports(status string, limit int) ([]*models.ChatReport, error) {
+	query := `
+		SELECT cr.id, cr.message_id, cr.reporter_id, reporter.username, cr.reported_user_id, reported.username,
+		       cr.content, COALESCE(cr.reason, ''), cr.status, cr.handled_by, cr.handled_at, cr.created_at
+		FROM chat_reports cr
+		JOIN users reporter ON reporter.id = cr.reporter_id
+		JOIN users reported ON reported.id = cr.reported_user_id`
+	args := []interface{}{}
+	if status != "" {
+		query += " WHERE cr.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY cr.created_at ASC, cr.id ASC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := make([]*models.ChatReport, 0)
+	for rows.Next() {
+		report := &models.ChatReport{}
+		var handledBy sql.NullInt64
+		var handledAt sql.NullTime
+		err := rows.Scan(
+			&report.ID, &report.MessageID, &report.ReporterID, &report.ReporterName, &report.ReportedUserID, &report.ReportedName,
+			&report.Content, &report.Reason, &report.Status, &handledBy, &handledAt, &report.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if handledBy.Valid {
+			id := int(handledBy.Int64)
+			report.HandledBy = &id
+		}
+		if handledAt.Valid {
+			report.HandledAt = &handledAt.Time
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// ResolveReport 处理举报（只能处理待处理的举报）
+func (r *ChatModerationRepository) ResolveReport(id int, status string, handledBy int) error {
+	result, err := database.DB.Exec(`
+		UPDATE chat_reports SET status = ?, handled_by = ?, handled_at = ?
+		WHERE id = ? AND status = 'pending'`,
+		status, handledBy, time.Now().UTC(), id,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrChatReportNotPending
+	}
+	return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// 限流状态
+// ═══════════════════════════════════════════════════════════
+
+// GetRateBucketTx 在事务中获取令牌桶状态（不存在时返回 nil）
+func (r *ChatModerationRepository) GetRateBucketTx(tx *sql.Tx, userID int, channel string) (*models.ChatRateBucket, error) {
+	bucket := &models.ChatRateBucket{UserID: userID, Channel: channel}
+	var lastSentAt sql.NullTime
+	err := tx.QueryRow(`
+		SELECT tokens, updated_at, COALESCE(last_content, ''), last_sent_at
+		FROM chat_rate_limits WHERE user_id = ? AND channel = ?`,
+		userID, channel,
+	).Scan(&bucket.Tokens, &bucket.UpdatedAt, &bucket.LastContent, &lastSentAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lastSentAt.Valid {
+		bucket.LastSentAt = &lastSentAt.Time
+	}
+	return bucket, nil
+}
+
+// SaveRateBucketTx 在事务中保存令牌桶状态
+func (r *ChatModerationRepository) SaveRateBucketTx(tx *sql.Tx, bucket *models.ChatRateBucket) error {
+	var lastSentAt interface{}
+	if bucket.LastSentAt != nil {
+		lastSentAt = bucket.LastSentAt.UTC()
+	}
+	_, err := tx.Exec(`
+		INSERT INTO chat_rate_limits (user_id, channel, tokens, updated_at, last_content, last_sent_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, channel) DO UPDATE SET
+			tokens = excluded.tokens, updated_at = excluded.updated_at,
+			last_content = excluded.last_content, last_sent_at = excluded.last_sent_at`,
+		bucket.UserID, bucket.Channel, bucket.Tokens, bucket.UpdatedAt.UTC(), nullString(bucket.LastContent), lastSentAt,
+	)
+	return err
+}
@@ -2,9 +2,12 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"text-wow/internal/database"
+	"text-wow/internal/models"
 )
 
 // ChatMessage 聊天消息
@@ -17,8 +20,12 @@ type ChatMessage struct {
 	SenderName  string    `json:"senderName"`
 	SenderClass string    `json:"senderClass,omitempty"`
 	ReceiverID  int       `json:"receiverId,omitempty"`
+	GuildID     int       `json:"guildId,omitempty"`
 	Content     string    `json:"content"`
+	MessageType string    `json:"messageType"` // say/emote/roll/announcement
 	CreatedAt   time.Time `json:"createdAt"`
+
+	Links []*models.ChatLink `json:"links,omitempty"`
 }
 
 // OnlineUser 在线用户（CharacterName字段现在存储玩家名，不是角色名）
@@ -32,6 +39,26 @@ type OnlineUser struct {
 	IsOnline      bool      `json:"isOnline"`
 }
 
+// WhoEntry /who 查询结果（角色信息取队长）
+type WhoEntry struct {
+	UserID        int    `json:"userId"`
+	PlayerName    string `json:"playerName"`
+	Faction       string `json:"faction"`
+	ZoneID        string `json:"zoneId"`
+	CharacterName string `json:"characterName,omitempty"`
+	ClassID       string `json:"classId,omitempty"`
+	Level         int    `json:"level"`
+}
+
+// WhoFilter /who 查询条件（空值/0 表示不限）
+type WhoFilter struct {
+	ZoneID   string
+	ClassID  string
+	MinLevel int
+	MaxLevel int
+	Limit    int
+}
+
 // ChatRepository 聊天数据仓库
 type ChatRepository struct{}
 
@@ -40,35 +67,79 @@ func NewChatRepository() *ChatRepository {
 	return &ChatRepository{}
 }
 
-// SendMessage 发送消息
+// SendMessage 发送消息（链接快照与消息在同一事务中保存）
 func (r *ChatRepository) SendMessage(msg *ChatMessage) (*ChatMessage, error) {
-	result, err := database.DB.Exec(`
-		INSERT INTO chat_messages (channel, faction, zone_id, sender_id, sender_name, sender_class, receiver_id, content, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		msg.Channel, nullString(msg.Faction), nullString(msg.ZoneID),
-		msg.SenderID, msg.SenderName, nullString(msg.SenderClass),
-		nullInt(msg.ReceiverID), msg.Content, time.Now(),
-	)
-	if err != nil {
-		return nil, err
+	if msg.MessageType == "" {
+		msg.MessageType = "say"
 	}
+	err := WithTransaction(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			INSERT INTO chat_messages (channel, faction, zone_id, sender_id, sender_name, sender_class, receiver_id, guild_id, content, message_type, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			msg.Channel, nullString(msg.Faction), nullString(msg.ZoneID),
+			msg.SenderID, msg.SenderName, nullString(msg.SenderClass),
+			nullInt(msg.ReceiverID), nullInt(msg.GuildID), msg.Content, msg.MessageType, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
 
-	id, err := result.LastInsertId()
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		msg.ID = int(id)
+
+		for i, link := range msg.Links {
+			snapshot, err := json.Marshal(link.Snapshot)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(`
+				INSERT INTO chat_message_links (message_id, position, token, link_type, target_id, name, quality, snapshot)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				msg.ID, i, link.Token, link.Type, link.TargetID, link.Name, nullString(link.Quality), string(snapshot),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	msg.ID = int(id)
 	msg.CreatedAt = time.Now()
 	return msg, nil
 }
 
+// GetMessageByID 获取单条消息
+func (r *ChatRepository) GetMessageByID(id int) (*ChatMessage, error) {
+	msg := &ChatMessage{}
+	err := database.DB.QueryRow(`
+		SELECT id, channel, COALESCE(faction, ''), COALESCE(zone_id, ''),
+		       sender_id, sender_name, COALESCE(sender_class, ''),
+		       COALESCE(receiver_id, 0), COALESCE(guild_id, 0), content, COALESCE(message_type, 'say'), created_at
+		FROM chat_messages
+		WHERE id = ?`, id,
+	).Scan(
+		&msg.ID, &msg.Channel, &msg.Faction, &msg.ZoneID,
+		&msg.SenderID, &msg.SenderName, &msg.SenderClass,
+		&msg.ReceiverID, &msg.GuildID, &msg.Content, &msg.MessageType, &msg.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
 // GetChannelMessages 获取频道消息
 func (r *ChatRepository) GetChannelMessages(channel, faction, zoneID string, limit, offset int) ([]ChatMessage, error) {
 	query := `
 		SELECT id, channel, COALESCE(faction, ''), COALESCE(zone_id, ''),
 		       sender_id, sender_name, COALESCE(sender_class, ''),
-		       COALESCE(receiver_id, 0), content, created_at
+		       COALESCE(receiver_id, 0), COALESCE(guild_id, 0), content, COALESCE(message_type, 'say'), created_at
 		FROM chat_messages
 		WHERE channel = ?`
 
@@ -93,7 +164,14 @@ func (r *ChatRepository) GetChannelMessages(channel, faction, zoneID string, lim
 	}
 	defer rows.Close()
 
-	return scanMessages(rows)
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.attachLinks(messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
 }
 
 // GetWhisperMessages 获取私聊消息
@@ -101,7 +179,7 @@ func (r *ChatRepository) GetWhisperMessages(userID, otherUserID int, limit, offs
 	rows, err := database.DB.Query(`
 		SELECT id, channel, COALESCE(faction, ''), COALESCE(zone_id, ''),
 		       sender_id, sender_name, COALESCE(sender_class, ''),
-		       COALESCE(receiver_id, 0), content, created_at
+		       COALESCE(receiver_id, 0), COALESCE(guild_id, 0), content, COALESCE(message_type, 'say'), created_at
 		FROM chat_messages
 		WHERE channel = 'whisper'
 		  AND ((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?))
@@ -114,7 +192,41 @@ func (r *ChatRepository) GetWhisperMessages(userID, otherUserID int, limit, offs
 	}
 	defer rows.Close()
 
-	return scanMessages(rows)
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.attachLinks(messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GetGuildMessages 获取公会频道消息
+func (r *ChatRepository) GetGuildMessages(guildID int, limit, offset int) ([]ChatMessage, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, channel, COALESCE(faction, ''), COALESCE(zone_id, ''),
+		       sender_id, sender_name, COALESCE(sender_class, ''),
+		       COALESCE(receiver_id, 0), COALESCE(guild_id, 0), content, COALESCE(message_type, 'say'), created_at
+		FROM chat_messages
+		WHERE channel = 'guild' AND guild_id = ?
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?`,
+		guildID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.attachLinks(messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
 }
 
 // GetRecentMessages 获取最近消息 (用于刚上线时加载)
@@ -122,10 +234,10 @@ func (r *ChatRepository) GetRecentMessages(faction string, limit int) ([]ChatMes
 	rows, err := database.DB.Query(`
 		SELECT id, channel, COALESCE(faction, ''), COALESCE(zone_id, ''),
 		       sender_id, sender_name, COALESCE(sender_class, ''),
-		       COALESCE(receiver_id, 0), content, created_at
+		       COALESCE(receiver_id, 0), COALESCE(guild_id, 0), content, COALESCE(message_type, 'say'), created_at
 		FROM chat_messages
 		WHERE (faction = ? OR faction IS NULL OR channel = 'system')
-		  AND channel != 'whisper'
+		  AND channel NOT IN ('whisper', 'guild')
 		ORDER BY created_at DESC
 		LIMIT ?`,
 		faction, limit,
@@ -135,7 +247,14 @@ func (r *ChatRepository) GetRecentMessages(faction string, limit int) ([]ChatMes
 	}
 	defer rows.Close()
 
-	return scanMessages(rows)
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.attachLinks(messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
 }
 
 // BlockUser 屏蔽用户
@@ -178,6 +297,27 @@ func (r *ChatRepository) GetBlockedUsers(userID int) ([]int, error) {
 	return blocked, nil
 }
 
+// GetBlockedBy 获取屏蔽了指定用户的玩家列表
+func (r *ChatRepository) GetBlockedBy(blockedID int) ([]int, error) {
+	rows, err := database.DB.Query(`
+		SELECT user_id FROM chat_blocks WHERE blocked_id = ?`, blockedID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		users = append(users, id)
+	}
+	return users, nil
+}
+
 // IsBlocked 检查是否被屏蔽
 func (r *ChatRepository) IsBlocked(userID, blockedID int) (bool, error) {
 	var count int
@@ -222,6 +362,15 @@ func (r *ChatRepository) UpdateLastActive(userID int) error {
 	return err
 }
 
+// MarkOffline 标记离线（保留玩家名，私聊仍可按名字查找）
+func (r *ChatRepository) MarkOffline(userID int) error {
+	_, err := database.DB.Exec(`
+		UPDATE user_online_status SET is_online = 0, last_active = ? WHERE user_id = ?`,
+		time.Now(), userID,
+	)
+	return err
+}
+
 // GetOnlineUsers 获取在线用户
 func (r *ChatRepository) GetOnlineUsers(faction string) ([]OnlineUser, error) {
 	query := `
@@ -302,6 +451,186 @@ func (r *ChatRepository) GetUserByName(name string) (*OnlineUser, error) {
 	return &u, nil
 }
 
+// GetLastWhisperSender 获取最近一条私聊的发送者（/r 回复对象）
+func (r *ChatRepository) GetLastWhisperSender(userID int) (int, string, error) {
+	var senderID int
+	var senderName string
+	err := database.DB.QueryRow(`
+		SELECT sender_id, sender_name FROM chat_messages
+		WHERE channel = 'whisper' AND receiver_id = ? AND sender_id != ?
+		ORDER BY id DESC LIMIT 1`,
+		userID, userID,
+	).Scan(&senderID, &senderName)
+	return senderID, senderName, err
+}
+
+// GetWhoList 查询同阵营在线玩家（/who）
+func (r *ChatRepository) GetWhoList(faction string, filter WhoFilter) ([]*WhoEntry, error) {
+	query := `
+		SELECT s.user_id, COALESCE(s.character_name, ''), COALESCE(s.faction, ''), COALESCE(s.zone_id, ''),
+		       COALESCE(c.name, ''), COALESCE(c.class_id, ''), COALESCE(c.level, 0)
+		FROM user_online_status s
+		LEFT JOIN characters c ON c.id = (
+			SELECT id FROM characters WHERE user_id = s.user_id ORDER BY is_active DESC, team_slot LIMIT 1)
+		WHERE s.is_online = 1 AND s.faction = ?`
+	args := []interface{}{faction}
+
+	if filter.ZoneID != "" {
+		query += " AND s.zone_id = ?"
+		args = append(args, filter.ZoneID)
+	}
+	if filter.ClassID != "" {
+		query += " AND c.class_id = ?"
+		args = append(args, filter.ClassID)
+	}
+	if filter.MinLevel > 0 {
+		query += " AND c.level >= ?"
+		args = append(args, filter.MinLevel)
+	}
+	if filter.MaxLevel > 0 {
+		query += " AND c.level <= ?"
+		args = append(args, filter.MaxLevel)
+	}
+	query += " ORDER BY s.character_name LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*WhoEntry, 0)
+	for rows.Next() {
+		e := &WhoEntry{}
+		if err := rows.Scan(&e.UserID, &e.PlayerName, &e.Faction, &e.ZoneID,
+			&e.CharacterName, &e.ClassID, &e.Level); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// JoinChannel 加入频道
+func (r *ChatRepository) JoinChannel(userID int, channel string) error {
+	_, err := database.DB.Exec(`
+		INSERT OR IGNORE INTO chat_channel_members (user_id, channel, joined_at) VALUES (?, ?, ?)`,
+		userID, channel, time.Now(),
+	)
+	return err
+}
+
+// LeaveChannel 离开频道
+func (r *ChatRepository) LeaveChannel(userID int, channel string) error {
+	_, err := database.DB.Exec(`
+		DELETE FROM chat_channel_members WHERE user_id = ? AND channel = ?`,
+		userID, channel,
+	)
+	return err
+}
+
+// GetJoinedChannels 获取玩家已加入的频道
+func (r *ChatRepository) GetJoinedChannels(userID int) ([]string, error) {
+	rows, err := database.DB.Query(`
+		SELECT channel FROM chat_channel_members WHERE user_id = ? ORDER BY channel`, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := make([]string, 0)
+	for rows.Next() {
+		var channel string
+		if err := rows.Scan(&channel); err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, rows.Err()
+}
+
+// GetChannelMembers 获取频道成员
+func (r *ChatRepository) GetChannelMembers(channel string) ([]int, error) {
+	rows, err := database.DB.Query(`
+		SELECT user_id FROM chat_channel_members WHERE channel = ?`, channel,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		members = append(members, id)
+	}
+	return members, rows.Err()
+}
+
+// attachLinks 批量加载消息中的链接快照
+func (r *ChatRepository) attachLinks(messages []ChatMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	index := make(map[int]*ChatMessage, len(messages))
+	placeholders := make([]string, len(messages))
+	args := make([]interface{}, len(messages))
+	for i := range messages {
+		index[messages[i].ID] = &messages[i]
+		placeholders[i] = "?"
+		args[i] = messages[i].ID
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT message_id, token, link_type, target_id, name, COALESCE(quality, ''), snapshot
+		FROM chat_message_links
+		WHERE message_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY message_id, position`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID int
+		var snapshot string
+		link := &models.ChatLink{}
+		if err := rows.Scan(&messageID, &link.Token, &link.Type, &link.TargetID, &link.Name, &link.Quality, &snapshot); err != nil {
+			return err
+		}
+		if link.Snapshot, err = decodeLinkSnapshot(link.Type, snapshot); err != nil {
+			return err
+		}
+		msg := index[messageID]
+		msg.Links = append(msg.Links, link)
+	}
+	return rows.Err()
+}
+
+// decodeLinkSnapshot 按链接类型解析快照
+func decodeLinkSnapshot(linkType, data string) (interface{}, error) {
+	var snapshot interface{}
+	switch linkType {
+	case "item":
+		snapshot = &models.ItemLinkSnapshot{}
+	case "skill":
+		snapshot = &models.Skill{}
+	case "achievement":
+		snapshot = &models.AchievementLinkSnapshot{}
+	default:
+		snapshot = &map[string]interface{}{}
+	}
+	if err := json.Unmarshal([]byte(data), snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
 // 辅助函数
 func scanMessages(rows *sql.Rows) ([]ChatMessage, error) {
 	var messages []ChatMessage
@@ -310,7 +639,7 @@ func scanMessages(rows *sql.Rows) ([]ChatMessage, error) {
 		err := rows.Scan(
 			&msg.ID, &msg.Channel, &msg.Faction, &msg.ZoneID,
 			&msg.SenderID, &msg.SenderName, &msg.SenderClass,
-			&msg.ReceiverID, &msg.Content, &msg.CreatedAt,
+			&msg.ReceiverID, &msg.GuildID, &msg.Content, &msg.MessageType, &msg.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// CodexRepository 图鉴数据仓库
+type CodexRepository struct{}
+
+// NewCodexRepository 创建图鉴仓库
+func NewCodexRepository() *CodexRepository {
+	return &CodexRepository{}
+}
+
+// codexSelect 图鉴查询（关联玩家解锁进度、怪物/物品描述和怪物族群）
+const codexSelect = `
+	SELECT c.id, c.category, c.target_id, c.name, COALESCE(c.description, ''),
+	       COALESCE(c.unlock_condition, ''), COALESCE(c.bonus_type, ''), COALESCE(c.bonus_value, 0),
+	       COALESCE(m.description, i.description, ''), COALESCE(m.family, ''),
+	       COALESCE(uc.unlock_count, 0), uc.first_unlock_at
+	FROM codex c
+	LEFT JOIN monsters m ON c.category IN ('monster', 'boss') AND m.id = c.target_id
+	LEFT JOIN items i ON c.category = 'item' AND i.id = c.target_id
+	LEFT JOIN user_codex uc ON uc.codex_id = c.id AND uc.user_id = ?`
+
+// GetEntries 获取图鉴条目（category为空时返回全部）
+func (r *CodexRepository) GetEntries(userID int, category string) ([]*models.CodexEntry, error) {
+	query := codexSelect
+	args := []interface{}{userID}
+	if category != "" {
+		query += " WHERE c.category = ?"
+		args = append(args, category)
+	}
+	query += " ORDER BY c.category, c.id"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCodexEntries(rows)
+}
+
+// GetUnlockedEntries 获取玩家已解锁的图鉴条目
+func (r *CodexRepository) GetUnlockedEntries(userID int) ([]*models.CodexEntry, error) {
+	rows, err := database.DB.Query(codexSelect+" WHERE uc.id IS NOT NULL ORDER BY c.id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCodexEntries(rows)
+}
+
+// GetEntryByID 获取单个图鉴条目
+func (r *CodexRepository) GetEntryByID(userID int, codexID string) (*models.CodexEntry, error) {
+	rows, err := database.DB.Query(codexSelect+" WHERE c.id = ?", userID, codexID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries, err := scanCodexEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return entries[0], nil
+}
+
+// GetEntryIDsByTarget 获取指定目标对应的图鉴条目ID
+func (r *CodexRepository) GetEntryIDsByTarget(targetID string, categories ...string) ([]string, error) {
+	if len(categories) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(categories)), ",")
+	args := []interface{}{targetID}
+	for _, category := range categories {
+		args = append(args, category)
+	}
+
+	rows, err := database.DB.Query(
+		"SELECT id FROM codex WHERE target_id = ? AND category IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Unlock 解锁图鉴条目（已解锁则累加解锁次数），返回最新解锁次数
+func (r *CodexRepository) Unlock(userID int, codexID string) (int, error) {
+	return WithTransactionResult(func(tx *sql.Tx) (int, error) {
+		_, err := tx.Exec(`
+			INSERT INTO user_codex (user_id, codex_id, unlock_count, first_unlock_at)
+			VALUES (?, ?, 1, CURRENT_TIMESTAMP)
+			ON CONFLICT(user_id, codex_id) DO UPDATE SET unlock_count = unlock_count + 1
+		`, userID, codexID)
+		if err != nil {
+			return 0, err
+		}
+
+		var count int
+		err = tx.QueryRow(`SELECT unlock_count FROM user_codex WHERE user_id = ? AND codex_id = ?`,
+			userID, codexID).Scan(&count)
+		return count, err
+	})
+}
+
+// scanCodexEntries 扫描图鉴条目
+func scanCodexEntries(rows *sql.Rows) ([]*models.CodexEntry, error) {
+	var entries []*models.CodexEntry
+	for rows.Next() {
+		e := &models.CodexEntry{}
+		var firstUnlockAt sql.NullTime
+		err := rows.Scan(
+			&e.ID, &e.Category, &e.TargetID, &e.Name, &e.Description,
+			&e.UnlockCondition, &e.BonusType, &e.BonusValue,
+			&e.Lore, &e.BonusFamily,
+			&e.UnlockCount, &firstUnlockAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if e.BonusType != "damage_vs_family" {
+			e.BonusFamily = ""
+		}
+		if firstUnlockAt.Valid {
+			e.FirstUnlockAt = &firstUnlockAt.Time
+		}
+		e.RequiredCount = parseRequiredCount(e.UnlockCondition)
+		e.Unlocked = e.UnlockCount > 0
+		e.BonusActive = e.BonusType != "" && e.UnlockCount >= e.RequiredCount
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// parseRequiredCount 解析解锁条件（加成生效所需解锁次数，默认1）
+func parseRequiredCount(condition string) int {
+	count, err := strconv.Atoi(strings.TrimSpace(condition))
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"testing"
+
+	"text-wow/internal/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ═══════════════════════════════════════════════════════════
+// 测试辅助函数
+// ═══════════════════════════════════════════════════════════
+
+func setupCodexRepoTest(t *testing.T) (*CodexRepository, int, func()) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = testDB.Exec(`
+		UPDATE monsters SET family = 'wolf', description = '成群出没的掠食者' WHERE id = 'wolf';
+		INSERT INTO codex (id, category, target_id, name, description, unlock_condition, bonus_type, bonus_value) VALUES
+			('codex_wolf', 'monster', 'wolf', '森林狼', '图鉴描述', '3', 'damage_vs_family', 2),
+			('codex_kobold', 'monster', 'kobold', '狗头人', '', NULL, 'damage_vs_monster', 5);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert codex data: %v", err)
+	}
+
+	user, err := NewUserRepository().Create("codexuser", "hash", "")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	cleanup := func() {
+		database.TeardownTestDB(testDB)
+	}
+	return NewCodexRepository(), user.ID, cleanup
+}
+
+// ═══════════════════════════════════════════════════════════
+// 解锁测试
+// ═══════════════════════════════════════════════════════════
+
+func TestCodexRepository_Unlock_IncrementsCount(t *testing.T) {
+	repo, userID, cleanup := setupCodexRepoTest(t)
+	defer cleanup()
+
+	count, err := repo.Unlock(userID, "codex_wolf")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count, "首次解锁次数应为1")
+
+	count, err = repo.Unlock(userID, "codex_wolf")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count, "重复解锁应累加次数")
+
+	entry, err := repo.GetEntryByID(userID, "codex_wolf")
+	assert.NoError(t, err)
+	assert.True(t, entry.Unlocked)
+	assert.NotNil(t, entry.FirstUnlockAt)
+	assert.Equal(t, 3, entry.RequiredCount)
+	assert.False(t, entry.BonusActive, "未达到解锁次数前加成不生效")
+
+	_, err = repo.Unlock(userID, "codex_wolf")
+	assert.NoError(t, err)
+	entry, err = repo.GetEntryByID(userID, "codex_wolf")
+	assert.NoError(t, err)
+	assert.True(t, entry.BonusActive, "达到解锁次数后加成生效")
+}
+
+func TestCodexRepository_GetEntries_IncludesLoreAndFamily(t *testing.T) {
+	repo, userID, cleanup := setupCodexRepoTest(t)
+	defer cleanup()
+
+	entries, err := repo.GetEntries(userID, "monster")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	for _, entry := range entries {
+		assert.False(t, entry.Unlocked)
+		if entry.ID == "codex_wolf" {
+			assert.Equal(t, "成群出没的掠食者", entry.Lore)
+			assert.Equal(t, "wolf", entry.BonusFamily)
+		}
+		if entry.ID == "codex_kobold" {
+			assert.Equal(t, 1, entry.RequiredCount, "未配置解锁条件时默认1次")
+			assert.Empty(t, entry.BonusFamily, "非族群加成不返回族群")
+		}
+	}
+
+	ids, err := repo.GetEntryIDsByTarget("wolf", "monster", "boss")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"codex_wolf"}, ids)
+}
+
+func TestCodexRepository_GetUnlockedEntries(t *testing.T) {
+	repo, userID, cleanup := setupCodexRepoTest(t)
+	defer cleanup()
+
+	_, err := repo.Unlock(userID, "codex_kobold")
+	assert.NoError(t, err)
+
+	entries, err := repo.GetUnlockedEntries(userID)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "codex_kobold", entries[0].ID)
+		assert.True(t, entries[0].BonusActive)
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 消耗品错误
+var (
+	ErrItemNotConsumable  = errors.New("item is not a consumable")
+	ErrItemNotInInventory = errors.New("item is not in the character's inventory")
+)
+
+// ConsumableRepository 消耗品数据仓库
+type ConsumableRepository struct{}
+
+// NewConsumableRepository 创建消耗品仓库
+func NewConsumableRepository() *ConsumableRepository {
+	return &ConsumableRepository{}
+}
+
+const consumableColumns = `
+	SELECT i.id, i.name, COALESCE(i.description, ''), COALESCE(i.subtype, ''), COALESCE(i.quality, 'common'),
+	       COALESCE(i.effect_type, ''), COALESCE(i.effect_value, 0), COALESCE(i.effect_stat, ''),
+	       COALESCE(i.effect_duration, 0), COALESCE(SUM(inv.quantity), 0)`
+
+// GetCharacterConsumables 获取角色背包中的所有消耗品（按物品汇总数量）
+func (r *ConsumableRepository) GetCharacterConsumables(characterID int) ([]*models.ConsumableItem, error) {
+	rows, err := database.DB.Query(consumableColumns+`
+		FROM inventory inv
+		JOIN items i ON i.id = inv.item_id
+		WHERE inv.character_id = ? AND i.type = 'consumable'
+		GROUP BY i.id
+		ORDER BY i.subtype, i.id`, characterID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*models.ConsumableItem, 0)
+	for rows.Next() {
+		item, err := scanConsumable(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetCharacterConsumable 获取角色持有的某个消耗品（数量可能为0）
+func (r *ConsumableRepository) GetCharacterConsumable(characterID int, itemID string) (*models.ConsumableItem, error) {
+	var itemType string
+	err := database.DB.QueryRow(`SELECT type FROM items WHERE id = ?`, itemID).Scan(&itemType)
+	if err == sql.ErrNoRows {
+		return nil, ErrItemNotInInventory
+	}
+	if err != nil {
+		return nil, err
+	}
+	if itemType != "consumable" {
+		return nil, ErrItemNotConsumable
+	}
+
+	return scanConsumable(database.DB.QueryRow(consumableColumns+`
+		FROM items i
+		LEFT JOIN inventory inv ON inv.item_id = i.id AND inv.character_id = ?
+		WHERE i.id = ?
+		GROUP BY i.id`, characterID, itemID,
+	))
+}
+
+// CountItem 统计角色背包中某物品的总数量
+func (r *ConsumableRepository) CountItem(characterID int, itemID string) (int, error) {
+	var count int
+	err := database.DB.QueryRow(`
+		SELECT COALESCE(SUM(quantity), 0) FROM inventory WHERE character_id = ? AND item_id = ?
+	`, characterID, itemID).Scan(&count)
+	return count, err
+}
+
+// ConsumeItem 消耗角色背包中的一个物品，返回剩余数量
+func (r *ConsumableRepository) ConsumeItem(characterID int, itemID string) (int, error) {
+	return WithTransactionResult(func(tx *sql.Tx) (int, error) {
+		var inventoryID, quantity int
+		err := tx.QueryRow(`
+			SELECT id, quantity FROM inventory
+			WHERE character_id = ? AND item_id = ? AND quantity > 0
+			ORDER BY slot ASC, id ASC LIMIT 1`, characterID, itemID,
+		).Scan(&inventoryID, &quantity)
+		if err == sql.ErrNoRows {
+			return 0, ErrItemNotInInventory
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if quantity > 1 {
+			_, err = tx.Exec(`UPDATE inventory SET quantity = quantity - 1 WHERE id = ?`, inventoryID)
+		} else {
+			_, err = tx.Exec(`DELETE FROM inventory WHERE id = ?`, inventoryID)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var remaining int
+		err = tx.QueryRow(`
+			SELECT COALESCE(SUM(quantity), 0) FROM inventory WHERE character_id = ? AND item_id = ?
+		`, characterID, itemID).Scan(&remaining)
+		return remaining, err
+	})
+}
+
+func scanConsumable(row rowScanner) (*models.ConsumableItem, error) {
+	item := &models.ConsumableItem{}
+	err := row.Scan(&item.ItemID, &item.Name, &item.Description, &item.Subtype, &item.Quality,
+		&item.EffectType, &item.EffectValue, &item.EffectStat, &item.EffectDuration, &item.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
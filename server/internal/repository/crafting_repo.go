@@ -0,0 +1,538 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 专业与制造错误
+var (
+	ErrProfessionNotFound       = errors.New("profession not found")
+	ErrProfessionNotLearned     = errors.New("character has not learned this profession")
+	ErrProfessionAlreadyLearned = errors.New("character already knows this profession")
+	ErrProfessionLimit          = errors.New("character already has the maximum number of professions of this type")
+	ErrProfessionInUse          = errors.New("profession has unfinished crafts in the queue")
+	ErrRecipeNotFound           = errors.New("recipe not found")
+	ErrMissingMaterials         = errors.New("not enough materials")
+	ErrCraftNotFound            = errors.New("crafting queue entry not found")
+	ErrCraftFinished            = errors.New("crafting queue entry is already finished")
+)
+
+// CraftingRepository 专业与制造数据仓库
+type CraftingRepository struct{}
+
+// NewCraftingRepository 创建制造仓库
+func NewCraftingRepository() *CraftingRepository {
+	return &CraftingRepository{}
+}
+
+// ═══════════════════════════════════════════════════════════
+// 专业与配方配置
+// ═══════════════════════════════════════════════════════════
+
+// GetProfessions 获取专业列表（professionType 为空时返回全部）
+func (r *CraftingRepository) GetProfessions(professionType string) ([]*models.Profession, error) {
+	query := `SELECT id, name, type, COALESCE(description, ''), max_skill FROM professions`
+	args := []interface{}{}
+	if professionType != "" {
+		query += ` WHERE type = ?`
+		args = append(args, professionType)
+	}
+	query += ` ORDER BY type, id`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	professions := make([]*models.Profession, 0)
+	for rows.Next() {
+		p := &models.Profession{}
+		if err := rows.Scan(&p.ID, &p.Name, &p.Type, &p.Description, &p.MaxSkill); err != nil {
+			return nil, err
+		}
+		professions = append(professions, p)
+	}
+	return professions, rows.Err()
+}
+
+// GetProfession 获取专业配置
+func (r *CraftingRepository) GetProfession(id string) (*models.Profession, error) {
+	p := &models.Profession{}
+	err := database.DB.QueryRow(`
+		SELECT id, name, type, COALESCE(description, ''), max_skill FROM professions WHERE id = ?
+	`, id).Scan(&p.ID, &p.Name, &p.Type, &p.Description, &p.MaxSkill)
+	if err == sql.ErrNoRows {
+		return nil, ErrProfessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+const recipeColumns = `
+	SELECT r.id, r.profession_id, r.name, COALESCE(r.description, ''), r.required_skill,
+	       r.skill_up_yellow, r.skill_up_gray, r.result_item_id, COALESCE(i.name, r.result_item_id),
+	       COALESCE(i.type, ''), COALESCE(i.level_required, 1), r.result_quantity,
+	       COALESCE(r.result_quality, 'common'), COALESCE(r.proc_chance, 0), r.craft_seconds
+	FROM recipes r
+	LEFT JOIN items i ON i.id = r.result_item_id`
+
+// GetRecipes 获取专业的所有配方（含材料）
+func (r *CraftingRepository) GetRecipes(professionID string) ([]*models.Recipe, error) {
+	return getRecipes(database.DB, recipeColumns+`
+		WHERE r.profession_id = ?
+		ORDER BY r.required_skill ASC, r.id ASC`, professionID)
+}
+
+// GetRecipe 获取配方（含材料）
+func (r *CraftingRepository) GetRecipe(id string) (*models.Recipe, error) {
+	return getRecipe(database.DB, id)
+}
+
+// GetRecipeTx 在事务中获取配方（含材料）
+func (r *CraftingRepository) GetRecipeTx(tx *sql.Tx, id string) (*models.Recipe, error) {
+	return getRecipe(tx, id)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 角色专业进度
+// ═══════════════════════════════════════════════════════════
+
+const characterProfessionColumns = `
+	SELECT cp.character_id, cp.profession_id, p.name, p.type, cp.skill_level, p.max_skill, cp.learned_at
+	FROM character_professions cp
+	JOIN professions p ON p.id = cp.profession_id`
+
+// GetCharacterProfessions 获取角色已学习的专业
+func (r *CraftingRepository) GetCharacterProfessions(characterID int) ([]*models.CharacterProfession, error) {
+	rows, err := database.DB.Query(characterProfessionColumns+`
+		WHERE cp.character_id = ?
+		ORDER BY p.type, cp.profession_id`, characterID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	professions := make([]*models.CharacterProfession, 0)
+	for rows.Next() {
+		cp, err := scanCharacterProfession(rows)
+		if err != nil {
+			return nil, err
+		}
+		professions = append(professions, cp)
+	}
+	return professions, rows.Err()
+}
+
+// GetCharacterProfession 获取角色某个专业的进度
+func (r *CraftingRepository) GetCharacterProfession(characterID int, professionID string) (*models.CharacterProfession, error) {
+	return getCharacterProfession(database.DB, characterID, professionID)
+}
+
+// GetCharacterProfessionTx 在事务中获取角色某个专业的进度
+func (r *CraftingRepository) GetCharacterProfessionTx(tx *sql.Tx, characterID int, professionID string) (*models.CharacterProfession, error) {
+	return getCharacterProfession(tx, characterID, professionID)
+}
+
+// LearnProfession 学习专业（同类型专业数量不能超过 maxPerType）
+func (r *CraftingRepository) LearnProfession(characterID int, professionID string, maxPerType int) (*models.CharacterProfession, error) {
+	return WithTransactionResult(func(tx *sql.Tx) (*models.CharacterProfession, error) {
+		var professionType string
+		err := tx.QueryRow(`SELECT type FROM professions WHERE id = ?`, professionID).Scan(&professionType)
+		if err == sql.ErrNoRows {
+			return nil, ErrProfessionNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := getCharacterProfession(tx, characterID, professionID); err == nil {
+			return nil, ErrProfessionAlreadyLearned
+		} else if err != ErrProfessionNotLearned {
+			return nil, err
+		}
+
+		var count int
+		err = tx.QueryRow(`
+			SELECT COUNT(*) FROM character_professions cp
+			JOIN professions p ON p.id = cp.profession_id
+			WHERE cp.character_id = ? AND p.type = ?
+		`, characterID, professionType).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		if count >= maxPerType {
+			return nil, ErrProfessionLimit
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO character_professions (character_id, profession_id, skill_level, learned_at)
+			VALUES (?, ?, 1, ?)
+		`, characterID, professionID, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+		return getCharacterProfession(tx, characterID, professionID)
+	})
+}
+
+// AbandonProfession 放弃专业（技能点清零，队列中有该专业的未完成制造时不允许）
+func (r *CraftingRepository) AbandonProfession(characterID int, professionID string) error {
+	return WithTransaction(func(tx *sql.Tx) error {
+		var pending int
+		err := tx.QueryRow(`
+			SELECT COUNT(*) FROM crafting_queue q
+			JOIN recipes r ON r.id = q.recipe_id
+			WHERE q.character_id = ? AND r.profession_id = ? AND q.status IN ('queued', 'active')
+		`, characterID, professionID).Scan(&pending)
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrProfessionInUse
+		}
+
+		result, err := tx.Exec(`
+			DELETE FROM character_professions WHERE character_id = ? AND profession_id = ?
+		`, characterID, professionID)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return ErrProfessionNotLearned
+		}
+		return nil
+	})
+}
+
+// SetSkillLevel 更新专业技能点
+func (r *CraftingRepository) SetSkillLevel(characterID int, professionID string, skillLevel int) error {
+	_, err := database.DB.Exec(`
+		UPDATE character_professions SET skill_level = ? WHERE character_id = ? AND profession_id = ?
+	`, skillLevel, characterID, professionID)
+	return err
+}
+
+// SetSkillLevelTx 更新专业技能点
+func (r *CraftingRepository) SetSkillLevelTx(tx *sql.Tx, characterID int, professionID string, skillLevel int) error {
+	_, err := tx.Exec(`
+		UPDATE character_professions SET skill_level = ? WHERE character_id = ? AND profession_id = ?
+	`, skillLevel, characterID, professionID)
+	return err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 材料
+// ═══════════════════════════════════════════════════════════
+
+// ConsumeMaterialsTx 从角色背包扣除配方材料（times 为制造次数，可跨多个堆叠扣除）
+func (r *CraftingRepository) ConsumeMaterialsTx(tx *sql.Tx, characterID int, materials []models.RecipeMaterial, times int) error {
+	for _, material := range materials {
+		if err := removeItemQuantity(tx, characterID, material.ItemID, material.Quantity*times); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReturnMaterialsTx 将配方材料返还到角色背包
+func (r *CraftingRepository) ReturnMaterialsTx(tx *sql.Tx, characterID int, materials []models.RecipeMaterial, times int) error {
+	for _, material := range materials {
+		if err := addInventoryItem(tx, characterID, material.ItemID, material.Quantity*times); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddItemTx 将制造产出放入角色背包
+func (r *CraftingRepository) AddItemTx(tx *sql.Tx, characterID int, itemID string, quantity int) error {
+	return addInventoryItem(tx, characterID, itemID, quantity)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 制造队列
+// ═══════════════════════════════════════════════════════════
+
+const craftingQueueColumns = `
+	SELECT q.id, q.user_id, q.character_id, q.recipe_id, COALESCE(r.name, q.recipe_id), COALESCE(r.profession_id, ''),
+	       q.quantity, q.crafted, q.status, q.next_craft_at, q.created_at, q.finished_at
+	FROM crafting_queue q
+	LEFT JOIN recipes r ON r.id = q.recipe_id`
+
+// CreateQueueEntryTx 创建制造队列条目
+func (r *CraftingRepository) CreateQueueEntryTx(tx *sql.Tx, entry *models.CraftingQueueEntry) error {
+	now := time.Now().UTC()
+	result, err := tx.Exec(`
+		INSERT INTO crafting_queue (user_id, character_id, recipe_id, quantity, crafted, status, next_craft_at, created_at)
+		VALUES (?, ?, ?, ?, 0, ?, ?, ?)
+	`, entry.UserID, entry.CharacterID, entry.RecipeID, entry.Quantity, entry.Status, entry.NextCraftAt, now)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	entry.ID = int(id)
+	entry.CreatedAt = now
+	return nil
+}
+
+// GetQueue 获取角色未完成的制造队列（按加入顺序）
+func (r *CraftingRepository) GetQueue(characterID int) ([]*models.CraftingQueueEntry, error) {
+	return getQueueEntries(database.DB, craftingQueueColumns+`
+		WHERE q.character_id = ? AND q.status IN ('queued', 'active')
+		ORDER BY q.id ASC`, characterID)
+}
+
+// CountPendingTx 统计角色未完成的队列条目数
+func (r *CraftingRepository) CountPendingTx(tx *sql.Tx, characterID int) (int, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM crafting_queue WHERE character_id = ? AND status IN ('queued', 'active')
+	`, characterID).Scan(&count)
+	return count, err
+}
+
+// GetQueueEntryTx 在事务中获取队列条目
+func (r *CraftingRepository) GetQueueEntryTx(tx *sql.Tx, id int) (*models.CraftingQueueEntry, error) {
+	entries, err := getQueueEntries(tx, craftingQueueColumns+` WHERE q.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrCraftNotFound
+	}
+	return entries[0], nil
+}
+
+// GetActiveEntry 获取角色正在制造的条目（没有时返回 nil）
+func (r *CraftingRepository) GetActiveEntry(characterID int) (*models.CraftingQueueEntry, error) {
+	return firstQueueEntry(database.DB, craftingQueueColumns+`
+		WHERE q.character_id = ? AND q.status = 'active'
+		ORDER BY q.id ASC LIMIT 1`, characterID)
+}
+
+// GetActiveEntryTx 获取角色正在制造的条目（没有时返回 nil）
+func (r *CraftingRepository) GetActiveEntryTx(tx *sql.Tx, characterID int) (*models.CraftingQueueEntry, error) {
+	return firstQueueEntry(tx, craftingQueueColumns+`
+		WHERE q.character_id = ? AND q.status = 'active'
+		ORDER BY q.id ASC LIMIT 1`, characterID)
+}
+
+// GetNextQueuedTx 获取角色下一个排队中的条目（没有时返回 nil）
+func (r *CraftingRepository) GetNextQueuedTx(tx *sql.Tx, characterID int) (*models.CraftingQueueEntry, error) {
+	return firstQueueEntry(tx, craftingQueueColumns+`
+		WHERE q.character_id = ? AND q.status = 'queued'
+		ORDER BY q.id ASC LIMIT 1`, characterID)
+}
+
+// UpdateQueueEntryTx 保存队列条目的进度和状态
+func (r *CraftingRepository) UpdateQueueEntryTx(tx *sql.Tx, entry *models.CraftingQueueEntry) error {
+	_, err := tx.Exec(`
+		UPDATE crafting_queue SET crafted = ?, status = ?, next_craft_at = ?, finished_at = ? WHERE id = ?
+	`, entry.Crafted, entry.Status, entry.NextCraftAt, entry.FinishedAt, entry.ID)
+	return err
+}
+
+// GetDueCharacterIDs 获取有到期制造的角色
+func (r *CraftingRepository) GetDueCharacterIDs(now time.Time) ([]int, error) {
+	rows, err := database.DB.Query(`
+		SELECT DISTINCT character_id FROM crafting_queue
+		WHERE status = 'active' AND next_craft_at <= ?
+		ORDER BY character_id ASC
+	`, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ═══════════════════════════════════════════════════════════
+// 内部辅助
+// ═══════════════════════════════════════════════════════════
+
+// removeItemQuantity 从角色背包移除指定数量的物品（按格子顺序跨堆叠扣除）
+func removeItemQuantity(tx *sql.Tx, characterID int, itemID string, quantity int) error {
+	rows, err := tx.Query(`
+		SELECT id, quantity FROM inventory
+		WHERE character_id = ? AND item_id = ? AND quantity > 0
+		ORDER BY slot ASC, id ASC`, characterID, itemID,
+	)
+	if err != nil {
+		return err
+	}
+	type stack struct{ id, quantity int }
+	stacks := make([]stack, 0)
+	total := 0
+	for rows.Next() {
+		var s stack
+		if err := rows.Scan(&s.id, &s.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		stacks = append(stacks, s)
+		total += s.quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if total < quantity {
+		return ErrMissingMaterials
+	}
+
+	remaining := quantity
+	for _, s := range stacks {
+		if remaining == 0 {
+			break
+		}
+		if s.quantity > remaining {
+			_, err = tx.Exec(`UPDATE inventory SET quantity = quantity - ? WHERE id = ?`, remaining, s.id)
+			remaining = 0
+		} else {
+			_, err = tx.Exec(`DELETE FROM inventory WHERE id = ?`, s.id)
+			remaining -= s.quantity
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getRecipe(db rowsQuerier, id string) (*models.Recipe, error) {
+	recipes, err := getRecipes(db, recipeColumns+` WHERE r.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return nil, ErrRecipeNotFound
+	}
+	return recipes[0], nil
+}
+
+func getRecipes(db rowsQuerier, query string, args ...interface{}) ([]*models.Recipe, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	recipes := make([]*models.Recipe, 0)
+	for rows.Next() {
+		recipe := &models.Recipe{Materials: make([]models.RecipeMaterial, 0)}
+		if err := rows.Scan(&recipe.ID, &recipe.ProfessionID, &recipe.Name, &recipe.Description, &recipe.RequiredSkill,
+			&recipe.SkillUpYellow, &recipe.SkillUpGray, &recipe.ResultItemID, &recipe.ResultItemName,
+			&recipe.ResultType, &recipe.ResultLevel, &recipe.ResultQuantity,
+			&recipe.ResultQuality, &recipe.ProcChance, &recipe.CraftSeconds); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, recipe := range recipes {
+		if err := loadRecipeMaterials(db, recipe); err != nil {
+			return nil, err
+		}
+	}
+	return recipes, nil
+}
+
+func loadRecipeMaterials(db rowsQuerier, recipe *models.Recipe) error {
+	rows, err := db.Query(`
+		SELECT m.item_id, COALESCE(i.name, m.item_id), m.quantity
+		FROM recipe_materials m
+		LEFT JOIN items i ON i.id = m.item_id
+		WHERE m.recipe_id = ?
+		ORDER BY m.item_id ASC`, recipe.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var material models.RecipeMaterial
+		if err := rows.Scan(&material.ItemID, &material.ItemName, &material.Quantity); err != nil {
+			return err
+		}
+		recipe.Materials = append(recipe.Materials, material)
+	}
+	return rows.Err()
+}
+
+func getCharacterProfession(db dbExecutor, characterID int, professionID string) (*models.CharacterProfession, error) {
+	cp, err := scanCharacterProfession(db.QueryRow(characterProfessionColumns+`
+		WHERE cp.character_id = ? AND cp.profession_id = ?`, characterID, professionID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrProfessionNotLearned
+	}
+	return cp, err
+}
+
+func scanCharacterProfession(row rowScanner) (*models.CharacterProfession, error) {
+	cp := &models.CharacterProfession{}
+	var learnedAt sql.NullTime
+	if err := row.Scan(&cp.CharacterID, &cp.ProfessionID, &cp.Name, &cp.Type, &cp.SkillLevel, &cp.MaxSkill, &learnedAt); err != nil {
+		return nil, err
+	}
+	if learnedAt.Valid {
+		cp.LearnedAt = learnedAt.Time
+	}
+	return cp, nil
+}
+
+func firstQueueEntry(db rowsQuerier, query string, args ...interface{}) (*models.CraftingQueueEntry, error) {
+	entries, err := getQueueEntries(db, query, args...)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return entries[0], nil
+}
+
+func getQueueEntries(db rowsQuerier, query string, args ...interface{}) ([]*models.CraftingQueueEntry, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.CraftingQueueEntry, 0)
+	for rows.Next() {
+		entry := &models.CraftingQueueEntry{}
+		var nextCraftAt, finishedAt sql.NullTime
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.CharacterID, &entry.RecipeID, &entry.RecipeName,
+			&entry.ProfessionID, &entry.Quantity, &entry.Crafted, &entry.Status, &nextCraftAt,
+			&entry.CreatedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		if nextCraftAt.Valid {
+			entry.NextCraftAt = &nextCraftAt.Time
+		}
+		if finishedAt.Valid {
+			entry.FinishedAt = &finishedAt.Time
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
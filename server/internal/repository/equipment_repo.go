@@ -2,12 +2,21 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 
 	"text-wow/internal/database"
 	"text-wow/internal/models"
 )
 
+// 装备交易校验错误
+var (
+	ErrEquipmentNotOwned = errors.New("equipment does not belong to you")
+	ErrEquipmentEquipped = errors.New("equipment is currently equipped, please unequip first")
+	ErrEquipmentLocked   = errors.New("equipment is locked")
+	ErrEquipmentInEscrow = errors.New("equipment is held in escrow")
+)
+
 // EquipmentRepository 装备数据仓库
 type EquipmentRepository struct{}
 
@@ -18,7 +27,12 @@ func NewEquipmentRepository() *EquipmentRepository {
 
 // Create 创建装备实例
 func (r *EquipmentRepository) Create(equipment *models.EquipmentInstance) (*models.EquipmentInstance, error) {
-	result, err := database.DB.Exec(`
+	return createEquipment(database.DB, equipment)
+}
+
+// createEquipment 创建装备实例（可在事务中调用）
+func createEquipment(db dbExecutor, equipment *models.EquipmentInstance) (*models.EquipmentInstance, error) {
+	result, err := db.Exec(`
 		INSERT INTO equipment_instance (
 			item_id, owner_id, character_id, slot, quality,
 			evolution_stage, evolution_path,
@@ -366,3 +380,46 @@ func (r *EquipmentRepository) Delete(id int) error {
 	return err
 }
 
+// CheckTradableTx 校验装备可以交易（属于该用户、未穿戴、未锁定、未被拍卖行托管），返回基础物品ID
+func (r *EquipmentRepository) CheckTradableTx(tx *sql.Tx, ownerID, equipmentID int) (string, error) {
+	var owner, isLocked int
+	var itemID string
+	var characterID sql.NullInt64
+	err := tx.QueryRow(`
+		SELECT owner_id, item_id, character_id, COALESCE(is_locked, 0)
+		FROM equipment_instance WHERE id = ?`, equipmentID,
+	).Scan(&owner, &itemID, &characterID, &isLocked)
+	if err == sql.ErrNoRows || (err == nil && owner != ownerID) {
+		return "", ErrEquipmentNotOwned
+	}
+	if err != nil {
+		return "", err
+	}
+	if characterID.Valid {
+		return "", ErrEquipmentEquipped
+	}
+	if isLocked != 0 {
+		return "", ErrEquipmentLocked
+	}
+	escrowed, err := isEquipmentEscrowed(tx, equipmentID)
+	if err != nil {
+		return "", err
+	}
+	if escrowed {
+		return "", ErrEquipmentInEscrow
+	}
+	return itemID, nil
+}
+
+// GetNameTx 在事务中获取装备名称（物品表中不存在时返回物品ID）
+func (r *EquipmentRepository) GetNameTx(tx *sql.Tx, equipmentID int) (string, error) {
+	return getEquipmentName(tx, equipmentID)
+}
+
+// TransferTx 在事务中转移装备所有权（放入新主人背包）
+func (r *EquipmentRepository) TransferTx(tx *sql.Tx, equipmentID, ownerID int) error {
+	_, err := tx.Exec(`
+		UPDATE equipment_instance SET owner_id = ?, character_id = NULL WHERE id = ?
+	`, ownerID, equipmentID)
+	return err
+}
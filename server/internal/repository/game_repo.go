@@ -127,7 +127,7 @@ func (r *GameRepository) GetZones() ([]models.Zone, error) {
 	rows, err := database.DB.Query(`
 		SELECT id, name, description, min_level, max_level, COALESCE(faction, ''), 
 		       COALESCE(exp_modifier, 1.0), COALESCE(gold_modifier, 1.0),
-		       unlock_zone_id, COALESCE(required_exploration, 0)
+		       unlock_zone_id, COALESCE(required_exploration, 0), stamina_cost
 		FROM zones ORDER BY min_level`)
 	if err != nil {
 		return nil, err
@@ -138,10 +138,11 @@ func (r *GameRepository) GetZones() ([]models.Zone, error) {
 	for rows.Next() {
 		zone := models.Zone{}
 		var unlockZoneID sql.NullString
+		var staminaCost sql.NullInt64
 		err := rows.Scan(
 			&zone.ID, &zone.Name, &zone.Description, &zone.MinLevel, &zone.MaxLevel,
 			&zone.Faction, &zone.ExpMulti, &zone.GoldMulti,
-			&unlockZoneID, &zone.RequiredExploration,
+			&unlockZoneID, &zone.RequiredExploration, &staminaCost,
 		)
 		if err != nil {
 			return nil, err
@@ -149,6 +150,10 @@ func (r *GameRepository) GetZones() ([]models.Zone, error) {
 		if unlockZoneID.Valid {
 			zone.UnlockZoneID = &unlockZoneID.String
 		}
+		if staminaCost.Valid {
+			cost := int(staminaCost.Int64)
+			zone.StaminaCost = &cost
+		}
 		zones = append(zones, zone)
 	}
 
@@ -159,15 +164,16 @@ func (r *GameRepository) GetZones() ([]models.Zone, error) {
 func (r *GameRepository) GetZoneByID(id string) (*models.Zone, error) {
 	zone := &models.Zone{}
 	var unlockZoneID sql.NullString
+	var staminaCost sql.NullInt64
 	err := database.DB.QueryRow(`
 		SELECT id, name, description, min_level, max_level, COALESCE(faction, ''), 
 		       COALESCE(exp_modifier, 1.0), COALESCE(gold_modifier, 1.0),
-		       unlock_zone_id, COALESCE(required_exploration, 0)
+		       unlock_zone_id, COALESCE(required_exploration, 0), stamina_cost
 		FROM zones WHERE id = ?`, id,
 	).Scan(
 		&zone.ID, &zone.Name, &zone.Description, &zone.MinLevel, &zone.MaxLevel,
 		&zone.Faction, &zone.ExpMulti, &zone.GoldMulti,
-		&unlockZoneID, &zone.RequiredExploration,
+		&unlockZoneID, &zone.RequiredExploration, &staminaCost,
 	)
 	if err != nil {
 		return nil, err
@@ -175,6 +181,10 @@ func (r *GameRepository) GetZoneByID(id string) (*models.Zone, error) {
 	if unlockZoneID.Valid {
 		zone.UnlockZoneID = &unlockZoneID.String
 	}
+	if staminaCost.Valid {
+		cost := int(staminaCost.Int64)
+		zone.StaminaCost = &cost
+	}
 	return zone, nil
 }
 
@@ -273,6 +283,13 @@ func (r *GameRepository) GetMonstersByZone(zoneID string) ([]models.Monster, err
 	return monsters, nil
 }
 
+// GetMonsterFamily 获取怪物族群
+func (r *GameRepository) GetMonsterFamily(monsterID string) (string, error) {
+	var family string
+	err := database.DB.QueryRow(`SELECT COALESCE(family, '') FROM monsters WHERE id = ?`, monsterID).Scan(&family)
+	return family, err
+}
+
 // GetMonsterByID 根据ID获取怪物
 func (r *GameRepository) GetMonsterByID(monsterID string) (*models.Monster, error) {
 	m := &models.Monster{}
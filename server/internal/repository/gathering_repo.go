@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// GatheringRepository 区域采集数据仓库
+type GatheringRepository struct{}
+
+// NewGatheringRepository 创建采集仓库
+func NewGatheringRepository() *GatheringRepository {
+	return &GatheringRepository{}
+}
+
+// GetZoneNodes 获取区域的采集节点（按需求技能排序）
+func (r *GatheringRepository) GetZoneNodes(zoneID string) ([]*models.GatheringNode, error) {
+	rows, err := database.DB.Query(`
+		SELECT n.id, n.zone_id, n.profession_id, n.node_type, n.name, n.item_id, COALESCE(i.name, n.item_id),
+		       n.required_skill, n.skill_up_yellow, n.skill_up_gray, n.chance,
+		       n.min_quantity, n.max_quantity, COALESCE(n.required_exploration, 0)
+		FROM zone_gathering_nodes n
+		LEFT JOIN items i ON i.id = n.item_id
+		WHERE n.zone_id = ?
+		ORDER BY n.profession_id, n.required_skill, n.id
+	`, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nodes := make([]*models.GatheringNode, 0)
+	for rows.Next() {
+		n := &models.GatheringNode{}
+		if err := rows.Scan(&n.ID, &n.ZoneID, &n.ProfessionID, &n.NodeType, &n.Name, &n.ItemID, &n.ItemName,
+			&n.RequiredSkill, &n.SkillUpYellow, &n.SkillUpGray, &n.Chance,
+			&n.MinQuantity, &n.MaxQuantity, &n.RequiredExploration); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
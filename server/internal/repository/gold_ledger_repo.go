@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 金币变动原因代码
+const (
+	GoldReasonStaminaOverflow   = "stamina_overflow"    // 体力溢出转化
+	GoldReasonAuctionListingFee = "auction_listing_fee" // 拍卖上架费
+	GoldReasonAuctionPurchase   = "auction_purchase"    // 拍卖一口价购买
+	GoldReasonAuctionBid        = "auction_bid"         // 拍卖出价托管
+	GoldReasonAuctionBidRefund  = "auction_bid_refund"  // 拍卖出价退还
+	GoldReasonTradeGive         = "trade_give"          // 玩家交易支出
+	GoldReasonTradeReceive      = "trade_receive"       // 玩家交易收入
+	GoldReasonMailSend          = "mail_send"           // 邮寄金币
+	GoldReasonMailClaim         = "mail_claim"          // 领取邮件金币
+	GoldReasonMailReturn        = "mail_return"         // 领取退信金币
+	GoldReasonMailCOD           = "mail_cod"            // 支付货到付款
+	GoldReasonVendorBuy         = "vendor_buy"          // 向商人购买
+	GoldReasonVendorSell        = "vendor_sell"         // 出售给商人
+	GoldReasonVendorBuyback     = "vendor_buyback"      // 从商人处回购
+	GoldReasonGuildCreate       = "guild_create"        // 创建公会
+	GoldReasonGuildDeposit      = "guild_deposit"       // 存入公会银行
+	GoldReasonGuildWithdraw     = "guild_withdraw"      // 从公会银行提取
+	GoldReasonAdminAdjust       = "admin_adjust"        // 管理员调整
+	GoldReasonReconcile         = "reconcile"           // 对账修正（只记流水，不改余额）
+	GoldReasonOpeningBalance    = "opening_balance"     // 流水上线前的期初余额（由 schema.sql 补记）
+	GoldReasonTestRunner        = "test_runner"         // 测试脚本发放
+)
+
+// GoldRef 金币变动关联的业务对象
+type GoldRef struct {
+	Type string
+	ID   string
+}
+
+// NewGoldRef 创建关联对象引用
+func NewGoldRef(refType string, id int) GoldRef {
+	return GoldRef{Type: refType, ID: strconv.Itoa(id)}
+}
+
+// GoldChange 一次金币变动
+type GoldChange struct {
+	UserID        int
+	Delta         int // 正数增加，负数扣除
+	Reason        string
+	Ref           GoldRef
+	CountAsGained bool // 是否计入累计获得金币
+}
+
+// GoldLedgerRepository 金币流水仓库 - 所有金币变动的唯一入口
+type GoldLedgerRepository struct{}
+
+// NewGoldLedgerRepository 创建金币流水仓库
+func NewGoldLedgerRepository() *GoldLedgerRepository {
+	return &GoldLedgerRepository{}
+}
+
+// ApplyTx 在事务中变动金币并写入流水（扣除时余额不足返回 ErrInsufficientGold）
+func (r *GoldLedgerRepository) ApplyTx(tx *sql.Tx, change GoldChange) (*models.GoldLedgerEntry, error) {
+	if change.Delta == 0 {
+		return nil, nil
+	}
+
+	var result sql.Result
+	var err error
+	switch {
+	case change.Delta < 0:
+		result, err = tx.Exec(`
+			UPDATE users SET gold = gold + ? WHERE id = ? AND gold >= ?`,
+			change.Delta, change.UserID, -change.Delta,
+		)
+	case change.CountAsGained:
+		result, err = tx.Exec(`
+			UPDATE users SET gold = gold + ?, total_gold_gained = total_gold_gained + ? WHERE id = ?`,
+			change.Delta, change.Delta, change.UserID,
+		)
+	default:
+		result, err = tx.Exec(`UPDATE users SET gold = gold + ? WHERE id = ?`, change.Delta, change.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		if change.Delta < 0 {
+			return nil, ErrInsufficientGold
+		}
+		return nil, ErrUserNotFound
+	}
+
+	var balance int
+	if err := tx.QueryRow(`SELECT gold FROM users WHERE id = ?`, change.UserID).Scan(&balance); err != nil {
+		return nil, err
+	}
+	return insertLedgerEntry(tx, change.UserID, change.Delta, balance, change.Reason, change.Ref)
+}
+
+// Apply 在独立事务中变动金币并写入流水
+func (r *GoldLedgerRepository) Apply(change GoldChange) (*models.GoldLedgerEntry, error) {
+	return WithTransactionResult(func(tx *sql.Tx) (*models.GoldLedgerEntry, error) {
+		return r.ApplyTx(tx, change)
+	})
+}
+
+// GetEntries 获取用户的金币流水（最新在前，beforeID>0 时从该ID之前开始分页）
+func (r *GoldLedgerRepository) GetEntries(userID int, reason string, beforeID, limit int) ([]*models.GoldLedgerEntry, error) {
+	query := `
+		SELECT id, user_id, delta, balance_after, reason, COALESCE(ref_type, ''), COALESCE(ref_id, ''), created_at
+		FROM gold_ledger WHERE user_id = ?`
+	args := []interface{}{userID}
+	if reason != "" {
+		query += ` AND reason = ?`
+		args = append(args, reason)
+	}
+	if beforeID > 0 {
+		query += ` AND id < ?`
+		args = append(args, beforeID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.GoldLedgerEntry, 0)
+	for rows.Next() {
+		entry := &models.GoldLedgerEntry{}
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Delta, &entry.BalanceAfter, &entry.Reason,
+			&entry.RefType, &entry.RefID, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// FindDiscrepancies 对账：找出账户余额与流水合计不一致的用户
+func (r *GoldLedgerRepository) FindDiscrepancies() ([]*models.GoldDiscrepancy, error) {
+	rows, err := database.DB.Query(`
+		SELECT u.id, u.username, u.gold, COALESCE(SUM(l.delta), 0) AS ledger_balance
+		FROM users u
+		LEFT JOIN gold_ledger l ON l.user_id = u.id
+		GROUP BY u.id
+		HAVING u.gold != ledger_balance
+		ORDER BY u.id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discrepancies := make([]*models.GoldDiscrepancy, 0)
+	for rows.Next() {
+		d := &models.GoldDiscrepancy{}
+		if err := rows.Scan(&d.UserID, &d.Username, &d.Gold, &d.LedgerBalance); err != nil {
+			return nil, err
+		}
+		d.Difference = d.Gold - d.LedgerBalance
+		discrepancies = append(discrepancies, d)
+	}
+	return discrepancies, rows.Err()
+}
+
+// RecordReconciliation 为对账差异补记一条修正流水（不修改账户余额）
+func (r *GoldLedgerRepository) RecordReconciliation(d *models.GoldDiscrepancy) (*models.GoldLedgerEntry, error) {
+	return WithTransactionResult(func(tx *sql.Tx) (*models.GoldLedgerEntry, error) {
+		return insertLedgerEntry(tx, d.UserID, d.Difference, d.Gold, GoldReasonReconcile, GoldRef{})
+	})
+}
+
+func insertLedgerEntry(tx *sql.Tx, userID, delta, balance int, reason string, ref GoldRef) (*models.GoldLedgerEntry, error) {
+	now := time.Now().UTC()
+	result, err := tx.Exec(`
+		INSERT INTO gold_ledger (user_id, delta, balance_after, reason, ref_type, ref_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, userID, delta, balance, reason, nullString(ref.Type), nullString(ref.ID), now)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := result.LastInsertId()
+	return &models.GoldLedgerEntry{
+		ID:           int(id),
+		UserID:       userID,
+		Delta:        delta,
+		BalanceAfter: balance,
+		Reason:       reason,
+		RefType:      ref.Type,
+		RefID:        ref.ID,
+		CreatedAt:    now,
+	}, nil
+}
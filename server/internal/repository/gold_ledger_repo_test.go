@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"text-wow/internal/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupGoldLedgerTest(t *testing.T) (*GoldLedgerRepository, int, func()) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	user, err := NewUserRepository().Create("golduser", "hash", "")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	cleanup := func() {
+		database.TeardownTestDB(testDB)
+	}
+	return NewGoldLedgerRepository(), user.ID, cleanup
+}
+
+// ═══════════════════════════════════════════════════════════
+// 金币变动测试
+// ═══════════════════════════════════════════════════════════
+
+func TestGoldLedgerRepository_ApplyWritesLedger(t *testing.T) {
+	repo, userID, cleanup := setupGoldLedgerTest(t)
+	defer cleanup()
+
+	entry, err := repo.Apply(GoldChange{
+		UserID: userID, Delta: 100, Reason: GoldReasonAdminAdjust, Ref: NewGoldRef("ticket", 7), CountAsGained: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 100, entry.BalanceAfter)
+
+	user, err := NewUserRepository().GetByID(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, user.Gold)
+	assert.Equal(t, 100, user.TotalGoldGained)
+
+	_, err = repo.Apply(GoldChange{UserID: userID, Delta: -150, Reason: GoldReasonMailSend})
+	assert.ErrorIs(t, err, ErrInsufficientGold)
+
+	_, err = repo.Apply(GoldChange{UserID: userID, Delta: -40, Reason: GoldReasonMailSend, Ref: NewGoldRef("mail", 3)})
+	assert.NoError(t, err)
+	_, err = repo.Apply(GoldChange{UserID: userID, Delta: 15, Reason: GoldReasonMailReturn})
+	assert.NoError(t, err)
+
+	user, err = NewUserRepository().GetByID(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 75, user.Gold)
+	assert.Equal(t, 100, user.TotalGoldGained, "退还与扣除不计入累计获得")
+
+	entries, err := repo.GetEntries(userID, "", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3, "失败的扣除不应写入流水")
+	assert.Equal(t, 75, entries[0].BalanceAfter)
+	assert.Equal(t, "mail", entries[1].RefType)
+	assert.Equal(t, "3", entries[1].RefID)
+
+	filtered, err := repo.GetEntries(userID, GoldReasonAdminAdjust, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, filtered, 1)
+
+	page, err := repo.GetEntries(userID, "", entries[1].ID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, entries[2].ID, page[0].ID)
+}
+
+func TestGoldLedgerRepository_AppendOnly(t *testing.T) {
+	repo, userID, cleanup := setupGoldLedgerTest(t)
+	defer cleanup()
+
+	entry, err := repo.Apply(GoldChange{UserID: userID, Delta: 10, Reason: GoldReasonAdminAdjust})
+	assert.NoError(t, err)
+
+	_, err = database.DB.Exec(`UPDATE gold_ledger SET delta = 1000 WHERE id = ?`, entry.ID)
+	assert.Error(t, err, "流水不允许修改")
+	_, err = database.DB.Exec(`DELETE FROM gold_ledger WHERE id = ?`, entry.ID)
+	assert.Error(t, err, "流水不允许删除")
+}
+
+// ═══════════════════════════════════════════════════════════
+// 对账测试
+// ═══════════════════════════════════════════════════════════
+
+func TestGoldLedgerRepository_Reconciliation(t *testing.T) {
+	repo, userID, cleanup := setupGoldLedgerTest(t)
+	defer cleanup()
+
+	_, err := repo.Apply(GoldChange{UserID: userID, Delta: 50, Reason: GoldReasonAdminAdjust})
+	assert.NoError(t, err)
+
+	discrepancies, err := repo.FindDiscrepancies()
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+
+	// 绕过流水直接修改余额
+	_, err = database.DB.Exec(`UPDATE users SET gold = gold + 30 WHERE id = ?`, userID)
+	assert.NoError(t, err)
+
+	discrepancies, err = repo.FindDiscrepancies()
+	assert.NoError(t, err)
+	assert.Len(t, discrepancies, 1)
+	assert.Equal(t, 80, discrepancies[0].Gold)
+	assert.Equal(t, 50, discrepancies[0].LedgerBalance)
+	assert.Equal(t, 30, discrepancies[0].Difference)
+
+	entry, err := repo.RecordReconciliation(discrepancies[0])
+	assert.NoError(t, err)
+	assert.Equal(t, GoldReasonReconcile, entry.Reason)
+
+	discrepancies, err = repo.FindDiscrepancies()
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+
+	user, err := NewUserRepository().GetByID(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 80, user.Gold, "对账修正不改变余额")
+}
+
+func TestGoldLedgerRepository_OpeningBalanceSeed(t *testing.T) {
+	repo, userID, cleanup := setupGoldLedgerTest(t)
+	defer cleanup()
+
+	// 模拟流水上线前已有金币的用户
+	legacy, err := NewUserRepository().Create("legacyuser", "hash", "")
+	assert.NoError(t, err)
+	_, err = database.DB.Exec(`UPDATE users SET gold = 250 WHERE id = ?`, legacy.ID)
+	assert.NoError(t, err)
+	_, err = repo.Apply(GoldChange{UserID: userID, Delta: 40, Reason: GoldReasonAdminAdjust})
+	assert.NoError(t, err)
+
+	schema, err := os.ReadFile(filepath.Join("..", "..", "database", "schema.sql"))
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = database.DB.Exec(string(schema))
+		assert.NoError(t, err, "schema.sql 可重复执行")
+	}
+
+	entries, err := repo.GetEntries(legacy.ID, "", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "期初余额只补记一次")
+	assert.Equal(t, GoldReasonOpeningBalance, entries[0].Reason)
+	assert.Equal(t, 250, entries[0].Delta)
+	assert.Equal(t, 250, entries[0].BalanceAfter)
+
+	entries, err = repo.GetEntries(userID, "", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "已有流水的用户不补记")
+
+	discrepancies, err := repo.FindDiscrepancies()
+	assert.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
@@ -0,0 +1,564 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 公会错误
+var (
+	ErrGuildNotFound             = errors.New("guild not found")
+	ErrGuildBankInsufficientGold = errors.New("not enough gold in guild bank")
+	ErrGuildBankItemNotFound     = errors.New("item not found in guild bank")
+)
+
+// 公会日志动作
+const (
+	GuildLogCreate       = "create"
+	GuildLogInvite       = "invite"
+	GuildLogJoin         = "join"
+	GuildLogLeave        = "leave"
+	GuildLogKick         = "kick"
+	GuildLogRankChange   = "rank_change"
+	GuildLogLeaderChange = "leader_change"
+	GuildLogRankEdit     = "rank_edit"
+	GuildLogMOTD         = "motd"
+	GuildLogDepositGold  = "deposit_gold"
+	GuildLogWithdrawGold = "withdraw_gold"
+	GuildLogDepositItem  = "deposit_item"
+	GuildLogWithdrawItem = "withdraw_item"
+)
+
+// GuildRepository 公会数据仓库
+type GuildRepository struct{}
+
+// NewGuildRepository 创建公会仓库
+func NewGuildRepository() *GuildRepository {
+	return &GuildRepository{}
+}
+
+// ═══════════════════════════════════════════════════════════
+// 公会
+// ═══════════════════════════════════════════════════════════
+
+const guildColumns = `
+	SELECT g.id, g.name, g.faction, g.leader_id, u.username, COALESCE(g.motd, ''), g.motd_updated_at,
+	       g.bank_gold, (SELECT COUNT(*) FROM guild_members m WHERE m.guild_id = g.id), g.created_at
+	FROM guilds g
+	JOIN users u ON u.id = g.leader_id`
+
+// CreateGuildTx 在事务中创建公会及其会阶
+func (r *GuildRepository) CreateGuildTx(tx *sql.Tx, guild *models.Guild, ranks []*models.GuildRank) (*models.Guild, error) {
+	result, err := tx.Exec(`
+		INSERT INTO guilds (name, faction, leader_id, bank_gold, created_at)
+		VALUES (?, ?, ?, 0, ?)`,
+		guild.Name, guild.Faction, guild.LeaderID, guild.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	guild.ID = int(id)
+
+	for _, rank := range ranks {
+		if err := r.SaveRankTx(tx, guild.ID, rank); err != nil {
+			return nil, err
+		}
+	}
+	return guild, nil
+}
+
+// NameExists 公会名是否已被使用（不区分大小写）
+func (r *GuildRepository) NameExists(name string) (bool, error) {
+	var count int
+	err := database.DB.QueryRow(`SELECT COUNT(*) FROM guilds WHERE name = ? COLLATE NOCASE`, name).Scan(&count)
+	return count > 0, err
+}
+
+// GetGuild 获取公会
+func (r *GuildRepository) GetGuild(id int) (*models.Guild, error) {
+	return getGuild(database.DB, id)
+}
+
+// GetGuildTx 在事务中获取公会
+func (r *GuildRepository) GetGuildTx(tx *sql.Tx, id int) (*models.Guild, error) {
+	return getGuild(tx, id)
+}
+
+// DeleteGuildTx 在事务中删除公会（会阶、成员、邀请、银行与日志级联删除）
+func (r *GuildRepository) DeleteGuildTx(tx *sql.Tx, id int) error {
+	_, err := tx.Exec(`DELETE FROM guilds WHERE id = ?`, id)
+	return err
+}
+
+// SetLeaderTx 在事务中更换会长
+func (r *GuildRepository) SetLeaderTx(tx *sql.Tx, guildID, leaderID int) error {
+	_, err := tx.Exec(`UPDATE guilds SET leader_id = ? WHERE id = ?`, leaderID, guildID)
+	return err
+}
+
+// SetMOTDTx 在事务中修改每日公告
+func (r *GuildRepository) SetMOTDTx(tx *sql.Tx, guildID int, motd string, now time.Time) error {
+	_, err := tx.Exec(`
+		UPDATE guilds SET motd = ?, motd_updated_at = ? WHERE id = ?`,
+		nullString(motd), now.UTC(), guildID,
+	)
+	return err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 会阶
+// ═══════════════════════════════════════════════════════════
+
+// GetRanks 获取公会全部会阶（按会阶从高到低）
+func (r *GuildRepository) GetRanks(guildID int) ([]*models.GuildRank, error) {
+	rows, err := database.DB.Query(`
+		SELECT rank_index, name, permissions, gold_withdraw_limit, item_withdraw_limit
+		FROM guild_ranks WHERE guild_id = ?
+		ORDER BY rank_index ASC`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ranks := make([]*models.GuildRank, 0)
+	for rows.Next() {
+		rank, err := scanGuildRank(rows)
+		if err != nil {
+			return nil, err
+		}
+		ranks = append(ranks, rank)
+	}
+	return ranks, rows.Err()
+}
+
+// GetRankTx 在事务中获取单个会阶
+func (r *GuildRepository) GetRankTx(tx *sql.Tx, guildID, rankIndex int) (*models.GuildRank, error) {
+	return scanGuildRank(tx.QueryRow(`
+		SELECT rank_index, name, permissions, gold_withdraw_limit, item_withdraw_limit
+		FROM guild_ranks WHERE guild_id = ? AND rank_index = ?`, guildID, rankIndex))
+}
+
+// GetLowestRankTx 在事务中获取公会最低会阶（新成员加入时使用）
+func (r *GuildRepository) GetLowestRankTx(tx *sql.Tx, guildID int) (int, error) {
+	var rankIndex int
+	err := tx.QueryRow(`SELECT MAX(rank_index) FROM guild_ranks WHERE guild_id = ?`, guildID).Scan(&rankIndex)
+	return rankIndex, err
+}
+
+// SaveRankTx 在事务中保存会阶（不存在时创建）
+func (r *GuildRepository) SaveRankTx(tx *sql.Tx, guildID int, rank *models.GuildRank) error {
+	permissions, err := json.Marshal(rank.Permissions)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO guild_ranks (guild_id, rank_index, name, permissions, gold_withdraw_limit, item_withdraw_limit)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, rank_index) DO UPDATE SET
+			name = excluded.name, permissions = excluded.permissions,
+			gold_withdraw_limit = excluded.gold_withdraw_limit, item_withdraw_limit = excluded.item_withdraw_limit`,
+		guildID, rank.Index, rank.Name, string(permissions), rank.GoldWithdrawLimit, rank.ItemWithdrawLimit,
+	)
+	return err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 成员
+// ═══════════════════════════════════════════════════════════
+
+// AddMemberTx 在事务中添加成员
+func (r *GuildRepository) AddMemberTx(tx *sql.Tx, guildID, userID, rankIndex int, now time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO guild_members (user_id, guild_id, rank_index, joined_at)
+		VALUES (?, ?, ?, ?)`,
+		userID, guildID, rankIndex, now.UTC(),
+	)
+	return err
+}
+
+// GetMembership 获取玩家的公会成员信息（未加入公会时返回 nil）
+func (r *GuildRepository) GetMembership(userID int) (*models.GuildMember, error) {
+	return getGuildMembership(database.DB, userID)
+}
+
+// GetMembershipTx 在事务中获取玩家的公会成员信息（未加入公会时返回 nil）
+func (r *GuildRepository) GetMembershipTx(tx *sql.Tx, userID int) (*models.GuildMember, error) {
+	return getGuildMembership(tx, userID)
+}
+
+// GetMembers 获取公会成员（按会阶、加入时间排序）
+func (r *GuildRepository) GetMembers(guildID int) ([]*models.GuildMember, error) {
+	rows, err := database.DB.Query(`
+		SELECT m.user_id, m.guild_id, u.username, m.rank_index, COALESCE(gr.name, ''), m.joined_at
+		FROM guild_members m
+		JOIN users u ON u.id = m.user_id
+		LEFT JOIN guild_ranks gr ON gr.guild_id = m.guild_id AND gr.rank_index = m.rank_index
+		WHERE m.guild_id = ?
+		ORDER BY m.rank_index ASC, m.joined_at ASC`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]*models.GuildMember, 0)
+	for rows.Next() {
+		member := &models.GuildMember{}
+		if err := rows.Scan(&member.UserID, &member.GuildID, &member.Name, &member.RankIndex,
+			&member.RankName, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// GetMemberIDs 获取公会全部成员ID（用于公会频道推送）
+func (r *GuildRepository) GetMemberIDs(guildID int) ([]int, error) {
+	return getGuildMemberIDs(database.DB, guildID)
+}
+
+// GetMemberIDsTx 在事务中获取公会全部成员ID
+func (r *GuildRepository) GetMemberIDsTx(tx *sql.Tx, guildID int) ([]int, error) {
+	return getGuildMemberIDs(tx, guildID)
+}
+
+// SetMemberRankTx 在事务中调整成员会阶
+func (r *GuildRepository) SetMemberRankTx(tx *sql.Tx, userID, rankIndex int) error {
+	_, err := tx.Exec(`UPDATE guild_members SET rank_index = ? WHERE user_id = ?`, rankIndex, userID)
+	return err
+}
+
+// RemoveMemberTx 在事务中移除成员
+func (r *GuildRepository) RemoveMemberTx(tx *sql.Tx, userID int) error {
+	_, err := tx.Exec(`DELETE FROM guild_members WHERE user_id = ?`, userID)
+	return err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 邀请
+// ═══════════════════════════════════════════════════════════
+
+// SaveInviteTx 在事务中保存邀请（重复邀请会刷新有效期）
+func (r *GuildRepository) SaveInviteTx(tx *sql.Tx, invite *models.GuildInvite) error {
+	_, err := tx.Exec(`
+		INSERT INTO guild_invites (guild_id, user_id, invited_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, user_id) DO UPDATE SET
+			invited_by = excluded.invited_by, created_at = excluded.created_at, expires_at = excluded.expires_at`,
+		invite.GuildID, invite.UserID, invite.InvitedBy, invite.CreatedAt.UTC(), invite.ExpiresAt.UTC(),
+	)
+	return err
+}
+
+// HasInviteTx 在事务中检查玩家是否有该公会未过期的邀请
+func (r *GuildRepository) HasInviteTx(tx *sql.Tx, guildID, userID int, now time.Time) (bool, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM guild_invites
+		WHERE guild_id = ? AND user_id = ? AND expires_at > ?`,
+		guildID, userID, now.UTC(),
+	).Scan(&count)
+	return count > 0, err
+}
+
+// GetInvites 获取玩家收到的未过期邀请
+func (r *GuildRepository) GetInvites(userID int, now time.Time) ([]*models.GuildInvite, error) {
+	rows, err := database.DB.Query(`
+		SELECT gi.guild_id, g.name, gi.user_id, gi.invited_by, u.username, gi.created_at, gi.expires_at
+		FROM guild_invites gi
+		JOIN guilds g ON g.id = gi.guild_id
+		JOIN users u ON u.id = gi.invited_by
+		WHERE gi.user_id = ? AND gi.expires_at > ?
+		ORDER BY gi.created_at DESC`, userID, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := make([]*models.GuildInvite, 0)
+	for rows.Next() {
+		invite := &models.GuildInvite{}
+		if err := rows.Scan(&invite.GuildID, &invite.GuildName, &invite.UserID, &invite.InvitedBy,
+			&invite.InviterName, &invite.CreatedAt, &invite.ExpiresAt); err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+// DeleteInvite 删除邀请，邀请不存在时返回 false
+func (r *GuildRepository) DeleteInvite(guildID, userID int) (bool, error) {
+	result, err := database.DB.Exec(`DELETE FROM guild_invites WHERE guild_id = ? AND user_id = ?`, guildID, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// DeleteUserInvitesTx 在事务中删除玩家收到的全部邀请（加入公会后）
+func (r *GuildRepository) DeleteUserInvitesTx(tx *sql.Tx, userID int) error {
+	_, err := tx.Exec(`DELETE FROM guild_invites WHERE user_id = ?`, userID)
+	return err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 公会银行
+// ═══════════════════════════════════════════════════════════
+
+// AddBankGoldTx 在事务中存入金币
+func (r *GuildRepository) AddBankGoldTx(tx *sql.Tx, guildID, amount int) error {
+	_, err := tx.Exec(`UPDATE guilds SET bank_gold = bank_gold + ? WHERE id = ?`, amount, guildID)
+	return err
+}
+
+// TakeBankGoldTx 在事务中取出金币，余额不足时返回 ErrGuildBankInsufficientGold
+func (r *GuildRepository) TakeBankGoldTx(tx *sql.Tx, guildID, amount int) error {
+	result, err := tx.Exec(`
+		UPDATE guilds SET bank_gold = bank_gold - ? WHERE id = ? AND bank_gold >= ?`,
+		amount, guildID, amount,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrGuildBankInsufficientGold
+	}
+	return nil
+}
+
+// StoreItemTx 在事务中将装备存入公会银行（存放期间装备处于托管状态），返回装备名称
+func (r *GuildRepository) StoreItemTx(tx *sql.Tx, guildID, equipmentID, userID int, now time.Time) (string, error) {
+	_, err := tx.Exec(`
+		INSERT INTO guild_bank_items (guild_id, equipment_id, deposited_by, deposited_at)
+		VALUES (?, ?, ?, ?)`,
+		guildID, equipmentID, userID, now.UTC(),
+	)
+	if err != nil {
+		return "", err
+	}
+	return getEquipmentName(tx, equipmentID)
+}
+
+// TakeItemTx 在事务中从公会银行取出装备，返回装备名称
+func (r *GuildRepository) TakeItemTx(tx *sql.Tx, guildID, equipmentID int) (string, error) {
+	result, err := tx.Exec(`DELETE FROM guild_bank_items WHERE guild_id = ? AND equipment_id = ?`, guildID, equipmentID)
+	if err != nil {
+		return "", err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return "", ErrGuildBankItemNotFound
+	}
+	return getEquipmentName(tx, equipmentID)
+}
+
+// GetBankItems 获取公会银行中的装备（最新存入的在前）
+func (r *GuildRepository) GetBankItems(guildID int) ([]*models.GuildBankItem, error) {
+	rows, err := database.DB.Query(`
+		SELECT gb.id, gb.equipment_id, e.item_id, COALESCE(i.name, e.item_id), e.slot, e.quality,
+		       gb.deposited_by, COALESCE(u.username, ''), gb.deposited_at
+		FROM guild_bank_items gb
+		JOIN equipment_instance e ON e.id = gb.equipment_id
+		LEFT JOIN items i ON i.id = e.item_id
+		LEFT JOIN users u ON u.id = gb.deposited_by
+		WHERE gb.guild_id = ?
+		ORDER BY gb.deposited_at DESC, gb.id DESC`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*models.GuildBankItem, 0)
+	for rows.Next() {
+		item := &models.GuildBankItem{}
+		if err := rows.Scan(&item.ID, &item.EquipmentID, &item.ItemID, &item.ItemName, &item.Slot, &item.Quality,
+			&item.DepositedBy, &item.DepositorName, &item.DepositedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetBankItemIDsTx 在事务中获取公会银行全部装备ID（解散公会时使用）
+func (r *GuildRepository) GetBankItemIDsTx(tx *sql.Tx, guildID int) ([]int, error) {
+	rows, err := tx.Query(`SELECT equipment_id FROM guild_bank_items WHERE guild_id = ?`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// isEquipmentInGuildBank 装备是否存放在公会银行
+func isEquipmentInGuildBank(db dbExecutor, equipmentID int) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM guild_bank_items WHERE equipment_id = ?`, equipmentID).Scan(&count)
+	return count > 0, err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 活动日志
+// ═══════════════════════════════════════════════════════════
+
+// AddLogTx 在事务中记录公会活动
+func (r *GuildRepository) AddLogTx(tx *sql.Tx, entry *models.GuildLogEntry) error {
+	_, err := tx.Exec(`
+		INSERT INTO guild_logs (guild_id, user_id, action, target_name, amount, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.GuildID, entry.UserID, entry.Action, nullString(entry.TargetName), entry.Amount, entry.CreatedAt.UTC(),
+	)
+	return err
+}
+
+// GetLogs 获取公会活动日志（最新在前，beforeID 为 0 时从最新开始）
+func (r *GuildRepository) GetLogs(guildID, beforeID, limit int) ([]*models.GuildLogEntry, error) {
+	query := `
+		SELECT l.id, l.guild_id, l.user_id, COALESCE(u.username, ''), l.action,
+		       COALESCE(l.target_name, ''), l.amount, l.created_at
+		FROM guild_logs l
+		LEFT JOIN users u ON u.id = l.user_id
+		WHERE l.guild_id = ?`
+	args := []interface{}{guildID}
+	if beforeID > 0 {
+		query += " AND l.id < ?"
+		args = append(args, beforeID)
+	}
+	query += " ORDER BY l.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.GuildLogEntry, 0)
+	for rows.Next() {
+		entry := &models.GuildLogEntry{}
+		if err := rows.Scan(&entry.ID, &entry.GuildID, &entry.UserID, &entry.PlayerName, &entry.Action,
+			&entry.TargetName, &entry.Amount, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// SumWithdrawals 统计成员自 since 起的提取总量（金币数或装备件数）
+func (r *GuildRepository) SumWithdrawals(guildID, userID int, action string, since time.Time) (int, error) {
+	return sumGuildWithdrawals(database.DB, guildID, userID, action, since)
+}
+
+// SumWithdrawalsTx 在事务中统计成员自 since 起的提取总量
+func (r *GuildRepository) SumWithdrawalsTx(tx *sql.Tx, guildID, userID int, action string, since time.Time) (int, error) {
+	return sumGuildWithdrawals(tx, guildID, userID, action, since)
+}
+
+func sumGuildWithdrawals(db dbExecutor, guildID, userID int, action string, since time.Time) (int, error) {
+	var total int
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(amount), 0) FROM guild_logs
+		WHERE guild_id = ? AND user_id = ? AND action = ? AND created_at > ?`,
+		guildID, userID, action, since.UTC(),
+	).Scan(&total)
+	return total, err
+}
+
+func getGuild(db dbExecutor, id int) (*models.Guild, error) {
+	guild := &models.Guild{}
+	var motdUpdatedAt sql.NullTime
+	err := db.QueryRow(guildColumns+` WHERE g.id = ?`, id).Scan(
+		&guild.ID, &guild.Name, &guild.Faction, &guild.LeaderID, &guild.LeaderName, &guild.MOTD, &motdUpdatedAt,
+		&guild.BankGold, &guild.MemberCount, &guild.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrGuildNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if motdUpdatedAt.Valid {
+		guild.MOTDUpdatedAt = &motdUpdatedAt.Time
+	}
+	return guild, nil
+}
+
+func getGuildMemberIDs(db rowsQuerier, guildID int) ([]int, error) {
+	rows, err := db.Query(`SELECT user_id FROM guild_members WHERE guild_id = ?`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func getEquipmentName(db dbExecutor, equipmentID int) (string, error) {
+	var name string
+	err := db.QueryRow(`
+		SELECT COALESCE(i.name, e.item_id)
+		FROM equipment_instance e
+		LEFT JOIN items i ON i.id = e.item_id
+		WHERE e.id = ?`, equipmentID,
+	).Scan(&name)
+	return name, err
+}
+
+func getGuildMembership(db dbExecutor, userID int) (*models.GuildMember, error) {
+	member := &models.GuildMember{}
+	err := db.QueryRow(`
+		SELECT m.user_id, m.guild_id, u.username, m.rank_index, COALESCE(gr.name, ''), m.joined_at
+		FROM guild_members m
+		JOIN users u ON u.id = m.user_id
+		LEFT JOIN guild_ranks gr ON gr.guild_id = m.guild_id AND gr.rank_index = m.rank_index
+		WHERE m.user_id = ?`, userID,
+	).Scan(&member.UserID, &member.GuildID, &member.Name, &member.RankIndex, &member.RankName, &member.JoinedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func scanGuildRank(row rowScanner) (*models.GuildRank, error) {
+	rank := &models.GuildRank{}
+	var permissions string
+	if err := row.Scan(&rank.Index, &rank.Name, &permissions, &rank.GoldWithdrawLimit, &rank.ItemWithdrawLimit); err != nil {
+		return nil, err
+	}
+	rank.Permissions = make([]string, 0)
+	if err := json.Unmarshal([]byte(permissions), &rank.Permissions); err != nil {
+		return nil, err
+	}
+	return rank, nil
+}
@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 荣誉商店购买错误
+var (
+	ErrHonorShopItemNotFound = errors.New("honor shop item not found")
+	ErrHonorFactionMismatch  = errors.New("item is not available for your faction")
+	ErrHonorRankTooLow       = errors.New("honor rank too low")
+	ErrInsufficientHonor     = errors.New("insufficient honor")
+	ErrHonorWeeklyLimit      = errors.New("weekly purchase limit reached")
+	ErrHonorOutOfStock       = errors.New("item is out of stock")
+	ErrHonorNoCharacter      = errors.New("a character is required to receive consumables")
+)
+
+// HonorRepository 荣誉军衔与荣誉商店数据仓库
+type HonorRepository struct{}
+
+// NewHonorRepository 创建荣誉仓库
+func NewHonorRepository() *HonorRepository {
+	return &HonorRepository{}
+}
+
+// HonorResetRecord 每周结算所需的荣誉数据
+type HonorResetRecord struct {
+	UserID        int
+	TotalHonor    int
+	WeeklyResetAt *time.Time
+}
+
+// ═══════════════════════════════════════════════════════════
+// 军衔
+// ═══════════════════════════════════════════════════════════
+
+// GetStandings 获取军衔排名（按累计荣誉排序，faction为空时返回双阵营）
+func (r *HonorRepository) GetStandings(faction string, limit int) ([]*models.HonorStanding, error) {
+	query := `
+		SELECT h.user_id, u.username, h.faction, h.honor_rank, h.total_honor, h.weekly_honor
+		FROM user_honor h
+		JOIN users u ON u.id = h.user_id`
+	args := []interface{}{}
+	if faction != "" {
+		query += " WHERE h.faction = ?"
+		args = append(args, faction)
+	}
+	query += " ORDER BY h.total_honor DESC, h.weekly_honor DESC, h.user_id LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var standings []*models.HonorStanding
+	for rows.Next() {
+		s := &models.HonorStanding{Position: len(standings) + 1}
+		if err := rows.Scan(&s.UserID, &s.Username, &s.Faction, &s.HonorRank, &s.TotalHonor, &s.WeeklyHonor); err != nil {
+			return nil, err
+		}
+		standings = append(standings, s)
+	}
+	return standings, rows.Err()
+}
+
+// GetStandingPosition 获取玩家在本阵营中的排名（无荣誉记录时返回0）
+func (r *HonorRepository) GetStandingPosition(userID int) (int, error) {
+	var position int
+	err := database.DB.QueryRow(`
+		SELECT (
+			SELECT COUNT(*) FROM user_honor other
+			WHERE other.faction = me.faction
+			  AND (other.total_honor > me.total_honor
+			       OR (other.total_honor = me.total_honor AND other.weekly_honor > me.weekly_honor)
+			       OR (other.total_honor = me.total_honor AND other.weekly_honor = me.weekly_honor AND other.user_id < me.user_id))
+		) + 1
+		FROM user_honor me WHERE me.user_id = ?
+	`, userID).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return position, err
+}
+
+// GetResetRecords 获取所有玩家的荣誉结算数据
+func (r *HonorRepository) GetResetRecords() ([]*HonorResetRecord, error) {
+	rows, err := database.DB.Query(`SELECT user_id, total_honor, weekly_reset_at FROM user_honor`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*HonorResetRecord
+	for rows.Next() {
+		record := &HonorResetRecord{}
+		var resetAt sql.NullTime
+		if err := rows.Scan(&record.UserID, &record.TotalHonor, &resetAt); err != nil {
+			return nil, err
+		}
+		if resetAt.Valid {
+			record.WeeklyResetAt = &resetAt.Time
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// ApplyWeeklyReset 写入每周结算结果：衰减后的累计荣誉、新军衔，并清零本周荣誉
+func (r *HonorRepository) ApplyWeeklyReset(userID, totalHonor, rank int, resetAt time.Time) error {
+	_, err := database.DB.Exec(`
+		UPDATE user_honor
+		SET total_honor = ?, honor_rank = ?, weekly_honor = 0, weekly_reset_at = ?
+		WHERE user_id = ?
+	`, totalHonor, rank, resetAt, userID)
+	return err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 荣誉商店
+// ═══════════════════════════════════════════════════════════
+
+const honorShopSelect = `
+	SELECT s.id, s.name, COALESCE(s.description, ''), s.item_type, COALESCE(s.item_id, ''),
+	       COALESCE(i.quality, ''), s.honor_cost, COALESCE(s.rank_required, 0), COALESCE(s.faction, ''),
+	       s.weekly_limit, s.stock
+	FROM honor_shop s
+	LEFT JOIN items i ON i.id = s.item_id`
+
+// GetShopItems 获取在售商品（faction不为空时过滤掉其他阵营专属商品）
+func (r *HonorRepository) GetShopItems(faction string) ([]*models.HonorShopItem, error) {
+	query := honorShopSelect + " WHERE s.is_active = 1"
+	args := []interface{}{}
+	if faction != "" {
+		query += " AND (s.faction IS NULL OR s.faction = ?)"
+		args = append(args, faction)
+	}
+	query += " ORDER BY s.rank_required, s.honor_cost, s.id"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.HonorShopItem
+	for rows.Next() {
+		item, err := scanHonorShopItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetWeeklyPurchaseCounts 获取玩家本周各商品的购买次数
+func (r *HonorRepository) GetWeeklyPurchaseCounts(userID int, weekStart time.Time) (map[string]int, error) {
+	rows, err := database.DB.Query(`
+		SELECT shop_item_id, COUNT(*)
+		FROM honor_shop_purchases
+		WHERE user_id = ? AND purchased_at >= ?
+		GROUP BY shop_item_id
+	`, userID, weekStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+	return counts, rows.Err()
+}
+
+// Purchase 购买荣誉商店商品
+// 在同一事务中校验阵营/军衔/荣誉/限购/库存，扣除荣誉和库存，发放物品并记录购买
+func (r *HonorRepository) Purchase(userID int, shopItemID string, characterID int, weekStart time.Time) (*models.HonorPurchase, error) {
+	return WithTransactionResult(func(tx *sql.Tx) (*models.HonorPurchase, error) {
+		item, err := scanHonorShopItem(tx.QueryRow(honorShopSelect+" WHERE s.id = ? AND s.is_active = 1", shopItemID))
+		if err == sql.ErrNoRows {
+			return nil, ErrHonorShopItemNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var faction string
+		var currentHonor, rank int
+		err = tx.QueryRow(`SELECT faction, current_honor, honor_rank FROM user_honor WHERE user_id = ?`, userID).
+			Scan(&faction, &currentHonor, &rank)
+		if err == sql.ErrNoRows {
+			return nil, ErrInsufficientHonor
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if item.Faction != "" && item.Faction != faction {
+			return nil, ErrHonorFactionMismatch
+		}
+		if rank < item.RankRequired {
+			return nil, ErrHonorRankTooLow
+		}
+		if currentHonor < item.HonorCost {
+			return nil, ErrInsufficientHonor
+		}
+
+		if item.WeeklyLimit != nil {
+			var bought int
+			err = tx.QueryRow(`
+				SELECT COUNT(*) FROM honor_shop_purchases
+				WHERE user_id = ? AND shop_item_id = ? AND purchased_at >= ?
+			`, userID, shopItemID, weekStart).Scan(&bought)
+			if err != nil {
+				return nil, err
+			}
+			if bought >= *item.WeeklyLimit {
+				return nil, ErrHonorWeeklyLimit
+			}
+		}
+
+		if item.Stock != nil {
+			result, err := tx.Exec(`UPDATE honor_shop SET stock = stock - 1 WHERE id = ? AND stock > 0`, shopItemID)
+			if err != nil {
+				return nil, err
+			}
+			if affected, _ := result.RowsAffected(); affected == 0 {
+				return nil, ErrHonorOutOfStock
+			}
+		}
+
+		result, err := tx.Exec(`
+			UPDATE user_honor SET current_honor = current_honor - ?
+			WHERE user_id = ? AND current_honor >= ?
+		`, item.HonorCost, userID, item.HonorCost)
+		if err != nil {
+			return nil, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return nil, ErrInsufficientHonor
+		}
+
+		purchase := &models.HonorPurchase{
+			UserID:       userID,
+			ShopItemID:   item.ID,
+			ShopItemName: item.Name,
+			ItemType:     item.ItemType,
+			ItemID:       item.ItemID,
+			HonorCost:    item.HonorCost,
+			PurchasedAt:  time.Now().UTC(),
+		}
+
+		// 发放物品
+		switch item.ItemType {
+		case "equipment":
+			var slot string
+			if err := tx.QueryRow(`SELECT COALESCE(slot, '') FROM items WHERE id = ?`, item.ItemID).Scan(&slot); err != nil {
+				return nil, err
+			}
+			quality := item.ItemQuality
+			if quality == "" {
+				quality = "common"
+			}
+			equipment, err := createEquipment(tx, &models.EquipmentInstance{
+				ItemID:         item.ItemID,
+				OwnerID:        userID,
+				Slot:           slot,
+				Quality:        quality,
+				EvolutionStage: 1,
+			})
+			if err != nil {
+				return nil, err
+			}
+			purchase.EquipmentID = &equipment.ID
+			// 装备通过邮件发放，领取前处于托管状态
+			if _, err := sendMailTx(tx, &models.Mail{
+				RecipientID: userID,
+				SenderName:  "荣誉军需官",
+				Type:        "system",
+				Subject:     fmt.Sprintf("荣誉商店：%s", item.Name),
+				Body:        "感谢你为阵营作出的贡献。",
+				CreatedAt:   purchase.PurchasedAt,
+			}, []int{equipment.ID}); err != nil {
+				return nil, err
+			}
+		case "consumable":
+			var owner int
+			err := tx.QueryRow(`SELECT user_id FROM characters WHERE id = ?`, characterID).Scan(&owner)
+			if err == sql.ErrNoRows || (err == nil && owner != userID) {
+				return nil, ErrHonorNoCharacter
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err := addInventoryItem(tx, characterID, item.ItemID, 1); err != nil {
+				return nil, err
+			}
+			purchase.CharacterID = &characterID
+		}
+
+		res, err := tx.Exec(`
+			INSERT INTO honor_shop_purchases (user_id, shop_item_id, character_id, honor_cost, equipment_instance_id, purchased_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, userID, item.ID, purchase.CharacterID, item.HonorCost, purchase.EquipmentID, purchase.PurchasedAt)
+		if err != nil {
+			return nil, err
+		}
+		id, _ := res.LastInsertId()
+		purchase.ID = int(id)
+
+		return purchase, nil
+	})
+}
+
+// GetPurchases 获取玩家的购买历史
+func (r *HonorRepository) GetPurchases(userID int, limit int) ([]*models.HonorPurchase, error) {
+	rows, err := database.DB.Query(`
+		SELECT p.id, p.user_id, p.shop_item_id, s.name, s.item_type, COALESCE(s.item_id, ''),
+		       p.character_id, p.honor_cost, p.equipment_instance_id, p.purchased_at
+		FROM honor_shop_purchases p
+		JOIN honor_shop s ON s.id = p.shop_item_id
+		WHERE p.user_id = ?
+		ORDER BY p.purchased_at DESC, p.id DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purchases []*models.HonorPurchase
+	for rows.Next() {
+		p := &models.HonorPurchase{}
+		var characterID, equipmentID sql.NullInt64
+		err := rows.Scan(&p.ID, &p.UserID, &p.ShopItemID, &p.ShopItemName, &p.ItemType, &p.ItemID,
+			&characterID, &p.HonorCost, &equipmentID, &p.PurchasedAt)
+		if err != nil {
+			return nil, err
+		}
+		if characterID.Valid {
+			id := int(characterID.Int64)
+			p.CharacterID = &id
+		}
+		if equipmentID.Valid {
+			id := int(equipmentID.Int64)
+			p.EquipmentID = &id
+		}
+		purchases = append(purchases, p)
+	}
+	return purchases, rows.Err()
+}
+
+func scanHonorShopItem(row rowScanner) (*models.HonorShopItem, error) {
+	item := &models.HonorShopItem{}
+	var weeklyLimit, stock sql.NullInt64
+	err := row.Scan(&item.ID, &item.Name, &item.Description, &item.ItemType, &item.ItemID,
+		&item.ItemQuality, &item.HonorCost, &item.RankRequired, &item.Faction,
+		&weeklyLimit, &stock)
+	if err != nil {
+		return nil, err
+	}
+	if weeklyLimit.Valid {
+		limit := int(weeklyLimit.Int64)
+		item.WeeklyLimit = &limit
+	}
+	if stock.Valid {
+		s := int(stock.Int64)
+		item.Stock = &s
+	}
+	return item, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ═══════════════════════════════════════════════════════════
+// 测试辅助函数
+// ═══════════════════════════════════════════════════════════
+
+func setupHonorRepoTest(t *testing.T, currentHonor, rank int) (*HonorRepository, int, int, func()) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = testDB.Exec(`
+		INSERT INTO items (id, name, type, quality, slot, stackable, max_stack) VALUES
+			('pvp_sword', 'PVP长剑', 'equipment', 'rare', 'main_hand', 0, 1),
+			('pvp_potion', 'PVP药水', 'consumable', 'common', NULL, 1, 20);
+		INSERT INTO honor_shop (id, name, item_type, item_id, honor_cost, rank_required, faction, weekly_limit, stock) VALUES
+			('shop_sword', 'PVP长剑', 'equipment', 'pvp_sword', 100, 2, 'alliance', NULL, 1),
+			('shop_potion', 'PVP药水', 'consumable', 'pvp_potion', 10, 0, NULL, 2, NULL),
+			('shop_horde', '部落专属', 'equipment', 'pvp_sword', 10, 0, 'horde', NULL, NULL);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert honor shop data: %v", err)
+	}
+
+	user, err := NewUserRepository().Create("honoruser", "hash", "")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	char, err := NewCharacterRepository().Create(&models.Character{
+		UserID: user.ID, Name: "honorchar", RaceID: "human", ClassID: "warrior", Faction: "alliance",
+		TeamSlot: 1, IsActive: true, Level: 20, HP: 100, MaxHP: 100,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	_, err = testDB.Exec(`
+		INSERT INTO user_honor (user_id, faction, total_honor, current_honor, honor_rank)
+		VALUES (?, 'alliance', ?, ?, ?)
+	`, user.ID, currentHonor, currentHonor, rank)
+	if err != nil {
+		t.Fatalf("Failed to insert honor: %v", err)
+	}
+
+	cleanup := func() {
+		database.TeardownTestDB(testDB)
+	}
+	return NewHonorRepository(), user.ID, char.ID, cleanup
+}
+
+func weekStartForTest() time.Time {
+	return time.Now().UTC().Add(-time.Hour)
+}
+
+// ═══════════════════════════════════════════════════════════
+// 购买测试
+// ═══════════════════════════════════════════════════════════
+
+func TestHonorRepository_Purchase_Equipment(t *testing.T) {
+	repo, userID, charID, cleanup := setupHonorRepoTest(t, 250, 2)
+	defer cleanup()
+
+	purchase, err := repo.Purchase(userID, "shop_sword", charID, weekStartForTest())
+	assert.NoError(t, err)
+	assert.NotNil(t, purchase.EquipmentID, "购买装备应生成装备实例")
+
+	equipment, err := NewEquipmentRepository().GetByID(*purchase.EquipmentID)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, equipment.OwnerID)
+	assert.Equal(t, "rare", equipment.Quality)
+	assert.Equal(t, "main_hand", equipment.Slot)
+
+	var currentHonor, stock int
+	database.DB.QueryRow(`SELECT current_honor FROM user_honor WHERE user_id = ?`, userID).Scan(&currentHonor)
+	database.DB.QueryRow(`SELECT stock FROM honor_shop WHERE id = 'shop_sword'`).Scan(&stock)
+	assert.Equal(t, 150, currentHonor)
+	assert.Equal(t, 0, stock)
+
+	_, err = repo.Purchase(userID, "shop_sword", charID, weekStartForTest())
+	assert.ErrorIs(t, err, ErrHonorOutOfStock)
+}
+
+func TestHonorRepository_Purchase_ConsumableWeeklyLimit(t *testing.T) {
+	repo, userID, charID, cleanup := setupHonorRepoTest(t, 100, 0)
+	defer cleanup()
+
+	for i := 0; i < 2; i++ {
+		_, err := repo.Purchase(userID, "shop_potion", charID, weekStartForTest())
+		assert.NoError(t, err)
+	}
+	_, err := repo.Purchase(userID, "shop_potion", charID, weekStartForTest())
+	assert.ErrorIs(t, err, ErrHonorWeeklyLimit)
+
+	var quantity int
+	database.DB.QueryRow(`SELECT quantity FROM inventory WHERE character_id = ? AND item_id = 'pvp_potion'`, charID).Scan(&quantity)
+	assert.Equal(t, 2, quantity, "消耗品应发放到角色背包")
+
+	purchases, err := repo.GetPurchases(userID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, purchases, 2)
+	assert.Equal(t, "PVP药水", purchases[0].ShopItemName)
+
+	counts, err := repo.GetWeeklyPurchaseCounts(userID, weekStartForTest())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, counts["shop_potion"])
+}
+
+func TestHonorRepository_Purchase_Restrictions(t *testing.T) {
+	repo, userID, charID, cleanup := setupHonorRepoTest(t, 50, 1)
+	defer cleanup()
+
+	_, err := repo.Purchase(userID, "shop_sword", charID, weekStartForTest())
+	assert.ErrorIs(t, err, ErrHonorRankTooLow)
+
+	_, err = repo.Purchase(userID, "shop_horde", charID, weekStartForTest())
+	assert.ErrorIs(t, err, ErrHonorFactionMismatch)
+
+	_, err = repo.Purchase(userID, "missing", charID, weekStartForTest())
+	assert.ErrorIs(t, err, ErrHonorShopItemNotFound)
+
+	// 失败的购买不应扣除荣誉
+	var currentHonor int
+	database.DB.QueryRow(`SELECT current_honor FROM user_honor WHERE user_id = ?`, userID).Scan(&currentHonor)
+	assert.Equal(t, 50, currentHonor)
+}
@@ -19,60 +19,65 @@ func NewInventoryRepository() *InventoryRepository {
 // 使用事务确保操作的原子性
 func (r *InventoryRepository) AddItem(characterID int, itemID string, quantity int) error {
 	return WithTransaction(func(tx *sql.Tx) error {
-		// 检查物品是否可堆叠
-		var stackable, maxStack int
-		err := tx.QueryRow(`
-			SELECT COALESCE(stackable, 0), COALESCE(max_stack, 1)
-			FROM items WHERE id = ?`, itemID,
-		).Scan(&stackable, &maxStack)
-		if err != nil {
-			// 如果物品不存在，仍然尝试添加（可能是装备类型）
-			stackable = 0
-			maxStack = 1
-		}
+		return addInventoryItem(tx, characterID, itemID, quantity)
+	})
+}
 
-		// 如果可堆叠，尝试更新现有记录
-		if stackable > 0 {
-			var existingID, existingQuantity int
-			err := tx.QueryRow(`
-				SELECT id, quantity FROM inventory
-				WHERE character_id = ? AND item_id = ?
-				LIMIT 1`, characterID, itemID,
-			).Scan(&existingID, &existingQuantity)
-			
-			if err == nil {
-				// 物品已存在，更新数量
-				newQuantity := existingQuantity + quantity
-				if newQuantity > maxStack {
-					newQuantity = maxStack
-				}
-				_, err = tx.Exec(`
-					UPDATE inventory SET quantity = ?
-					WHERE id = ?`, newQuantity, existingID,
-				)
-				return err
-			}
-			// 如果查询失败（物品不存在），继续创建新记录
-		}
+// addInventoryItem 在事务中添加物品到背包
+func addInventoryItem(tx *sql.Tx, characterID int, itemID string, quantity int) error {
+	// 检查物品是否可堆叠
+	var stackable, maxStack int
+	err := tx.QueryRow(`
+		SELECT COALESCE(stackable, 0), COALESCE(max_stack, 1)
+		FROM items WHERE id = ?`, itemID,
+	).Scan(&stackable, &maxStack)
+	if err != nil {
+		// 如果物品不存在，仍然尝试添加（可能是装备类型）
+		stackable = 0
+		maxStack = 1
+	}
 
-		// 创建新记录
-		// 找到下一个可用的槽位
-		var nextSlot sql.NullInt64
-		err = tx.QueryRow(`
-			SELECT MAX(slot) FROM inventory WHERE character_id = ?`, characterID,
-		).Scan(&nextSlot)
+	// 如果可堆叠，尝试更新现有记录
+	if stackable > 0 {
+		var existingID, existingQuantity int
+		err := tx.QueryRow(`
+			SELECT id, quantity FROM inventory
+			WHERE character_id = ? AND item_id = ?
+			LIMIT 1`, characterID, itemID,
+		).Scan(&existingID, &existingQuantity)
 		
-		slot := 1
-		if err == nil && nextSlot.Valid {
-			slot = int(nextSlot.Int64) + 1
+		if err == nil {
+			// 物品已存在，更新数量
+			newQuantity := existingQuantity + quantity
+			if newQuantity > maxStack {
+				newQuantity = maxStack
+			}
+			_, err = tx.Exec(`
+				UPDATE inventory SET quantity = ?
+				WHERE id = ?`, newQuantity, existingID,
+			)
+			return err
 		}
+		// 如果查询失败（物品不存在），继续创建新记录
+	}
 
-		_, err = tx.Exec(`
-			INSERT INTO inventory (character_id, item_id, quantity, slot)
-			VALUES (?, ?, ?, ?)`, characterID, itemID, quantity, slot,
-		)
-		return err
-	})
+	// 创建新记录
+	// 找到下一个可用的槽位
+	var nextSlot sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT MAX(slot) FROM inventory WHERE character_id = ?`, characterID,
+	).Scan(&nextSlot)
+	
+	slot := 1
+	if err == nil && nextSlot.Valid {
+		slot = int(nextSlot.Int64) + 1
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO inventory (character_id, item_id, quantity, slot)
+		VALUES (?, ?, ?, ?)`, characterID, itemID, quantity, slot,
+	)
+	return err
 }
 
 // GetByCharacterID 获取角色的所有背包物品
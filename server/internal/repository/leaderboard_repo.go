@@ -0,0 +1,369 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 排行榜错误
+var (
+	ErrLeaderboardSeasonNotFound = errors.New("leaderboard season not found")
+	ErrUnknownLeaderboard        = errors.New("unknown leaderboard")
+)
+
+// LeaderboardRepository 排行榜数据仓库
+type LeaderboardRepository struct{}
+
+// NewLeaderboardRepository 创建排行榜仓库
+func NewLeaderboardRepository() *LeaderboardRepository {
+	return &LeaderboardRepository{}
+}
+
+// ═══════════════════════════════════════════════════════════
+// 赛季
+// ═══════════════════════════════════════════════════════════
+
+const seasonColumns = `SELECT id, name, started_at, ended_at, last_snapshot_at FROM leaderboard_seasons`
+
+// GetCurrentSeason 获取当前赛季（没有进行中的赛季时返回 nil）
+func (r *LeaderboardRepository) GetCurrentSeason() (*models.LeaderboardSeason, error) {
+	return getCurrentSeason(database.DB)
+}
+
+// GetCurrentSeasonTx 在事务中获取当前赛季
+func (r *LeaderboardRepository) GetCurrentSeasonTx(tx *sql.Tx) (*models.LeaderboardSeason, error) {
+	return getCurrentSeason(tx)
+}
+
+func getCurrentSeason(db dbExecutor) (*models.LeaderboardSeason, error) {
+	season, err := scanSeason(db.QueryRow(seasonColumns + ` WHERE ended_at IS NULL ORDER BY id DESC LIMIT 1`))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return season, err
+}
+
+// GetSeason 根据ID获取赛季
+func (r *LeaderboardRepository) GetSeason(id int) (*models.LeaderboardSeason, error) {
+	season, err := scanSeason(database.DB.QueryRow(seasonColumns+` WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrLeaderboardSeasonNotFound
+	}
+	return season, err
+}
+
+// GetSeasons 获取全部赛季（最新在前）
+func (r *LeaderboardRepository) GetSeasons() ([]*models.LeaderboardSeason, error) {
+	rows, err := database.DB.Query(seasonColumns + ` ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seasons := make([]*models.LeaderboardSeason, 0)
+	for rows.Next() {
+		season, err := scanSeason(rows)
+		if err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, season)
+	}
+	return seasons, rows.Err()
+}
+
+func scanSeason(row rowScanner) (*models.LeaderboardSeason, error) {
+	season := &models.LeaderboardSeason{}
+	var endedAt, lastSnapshotAt sql.NullTime
+	if err := row.Scan(&season.ID, &season.Name, &season.StartedAt, &endedAt, &lastSnapshotAt); err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		season.EndedAt = &endedAt.Time
+	}
+	if lastSnapshotAt.Valid {
+		season.LastSnapshotAt = &lastSnapshotAt.Time
+	}
+	return season, nil
+}
+
+// CreateSeasonTx 在事务中开启新赛季
+func (r *LeaderboardRepository) CreateSeasonTx(tx *sql.Tx, name string, now time.Time) (*models.LeaderboardSeason, error) {
+	result, err := tx.Exec(`INSERT INTO leaderboard_seasons (name, started_at) VALUES (?, ?)`, name, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &models.LeaderboardSeason{ID: int(id), Name: name, StartedAt: now.UTC()}, nil
+}
+
+// EndSeasonTx 在事务中结束赛季
+func (r *LeaderboardRepository) EndSeasonTx(tx *sql.Tx, seasonID int, now time.Time) error {
+	_, err := tx.Exec(`UPDATE leaderboard_seasons SET ended_at = ? WHERE id = ? AND ended_at IS NULL`, now.UTC(), seasonID)
+	return err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 成绩采集
+// ═══════════════════════════════════════════════════════════
+
+// cumulativeBoards 累计类榜单的数据来源（赛季成绩 = 当前值 - 赛季基线）
+var cumulativeBoards = map[string]struct {
+	join   string
+	column string
+}{
+	models.LeaderboardKills:      {"", "u.total_kills"},
+	models.LeaderboardGoldEarned: {"", "u.total_gold_gained"},
+	models.LeaderboardHonor:      {"JOIN user_honor h ON h.user_id = u.id", "h.total_honor"},
+}
+
+// leaderboardLeaders 玩家榜以队伍首位角色代表玩家的阵营与职业
+const leaderboardLeaders = `
+	WITH leaders AS (
+		SELECT c.user_id, c.faction, c.class_id, c.level
+		FROM characters c
+		WHERE c.id = (SELECT c2.id FROM characters c2 WHERE c2.user_id = c.user_id ORDER BY c2.team_slot, c2.id LIMIT 1)
+	)
+	SELECT u.id, 0, u.username, l.faction, l.class_id, l.level, `
+
+// SaveBaselinesTx 在事务中记录赛季开始时各累计类榜单的基线
+func (r *LeaderboardRepository) SaveBaselinesTx(tx *sql.Tx, seasonID int) error {
+	for board, source := range cumulativeBoards {
+		_, err := tx.Exec(fmt.Sprintf(`
+			INSERT OR REPLACE INTO leaderboard_baselines (season_id, board, user_id, score)
+			SELECT ?, ?, u.id, %s FROM users u %s`, source.column, source.join),
+			seasonID, board,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CollectScoresTx 在事务中按排名顺序采集某个榜单的当前成绩
+func (r *LeaderboardRepository) CollectScoresTx(tx *sql.Tx, board string, season *models.LeaderboardSeason) ([]*models.LeaderboardEntry, error) {
+	var query string
+	var args []interface{}
+	switch board {
+	case models.LeaderboardLevel:
+		query = `
+			SELECT c.user_id, c.id, c.name, c.faction, c.class_id, c.level, c.level
+			FROM characters c
+			ORDER BY c.level DESC, c.exp DESC, c.id`
+	case models.LeaderboardHighestHit:
+		query = `
+			SELECT c.user_id, c.id, c.name, c.faction, c.class_id, c.level, s.highest_damage_single
+			FROM character_lifetime_stats s
+			JOIN characters c ON c.id = s.character_id
+			WHERE s.highest_damage_single > 0
+			ORDER BY s.highest_damage_single DESC, c.id`
+	case models.LeaderboardAbyssFloor:
+		query = leaderboardLeaders + `ap.highest_floor
+			FROM users u
+			JOIN leaders l ON l.user_id = u.id
+			JOIN abyss_progress ap ON ap.user_id = u.id
+			WHERE ap.highest_floor > 0
+			ORDER BY ap.highest_floor DESC, u.id`
+	case models.LeaderboardFastestBossKill:
+		// 只统计上个赛季结束之后的击杀
+		query = leaderboardLeaders + `k.best
+			FROM users u
+			JOIN leaders l ON l.user_id = u.id
+			JOIN (
+				SELECT br.user_id, MIN(br.duration_seconds) AS best
+				FROM battle_records br
+				JOIN monsters m ON m.id = br.monster_id
+				WHERE m.type = 'boss' AND br.result = 'victory' AND br.duration_seconds > 0
+				  AND br.created_at >= COALESCE((SELECT MAX(ended_at) FROM leaderboard_seasons WHERE id < ?), '')
+				GROUP BY br.user_id
+			) k ON k.user_id = u.id
+			ORDER BY k.best, u.id`
+		args = append(args, season.ID)
+	default:
+		source, ok := cumulativeBoards[board]
+		if !ok {
+			return nil, ErrUnknownLeaderboard
+		}
+		score := source.column + " - COALESCE(b.score, 0)"
+		query = leaderboardLeaders + score + fmt.Sprintf(`
+			FROM users u
+			JOIN leaders l ON l.user_id = u.id
+			%s
+			LEFT JOIN leaderboard_baselines b ON b.season_id = ? AND b.board = ? AND b.user_id = u.id
+			WHERE %s > 0
+			ORDER BY %s DESC, u.id`, source.join, score, score)
+		args = append(args, season.ID, board)
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.LeaderboardEntry, 0)
+	for rows.Next() {
+		entry := &models.LeaderboardEntry{}
+		if err := rows.Scan(&entry.UserID, &entry.CharacterID, &entry.Name, &entry.Faction,
+			&entry.ClassID, &entry.Level, &entry.Score); err != nil {
+			return nil, err
+		}
+		entry.GlobalRank = len(entries) + 1
+		entry.Rank = entry.GlobalRank
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// ReplaceEntriesTx 在事务中用新快照替换某个榜单
+func (r *LeaderboardRepository) ReplaceEntriesTx(tx *sql.Tx, seasonID int, board string, entries []*models.LeaderboardEntry) error {
+	if _, err := tx.Exec(`DELETE FROM leaderboard_entries WHERE season_id = ? AND board = ?`, seasonID, board); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`
+		INSERT INTO leaderboard_entries (season_id, board, rank, user_id, character_id, name, faction, class_id, level, score)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, entry := range entries {
+		if _, err := stmt.Exec(seasonID, board, entry.GlobalRank, entry.UserID, entry.CharacterID,
+			entry.Name, entry.Faction, entry.ClassID, entry.Level, entry.Score); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetSnapshotTimeTx 在事务中记录赛季最近一次快照时间
+func (r *LeaderboardRepository) SetSnapshotTimeTx(tx *sql.Tx, seasonID int, now time.Time) error {
+	_, err := tx.Exec(`UPDATE leaderboard_seasons SET last_snapshot_at = ? WHERE id = ?`, now.UTC(), seasonID)
+	return err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 榜单查询
+// ═══════════════════════════════════════════════════════════
+
+// LeaderboardFilter 榜单筛选条件（空字符串表示不限）
+type LeaderboardFilter struct {
+	SeasonID int
+	Board    string
+	Faction  string
+	ClassID  string
+}
+
+func (f *LeaderboardFilter) where() (string, []interface{}) {
+	clause := ` WHERE season_id = ? AND board = ?`
+	args := []interface{}{f.SeasonID, f.Board}
+	if f.Faction != "" {
+		clause += ` AND faction = ?`
+		args = append(args, f.Faction)
+	}
+	if f.ClassID != "" {
+		clause += ` AND class_id = ?`
+		args = append(args, f.ClassID)
+	}
+	return clause, args
+}
+
+const leaderboardEntryColumns = `
+	SELECT rank, user_id, character_id, name, faction, class_id, level, score
+	FROM leaderboard_entries`
+
+// GetEntries 获取筛选后的一页排名（Rank 为筛选内排名）
+func (r *LeaderboardRepository) GetEntries(filter *LeaderboardFilter, limit, offset int) ([]*models.LeaderboardEntry, error) {
+	where, args := filter.where()
+	entries, err := queryLeaderboardEntries(leaderboardEntryColumns+where+` ORDER BY rank LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		entry.Rank = offset + i + 1
+	}
+	return entries, nil
+}
+
+// CountEntries 统计筛选后的上榜人数
+func (r *LeaderboardRepository) CountEntries(filter *LeaderboardFilter) (int, error) {
+	where, args := filter.where()
+	var count int
+	err := database.DB.QueryRow(`SELECT COUNT(*) FROM leaderboard_entries`+where, args...).Scan(&count)
+	return count, err
+}
+
+// GetBestEntry 获取玩家在筛选内的最佳条目（未上榜返回 nil）
+func (r *LeaderboardRepository) GetBestEntry(filter *LeaderboardFilter, userID int) (*models.LeaderboardEntry, error) {
+	where, args := filter.where()
+	entries, err := queryLeaderboardEntries(leaderboardEntryColumns+where+` AND user_id = ? ORDER BY rank LIMIT 1`,
+		append(args, userID)...)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	entry := entries[0]
+
+	var ahead int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM leaderboard_entries`+where+` AND rank < ?`,
+		append(args, entry.GlobalRank)...).Scan(&ahead); err != nil {
+		return nil, err
+	}
+	entry.Rank = ahead + 1
+	return entry, nil
+}
+
+// GetNeighbours 获取某个全服排名前（above）或后的 n 个筛选内条目，按排名升序返回
+func (r *LeaderboardRepository) GetNeighbours(filter *LeaderboardFilter, entry *models.LeaderboardEntry, n int, above bool) ([]*models.LeaderboardEntry, error) {
+	where, args := filter.where()
+	query := leaderboardEntryColumns + where + ` AND rank > ? ORDER BY rank LIMIT ?`
+	if above {
+		query = leaderboardEntryColumns + where + ` AND rank < ? ORDER BY rank DESC LIMIT ?`
+	}
+	entries, err := queryLeaderboardEntries(query, append(args, entry.GlobalRank, n)...)
+	if err != nil {
+		return nil, err
+	}
+	if above {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+		for i, e := range entries {
+			e.Rank = entry.Rank - len(entries) + i
+		}
+	} else {
+		for i, e := range entries {
+			e.Rank = entry.Rank + i + 1
+		}
+	}
+	return entries, nil
+}
+
+func queryLeaderboardEntries(query string, args ...interface{}) ([]*models.LeaderboardEntry, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.LeaderboardEntry, 0)
+	for rows.Next() {
+		entry := &models.LeaderboardEntry{}
+		if err := rows.Scan(&entry.GlobalRank, &entry.UserID, &entry.CharacterID, &entry.Name,
+			&entry.Faction, &entry.ClassID, &entry.Level, &entry.Score); err != nil {
+			return nil, err
+		}
+		entry.Rank = entry.GlobalRank
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// LoginAttemptRepository 登录尝试审计与锁定状态数据仓库
+type LoginAttemptRepository struct{}
+
+// NewLoginAttemptRepository 创建登录尝试仓库
+func NewLoginAttemptRepository() *LoginAttemptRepository {
+	return &LoginAttemptRepository{}
+}
+
+// RecordAttemptTx 在事务中写入登录尝试（按用户名关联账号，不存在时 user_id 为 NULL）
+func (r *LoginAttemptRepository) RecordAttemptTx(tx *sql.Tx, attempt *models.LoginAttempt) error {
+	result, err := tx.Exec(`
+		INSERT INTO login_attempts (username, user_id, ip_address, user_agent, result, created_at)
+		VALUES (?, (SELECT id FROM users WHERE LOWER(username) = ? LIMIT 1), ?, ?, ?, ?)`,
+		attempt.Username, attempt.Username, attempt.IPAddress, nullString(attempt.UserAgent),
+		attempt.Result, attempt.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	attempt.ID = int(id)
+	return nil
+}
+
+// UpdateAttemptResultTx 在事务中更新登录尝试结果（结算预占的尝试）
+func (r *LoginAttemptRepository) UpdateAttemptResultTx(tx *sql.Tx, id int, result string) error {
+	_, err := tx.Exec(`UPDATE login_attempts SET result = ? WHERE id = ?`, result, id)
+	return err
+}
+
+// DeleteAttemptTx 在事务中删除登录尝试（预占后未进行凭据校验）
+func (r *LoginAttemptRepository) DeleteAttemptTx(tx *sql.Tx, id int) error {
+	_, err := tx.Exec(`DELETE FROM login_attempts WHERE id = ?`, id)
+	return err
+}
+
+// CountIPFailuresTx 统计IP在 since 之后的失败次数
+func (r *LoginAttemptRepository) CountIPFailuresTx(tx *sql.Tx, ip string, since time.Time) (int, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM login_attempts
+		WHERE ip_address = ? AND result = ? AND created_at > ?`,
+		ip, models.LoginResultFailure, since.UTC(),
+	).Scan(&count)
+	return count, err
+}
+
+// CountUsernameFailuresTx 统计用户名在 since 之后、且最近一次成功登录之后的失败次数
+func (r *LoginAttemptRepository) CountUsernameFailuresTx(tx *sql.Tx, username string, since time.Time) (int, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM login_attempts
+		WHERE username = ? AND result = ? AND created_at > ?
+		  AND id > COALESCE((SELECT MAX(id) FROM login_attempts WHERE username = ? AND result = ?), 0)`,
+		username, models.LoginResultFailure, since.UTC(), username, models.LoginResultSuccess,
+	).Scan(&count)
+	return count, err
+}
+
+// CountIPPendingTx 统计IP在 since 之后尚未结算的尝试次数
+func (r *LoginAttemptRepository) CountIPPendingTx(tx *sql.Tx, ip string, since time.Time) (int, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM login_attempts
+		WHERE ip_address = ? AND result = ? AND created_at > ?`,
+		ip, models.LoginResultPending, since.UTC(),
+	).Scan(&count)
+	return count, err
+}
+
+// CountUsernamePendingTx 统计用户名在 since 之后尚未结算的尝试次数
+func (r *LoginAttemptRepository) CountUsernamePendingTx(tx *sql.Tx, username string, since time.Time) (int, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM login_attempts
+		WHERE username = ? AND result = ? AND created_at > ?`,
+		username, models.LoginResultPending, since.UTC(),
+	).Scan(&count)
+	return count, err
+}
+
+// GetActiveLockout 获取IP或用户名上尚未到期的锁定（到期最晚的一条），没有时返回 nil
+func (r *LoginAttemptRepository) GetActiveLockout(ip, username string, now time.Time) (*models.LoginLockout, error) {
+	return getActiveLockout(database.DB, ip, username, now)
+}
+
+// GetActiveLockoutTx 在事务中获取IP或用户名上尚未到期的锁定
+func (r *LoginAttemptRepository) GetActiveLockoutTx(tx *sql.Tx, ip, username string, now time.Time) (*models.LoginLockout, error) {
+	return getActiveLockout(tx, ip, username, now)
+}
+
+func getActiveLockout(db dbExecutor, ip, username string, now time.Time) (*models.LoginLockout, error) {
+	lockout := &models.LoginLockout{}
+	err := db.QueryRow(`
+		SELECT scope, lock_key, level, locked_until FROM login_lockouts
+		WHERE ((scope = ? AND lock_key = ?) OR (scope = ? AND lock_key = ?)) AND locked_until > ?
+		ORDER BY locked_until DESC
+		LIMIT 1`,
+		models.LoginScopeIP, ip, models.LoginScopeUsername, username, now.UTC(),
+	).Scan(&lockout.Scope, &lockout.Key, &lockout.Level, &lockout.LockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lockout, nil
+}
+
+// GetLockoutTx 在事务中获取锁定状态（含已到期的），没有时返回 nil
+func (r *LoginAttemptRepository) GetLockoutTx(tx *sql.Tx, scope, key string) (*models.LoginLockout, error) {
+	lockout := &models.LoginLockout{Scope: scope, Key: key}
+	err := tx.QueryRow(`
+		SELECT level, locked_until FROM login_lockouts WHERE scope = ? AND lock_key = ?`,
+		scope, key,
+	).Scan(&lockout.Level, &lockout.LockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lockout, nil
+}
+
+// SaveLockoutTx 在事务中保存锁定状态
+func (r *LoginAttemptRepository) SaveLockoutTx(tx *sql.Tx, lockout *models.LoginLockout) error {
+	_, err := tx.Exec(`
+		INSERT INTO login_lockouts (scope, lock_key, level, locked_until)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(scope, lock_key) DO UPDATE SET
+			level = excluded.level, locked_until = excluded.locked_until`,
+		lockout.Scope, lockout.Key, lockout.Level, lockout.LockedUntil.UTC(),
+	)
+	return err
+}
+
+// ClearLockoutTx 在事务中清除锁定状态
+func (r *LoginAttemptRepository) ClearLockoutTx(tx *sql.Tx, scope, key string) error {
+	_, err := tx.Exec(`DELETE FROM login_lockouts WHERE scope = ? AND lock_key = ?`, scope, key)
+	return err
+}
+
+// GetAttempts 获取登录尝试记录（最新在前，username/ip 为空表示不限，beforeID>0 时向前翻页）
+func (r *LoginAttemptRepository) GetAttempts(username, ip string, beforeID, limit int) ([]*models.LoginAttempt, error) {
+	query := `
+		SELECT id, username, user_id, ip_address, COALESCE(user_agent, ''), result, created_at
+		FROM login_attempts
+		WHERE 1 = 1`
+	args := []interface{}{}
+	if username != "" {
+		query += " AND username = ?"
+		args = append(args, username)
+	}
+	if ip != "" {
+		query += " AND ip_address = ?"
+		args = append(args, ip)
+	}
+	if beforeID > 0 {
+		query += " AND id < ?"
+		args = append(args, beforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := make([]*models.LoginAttempt, 0)
+	for rows.Next() {
+		a := &models.LoginAttempt{}
+		var userID sql.NullInt64
+		if err := rows.Scan(&a.ID, &a.Username, &userID, &a.IPAddress, &a.UserAgent, &a.Result, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.UserID = nullIntPtr(userID)
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// PruneBefore 删除 before 之前的登录尝试与已过期的锁定，返回删除的尝试数量
+func (r *LoginAttemptRepository) PruneBefore(before time.Time) (int, error) {
+	result, err := database.DB.Exec(`DELETE FROM login_attempts WHERE created_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	if _, err := database.DB.Exec(`DELETE FROM login_lockouts WHERE locked_until < ?`, before.UTC()); err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
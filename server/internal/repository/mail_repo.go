@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// DefaultMailExpiry 邮件默认保留时间
+const DefaultMailExpiry = 30 * 24 * time.Hour
+
+// 邮箱错误
+var (
+	ErrMailNotFound       = errors.New("mail not found")
+	ErrMailNotActive      = errors.New("mail is no longer available")
+	ErrMailExpired        = errors.New("mail has expired")
+	ErrMailAlreadyClaimed = errors.New("mail attachments have already been claimed")
+	ErrMailHasAttachments = errors.New("mail still has unclaimed attachments")
+)
+
+// MailRepository 邮箱数据仓库
+type MailRepository struct{}
+
+// NewMailRepository 创建邮箱仓库
+func NewMailRepository() *MailRepository {
+	return &MailRepository{}
+}
+
+// SendMailTx 在事务中投递邮件（附件装备在领取前处于托管状态）
+func (r *MailRepository) SendMailTx(tx *sql.Tx, mail *models.Mail, equipmentIDs []int) (*models.Mail, error) {
+	return sendMailTx(tx, mail, equipmentIDs)
+}
+
+// GetMail 获取邮件（含附件）
+func (r *MailRepository) GetMail(id int) (*models.Mail, error) {
+	return getMail(database.DB, id)
+}
+
+// GetMailTx 在事务中获取邮件
+func (r *MailRepository) GetMailTx(tx *sql.Tx, id int) (*models.Mail, error) {
+	return getMail(tx, id)
+}
+
+// GetMailbox 获取收件箱中未过期的邮件（最新在前）
+func (r *MailRepository) GetMailbox(userID int, now time.Time, limit int) ([]*models.Mail, error) {
+	rows, err := database.DB.Query(`
+		SELECT id FROM mails
+		WHERE recipient_id = ? AND status = 'active' AND expires_at > ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, userID, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := scanMailIDs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	mails := make([]*models.Mail, 0, len(ids))
+	for _, id := range ids {
+		mail, err := getMail(database.DB, id)
+		if err != nil {
+			return nil, err
+		}
+		mails = append(mails, mail)
+	}
+	return mails, nil
+}
+
+// CountUnread 统计未读邮件数
+func (r *MailRepository) CountUnread(userID int, now time.Time) (int, error) {
+	var count int
+	err := database.DB.QueryRow(`
+		SELECT COUNT(*) FROM mails
+		WHERE recipient_id = ? AND status = 'active' AND is_read = 0 AND expires_at > ?
+	`, userID, now.UTC()).Scan(&count)
+	return count, err
+}
+
+// MarkRead 标记邮件已读
+func (r *MailRepository) MarkRead(id int, now time.Time) error {
+	_, err := database.DB.Exec(`
+		UPDATE mails SET is_read = 1, read_at = COALESCE(read_at, ?) WHERE id = ?
+	`, now.UTC(), id)
+	return err
+}
+
+// MarkClaimedTx 标记附件已领取
+func (r *MailRepository) MarkClaimedTx(tx *sql.Tx, id int, now time.Time) error {
+	result, err := tx.Exec(`
+		UPDATE mails SET claimed_at = ?, is_read = 1, read_at = COALESCE(read_at, ?)
+		WHERE id = ? AND status = 'active' AND claimed_at IS NULL
+	`, now.UTC(), now.UTC(), id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrMailAlreadyClaimed
+	}
+	return nil
+}
+
+// CloseMailTx 结束邮件（退回/过期/删除）
+func (r *MailRepository) CloseMailTx(tx *sql.Tx, id int, status string, now time.Time) error {
+	result, err := tx.Exec(`
+		UPDATE mails SET status = ?, closed_at = ? WHERE id = ? AND status = 'active'
+	`, status, now.UTC(), id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrMailNotActive
+	}
+	return nil
+}
+
+// GetDueMailIDs 获取已到期但仍为活跃状态的邮件
+func (r *MailRepository) GetDueMailIDs(now time.Time) ([]int, error) {
+	rows, err := database.DB.Query(`
+		SELECT id FROM mails WHERE status = 'active' AND expires_at <= ? ORDER BY expires_at ASC
+	`, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return scanMailIDs(rows)
+}
+
+// isEquipmentInMail 装备是否作为未领取的邮件附件被托管
+func isEquipmentInMail(db dbExecutor, equipmentID int) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM mail_attachments ma
+		JOIN mails m ON m.id = ma.mail_id
+		WHERE ma.equipment_id = ? AND m.status = 'active' AND m.claimed_at IS NULL
+	`, equipmentID).Scan(&count)
+	return count > 0, err
+}
+
+func sendMailTx(tx *sql.Tx, mail *models.Mail, equipmentIDs []int) (*models.Mail, error) {
+	if mail.Type == "" {
+		mail.Type = "player"
+	}
+	now := mail.CreatedAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if mail.ExpiresAt.IsZero() {
+		mail.ExpiresAt = now.Add(DefaultMailExpiry)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO mails (recipient_id, sender_id, sender_name, mail_type, subject, body, gold, cod_amount,
+		                   status, returned_from_id, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'active', ?, ?, ?)
+	`, mail.RecipientID, mail.SenderID, mail.SenderName, mail.Type, mail.Subject, mail.Body, mail.Gold, mail.CODAmount,
+		mail.ReturnedFromID, now.UTC(), mail.ExpiresAt.UTC())
+	if err != nil {
+		return nil, err
+	}
+	id, _ := result.LastInsertId()
+
+	for _, equipmentID := range equipmentIDs {
+		if _, err := tx.Exec(`
+			INSERT INTO mail_attachments (mail_id, equipment_id) VALUES (?, ?)
+		`, id, equipmentID); err != nil {
+			return nil, err
+		}
+	}
+	return getMail(tx, int(id))
+}
+
+func getMail(db rowsQuerier, id int) (*models.Mail, error) {
+	mail := &models.Mail{}
+	var senderID, returnedFromID sql.NullInt64
+	var isRead int
+	var readAt, claimedAt sql.NullTime
+	err := db.QueryRow(`
+		SELECT m.id, m.recipient_id, COALESCE(u.username, ''), m.sender_id, m.sender_name, COALESCE(m.mail_type, 'player'),
+		       m.subject, COALESCE(m.body, ''), COALESCE(m.gold, 0), COALESCE(m.cod_amount, 0), m.status,
+		       COALESCE(m.is_read, 0), m.returned_from_id, m.created_at, m.read_at, m.claimed_at, m.expires_at
+		FROM mails m
+		LEFT JOIN users u ON u.id = m.recipient_id
+		WHERE m.id = ?`, id,
+	).Scan(
+		&mail.ID, &mail.RecipientID, &mail.RecipientName, &senderID, &mail.SenderName, &mail.Type, &mail.Subject, &mail.Body,
+		&mail.Gold, &mail.CODAmount, &mail.Status, &isRead, &returnedFromID,
+		&mail.CreatedAt, &readAt, &claimedAt, &mail.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrMailNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	mail.SenderID = nullIntPtr(senderID)
+	mail.ReturnedFromID = nullIntPtr(returnedFromID)
+	mail.IsRead = intToBool(isRead)
+	if readAt.Valid {
+		mail.ReadAt = &readAt.Time
+	}
+	if claimedAt.Valid {
+		mail.ClaimedAt = &claimedAt.Time
+	}
+
+	rows, err := db.Query(`
+		SELECT ma.id, ma.mail_id, ma.equipment_id, e.item_id, COALESCE(i.name, e.item_id), e.slot, e.quality
+		FROM mail_attachments ma
+		JOIN equipment_instance e ON e.id = ma.equipment_id
+		LEFT JOIN items i ON i.id = e.item_id
+		WHERE ma.mail_id = ?
+		ORDER BY ma.id ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mail.Attachments = make([]*models.MailAttachment, 0)
+	for rows.Next() {
+		attachment := &models.MailAttachment{}
+		if err := rows.Scan(&attachment.ID, &attachment.MailID, &attachment.EquipmentID, &attachment.ItemID,
+			&attachment.ItemName, &attachment.Slot, &attachment.Quality); err != nil {
+			return nil, err
+		}
+		mail.Attachments = append(mail.Attachments, attachment)
+	}
+	return mail, rows.Err()
+}
+
+func scanMailIDs(rows *sql.Rows) ([]int, error) {
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// PvPRepository 阵营PVP数据仓库
+type PvPRepository struct{}
+
+// NewPvPRepository 创建PVP仓库
+func NewPvPRepository() *PvPRepository {
+	return &PvPRepository{}
+}
+
+// PvP战斗结果（用于荣誉战绩更新）
+const (
+	PvPResultWin  = "win"
+	PvPResultLoss = "loss"
+	PvPResultDraw = "draw"
+)
+
+// PvPParticipantResult 参战方战绩变化
+type PvPParticipantResult struct {
+	UserID      int
+	Faction     string
+	Result      string // win/loss/draw
+	Honor       int
+	Kills       int
+	Deaths      int
+	DamageDealt int
+}
+
+// ═══════════════════════════════════════════════════════════
+// 地图控制
+// ═══════════════════════════════════════════════════════════
+
+// GetZoneControl 获取地图控制状态（无记录时返回中立状态）
+func (r *PvPRepository) GetZoneControl(zoneID string) (*models.ZoneFactionControl, error) {
+	row := database.DB.QueryRow(zoneControlSelect+" WHERE zone_id = ?", zoneID)
+	control, err := scanZoneControl(row)
+	if err == sql.ErrNoRows {
+		return newNeutralZoneControl(zoneID), nil
+	}
+	return control, err
+}
+
+const zoneControlSelect = `
+	SELECT zone_id, COALESCE(controlling_faction, 'neutral'), COALESCE(alliance_wins, 0), COALESCE(horde_wins, 0),
+	       COALESCE(alliance_win_rate, 0.5), COALESCE(control_score, 0),
+	       COALESCE(efficiency_bonus, 0), COALESCE(efficiency_penalty, 0),
+	       last_battle_at, stats_reset_at
+	FROM zone_faction_control`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanZoneControl(row rowScanner) (*models.ZoneFactionControl, error) {
+	control := &models.ZoneFactionControl{}
+	var lastBattleAt, statsResetAt sql.NullTime
+	err := row.Scan(
+		&control.ZoneID, &control.ControllingFaction, &control.AllianceWins, &control.HordeWins,
+		&control.AllianceWinRate, &control.ControlScore,
+		&control.EfficiencyBonus, &control.EfficiencyPenalty,
+		&lastBattleAt, &statsResetAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lastBattleAt.Valid {
+		control.LastBattleAt = &lastBattleAt.Time
+	}
+	if statsResetAt.Valid {
+		control.StatsResetAt = &statsResetAt.Time
+	}
+	return control, nil
+}
+
+func newNeutralZoneControl(zoneID string) *models.ZoneFactionControl {
+	return &models.ZoneFactionControl{
+		ZoneID:             zoneID,
+		ControllingFaction: "neutral",
+		AllianceWinRate:    0.5,
+	}
+}
+
+// ═══════════════════════════════════════════════════════════
+// 匹配
+// ═══════════════════════════════════════════════════════════
+
+// FindOpponentUserID 在指定地图中随机寻找敌对阵营玩家（按出战角色最高等级过滤）
+func (r *PvPRepository) FindOpponentUserID(zoneID, opponentFaction string, excludeUserID, minLevel, maxLevel int) (int, error) {
+	var userID int
+	err := database.DB.QueryRow(`
+		SELECT u.id
+		FROM users u
+		JOIN characters c ON c.user_id = u.id AND c.is_active = 1
+		WHERE u.current_zone_id = ? AND u.id != ? AND c.faction = ?
+		GROUP BY u.id
+		HAVING MAX(c.level) BETWEEN ? AND ?
+		ORDER BY RANDOM()
+		LIMIT 1
+	`, zoneID, excludeUserID, opponentFaction, minLevel, maxLevel).Scan(&userID)
+	return userID, err
+}
+
+// ═══════════════════════════════════════════════════════════
+// 遭遇战记录
+// ═══════════════════════════════════════════════════════════
+
+// RecordEncounter 记录PVP遭遇战
+// 在同一事务中写入战斗记录、双方荣誉战绩，并通过 updateControl 更新地图控制状态
+func (r *PvPRepository) RecordEncounter(
+	enc *models.PvPEncounter,
+	participants []PvPParticipantResult,
+	updateControl func(control *models.ZoneFactionControl),
+) (*models.ZoneFactionControl, error) {
+	return WithTransactionResult(func(tx *sql.Tx) (*models.ZoneFactionControl, error) {
+		attackerTeam, _ := json.Marshal(enc.AttackerTeam)
+		defenderTeam, _ := json.Marshal(enc.DefenderTeam)
+		battleLog, _ := json.Marshal(enc.BattleLog)
+
+		var winnerUserID interface{}
+		if enc.WinnerUserID != nil {
+			winnerUserID = *enc.WinnerUserID
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO pvp_encounters (
+				zone_id, attacker_user_id, defender_user_id, attacker_faction, defender_faction,
+				winner_user_id, winner_faction, attacker_team_info, defender_team_info,
+				battle_rounds, battle_duration, attacker_damage_dealt, defender_damage_dealt,
+				honor_reward, battle_log, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, enc.ZoneID, enc.AttackerUserID, enc.DefenderUserID, enc.AttackerFaction, enc.DefenderFaction,
+			winnerUserID, nullString(enc.WinnerFaction), string(attackerTeam), string(defenderTeam),
+			enc.BattleRounds, enc.BattleDuration, enc.AttackerDamageDealt, enc.DefenderDamageDealt,
+			enc.HonorReward, string(battleLog), enc.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		id, _ := result.LastInsertId()
+		enc.ID = int(id)
+
+		for _, p := range participants {
+			if err := applyPvPResult(tx, p); err != nil {
+				return nil, err
+			}
+		}
+
+		control, err := scanZoneControl(tx.QueryRow(zoneControlSelect+" WHERE zone_id = ?", enc.ZoneID))
+		if err == sql.ErrNoRows {
+			control = newNeutralZoneControl(enc.ZoneID)
+		} else if err != nil {
+			return nil, err
+		}
+
+		updateControl(control)
+
+		_, err = tx.Exec(`
+			INSERT INTO zone_faction_control (
+				zone_id, controlling_faction, alliance_wins, horde_wins, alliance_win_rate,
+				control_score, efficiency_bonus, efficiency_penalty, last_battle_at, stats_reset_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(zone_id) DO UPDATE SET
+				controlling_faction = excluded.controlling_faction,
+				alliance_wins = excluded.alliance_wins,
+				horde_wins = excluded.horde_wins,
+				alliance_win_rate = excluded.alliance_win_rate,
+				control_score = excluded.control_score,
+				efficiency_bonus = excluded.efficiency_bonus,
+				efficiency_penalty = excluded.efficiency_penalty,
+				last_battle_at = excluded.last_battle_at,
+				stats_reset_at = excluded.stats_reset_at
+		`, control.ZoneID, control.ControllingFaction, control.AllianceWins, control.HordeWins, control.AllianceWinRate,
+			control.ControlScore, control.EfficiencyBonus, control.EfficiencyPenalty, control.LastBattleAt, control.StatsResetAt)
+		if err != nil {
+			return nil, err
+		}
+
+		return control, nil
+	})
+}
+
+// applyPvPResult 更新玩家荣誉战绩
+func applyPvPResult(tx *sql.Tx, p PvPParticipantResult) error {
+	_, err := tx.Exec(`
+		INSERT INTO user_honor (user_id, faction, weekly_reset_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO NOTHING
+	`, p.UserID, p.Faction, time.Now())
+	if err != nil {
+		return err
+	}
+
+	win, loss, draw := 0, 0, 0
+	switch p.Result {
+	case PvPResultWin:
+		win = 1
+	case PvPResultLoss:
+		loss = 1
+	default:
+		draw = 1
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_honor SET
+			total_honor = total_honor + ?,
+			current_honor = current_honor + ?,
+			weekly_honor = weekly_honor + ?,
+			pvp_wins = pvp_wins + ?,
+			pvp_losses = pvp_losses + ?,
+			pvp_draws = pvp_draws + ?,
+			win_streak = CASE WHEN ? = 1 THEN win_streak + 1 ELSE 0 END,
+			best_win_streak = MAX(best_win_streak, CASE WHEN ? = 1 THEN win_streak + 1 ELSE 0 END),
+			total_kills = total_kills + ?,
+			total_deaths = total_deaths + ?,
+			total_damage_dealt = total_damage_dealt + ?
+		WHERE user_id = ?
+	`, p.Honor, p.Honor, p.Honor, win, loss, draw, win, win,
+		p.Kills, p.Deaths, p.DamageDealt, p.UserID)
+	return err
+}
+
+// GetEncounterByID 获取遭遇战详情（包含战斗日志）
+func (r *PvPRepository) GetEncounterByID(id int) (*models.PvPEncounter, error) {
+	rows, err := database.DB.Query(encounterSelect+", COALESCE(battle_log, '') FROM pvp_encounters WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+
+	var battleLog string
+	enc, err := scanEncounter(rows, &battleLog)
+	if err != nil {
+		return nil, err
+	}
+	if battleLog != "" {
+		json.Unmarshal([]byte(battleLog), &enc.BattleLog)
+	}
+	return enc, nil
+}
+
+// GetUserEncounters 获取玩家参与的遭遇战（不含战斗日志）
+func (r *PvPRepository) GetUserEncounters(userID int, limit int) ([]*models.PvPEncounter, error) {
+	rows, err := database.DB.Query(encounterSelect+`
+		FROM pvp_encounters
+		WHERE attacker_user_id = ? OR defender_user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, userID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var encounters []*models.PvPEncounter
+	for rows.Next() {
+		enc, err := scanEncounter(rows)
+		if err != nil {
+			return nil, err
+		}
+		encounters = append(encounters, enc)
+	}
+	return encounters, rows.Err()
+}
+
+const encounterSelect = `
+	SELECT id, zone_id, attacker_user_id, defender_user_id, attacker_faction, defender_faction,
+	       winner_user_id, COALESCE(winner_faction, ''),
+	       COALESCE(attacker_team_info, ''), COALESCE(defender_team_info, ''),
+	       battle_rounds, battle_duration, attacker_damage_dealt, defender_damage_dealt,
+	       honor_reward, created_at`
+
+func scanEncounter(rows *sql.Rows, extra ...interface{}) (*models.PvPEncounter, error) {
+	enc := &models.PvPEncounter{}
+	var winnerUserID sql.NullInt64
+	var attackerTeam, defenderTeam string
+	dest := []interface{}{
+		&enc.ID, &enc.ZoneID, &enc.AttackerUserID, &enc.DefenderUserID, &enc.AttackerFaction, &enc.DefenderFaction,
+		&winnerUserID, &enc.WinnerFaction,
+		&attackerTeam, &defenderTeam,
+		&enc.BattleRounds, &enc.BattleDuration, &enc.AttackerDamageDealt, &enc.DefenderDamageDealt,
+		&enc.HonorReward, &enc.CreatedAt,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if winnerUserID.Valid {
+		id := int(winnerUserID.Int64)
+		enc.WinnerUserID = &id
+	}
+	if attackerTeam != "" {
+		json.Unmarshal([]byte(attackerTeam), &enc.AttackerTeam)
+	}
+	if defenderTeam != "" {
+		json.Unmarshal([]byte(defenderTeam), &enc.DefenderTeam)
+	}
+	return enc, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// 荣誉
+// ═══════════════════════════════════════════════════════════
+
+// GetUserHonor 获取玩家荣誉战绩（无记录时返回零值）
+func (r *PvPRepository) GetUserHonor(userID int) (*models.UserHonor, error) {
+	honor := &models.UserHonor{UserID: userID}
+	var weeklyResetAt sql.NullTime
+	err := database.DB.QueryRow(`
+		SELECT faction, total_honor, current_honor, honor_rank, pvp_wins, pvp_losses, pvp_draws,
+		       win_streak, best_win_streak, total_kills, total_deaths, total_damage_dealt,
+		       weekly_honor, weekly_reset_at
+		FROM user_honor WHERE user_id = ?
+	`, userID).Scan(
+		&honor.Faction, &honor.TotalHonor, &honor.CurrentHonor, &honor.HonorRank,
+		&honor.PvPWins, &honor.PvPLosses, &honor.PvPDraws,
+		&honor.WinStreak, &honor.BestWinStreak, &honor.TotalKills, &honor.TotalDeaths, &honor.TotalDamageDealt,
+		&honor.WeeklyHonor, &weeklyResetAt,
+	)
+	if err == sql.ErrNoRows {
+		return honor, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if weeklyResetAt.Valid {
+		honor.WeeklyResetAt = &weeklyResetAt.Time
+	}
+	return honor, nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ═══════════════════════════════════════════════════════════
+// 测试辅助函数
+// ═══════════════════════════════════════════════════════════
+
+func setupPvPRepoTest(t *testing.T) (*PvPRepository, int, int, func()) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = testDB.Exec(`
+		INSERT INTO zones (id, name, description, min_level, max_level, faction, exp_modifier, gold_modifier)
+		VALUES ('duskwood', '暮色森林', '争夺区域', 18, 30, NULL, 1.2, 1.2);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert zone: %v", err)
+	}
+
+	userRepo := NewUserRepository()
+	charRepo := NewCharacterRepository()
+	createPlayer := func(name, raceID, faction string, level int) int {
+		user, err := userRepo.Create(name, "hash", "")
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if err := userRepo.UpdateZone(user.ID, "duskwood"); err != nil {
+			t.Fatalf("Failed to update zone: %v", err)
+		}
+		_, err = charRepo.Create(&models.Character{
+			UserID: user.ID, Name: name + "_char", RaceID: raceID, ClassID: "warrior", Faction: faction,
+			TeamSlot: 1, IsActive: true, Level: level, HP: 100, MaxHP: 100, ResourceType: "rage", MaxResource: 100,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create character: %v", err)
+		}
+		return user.ID
+	}
+
+	allianceID := createPlayer("alliance", "human", "alliance", 20)
+	hordeID := createPlayer("horde", "orc", "horde", 22)
+
+	cleanup := func() {
+		database.TeardownTestDB(testDB)
+	}
+	return NewPvPRepository(), allianceID, hordeID, cleanup
+}
+
+// ═══════════════════════════════════════════════════════════
+// 匹配测试
+// ═══════════════════════════════════════════════════════════
+
+func TestPvPRepository_FindOpponentUserID(t *testing.T) {
+	repo, allianceID, hordeID, cleanup := setupPvPRepoTest(t)
+	defer cleanup()
+
+	opponentID, err := repo.FindOpponentUserID("duskwood", "horde", allianceID, 15, 25)
+	assert.NoError(t, err)
+	assert.Equal(t, hordeID, opponentID)
+
+	_, err = repo.FindOpponentUserID("duskwood", "horde", allianceID, 1, 10)
+	assert.Error(t, err, "等级范围外不应匹配到对手")
+}
+
+// ═══════════════════════════════════════════════════════════
+// 遭遇战记录测试
+// ═══════════════════════════════════════════════════════════
+
+func TestPvPRepository_RecordEncounter_UpdatesHonorAndControl(t *testing.T) {
+	repo, allianceID, hordeID, cleanup := setupPvPRepoTest(t)
+	defer cleanup()
+
+	control, err := repo.GetZoneControl("duskwood")
+	assert.NoError(t, err)
+	assert.Equal(t, "neutral", control.ControllingFaction, "无记录时应为中立")
+
+	winnerID := allianceID
+	enc := &models.PvPEncounter{
+		ZoneID:          "duskwood",
+		AttackerUserID:  allianceID,
+		DefenderUserID:  hordeID,
+		AttackerFaction: "alliance",
+		DefenderFaction: "horde",
+		WinnerUserID:    &winnerID,
+		WinnerFaction:   "alliance",
+		AttackerTeam:    []models.PvPTeamMember{{CharacterID: 1, Name: "alliance_char", Level: 20, MaxHP: 100, RemainingHP: 40}},
+		BattleRounds:    5,
+		HonorReward:     12,
+		BattleLog:       []models.BattleLog{{Message: "测试日志", LogType: "combat"}},
+		CreatedAt:       time.Now(),
+	}
+	participants := []PvPParticipantResult{
+		{UserID: allianceID, Faction: "alliance", Result: PvPResultWin, Honor: 12, Kills: 1, DamageDealt: 100},
+		{UserID: hordeID, Faction: "horde", Result: PvPResultLoss, Deaths: 1, DamageDealt: 60},
+	}
+
+	control, err = repo.RecordEncounter(enc, participants, func(c *models.ZoneFactionControl) {
+		c.AllianceWins++
+		c.ControlScore++
+	})
+	assert.NoError(t, err)
+	assert.NotZero(t, enc.ID)
+	assert.Equal(t, 1, control.ControlScore)
+
+	saved, err := repo.GetZoneControl("duskwood")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, saved.AllianceWins)
+	assert.Equal(t, 1, saved.ControlScore)
+
+	honor, err := repo.GetUserHonor(allianceID)
+	assert.NoError(t, err)
+	assert.Equal(t, 12, honor.TotalHonor)
+	assert.Equal(t, 12, honor.WeeklyHonor)
+	assert.Equal(t, 1, honor.PvPWins)
+	assert.Equal(t, 1, honor.WinStreak)
+	assert.Equal(t, 1, honor.BestWinStreak)
+
+	loser, err := repo.GetUserHonor(hordeID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loser.PvPLosses)
+	assert.Equal(t, 1, loser.TotalDeaths)
+	assert.Equal(t, 0, loser.WinStreak)
+
+	detail, err := repo.GetEncounterByID(enc.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, allianceID, *detail.WinnerUserID)
+	assert.Len(t, detail.AttackerTeam, 1)
+	assert.Len(t, detail.BattleLog, 1)
+
+	list, err := repo.GetUserEncounters(hordeID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Empty(t, list[0].BattleLog, "列表不应包含战斗日志")
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// SalvageCandidate 待分解装备及其状态
+type SalvageCandidate struct {
+	EquipmentID  int
+	ItemID       string
+	ItemName     string
+	Quality      string
+	Level        int
+	Slot         string
+	AffixCount   int
+	HasLegendary bool
+	Equipped     bool
+	Locked       bool
+	Escrowed     bool
+}
+
+// SalvageRepository 装备分解数据仓库
+type SalvageRepository struct{}
+
+// NewSalvageRepository 创建分解仓库
+func NewSalvageRepository() *SalvageRepository {
+	return &SalvageRepository{}
+}
+
+// GetCandidates 获取用户的待分解装备：指定 equipmentIDs 时按ID查询，否则按筛选条件查询背包中的装备
+func (r *SalvageRepository) GetCandidates(ownerID int, equipmentIDs []int, filter *models.SalvageFilter) ([]*SalvageCandidate, error) {
+	query := `
+		SELECT e.id, e.item_id, COALESCE(i.name, e.item_id), e.quality, COALESCE(i.level_required, 1), COALESCE(e.slot, ''),
+		       (CASE WHEN e.prefix_id IS NOT NULL THEN 1 ELSE 0 END) +
+		       (CASE WHEN e.suffix_id IS NOT NULL THEN 1 ELSE 0 END) +
+		       (CASE WHEN e.bonus_affix_1 IS NOT NULL THEN 1 ELSE 0 END) +
+		       (CASE WHEN e.bonus_affix_2 IS NOT NULL THEN 1 ELSE 0 END),
+		       e.legendary_effect_id IS NOT NULL, e.character_id IS NOT NULL, COALESCE(e.is_locked, 0)
+		FROM equipment_instance e
+		LEFT JOIN items i ON i.id = e.item_id
+		WHERE e.owner_id = ?`
+	args := []interface{}{ownerID}
+
+	if len(equipmentIDs) > 0 {
+		query += ` AND e.id IN (?` + strings.Repeat(`, ?`, len(equipmentIDs)-1) + `)`
+		for _, id := range equipmentIDs {
+			args = append(args, id)
+		}
+	} else if filter != nil {
+		query += ` AND e.character_id IS NULL`
+		if len(filter.Qualities) > 0 {
+			query += ` AND e.quality IN (?` + strings.Repeat(`, ?`, len(filter.Qualities)-1) + `)`
+			for _, quality := range filter.Qualities {
+				args = append(args, quality)
+			}
+		}
+		if filter.MaxLevel > 0 {
+			query += ` AND COALESCE(i.level_required, 1) <= ?`
+			args = append(args, filter.MaxLevel)
+		}
+		if filter.Slot != "" {
+			query += ` AND e.slot = ?`
+			args = append(args, filter.Slot)
+		}
+	}
+	query += ` ORDER BY e.id ASC`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := make([]*SalvageCandidate, 0)
+	for rows.Next() {
+		c := &SalvageCandidate{}
+		var hasLegendary, equipped, locked int
+		if err := rows.Scan(&c.EquipmentID, &c.ItemID, &c.ItemName, &c.Quality, &c.Level, &c.Slot,
+			&c.AffixCount, &hasLegendary, &equipped, &locked); err != nil {
+			return nil, err
+		}
+		c.HasLegendary = hasLegendary != 0
+		c.Equipped = equipped != 0
+		c.Locked = locked != 0
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range candidates {
+		if c.Escrowed, err = isEquipmentEscrowed(database.DB, c.EquipmentID); err != nil {
+			return nil, err
+		}
+	}
+	return candidates, nil
+}
+
+// DeleteEquipmentTx 在事务中销毁已分解的装备
+func (r *SalvageRepository) DeleteEquipmentTx(tx *sql.Tx, equipmentID int) error {
+	_, err := tx.Exec(`DELETE FROM equipment_instance WHERE id = ?`, equipmentID)
+	return err
+}
+
+// AddMaterialTx 将分解产出的材料放入角色背包
+func (r *SalvageRepository) AddMaterialTx(tx *sql.Tx, characterID int, itemID string, quantity int) error {
+	return addInventoryItem(tx, characterID, itemID, quantity)
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 登录会话错误
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionRevoked     = errors.New("session has been revoked")
+	ErrSessionExpired     = errors.New("session expired")
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+)
+
+// SessionRepository 登录会话数据仓库
+type SessionRepository struct{}
+
+// NewSessionRepository 创建登录会话仓库
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{}
+}
+
+// Create 保存新会话及其刷新令牌哈希
+func (r *SessionRepository) Create(s *models.AuthSession, refreshTokenHash string) error {
+	_, err := database.DB.Exec(`
+		INSERT INTO auth_sessions (
+			id, user_id, refresh_token_hash, user_agent, ip_address, created_at, last_used_at, expires_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, s.UserID, refreshTokenHash, nullString(s.UserAgent), nullString(s.IPAddress),
+		s.CreatedAt.UTC(), s.LastUsedAt.UTC(), s.ExpiresAt.UTC(),
+	)
+	return err
+}
+
+// Rotate 用旧刷新令牌换新令牌并顺延过期时间
+// 旧令牌已被轮换过又再次出现时视为泄露，整个会话被注销并返回 ErrRefreshTokenReused
+func (r *SessionRepository) Rotate(oldHash, newHash string, now, expiresAt time.Time) (*models.AuthSession, error) {
+	type rotation struct {
+		session *models.AuthSession
+		reused  bool
+	}
+	result, err := WithTransactionResult(func(tx *sql.Tx) (rotation, error) {
+		session, err := r.scanSession(tx.QueryRow(sessionSelect+" WHERE refresh_token_hash = ?", oldHash))
+		if err == sql.ErrNoRows {
+			var id string
+			err = tx.QueryRow(`
+				SELECT id FROM auth_sessions WHERE previous_token_hash = ? AND revoked_at IS NULL`,
+				oldHash,
+			).Scan(&id)
+			if err == sql.ErrNoRows {
+				return rotation{}, ErrSessionNotFound
+			}
+			if err != nil {
+				return rotation{}, err
+			}
+			if _, err := tx.Exec(`UPDATE auth_sessions SET revoked_at = ? WHERE id = ?`, now.UTC(), id); err != nil {
+				return rotation{}, err
+			}
+			return rotation{reused: true}, nil
+		}
+		if err != nil {
+			return rotation{}, err
+		}
+		if session.RevokedAt != nil {
+			return rotation{}, ErrSessionRevoked
+		}
+		if !session.ExpiresAt.After(now) {
+			return rotation{}, ErrSessionExpired
+		}
+
+		if _, err := tx.Exec(`
+			UPDATE auth_sessions
+			SET previous_token_hash = refresh_token_hash, refresh_token_hash = ?, last_used_at = ?, expires_at = ?
+			WHERE id = ?`,
+			newHash, now.UTC(), expiresAt.UTC(), session.ID,
+		); err != nil {
+			return rotation{}, err
+		}
+		session.LastUsedAt = now
+		session.ExpiresAt = expiresAt
+		return rotation{session: session}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return result.session, nil
+}
+
+// IsActive 会话是否未注销且未过期
+func (r *SessionRepository) IsActive(id string, userID int, now time.Time) (bool, error) {
+	if id == "" {
+		return false, nil
+	}
+	var count int
+	err := database.DB.QueryRow(`
+		SELECT COUNT(*) FROM auth_sessions
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?`,
+		id, userID, now.UTC(),
+	).Scan(&count)
+	return count > 0, err
+}
+
+// CountActive 统计用户未注销且未过期的会话数
+func (r *SessionRepository) CountActive(userID int, now time.Time) (int, error) {
+	var count int
+	err := database.DB.QueryRow(`
+		SELECT COUNT(*) FROM auth_sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?`,
+		userID, now.UTC(),
+	).Scan(&count)
+	return count, err
+}
+
+// Revoke 注销用户的单个会话
+func (r *SessionRepository) Revoke(id string, userID int, now time.Time) error {
+	result, err := database.DB.Exec(`
+		UPDATE auth_sessions SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		now.UTC(), id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAll 注销用户的全部会话，返回注销数量
+func (r *SessionRepository) RevokeAll(userID int, now time.Time) (int, error) {
+	result, err := database.DB.Exec(`
+		UPDATE auth_sessions SET revoked_at = ?
+		WHERE user_id = ? AND revoked_at IS NULL`,
+		now.UTC(), userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+const sessionSelect = `
+	SELECT id, user_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+	       created_at, last_used_at, expires_at, revoked_at
+	FROM auth_sessions`
+
+func (r *SessionRepository) scanSession(row rowScanner) (*models.AuthSession, error) {
+	s := &models.AuthSession{}
+	var revokedAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return s, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// StaminaRepository 体力数据仓库
+type StaminaRepository struct{}
+
+// NewStaminaRepository 创建体力仓库
+func NewStaminaRepository() *StaminaRepository {
+	return &StaminaRepository{}
+}
+
+// Get 获取玩家体力（无记录时返回满体力的默认值，不落库）
+func (r *StaminaRepository) Get(userID int, defaultMax int, now time.Time) (*models.UserStamina, error) {
+	return getStamina(database.DB, userID, defaultMax, now)
+}
+
+// Update 在事务中读取玩家体力、执行修改并写回（无记录时以满体力创建）
+// fn 可使用同一事务写入关联数据（如发放溢出金币），返回错误时整体回滚
+func (r *StaminaRepository) Update(userID int, defaultMax int, now time.Time, fn func(*sql.Tx, *models.UserStamina) error) (*models.UserStamina, error) {
+	return WithTransactionResult(func(tx *sql.Tx) (*models.UserStamina, error) {
+		stamina, err := getStamina(tx, userID, defaultMax, now)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(tx, stamina); err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO user_stamina (user_id, current_stamina, max_stamina, last_regen_at, overflow_exp, overflow_gold)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(user_id) DO UPDATE SET
+				current_stamina = excluded.current_stamina,
+				max_stamina = excluded.max_stamina,
+				last_regen_at = excluded.last_regen_at,
+				overflow_exp = excluded.overflow_exp,
+				overflow_gold = excluded.overflow_gold`,
+			stamina.UserID, stamina.CurrentStamina, stamina.MaxStamina, stamina.LastRegenAt.UTC(),
+			stamina.OverflowExp, stamina.OverflowGold,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return stamina, nil
+	})
+}
+
+func getStamina(db dbExecutor, userID int, defaultMax int, now time.Time) (*models.UserStamina, error) {
+	stamina := &models.UserStamina{}
+	var lastRegenAt sql.NullTime
+	err := db.QueryRow(`
+		SELECT user_id, COALESCE(current_stamina, 0), COALESCE(max_stamina, ?), last_regen_at,
+		       COALESCE(overflow_exp, 0), COALESCE(overflow_gold, 0)
+		FROM user_stamina WHERE user_id = ?`, defaultMax, userID,
+	).Scan(
+		&stamina.UserID, &stamina.CurrentStamina, &stamina.MaxStamina, &lastRegenAt,
+		&stamina.OverflowExp, &stamina.OverflowGold,
+	)
+	if err == sql.ErrNoRows {
+		return &models.UserStamina{
+			UserID:         userID,
+			CurrentStamina: defaultMax,
+			MaxStamina:     defaultMax,
+			LastRegenAt:    now.UTC(),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stamina.LastRegenAt = now.UTC()
+	if lastRegenAt.Valid {
+		stamina.LastRegenAt = lastRegenAt.Time.UTC()
+	}
+	return stamina, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaminaRepository_UpdateCreatesAndPersists(t *testing.T) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer database.TeardownTestDB(testDB)
+
+	user, err := NewUserRepository().Create("stamina_user", "hash", "")
+	assert.NoError(t, err)
+
+	repo := NewStaminaRepository()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	// 无记录时返回满体力的默认值
+	stamina, err := repo.Get(user.ID, 100, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, stamina.CurrentStamina)
+	assert.Equal(t, 100, stamina.MaxStamina)
+
+	_, err = repo.Update(user.ID, 100, now, func(_ *sql.Tx, s *models.UserStamina) error {
+		s.CurrentStamina -= 30
+		s.OverflowExp = 15
+		return nil
+	})
+	assert.NoError(t, err)
+
+	stamina, err = repo.Get(user.ID, 100, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 70, stamina.CurrentStamina)
+	assert.Equal(t, 15, stamina.OverflowExp)
+	assert.True(t, stamina.LastRegenAt.Equal(now), "last regen time should be persisted")
+}
@@ -410,16 +410,24 @@ func GetStrategyTemplates() map[string]*models.BattleStrategy {
 				},
 				{
 					ID: "rule_3", Priority: 3, Enabled: true,
+					Condition: models.RuleCondition{
+						Type: "self_hp_percent", Operator: "<", Value: 25,
+						And: []models.RuleCondition{{Type: "item_count", ItemID: "healing_potion", Operator: ">", Value: 0}},
+					},
+					Action: models.RuleAction{Type: "use_item", ItemID: "healing_potion", Comment: "低血量喝治疗药水"},
+				},
+				{
+					ID: "rule_4", Priority: 4, Enabled: true,
 					Condition: models.RuleCondition{Type: "alive_enemy_count", Operator: ">=", Value: 3},
 					Action:    models.RuleAction{Type: "use_skill", SkillID: "whirlwind"},
 				},
 				{
-					ID: "rule_4", Priority: 4, Enabled: true,
+					ID: "rule_5", Priority: 5, Enabled: true,
 					Condition: models.RuleCondition{Type: "target_hp_percent", Operator: "<", Value: 20},
 					Action:    models.RuleAction{Type: "use_skill", SkillID: "execute"},
 				},
 				{
-					ID: "rule_5", Priority: 5, Enabled: true,
+					ID: "rule_6", Priority: 6, Enabled: true,
 					Condition: models.RuleCondition{Type: "target_hp_percent", Operator: "<", Value: 10},
 					Action:    models.RuleAction{Type: "normal_attack", Comment: "残血普攻节省资源"},
 				},
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 交易窗口错误
+var (
+	ErrTradeNotFound       = errors.New("trade not found")
+	ErrTradeNotOpen        = errors.New("trade is no longer open")
+	ErrTradeExpired        = errors.New("trade has expired")
+	ErrTradeNotParticipant = errors.New("you are not part of this trade")
+	ErrTradeAlreadyOpen    = errors.New("player already has an open trade")
+	ErrTradeItemNotFound   = errors.New("item is not in the trade")
+	ErrTradeItemDuplicate  = errors.New("item is already in the trade")
+)
+
+// TradeRepository 交易窗口数据仓库
+type TradeRepository struct{}
+
+// NewTradeRepository 创建交易仓库
+func NewTradeRepository() *TradeRepository {
+	return &TradeRepository{}
+}
+
+// CreateTrade 创建交易窗口（任一方已有未结束的交易时返回 ErrTradeAlreadyOpen）
+func (r *TradeRepository) CreateTrade(initiatorID, partnerID int, expiresAt, now time.Time) (*models.TradeSession, error) {
+	return WithTransactionResult(func(tx *sql.Tx) (*models.TradeSession, error) {
+		var count int
+		err := tx.QueryRow(`
+			SELECT COUNT(*) FROM trade_sessions
+			WHERE status = 'open' AND expires_at > ?
+			  AND (initiator_id IN (?, ?) OR partner_id IN (?, ?))
+		`, now.UTC(), initiatorID, partnerID, initiatorID, partnerID).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrTradeAlreadyOpen
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO trade_sessions (initiator_id, partner_id, status, created_at, updated_at, expires_at)
+			VALUES (?, ?, 'open', ?, ?, ?)
+		`, initiatorID, partnerID, now.UTC(), now.UTC(), expiresAt.UTC())
+		if err != nil {
+			return nil, err
+		}
+		id, _ := result.LastInsertId()
+		return getTrade(tx, int(id))
+	})
+}
+
+// GetTrade 获取交易窗口（含双方物品）
+func (r *TradeRepository) GetTrade(id int) (*models.TradeSession, error) {
+	return getTrade(database.DB, id)
+}
+
+// GetTradeTx 在事务中获取交易窗口
+func (r *TradeRepository) GetTradeTx(tx *sql.Tx, id int) (*models.TradeSession, error) {
+	return getTrade(tx, id)
+}
+
+// GetOpenTradesByUser 获取用户未结束的交易窗口
+func (r *TradeRepository) GetOpenTradesByUser(userID int, now time.Time) ([]*models.TradeSession, error) {
+	rows, err := database.DB.Query(`
+		SELECT id FROM trade_sessions
+		WHERE status = 'open' AND expires_at > ? AND (initiator_id = ? OR partner_id = ?)
+		ORDER BY updated_at DESC, id DESC
+	`, now.UTC(), userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	trades := make([]*models.TradeSession, 0, len(ids))
+	for _, id := range ids {
+		trade, err := getTrade(database.DB, id)
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+	return trades, nil
+}
+
+// AddItemTx 放入装备
+func (r *TradeRepository) AddItemTx(tx *sql.Tx, tradeID, userID, equipmentID int, now time.Time) error {
+	result, err := tx.Exec(`
+		INSERT INTO trade_session_items (trade_id, user_id, equipment_id, added_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(trade_id, equipment_id) DO NOTHING
+	`, tradeID, userID, equipmentID, now.UTC())
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrTradeItemDuplicate
+	}
+	return nil
+}
+
+// RemoveItemTx 取回装备
+func (r *TradeRepository) RemoveItemTx(tx *sql.Tx, tradeID, userID, equipmentID int) error {
+	result, err := tx.Exec(`
+		DELETE FROM trade_session_items WHERE trade_id = ? AND user_id = ? AND equipment_id = ?
+	`, tradeID, userID, equipmentID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrTradeItemNotFound
+	}
+	return nil
+}
+
+// SetGoldTx 设置一方放入的金币
+func (r *TradeRepository) SetGoldTx(tx *sql.Tx, trade *models.TradeSession, userID, amount int) error {
+	column := "partner_gold"
+	if trade.InitiatorID == userID {
+		column = "initiator_gold"
+	}
+	_, err := tx.Exec(`UPDATE trade_sessions SET `+column+` = ? WHERE id = ?`, amount, trade.ID)
+	return err
+}
+
+// TouchTx 交易内容变更：重置双方确认并顺延超时时间
+func (r *TradeRepository) TouchTx(tx *sql.Tx, tradeID int, expiresAt, now time.Time) error {
+	result, err := tx.Exec(`
+		UPDATE trade_sessions
+		SET initiator_confirmed = 0, partner_confirmed = 0, updated_at = ?, expires_at = ?
+		WHERE id = ? AND status = 'open'
+	`, now.UTC(), expiresAt.UTC(), tradeID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrTradeNotOpen
+	}
+	return nil
+}
+
+// ConfirmTx 一方确认交易
+func (r *TradeRepository) ConfirmTx(tx *sql.Tx, trade *models.TradeSession, userID int, now time.Time) error {
+	column := "partner_confirmed"
+	if trade.InitiatorID == userID {
+		column = "initiator_confirmed"
+	}
+	_, err := tx.Exec(`UPDATE trade_sessions SET `+column+` = 1, updated_at = ? WHERE id = ?`, now.UTC(), trade.ID)
+	return err
+}
+
+// CloseTradeTx 结束交易窗口（完成/取消/过期）
+func (r *TradeRepository) CloseTradeTx(tx *sql.Tx, tradeID int, status string, now time.Time) error {
+	result, err := tx.Exec(`
+		UPDATE trade_sessions SET status = ?, updated_at = ?, closed_at = ?
+		WHERE id = ? AND status = 'open'
+	`, status, now.UTC(), now.UTC(), tradeID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrTradeNotOpen
+	}
+	return nil
+}
+
+// ExpireTrades 将超时的交易窗口标记为过期，返回过期的交易
+func (r *TradeRepository) ExpireTrades(now time.Time) ([]*models.TradeSession, error) {
+	return WithTransactionResult(func(tx *sql.Tx) ([]*models.TradeSession, error) {
+		rows, err := tx.Query(`
+			SELECT id FROM trade_sessions WHERE status = 'open' AND expires_at <= ?
+		`, now.UTC())
+		if err != nil {
+			return nil, err
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		expired := make([]*models.TradeSession, 0, len(ids))
+		for _, id := range ids {
+			if err := r.CloseTradeTx(tx, id, "expired", now); err != nil {
+				return nil, err
+			}
+			trade, err := getTrade(tx, id)
+			if err != nil {
+				return nil, err
+			}
+			expired = append(expired, trade)
+		}
+		return expired, nil
+	})
+}
+
+// rowsQuerier 支持多行查询的数据库接口（*sql.DB 和 *sql.Tx）
+type rowsQuerier interface {
+	dbExecutor
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func getTrade(db rowsQuerier, id int) (*models.TradeSession, error) {
+	trade := &models.TradeSession{}
+	var initiatorConfirmed, partnerConfirmed int
+	var closedAt sql.NullTime
+	err := db.QueryRow(`
+		SELECT t.id, t.initiator_id, COALESCE(ui.username, ''), t.partner_id, COALESCE(up.username, ''),
+		       t.status, COALESCE(t.initiator_gold, 0), COALESCE(t.partner_gold, 0),
+		       COALESCE(t.initiator_confirmed, 0), COALESCE(t.partner_confirmed, 0),
+		       t.created_at, t.updated_at, t.expires_at, t.closed_at
+		FROM trade_sessions t
+		LEFT JOIN users ui ON ui.id = t.initiator_id
+		LEFT JOIN users up ON up.id = t.partner_id
+		WHERE t.id = ?`, id,
+	).Scan(
+		&trade.ID, &trade.InitiatorID, &trade.InitiatorName, &trade.PartnerID, &trade.PartnerName,
+		&trade.Status, &trade.InitiatorGold, &trade.PartnerGold,
+		&initiatorConfirmed, &partnerConfirmed,
+		&trade.CreatedAt, &trade.UpdatedAt, &trade.ExpiresAt, &closedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrTradeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	trade.InitiatorConfirmed = intToBool(initiatorConfirmed)
+	trade.PartnerConfirmed = intToBool(partnerConfirmed)
+	if closedAt.Valid {
+		trade.ClosedAt = &closedAt.Time
+	}
+
+	rows, err := db.Query(`
+		SELECT ti.id, ti.trade_id, ti.user_id, ti.equipment_id, e.item_id, COALESCE(i.name, e.item_id),
+		       e.slot, e.quality
+		FROM trade_session_items ti
+		JOIN equipment_instance e ON e.id = ti.equipment_id
+		LEFT JOIN items i ON i.id = e.item_id
+		WHERE ti.trade_id = ?
+		ORDER BY ti.id ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trade.Items = make([]*models.TradeItem, 0)
+	for rows.Next() {
+		item := &models.TradeItem{}
+		if err := rows.Scan(&item.ID, &item.TradeID, &item.UserID, &item.EquipmentID, &item.ItemID, &item.ItemName,
+			&item.Slot, &item.Quality); err != nil {
+			return nil, err
+		}
+		trade.Items = append(trade.Items, item)
+	}
+	return trade, rows.Err()
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTradeRepoTest(t *testing.T) (*TradeRepository, int, int, func()) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = testDB.Exec(`
+		INSERT INTO items (id, name, type, quality, slot, level_required)
+		VALUES ('trade_ring', '交易戒指', 'equipment', 'rare', 'ring', 10)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert item: %v", err)
+	}
+
+	userRepo := NewUserRepository()
+	initiator, err := userRepo.Create("initiator", "hash", "")
+	if err != nil {
+		t.Fatalf("Failed to create initiator: %v", err)
+	}
+	partner, err := userRepo.Create("partner", "hash", "")
+	if err != nil {
+		t.Fatalf("Failed to create partner: %v", err)
+	}
+	cleanup := func() {
+		database.TeardownTestDB(testDB)
+	}
+	return NewTradeRepository(), initiator.ID, partner.ID, cleanup
+}
+
+func TestTradeRepository_ItemsGoldAndConfirmations(t *testing.T) {
+	repo, initiatorID, partnerID, cleanup := setupTradeRepoTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	trade, err := repo.CreateTrade(initiatorID, partnerID, now.Add(10*time.Minute), now)
+	assert.NoError(t, err)
+	assert.Equal(t, "open", trade.Status)
+	assert.Equal(t, "partner", trade.PartnerName)
+
+	_, err = repo.CreateTrade(partnerID, initiatorID, now.Add(10*time.Minute), now)
+	assert.ErrorIs(t, err, ErrTradeAlreadyOpen, "同一玩家同时只能有一个交易窗口")
+
+	equipment, err := NewEquipmentRepository().Create(&models.EquipmentInstance{
+		ItemID: "trade_ring", OwnerID: initiatorID, Slot: "ring", Quality: "rare", EvolutionStage: 1,
+	})
+	assert.NoError(t, err)
+
+	err = WithTransaction(func(tx *sql.Tx) error {
+		if err := repo.AddItemTx(tx, trade.ID, initiatorID, equipment.ID, now); err != nil {
+			return err
+		}
+		if err := repo.SetGoldTx(tx, trade, partnerID, 250); err != nil {
+			return err
+		}
+		return repo.ConfirmTx(tx, trade, initiatorID, now)
+	})
+	assert.NoError(t, err)
+
+	err = WithTransaction(func(tx *sql.Tx) error {
+		return repo.AddItemTx(tx, trade.ID, initiatorID, equipment.ID, now)
+	})
+	assert.ErrorIs(t, err, ErrTradeItemDuplicate)
+
+	current, err := repo.GetTrade(trade.ID)
+	assert.NoError(t, err)
+	assert.Len(t, current.Items, 1)
+	assert.Equal(t, "交易戒指", current.Items[0].ItemName)
+	assert.Equal(t, 250, current.PartnerGold)
+	assert.True(t, current.InitiatorConfirmed)
+	assert.False(t, current.PartnerConfirmed)
+
+	err = WithTransaction(func(tx *sql.Tx) error {
+		return repo.TouchTx(tx, trade.ID, now.Add(20*time.Minute), now)
+	})
+	assert.NoError(t, err)
+	current, err = repo.GetTrade(trade.ID)
+	assert.NoError(t, err)
+	assert.False(t, current.InitiatorConfirmed, "交易内容变更后应重置确认")
+
+	err = WithTransaction(func(tx *sql.Tx) error {
+		return repo.RemoveItemTx(tx, trade.ID, partnerID, equipment.ID)
+	})
+	assert.ErrorIs(t, err, ErrTradeItemNotFound, "只能取回自己放入的装备")
+}
+
+func TestTradeRepository_ExpireTrades(t *testing.T) {
+	repo, initiatorID, partnerID, cleanup := setupTradeRepoTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	trade, err := repo.CreateTrade(initiatorID, partnerID, now.Add(10*time.Minute), now)
+	assert.NoError(t, err)
+
+	open, err := repo.GetOpenTradesByUser(partnerID, now)
+	assert.NoError(t, err)
+	assert.Len(t, open, 1)
+
+	expired, err := repo.ExpireTrades(now)
+	assert.NoError(t, err)
+	assert.Empty(t, expired, "未超时的交易不应过期")
+
+	expired, err = repo.ExpireTrades(now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, trade.ID, expired[0].ID)
+	assert.Equal(t, "expired", expired[0].Status)
+
+	open, err = repo.GetOpenTradesByUser(partnerID, now)
+	assert.NoError(t, err)
+	assert.Empty(t, open)
+
+	_, err = repo.CreateTrade(initiatorID, partnerID, now.Add(10*time.Minute), now)
+	assert.NoError(t, err, "过期后可以重新发起交易")
+}
@@ -69,6 +69,12 @@ func WithTransactionResult[T any](fn func(*sql.Tx) (T, error)) (T, error) {
 	return result, nil
 }
 
+// dbExecutor 数据库执行接口（兼容 *sql.DB 和 *sql.Tx，便于在事务中复用写入逻辑）
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 
 
 
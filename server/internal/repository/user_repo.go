@@ -2,12 +2,19 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 
 	"text-wow/internal/database"
 	"text-wow/internal/models"
 )
 
+// 用户错误
+var (
+	ErrInsufficientGold = errors.New("insufficient gold")
+	ErrUserNotFound     = errors.New("user not found")
+)
+
 // UserRepository 用户数据仓库
 type UserRepository struct{}
 
@@ -105,6 +112,24 @@ func (r *UserRepository) GetPasswordHash(username string) (int, string, error) {
 	return id, hash, err
 }
 
+// GetRole 获取用户角色
+func (r *UserRepository) GetRole(id int) (string, error) {
+	var role string
+	err := database.DB.QueryRow(`
+		SELECT COALESCE(role, 'player') FROM users WHERE id = ?`, id,
+	).Scan(&role)
+	return role, err
+}
+
+// SetRole 设置用户角色
+func (r *UserRepository) SetRole(id int, role string) error {
+	_, err := database.DB.Exec(`
+		UPDATE users SET role = ? WHERE id = ?`,
+		role, id,
+	)
+	return err
+}
+
 // UsernameExists 检查用户名是否存在
 func (r *UserRepository) UsernameExists(username string) (bool, error) {
 	var count int
@@ -123,15 +148,6 @@ func (r *UserRepository) UpdateLastLogin(id int) error {
 	return err
 }
 
-// UpdateGold 更新金币
-func (r *UserRepository) UpdateGold(id int, gold int) error {
-	_, err := database.DB.Exec(`
-		UPDATE users SET gold = gold + ?, total_gold_gained = total_gold_gained + ? WHERE id = ?`,
-		gold, gold, id,
-	)
-	return err
-}
-
 // UpdateZone 更新当前区域
 func (r *UserRepository) UpdateZone(id int, zoneID string) error {
 	_, err := database.DB.Exec(`
@@ -269,40 +269,6 @@ func TestUserRepository_UpdateLastLogin(t *testing.T) {
 	}
 }
 
-// ═══════════════════════════════════════════════════════════
-// UpdateGold 测试
-// ═══════════════════════════════════════════════════════════
-
-func TestUserRepository_UpdateGold(t *testing.T) {
-	repo, cleanup := setupUserRepoTest(t)
-	defer cleanup()
-
-	passwordHash, _ := auth.HashPassword("testpassword")
-	created, _ := repo.Create("golduser", passwordHash, "")
-
-	// 初始金币为0
-	user, _ := repo.GetByID(created.ID)
-	if user.Gold != 0 {
-		t.Errorf("Expected initial gold 0, got %d", user.Gold)
-	}
-
-	// 增加金币
-	err := repo.UpdateGold(created.ID, 100)
-	if err != nil {
-		t.Fatalf("UpdateGold failed: %v", err)
-	}
-
-	user, _ = repo.GetByID(created.ID)
-	if user.Gold != 100 {
-		t.Errorf("Expected gold 100, got %d", user.Gold)
-	}
-
-	// 验证total_gold_gained也增加了
-	if user.TotalGoldGained != 100 {
-		t.Errorf("Expected TotalGoldGained 100, got %d", user.TotalGoldGained)
-	}
-}
-
 // ═══════════════════════════════════════════════════════════
 // UpdateZone 测试
 // ═══════════════════════════════════════════════════════════
@@ -0,0 +1,464 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"text-wow/internal/database"
+	"text-wow/internal/models"
+)
+
+// 商人错误
+var (
+	ErrVendorNotFound        = errors.New("vendor not found")
+	ErrVendorItemNotFound    = errors.New("vendor does not sell this item")
+	ErrVendorOutOfStock      = errors.New("vendor is out of stock")
+	ErrInventoryItemNotFound = errors.New("inventory item not found")
+	ErrInsufficientQuantity  = errors.New("not enough items")
+	ErrItemNotSellable       = errors.New("item cannot be sold to vendors")
+	ErrBuybackNotFound       = errors.New("buyback entry not found")
+	ErrBuybackNotAvailable   = errors.New("buyback entry is no longer available")
+	ErrVendorNoCharacter     = errors.New("no character to receive the item")
+)
+
+// VendorRepository NPC商人数据仓库
+type VendorRepository struct{}
+
+// NewVendorRepository 创建商人仓库
+func NewVendorRepository() *VendorRepository {
+	return &VendorRepository{}
+}
+
+// SellableItem 可出售给商人的物品信息
+type SellableItem struct {
+	ItemID      string
+	ItemName    string
+	Quality     string
+	SellPrice   int // 单价（未计算品质倍率）
+	CharacterID int // 背包物品所在角色（装备为0）
+	Quantity    int // 背包中的数量（装备为1）
+}
+
+const vendorColumns = `
+	SELECT id, name, COALESCE(title, ''), zone_id, COALESCE(description, ''),
+	       COALESCE(restock_interval_minutes, 60), last_restock_at
+	FROM vendors`
+
+const vendorItemColumns = `
+	SELECT vi.vendor_id, vi.item_id, i.name, i.type, COALESCE(i.quality, 'common'),
+	       COALESCE(vi.price, i.buy_price, 0), vi.max_stock, vi.current_stock, COALESCE(i.stackable, 0)
+	FROM vendor_items vi
+	JOIN items i ON i.id = vi.item_id`
+
+// GetZoneVendors 获取区域内的商人（含出售列表）
+func (r *VendorRepository) GetZoneVendors(zoneID string) ([]*models.Vendor, error) {
+	rows, err := database.DB.Query(vendorColumns+` WHERE zone_id = ? AND is_active = 1 ORDER BY id ASC`, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	vendors, err := scanVendors(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, vendor := range vendors {
+		if vendor.Items, err = getVendorItems(database.DB, vendor.ID); err != nil {
+			return nil, err
+		}
+	}
+	return vendors, nil
+}
+
+// GetVendor 获取商人（含出售列表）
+func (r *VendorRepository) GetVendor(id string) (*models.Vendor, error) {
+	return getVendor(database.DB, id)
+}
+
+// GetVendorTx 在事务中获取商人
+func (r *VendorRepository) GetVendorTx(tx *sql.Tx, id string) (*models.Vendor, error) {
+	return getVendor(tx, id)
+}
+
+// TakeStockTx 扣减限量商品库存（无限商品不做处理），库存不足返回 ErrVendorOutOfStock
+func (r *VendorRepository) TakeStockTx(tx *sql.Tx, vendorID, itemID string, quantity int) error {
+	result, err := tx.Exec(`
+		UPDATE vendor_items SET current_stock = current_stock - ?
+		WHERE vendor_id = ? AND item_id = ? AND max_stock IS NOT NULL AND current_stock >= ?
+	`, quantity, vendorID, itemID, quantity)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return nil
+	}
+
+	var maxStock sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT max_stock FROM vendor_items WHERE vendor_id = ? AND item_id = ?
+	`, vendorID, itemID).Scan(&maxStock)
+	if err == sql.ErrNoRows {
+		return ErrVendorItemNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if maxStock.Valid {
+		return ErrVendorOutOfStock
+	}
+	return nil
+}
+
+// GrantItemTx 发放物品：装备生成新的装备实例放入背包，其他物品放入角色背包
+func (r *VendorRepository) GrantItemTx(tx *sql.Tx, userID, characterID int, itemID string, quantity int) (*int, error) {
+	var itemType, slot, quality string
+	err := tx.QueryRow(`
+		SELECT type, COALESCE(slot, ''), COALESCE(quality, 'common') FROM items WHERE id = ?
+	`, itemID).Scan(&itemType, &slot, &quality)
+	if err == sql.ErrNoRows {
+		return nil, ErrVendorItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if itemType == "equipment" {
+		equipment, err := createEquipment(tx, &models.EquipmentInstance{
+			ItemID:         itemID,
+			OwnerID:        userID,
+			Slot:           slot,
+			Quality:        quality,
+			EvolutionStage: 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &equipment.ID, nil
+	}
+
+	if err := checkCharacterOwner(tx, userID, characterID); err != nil {
+		return nil, err
+	}
+	return nil, addInventoryItem(tx, characterID, itemID, quantity)
+}
+
+// GetInventorySellableTx 获取背包物品的出售信息（校验物品属于该用户）
+func (r *VendorRepository) GetInventorySellableTx(tx *sql.Tx, userID, inventoryID int) (*SellableItem, error) {
+	item := &SellableItem{}
+	var owner int
+	err := tx.QueryRow(`
+		SELECT inv.item_id, i.name, COALESCE(i.quality, 'common'), COALESCE(i.sell_price, 0),
+		       inv.character_id, COALESCE(inv.quantity, 1), c.user_id
+		FROM inventory inv
+		JOIN characters c ON c.id = inv.character_id
+		JOIN items i ON i.id = inv.item_id
+		WHERE inv.id = ?`, inventoryID,
+	).Scan(&item.ItemID, &item.ItemName, &item.Quality, &item.SellPrice, &item.CharacterID, &item.Quantity, &owner)
+	if err == sql.ErrNoRows || (err == nil && owner != userID) {
+		return nil, ErrInventoryItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// RemoveInventoryItemTx 从背包移除指定数量的物品（数量用尽时删除该格）
+func (r *VendorRepository) RemoveInventoryItemTx(tx *sql.Tx, inventoryID, quantity int) error {
+	result, err := tx.Exec(`
+		UPDATE inventory SET quantity = quantity - ? WHERE id = ? AND quantity > ?
+	`, quantity, inventoryID, quantity)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return nil
+	}
+	result, err = tx.Exec(`DELETE FROM inventory WHERE id = ? AND quantity = ?`, inventoryID, quantity)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrInsufficientQuantity
+	}
+	return nil
+}
+
+// GetEquipmentSellableTx 获取装备的出售信息（需先通过 CheckTradableTx 校验）
+func (r *VendorRepository) GetEquipmentSellableTx(tx *sql.Tx, equipmentID int) (*SellableItem, error) {
+	item := &SellableItem{Quantity: 1}
+	err := tx.QueryRow(`
+		SELECT e.item_id, COALESCE(i.name, e.item_id), e.quality, COALESCE(i.sell_price, 0)
+		FROM equipment_instance e
+		LEFT JOIN items i ON i.id = e.item_id
+		WHERE e.id = ?`, equipmentID,
+	).Scan(&item.ItemID, &item.ItemName, &item.Quality, &item.SellPrice)
+	if err == sql.ErrNoRows {
+		return nil, ErrEquipmentNotOwned
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// CreateBuybackTx 记录卖出物品，供回购
+func (r *VendorRepository) CreateBuybackTx(tx *sql.Tx, buyback *models.VendorBuyback) (*models.VendorBuyback, error) {
+	result, err := tx.Exec(`
+		INSERT INTO vendor_buyback (user_id, character_id, item_id, quantity, equipment_id, price, status, sold_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, 'available', ?, ?)
+	`, buyback.UserID, buyback.CharacterID, buyback.ItemID, buyback.Quantity, buyback.EquipmentID, buyback.Price,
+		buyback.SoldAt.UTC(), buyback.ExpiresAt.UTC())
+	if err != nil {
+		return nil, err
+	}
+	id, _ := result.LastInsertId()
+	return getBuyback(tx, int(id))
+}
+
+// GetBuybackTx 在事务中获取回购记录
+func (r *VendorRepository) GetBuybackTx(tx *sql.Tx, id int) (*models.VendorBuyback, error) {
+	return getBuyback(tx, id)
+}
+
+// GetBuybackList 获取用户可回购的物品（最近卖出的在前）
+func (r *VendorRepository) GetBuybackList(userID int, now time.Time) ([]*models.VendorBuyback, error) {
+	rows, err := database.DB.Query(buybackColumns+`
+		WHERE b.user_id = ? AND b.status = 'available' AND b.expires_at > ?
+		ORDER BY b.sold_at DESC, b.id DESC`, userID, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*models.VendorBuyback, 0)
+	for rows.Next() {
+		buyback, err := scanBuyback(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, buyback)
+	}
+	return list, rows.Err()
+}
+
+// GetOverflowBuybackIDsTx 获取超出回购栏容量的旧记录
+func (r *VendorRepository) GetOverflowBuybackIDsTx(tx *sql.Tx, userID, keep int) ([]int, error) {
+	rows, err := tx.Query(`
+		SELECT id FROM vendor_buyback
+		WHERE user_id = ? AND status = 'available'
+		ORDER BY sold_at DESC, id DESC
+		LIMIT -1 OFFSET ?
+	`, userID, keep)
+	if err != nil {
+		return nil, err
+	}
+	return scanBuybackIDs(rows)
+}
+
+// GetDueBuybackIDs 获取已过期但仍可回购的记录
+func (r *VendorRepository) GetDueBuybackIDs(now time.Time) ([]int, error) {
+	rows, err := database.DB.Query(`
+		SELECT id FROM vendor_buyback WHERE status = 'available' AND expires_at <= ? ORDER BY expires_at ASC
+	`, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return scanBuybackIDs(rows)
+}
+
+// CloseBuybackTx 结束回购记录（bought_back/expired）
+func (r *VendorRepository) CloseBuybackTx(tx *sql.Tx, id int, status string, now time.Time) error {
+	result, err := tx.Exec(`
+		UPDATE vendor_buyback SET status = ?, closed_at = ? WHERE id = ? AND status = 'available'
+	`, status, now.UTC(), id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrBuybackNotAvailable
+	}
+	return nil
+}
+
+// ExpireBuybackTx 回购过期：卖出的装备由商人回收销毁
+func (r *VendorRepository) ExpireBuybackTx(tx *sql.Tx, id int, now time.Time) error {
+	buyback, err := getBuyback(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.CloseBuybackTx(tx, id, "expired", now); err != nil {
+		return err
+	}
+	if buyback.EquipmentID != nil {
+		if _, err := tx.Exec(`DELETE FROM equipment_instance WHERE id = ?`, *buyback.EquipmentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestockVendors 为到达补货间隔的商人恢复限量商品库存，返回补货的商人数
+func (r *VendorRepository) RestockVendors(now time.Time) (int, error) {
+	rows, err := database.DB.Query(vendorColumns + ` WHERE is_active = 1`)
+	if err != nil {
+		return 0, err
+	}
+	vendors, err := scanVendors(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	restocked := 0
+	for _, vendor := range vendors {
+		interval := time.Duration(vendor.RestockIntervalMinutes) * time.Minute
+		if vendor.LastRestockAt != nil && now.Before(vendor.LastRestockAt.Add(interval)) {
+			continue
+		}
+		err := WithTransaction(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`
+				UPDATE vendor_items SET current_stock = max_stock
+				WHERE vendor_id = ? AND max_stock IS NOT NULL
+			`, vendor.ID); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`UPDATE vendors SET last_restock_at = ? WHERE id = ?`, now.UTC(), vendor.ID)
+			return err
+		})
+		if err != nil {
+			return restocked, err
+		}
+		restocked++
+	}
+	return restocked, nil
+}
+
+// isEquipmentSoldToVendor 装备是否已卖给商人且仍在回购期内
+func isEquipmentSoldToVendor(db dbExecutor, equipmentID int) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM vendor_buyback WHERE equipment_id = ? AND status = 'available'
+	`, equipmentID).Scan(&count)
+	return count > 0, err
+}
+
+// checkCharacterOwner 校验角色属于该用户
+func checkCharacterOwner(db dbExecutor, userID, characterID int) error {
+	var owner int
+	err := db.QueryRow(`SELECT user_id FROM characters WHERE id = ?`, characterID).Scan(&owner)
+	if err == sql.ErrNoRows || (err == nil && owner != userID) {
+		return ErrVendorNoCharacter
+	}
+	return err
+}
+
+func getVendor(db rowsQuerier, id string) (*models.Vendor, error) {
+	rows, err := db.Query(vendorColumns+` WHERE id = ? AND is_active = 1`, id)
+	if err != nil {
+		return nil, err
+	}
+	vendors, err := scanVendors(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(vendors) == 0 {
+		return nil, ErrVendorNotFound
+	}
+	vendor := vendors[0]
+	if vendor.Items, err = getVendorItems(db, vendor.ID); err != nil {
+		return nil, err
+	}
+	return vendor, nil
+}
+
+func getVendorItems(db rowsQuerier, vendorID string) ([]*models.VendorItem, error) {
+	rows, err := db.Query(vendorItemColumns+` WHERE vi.vendor_id = ? ORDER BY vi.sort_order ASC, vi.id ASC`, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*models.VendorItem, 0)
+	for rows.Next() {
+		item := &models.VendorItem{}
+		var maxStock, stock sql.NullInt64
+		var stackable int
+		if err := rows.Scan(&item.VendorID, &item.ItemID, &item.ItemName, &item.ItemType, &item.Quality,
+			&item.Price, &maxStock, &stock, &stackable); err != nil {
+			return nil, err
+		}
+		if maxStock.Valid {
+			item.MaxStock = nullIntPtr(maxStock)
+			current := 0
+			if stock.Valid {
+				current = int(stock.Int64)
+			}
+			item.Stock = &current
+		}
+		item.Stackable = intToBool(stackable)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func scanVendors(rows *sql.Rows) ([]*models.Vendor, error) {
+	defer rows.Close()
+	var vendors []*models.Vendor
+	for rows.Next() {
+		vendor := &models.Vendor{}
+		var lastRestockAt sql.NullTime
+		if err := rows.Scan(&vendor.ID, &vendor.Name, &vendor.Title, &vendor.ZoneID, &vendor.Description,
+			&vendor.RestockIntervalMinutes, &lastRestockAt); err != nil {
+			return nil, err
+		}
+		if lastRestockAt.Valid {
+			vendor.LastRestockAt = &lastRestockAt.Time
+		}
+		vendors = append(vendors, vendor)
+	}
+	return vendors, rows.Err()
+}
+
+const buybackColumns = `
+	SELECT b.id, b.user_id, b.character_id, b.item_id, COALESCE(i.name, b.item_id), b.quantity, b.equipment_id,
+	       COALESCE(e.quality, i.quality, 'common'), b.price, b.status, b.sold_at, b.expires_at, b.closed_at
+	FROM vendor_buyback b
+	LEFT JOIN items i ON i.id = b.item_id
+	LEFT JOIN equipment_instance e ON e.id = b.equipment_id`
+
+func getBuyback(db dbExecutor, id int) (*models.VendorBuyback, error) {
+	buyback, err := scanBuyback(db.QueryRow(buybackColumns+` WHERE b.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrBuybackNotFound
+	}
+	return buyback, err
+}
+
+func scanBuyback(row rowScanner) (*models.VendorBuyback, error) {
+	buyback := &models.VendorBuyback{}
+	var characterID, equipmentID sql.NullInt64
+	var closedAt sql.NullTime
+	err := row.Scan(&buyback.ID, &buyback.UserID, &characterID, &buyback.ItemID, &buyback.ItemName, &buyback.Quantity,
+		&equipmentID, &buyback.Quality, &buyback.Price, &buyback.Status, &buyback.SoldAt, &buyback.ExpiresAt, &closedAt)
+	if err != nil {
+		return nil, err
+	}
+	buyback.CharacterID = nullIntPtr(characterID)
+	buyback.EquipmentID = nullIntPtr(equipmentID)
+	if closedAt.Valid {
+		buyback.ClosedAt = &closedAt.Time
+	}
+	return buyback, nil
+}
+
+func scanBuybackIDs(rows *sql.Rows) ([]int, error) {
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
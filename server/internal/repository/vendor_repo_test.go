@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"text-wow/internal/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupVendorRepoTest(t *testing.T) (*VendorRepository, func()) {
+	testDB, err := database.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = testDB.Exec(`
+		INSERT INTO items (id, name, type, quality, stackable, max_stack, sell_price, buy_price) VALUES
+			('vendor_potion', '商人药水', 'consumable', 'common', 1, 20, 1, 5),
+			('vendor_rare', '稀有药水', 'consumable', 'rare', 1, 20, 5, 30);
+		INSERT INTO vendors (id, name, zone_id, restock_interval_minutes) VALUES
+			('test_vendor', '测试商人', 'elwynn', 30);
+		INSERT INTO vendor_items (vendor_id, item_id, price, max_stock, current_stock, sort_order) VALUES
+			('test_vendor', 'vendor_potion', NULL, NULL, NULL, 1),
+			('test_vendor', 'vendor_rare', 40, 3, 3, 2);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert vendor data: %v", err)
+	}
+
+	cleanup := func() {
+		database.TeardownTestDB(testDB)
+	}
+	return NewVendorRepository(), cleanup
+}
+
+// ═══════════════════════════════════════════════════════════
+// 库存与补货测试
+// ═══════════════════════════════════════════════════════════
+
+func TestVendorRepository_StockAndRestock(t *testing.T) {
+	repo, cleanup := setupVendorRepoTest(t)
+	defer cleanup()
+
+	vendors, err := repo.GetZoneVendors("elwynn")
+	assert.NoError(t, err)
+	assert.Len(t, vendors, 1)
+	assert.Len(t, vendors[0].Items, 2)
+	assert.Equal(t, 5, vendors[0].Items[0].Price, "未配置售价时使用物品的buy_price")
+	assert.Nil(t, vendors[0].Items[0].Stock, "无限商品不显示库存")
+	assert.Equal(t, 40, vendors[0].Items[1].Price)
+
+	err = WithTransaction(func(tx *sql.Tx) error {
+		if err := repo.TakeStockTx(tx, "test_vendor", "vendor_potion", 50); err != nil {
+			return err
+		}
+		return repo.TakeStockTx(tx, "test_vendor", "vendor_rare", 2)
+	})
+	assert.NoError(t, err)
+	err = WithTransaction(func(tx *sql.Tx) error {
+		return repo.TakeStockTx(tx, "test_vendor", "vendor_rare", 2)
+	})
+	assert.ErrorIs(t, err, ErrVendorOutOfStock)
+	err = WithTransaction(func(tx *sql.Tx) error {
+		return repo.TakeStockTx(tx, "test_vendor", "missing_item", 1)
+	})
+	assert.ErrorIs(t, err, ErrVendorItemNotFound)
+
+	now := time.Now()
+	count, err := repo.RestockVendors(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count, "从未补货的商人立即补货")
+
+	vendor, err := repo.GetVendor("test_vendor")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *vendor.Items[1].Stock)
+
+	count, err = repo.RestockVendors(now.Add(10 * time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count, "未到补货间隔")
+	count, err = repo.RestockVendors(now.Add(31 * time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
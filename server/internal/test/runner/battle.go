@@ -783,7 +783,12 @@ func (tr *TestRunner) setBattleResult(isVictory bool, char *models.Character) {
 				userRepo := repository.NewUserRepository()
 				if user, err := userRepo.GetByID(char.UserID); err == nil && user != nil {
 					newGold := user.Gold + goldGain
-					userRepo.UpdateGold(char.UserID, newGold)
+					repository.NewGoldLedgerRepository().Apply(repository.GoldChange{
+						UserID:        char.UserID,
+						Delta:         goldGain,
+						Reason:        repository.GoldReasonTestRunner,
+						CountAsGained: true,
+					})
 					tr.safeSetContext("character.gold", newGold)
 					tr.context.Variables["character.gold"] = newGold
 					tr.safeSetContext("character.gold_gained", goldGain)
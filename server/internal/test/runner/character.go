@@ -1,6 +1,8 @@
 package runner
 
 import (
+	"database/sql"
+
 	"fmt"
 
 	"regexp"
@@ -9,8 +11,6 @@ import (
 
 	"strings"
 
-	"text-wow/internal/database"
-
 	"text-wow/internal/models"
 
 	"text-wow/internal/repository"
@@ -1542,8 +1542,18 @@ func (tr *TestRunner) createCharacter(instruction string) error {
 
 		if gold, ok := goldVal.(int); ok {
 
-			// 直接更新数据库中的用户金币
-			_, err := database.DB.Exec(`UPDATE users SET gold = ? WHERE id = ?`, gold, char.UserID)
+			// 按差额写入金币流水，使用户金币等于设定值
+			_, err := repository.WithTransactionResult(func(tx *sql.Tx) (*models.GoldLedgerEntry, error) {
+				var current int
+				if err := tx.QueryRow(`SELECT gold FROM users WHERE id = ?`, char.UserID).Scan(&current); err != nil {
+					return nil, err
+				}
+				return repository.NewGoldLedgerRepository().ApplyTx(tx, repository.GoldChange{
+					UserID: char.UserID,
+					Delta:  gold - current,
+					Reason: repository.GoldReasonTestRunner,
+				})
+			})
 
 			if err != nil {
 
@@ -582,11 +582,17 @@ func (tr *TestRunner) executePurchaseItem(instruction string) error {
 	}
 
 	// 扣除金币
-	user.Gold -= price
-	_, err = database.DB.Exec(`UPDATE users SET gold = ? WHERE id = ?`, user.Gold, char.UserID)
+	entry, err := repository.NewGoldLedgerRepository().Apply(repository.GoldChange{
+		UserID: char.UserID,
+		Delta:  -price,
+		Reason: repository.GoldReasonTestRunner,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update user gold: %w", err)
 	}
+	if entry != nil {
+		user.Gold = entry.BalanceAfter
+	}
 
 	// 标记角色拥有该物品
 	itemKey := fmt.Sprintf("character.has_%s", strings.ToLower(strings.ReplaceAll(itemName, " ", "_")))
@@ -633,17 +639,17 @@ func (tr *TestRunner) executeGainGold(instruction string) error {
 		goldStr := strings.TrimSpace(strings.Split(parts[1], "金币")[0])
 		if gold, err := strconv.Atoi(goldStr); err == nil {
 			// 更新用户金币
-			userRepo := repository.NewUserRepository()
-			user, err := userRepo.GetByID(char.UserID)
-			if err == nil && user != nil {
-				user.Gold += gold
-				_, err = database.DB.Exec(`UPDATE users SET gold = ?, total_gold_gained = total_gold_gained + ? WHERE id = ?`,
-					user.Gold, gold, char.UserID)
-				if err != nil {
-					debugPrint("[DEBUG] executeGainGold: failed to update user gold: %v\n", err)
-				}
-				tr.context.Variables["character.gold"] = user.Gold
-				tr.safeSetContext("character.gold", user.Gold)
+			entry, err := repository.NewGoldLedgerRepository().Apply(repository.GoldChange{
+				UserID:        char.UserID,
+				Delta:         gold,
+				Reason:        repository.GoldReasonTestRunner,
+				CountAsGained: true,
+			})
+			if err != nil {
+				debugPrint("[DEBUG] executeGainGold: failed to update user gold: %v\n", err)
+			} else if entry != nil {
+				tr.context.Variables["character.gold"] = entry.BalanceAfter
+				tr.safeSetContext("character.gold", entry.BalanceAfter)
 			}
 		}
 	}
@@ -5,7 +5,6 @@ import (
 	"strconv"
 	"strings"
 
-	"text-wow/internal/database"
 	"text-wow/internal/models"
 	"text-wow/internal/repository"
 )
@@ -362,13 +361,12 @@ func (tr *TestRunner) executeKillMonsterInZone(instruction string) error {
 
 	// 给予经验和金币
 	char.Exp += expGain
-	userRepo := repository.NewUserRepository()
-	user, err := userRepo.GetByID(char.UserID)
-	if err == nil && user != nil {
-		user.Gold += goldGain
-		_, err = database.DB.Exec(`UPDATE users SET gold = ?, total_gold_gained = total_gold_gained + ? WHERE id = ?`, 
-			user.Gold, goldGain, char.UserID)
-	}
+	repository.NewGoldLedgerRepository().Apply(repository.GoldChange{
+		UserID:        char.UserID,
+		Delta:         goldGain,
+		Reason:        repository.GoldReasonTestRunner,
+		CountAsGained: true,
+	})
 
 	// 更新上下文
 	tr.context.Characters["character"] = char
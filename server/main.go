@@ -1,11 +1,17 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"text-wow/internal/api"
+	"text-wow/internal/auth"
 	"text-wow/internal/database"
+	"text-wow/internal/game"
+	"text-wow/internal/models"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -18,8 +24,40 @@ func main() {
 	}
 	defer database.Close()
 
-	// 创建Gin实例
-	r := gin.Default()
+	// 加载JWT签名密钥（JWT_SIGNING_KEY_FILE 或 JWT_SIGNING_KEYS）
+	keyRing, err := auth.LoadKeyRing()
+	switch {
+	case errors.Is(err, auth.ErrNoSigningKeys):
+		// 开发密钥随源码公开，只允许在显式声明的开发环境中使用
+		if !auth.DevelopmentKeyAllowed() {
+			log.Fatalf("❌ No JWT signing keys configured: set %s or %s (for local development set %s=1 or GIN_MODE=debug)",
+				auth.EnvSigningKeyFile, auth.EnvSigningKeys, auth.EnvAllowDevSigningKey)
+		}
+		log.Println("⚠️  No JWT signing keys configured, using the development key (local development only)")
+	case err != nil:
+		log.Fatalf("❌ Failed to load JWT signing keys: %v", err)
+	default:
+		auth.SetKeyRing(keyRing)
+		log.Printf("🔑 JWT signing key loaded (active kid: %s)", keyRing.ActiveKeyID())
+	}
+
+	// 按 ADMIN_USERNAMES 提升管理员（用于创建第一个管理员，之后可通过 /api/admin 管理角色）
+	if usernames := game.ParseAdminUsernames(os.Getenv(game.EnvAdminUsernames)); len(usernames) > 0 {
+		promoted, missing, err := game.GetAdminManager().BootstrapAdmins(usernames)
+		if err != nil {
+			log.Fatalf("❌ Failed to bootstrap administrators: %v", err)
+		}
+		for _, username := range promoted {
+			log.Printf("🛡️  Promoted %s to admin (%s)", username, game.EnvAdminUsernames)
+		}
+		for _, username := range missing {
+			log.Printf("⚠️  %s: user %s is not registered yet, restart after registering", game.EnvAdminUsernames, username)
+		}
+	}
+
+	// 创建Gin实例（访问日志隐藏查询参数中的凭据）
+	r := gin.New()
+	r.Use(api.RequestLogger(), gin.Recovery())
 
 	// CORS 配置
 	r.Use(cors.New(cors.Config{
@@ -34,6 +72,32 @@ func main() {
 	chatHandler := api.NewChatHandler()
 	battleHandler := api.NewBattleHandler()
 	strategyHandler := api.NewStrategyHandlers()
+	codexHandler := api.NewCodexHandler()
+	pvpHandler := api.NewPvPHandler()
+	honorHandler := api.NewHonorHandler()
+	auctionHandler := api.NewAuctionHandler()
+	tradeHandler := api.NewTradeHandler()
+	mailHandler := api.NewMailHandler()
+	economyHandler := api.NewEconomyHandler()
+	vendorHandler := api.NewVendorHandler()
+	consumableHandler := api.NewConsumableHandler()
+	craftingHandler := api.NewCraftingHandler()
+	salvageHandler := api.NewSalvageHandler()
+	realtimeHandler := api.NewRealtimeHandler()
+	moderationHandler := api.NewChatModerationHandler()
+	guildHandler := api.NewGuildHandler()
+	leaderboardHandler := api.NewLeaderboardHandler()
+	announcementHandler := api.NewAnnouncementHandler()
+	adminHandler := api.NewAdminHandler()
+
+	// 后台任务
+	game.GetBattleManager().GetHonorManager().StartWeeklyResetJob(time.Hour)
+	game.GetTradingManager().StartExpiryJob(time.Minute)
+	game.GetMailManager().StartExpiryJob(10 * time.Minute)
+	game.GetVendorManager().StartRestockJob(time.Minute)
+	game.GetCraftingManager().StartQueueJob(5 * time.Second)
+	game.GetLeaderboardManager().StartSnapshotJob(time.Hour)
+	game.GetLoginLimiter().StartPruneJob(time.Hour)
 
 	// API 路由
 	apiGroup := r.Group("/api")
@@ -51,7 +115,10 @@ func main() {
 		auth := apiGroup.Group("/auth")
 		{
 			auth.POST("/register", h.Register)
-			auth.POST("/login", h.Login)
+			auth.POST("/login", api.LoginRateLimit(game.GetLoginLimiter(), "username"), h.Login)
+			auth.POST("/refresh", h.RefreshToken)
+			auth.POST("/logout", h.AuthMiddleware(), h.Logout)
+			auth.POST("/logout-all", h.AuthMiddleware(), h.LogoutAll)
 		}
 
 		// 游戏配置（公开）
@@ -59,6 +126,9 @@ func main() {
 		apiGroup.GET("/classes", h.GetClasses)
 		apiGroup.GET("/zones", h.GetZones)
 
+		// 全服公告（公开）
+		apiGroup.GET("/announcements", announcementHandler.GetAnnouncements)
+
 		// ═══════════════════════════════════════════════════════════
 		// 需要认证的API
 		// ═══════════════════════════════════════════════════════════
@@ -91,12 +161,21 @@ func main() {
 			{
 				chat.GET("/messages", chatHandler.GetMessages)
 				chat.POST("/send", chatHandler.SendMessage)
-				chat.GET("/online", chatHandler.GetOnlineUsers)
+				chat.GET("/online", chatHandler.GetOnlineUsers) // 在线状态由实时推送连接维护（/api/stream）
 				chat.POST("/block", chatHandler.BlockUser)
 				chat.POST("/unblock", chatHandler.UnblockUser)
-				chat.POST("/online", chatHandler.SetOnline)
-				chat.POST("/offline", chatHandler.SetOffline)
-				chat.POST("/heartbeat", chatHandler.Heartbeat)
+				chat.POST("/report", moderationHandler.ReportMessage)
+				chat.GET("/sanctions", moderationHandler.GetMySanctions)
+
+				// 聊天管理（版主/管理员）
+				moderation := chat.Group("/moderation")
+				{
+					moderation.GET("/reports", moderationHandler.GetReports)
+					moderation.POST("/reports/:reportId/resolve", moderationHandler.ResolveReport)
+					moderation.GET("/sanctions", moderationHandler.GetSanctions)
+					moderation.POST("/sanctions", moderationHandler.CreateSanction)
+					moderation.DELETE("/sanctions/:sanctionId", moderationHandler.RevokeSanction)
+				}
 			}
 
 			// 战斗
@@ -108,6 +187,7 @@ func main() {
 				battle.POST("/tick", battleHandler.BattleTick)
 				battle.GET("/status", battleHandler.GetBattleStatus)
 				battle.GET("/logs", battleHandler.GetBattleLogs)
+				battle.GET("/stamina", battleHandler.GetStamina)
 				battle.GET("/zones", battleHandler.GetZonesWithMonsters)
 				battle.GET("/explorations", battleHandler.GetExplorations)
 				battle.POST("/change-zone", battleHandler.ChangeZone)
@@ -135,16 +215,153 @@ func main() {
 				stats.GET("/session/status", h.GetStatsSessionStatus)
 				stats.GET("/cumulative/dps", h.GetCumulativeDPSAnalysis)
 				stats.GET("/battles/:battleId/dps", h.GetBattleDPSAnalysis)
+				stats.GET("/battles/:battleId/threat", h.GetBattleThreatAnalysis)
 			}
 			protected.GET("/characters/:characterId/stats", h.GetCharacterLifetimeStats)
 			protected.GET("/characters/:characterId/stats/summary", h.GetCharacterBattleSummary)
+
+			// 图鉴
+			protected.GET("/codex", codexHandler.GetCodex)
+			protected.GET("/codex/:codexId", codexHandler.GetCodexEntry)
+
+			// 阵营PVP
+			protected.GET("/pvp/zones", pvpHandler.GetZoneControls)
+			protected.GET("/pvp/encounters", pvpHandler.GetEncounters)
+			protected.GET("/pvp/encounters/:encounterId", pvpHandler.GetEncounter)
+			protected.GET("/pvp/honor", pvpHandler.GetHonor)
+			protected.GET("/pvp/standings", honorHandler.GetStandings)
+			protected.GET("/pvp/shop", honorHandler.GetShop)
+			protected.POST("/pvp/shop/:shopItemId/purchase", honorHandler.Purchase)
+			protected.GET("/pvp/shop/purchases", honorHandler.GetPurchases)
+
+			// 拍卖行
+			protected.GET("/auction", auctionHandler.SearchListings)
+			protected.GET("/auction/mine", auctionHandler.GetMyListings)
+			protected.GET("/auction/mine/bids", auctionHandler.GetMyBids)
+			protected.POST("/auction", auctionHandler.CreateListing)
+			protected.POST("/auction/:listingId/buy", auctionHandler.BuyListing)
+			protected.POST("/auction/:listingId/bid", auctionHandler.PlaceBid)
+			protected.GET("/auction/:listingId/bids", auctionHandler.GetListingBids)
+			protected.DELETE("/auction/:listingId", auctionHandler.CancelListing)
+
+			// 玩家直接交易
+			protected.GET("/trades", tradeHandler.GetMyTrades)
+			protected.POST("/trades", tradeHandler.OpenTrade)
+			protected.GET("/trades/:tradeId", tradeHandler.GetTrade)
+			protected.POST("/trades/:tradeId/items", tradeHandler.AddItem)
+			protected.DELETE("/trades/:tradeId/items/:equipmentId", tradeHandler.RemoveItem)
+			protected.PUT("/trades/:tradeId/gold", tradeHandler.SetGold)
+			protected.POST("/trades/:tradeId/confirm", tradeHandler.Confirm)
+			protected.POST("/trades/:tradeId/cancel", tradeHandler.Cancel)
+
+			// 邮箱
+			protected.GET("/mail", mailHandler.GetMailbox)
+			protected.POST("/mail", mailHandler.SendMail)
+			protected.GET("/mail/:mailId", mailHandler.ReadMail)
+			protected.POST("/mail/:mailId/claim", mailHandler.ClaimMail)
+			protected.DELETE("/mail/:mailId", mailHandler.DeleteMail)
+
+			// 经济
+			protected.GET("/economy/ledger", economyHandler.GetLedger)
+
+			// NPC商人
+			protected.GET("/vendors", vendorHandler.GetZoneVendors)
+			protected.GET("/vendors/buyback", vendorHandler.GetBuyback)
+			protected.GET("/vendors/:vendorId", vendorHandler.GetVendor)
+			protected.POST("/vendors/:vendorId/buy", vendorHandler.BuyItem)
+			protected.POST("/vendors/:vendorId/sell", vendorHandler.SellItem)
+			protected.POST("/vendors/:vendorId/buyback/:buybackId", vendorHandler.Buyback)
+
+			// 消耗品
+			protected.GET("/characters/:characterId/consumables", consumableHandler.GetConsumables)
+			protected.POST("/characters/:characterId/use-item", consumableHandler.UseItem)
+
+			// 专业与制造
+			protected.GET("/professions", craftingHandler.GetProfessions)
+			protected.GET("/professions/:professionId/recipes", craftingHandler.GetRecipes)
+			protected.GET("/zones/:zoneId/gathering", craftingHandler.GetZoneGathering)
+			protected.GET("/characters/:characterId/professions", craftingHandler.GetCharacterProfessions)
+			protected.POST("/characters/:characterId/professions/:professionId", craftingHandler.LearnProfession)
+			protected.DELETE("/characters/:characterId/professions/:professionId", craftingHandler.AbandonProfession)
+			protected.GET("/characters/:characterId/crafting", craftingHandler.GetQueue)
+			protected.POST("/characters/:characterId/crafting", craftingHandler.QueueCraft)
+			protected.DELETE("/characters/:characterId/crafting/:queueId", craftingHandler.CancelCraft)
+
+			// 装备分解
+			protected.POST("/equipment/salvage/preview", salvageHandler.PreviewSalvage)
+			protected.POST("/equipment/salvage", salvageHandler.Salvage)
+
+			// 公会
+			protected.GET("/guild", guildHandler.GetGuild)
+			protected.POST("/guild", guildHandler.CreateGuild)
+			protected.DELETE("/guild", guildHandler.DisbandGuild)
+			protected.POST("/guild/leave", guildHandler.LeaveGuild)
+			protected.GET("/guild/invites", guildHandler.GetInvites)
+			protected.POST("/guild/invites", guildHandler.Invite)
+			protected.POST("/guild/invites/:guildId/accept", guildHandler.AcceptInvite)
+			protected.DELETE("/guild/invites/:guildId", guildHandler.DeclineInvite)
+			protected.DELETE("/guild/members/:playerName", guildHandler.KickMember)
+			protected.PUT("/guild/members/:playerName/rank", guildHandler.SetMemberRank)
+			protected.POST("/guild/leader", guildHandler.TransferLeadership)
+			protected.PUT("/guild/ranks/:rankIndex", guildHandler.UpdateRank)
+			protected.PUT("/guild/motd", guildHandler.SetMOTD)
+			protected.GET("/guild/log", guildHandler.GetLog)
+			protected.GET("/guild/bank", guildHandler.GetBank)
+			protected.POST("/guild/bank/gold", guildHandler.DepositGold)
+			protected.POST("/guild/bank/gold/withdraw", guildHandler.WithdrawGold)
+			protected.POST("/guild/bank/items", guildHandler.DepositItem)
+			protected.POST("/guild/bank/items/:equipmentId/withdraw", guildHandler.WithdrawItem)
+
+			// 排行榜
+			protected.GET("/leaderboards", leaderboardHandler.GetBoards)
+			protected.GET("/leaderboards/:board", leaderboardHandler.GetLeaderboard)
+			protected.GET("/leaderboards/:board/me", leaderboardHandler.GetMyPosition)
+		}
+
+		// ═══════════════════════════════════════════════════════════
+		// GM管理（版主及以上，具体操作按角色再校验）
+		// ═══════════════════════════════════════════════════════════
+
+		admin := apiGroup.Group("/admin")
+		admin.Use(h.AuthMiddleware(), h.RequireRole(models.RoleModerator))
+		{
+			admin.GET("/users", adminHandler.FindUser)
+			admin.GET("/users/:id", adminHandler.GetUser)
+			admin.POST("/users/:id/ban", adminHandler.Ban)
+			admin.DELETE("/users/:id/ban", adminHandler.Unban)
+			admin.POST("/users/:id/gold", adminHandler.GrantGold)
+			admin.POST("/users/:id/items", adminHandler.GrantItem)
+			admin.POST("/users/:id/teleport", adminHandler.Teleport)
+			admin.POST("/users/:id/session/reset", adminHandler.ResetSession)
+			admin.POST("/users/:id/battle/stop", adminHandler.StopBattle)
+			admin.PUT("/users/:id/role", adminHandler.SetRole)
+			admin.POST("/config/reload", adminHandler.ReloadConfig)
+			admin.GET("/audit", adminHandler.GetAuditLog)
+			admin.GET("/login-attempts", adminHandler.GetLoginAttempts)
+			admin.POST("/leaderboards/seasons", adminHandler.StartLeaderboardSeason)
+			admin.POST("/announcements", adminHandler.PostAnnouncement)
+		}
+
+		// ═══════════════════════════════════════════════════════════
+		// 实时推送（浏览器先用访问令牌换取一次性票据，再通过 ticket 查询参数连接）
+		// ═══════════════════════════════════════════════════════════
+
+		stream := apiGroup.Group("/stream")
+		stream.POST("/ticket", h.AuthMiddleware(), h.IssueStreamTicket)
+		stream.Use(h.StreamAuthMiddleware())
+		{
+			stream.GET("/ws", realtimeHandler.WebSocket)
+			stream.GET("/events", realtimeHandler.Events)
 		}
 	}
 
 	log.Println("🎮 Text WoW Server starting on :8080")
 	log.Println("📌 API Documentation:")
 	log.Println("   POST /api/auth/register    - 用户注册")
-	log.Println("   POST /api/auth/login       - 用户登录")
+	log.Println("   POST /api/auth/login       - 用户登录 (按IP/用户名限流，失败过多时临时锁定)")
+	log.Println("   POST /api/auth/refresh     - 刷新访问令牌")
+	log.Println("   POST /api/auth/logout      - 注销当前会话 (需认证)")
+	log.Println("   POST /api/auth/logout-all  - 注销所有设备 (需认证)")
 	log.Println("   GET  /api/races            - 获取种族列表")
 	log.Println("   GET  /api/classes          - 获取职业列表")
 	log.Println("   GET  /api/characters       - 获取角色列表 (需认证)")
@@ -156,12 +373,87 @@ func main() {
 	log.Println("   POST /api/battle/tick      - 战斗回合 (需认证)")
 	log.Println("   GET  /api/battle/status    - 战斗状态 (需认证)")
 		log.Println("   GET  /api/battle/logs      - 战斗日志 (需认证)")
+		log.Println("   GET  /api/battle/stamina   - 体力状态 (需认证)")
 		log.Println("   GET  /api/battle/zones     - 获取地图列表 (需认证)")
 		log.Println("   POST /api/battle/change-zone - 切换区域 (需认证)")
 	log.Println("   GET  /api/characters/:id/strategies - 获取策略列表 (需认证)")
 	log.Println("   POST /api/characters/:id/strategies - 创建策略 (需认证)")
 	log.Println("   PUT  /api/strategies/:id   - 更新策略 (需认证)")
 	log.Println("   DELETE /api/strategies/:id - 删除策略 (需认证)")
+	log.Println("   GET  /api/codex            - 图鉴列表 (需认证)")
+	log.Println("   GET  /api/pvp/zones        - 争夺区域控制状态 (需认证)")
+	log.Println("   GET  /api/pvp/encounters   - PVP遭遇战记录 (需认证)")
+	log.Println("   GET  /api/pvp/honor        - 荣誉战绩 (需认证)")
+	log.Println("   GET  /api/pvp/standings    - 军衔排名 (需认证)")
+	log.Println("   GET  /api/pvp/shop         - 荣誉商店 (需认证)")
+	log.Println("   POST /api/pvp/shop/:id/purchase - 荣誉商店购买 (需认证)")
+	log.Println("   GET  /api/pvp/shop/purchases - 荣誉商店购买记录 (需认证)")
+	log.Println("   GET  /api/auction          - 搜索拍卖行 (需认证)")
+	log.Println("   POST /api/auction          - 上架装备/发起拍卖 (需认证)")
+	log.Println("   POST /api/auction/:id/buy  - 一口价购买 (需认证)")
+	log.Println("   POST /api/auction/:id/bid  - 竞价出价 (需认证)")
+	log.Println("   DELETE /api/auction/:id    - 取消上架 (需认证)")
+	log.Println("   POST /api/trades           - 发起玩家交易 (需认证)")
+	log.Println("   POST /api/trades/:id/items - 放入交易装备 (需认证)")
+	log.Println("   PUT  /api/trades/:id/gold  - 设置交易金币 (需认证)")
+	log.Println("   POST /api/trades/:id/confirm - 确认交易 (需认证)")
+	log.Println("   GET  /api/mail             - 获取收件箱 (需认证)")
+	log.Println("   POST /api/mail             - 发送邮件 (需认证)")
+	log.Println("   POST /api/mail/:id/claim   - 领取邮件附件 (需认证)")
+	log.Println("   DELETE /api/mail/:id       - 删除邮件 (需认证)")
+	log.Println("   GET  /api/economy/ledger   - 金币流水 (需认证)")
+	log.Println("   GET  /api/vendors          - 当前区域商人 (需认证)")
+	log.Println("   POST /api/vendors/:id/buy  - 向商人购买 (需认证)")
+	log.Println("   POST /api/vendors/:id/sell - 出售物品/装备 (需认证)")
+	log.Println("   GET  /api/vendors/buyback  - 回购栏 (需认证)")
+	log.Println("   GET  /api/characters/:id/consumables - 角色消耗品 (需认证)")
+	log.Println("   POST /api/characters/:id/use-item - 使用消耗品 (需认证)")
+	log.Println("   GET  /api/professions      - 专业列表 (需认证)")
+	log.Println("   GET  /api/professions/:id/recipes - 专业配方 (需认证)")
+	log.Println("   GET  /api/zones/:id/gathering - 区域采集表 (需认证)")
+	log.Println("   POST /api/characters/:id/professions/:professionId - 学习专业 (需认证)")
+	log.Println("   GET  /api/characters/:id/crafting - 制造队列 (需认证)")
+	log.Println("   POST /api/characters/:id/crafting - 加入制造队列 (需认证)")
+	log.Println("   DELETE /api/characters/:id/crafting/:queueId - 取消制造 (需认证)")
+	log.Println("   POST /api/equipment/salvage/preview - 预览分解产出 (需认证)")
+	log.Println("   POST /api/equipment/salvage - 分解装备 (需认证)")
+	log.Println("   POST /api/chat/report      - 举报聊天消息 (需认证)")
+	log.Println("   GET  /api/chat/sanctions   - 自己的禁言/封禁状态 (需认证)")
+	log.Println("   GET  /api/chat/moderation/reports - 举报列表 (版主)")
+	log.Println("   POST /api/chat/moderation/sanctions - 禁言/封禁玩家 (版主)")
+	log.Println("   DELETE /api/chat/moderation/sanctions/:id - 解除禁言/封禁 (版主)")
+	log.Println("   GET  /api/guild            - 公会详情 (需认证)")
+	log.Println("   POST /api/guild            - 创建公会 (需认证)")
+	log.Println("   DELETE /api/guild          - 解散公会 (需认证)")
+	log.Println("   POST /api/guild/invites    - 邀请玩家加入公会 (需认证)")
+	log.Println("   POST /api/guild/invites/:id/accept - 接受公会邀请 (需认证)")
+	log.Println("   PUT  /api/guild/members/:name/rank - 调整成员会阶 (需认证)")
+	log.Println("   PUT  /api/guild/ranks/:index - 修改会阶权限 (需认证)")
+	log.Println("   PUT  /api/guild/motd       - 修改每日公告 (需认证)")
+	log.Println("   GET  /api/guild/bank       - 公会银行 (需认证)")
+	log.Println("   GET  /api/guild/log        - 公会活动日志 (需认证)")
+	log.Println("   GET  /api/leaderboards     - 排行榜与赛季列表 (需认证)")
+	log.Println("   GET  /api/leaderboards/:board - 排行榜排名 (需认证)")
+	log.Println("   GET  /api/leaderboards/:board/me - 自己的排名及相邻玩家 (需认证)")
+	log.Println("   GET  /api/announcements    - 全服公告")
+	log.Println("   GET  /api/admin/users?name= - 按用户名查找玩家 (版主)")
+	log.Println("   GET  /api/admin/users/:id  - 查看玩家状态 (版主)")
+	log.Println("   POST /api/admin/users/:id/ban - 封禁账号 (版主)")
+	log.Println("   DELETE /api/admin/users/:id/ban - 解除封禁 (版主)")
+	log.Println("   POST /api/admin/users/:id/gold - 发放/扣除金币 (GM)")
+	log.Println("   POST /api/admin/users/:id/items - 发放物品 (GM)")
+	log.Println("   POST /api/admin/users/:id/teleport - 传送玩家 (GM)")
+	log.Println("   POST /api/admin/users/:id/session/reset - 重置战斗会话 (GM)")
+	log.Println("   POST /api/admin/users/:id/battle/stop - 停止/强制结束战斗 (GM)")
+	log.Println("   PUT  /api/admin/users/:id/role - 设置玩家角色 (管理员)")
+	log.Println("   POST /api/admin/config/reload - 热重载配置 (管理员)")
+	log.Println("   GET  /api/admin/audit      - GM操作审计日志 (管理员)")
+	log.Println("   GET  /api/admin/login-attempts - 登录尝试审计日志 (管理员)")
+	log.Println("   POST /api/admin/leaderboards/seasons - 开启排行榜新赛季 (管理员)")
+	log.Println("   POST /api/admin/announcements - 发布全服公告 (管理员)")
+	log.Println("   POST /api/stream/ticket    - 签发实时推送连接票据 (需认证, 30秒内一次有效)")
+	log.Println("   GET  /api/stream/ws        - 实时推送 WebSocket (?ticket= 票据或 Authorization)")
+	log.Println("   GET  /api/stream/events    - 实时推送 SSE (?ticket= 票据或 Authorization)")
 
 	if err := r.Run(":8080"); err != nil {
 		log.Fatalf("❌ Failed to start server: %v", err)
@@ -35,7 +35,7 @@ if "%ERRORLEVEL%"=="0" (
 echo.
 echo [1/2] 启动后端服务器 (端口 8080)...
 cd /d %~dp0server
-start "Text WoW Server" cmd /k "title Text WoW Server && go run main.go"
+start "Text WoW Server" cmd /k "title Text WoW Server && set ALLOW_DEV_JWT_KEY=1&& go run main.go"
 timeout /t 2 /nobreak >NUL
 
 echo [2/2] 启动前端开发服务器 (端口 5173)...
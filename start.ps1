@@ -47,7 +47,7 @@ $clientPath = Join-Path $scriptPath "client"
 
 Write-Host "[1/2] Starting backend server (port 8080)..." -ForegroundColor Green
 $serverScript = Join-Path $env:TEMP "start-server.ps1"
-$serverContent = "Set-Location '$serverPath'`nWrite-Host 'Text WoW Server - Backend' -ForegroundColor Cyan`nWrite-Host 'Port: 8080' -ForegroundColor Gray`nWrite-Host ''`ngo run main.go`n"
+$serverContent = "Set-Location '$serverPath'`nWrite-Host 'Text WoW Server - Backend' -ForegroundColor Cyan`nWrite-Host 'Port: 8080' -ForegroundColor Gray`nWrite-Host ''`n`$env:ALLOW_DEV_JWT_KEY = '1'`ngo run main.go`n"
 $serverContent | Out-File -FilePath $serverScript -Encoding UTF8 -NoNewline
 Start-Process powershell -ArgumentList "-NoExit", "-File", $serverScript -WindowStyle Normal
 